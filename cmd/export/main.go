@@ -0,0 +1,101 @@
+// Command export writes the book shop catalogue to a CSV, JSON Lines or XLSX file, like
+// GET /export/books does. Usage:
+//
+//	export [-format csv|jsonl|xlsx] [-o FILE] [-category ID,...] [-in-stock] [-include-archived]
+//
+// The export goes to standard output unless -o is set. The format is taken from the
+// extension of the output file when -format is not set, and defaults to CSV.
+package main
+
+import (
+	"bufio"
+	"context"
+	"flag"
+	"fmt"
+	"io"
+	"log"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"toptal/internal/app/config"
+	"toptal/internal/app/domain"
+	"toptal/internal/app/repository"
+	"toptal/internal/app/service"
+	"toptal/internal/pkg/pg"
+
+	_ "github.com/lib/pq"
+)
+
+func main() {
+	if err := run(); err != nil {
+		log.Fatal(err)
+	}
+}
+
+func run() error {
+	format := flag.String("format", "", "file format, csv, jsonl or xlsx; taken from the output file when empty")
+	output := flag.String("o", "-", "file to write, - for standard output")
+	categories := flag.String("category", "", "comma separated IDs of the categories to export")
+	inStock := flag.Bool("in-stock", false, "only export books in stock")
+	includeArchived := flag.Bool("include-archived", false, "also export archived books")
+	flag.Parse()
+
+	filter := domain.BookExportFilter{InStockOnly: *inStock, IncludeArchived: *includeArchived}
+	if *categories != "" {
+		for _, v := range strings.Split(*categories, ",") {
+			id, err := strconv.Atoi(strings.TrimSpace(v))
+			if err != nil {
+				return fmt.Errorf("invalid category ID %q", v)
+			}
+			filter.CategoryIds = append(filter.CategoryIds, id)
+		}
+	}
+	if *format == "" {
+		*format = strings.TrimPrefix(strings.ToLower(filepath.Ext(*output)), ".")
+		if *format == "" {
+			*format = domain.ExportFormatCSV
+		}
+	}
+	switch *format {
+	case domain.ExportFormatCSV, domain.ExportFormatJSONL, domain.ExportFormatXLSX:
+	default:
+		return fmt.Errorf("unknown format %q, use csv, jsonl or xlsx", *format)
+	}
+
+	slog.SetDefault(slog.New(slog.NewTextHandler(os.Stderr, nil)))
+	cfg, err := config.LoadConfig()
+	if err != nil {
+		return fmt.Errorf("failed to load config: %w", err)
+	}
+	db, err := pg.Connect(cfg.DB)
+	if err != nil {
+		return fmt.Errorf("failed to connect to database: %w", err)
+	}
+	defer func(db *pg.DB) {
+		err := db.Close()
+		if err != nil {
+			slog.Error("failed to close database connection", "error", err)
+		}
+	}(db)
+
+	var file io.WriteCloser = os.Stdout
+	if *output != "-" {
+		if file, err = os.Create(*output); err != nil {
+			return err
+		}
+	}
+	out := bufio.NewWriter(file)
+
+	exportService := service.NewExportService(repository.NewBookRepository(db))
+	if err := exportService.ExportBooks(context.Background(), out, *format, filter); err != nil {
+		_ = file.Close()
+		return err
+	}
+	if err := out.Flush(); err != nil {
+		_ = file.Close()
+		return err
+	}
+	return file.Close()
+}
@@ -0,0 +1,110 @@
+// Command import loads a CSV, JSON Lines or ONIX 3.0 catalogue into the book shop
+// database, like POST /book/import does. Usage:
+//
+//	import [-format csv|jsonl|onix] [-dry-run] FILE
+//
+// FILE may be "-" to read standard input. The format is taken from the file extension when
+// -format is not set. The command exits with status 1 when the file cannot be imported or
+// any of its rows failed.
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"io"
+	"log"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"strings"
+	"toptal/internal/app/config"
+	"toptal/internal/app/domain"
+	"toptal/internal/app/repository"
+	"toptal/internal/app/service"
+	"toptal/internal/pkg/pg"
+
+	_ "github.com/lib/pq"
+)
+
+func main() {
+	failed, err := run()
+	if err != nil {
+		log.Fatal(err)
+	}
+	if failed {
+		os.Exit(1)
+	}
+}
+
+func run() (bool, error) {
+	format := flag.String("format", "", "file format, csv, jsonl or onix; taken from the file extension when empty")
+	dryRun := flag.Bool("dry-run", false, "report what the import would do without changing anything")
+	flag.Usage = func() {
+		fmt.Fprintf(flag.CommandLine.Output(), "Usage: %s [-format csv|jsonl|onix] [-dry-run] FILE\n", os.Args[0])
+		flag.PrintDefaults()
+	}
+	flag.Parse()
+	if flag.NArg() != 1 {
+		flag.Usage()
+		os.Exit(2)
+	}
+	path := flag.Arg(0)
+	if *format == "" {
+		*format = strings.TrimPrefix(strings.ToLower(filepath.Ext(path)), ".")
+		switch *format {
+		case "ndjson":
+			*format = domain.ImportFormatJSONL
+		case "xml":
+			*format = domain.ImportFormatONIX
+		}
+	}
+
+	slog.SetDefault(slog.New(slog.NewTextHandler(os.Stderr, nil)))
+	cfg, err := config.LoadConfig()
+	if err != nil {
+		return false, fmt.Errorf("failed to load config: %w", err)
+	}
+
+	var file io.Reader = os.Stdin
+	if path != "-" {
+		f, err := os.Open(path)
+		if err != nil {
+			return false, err
+		}
+		defer f.Close()
+		file = f
+	}
+
+	db, err := pg.Connect(cfg.DB)
+	if err != nil {
+		return false, fmt.Errorf("failed to connect to database: %w", err)
+	}
+	defer func(db *pg.DB) {
+		err := db.Close()
+		if err != nil {
+			slog.Error("failed to close database connection", "error", err)
+		}
+	}(db)
+
+	importService, err := service.NewImportService(
+		repository.NewImportRepository(db), repository.NewCategoryRepository(db), &cfg.ONIX,
+	)
+	if err != nil {
+		return false, fmt.Errorf("failed to load ONIX mapping: %w", err)
+	}
+	report, err := importService.ImportBooks(context.Background(), file, *format, *dryRun)
+	if err != nil {
+		return false, err
+	}
+
+	if report.DryRun() {
+		fmt.Println("Dry run, nothing was changed.")
+	}
+	fmt.Printf("%d rows: %d created, %d updated, %d unchanged, %d archived, %d failed\n",
+		report.Rows(), report.Created(), report.Updated(), report.Unchanged(), report.Archived(), len(report.Errors()))
+	for _, rowErr := range report.Errors() {
+		fmt.Printf("line %d: %s\n", rowErr.Line(), rowErr.Message())
+	}
+	return len(report.Errors()) > 0, nil
+}
@@ -25,6 +25,7 @@ import (
 	"os"
 	"os/signal"
 	"syscall"
+	"time"
 	_ "toptal/docs"
 	"toptal/internal/app/auth"
 	"toptal/internal/app/config"
@@ -33,7 +34,12 @@ import (
 	"toptal/internal/app/health"
 	"toptal/internal/app/repository"
 	"toptal/internal/app/service"
+	"toptal/internal/pkg/blob"
+	"toptal/internal/pkg/mailer"
+	"toptal/internal/pkg/oidc"
+	"toptal/internal/pkg/password"
 	"toptal/internal/pkg/pg"
+	"toptal/internal/pkg/webhook"
 
 	"github.com/golang-migrate/migrate/v4"
 	_ "github.com/golang-migrate/migrate/v4/database/postgres"
@@ -57,7 +63,9 @@ func run() error {
 	if err != nil {
 		return fmt.Errorf("failed to load config: %w", err)
 	}
-	auth.SetConfig(cfg.Security)
+	if err := auth.SetConfig(cfg.Security); err != nil {
+		return fmt.Errorf("failed to load JWT keys: %w", err)
+	}
 
 	db, err := pg.Connect(cfg.DB)
 	if err != nil {
@@ -79,16 +87,95 @@ func run() error {
 	categoryRepository := repository.NewCategoryRepository(db)
 	userRepository := repository.NewUserRepository(db)
 	cartRepository := repository.NewCartRepository(db, &cfg.Cart)
+	apiKeyRepository := repository.NewAPIKeyRepository(db)
+	userTokenRepository := repository.NewUserTokenRepository(db)
+	outboxRepository := repository.NewOutboxRepository(db)
+	orderRepository := repository.NewOrderRepository(db)
+	oidcRepository := repository.NewOIDCRepository(db)
+	sessionRepository := repository.NewSessionRepository(db)
+	auditRepository := repository.NewAuditRepository(db)
+	priceRepository := repository.NewPriceRepository(db)
+	importRepository := repository.NewImportRepository(db)
+	formatRepository := repository.NewFormatRepository(db)
+	downloadRepository := repository.NewDownloadRepository(db)
+	coverRepository := repository.NewCoverRepository(db)
+	recommendationRepository := repository.NewRecommendationRepository(db)
+	chartRepository := repository.NewChartRepository(db)
+	reportRepository := repository.NewReportRepository(db, cfg.Reports.StatementTimeout)
+	stockAlertRepository := repository.NewStockAlertRepository(db)
+	supplierRepository := repository.NewSupplierRepository(db)
+	purchaseOrderRepository := repository.NewPurchaseOrderRepository(db)
+
+	mail, err := newMailer(cfg.Mail)
+	if err != nil {
+		return fmt.Errorf("failed to create mailer: %w", err)
+	}
+
+	blobStore, err := newBlobStore(cfg.Blob)
+	if err != nil {
+		return fmt.Errorf("failed to create blob store: %w", err)
+	}
+
+	passwordHasher, err := password.NewHasher(password.Params{
+		Algorithm:         cfg.Security.PasswordHashAlgorithm,
+		BcryptCost:        cfg.Security.BcryptCost,
+		Argon2Memory:      uint32(cfg.Security.Argon2Memory),
+		Argon2Iterations:  uint32(cfg.Security.Argon2Iterations),
+		Argon2Parallelism: uint8(cfg.Security.Argon2Parallelism),
+	})
+	if err != nil {
+		return fmt.Errorf("failed to create password hasher: %w", err)
+	}
 
 	// service
-	authService := service.NewAuthService(userRepository)
-	bookService := service.NewBookService(bookRepository, *authService)
+	sessionService := service.NewSessionService(sessionRepository)
+	auditService := service.NewAuditService(auditRepository)
+	authService := service.NewAuthService(userRepository, passwordHasher, sessionService, &cfg.Security)
+	bookService := service.NewBookService(bookRepository, priceRepository, *authService)
 	categoryService := service.NewCategoryService(categoryRepository, *authService)
-	cartService := service.NewCartService(cartRepository, &cfg.Cart)
+	taxService, err := service.NewTaxService(&cfg.Tax)
+	if err != nil {
+		return fmt.Errorf("failed to load tax rates: %w", err)
+	}
+	cartService := service.NewCartService(cartRepository, priceRepository, taxService, &cfg.Cart)
+	archiveService := service.NewArchiveService(bookRepository, categoryRepository, blobStore, &cfg.Catalog)
+	priceService := service.NewPriceService(priceRepository, &cfg.Catalog)
+	importService, err := service.NewImportService(importRepository, categoryRepository, &cfg.ONIX)
+	if err != nil {
+		return fmt.Errorf("failed to load ONIX mapping: %w", err)
+	}
+	exportService := service.NewExportService(bookRepository)
+	formatService := service.NewFormatService(formatRepository, blobStore, &cfg.Download)
+	downloadService := service.NewDownloadService(downloadRepository, blobStore, &cfg.Download, cfg.Mail.BaseURL)
+	coverService := service.NewCoverService(coverRepository, blobStore, &cfg.Cover)
+	recommendationService := service.NewRecommendationService(
+		recommendationRepository, bookRepository, priceRepository, &cfg.Recommend,
+	)
+	chartService := service.NewChartService(chartRepository, priceRepository, &cfg.Charts)
+	reportService := service.NewReportService(reportRepository)
+	stockNotifier, err := newStockNotifier(cfg.Stock, outboxRepository)
+	if err != nil {
+		return fmt.Errorf("failed to create stock notifier: %w", err)
+	}
+	stockAlertService := service.NewStockAlertService(stockAlertRepository, stockNotifier, &cfg.Stock)
+	supplierService := service.NewSupplierService(supplierRepository)
+	purchaseOrderService := service.NewPurchaseOrderService(purchaseOrderRepository, supplierRepository)
 	healthService := health.NewHealthService(db)
+	apiKeyService := service.NewAPIKeyService(apiKeyRepository, &cfg.Security)
+	accountService := service.NewAccountService(
+		userRepository, userTokenRepository, orderRepository, cartRepository, passwordHasher, sessionService,
+		&cfg.Security, &cfg.Mail,
+	)
+	outboxService := service.NewOutboxService(outboxRepository, mail, &cfg.Mail)
+	oidcService := service.NewOIDCService(newOIDCProviders(cfg.OIDC), oidcRepository, userRepository, sessionService, &cfg.OIDC)
 
 	// server
-	server := handler.NewServer(bookService, categoryService, authService, cartService, healthService)
+	server := handler.NewServer(
+		bookService, categoryService, authService, cartService, healthService, apiKeyService, accountService,
+		oidcService, sessionService, auditService, priceService, importService, exportService, formatService,
+		downloadService, coverService, recommendationService, chartService, reportService, stockAlertService,
+		supplierService, purchaseOrderService,
+	)
 
 	ctx, cancel := context.WithCancel(context.Background())
 	defer cancel()
@@ -100,9 +187,10 @@ func run() error {
 		cancel()
 	}()
 
+	routes := middleware.ClientInfoMiddleware(cfg.Server.TrustProxyHeaders, server.Handler())
 	httpServer := &http.Server{
 		Addr:         fmt.Sprintf(":%s", cfg.Server.Port),
-		Handler:      middleware.MetricsMiddleware(server.Handler()),
+		Handler:      middleware.MetricsMiddleware(middleware.RequestIDMiddleware(routes)),
 		ReadTimeout:  cfg.Server.ReadTimeout,
 		WriteTimeout: cfg.Server.WriteTimeout,
 	}
@@ -122,6 +210,12 @@ func run() error {
 	}
 
 	cartService.StartCartCleanerJob(ctx)
+	outboxService.StartOutboxDispatcherJob(ctx)
+	archiveService.StartArchivePurgeJob(ctx)
+	priceService.StartPriceSchedulerJob(ctx)
+	recommendationService.StartRecommendationRefreshJob(ctx)
+	chartService.StartChartRefreshJob(ctx)
+	stockAlertService.StartStockAlertJob(ctx)
 
 	go func() {
 		if err := httpServer.ListenAndServe(); err != nil && !errors.Is(err, http.ErrServerClosed) {
@@ -164,3 +258,58 @@ func runMigrations(psqlInfo string) error {
 	slog.Info("Migrations applied successfully")
 	return nil
 }
+
+func newOIDCProviders(cfg config.OIDCConfig) map[string]service.OIDCProvider {
+	httpClient := &http.Client{Timeout: 10 * time.Second}
+	providers := make(map[string]service.OIDCProvider, len(cfg.Providers))
+	for _, provider := range cfg.Providers {
+		providers[provider.Name] = oidc.NewClient(oidc.Config{
+			Issuer:       provider.Issuer,
+			ClientID:     provider.ClientID,
+			ClientSecret: provider.ClientSecret,
+			RedirectURL:  provider.RedirectURL,
+			Scopes:       provider.Scopes,
+		}, httpClient)
+		slog.Info("OIDC provider configured", "provider", provider.Name, "issuer", provider.Issuer)
+	}
+	return providers
+}
+
+func newBlobStore(cfg config.BlobConfig) (blob.Store, error) {
+	switch cfg.Driver {
+	case "file":
+		return blob.NewFileStore(cfg.Dir)
+	default:
+		return nil, fmt.Errorf("unknown blob driver %q", cfg.Driver)
+	}
+}
+
+// newStockNotifier sends stock alerts to every configured notifier.
+func newStockNotifier(cfg config.StockConfig, outbox service.EmailQueue) (service.StockNotifier, error) {
+	var notifiers service.StockNotifiers
+	for _, driver := range cfg.Notifiers {
+		switch driver {
+		case "log":
+			notifiers = append(notifiers, service.NewLogStockNotifier())
+		case "webhook":
+			client := webhook.NewClient(cfg.WebhookURL, cfg.WebhookSecret, &http.Client{Timeout: 10 * time.Second})
+			notifiers = append(notifiers, service.NewWebhookStockNotifier(client))
+		case "email":
+			notifiers = append(notifiers, service.NewEmailStockNotifier(outbox, cfg.AlertEmails))
+		default:
+			return nil, fmt.Errorf("unknown stock notifier %q", driver)
+		}
+	}
+	return notifiers, nil
+}
+
+func newMailer(cfg config.MailConfig) (mailer.Mailer, error) {
+	switch cfg.Driver {
+	case "smtp":
+		return mailer.NewSMTPMailer(cfg.SMTPHost, cfg.SMTPPort, cfg.SMTPUsername, cfg.SMTPPassword, cfg.From), nil
+	case "file":
+		return mailer.NewFileMailer(cfg.FileDir, cfg.From)
+	default:
+		return nil, fmt.Errorf("unknown mail driver %q", cfg.Driver)
+	}
+}
@@ -0,0 +1,49 @@
+package auth
+
+import (
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/base64"
+	"encoding/hex"
+	"fmt"
+	"strings"
+)
+
+const apiKeyPrefix = "bsk"
+
+// GenerateAPIKey returns a new raw API key of the form bsk_<prefix>_<secret>
+// together with its lookup prefix and the hash that is stored instead of the key.
+func GenerateAPIKey() (rawKey string, prefix string, keyHash string, err error) {
+	prefixBytes := make([]byte, 6)
+	if _, err := rand.Read(prefixBytes); err != nil {
+		return "", "", "", fmt.Errorf("failed to generate api key prefix: %w", err)
+	}
+	secretBytes := make([]byte, 32)
+	if _, err := rand.Read(secretBytes); err != nil {
+		return "", "", "", fmt.Errorf("failed to generate api key secret: %w", err)
+	}
+
+	prefix = hex.EncodeToString(prefixBytes)
+	rawKey = fmt.Sprintf("%s_%s_%s", apiKeyPrefix, prefix, base64.RawURLEncoding.EncodeToString(secretBytes))
+	return rawKey, prefix, HashAPIKey(rawKey), nil
+}
+
+// ParseAPIKeyPrefix extracts the lookup prefix from a raw API key.
+func ParseAPIKeyPrefix(rawKey string) (string, bool) {
+	parts := strings.SplitN(rawKey, "_", 3)
+	if len(parts) != 3 || parts[0] != apiKeyPrefix || parts[1] == "" || parts[2] == "" {
+		return "", false
+	}
+	return parts[1], true
+}
+
+func HashAPIKey(rawKey string) string {
+	sum := sha256.Sum256([]byte(rawKey))
+	return hex.EncodeToString(sum[:])
+}
+
+// CompareAPIKey checks a raw key against a stored hash in constant time.
+func CompareAPIKey(rawKey string, keyHash string) bool {
+	return subtle.ConstantTimeCompare([]byte(HashAPIKey(rawKey)), []byte(keyHash)) == 1
+}
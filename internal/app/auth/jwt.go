@@ -2,6 +2,9 @@ package auth
 
 import (
 	"errors"
+	"fmt"
+	"slices"
+	"sort"
 	"time"
 	"toptal/internal/app/config"
 	"toptal/internal/app/domain"
@@ -9,39 +12,173 @@ import (
 	"github.com/golang-jwt/jwt/v5"
 )
 
-var jwtConfig config.SecurityConfig
+var (
+	jwtConfig   config.SecurityConfig
+	signingKeys map[string]SigningKey
+	activeKey   *SigningKey
+)
+
+// SetConfig loads the configured signing keys. When no key files are configured tokens
+// are signed with the shared HS256 secret; otherwise the key named by JWTSigningKeyID
+// signs new tokens and every loaded key is accepted for verification, so keys can be
+// rotated by deploying the new key first and switching the signer afterwards.
+func SetConfig(cfg config.SecurityConfig) error {
+	keys := make(map[string]SigningKey, len(cfg.JWTKeyFiles))
+	for _, path := range cfg.JWTKeyFiles {
+		key, err := LoadSigningKey(path)
+		if err != nil {
+			return err
+		}
+		if _, exists := keys[key.ID]; exists {
+			return fmt.Errorf("duplicate JWT key id %q", key.ID)
+		}
+		keys[key.ID] = key
+	}
+
+	var active *SigningKey
+	if len(keys) > 0 {
+		kid := cfg.JWTSigningKeyID
+		if kid == "" && len(keys) == 1 {
+			for id := range keys {
+				kid = id
+			}
+		}
+		key, ok := keys[kid]
+		if !ok {
+			return fmt.Errorf("JWT signing key %q is not among the loaded keys", kid)
+		}
+		if key.Private == nil {
+			return fmt.Errorf("JWT signing key %q has no private key", kid)
+		}
+		active = &key
+	}
 
-func SetConfig(cfg config.SecurityConfig) {
 	jwtConfig = cfg
+	signingKeys = keys
+	activeKey = active
+	return nil
 }
 
+const (
+	purposeTwoFactor    = "2fa"
+	challengeExpiration = 5 * time.Minute
+)
+
 type Claims struct {
 	UserID int `json:"user_id"`
+	// SessionID names the session an access token belongs to. Revoking the session
+	// invalidates the token before it expires.
+	SessionID int `json:"sid,omitempty"`
+	// ChallengeID names the stored challenge a two-factor challenge token answers to.
+	ChallengeID int `json:"cid,omitempty"`
+	// Purpose is empty for access tokens and names the flow for single-purpose tokens.
+	Purpose string `json:"purpose,omitempty"`
 	jwt.RegisteredClaims
 }
 
-func GenerateToken(user *domain.User) (string, error) {
-	expirationTime := time.Now().Add(time.Duration(jwtConfig.JWTExpirationHours) * time.Hour)
+// TokenExpiration returns the expiry of an access token issued now.
+func TokenExpiration() time.Time {
+	return time.Now().Add(time.Duration(jwtConfig.JWTExpirationHours) * time.Hour)
+}
+
+// ChallengeExpiration returns the expiry of a two-factor challenge issued now.
+func ChallengeExpiration() time.Time {
+	return time.Now().Add(challengeExpiration)
+}
+
+// GenerateToken issues an access token for the session. The token expires together with it.
+func GenerateToken(user *domain.User, session *domain.Session) (string, error) {
+	claims := &Claims{
+		UserID:    user.Id(),
+		SessionID: session.Id(),
+		RegisteredClaims: jwt.RegisteredClaims{
+			ExpiresAt: jwt.NewNumericDate(session.ExpiresAt()),
+		},
+	}
+
+	return signClaims(claims)
+}
 
+// GenerateChallengeToken issues a short-lived token proving that the password step of
+// a two-factor login succeeded. It expires together with the stored challenge and is not
+// accepted as an access token.
+func GenerateChallengeToken(user *domain.User, challengeId int, expiresAt time.Time) (string, error) {
 	claims := &Claims{
-		UserID: user.Id(),
+		UserID:      user.Id(),
+		ChallengeID: challengeId,
+		Purpose:     purposeTwoFactor,
 		RegisteredClaims: jwt.RegisteredClaims{
-			ExpiresAt: jwt.NewNumericDate(expirationTime),
+			ExpiresAt: jwt.NewNumericDate(expiresAt),
 		},
 	}
 
-	token := jwt.NewWithClaims(jwt.SigningMethodHS256, claims)
-	return token.SignedString([]byte(jwtConfig.JWTSecret))
+	return signClaims(claims)
 }
 
 func ParseToken(tokenString string) (*Claims, error) {
+	return parseToken(tokenString, "")
+}
+
+func ParseChallengeToken(tokenString string) (*Claims, error) {
+	return parseToken(tokenString, purposeTwoFactor)
+}
+
+func parseToken(tokenString string, purpose string) (*Claims, error) {
 	claims := &Claims{}
-	token, err := jwt.ParseWithClaims(tokenString, claims, func(token *jwt.Token) (interface{}, error) {
-		return []byte(jwtConfig.JWTSecret), nil
-	})
+	token, err := jwt.ParseWithClaims(tokenString, claims, verificationKey, jwt.WithValidMethods(validMethods()))
 
-	if err != nil || !token.Valid {
+	if err != nil || !token.Valid || claims.Purpose != purpose {
 		return nil, errors.New("invalid token")
 	}
 	return claims, nil
 }
+
+// PublicJWKS returns the public half of every loaded key for the JWKS endpoint.
+func PublicJWKS() []JWK {
+	jwks := make([]JWK, 0, len(signingKeys))
+	for _, key := range signingKeys {
+		jwks = append(jwks, key.JWK())
+	}
+	sort.Slice(jwks, func(i, j int) bool { return jwks[i].Kid < jwks[j].Kid })
+	return jwks
+}
+
+func signClaims(claims jwt.Claims) (string, error) {
+	if activeKey == nil {
+		token := jwt.NewWithClaims(jwt.SigningMethodHS256, claims)
+		return token.SignedString([]byte(jwtConfig.JWTSecret))
+	}
+
+	token := jwt.NewWithClaims(activeKey.Method, claims)
+	token.Header["kid"] = activeKey.ID
+	return token.SignedString(activeKey.Private)
+}
+
+func verificationKey(token *jwt.Token) (interface{}, error) {
+	if len(signingKeys) == 0 {
+		return []byte(jwtConfig.JWTSecret), nil
+	}
+
+	kid, _ := token.Header["kid"].(string)
+	key, ok := signingKeys[kid]
+	if !ok {
+		return nil, fmt.Errorf("unknown key id %q", kid)
+	}
+	if token.Method.Alg() != key.Method.Alg() {
+		return nil, fmt.Errorf("key %q does not sign with %s", kid, token.Method.Alg())
+	}
+	return key.Public, nil
+}
+
+func validMethods() []string {
+	if len(signingKeys) == 0 {
+		return []string{jwt.SigningMethodHS256.Alg()}
+	}
+	var methods []string
+	for _, key := range signingKeys {
+		if !slices.Contains(methods, key.Method.Alg()) {
+			methods = append(methods, key.Method.Alg())
+		}
+	}
+	return methods
+}
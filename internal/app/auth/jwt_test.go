@@ -0,0 +1,180 @@
+package auth
+
+import (
+	"crypto/ed25519"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/x509"
+	"encoding/pem"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"toptal/internal/app/config"
+	"toptal/internal/app/domain"
+)
+
+func writeRSAKey(t *testing.T, dir, kid string) string {
+	key, err := rsa.GenerateKey(rand.Reader, 2048)
+	require.NoError(t, err)
+	der, err := x509.MarshalPKCS8PrivateKey(key)
+	require.NoError(t, err)
+	return writePEM(t, dir, kid, "PRIVATE KEY", der)
+}
+
+func writeEd25519Key(t *testing.T, dir, kid string) (privatePath string, publicPath string) {
+	pub, priv, err := ed25519.GenerateKey(rand.Reader)
+	require.NoError(t, err)
+	privDer, err := x509.MarshalPKCS8PrivateKey(priv)
+	require.NoError(t, err)
+	pubDer, err := x509.MarshalPKIXPublicKey(pub)
+	require.NoError(t, err)
+	return writePEM(t, dir, kid, "PRIVATE KEY", privDer), writePEM(t, filepath.Join(dir, "public"), kid, "PUBLIC KEY", pubDer)
+}
+
+func writePEM(t *testing.T, dir, kid, blockType string, der []byte) string {
+	require.NoError(t, os.MkdirAll(dir, 0o700))
+	path := filepath.Join(dir, kid+".pem")
+	require.NoError(t, os.WriteFile(path, pem.EncodeToMemory(&pem.Block{Type: blockType, Bytes: der}), 0o600))
+	return path
+}
+
+func testUser(t *testing.T) domain.User {
+	user, err := domain.NewUser(42, "alice", "hash", false)
+	require.NoError(t, err)
+	return user
+}
+
+func testSession(t *testing.T) domain.Session {
+	session, err := domain.NewSession(42, "test", "127.0.0.1", time.Now().Add(time.Hour))
+	require.NoError(t, err)
+	require.NoError(t, session.SetId(7))
+	return session
+}
+
+func TestToken_SharedSecret(t *testing.T) {
+	t.Cleanup(func() { _ = SetConfig(config.SecurityConfig{}) })
+	require.NoError(t, SetConfig(config.SecurityConfig{JWTSecret: "secret", JWTExpirationHours: 1}))
+
+	user := testUser(t)
+	session := testSession(t)
+	token, err := GenerateToken(&user, &session)
+	require.NoError(t, err)
+
+	claims, err := ParseToken(token)
+	require.NoError(t, err)
+	assert.Equal(t, 42, claims.UserID)
+	assert.Equal(t, 7, claims.SessionID)
+	assert.Empty(t, PublicJWKS())
+}
+
+func TestToken_RS256WithKeyRotation(t *testing.T) {
+	t.Cleanup(func() { _ = SetConfig(config.SecurityConfig{}) })
+	dir := t.TempDir()
+	oldKey := writeRSAKey(t, dir, "2024")
+	newKey := writeRSAKey(t, dir, "2025")
+	user := testUser(t)
+	session := testSession(t)
+
+	require.NoError(t, SetConfig(config.SecurityConfig{JWTKeyFiles: []string{oldKey}, JWTExpirationHours: 1}))
+	oldToken, err := GenerateToken(&user, &session)
+	require.NoError(t, err)
+
+	parsed, _, err := jwt.NewParser().ParseUnverified(oldToken, &Claims{})
+	require.NoError(t, err)
+	assert.Equal(t, "2024", parsed.Header["kid"])
+	assert.Equal(t, "RS256", parsed.Method.Alg())
+
+	// Switch the signer while still trusting the old key.
+	require.NoError(t, SetConfig(config.SecurityConfig{
+		JWTKeyFiles:        []string{oldKey, newKey},
+		JWTSigningKeyID:    "2025",
+		JWTExpirationHours: 1,
+	}))
+	newToken, err := GenerateToken(&user, &session)
+	require.NoError(t, err)
+
+	_, err = ParseToken(oldToken)
+	assert.NoError(t, err)
+	_, err = ParseToken(newToken)
+	assert.NoError(t, err)
+
+	jwks := PublicJWKS()
+	require.Len(t, jwks, 2)
+	assert.Equal(t, "2024", jwks[0].Kid)
+	assert.Equal(t, "RSA", jwks[0].Kty)
+	assert.Equal(t, "AQAB", jwks[0].E)
+
+	// Once the old key is retired its tokens are rejected.
+	require.NoError(t, SetConfig(config.SecurityConfig{JWTKeyFiles: []string{newKey}, JWTExpirationHours: 1}))
+	_, err = ParseToken(oldToken)
+	assert.Error(t, err)
+	_, err = ParseToken(newToken)
+	assert.NoError(t, err)
+}
+
+func TestToken_EdDSAVerifyOnlyKey(t *testing.T) {
+	t.Cleanup(func() { _ = SetConfig(config.SecurityConfig{}) })
+	dir := t.TempDir()
+	privatePath, publicPath := writeEd25519Key(t, dir, "ed-1")
+	user := testUser(t)
+	session := testSession(t)
+
+	require.NoError(t, SetConfig(config.SecurityConfig{JWTKeyFiles: []string{privatePath}, JWTExpirationHours: 1}))
+	token, err := GenerateToken(&user, &session)
+	require.NoError(t, err)
+
+	// A verifier holding only the public key accepts the token.
+	rsaPath := writeRSAKey(t, dir, "rsa-1")
+	require.NoError(t, SetConfig(config.SecurityConfig{
+		JWTKeyFiles:        []string{publicPath, rsaPath},
+		JWTSigningKeyID:    "rsa-1",
+		JWTExpirationHours: 1,
+	}))
+	claims, err := ParseToken(token)
+	require.NoError(t, err)
+	assert.Equal(t, 42, claims.UserID)
+
+	jwks := PublicJWKS()
+	require.Len(t, jwks, 2)
+	assert.Equal(t, "OKP", jwks[0].Kty)
+	assert.Equal(t, "Ed25519", jwks[0].Crv)
+
+	err = SetConfig(config.SecurityConfig{JWTKeyFiles: []string{publicPath}})
+	assert.Error(t, err, "a public key cannot be the signer")
+}
+
+func TestToken_RejectsSharedSecretWhenKeysConfigured(t *testing.T) {
+	t.Cleanup(func() { _ = SetConfig(config.SecurityConfig{}) })
+	dir := t.TempDir()
+	require.NoError(t, SetConfig(config.SecurityConfig{
+		JWTSecret:   "secret",
+		JWTKeyFiles: []string{writeRSAKey(t, dir, "k1")},
+	}))
+
+	forged := jwt.NewWithClaims(jwt.SigningMethodHS256, &Claims{
+		UserID:           1,
+		RegisteredClaims: jwt.RegisteredClaims{ExpiresAt: jwt.NewNumericDate(time.Now().Add(time.Hour))},
+	})
+	forged.Header["kid"] = "k1"
+	token, err := forged.SignedString([]byte("secret"))
+	require.NoError(t, err)
+
+	_, err = ParseToken(token)
+	assert.Error(t, err)
+}
+
+func TestSetConfig_UnknownSigningKey(t *testing.T) {
+	t.Cleanup(func() { _ = SetConfig(config.SecurityConfig{}) })
+	dir := t.TempDir()
+	err := SetConfig(config.SecurityConfig{
+		JWTKeyFiles:     []string{writeRSAKey(t, dir, "a"), writeRSAKey(t, dir, "b")},
+		JWTSigningKeyID: "c",
+	})
+	assert.Error(t, err)
+}
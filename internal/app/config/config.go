@@ -1,15 +1,22 @@
 package config
 
 import (
+	"errors"
 	"fmt"
 	"log/slog"
 	"os"
 	"strconv"
+	"strings"
 	"time"
 
 	"github.com/joho/godotenv"
 )
 
+const (
+	defaultJWTSecret      = "your_secret_key"
+	defaultDownloadSecret = "your_download_key"
+)
+
 type DatabaseConfig struct {
 	Host         string
 	Port         string
@@ -27,6 +34,9 @@ type ServerConfig struct {
 	ReadTimeout     time.Duration
 	WriteTimeout    time.Duration
 	ShutdownTimeout time.Duration
+	// TrustProxyHeaders takes the client address from X-Forwarded-For. Only enable it
+	// behind a proxy that overwrites the header.
+	TrustProxyHeaders bool
 }
 
 type MetricsConfig struct {
@@ -36,8 +46,58 @@ type MetricsConfig struct {
 
 type SecurityConfig struct {
 	JWTSecret          string
+	JWTKeyFiles        []string
+	JWTSigningKeyID    string
 	JWTExpirationHours int
-	BcryptCost         int
+	// PasswordHashAlgorithm is "bcrypt" or "argon2id". Existing hashes are upgraded at login.
+	PasswordHashAlgorithm string
+	BcryptCost            int
+	Argon2Memory          int
+	Argon2Iterations      int
+	Argon2Parallelism     int
+	PasswordMinLength     int
+	PasswordRequireUpper  bool
+	PasswordRequireLower  bool
+	PasswordRequireDigit  bool
+	PasswordRequireSymbol bool
+	APIKeyRotationGrace   time.Duration
+	TOTPIssuer            string
+	// AdminTwoFactorRequired denies admin routes to users without two-factor authentication.
+	AdminTwoFactorRequired bool
+	PasswordResetTTL       time.Duration
+	EmailVerificationTTL   time.Duration
+	// ReauthenticationMaxAge is how recently users without a password, such as those
+	// signing in through OIDC, must have logged in to set a password or delete their account.
+	ReauthenticationMaxAge time.Duration
+}
+
+type MailConfig struct {
+	// Driver is "smtp" or "file"; the file driver writes messages to FileDir or only logs them.
+	Driver           string
+	From             string
+	SMTPHost         string
+	SMTPPort         string
+	SMTPUsername     string
+	SMTPPassword     string
+	FileDir          string
+	BaseURL          string
+	DispatchInterval time.Duration
+	MaxAttempts      int
+}
+
+type OIDCConfig struct {
+	Providers []OIDCProviderConfig
+	// StateTTL is how long a user may take to sign in at the provider.
+	StateTTL time.Duration
+}
+
+type OIDCProviderConfig struct {
+	Name         string
+	Issuer       string
+	ClientID     string
+	ClientSecret string
+	RedirectURL  string
+	Scopes       []string
 }
 
 type CartConfig struct {
@@ -45,6 +105,108 @@ type CartConfig struct {
 	ExpiryTime      time.Duration
 }
 
+type CatalogConfig struct {
+	// PurgeAfter is how long archived books and categories are kept; zero keeps them forever.
+	// Books that were sold or restocked are never purged.
+	PurgeAfter    time.Duration
+	PurgeInterval time.Duration
+	// PriceSchedulerInterval is how often scheduled price changes and sales are applied.
+	PriceSchedulerInterval time.Duration
+}
+
+type TaxConfig struct {
+	// PricesIncludeTax treats book prices as gross, with tax taken out of them at checkout.
+	PricesIncludeTax bool
+	// Rates are written as "region:class=percent", for example "DE:reduced=7".
+	Rates []string
+}
+
+// ONIXConfig maps ONIX product records onto books.
+type ONIXConfig struct {
+	// SubjectCategories are written as "scheme:code=Category", for example "10:FIC=Fiction".
+	// A code also matches the longer codes it starts, so the example takes every BISAC
+	// fiction subject.
+	SubjectCategories []string
+	// DefaultCategory takes products none of whose subjects are mapped; empty rejects them.
+	DefaultCategory string
+	// Currency selects the price of a product; empty takes the first one.
+	Currency string
+	// PriceTypes are the ONIX price types to take, in order of preference.
+	PriceTypes []string
+}
+
+type BlobConfig struct {
+	// Driver is "file", which keeps blobs below Dir.
+	Driver string
+	Dir    string
+}
+
+// DownloadConfig governs the files of digital formats and their delivery.
+type DownloadConfig struct {
+	// SigningKey signs download URLs.
+	SigningKey string
+	// URLTTL is how long a download URL works once handed out.
+	URLTTL time.Duration
+	// Limit is how many times each purchase may be downloaded.
+	Limit       int
+	MaxFileSize int64
+}
+
+// CoverConfig governs cover images and the renditions made of them.
+type CoverConfig struct {
+	MaxFileSize int64
+	// MaxPixels bounds the width times height of uploaded images, which are decoded whole.
+	MaxPixels      int
+	MediumWidth    int
+	ThumbnailWidth int
+}
+
+// RecommendationConfig governs the "customers also bought" recommendations.
+type RecommendationConfig struct {
+	// RefreshInterval is how often new orders are added to the co-purchase counts.
+	RefreshInterval time.Duration
+	// BatchSize is how many orders a refresh adds per transaction.
+	BatchSize int
+	// MinScore is how many customers must have bought two books together before one
+	// is recommended with the other; below it bestsellers are recommended instead.
+	MinScore int
+}
+
+// ChartConfig governs the bestseller and trending charts.
+type ChartConfig struct {
+	// RefreshInterval is how often the charts are ranked again from new orders and cart adds.
+	RefreshInterval time.Duration
+	// Books trend when they were put in carts more often a day over the last TrendingDays
+	// than over the TrendingBaselineDays before.
+	TrendingDays         int
+	TrendingBaselineDays int
+}
+
+// ReportConfig governs the sales reports.
+type ReportConfig struct {
+	// StatementTimeout cancels report queries that run longer, so they cannot hold up orders.
+	StatementTimeout time.Duration
+}
+
+// StockConfig governs low stock alerts and reorder suggestions.
+type StockConfig struct {
+	// AlertInterval is how often stock levels are checked.
+	AlertInterval time.Duration
+	// Books without a reorder threshold of their own are low on stock at or below
+	// DefaultThreshold copies.
+	DefaultThreshold int
+	// Reorders are sized from the copies sold over the last SalesDays, to cover CoverDays
+	// of sales on top of the threshold.
+	SalesDays int
+	CoverDays int
+	// Notifiers are the drivers alerts are sent to: "log", "webhook" and "email".
+	Notifiers     []string
+	WebhookURL    string
+	WebhookSecret string
+	// AlertEmails receive the alerts of the email notifier through the email outbox.
+	AlertEmails []string
+}
+
 type LogConfig struct {
 	Level string
 	JSON  bool
@@ -57,7 +219,19 @@ type Config struct {
 	Metrics     MetricsConfig
 	Security    SecurityConfig
 	Cart        CartConfig
+	Catalog     CatalogConfig
+	Tax         TaxConfig
+	ONIX        ONIXConfig
+	Blob        BlobConfig
+	Download    DownloadConfig
+	Cover       CoverConfig
+	Recommend   RecommendationConfig
+	Charts      ChartConfig
+	Reports     ReportConfig
+	Stock       StockConfig
 	Log         LogConfig
+	Mail        MailConfig
+	OIDC        OIDCConfig
 }
 
 func LoadConfig() (*Config, error) {
@@ -65,8 +239,8 @@ func LoadConfig() (*Config, error) {
 		slog.Warn("Failed to load .env file, using environment variables", "error", err)
 	}
 
-	return &Config{
-		Environment: getEnv("ENVIRONMENT", "development"),
+	cfg := &Config{
+		Environment: getEnv("ENVIRONMENT", "production"),
 		DB: DatabaseConfig{
 			Host:         getEnv("DB_HOST", "localhost"),
 			Port:         getEnv("DB_PORT", "5432"),
@@ -79,29 +253,201 @@ func LoadConfig() (*Config, error) {
 			SSLMode:      getEnv("DB_SSL_MODE", "disable"),
 		},
 		Server: ServerConfig{
-			Port:            getEnv("SERVER_PORT", "8080"),
-			ReadTimeout:     getEnvAsDuration("SERVER_READ_TIMEOUT", 10*time.Second),
-			WriteTimeout:    getEnvAsDuration("SERVER_WRITE_TIMEOUT", 10*time.Second),
-			ShutdownTimeout: getEnvAsDuration("SERVER_SHUTDOWN_TIMEOUT", 30*time.Second),
+			Port:              getEnv("SERVER_PORT", "8080"),
+			ReadTimeout:       getEnvAsDuration("SERVER_READ_TIMEOUT", 10*time.Second),
+			WriteTimeout:      getEnvAsDuration("SERVER_WRITE_TIMEOUT", 10*time.Second),
+			ShutdownTimeout:   getEnvAsDuration("SERVER_SHUTDOWN_TIMEOUT", 30*time.Second),
+			TrustProxyHeaders: getEnvAsBool("TRUST_PROXY_HEADERS", false),
 		},
 		Metrics: MetricsConfig{
 			Enabled: getEnvAsBool("METRICS_ENABLED", true),
 			Port:    getEnv("METRICS_PORT", "2112"),
 		},
 		Security: SecurityConfig{
-			JWTSecret:          getEnv("JWT_SECRET", "your_secret_key"),
-			JWTExpirationHours: getEnvAsInt("JWT_EXPIRATION_HOURS", 24),
-			BcryptCost:         getEnvAsInt("BCRYPT_COST", 10),
+			JWTSecret:              getEnv("JWT_SECRET", defaultJWTSecret),
+			JWTKeyFiles:            getEnvAsSlice("JWT_KEY_FILES", nil),
+			JWTSigningKeyID:        getEnv("JWT_SIGNING_KEY_ID", ""),
+			JWTExpirationHours:     getEnvAsInt("JWT_EXPIRATION_HOURS", 24),
+			PasswordHashAlgorithm:  getEnv("PASSWORD_HASH_ALGORITHM", "bcrypt"),
+			BcryptCost:             getEnvAsInt("BCRYPT_COST", 10),
+			Argon2Memory:           getEnvAsInt("ARGON2_MEMORY_KIB", 64*1024),
+			Argon2Iterations:       getEnvAsInt("ARGON2_ITERATIONS", 3),
+			Argon2Parallelism:      getEnvAsInt("ARGON2_PARALLELISM", 2),
+			PasswordMinLength:      getEnvAsInt("PASSWORD_MIN_LENGTH", 8),
+			PasswordRequireUpper:   getEnvAsBool("PASSWORD_REQUIRE_UPPER", false),
+			PasswordRequireLower:   getEnvAsBool("PASSWORD_REQUIRE_LOWER", false),
+			PasswordRequireDigit:   getEnvAsBool("PASSWORD_REQUIRE_DIGIT", true),
+			PasswordRequireSymbol:  getEnvAsBool("PASSWORD_REQUIRE_SYMBOL", false),
+			APIKeyRotationGrace:    getEnvAsDuration("API_KEY_ROTATION_GRACE", 24*time.Hour),
+			TOTPIssuer:             getEnv("TOTP_ISSUER", "Book Shop"),
+			AdminTwoFactorRequired: getEnvAsBool("ADMIN_2FA_REQUIRED", false),
+			PasswordResetTTL:       getEnvAsDuration("PASSWORD_RESET_TTL", time.Hour),
+			EmailVerificationTTL:   getEnvAsDuration("EMAIL_VERIFICATION_TTL", 48*time.Hour),
+			ReauthenticationMaxAge: getEnvAsDuration("REAUTHENTICATION_MAX_AGE", 10*time.Minute),
 		},
 		Cart: CartConfig{
 			CleanupInterval: getEnvAsDuration("CART_CLEANUP_INTERVAL", 5*time.Minute),
 			ExpiryTime:      getEnvAsDuration("CART_EXPIRY_TIME", 30*time.Minute),
 		},
+		Catalog: CatalogConfig{
+			PurgeAfter:             getEnvAsDuration("ARCHIVE_PURGE_AFTER", 0),
+			PurgeInterval:          getEnvAsDuration("ARCHIVE_PURGE_INTERVAL", time.Hour),
+			PriceSchedulerInterval: getEnvAsDuration("PRICE_SCHEDULER_INTERVAL", time.Minute),
+		},
+		Tax: TaxConfig{
+			PricesIncludeTax: getEnvAsBool("TAX_PRICES_INCLUDE_TAX", false),
+			Rates:            getEnvAsSlice("TAX_RATES", nil),
+		},
+		ONIX: ONIXConfig{
+			SubjectCategories: getEnvAsSlice("ONIX_SUBJECT_CATEGORIES", nil),
+			DefaultCategory:   getEnv("ONIX_DEFAULT_CATEGORY", ""),
+			Currency:          getEnv("ONIX_CURRENCY", ""),
+			PriceTypes:        getEnvAsSlice("ONIX_PRICE_TYPES", []string{"01", "02"}),
+		},
+		Blob: BlobConfig{
+			Driver: getEnv("BLOB_DRIVER", "file"),
+			Dir:    getEnv("BLOB_DIR", "data/blobs"),
+		},
+		Download: DownloadConfig{
+			SigningKey:  getEnv("DOWNLOAD_SIGNING_KEY", defaultDownloadSecret),
+			URLTTL:      getEnvAsDuration("DOWNLOAD_URL_TTL", 15*time.Minute),
+			Limit:       getEnvAsInt("DOWNLOAD_LIMIT", 5),
+			MaxFileSize: int64(getEnvAsInt("DOWNLOAD_MAX_FILE_SIZE_MB", 200)) << 20,
+		},
+		Cover: CoverConfig{
+			MaxFileSize:    int64(getEnvAsInt("COVER_MAX_FILE_SIZE_MB", 10)) << 20,
+			MaxPixels:      getEnvAsInt("COVER_MAX_MEGAPIXELS", 40) * 1000000,
+			MediumWidth:    getEnvAsInt("COVER_MEDIUM_WIDTH", 600),
+			ThumbnailWidth: getEnvAsInt("COVER_THUMBNAIL_WIDTH", 150),
+		},
+		Recommend: RecommendationConfig{
+			RefreshInterval: getEnvAsDuration("RECOMMENDATION_REFRESH_INTERVAL", 10*time.Minute),
+			BatchSize:       getEnvAsInt("RECOMMENDATION_BATCH_SIZE", 1000),
+			MinScore:        getEnvAsInt("RECOMMENDATION_MIN_SCORE", 2),
+		},
+		Charts: ChartConfig{
+			RefreshInterval:      getEnvAsDuration("CHART_REFRESH_INTERVAL", 15*time.Minute),
+			TrendingDays:         getEnvAsInt("CHART_TRENDING_DAYS", 3),
+			TrendingBaselineDays: getEnvAsInt("CHART_TRENDING_BASELINE_DAYS", 28),
+		},
+		Reports: ReportConfig{
+			StatementTimeout: getEnvAsDuration("REPORT_STATEMENT_TIMEOUT", 30*time.Second),
+		},
+		Stock: StockConfig{
+			AlertInterval:    getEnvAsDuration("STOCK_ALERT_INTERVAL", 15*time.Minute),
+			DefaultThreshold: getEnvAsInt("STOCK_DEFAULT_REORDER_THRESHOLD", 5),
+			SalesDays:        getEnvAsInt("STOCK_SALES_DAYS", 28),
+			CoverDays:        getEnvAsInt("STOCK_REORDER_COVER_DAYS", 30),
+			Notifiers:        getEnvAsSlice("STOCK_ALERT_NOTIFIERS", []string{"log"}),
+			WebhookURL:       getEnv("STOCK_ALERT_WEBHOOK_URL", ""),
+			WebhookSecret:    getEnv("STOCK_ALERT_WEBHOOK_SECRET", ""),
+			AlertEmails:      getEnvAsSlice("STOCK_ALERT_EMAILS", nil),
+		},
 		Log: LogConfig{
 			Level: getEnv("LOG_LEVEL", "info"),
 			JSON:  getEnvAsBool("LOG_JSON", true),
 		},
-	}, nil
+		Mail: MailConfig{
+			Driver:           getEnv("MAIL_DRIVER", "file"),
+			From:             getEnv("MAIL_FROM", "Book Shop <no-reply@localhost>"),
+			SMTPHost:         getEnv("SMTP_HOST", "localhost"),
+			SMTPPort:         getEnv("SMTP_PORT", "587"),
+			SMTPUsername:     getEnv("SMTP_USERNAME", ""),
+			SMTPPassword:     getEnv("SMTP_PASSWORD", ""),
+			FileDir:          getEnv("MAIL_FILE_DIR", ""),
+			BaseURL:          getEnv("APP_BASE_URL", "http://localhost:8080"),
+			DispatchInterval: getEnvAsDuration("MAIL_DISPATCH_INTERVAL", 10*time.Second),
+			MaxAttempts:      getEnvAsInt("MAIL_MAX_ATTEMPTS", 8),
+		},
+		OIDC: OIDCConfig{
+			StateTTL: getEnvAsDuration("OIDC_STATE_TTL", 10*time.Minute),
+		},
+	}
+	cfg.OIDC.Providers = loadOIDCProviders(cfg.Mail.BaseURL)
+
+	if err := cfg.validate(); err != nil {
+		return nil, err
+	}
+	return cfg, nil
+}
+
+// validate rejects malformed settings and those only acceptable on a developer machine.
+func (c *Config) validate() error {
+	if c.Security.Argon2Memory < 0 || c.Security.Argon2Iterations < 0 || c.Security.Argon2Parallelism < 0 ||
+		c.Security.Argon2Parallelism > 255 {
+		return errors.New("ARGON2_MEMORY_KIB, ARGON2_ITERATIONS and ARGON2_PARALLELISM must be positive, parallelism at most 255")
+	}
+	if c.Security.ReauthenticationMaxAge <= 0 {
+		return errors.New("REAUTHENTICATION_MAX_AGE must be positive")
+	}
+	if c.Mail.DispatchInterval <= 0 {
+		return errors.New("MAIL_DISPATCH_INTERVAL must be positive")
+	}
+	if c.Catalog.PurgeAfter > 0 && c.Catalog.PurgeInterval <= 0 {
+		return errors.New("ARCHIVE_PURGE_INTERVAL must be positive when ARCHIVE_PURGE_AFTER is set")
+	}
+	if c.Catalog.PriceSchedulerInterval <= 0 {
+		return errors.New("PRICE_SCHEDULER_INTERVAL must be positive")
+	}
+	if c.Cover.MediumWidth <= 0 || c.Cover.ThumbnailWidth <= 0 {
+		return errors.New("COVER_MEDIUM_WIDTH and COVER_THUMBNAIL_WIDTH must be positive")
+	}
+	if c.Recommend.RefreshInterval <= 0 || c.Recommend.BatchSize <= 0 {
+		return errors.New("RECOMMENDATION_REFRESH_INTERVAL and RECOMMENDATION_BATCH_SIZE must be positive")
+	}
+	if c.Charts.RefreshInterval <= 0 || c.Charts.TrendingDays <= 0 || c.Charts.TrendingBaselineDays <= 0 {
+		return errors.New("CHART_REFRESH_INTERVAL, CHART_TRENDING_DAYS and CHART_TRENDING_BASELINE_DAYS must be positive")
+	}
+	if c.Stock.AlertInterval <= 0 || c.Stock.SalesDays <= 0 || c.Stock.CoverDays <= 0 || c.Stock.DefaultThreshold < 0 {
+		return errors.New("STOCK_ALERT_INTERVAL, STOCK_SALES_DAYS and STOCK_REORDER_COVER_DAYS must be positive, STOCK_DEFAULT_REORDER_THRESHOLD not negative")
+	}
+	for _, notifier := range c.Stock.Notifiers {
+		switch notifier {
+		case "log":
+		case "webhook":
+			if c.Stock.WebhookURL == "" {
+				return errors.New("STOCK_ALERT_WEBHOOK_URL is required by the webhook notifier")
+			}
+		case "email":
+			if len(c.Stock.AlertEmails) == 0 {
+				return errors.New("STOCK_ALERT_EMAILS is required by the email notifier")
+			}
+		default:
+			return fmt.Errorf("unknown stock alert notifier %q", notifier)
+		}
+	}
+	for _, provider := range c.OIDC.Providers {
+		if provider.Issuer == "" || provider.ClientID == "" {
+			return fmt.Errorf("OIDC provider %q needs an issuer and a client id", provider.Name)
+		}
+	}
+	if c.Environment == "development" {
+		return nil
+	}
+	if len(c.Security.JWTKeyFiles) == 0 && c.Security.JWTSecret == defaultJWTSecret {
+		return errors.New("JWT_SECRET must be changed from its default or JWT_KEY_FILES configured outside development")
+	}
+	if c.Download.SigningKey == defaultDownloadSecret {
+		return errors.New("DOWNLOAD_SIGNING_KEY must be changed from its default outside development")
+	}
+	return nil
+}
+
+// loadOIDCProviders reads OIDC_<NAME>_* variables for every name listed in OIDC_PROVIDERS.
+func loadOIDCProviders(baseURL string) []OIDCProviderConfig {
+	var providers []OIDCProviderConfig
+	for _, name := range getEnvAsSlice("OIDC_PROVIDERS", nil) {
+		prefix := "OIDC_" + strings.ToUpper(name) + "_"
+		providers = append(providers, OIDCProviderConfig{
+			Name:         name,
+			Issuer:       getEnv(prefix+"ISSUER", ""),
+			ClientID:     getEnv(prefix+"CLIENT_ID", ""),
+			ClientSecret: getEnv(prefix+"CLIENT_SECRET", ""),
+			RedirectURL:  getEnv(prefix+"REDIRECT_URL", strings.TrimRight(baseURL, "/")+"/oidc/"+name+"/callback"),
+			Scopes:       getEnvAsSlice(prefix+"SCOPES", []string{"openid", "email", "profile"}),
+		})
+	}
+	return providers
 }
 
 func (c *DatabaseConfig) DSN() string {
@@ -142,3 +488,16 @@ func getEnvAsDuration(key string, defaultValue time.Duration) time.Duration {
 	}
 	return defaultValue
 }
+
+func getEnvAsSlice(key string, defaultValue []string) []string {
+	if value := os.Getenv(key); value != "" {
+		var values []string
+		for _, v := range strings.Split(value, ",") {
+			if v = strings.TrimSpace(v); v != "" {
+				values = append(values, v)
+			}
+		}
+		return values
+	}
+	return defaultValue
+}
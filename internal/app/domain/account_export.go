@@ -0,0 +1,31 @@
+package domain
+
+import "time"
+
+// AccountExport holds everything stored about a user, for data access requests.
+type AccountExport struct {
+	user       User
+	cart       []Book
+	orders     []Order
+	exportedAt time.Time
+}
+
+func NewAccountExport(user User, cart []Book, orders []Order, exportedAt time.Time) AccountExport {
+	return AccountExport{user: user, cart: cart, orders: orders, exportedAt: exportedAt}
+}
+
+func (e *AccountExport) User() User {
+	return e.user
+}
+
+func (e *AccountExport) Cart() []Book {
+	return e.cart
+}
+
+func (e *AccountExport) Orders() []Order {
+	return e.orders
+}
+
+func (e *AccountExport) ExportedAt() time.Time {
+	return e.exportedAt
+}
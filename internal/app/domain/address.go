@@ -0,0 +1,88 @@
+package domain
+
+import (
+	"fmt"
+	"regexp"
+)
+
+var (
+	countryPattern     = regexp.MustCompile(`^[A-Z]{2}$`)
+	subdivisionPattern = regexp.MustCompile(`^[A-Z0-9]{1,3}$`)
+)
+
+// ShippingAddress is where an order is sent. Its country and region decide the tax rates
+// the order pays.
+type ShippingAddress struct {
+	name       string
+	line1      string
+	line2      string
+	city       string
+	postalCode string
+	region     string
+	country    string
+}
+
+// NewShippingAddress creates an address. country is an ISO 3166-1 alpha-2 code and region
+// the optional ISO 3166-2 subdivision within it, such as "CA" for California.
+func NewShippingAddress(name, line1, line2, city, postalCode, region, country string) (ShippingAddress, error) {
+	if name == "" || line1 == "" || city == "" {
+		return ShippingAddress{}, fmt.Errorf("shipping address needs a name, a street and a city")
+	}
+	if !countryPattern.MatchString(country) {
+		return ShippingAddress{}, fmt.Errorf("invalid country code: %q", country)
+	}
+	if region != "" && !subdivisionPattern.MatchString(region) {
+		return ShippingAddress{}, fmt.Errorf("invalid region code: %q", region)
+	}
+	return ShippingAddress{
+		name:       name,
+		line1:      line1,
+		line2:      line2,
+		city:       city,
+		postalCode: postalCode,
+		region:     region,
+		country:    country,
+	}, nil
+}
+
+func (a *ShippingAddress) Name() string {
+	return a.name
+}
+
+func (a *ShippingAddress) Line1() string {
+	return a.line1
+}
+
+func (a *ShippingAddress) Line2() string {
+	return a.line2
+}
+
+func (a *ShippingAddress) City() string {
+	return a.city
+}
+
+func (a *ShippingAddress) PostalCode() string {
+	return a.postalCode
+}
+
+func (a *ShippingAddress) Region() string {
+	return a.region
+}
+
+func (a *ShippingAddress) Country() string {
+	return a.country
+}
+
+// IsSet reports whether the address was given; orders placed before addresses were
+// recorded have none.
+func (a *ShippingAddress) IsSet() bool {
+	return a.country != ""
+}
+
+// TaxRegion is the country, followed by the region when there is one, as in "US-CA".
+func (a *ShippingAddress) TaxRegion() string {
+	if a.region == "" {
+		return a.country
+	}
+	return a.country + "-" + a.region
+}
@@ -0,0 +1,176 @@
+package domain
+
+import (
+	"fmt"
+	"slices"
+	"time"
+)
+
+// API key scopes each grant one area of the admin API.
+const (
+	// ScopeCatalogWrite manages books, categories, formats and covers, and imports catalogues.
+	ScopeCatalogWrite = "catalog:write"
+	// ScopeCatalogExport exports the whole catalogue.
+	ScopeCatalogExport = "catalog:export"
+	// ScopePricingWrite schedules price changes and sets price lists.
+	ScopePricingWrite = "pricing:write"
+	// ScopeStockWrite reads stock alerts and movements and sets reorder thresholds.
+	ScopeStockWrite = "stock:write"
+	// ScopePurchasingWrite manages suppliers and purchase orders, including their receipt.
+	ScopePurchasingWrite = "purchasing:write"
+)
+
+var apiKeyScopes = []string{ScopeCatalogWrite, ScopeCatalogExport, ScopePricingWrite, ScopeStockWrite, ScopePurchasingWrite}
+
+type APIKey struct {
+	id         int
+	name       string
+	prefix     string
+	keyHash    string
+	scopes     []string
+	createdBy  int
+	createdAt  time.Time
+	expiresAt  time.Time
+	lastUsedAt time.Time
+	revokedAt  time.Time
+}
+
+func NewAPIKey(name string, scopes []string, expiresAt time.Time, createdBy int) (APIKey, error) {
+	key := APIKey{}
+	if err := key.SetName(name); err != nil {
+		return key, err
+	}
+	if err := key.SetScopes(scopes); err != nil {
+		return key, err
+	}
+	if err := key.SetExpiresAt(expiresAt); err != nil {
+		return key, err
+	}
+	if err := key.SetCreatedBy(createdBy); err != nil {
+		return key, err
+	}
+	return key, nil
+}
+
+// Getter methods
+
+func (k *APIKey) Id() int {
+	return k.id
+}
+
+func (k *APIKey) Name() string {
+	return k.name
+}
+
+func (k *APIKey) Prefix() string {
+	return k.prefix
+}
+
+func (k *APIKey) KeyHash() string {
+	return k.keyHash
+}
+
+func (k *APIKey) Scopes() []string {
+	return k.scopes
+}
+
+func (k *APIKey) CreatedBy() int {
+	return k.createdBy
+}
+
+func (k *APIKey) CreatedAt() time.Time {
+	return k.createdAt
+}
+
+// ExpiresAt returns the zero time for keys that never expire.
+func (k *APIKey) ExpiresAt() time.Time {
+	return k.expiresAt
+}
+
+func (k *APIKey) LastUsedAt() time.Time {
+	return k.lastUsedAt
+}
+
+func (k *APIKey) RevokedAt() time.Time {
+	return k.revokedAt
+}
+
+func (k *APIKey) HasScope(scope string) bool {
+	return slices.Contains(k.scopes, scope)
+}
+
+// Active reports whether the key is neither revoked nor expired at the given time.
+func (k *APIKey) Active(now time.Time) bool {
+	if !k.revokedAt.IsZero() {
+		return false
+	}
+	return k.expiresAt.IsZero() || now.Before(k.expiresAt)
+}
+
+// Setter methods
+
+func (k *APIKey) SetId(id int) error {
+	if id <= 0 {
+		return fmt.Errorf("invalid api key id: %d", id)
+	}
+	k.id = id
+	return nil
+}
+
+func (k *APIKey) SetName(name string) error {
+	if name == "" {
+		return fmt.Errorf("invalid api key name: %s", name)
+	}
+	k.name = name
+	return nil
+}
+
+func (k *APIKey) SetCredentials(prefix string, keyHash string) error {
+	if prefix == "" || keyHash == "" {
+		return fmt.Errorf("invalid api key credentials")
+	}
+	k.prefix = prefix
+	k.keyHash = keyHash
+	return nil
+}
+
+func (k *APIKey) SetScopes(scopes []string) error {
+	if len(scopes) == 0 {
+		return fmt.Errorf("api key must have at least one scope")
+	}
+	for _, scope := range scopes {
+		if !slices.Contains(apiKeyScopes, scope) {
+			return fmt.Errorf("invalid api key scope: %s", scope)
+		}
+	}
+	k.scopes = scopes
+	return nil
+}
+
+func (k *APIKey) SetCreatedBy(userId int) error {
+	if userId <= 0 {
+		return fmt.Errorf("invalid api key owner id: %d", userId)
+	}
+	k.createdBy = userId
+	return nil
+}
+
+func (k *APIKey) SetCreatedAt(createdAt time.Time) error {
+	k.createdAt = createdAt
+	return nil
+}
+
+func (k *APIKey) SetExpiresAt(expiresAt time.Time) error {
+	k.expiresAt = expiresAt
+	return nil
+}
+
+func (k *APIKey) SetLastUsedAt(lastUsedAt time.Time) error {
+	k.lastUsedAt = lastUsedAt
+	return nil
+}
+
+func (k *APIKey) SetRevokedAt(revokedAt time.Time) error {
+	k.revokedAt = revokedAt
+	return nil
+}
@@ -0,0 +1,151 @@
+package domain
+
+import (
+	"fmt"
+	"time"
+)
+
+const (
+	AuditActionCreate = "create"
+	AuditActionUpdate = "update"
+	AuditActionDelete = "delete"
+	AuditActionRotate = "rotate"
+	AuditActionRevoke = "revoke"
+	// AuditActionArchive and AuditActionRestore hide and re-list catalogue entries;
+	// AuditActionPurge is the background removal of long archived ones.
+	AuditActionArchive = "archive"
+	AuditActionRestore = "restore"
+	AuditActionPurge   = "purge"
+	AuditActionCancel  = "cancel"
+
+	AuditEntityBook          = "book"
+	AuditEntityCategory      = "category"
+	AuditEntityAPIKey        = "api_key"
+	AuditEntityUser          = "user"
+	AuditEntityPriceChange   = "price_change"
+	AuditEntityListPrice     = "list_price"
+	AuditEntityBookFormat    = "book_format"
+	AuditEntitySupplier      = "supplier"
+	AuditEntityBookSupplier  = "book_supplier"
+	AuditEntityPurchaseOrder = "purchase_order"
+)
+
+// AuditActor identifies who made a change and the request it came with. Changes are
+// made either by a user or through an API key.
+type AuditActor struct {
+	userId    int
+	apiKeyId  int
+	requestId string
+	ipAddress string
+}
+
+func NewAuditActor(userId int, apiKeyId int, requestId string, ipAddress string) AuditActor {
+	return AuditActor{userId: userId, apiKeyId: apiKeyId, requestId: requestId, ipAddress: ipAddress}
+}
+
+func (a *AuditActor) UserId() int {
+	return a.userId
+}
+
+func (a *AuditActor) APIKeyId() int {
+	return a.apiKeyId
+}
+
+func (a *AuditActor) RequestId() string {
+	return a.requestId
+}
+
+func (a *AuditActor) IPAddress() string {
+	return a.ipAddress
+}
+
+// AuditEntry records one change to an entity. Before and after only hold the fields
+// that changed; a created entity has no before and a deleted one no after.
+type AuditEntry struct {
+	id         int
+	actor      AuditActor
+	action     string
+	entityType string
+	entityId   int
+	before     map[string]any
+	after      map[string]any
+	createdAt  time.Time
+}
+
+func NewAuditEntry(actor AuditActor, action string, entityType string, entityId int, before, after map[string]any) (AuditEntry, error) {
+	if action == "" || entityType == "" {
+		return AuditEntry{}, fmt.Errorf("audit entry needs an action and an entity type")
+	}
+	if entityId <= 0 {
+		return AuditEntry{}, fmt.Errorf("invalid audit entity id: %d", entityId)
+	}
+	return AuditEntry{
+		actor:      actor,
+		action:     action,
+		entityType: entityType,
+		entityId:   entityId,
+		before:     before,
+		after:      after,
+	}, nil
+}
+
+// Getter methods
+
+func (e *AuditEntry) Id() int {
+	return e.id
+}
+
+func (e *AuditEntry) Actor() AuditActor {
+	return e.actor
+}
+
+func (e *AuditEntry) Action() string {
+	return e.action
+}
+
+func (e *AuditEntry) EntityType() string {
+	return e.entityType
+}
+
+func (e *AuditEntry) EntityId() int {
+	return e.entityId
+}
+
+func (e *AuditEntry) Before() map[string]any {
+	return e.before
+}
+
+func (e *AuditEntry) After() map[string]any {
+	return e.after
+}
+
+func (e *AuditEntry) CreatedAt() time.Time {
+	return e.createdAt
+}
+
+// Setter methods
+
+func (e *AuditEntry) SetId(id int) error {
+	if id <= 0 {
+		return fmt.Errorf("invalid audit entry id: %d", id)
+	}
+	e.id = id
+	return nil
+}
+
+func (e *AuditEntry) SetCreatedAt(createdAt time.Time) error {
+	e.createdAt = createdAt
+	return nil
+}
+
+// AuditFilter selects audit entries. Zero values match everything.
+type AuditFilter struct {
+	EntityType    string
+	EntityId      int
+	ActorUserId   int
+	ActorAPIKeyId int
+	From          time.Time
+	To            time.Time
+	Limit         int
+	Offset        int
+}
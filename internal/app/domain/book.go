@@ -1,18 +1,30 @@
 package domain
 
-import "fmt"
+import (
+	"fmt"
+	"time"
+)
 
 type Book struct {
 	id         int
 	title      string
 	year       int
 	author     string
-	price      int
+	price      Money
 	stock      int
 	categoryId int
+	isbn       string
+	salePrice  Money
+	onSale     bool
+	saleEndsAt time.Time
+	archivedAt time.Time
+	addedAt    time.Time
+	format     string
+	cover      Cover
+	metadata   BookMetadata
 }
 
-func NewBook(id int, title string, year int, author string, price int, stock int, categoryId int) (Book, error) {
+func NewBook(id int, title string, year int, author string, price Money, stock int, categoryId int) (Book, error) {
 	book := Book{}
 	if err := book.SetID(id); err != nil {
 		return book, err
@@ -56,7 +68,7 @@ func (b *Book) Author() string {
 	return b.author
 }
 
-func (b *Book) Price() int {
+func (b *Book) Price() Money {
 	return b.price
 }
 
@@ -68,6 +80,44 @@ func (b *Book) CategoryId() int {
 	return b.categoryId
 }
 
+// ISBN is the ISBN-13 of the book, empty when it has none.
+func (b *Book) ISBN() string {
+	return b.isbn
+}
+
+// SalePrice returns the price of a running sale, if there is one. A sale that has ended
+// is over even before the price scheduler clears it.
+func (b *Book) SalePrice() (Money, bool) {
+	if !b.onSale || (!b.saleEndsAt.IsZero() && !time.Now().Before(b.saleEndsAt)) {
+		return Money{}, false
+	}
+	return b.salePrice, true
+}
+
+func (b *Book) SaleEndsAt() time.Time {
+	return b.saleEndsAt
+}
+
+// ArchivedAt is when the book was removed from the catalogue, zero while it is listed.
+func (b *Book) ArchivedAt() time.Time {
+	return b.archivedAt
+}
+
+// AddedAt is when the book was added to the catalogue.
+func (b *Book) AddedAt() time.Time {
+	return b.addedAt
+}
+
+// Format is the format a cart line is for, empty for the book itself.
+func (b *Book) Format() string {
+	return b.format
+}
+
+// Cover returns the cover image of the book, if one has been uploaded.
+func (b *Book) Cover() (Cover, bool) {
+	return b.cover, b.cover.key != ""
+}
+
 // Setter methods with validations
 
 func (b *Book) SetID(id int) error {
@@ -102,8 +152,11 @@ func (b *Book) SetAuthor(author string) error {
 	return nil
 }
 
-func (b *Book) SetPrice(price int) error {
-	if price < 0 {
+func (b *Book) SetPrice(price Money) error {
+	if !price.IsSet() {
+		return fmt.Errorf("price is required")
+	}
+	if price.IsNegative() {
 		return fmt.Errorf("price cannot be negative")
 	}
 	b.price = price
@@ -125,3 +178,88 @@ func (b *Book) SetCategoryId(categoryId int) error {
 	b.categoryId = categoryId
 	return nil
 }
+
+// SetISBN accepts an ISBN-10 or ISBN-13 and stores it as an ISBN-13. An empty ISBN
+// removes it.
+func (b *Book) SetISBN(isbn string) error {
+	if isbn == "" {
+		b.isbn = ""
+		return nil
+	}
+	normalized, err := NormalizeISBN(isbn)
+	if err != nil {
+		return err
+	}
+	b.isbn = normalized
+	return nil
+}
+
+// SetSale puts the book on sale at the given price until endsAt. The sale is in the
+// currency of the regular price.
+func (b *Book) SetSale(salePrice Money, endsAt time.Time) error {
+	if salePrice.IsNegative() {
+		return fmt.Errorf("sale price cannot be negative")
+	}
+	if salePrice.Currency() != b.price.Currency() {
+		return fmt.Errorf("%w: sale price in %s, price in %s", ErrCurrencyMismatch, salePrice.Currency(), b.price.Currency())
+	}
+	b.salePrice = salePrice
+	b.onSale = true
+	b.saleEndsAt = endsAt
+	return nil
+}
+
+// WithListPrice returns a copy of the book priced from a price list in another currency.
+// Sales only apply to the book's own currency, so the copy has none.
+func (b *Book) WithListPrice(price Money) Book {
+	listed := *b
+	listed.price = price
+	listed.salePrice = Money{}
+	listed.onSale = false
+	listed.saleEndsAt = time.Time{}
+	return listed
+}
+
+func (b *Book) SetArchivedAt(archivedAt time.Time) error {
+	b.archivedAt = archivedAt
+	return nil
+}
+
+func (b *Book) SetAddedAt(addedAt time.Time) error {
+	b.addedAt = addedAt
+	return nil
+}
+
+func (b *Book) SetCover(cover Cover) error {
+	b.cover = cover
+	return nil
+}
+
+// WithFormat returns a copy of the book sold in one of its formats, at the format's price
+// and stock.
+func (b *Book) WithFormat(format BookFormat) Book {
+	formatted := b.WithListPrice(format.Price())
+	formatted.format = format.Format()
+	formatted.stock = format.Stock()
+	return formatted
+}
+
+// PurgedBook is a book deleted for good by the archive purge, with the keys of the files
+// its formats had. Its cover and those files are left in the blob store for the caller to
+// remove once the deletion is committed.
+type PurgedBook struct {
+	book     Book
+	fileKeys []string
+}
+
+func NewPurgedBook(book Book, fileKeys []string) PurgedBook {
+	return PurgedBook{book: book, fileKeys: fileKeys}
+}
+
+func (p *PurgedBook) Book() Book {
+	return p.book
+}
+
+func (p *PurgedBook) FileKeys() []string {
+	return p.fileKeys
+}
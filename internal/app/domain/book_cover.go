@@ -0,0 +1,73 @@
+package domain
+
+import (
+	"fmt"
+	"path"
+	"time"
+)
+
+// Renditions of a cover. The original is the uploaded image; medium and thumbnail are
+// JPEGs scaled down from it.
+const (
+	CoverOriginal  = "original"
+	CoverMedium    = "medium"
+	CoverThumbnail = "thumbnail"
+)
+
+var CoverRenditions = []string{CoverOriginal, CoverMedium, CoverThumbnail}
+
+func IsCoverRendition(rendition string) bool {
+	switch rendition {
+	case CoverOriginal, CoverMedium, CoverThumbnail:
+		return true
+	}
+	return false
+}
+
+// Cover is the cover image of a book. Its renditions are kept in the blob store under its
+// key, which changes with every upload.
+type Cover struct {
+	key         string
+	contentType string
+	updatedAt   time.Time
+}
+
+func NewCover(key string, contentType string, updatedAt time.Time) (Cover, error) {
+	if key == "" {
+		return Cover{}, fmt.Errorf("cover key cannot be empty")
+	}
+	if contentType == "" {
+		return Cover{}, fmt.Errorf("cover content type cannot be empty")
+	}
+	return Cover{key: key, contentType: contentType, updatedAt: updatedAt}, nil
+}
+
+func (c *Cover) Key() string {
+	return c.key
+}
+
+// ContentType is the media type of the original image.
+func (c *Cover) ContentType() string {
+	return c.contentType
+}
+
+func (c *Cover) UpdatedAt() time.Time {
+	return c.updatedAt
+}
+
+// Version tells uploads apart, so URLs of an old cover are not served from caches.
+func (c *Cover) Version() string {
+	return path.Base(c.key)
+}
+
+// RenditionKey is the blob key of a rendition.
+func (c *Cover) RenditionKey(rendition string) string {
+	return c.key + "/" + rendition
+}
+
+func (c *Cover) RenditionContentType(rendition string) string {
+	if rendition == CoverOriginal {
+		return c.contentType
+	}
+	return "image/jpeg"
+}
@@ -0,0 +1,169 @@
+package domain
+
+import (
+	"fmt"
+	"time"
+)
+
+const (
+	FormatHardcover = "hardcover"
+	FormatPaperback = "paperback"
+	FormatEPUB      = "epub"
+	FormatPDF       = "pdf"
+)
+
+// formatContentTypes maps the digital formats to the media type of their files.
+var formatContentTypes = map[string]string{
+	FormatEPUB: "application/epub+zip",
+	FormatPDF:  "application/pdf",
+}
+
+// IsFormat reports whether format is one a book can be sold in.
+func IsFormat(format string) bool {
+	switch format {
+	case FormatHardcover, FormatPaperback, FormatEPUB, FormatPDF:
+		return true
+	}
+	return false
+}
+
+// IsDigitalFormat reports whether format is delivered as a file rather than shipped.
+func IsDigitalFormat(format string) bool {
+	_, ok := formatContentTypes[format]
+	return ok
+}
+
+// FormatContentType is the media type of the files of a digital format.
+func FormatContentType(format string) string {
+	return formatContentTypes[format]
+}
+
+// BookFormat is an edition of a book sold next to the book itself, at its own price.
+// Printed formats have a stock; digital formats never run out but are only sold once
+// their file is uploaded.
+type BookFormat struct {
+	id        int
+	bookId    int
+	format    string
+	price     Money
+	stock     int
+	file      FormatFile
+	updatedAt time.Time
+}
+
+// FormatFile is the file of a digital format, kept in the blob store under its key.
+type FormatFile struct {
+	key         string
+	name        string
+	contentType string
+	size        int64
+}
+
+// NewBookFormat creates a format of a book. The stock of digital formats is ignored.
+func NewBookFormat(bookId int, format string, price Money, stock int) (BookFormat, error) {
+	if bookId <= 0 {
+		return BookFormat{}, fmt.Errorf("bookId must be a positive integer")
+	}
+	if !IsFormat(format) {
+		return BookFormat{}, fmt.Errorf("%w: %q", ErrInvalidFormat, format)
+	}
+	if !price.IsSet() {
+		return BookFormat{}, fmt.Errorf("price is required")
+	}
+	if price.IsNegative() {
+		return BookFormat{}, fmt.Errorf("price cannot be negative")
+	}
+	if stock < 0 {
+		return BookFormat{}, fmt.Errorf("stock cannot be negative")
+	}
+	if IsDigitalFormat(format) {
+		stock = 0
+	}
+	return BookFormat{bookId: bookId, format: format, price: price, stock: stock}, nil
+}
+
+func NewFormatFile(key, name, contentType string, size int64) (FormatFile, error) {
+	if key == "" || name == "" {
+		return FormatFile{}, fmt.Errorf("file key and name cannot be empty")
+	}
+	if size < 0 {
+		return FormatFile{}, fmt.Errorf("file size cannot be negative")
+	}
+	return FormatFile{key: key, name: name, contentType: contentType, size: size}, nil
+}
+
+// Getter methods
+
+func (f *BookFormat) Id() int {
+	return f.id
+}
+
+func (f *BookFormat) BookId() int {
+	return f.bookId
+}
+
+func (f *BookFormat) Format() string {
+	return f.format
+}
+
+func (f *BookFormat) Price() Money {
+	return f.price
+}
+
+// Stock is the number of printed copies in stock, always 0 for digital formats.
+func (f *BookFormat) Stock() int {
+	return f.stock
+}
+
+func (f *BookFormat) IsDigital() bool {
+	return IsDigitalFormat(f.format)
+}
+
+// File returns the file of a digital format, if one has been uploaded.
+func (f *BookFormat) File() (FormatFile, bool) {
+	return f.file, f.file.key != ""
+}
+
+func (f *BookFormat) UpdatedAt() time.Time {
+	return f.updatedAt
+}
+
+func (f *FormatFile) Key() string {
+	return f.key
+}
+
+// Name is the file name the file is downloaded as.
+func (f *FormatFile) Name() string {
+	return f.name
+}
+
+func (f *FormatFile) ContentType() string {
+	return f.contentType
+}
+
+func (f *FormatFile) Size() int64 {
+	return f.size
+}
+
+// Setter methods
+
+func (f *BookFormat) SetId(id int) error {
+	if id <= 0 {
+		return fmt.Errorf("id must be a positive integer")
+	}
+	f.id = id
+	return nil
+}
+
+func (f *BookFormat) SetFile(file FormatFile) error {
+	if !f.IsDigital() {
+		return fmt.Errorf("%w: %s is not a digital format", ErrInvalidFormat, f.format)
+	}
+	f.file = file
+	return nil
+}
+
+func (f *BookFormat) SetUpdatedAt(updatedAt time.Time) error {
+	f.updatedAt = updatedAt
+	return nil
+}
@@ -0,0 +1,187 @@
+package domain
+
+import (
+	"fmt"
+	"strings"
+	"time"
+	"toptal/internal/pkg/markdown"
+	"unicode/utf8"
+
+	"golang.org/x/text/language"
+)
+
+const (
+	MaxDescriptionLength = 10000
+	maxPageCount         = 100000
+	maxDimensionMm       = 1000
+	maxWeightGrams       = 50000
+)
+
+// BookFilter selects the books of the catalogue. Zero values match every listed book.
+type BookFilter struct {
+	CategoryIds []int
+	// Language matches books in the language and its regional variants, so "en" matches
+	// "en-GB".
+	Language string
+	// Series matches the series name, ignoring case. Books of a series are listed in order.
+	Series string
+}
+
+// BookMetadata describes a book for the storefront and for shipping. Zero values are
+// unknown.
+type BookMetadata struct {
+	description     string
+	pageCount       int
+	language        string
+	publisher       string
+	publicationDate time.Time
+	series          string
+	seriesPosition  int
+	widthMm         int
+	heightMm        int
+	depthMm         int
+	weightGrams     int
+}
+
+// Description is Markdown without raw HTML.
+func (b *Book) Description() string {
+	return b.metadata.description
+}
+
+func (b *Book) PageCount() int {
+	return b.metadata.pageCount
+}
+
+// Language is a BCP 47 tag such as "en" or "pt-BR".
+func (b *Book) Language() string {
+	return b.metadata.language
+}
+
+func (b *Book) Publisher() string {
+	return b.metadata.publisher
+}
+
+func (b *Book) PublicationDate() time.Time {
+	return b.metadata.publicationDate
+}
+
+// Series returns the series the book belongs to and its position in it, 0 when unknown.
+func (b *Book) Series() (string, int) {
+	return b.metadata.series, b.metadata.seriesPosition
+}
+
+// Dimensions returns the width, height and depth of the book in millimetres.
+func (b *Book) Dimensions() (width, height, depth int) {
+	return b.metadata.widthMm, b.metadata.heightMm, b.metadata.depthMm
+}
+
+func (b *Book) WeightGrams() int {
+	return b.metadata.weightGrams
+}
+
+// SetDescription sanitises Markdown and stores it.
+func (b *Book) SetDescription(description string) error {
+	description = markdown.Sanitize(description)
+	if utf8.RuneCountInString(description) > MaxDescriptionLength {
+		return fmt.Errorf("description cannot be longer than %d characters", MaxDescriptionLength)
+	}
+	b.metadata.description = description
+	return nil
+}
+
+func (b *Book) SetPageCount(pageCount int) error {
+	if pageCount < 0 || pageCount > maxPageCount {
+		return fmt.Errorf("page count must be between 0 and %d", maxPageCount)
+	}
+	b.metadata.pageCount = pageCount
+	return nil
+}
+
+// SetLanguage accepts a BCP 47 language tag and stores it in canonical form.
+func (b *Book) SetLanguage(tag string) error {
+	if tag == "" {
+		b.metadata.language = ""
+		return nil
+	}
+	canonical, err := NormalizeLanguage(tag)
+	if err != nil {
+		return err
+	}
+	b.metadata.language = canonical
+	return nil
+}
+
+func (b *Book) SetPublisher(publisher string) error {
+	publisher = strings.TrimSpace(publisher)
+	if utf8.RuneCountInString(publisher) > 255 {
+		return fmt.Errorf("publisher cannot be longer than 255 characters")
+	}
+	b.metadata.publisher = publisher
+	return nil
+}
+
+// SetPublicationDate keeps the date of publicationDate only.
+func (b *Book) SetPublicationDate(publicationDate time.Time) error {
+	if publicationDate.IsZero() {
+		b.metadata.publicationDate = time.Time{}
+		return nil
+	}
+	if publicationDate.Year() < 1000 || publicationDate.Year() > 2100 {
+		return fmt.Errorf("publication date must be between the years 1000 and 2100")
+	}
+	y, m, d := publicationDate.Date()
+	b.metadata.publicationDate = time.Date(y, m, d, 0, 0, 0, 0, time.UTC)
+	return nil
+}
+
+// SetSeries puts the book in a series at a position, 0 when unknown. An empty name takes
+// it out of its series.
+func (b *Book) SetSeries(name string, position int) error {
+	name = strings.TrimSpace(name)
+	if utf8.RuneCountInString(name) > 255 {
+		return fmt.Errorf("series cannot be longer than 255 characters")
+	}
+	if position < 0 {
+		return fmt.Errorf("series position cannot be negative")
+	}
+	if name == "" && position != 0 {
+		return fmt.Errorf("series position needs a series")
+	}
+	b.metadata.series = name
+	b.metadata.seriesPosition = position
+	return nil
+}
+
+// SetDimensions sets the width, height and depth of the book in millimetres. Either all
+// are known or none.
+func (b *Book) SetDimensions(width, height, depth int) error {
+	if width == 0 && height == 0 && depth == 0 {
+		b.metadata.widthMm, b.metadata.heightMm, b.metadata.depthMm = 0, 0, 0
+		return nil
+	}
+	for _, size := range []int{width, height, depth} {
+		if size <= 0 || size > maxDimensionMm {
+			return fmt.Errorf("width, height and depth must all be between 1 and %d mm", maxDimensionMm)
+		}
+	}
+	b.metadata.widthMm, b.metadata.heightMm, b.metadata.depthMm = width, height, depth
+	return nil
+}
+
+func (b *Book) SetWeightGrams(weight int) error {
+	if weight < 0 || weight > maxWeightGrams {
+		return fmt.Errorf("weight must be between 0 and %d grams", maxWeightGrams)
+	}
+	b.metadata.weightGrams = weight
+	return nil
+}
+
+// NormalizeLanguage returns the canonical form of a BCP 47 language tag, such as "pt-BR"
+// for "PT_br".
+func NormalizeLanguage(tag string) (string, error) {
+	parsed, err := language.Parse(strings.ReplaceAll(strings.TrimSpace(tag), "_", "-"))
+	if err != nil || parsed == language.Und {
+		return "", fmt.Errorf("%q is not a BCP 47 language tag", tag)
+	}
+	return parsed.String(), nil
+}
@@ -0,0 +1,52 @@
+package domain
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestNormalizeLanguage(t *testing.T) {
+	for input, expected := range map[string]string{
+		"en":         "en",
+		"EN-gb":      "en-GB",
+		"pt_BR":      "pt-BR",
+		"zh-hant-tw": "zh-Hant-TW",
+	} {
+		tag, err := NormalizeLanguage(input)
+		require.NoError(t, err, input)
+		assert.Equal(t, expected, tag, input)
+	}
+
+	for _, input := range []string{"english", "en-", "und", "12"} {
+		_, err := NormalizeLanguage(input)
+		assert.Error(t, err, input)
+	}
+}
+
+func TestBook_Metadata(t *testing.T) {
+	price, err := NewMoney(1000, "USD")
+	require.NoError(t, err)
+	book, err := NewBook(1, "Dune", 1965, "Frank Herbert", price, 3, 1)
+	require.NoError(t, err)
+
+	require.NoError(t, book.SetDescription("A <em>desert</em> planet.\r\n\r\n[More](javascript:alert(1))"))
+	assert.Equal(t, "A desert planet.\n\n[More](#)", book.Description())
+
+	assert.NoError(t, book.SetPublicationDate(time.Date(1965, 8, 1, 15, 0, 0, 0, time.Local)))
+	assert.Equal(t, time.Date(1965, 8, 1, 0, 0, 0, 0, time.UTC), book.PublicationDate())
+	assert.Error(t, book.SetPublicationDate(time.Date(2965, 1, 1, 0, 0, 0, 0, time.UTC)))
+
+	assert.NoError(t, book.SetSeries("Dune", 1))
+	assert.Error(t, book.SetSeries("", 2))
+	assert.Error(t, book.SetSeries("Dune", -1))
+
+	assert.NoError(t, book.SetDimensions(0, 0, 0))
+	assert.NoError(t, book.SetDimensions(135, 210, 40))
+	assert.Error(t, book.SetDimensions(135, 0, 40))
+
+	assert.Error(t, book.SetPageCount(-1))
+	assert.Error(t, book.SetWeightGrams(maxWeightGrams+1))
+}
@@ -0,0 +1,40 @@
+package domain
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestBook_SalePrice(t *testing.T) {
+	price, err := ParseMoney("10.00", "USD")
+	require.NoError(t, err)
+	sale, err := ParseMoney("7.50", "USD")
+	require.NoError(t, err)
+
+	tests := []struct {
+		name   string
+		endsAt time.Time
+		onSale bool
+	}{
+		{name: "running", endsAt: time.Now().Add(time.Hour), onSale: true},
+		{name: "without end", onSale: true},
+		// The price scheduler has not cleared the sale yet.
+		{name: "ended", endsAt: time.Now().Add(-time.Minute), onSale: false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			book, err := NewBook(1, "Dune", 1965, "Frank Herbert", price, 3, 1)
+			require.NoError(t, err)
+			require.NoError(t, book.SetSale(sale, tt.endsAt))
+
+			got, ok := book.SalePrice()
+			assert.Equal(t, tt.onSale, ok)
+			if tt.onSale {
+				assert.Equal(t, sale, got)
+			}
+		})
+	}
+}
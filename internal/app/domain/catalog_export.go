@@ -0,0 +1,33 @@
+package domain
+
+const (
+	ExportFormatCSV   = "csv"
+	ExportFormatJSONL = "jsonl"
+	ExportFormatXLSX  = "xlsx"
+)
+
+// BookExportFilter selects the books of a catalogue export. Zero values export every listed
+// book.
+type BookExportFilter struct {
+	CategoryIds     []int
+	InStockOnly     bool
+	IncludeArchived bool
+}
+
+// ExportedBook is a book of a catalogue export with the name of its category.
+type ExportedBook struct {
+	book         Book
+	categoryName string
+}
+
+func NewExportedBook(book Book, categoryName string) ExportedBook {
+	return ExportedBook{book: book, categoryName: categoryName}
+}
+
+func (e *ExportedBook) Book() Book {
+	return e.book
+}
+
+func (e *ExportedBook) CategoryName() string {
+	return e.categoryName
+}
@@ -0,0 +1,149 @@
+package domain
+
+import (
+	"fmt"
+	"sort"
+)
+
+const (
+	ImportFormatCSV   = "csv"
+	ImportFormatJSONL = "jsonl"
+	ImportFormatONIX  = "onix"
+)
+
+// BookImport is a valid row of a catalogue import. Line is where the row starts in the
+// imported file.
+type BookImport struct {
+	line      int
+	book      Book
+	keepStock bool
+}
+
+func NewBookImport(line int, book Book) BookImport {
+	return BookImport{line: line, book: book}
+}
+
+func (i *BookImport) Line() int {
+	return i.line
+}
+
+func (i *BookImport) Book() Book {
+	return i.book
+}
+
+// KeepsStock reports whether the row leaves the stock of a book it updates alone, because
+// the file does not know it.
+func (i *BookImport) KeepsStock() bool {
+	return i.keepStock
+}
+
+// WithoutStock returns the row with its stock unknown: books it creates start with the
+// stock of its book, books it updates keep theirs.
+func (i BookImport) WithoutStock() BookImport {
+	i.keepStock = true
+	return i
+}
+
+// BookRemoval is a row of an import that withdraws the book with an ISBN from sale.
+type BookRemoval struct {
+	line int
+	isbn string
+}
+
+func NewBookRemoval(line int, isbn string) BookRemoval {
+	return BookRemoval{line: line, isbn: isbn}
+}
+
+func (r *BookRemoval) Line() int {
+	return r.line
+}
+
+func (r *BookRemoval) ISBN() string {
+	return r.isbn
+}
+
+// ImportRowError explains why a row of an import was skipped.
+type ImportRowError struct {
+	line    int
+	message string
+}
+
+func (e *ImportRowError) Line() int {
+	return e.line
+}
+
+func (e *ImportRowError) Message() string {
+	return e.message
+}
+
+// ImportReport counts what a catalogue import did, or would do when it is a dry run,
+// and lists the rows it skipped.
+type ImportReport struct {
+	dryRun    bool
+	rows      int
+	created   int
+	updated   int
+	unchanged int
+	archived  int
+	errors    []ImportRowError
+}
+
+func NewImportReport(dryRun bool) ImportReport {
+	return ImportReport{dryRun: dryRun}
+}
+
+func (r *ImportReport) DryRun() bool {
+	return r.dryRun
+}
+
+func (r *ImportReport) Rows() int {
+	return r.rows
+}
+
+func (r *ImportReport) Created() int {
+	return r.created
+}
+
+func (r *ImportReport) Updated() int {
+	return r.updated
+}
+
+func (r *ImportReport) Unchanged() int {
+	return r.unchanged
+}
+
+func (r *ImportReport) Archived() int {
+	return r.archived
+}
+
+// Errors returns the skipped rows in the order of the file.
+func (r *ImportReport) Errors() []ImportRowError {
+	errors := append([]ImportRowError(nil), r.errors...)
+	sort.SliceStable(errors, func(i, j int) bool { return errors[i].line < errors[j].line })
+	return errors
+}
+
+// AddRow counts a row read from the file, valid or not.
+func (r *ImportReport) AddRow() {
+	r.rows++
+}
+
+func (r *ImportReport) AddCreated() {
+	r.created++
+}
+
+func (r *ImportReport) AddUpdated() {
+	r.updated++
+}
+
+func (r *ImportReport) AddUnchanged() {
+	r.unchanged++
+}
+
+func (r *ImportReport) AddArchived() {
+	r.archived++
+}
+
+func (r *ImportReport) AddError(line int, format string, args ...any) {
+	r.errors = append(r.errors, ImportRowError{line: line, message: fmt.Sprintf(format, args...)})
+}
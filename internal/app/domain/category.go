@@ -1,10 +1,21 @@
 package domain
 
-import "fmt"
+import (
+	"fmt"
+	"regexp"
+	"time"
+)
+
+// TaxClassStandard is the tax class of categories that were not given one.
+const TaxClassStandard = "standard"
+
+var taxClassPattern = regexp.MustCompile(`^[a-z][a-z0-9_]{0,31}$`)
 
 type Category struct {
-	id   int
-	name string
+	id         int
+	name       string
+	taxClass   string
+	archivedAt time.Time
 }
 
 func NewCategory(id int, name string) (Category, error) {
@@ -15,6 +26,7 @@ func NewCategory(id int, name string) (Category, error) {
 	if err := category.SetName(name); err != nil {
 		return category, err
 	}
+	category.taxClass = TaxClassStandard
 	return category, nil
 }
 
@@ -28,6 +40,17 @@ func (c *Category) Name() string {
 	return c.name
 }
 
+// TaxClass decides the tax rate of the category's books, for example "reduced" for
+// printed books in countries with a reduced VAT rate on them.
+func (c *Category) TaxClass() string {
+	return c.taxClass
+}
+
+// ArchivedAt is when the category was removed from the catalogue, zero while it is listed.
+func (c *Category) ArchivedAt() time.Time {
+	return c.archivedAt
+}
+
 // Setter methods
 
 func (c *Category) SetId(id int) error {
@@ -45,3 +68,16 @@ func (c *Category) SetName(name string) error {
 	c.name = name
 	return nil
 }
+
+func (c *Category) SetTaxClass(taxClass string) error {
+	if !taxClassPattern.MatchString(taxClass) {
+		return fmt.Errorf("invalid tax class: %q", taxClass)
+	}
+	c.taxClass = taxClass
+	return nil
+}
+
+func (c *Category) SetArchivedAt(archivedAt time.Time) error {
+	c.archivedAt = archivedAt
+	return nil
+}
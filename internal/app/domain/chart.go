@@ -0,0 +1,28 @@
+package domain
+
+// Bestseller charts count the copies sold over one of these periods, up to today.
+const (
+	ChartPeriodWeek  = "week"
+	ChartPeriodMonth = "month"
+	ChartPeriodYear  = "year"
+)
+
+// ChartPeriods lists the periods bestsellers are counted over, shortest first.
+var ChartPeriods = []string{ChartPeriodWeek, ChartPeriodMonth, ChartPeriodYear}
+
+var chartPeriodDays = map[string]int{
+	ChartPeriodWeek:  7,
+	ChartPeriodMonth: 30,
+	ChartPeriodYear:  365,
+}
+
+// ChartPeriodDays is the length of a period in days, or false for an unknown period.
+func ChartPeriodDays(period string) (int, bool) {
+	days, ok := chartPeriodDays[period]
+	return days, ok
+}
+
+func IsChartPeriod(period string) bool {
+	_, ok := chartPeriodDays[period]
+	return ok
+}
@@ -0,0 +1,134 @@
+package domain
+
+import (
+	"fmt"
+	"time"
+)
+
+// Download is a user's right to fetch the file of a digital format they bought. It can
+// be used a limited number of times, through signed links that expire.
+type Download struct {
+	id             int
+	userId         int
+	orderId        int
+	title          string
+	author         string
+	format         string
+	count          int
+	createdAt      time.Time
+	lastDownloadAt time.Time
+	file           FormatFile
+}
+
+// DownloadLink is a signed URL for a download, valid until it expires.
+type DownloadLink struct {
+	download  Download
+	url       string
+	expiresAt time.Time
+	remaining int
+}
+
+func NewDownload(
+	id int, userId int, orderId int, title string, author string, format string, count int, createdAt time.Time,
+) (Download, error) {
+	if id <= 0 {
+		return Download{}, fmt.Errorf("invalid download id: %d", id)
+	}
+	if !IsDigitalFormat(format) {
+		return Download{}, fmt.Errorf("%w: %q is not a digital format", ErrInvalidFormat, format)
+	}
+	if count < 0 {
+		return Download{}, fmt.Errorf("download count cannot be negative")
+	}
+	return Download{
+		id:        id,
+		userId:    userId,
+		orderId:   orderId,
+		title:     title,
+		author:    author,
+		format:    format,
+		count:     count,
+		createdAt: createdAt,
+	}, nil
+}
+
+// NewDownloadLink creates a link to download that works until expiresAt. remaining is the
+// number of downloads left.
+func NewDownloadLink(download Download, url string, expiresAt time.Time, remaining int) DownloadLink {
+	return DownloadLink{download: download, url: url, expiresAt: expiresAt, remaining: max(remaining, 0)}
+}
+
+// Getter methods
+
+func (d *Download) Id() int {
+	return d.id
+}
+
+func (d *Download) UserId() int {
+	return d.userId
+}
+
+func (d *Download) OrderId() int {
+	return d.orderId
+}
+
+func (d *Download) Title() string {
+	return d.title
+}
+
+func (d *Download) Author() string {
+	return d.author
+}
+
+func (d *Download) Format() string {
+	return d.format
+}
+
+// Count is the number of times the file has been downloaded.
+func (d *Download) Count() int {
+	return d.count
+}
+
+func (d *Download) CreatedAt() time.Time {
+	return d.createdAt
+}
+
+// LastDownloadAt is zero until the file is first downloaded.
+func (d *Download) LastDownloadAt() time.Time {
+	return d.lastDownloadAt
+}
+
+// File returns the file that was sold, which is kept when the format is replaced or
+// removed. It is only missing for downloads whose format was removed before files were
+// kept with them.
+func (d *Download) File() (FormatFile, bool) {
+	return d.file, d.file.key != ""
+}
+
+func (l *DownloadLink) Download() Download {
+	return l.download
+}
+
+func (l *DownloadLink) URL() string {
+	return l.url
+}
+
+func (l *DownloadLink) ExpiresAt() time.Time {
+	return l.expiresAt
+}
+
+func (l *DownloadLink) Remaining() int {
+	return l.remaining
+}
+
+// Setter methods
+
+func (d *Download) SetLastDownloadAt(lastDownloadAt time.Time) error {
+	d.lastDownloadAt = lastDownloadAt
+	return nil
+}
+
+func (d *Download) SetFile(file FormatFile) error {
+	d.file = file
+	return nil
+}
@@ -8,8 +8,59 @@ var (
 	ErrForbidden       = errors.New("forbidden")
 	ErrAlreadyExists   = errors.New("already exists")
 	ErrInvalidCategory = errors.New("invalid category")
+	ErrCategoryInUse   = errors.New("category has books")
 	ErrBookNotFound    = errors.New("book not found")
 	ErrBookOutOfStock  = errors.New("book out of stock")
 	ErrBookNotInCart   = errors.New("book not in cart")
 	ErrCartEmpty       = errors.New("cart is empty")
+	ErrInvalidAPIKey   = errors.New("invalid api key")
+
+	ErrInvalidTwoFactorCode    = errors.New("invalid two-factor code")
+	ErrTwoFactorAlreadyEnabled = errors.New("two-factor authentication already enabled")
+	ErrTwoFactorNotEnabled     = errors.New("two-factor authentication not enabled")
+
+	ErrInvalidToken  = errors.New("invalid or expired token")
+	ErrWeakPassword  = errors.New("password does not meet the policy")
+	ErrWrongPassword = errors.New("wrong password")
+	ErrEmailNotSet   = errors.New("email not set")
+	ErrEmailVerified = errors.New("email already verified")
+
+	ErrSessionRevoked = errors.New("session revoked or expired")
+	// ErrReauthenticationRequired is returned to users without a password whose login is
+	// too old to confirm a sensitive change.
+	ErrReauthenticationRequired = errors.New("log in again to confirm")
+
+	ErrInvalidPriceChange = errors.New("invalid price change")
+	ErrSaleOverlap        = errors.New("sale overlaps another sale of the book")
+	ErrPriceChangeClosed  = errors.New("price change already applied or cancelled")
+
+	ErrInvalidMoney     = errors.New("invalid money amount")
+	ErrCurrencyMismatch = errors.New("currencies do not match")
+	// ErrPriceUnavailable is returned when a book has no price in the requested currency.
+	ErrPriceUnavailable = errors.New("price not available in currency")
+
+	// ErrInvalidImport is returned for import files that cannot be read at all, as opposed
+	// to single rows that are invalid.
+	ErrInvalidImport = errors.New("invalid import file")
+	ErrInvalidExport = errors.New("invalid export format")
+
+	ErrInvalidFormat = errors.New("invalid book format")
+	// ErrFormatFileMissing is returned for digital formats that cannot be sold yet because
+	// their file has not been uploaded.
+	ErrFormatFileMissing    = errors.New("digital format has no file")
+	ErrFileTooLarge         = errors.New("file too large")
+	ErrInvalidDownloadLink  = errors.New("invalid or expired download link")
+	ErrDownloadLimitReached = errors.New("download limit reached")
+
+	// ErrInvalidImage is returned for uploaded images that cannot be decoded or are not of
+	// a supported type.
+	ErrInvalidImage = errors.New("invalid image")
+
+	ErrInvalidReport = errors.New("invalid report")
+
+	ErrSupplierInUse        = errors.New("supplier has purchase orders")
+	ErrInvalidPurchaseOrder = errors.New("invalid purchase order")
+	// ErrPurchaseOrderStatus is returned for changes the status of a purchase order does
+	// not allow, such as editing one that was sent.
+	ErrPurchaseOrderStatus = errors.New("not allowed in the purchase order's status")
 )
@@ -0,0 +1,64 @@
+package domain
+
+import (
+	"fmt"
+	"strings"
+)
+
+// NormalizeISBN checks an ISBN-10 or ISBN-13, written with or without hyphens and spaces,
+// and returns it as the 13 digits of an ISBN-13.
+func NormalizeISBN(isbn string) (string, error) {
+	digits := strings.Map(func(r rune) rune {
+		if r == '-' || r == ' ' {
+			return -1
+		}
+		return r
+	}, strings.ToUpper(isbn))
+
+	switch len(digits) {
+	case 10:
+		if !isbn10Valid(digits) {
+			return "", fmt.Errorf("invalid ISBN-10: %q", isbn)
+		}
+		body := "978" + digits[:9]
+		return body + isbn13CheckDigit(body), nil
+	case 13:
+		if !isDigits(digits) || isbn13CheckDigit(digits[:12]) != digits[12:] {
+			return "", fmt.Errorf("invalid ISBN-13: %q", isbn)
+		}
+		return digits, nil
+	default:
+		return "", fmt.Errorf("invalid ISBN: %q", isbn)
+	}
+}
+
+func isbn10Valid(digits string) bool {
+	if !isDigits(digits[:9]) {
+		return false
+	}
+	sum := 0
+	for i := 0; i < 9; i++ {
+		sum += int(digits[i]-'0') * (10 - i)
+	}
+	switch check := digits[9]; {
+	case check == 'X':
+		sum += 10
+	case check >= '0' && check <= '9':
+		sum += int(check - '0')
+	default:
+		return false
+	}
+	return sum%11 == 0
+}
+
+func isbn13CheckDigit(body string) string {
+	sum := 0
+	for i := 0; i < 12; i++ {
+		weight := 1
+		if i%2 == 1 {
+			weight = 3
+		}
+		sum += int(body[i]-'0') * weight
+	}
+	return fmt.Sprint((10 - sum%10) % 10)
+}
@@ -0,0 +1,26 @@
+package domain
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestNormalizeISBN(t *testing.T) {
+	for input, expected := range map[string]string{
+		"978-0-441-17271-9": "9780441172719",
+		"9780441172719":     "9780441172719",
+		"0-441-17271-7":     "9780441172719",
+		"0-8044-2957-x":     "9780804429573",
+	} {
+		isbn, err := NormalizeISBN(input)
+		require.NoError(t, err, input)
+		assert.Equal(t, expected, isbn, input)
+	}
+
+	for _, input := range []string{"", "978-0-441-17271-8", "0-441-17271-6", "97804411727", "97804411727X9"} {
+		_, err := NormalizeISBN(input)
+		assert.Error(t, err, input)
+	}
+}
@@ -0,0 +1,29 @@
+package domain
+
+// LoginResult is the outcome of the password step of a login. Users with two-factor
+// authentication get a challenge token that must be exchanged together with a code
+// for the access token.
+type LoginResult struct {
+	token          string
+	challengeToken string
+}
+
+func NewTokenLoginResult(token string) LoginResult {
+	return LoginResult{token: token}
+}
+
+func NewChallengeLoginResult(challengeToken string) LoginResult {
+	return LoginResult{challengeToken: challengeToken}
+}
+
+func (r *LoginResult) Token() string {
+	return r.token
+}
+
+func (r *LoginResult) ChallengeToken() string {
+	return r.challengeToken
+}
+
+func (r *LoginResult) TwoFactorRequired() bool {
+	return r.challengeToken != ""
+}
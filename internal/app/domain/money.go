@@ -0,0 +1,196 @@
+package domain
+
+import (
+	"encoding/json"
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+)
+
+// currencyExponents maps the ISO 4217 codes we accept to the number of digits of their
+// minor unit.
+var currencyExponents = map[string]int{
+	"AED": 2, "AUD": 2, "BGN": 2, "BHD": 3, "BRL": 2, "CAD": 2, "CHF": 2, "CLP": 0,
+	"CNY": 2, "CZK": 2, "DKK": 2, "EUR": 2, "GBP": 2, "HKD": 2, "HUF": 2, "IDR": 2,
+	"ILS": 2, "INR": 2, "ISK": 0, "JOD": 3, "JPY": 0, "KRW": 0, "KWD": 3, "MXN": 2,
+	"MYR": 2, "NOK": 2, "NZD": 2, "OMR": 3, "PHP": 2, "PLN": 2, "RON": 2, "RSD": 2,
+	"SAR": 2, "SEK": 2, "SGD": 2, "THB": 2, "TND": 3, "TRY": 2, "TWD": 2, "UAH": 2,
+	"USD": 2, "VND": 0, "ZAR": 2,
+}
+
+// Money is an amount in the minor unit of an ISO 4217 currency, for example cents for
+// USD. The zero value has no currency and stands for a missing amount.
+type Money struct {
+	amount   int64
+	currency string
+}
+
+func NewMoney(amount int64, currency string) (Money, error) {
+	if _, ok := currencyExponents[currency]; !ok {
+		return Money{}, fmt.Errorf("%w: unknown currency %q", ErrInvalidMoney, currency)
+	}
+	return Money{amount: amount, currency: currency}, nil
+}
+
+// ParseMoney reads a decimal amount such as "12.50" in the given currency. It refuses
+// more decimals than the currency's minor unit has rather than rounding.
+func ParseMoney(value string, currency string) (Money, error) {
+	exponent, ok := currencyExponents[currency]
+	if !ok {
+		return Money{}, fmt.Errorf("%w: unknown currency %q", ErrInvalidMoney, currency)
+	}
+
+	negative := strings.HasPrefix(value, "-")
+	units, fraction, hasFraction := strings.Cut(strings.TrimPrefix(value, "-"), ".")
+	if units == "" || (hasFraction && fraction == "") || len(fraction) > exponent ||
+		!isDigits(units) || !isDigits(fraction) {
+		return Money{}, fmt.Errorf("%w: %q is not an amount in %s", ErrInvalidMoney, value, currency)
+	}
+	fraction += strings.Repeat("0", exponent-len(fraction))
+
+	amount, err := strconv.ParseInt(units+fraction, 10, 64)
+	if err != nil {
+		return Money{}, fmt.Errorf("%w: %q is out of range", ErrInvalidMoney, value)
+	}
+	if negative {
+		amount = -amount
+	}
+	return Money{amount: amount, currency: currency}, nil
+}
+
+func isDigits(s string) bool {
+	for _, r := range s {
+		if r < '0' || r > '9' {
+			return false
+		}
+	}
+	return true
+}
+
+// IsCurrency reports whether code is an ISO 4217 currency we accept.
+func IsCurrency(code string) bool {
+	_, ok := currencyExponents[code]
+	return ok
+}
+
+// Amount is the amount in minor units.
+func (m Money) Amount() int64 {
+	return m.amount
+}
+
+func (m Money) Currency() string {
+	return m.currency
+}
+
+// IsSet reports whether the money has a currency, that is, whether it is not the zero value.
+func (m Money) IsSet() bool {
+	return m.currency != ""
+}
+
+func (m Money) IsNegative() bool {
+	return m.amount < 0
+}
+
+func (m Money) Add(other Money) (Money, error) {
+	if err := m.sameCurrency(other); err != nil {
+		return Money{}, err
+	}
+	sum := m.amount + other.amount
+	if (other.amount > 0 && sum < m.amount) || (other.amount < 0 && sum > m.amount) {
+		return Money{}, fmt.Errorf("%w: overflow", ErrInvalidMoney)
+	}
+	return Money{amount: sum, currency: m.currency}, nil
+}
+
+func (m Money) Sub(other Money) (Money, error) {
+	if other.amount == math.MinInt64 {
+		return Money{}, fmt.Errorf("%w: overflow", ErrInvalidMoney)
+	}
+	return m.Add(Money{amount: -other.amount, currency: other.currency})
+}
+
+// Mul multiplies the amount, for example by a quantity.
+func (m Money) Mul(factor int64) (Money, error) {
+	if m.amount == 0 || factor == 0 {
+		return Money{amount: 0, currency: m.currency}, nil
+	}
+	// The division cannot detect MinInt64 times -1, which wraps to MinInt64 again.
+	if (m.amount == -1 && factor == math.MinInt64) || (m.amount == math.MinInt64 && factor == -1) {
+		return Money{}, fmt.Errorf("%w: overflow", ErrInvalidMoney)
+	}
+	product := m.amount * factor
+	if product/factor != m.amount {
+		return Money{}, fmt.Errorf("%w: overflow", ErrInvalidMoney)
+	}
+	return Money{amount: product, currency: m.currency}, nil
+}
+
+func (m Money) sameCurrency(other Money) error {
+	if m.currency != other.currency {
+		return fmt.Errorf("%w: %s and %s", ErrCurrencyMismatch, m.currency, other.currency)
+	}
+	return nil
+}
+
+// Decimal formats the amount in major units, such as "12.50".
+func (m Money) Decimal() string {
+	exponent := currencyExponents[m.currency]
+	digits := strconv.FormatUint(absAmount(m.amount), 10)
+	if len(digits) <= exponent {
+		digits = strings.Repeat("0", exponent-len(digits)+1) + digits
+	}
+	sign := ""
+	if m.amount < 0 {
+		sign = "-"
+	}
+	if exponent == 0 {
+		return sign + digits
+	}
+	return sign + digits[:len(digits)-exponent] + "." + digits[len(digits)-exponent:]
+}
+
+func absAmount(amount int64) uint64 {
+	if amount < 0 {
+		return uint64(-(amount + 1)) + 1
+	}
+	return uint64(amount)
+}
+
+func (m Money) String() string {
+	if !m.IsSet() {
+		return ""
+	}
+	return m.Decimal() + " " + m.currency
+}
+
+type moneyJSON struct {
+	Amount   string `json:"amount"`
+	Currency string `json:"currency"`
+}
+
+// MarshalJSON encodes the amount as a decimal string so clients never see binary floats,
+// for example {"amount":"12.50","currency":"EUR"}.
+func (m Money) MarshalJSON() ([]byte, error) {
+	if !m.IsSet() {
+		return []byte("null"), nil
+	}
+	return json.Marshal(moneyJSON{Amount: m.Decimal(), Currency: m.currency})
+}
+
+func (m *Money) UnmarshalJSON(data []byte) error {
+	if string(data) == "null" {
+		*m = Money{}
+		return nil
+	}
+	var value moneyJSON
+	if err := json.Unmarshal(data, &value); err != nil {
+		return fmt.Errorf("%w: expected an object with a decimal string amount and a currency", ErrInvalidMoney)
+	}
+	parsed, err := ParseMoney(value.Amount, value.Currency)
+	if err != nil {
+		return err
+	}
+	*m = parsed
+	return nil
+}
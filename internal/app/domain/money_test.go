@@ -0,0 +1,137 @@
+package domain
+
+import (
+	"encoding/json"
+	"math"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseMoney(t *testing.T) {
+	tests := []struct {
+		value    string
+		currency string
+		amount   int64
+		wantErr  bool
+	}{
+		{"12.50", "EUR", 1250, false},
+		{"12.5", "EUR", 1250, false},
+		{"12", "USD", 1200, false},
+		{"0.07", "USD", 7, false},
+		{"-3.10", "USD", -310, false},
+		{"1500", "JPY", 1500, false},
+		{"1.234", "KWD", 1234, false},
+		{"12.345", "EUR", 0, true},
+		{"12.", "EUR", 0, true},
+		{".50", "EUR", 0, true},
+		{"1e3", "EUR", 0, true},
+		{"12.50", "XXX", 0, true},
+		{"99999999999999999999", "USD", 0, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.value+" "+tt.currency, func(t *testing.T) {
+			money, err := ParseMoney(tt.value, tt.currency)
+			if tt.wantErr {
+				assert.ErrorIs(t, err, ErrInvalidMoney)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, tt.amount, money.Amount())
+			assert.Equal(t, tt.currency, money.Currency())
+		})
+	}
+}
+
+func TestMoney_Decimal(t *testing.T) {
+	for amount, want := range map[int64]string{0: "0.00", 7: "0.07", 1250: "12.50", -310: "-3.10"} {
+		money, err := NewMoney(amount, "EUR")
+		require.NoError(t, err)
+		assert.Equal(t, want, money.Decimal())
+	}
+	yen, err := NewMoney(1500, "JPY")
+	require.NoError(t, err)
+	assert.Equal(t, "1500", yen.Decimal())
+	lowest, err := NewMoney(math.MinInt64, "USD")
+	require.NoError(t, err)
+	assert.Equal(t, "-92233720368547758.08", lowest.Decimal())
+}
+
+func TestMoney_Arithmetic(t *testing.T) {
+	a, _ := NewMoney(1250, "EUR")
+	b, _ := NewMoney(99, "EUR")
+	usd, _ := NewMoney(100, "USD")
+
+	sum, err := a.Add(b)
+	require.NoError(t, err)
+	assert.Equal(t, int64(1349), sum.Amount())
+
+	diff, err := b.Sub(a)
+	require.NoError(t, err)
+	assert.Equal(t, int64(-1151), diff.Amount())
+
+	product, err := a.Mul(3)
+	require.NoError(t, err)
+	assert.Equal(t, int64(3750), product.Amount())
+
+	_, err = a.Add(usd)
+	assert.ErrorIs(t, err, ErrCurrencyMismatch)
+
+	highest, _ := NewMoney(math.MaxInt64, "EUR")
+	_, err = highest.Add(b)
+	assert.ErrorIs(t, err, ErrInvalidMoney)
+	_, err = highest.Mul(2)
+	assert.ErrorIs(t, err, ErrInvalidMoney)
+}
+
+func TestMoney_MulOverflow(t *testing.T) {
+	tests := []struct {
+		name   string
+		amount int64
+		factor int64
+		want   int64
+		err    bool
+	}{
+		{name: "largest product", amount: math.MaxInt64, factor: 1, want: math.MaxInt64},
+		{name: "smallest product", amount: math.MinInt64, factor: 1, want: math.MinInt64},
+		{name: "negated largest", amount: math.MaxInt64, factor: -1, want: -math.MaxInt64},
+		{name: "twice the largest", amount: math.MaxInt64, factor: 2, err: true},
+		{name: "minus one times smallest", amount: -1, factor: math.MinInt64, err: true},
+		{name: "smallest times minus one", amount: math.MinInt64, factor: -1, err: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			money, err := NewMoney(tt.amount, "EUR")
+			require.NoError(t, err)
+
+			product, err := money.Mul(tt.factor)
+			if tt.err {
+				assert.ErrorIs(t, err, ErrInvalidMoney)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, tt.want, product.Amount())
+		})
+	}
+}
+
+func TestMoney_JSON(t *testing.T) {
+	money, _ := NewMoney(1250, "EUR")
+	data, err := json.Marshal(money)
+	require.NoError(t, err)
+	assert.JSONEq(t, `{"amount":"12.50","currency":"EUR"}`, string(data))
+
+	var decoded Money
+	require.NoError(t, json.Unmarshal(data, &decoded))
+	assert.Equal(t, money, decoded)
+
+	assert.ErrorIs(t, json.Unmarshal([]byte(`{"amount":12.5,"currency":"EUR"}`), &decoded), ErrInvalidMoney)
+	assert.ErrorIs(t, json.Unmarshal([]byte(`{"amount":"12.50","currency":"eur"}`), &decoded), ErrInvalidMoney)
+
+	data, err = json.Marshal(struct {
+		Price Money `json:"price"`
+	}{})
+	require.NoError(t, err)
+	assert.JSONEq(t, `{"price":null}`, string(data))
+}
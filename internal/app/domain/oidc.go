@@ -0,0 +1,80 @@
+package domain
+
+import (
+	"fmt"
+	"time"
+)
+
+// OIDCAuthRequest is a login waiting for the provider's callback. Only a hash of the
+// state is stored; the nonce and PKCE verifier are needed to finish the login.
+type OIDCAuthRequest struct {
+	provider     string
+	stateHash    string
+	nonce        string
+	codeVerifier string
+	expiresAt    time.Time
+}
+
+func NewOIDCAuthRequest(provider string, stateHash string, nonce string, codeVerifier string, expiresAt time.Time) (OIDCAuthRequest, error) {
+	if provider == "" || stateHash == "" || nonce == "" || codeVerifier == "" {
+		return OIDCAuthRequest{}, fmt.Errorf("incomplete oidc auth request")
+	}
+	return OIDCAuthRequest{
+		provider:     provider,
+		stateHash:    stateHash,
+		nonce:        nonce,
+		codeVerifier: codeVerifier,
+		expiresAt:    expiresAt,
+	}, nil
+}
+
+func (r *OIDCAuthRequest) Provider() string {
+	return r.provider
+}
+
+func (r *OIDCAuthRequest) StateHash() string {
+	return r.stateHash
+}
+
+func (r *OIDCAuthRequest) Nonce() string {
+	return r.nonce
+}
+
+func (r *OIDCAuthRequest) CodeVerifier() string {
+	return r.codeVerifier
+}
+
+func (r *OIDCAuthRequest) ExpiresAt() time.Time {
+	return r.expiresAt
+}
+
+// UserIdentity links a user to the subject identifier of an external provider.
+type UserIdentity struct {
+	userId   int
+	provider string
+	subject  string
+	email    string
+}
+
+func NewUserIdentity(userId int, provider string, subject string, email string) (UserIdentity, error) {
+	if provider == "" || subject == "" {
+		return UserIdentity{}, fmt.Errorf("identity needs a provider and a subject")
+	}
+	return UserIdentity{userId: userId, provider: provider, subject: subject, email: email}, nil
+}
+
+func (i *UserIdentity) UserId() int {
+	return i.userId
+}
+
+func (i *UserIdentity) Provider() string {
+	return i.provider
+}
+
+func (i *UserIdentity) Subject() string {
+	return i.subject
+}
+
+func (i *UserIdentity) Email() string {
+	return i.email
+}
@@ -0,0 +1,278 @@
+package domain
+
+import (
+	"fmt"
+	"time"
+)
+
+// Order is a completed purchase. Its items keep a copy of the book details at the
+// time of purchase.
+type Order struct {
+	id        int
+	userId    int
+	total     Money
+	createdAt time.Time
+	items     []OrderItem
+	// subtotal adds up the item prices as listed, with or without tax as pricesIncludeTax says.
+	subtotal         Money
+	taxTotal         Money
+	pricesIncludeTax bool
+	taxLines         []OrderTaxLine
+	shippingAddress  ShippingAddress
+}
+
+type OrderItem struct {
+	bookId   int
+	title    string
+	author   string
+	price    Money
+	taxClass string
+	tax      Money
+	formatId int
+	format   string
+}
+
+// OrderTaxLine is the tax on all items of an order taxed in the same class at the same rate.
+type OrderTaxLine struct {
+	taxClass    string
+	basisPoints int
+	net         Money
+	tax         Money
+}
+
+// OrderTax is the result of taxing the items of an order before it is placed.
+type OrderTax struct {
+	items            []OrderItem
+	lines            []OrderTaxLine
+	subtotal         Money
+	taxTotal         Money
+	total            Money
+	pricesIncludeTax bool
+}
+
+func NewOrder(id int, userId int, total Money, createdAt time.Time, items []OrderItem) (Order, error) {
+	if id <= 0 {
+		return Order{}, fmt.Errorf("invalid order id: %d", id)
+	}
+	if total.IsNegative() {
+		return Order{}, fmt.Errorf("order total cannot be negative")
+	}
+	return Order{id: id, userId: userId, total: total, createdAt: createdAt, items: items}, nil
+}
+
+// NewOrderItem creates an order line. bookId is 0 once the book has been deleted.
+func NewOrderItem(bookId int, title string, author string, price Money) (OrderItem, error) {
+	if title == "" {
+		return OrderItem{}, fmt.Errorf("order item title cannot be empty")
+	}
+	if price.IsNegative() {
+		return OrderItem{}, fmt.Errorf("order item price cannot be negative")
+	}
+	return OrderItem{bookId: bookId, title: title, author: author, price: price, taxClass: TaxClassStandard}, nil
+}
+
+// NewOrderTaxLine creates a tax line. basisPoints is the rate in hundredths of a percent.
+func NewOrderTaxLine(taxClass string, basisPoints int, net Money, tax Money) (OrderTaxLine, error) {
+	if !taxClassPattern.MatchString(taxClass) {
+		return OrderTaxLine{}, fmt.Errorf("invalid tax class: %q", taxClass)
+	}
+	if basisPoints < 0 {
+		return OrderTaxLine{}, fmt.Errorf("tax rate cannot be negative")
+	}
+	if net.IsNegative() || tax.IsNegative() {
+		return OrderTaxLine{}, fmt.Errorf("tax line amounts cannot be negative")
+	}
+	if net.Currency() != tax.Currency() {
+		return OrderTaxLine{}, ErrCurrencyMismatch
+	}
+	return OrderTaxLine{taxClass: taxClass, basisPoints: basisPoints, net: net, tax: tax}, nil
+}
+
+// NewOrderTax records the taxed items of an order and its totals. total is what the
+// customer pays: the subtotal, plus the tax unless prices already include it.
+func NewOrderTax(
+	items []OrderItem, lines []OrderTaxLine, subtotal, taxTotal, total Money, pricesIncludeTax bool,
+) (OrderTax, error) {
+	if subtotal.IsNegative() || taxTotal.IsNegative() || total.IsNegative() {
+		return OrderTax{}, fmt.Errorf("order totals cannot be negative")
+	}
+	if subtotal.Currency() != taxTotal.Currency() || subtotal.Currency() != total.Currency() {
+		return OrderTax{}, ErrCurrencyMismatch
+	}
+	return OrderTax{
+		items:            items,
+		lines:            lines,
+		subtotal:         subtotal,
+		taxTotal:         taxTotal,
+		total:            total,
+		pricesIncludeTax: pricesIncludeTax,
+	}, nil
+}
+
+// Getter methods
+
+func (o *Order) Id() int {
+	return o.id
+}
+
+func (o *Order) UserId() int {
+	return o.userId
+}
+
+func (o *Order) Total() Money {
+	return o.total
+}
+
+func (o *Order) CreatedAt() time.Time {
+	return o.createdAt
+}
+
+func (o *Order) Items() []OrderItem {
+	return o.items
+}
+
+func (o *Order) Subtotal() Money {
+	return o.subtotal
+}
+
+func (o *Order) TaxTotal() Money {
+	return o.taxTotal
+}
+
+// PricesIncludeTax reports whether the item prices and subtotal already contain the tax.
+func (o *Order) PricesIncludeTax() bool {
+	return o.pricesIncludeTax
+}
+
+func (o *Order) TaxLines() []OrderTaxLine {
+	return o.taxLines
+}
+
+// ShippingAddress is unset on orders placed before addresses were recorded.
+func (o *Order) ShippingAddress() ShippingAddress {
+	return o.shippingAddress
+}
+
+func (i *OrderItem) BookId() int {
+	return i.bookId
+}
+
+func (i *OrderItem) Title() string {
+	return i.title
+}
+
+func (i *OrderItem) Author() string {
+	return i.author
+}
+
+func (i *OrderItem) Price() Money {
+	return i.price
+}
+
+func (i *OrderItem) TaxClass() string {
+	return i.taxClass
+}
+
+// Tax is the tax on the item, contained in its price or added to it.
+func (i *OrderItem) Tax() Money {
+	return i.tax
+}
+
+// FormatId is the format the item was bought in, 0 for the book itself or once the
+// format has been deleted.
+func (i *OrderItem) FormatId() int {
+	return i.formatId
+}
+
+// Format is the format the item was bought in, empty for the book itself.
+func (i *OrderItem) Format() string {
+	return i.format
+}
+
+func (l *OrderTaxLine) TaxClass() string {
+	return l.taxClass
+}
+
+func (l *OrderTaxLine) BasisPoints() int {
+	return l.basisPoints
+}
+
+func (l *OrderTaxLine) Net() Money {
+	return l.net
+}
+
+func (l *OrderTaxLine) Tax() Money {
+	return l.tax
+}
+
+func (t *OrderTax) Items() []OrderItem {
+	return t.items
+}
+
+func (t *OrderTax) Lines() []OrderTaxLine {
+	return t.lines
+}
+
+func (t *OrderTax) Subtotal() Money {
+	return t.subtotal
+}
+
+func (t *OrderTax) TaxTotal() Money {
+	return t.taxTotal
+}
+
+func (t *OrderTax) Total() Money {
+	return t.total
+}
+
+func (t *OrderTax) PricesIncludeTax() bool {
+	return t.pricesIncludeTax
+}
+
+// Setter methods
+
+func (o *Order) SetTax(subtotal, taxTotal Money, pricesIncludeTax bool, lines []OrderTaxLine) error {
+	if subtotal.IsNegative() || taxTotal.IsNegative() {
+		return fmt.Errorf("order totals cannot be negative")
+	}
+	o.subtotal = subtotal
+	o.taxTotal = taxTotal
+	o.pricesIncludeTax = pricesIncludeTax
+	o.taxLines = lines
+	return nil
+}
+
+func (o *Order) SetShippingAddress(address ShippingAddress) error {
+	o.shippingAddress = address
+	return nil
+}
+
+func (i *OrderItem) SetTaxClass(taxClass string) error {
+	if !taxClassPattern.MatchString(taxClass) {
+		return fmt.Errorf("invalid tax class: %q", taxClass)
+	}
+	i.taxClass = taxClass
+	return nil
+}
+
+func (i *OrderItem) SetTax(tax Money) error {
+	if tax.IsNegative() {
+		return fmt.Errorf("order item tax cannot be negative")
+	}
+	if tax.Currency() != i.price.Currency() {
+		return ErrCurrencyMismatch
+	}
+	i.tax = tax
+	return nil
+}
+
+// SetFormat records the format the item was bought in. formatId is 0 once the format has
+// been deleted.
+func (i *OrderItem) SetFormat(formatId int, format string) error {
+	if format != "" && !IsFormat(format) {
+		return fmt.Errorf("%w: %q", ErrInvalidFormat, format)
+	}
+	i.formatId = formatId
+	i.format = format
+	return nil
+}
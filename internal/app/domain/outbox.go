@@ -0,0 +1,66 @@
+package domain
+
+import "fmt"
+
+// OutboxEmail is an email queued in the same transaction as the change that caused it.
+type OutboxEmail struct {
+	id        int
+	recipient string
+	subject   string
+	body      string
+	attempts  int
+}
+
+func NewOutboxEmail(recipient string, subject string, body string) (OutboxEmail, error) {
+	email := OutboxEmail{}
+	if recipient == "" {
+		return email, fmt.Errorf("outbox email recipient cannot be empty")
+	}
+	if subject == "" {
+		return email, fmt.Errorf("outbox email subject cannot be empty")
+	}
+	email.recipient = recipient
+	email.subject = subject
+	email.body = body
+	return email, nil
+}
+
+// Getter methods
+
+func (e *OutboxEmail) Id() int {
+	return e.id
+}
+
+func (e *OutboxEmail) Recipient() string {
+	return e.recipient
+}
+
+func (e *OutboxEmail) Subject() string {
+	return e.subject
+}
+
+func (e *OutboxEmail) Body() string {
+	return e.body
+}
+
+func (e *OutboxEmail) Attempts() int {
+	return e.attempts
+}
+
+// Setter methods
+
+func (e *OutboxEmail) SetId(id int) error {
+	if id <= 0 {
+		return fmt.Errorf("invalid outbox email id: %d", id)
+	}
+	e.id = id
+	return nil
+}
+
+func (e *OutboxEmail) SetAttempts(attempts int) error {
+	if attempts < 0 {
+		return fmt.Errorf("attempts cannot be negative")
+	}
+	e.attempts = attempts
+	return nil
+}
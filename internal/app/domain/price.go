@@ -0,0 +1,239 @@
+package domain
+
+import (
+	"fmt"
+	"time"
+)
+
+const (
+	PriceChangeScheduled = "scheduled"
+	PriceChangeActive    = "active"
+	PriceChangeApplied   = "applied"
+	PriceChangeEnded     = "ended"
+	PriceChangeCancelled = "cancelled"
+
+	// Reasons recorded in the price history.
+	PriceReasonInitial    = "initial"
+	PriceReasonCreate     = "create"
+	PriceReasonUpdate     = "update"
+	PriceReasonScheduled  = "scheduled"
+	PriceReasonSaleStart  = "sale_start"
+	PriceReasonSaleEnd    = "sale_end"
+	PriceReasonSaleCancel = "sale_cancel"
+)
+
+// PriceChange is a price an admin scheduled for a book. Without an end time it replaces
+// the book's price once it starts; with one it is a sale shown next to the regular price
+// until it ends.
+type PriceChange struct {
+	id          int
+	bookId      int
+	price       Money
+	startsAt    time.Time
+	endsAt      time.Time
+	createdBy   int
+	createdAt   time.Time
+	appliedAt   time.Time
+	endedAt     time.Time
+	cancelledAt time.Time
+}
+
+func NewPriceChange(bookId int, price Money, startsAt time.Time, endsAt time.Time, createdBy int) (PriceChange, error) {
+	change := PriceChange{}
+	if err := change.SetBookId(bookId); err != nil {
+		return change, err
+	}
+	if err := change.SetPrice(price); err != nil {
+		return change, err
+	}
+	if err := change.SetPeriod(startsAt, endsAt); err != nil {
+		return change, err
+	}
+	if err := change.SetCreatedBy(createdBy); err != nil {
+		return change, err
+	}
+	return change, nil
+}
+
+// Getter methods
+
+func (c *PriceChange) Id() int {
+	return c.id
+}
+
+func (c *PriceChange) BookId() int {
+	return c.bookId
+}
+
+func (c *PriceChange) Price() Money {
+	return c.price
+}
+
+func (c *PriceChange) StartsAt() time.Time {
+	return c.startsAt
+}
+
+func (c *PriceChange) EndsAt() time.Time {
+	return c.endsAt
+}
+
+func (c *PriceChange) CreatedBy() int {
+	return c.createdBy
+}
+
+func (c *PriceChange) CreatedAt() time.Time {
+	return c.createdAt
+}
+
+func (c *PriceChange) AppliedAt() time.Time {
+	return c.appliedAt
+}
+
+func (c *PriceChange) EndedAt() time.Time {
+	return c.endedAt
+}
+
+func (c *PriceChange) CancelledAt() time.Time {
+	return c.cancelledAt
+}
+
+func (c *PriceChange) IsSale() bool {
+	return !c.endsAt.IsZero()
+}
+
+// Status is what the scheduler has done with the change so far.
+func (c *PriceChange) Status() string {
+	switch {
+	case !c.cancelledAt.IsZero():
+		return PriceChangeCancelled
+	case !c.endedAt.IsZero():
+		return PriceChangeEnded
+	case c.appliedAt.IsZero():
+		return PriceChangeScheduled
+	case c.IsSale():
+		return PriceChangeActive
+	default:
+		return PriceChangeApplied
+	}
+}
+
+// Setter methods
+
+func (c *PriceChange) SetId(id int) error {
+	if id <= 0 {
+		return fmt.Errorf("invalid price change id: %d", id)
+	}
+	c.id = id
+	return nil
+}
+
+func (c *PriceChange) SetBookId(bookId int) error {
+	if bookId <= 0 {
+		return fmt.Errorf("invalid book id: %d", bookId)
+	}
+	c.bookId = bookId
+	return nil
+}
+
+func (c *PriceChange) SetPrice(price Money) error {
+	if !price.IsSet() {
+		return fmt.Errorf("price is required")
+	}
+	if price.IsNegative() {
+		return fmt.Errorf("price cannot be negative")
+	}
+	c.price = price
+	return nil
+}
+
+// SetPeriod sets when the change starts and, for a sale, when it ends.
+func (c *PriceChange) SetPeriod(startsAt time.Time, endsAt time.Time) error {
+	if startsAt.IsZero() {
+		return fmt.Errorf("start time is required")
+	}
+	if !endsAt.IsZero() && !endsAt.After(startsAt) {
+		return fmt.Errorf("end time must be after the start time")
+	}
+	c.startsAt = startsAt
+	c.endsAt = endsAt
+	return nil
+}
+
+// SetCreatedBy records the admin who scheduled the change; zero when made with an API key.
+func (c *PriceChange) SetCreatedBy(userId int) error {
+	if userId < 0 {
+		return fmt.Errorf("invalid price change owner id: %d", userId)
+	}
+	c.createdBy = userId
+	return nil
+}
+
+func (c *PriceChange) SetCreatedAt(createdAt time.Time) error {
+	c.createdAt = createdAt
+	return nil
+}
+
+func (c *PriceChange) SetAppliedAt(appliedAt time.Time) error {
+	c.appliedAt = appliedAt
+	return nil
+}
+
+func (c *PriceChange) SetEndedAt(endedAt time.Time) error {
+	c.endedAt = endedAt
+	return nil
+}
+
+func (c *PriceChange) SetCancelledAt(cancelledAt time.Time) error {
+	c.cancelledAt = cancelledAt
+	return nil
+}
+
+// PriceHistoryEntry is the price of a book after a change, with the reason it changed.
+type PriceHistoryEntry struct {
+	bookId        int
+	price         Money
+	salePrice     Money
+	onSale        bool
+	reason        string
+	priceChangeId int
+	changedAt     time.Time
+}
+
+func NewPriceHistoryEntry(
+	bookId int, price Money, salePrice Money, onSale bool, reason string, priceChangeId int, changedAt time.Time,
+) PriceHistoryEntry {
+	return PriceHistoryEntry{
+		bookId:        bookId,
+		price:         price,
+		salePrice:     salePrice,
+		onSale:        onSale,
+		reason:        reason,
+		priceChangeId: priceChangeId,
+		changedAt:     changedAt,
+	}
+}
+
+func (e *PriceHistoryEntry) BookId() int {
+	return e.bookId
+}
+
+func (e *PriceHistoryEntry) Price() Money {
+	return e.price
+}
+
+// SalePrice returns the sale price in effect after the change, if there was one.
+func (e *PriceHistoryEntry) SalePrice() (Money, bool) {
+	return e.salePrice, e.onSale
+}
+
+func (e *PriceHistoryEntry) Reason() string {
+	return e.reason
+}
+
+func (e *PriceHistoryEntry) PriceChangeId() int {
+	return e.priceChangeId
+}
+
+func (e *PriceHistoryEntry) ChangedAt() time.Time {
+	return e.changedAt
+}
@@ -0,0 +1,360 @@
+package domain
+
+import (
+	"fmt"
+	"time"
+)
+
+// Purchase orders are drafted, sent to the supplier, then received in one or more
+// deliveries. Only drafts can be changed or deleted.
+const (
+	PurchaseOrderDraft             = "draft"
+	PurchaseOrderSent              = "sent"
+	PurchaseOrderPartiallyReceived = "partially_received"
+	PurchaseOrderReceived          = "received"
+)
+
+// StockMovementReceipt is the reason of the stock received with a purchase order.
+const StockMovementReceipt = "purchase_order_receipt"
+
+func IsPurchaseOrderStatus(status string) bool {
+	switch status {
+	case PurchaseOrderDraft, PurchaseOrderSent, PurchaseOrderPartiallyReceived, PurchaseOrderReceived:
+		return true
+	}
+	return false
+}
+
+// PurchaseOrderFilter narrows a list of purchase orders to a supplier and a status when
+// they are set.
+type PurchaseOrderFilter struct {
+	SupplierId int
+	Status     string
+	Limit      int
+	Offset     int
+}
+
+// PurchaseQuantity is a number of copies of a book to order or that were received.
+type PurchaseQuantity struct {
+	BookId   int
+	Quantity int
+}
+
+// PurchaseOrderLine is a book ordered from a supplier at its cost price. The book id is
+// zero once the book has been purged; the title is kept.
+type PurchaseOrderLine struct {
+	bookId    int
+	title     string
+	quantity  int
+	received  int
+	costPrice Money
+}
+
+func NewPurchaseOrderLine(bookId int, title string, quantity int, costPrice Money) (PurchaseOrderLine, error) {
+	if bookId < 0 {
+		return PurchaseOrderLine{}, fmt.Errorf("%w: invalid book id %d", ErrInvalidPurchaseOrder, bookId)
+	}
+	if quantity <= 0 {
+		return PurchaseOrderLine{}, fmt.Errorf("%w: quantity must be positive", ErrInvalidPurchaseOrder)
+	}
+	if !costPrice.IsSet() || costPrice.IsNegative() {
+		return PurchaseOrderLine{}, fmt.Errorf("%w: cost price is required and cannot be negative", ErrInvalidPurchaseOrder)
+	}
+	return PurchaseOrderLine{bookId: bookId, title: title, quantity: quantity, costPrice: costPrice}, nil
+}
+
+func (l *PurchaseOrderLine) BookId() int {
+	return l.bookId
+}
+
+func (l *PurchaseOrderLine) Title() string {
+	return l.title
+}
+
+func (l *PurchaseOrderLine) Quantity() int {
+	return l.quantity
+}
+
+// Received is the number of copies delivered so far.
+func (l *PurchaseOrderLine) Received() int {
+	return l.received
+}
+
+// Outstanding is the number of copies still to be delivered. Lines whose book was purged
+// are closed: nothing more is expected for them.
+func (l *PurchaseOrderLine) Outstanding() int {
+	if l.bookId == 0 {
+		return 0
+	}
+	return l.quantity - l.received
+}
+
+func (l *PurchaseOrderLine) CostPrice() Money {
+	return l.costPrice
+}
+
+func (l *PurchaseOrderLine) Total() Money {
+	total, _ := l.costPrice.Mul(int64(l.quantity))
+	return total
+}
+
+func (l *PurchaseOrderLine) SetReceived(received int) error {
+	if received < 0 || received > l.quantity {
+		return fmt.Errorf("%w: received %d of %d copies", ErrInvalidPurchaseOrder, received, l.quantity)
+	}
+	l.received = received
+	return nil
+}
+
+type PurchaseOrder struct {
+	id           int
+	supplierId   int
+	supplierName string
+	status       string
+	currency     string
+	lines        []PurchaseOrderLine
+	createdAt    time.Time
+	sentAt       time.Time
+	receivedAt   time.Time
+}
+
+// NewPurchaseOrder creates a purchase order. id is zero for orders not stored yet. All
+// lines are in the same currency, which is the currency of the order, and each book is
+// ordered once.
+func NewPurchaseOrder(id int, supplierId int, status string, lines []PurchaseOrderLine) (PurchaseOrder, error) {
+	if id < 0 {
+		return PurchaseOrder{}, fmt.Errorf("invalid purchase order id: %d", id)
+	}
+	if supplierId <= 0 {
+		return PurchaseOrder{}, fmt.Errorf("%w: supplierId must be a positive integer", ErrInvalidPurchaseOrder)
+	}
+	if !IsPurchaseOrderStatus(status) {
+		return PurchaseOrder{}, fmt.Errorf("%w: unknown status %q", ErrInvalidPurchaseOrder, status)
+	}
+	if len(lines) == 0 {
+		return PurchaseOrder{}, fmt.Errorf("%w: a purchase order needs at least one book", ErrInvalidPurchaseOrder)
+	}
+
+	currency := lines[0].costPrice.Currency()
+	books := make(map[int]bool, len(lines))
+	for _, line := range lines {
+		if line.costPrice.Currency() != currency {
+			return PurchaseOrder{}, fmt.Errorf("%w: %w: lines in %s and %s",
+				ErrInvalidPurchaseOrder, ErrCurrencyMismatch, currency, line.costPrice.Currency())
+		}
+		if line.bookId != 0 && books[line.bookId] {
+			return PurchaseOrder{}, fmt.Errorf("%w: book %d is ordered twice", ErrInvalidPurchaseOrder, line.bookId)
+		}
+		books[line.bookId] = true
+	}
+
+	return PurchaseOrder{id: id, supplierId: supplierId, status: status, currency: currency, lines: lines}, nil
+}
+
+func (o *PurchaseOrder) Id() int {
+	return o.id
+}
+
+func (o *PurchaseOrder) SupplierId() int {
+	return o.supplierId
+}
+
+// SupplierName is set on purchase orders read from the database.
+func (o *PurchaseOrder) SupplierName() string {
+	return o.supplierName
+}
+
+func (o *PurchaseOrder) Status() string {
+	return o.status
+}
+
+func (o *PurchaseOrder) Currency() string {
+	return o.currency
+}
+
+func (o *PurchaseOrder) Lines() []PurchaseOrderLine {
+	return o.lines
+}
+
+func (o *PurchaseOrder) CreatedAt() time.Time {
+	return o.createdAt
+}
+
+// SentAt is when the order was sent to the supplier, zero for drafts.
+func (o *PurchaseOrder) SentAt() time.Time {
+	return o.sentAt
+}
+
+// ReceivedAt is when the last copy was received, zero until then.
+func (o *PurchaseOrder) ReceivedAt() time.Time {
+	return o.receivedAt
+}
+
+// Total is the cost of all the copies ordered.
+func (o *PurchaseOrder) Total() Money {
+	total := Money{currency: o.currency}
+	for _, line := range o.lines {
+		total, _ = total.Add(line.Total())
+	}
+	return total
+}
+
+// IsDraft reports whether the order can still be changed or deleted.
+func (o *PurchaseOrder) IsDraft() bool {
+	return o.status == PurchaseOrderDraft
+}
+
+// Send marks a draft as sent to the supplier.
+func (o *PurchaseOrder) Send() error {
+	if !o.IsDraft() {
+		return fmt.Errorf("%w: the order is %s", ErrPurchaseOrderStatus, o.status)
+	}
+	o.status = PurchaseOrderSent
+	return nil
+}
+
+// Receive books a delivery: quantities maps book ids to the copies delivered, and an
+// empty map delivers everything outstanding. It returns the stock movements the delivery
+// makes and updates the status of the order, which is received once nothing is
+// outstanding; lines whose book was purged do not hold it open.
+func (o *PurchaseOrder) Receive(quantities map[int]int) ([]StockMovement, error) {
+	if o.status != PurchaseOrderSent && o.status != PurchaseOrderPartiallyReceived {
+		return nil, fmt.Errorf("%w: the order is %s", ErrPurchaseOrderStatus, o.status)
+	}
+	if len(quantities) == 0 {
+		quantities = make(map[int]int, len(o.lines))
+		for _, line := range o.lines {
+			if line.Outstanding() > 0 {
+				quantities[line.bookId] = line.Outstanding()
+			}
+		}
+	}
+
+	lines := make([]PurchaseOrderLine, len(o.lines))
+	copy(lines, o.lines)
+	var movements []StockMovement
+	for i := range lines {
+		line := &lines[i]
+		quantity, ok := quantities[line.bookId]
+		if !ok || line.bookId == 0 {
+			continue
+		}
+		if quantity <= 0 || quantity > line.Outstanding() {
+			return nil, fmt.Errorf("%w: %d copies of book %d received, %d outstanding",
+				ErrInvalidPurchaseOrder, quantity, line.bookId, line.Outstanding())
+		}
+		line.received += quantity
+		movement, err := NewStockMovement(line.bookId, quantity, StockMovementReceipt, o.id)
+		if err != nil {
+			return nil, err
+		}
+		movements = append(movements, movement)
+	}
+	if len(movements) != len(quantities) {
+		return nil, fmt.Errorf("%w: books received that are not on the order", ErrInvalidPurchaseOrder)
+	}
+
+	o.lines = lines
+	o.status = PurchaseOrderReceived
+	for _, line := range o.lines {
+		if line.Outstanding() > 0 {
+			o.status = PurchaseOrderPartiallyReceived
+			break
+		}
+	}
+	return movements, nil
+}
+
+func (o *PurchaseOrder) SetSupplierName(name string) error {
+	o.supplierName = name
+	return nil
+}
+
+func (o *PurchaseOrder) SetCreatedAt(createdAt time.Time) error {
+	o.createdAt = createdAt
+	return nil
+}
+
+func (o *PurchaseOrder) SetSentAt(sentAt time.Time) error {
+	o.sentAt = sentAt
+	return nil
+}
+
+func (o *PurchaseOrder) SetReceivedAt(receivedAt time.Time) error {
+	o.receivedAt = receivedAt
+	return nil
+}
+
+// StockMovement is a change to the stock of a book other than a sale. Positive
+// quantities add stock. The book id is zero once the book has been purged; the title is
+// kept.
+type StockMovement struct {
+	id              int
+	bookId          int
+	title           string
+	quantity        int
+	reason          string
+	purchaseOrderId int
+	createdAt       time.Time
+}
+
+// NewStockMovement creates a movement. purchaseOrderId is zero for movements not made by
+// a purchase order.
+func NewStockMovement(bookId int, quantity int, reason string, purchaseOrderId int) (StockMovement, error) {
+	if bookId < 0 {
+		return StockMovement{}, fmt.Errorf("invalid book id: %d", bookId)
+	}
+	if quantity == 0 {
+		return StockMovement{}, fmt.Errorf("stock movement quantity cannot be zero")
+	}
+	if reason == "" {
+		return StockMovement{}, fmt.Errorf("stock movement reason cannot be empty")
+	}
+	return StockMovement{bookId: bookId, quantity: quantity, reason: reason, purchaseOrderId: purchaseOrderId}, nil
+}
+
+func (m *StockMovement) Id() int {
+	return m.id
+}
+
+func (m *StockMovement) BookId() int {
+	return m.bookId
+}
+
+func (m *StockMovement) Title() string {
+	return m.title
+}
+
+func (m *StockMovement) Quantity() int {
+	return m.quantity
+}
+
+func (m *StockMovement) Reason() string {
+	return m.reason
+}
+
+func (m *StockMovement) PurchaseOrderId() int {
+	return m.purchaseOrderId
+}
+
+func (m *StockMovement) CreatedAt() time.Time {
+	return m.createdAt
+}
+
+func (m *StockMovement) SetId(id int) error {
+	if id <= 0 {
+		return fmt.Errorf("invalid stock movement id: %d", id)
+	}
+	m.id = id
+	return nil
+}
+
+func (m *StockMovement) SetTitle(title string) error {
+	m.title = title
+	return nil
+}
+
+func (m *StockMovement) SetCreatedAt(createdAt time.Time) error {
+	m.createdAt = createdAt
+	return nil
+}
@@ -0,0 +1,119 @@
+package domain
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func newTestPurchaseOrder(t *testing.T, status string) PurchaseOrder {
+	cost, err := ParseMoney("4.50", "EUR")
+	require.NoError(t, err)
+	dune, err := NewPurchaseOrderLine(1, "Dune", 10, cost)
+	require.NoError(t, err)
+	emma, err := NewPurchaseOrderLine(2, "Emma", 4, cost)
+	require.NoError(t, err)
+	order, err := NewPurchaseOrder(7, 3, status, []PurchaseOrderLine{dune, emma})
+	require.NoError(t, err)
+	return order
+}
+
+func TestNewPurchaseOrder(t *testing.T) {
+	order := newTestPurchaseOrder(t, PurchaseOrderDraft)
+	assert.Equal(t, "EUR", order.Currency())
+	assert.Equal(t, "63.00", order.Total().Decimal())
+
+	eur, err := ParseMoney("4.50", "EUR")
+	require.NoError(t, err)
+	usd, err := ParseMoney("5.00", "USD")
+	require.NoError(t, err)
+	first, err := NewPurchaseOrderLine(1, "Dune", 1, eur)
+	require.NoError(t, err)
+	second, err := NewPurchaseOrderLine(2, "Emma", 1, usd)
+	require.NoError(t, err)
+	again, err := NewPurchaseOrderLine(1, "Dune", 2, eur)
+	require.NoError(t, err)
+
+	for name, lines := range map[string][]PurchaseOrderLine{
+		"no lines":           nil,
+		"mixed currency":     {first, second},
+		"book ordered twice": {first, again},
+	} {
+		t.Run(name, func(t *testing.T) {
+			_, err := NewPurchaseOrder(0, 3, PurchaseOrderDraft, lines)
+			assert.ErrorIs(t, err, ErrInvalidPurchaseOrder)
+		})
+	}
+
+	_, err = NewPurchaseOrderLine(1, "Dune", 0, eur)
+	assert.ErrorIs(t, err, ErrInvalidPurchaseOrder)
+}
+
+func TestPurchaseOrder_Send(t *testing.T) {
+	order := newTestPurchaseOrder(t, PurchaseOrderDraft)
+	require.NoError(t, order.Send())
+	assert.Equal(t, PurchaseOrderSent, order.Status())
+	assert.ErrorIs(t, order.Send(), ErrPurchaseOrderStatus)
+}
+
+func TestPurchaseOrder_ReceivePartially(t *testing.T) {
+	order := newTestPurchaseOrder(t, PurchaseOrderSent)
+
+	movements, err := order.Receive(map[int]int{1: 6})
+	require.NoError(t, err)
+	require.Len(t, movements, 1)
+	assert.Equal(t, 1, movements[0].BookId())
+	assert.Equal(t, 6, movements[0].Quantity())
+	assert.Equal(t, StockMovementReceipt, movements[0].Reason())
+	assert.Equal(t, 7, movements[0].PurchaseOrderId())
+	assert.Equal(t, PurchaseOrderPartiallyReceived, order.Status())
+	assert.Equal(t, 4, order.Lines()[0].Outstanding())
+
+	movements, err = order.Receive(nil)
+	require.NoError(t, err)
+	require.Len(t, movements, 2)
+	assert.Equal(t, 4, movements[0].Quantity())
+	assert.Equal(t, 4, movements[1].Quantity())
+	assert.Equal(t, PurchaseOrderReceived, order.Status())
+
+	_, err = order.Receive(nil)
+	assert.ErrorIs(t, err, ErrPurchaseOrderStatus)
+}
+
+func TestPurchaseOrder_ReceiveClosesPurgedLines(t *testing.T) {
+	cost, err := ParseMoney("4.50", "EUR")
+	require.NoError(t, err)
+	dune, err := NewPurchaseOrderLine(1, "Dune", 10, cost)
+	require.NoError(t, err)
+	purged, err := NewPurchaseOrderLine(0, "Emma", 4, cost)
+	require.NoError(t, err)
+	order, err := NewPurchaseOrder(7, 3, PurchaseOrderSent, []PurchaseOrderLine{dune, purged})
+	require.NoError(t, err)
+	assert.Zero(t, order.Lines()[1].Outstanding())
+
+	movements, err := order.Receive(map[int]int{1: 10})
+	require.NoError(t, err)
+	require.Len(t, movements, 1)
+	assert.Equal(t, PurchaseOrderReceived, order.Status())
+}
+
+func TestPurchaseOrder_ReceiveRejectsInvalidQuantities(t *testing.T) {
+	for name, quantities := range map[string]map[int]int{
+		"more than outstanding": {1: 11},
+		"not positive":          {1: 0},
+		"book not on the order": {1: 1, 9: 1},
+	} {
+		t.Run(name, func(t *testing.T) {
+			order := newTestPurchaseOrder(t, PurchaseOrderSent)
+			_, err := order.Receive(quantities)
+			assert.ErrorIs(t, err, ErrInvalidPurchaseOrder)
+			assert.Equal(t, PurchaseOrderSent, order.Status())
+			assert.Zero(t, order.Lines()[0].Received())
+		})
+	}
+
+	draft := newTestPurchaseOrder(t, PurchaseOrderDraft)
+	_, err := draft.Receive(nil)
+	assert.ErrorIs(t, err, ErrPurchaseOrderStatus)
+}
@@ -0,0 +1,25 @@
+package domain
+
+// Reasons a book is recommended: bought by the same customers, or selling well where
+// there is too little purchase data.
+const (
+	RecommendationCoPurchase = "co_purchase"
+	RecommendationBestseller = "bestseller"
+)
+
+type Recommendation struct {
+	book   Book
+	reason string
+}
+
+func NewRecommendation(book Book, reason string) Recommendation {
+	return Recommendation{book: book, reason: reason}
+}
+
+func (r *Recommendation) Book() Book {
+	return r.book
+}
+
+func (r *Recommendation) Reason() string {
+	return r.reason
+}
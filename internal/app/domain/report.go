@@ -0,0 +1,235 @@
+package domain
+
+import (
+	"fmt"
+	"time"
+)
+
+// Reports group sales into periods of a day, an ISO week starting on Monday, or a
+// calendar month, in UTC.
+const (
+	ReportGranularityDay   = "day"
+	ReportGranularityWeek  = "week"
+	ReportGranularityMonth = "month"
+)
+
+// maxReportPeriods bounds the rows of a report per category or book.
+const maxReportPeriods = 1000
+
+// ReportFilter is the date range [From, To) a report covers, split by Granularity. The
+// first and last periods only count the part of them within the range.
+type ReportFilter struct {
+	From        time.Time
+	To          time.Time
+	Granularity string
+}
+
+func (f ReportFilter) Validate() error {
+	var step time.Duration
+	switch f.Granularity {
+	case ReportGranularityDay:
+		step = 24 * time.Hour
+	case ReportGranularityWeek:
+		step = 7 * 24 * time.Hour
+	case ReportGranularityMonth:
+		step = 28 * 24 * time.Hour
+	default:
+		return fmt.Errorf("%w: granularity must be day, week or month", ErrInvalidReport)
+	}
+	if f.From.IsZero() || f.To.IsZero() || !f.From.Before(f.To) {
+		return fmt.Errorf("%w: from must be before to", ErrInvalidReport)
+	}
+	if f.To.Sub(f.From)/step > maxReportPeriods {
+		return fmt.Errorf("%w: the range spans more than %d periods", ErrInvalidReport, maxReportPeriods)
+	}
+	return nil
+}
+
+// CategoryRevenue is what the books of a category sold for in a period and currency.
+// Net leaves out the tax, whether prices included it or not.
+type CategoryRevenue struct {
+	period       time.Time
+	categoryId   int
+	categoryName string
+	orders       int
+	units        int
+	net          Money
+	tax          Money
+}
+
+func NewCategoryRevenue(
+	period time.Time, categoryId int, categoryName string, orders, units int, net, tax Money,
+) CategoryRevenue {
+	return CategoryRevenue{
+		period: period, categoryId: categoryId, categoryName: categoryName,
+		orders: orders, units: units, net: net, tax: tax,
+	}
+}
+
+func (r *CategoryRevenue) Period() time.Time {
+	return r.period
+}
+
+// CategoryId is 0 for books sold without a category that still exists.
+func (r *CategoryRevenue) CategoryId() int {
+	return r.categoryId
+}
+
+func (r *CategoryRevenue) CategoryName() string {
+	return r.categoryName
+}
+
+func (r *CategoryRevenue) Orders() int {
+	return r.orders
+}
+
+func (r *CategoryRevenue) Units() int {
+	return r.units
+}
+
+func (r *CategoryRevenue) Net() Money {
+	return r.net
+}
+
+func (r *CategoryRevenue) Tax() Money {
+	return r.tax
+}
+
+// BookSales counts the copies of a book sold in a period, in all its formats, and what
+// they sold for net of tax in a currency.
+type BookSales struct {
+	period time.Time
+	bookId int
+	title  string
+	author string
+	units  int
+	net    Money
+}
+
+func NewBookSales(period time.Time, bookId int, title, author string, units int, net Money) BookSales {
+	return BookSales{period: period, bookId: bookId, title: title, author: author, units: units, net: net}
+}
+
+func (s *BookSales) Period() time.Time {
+	return s.period
+}
+
+// BookId is 0 for books purged from the catalogue.
+func (s *BookSales) BookId() int {
+	return s.bookId
+}
+
+func (s *BookSales) Title() string {
+	return s.title
+}
+
+func (s *BookSales) Author() string {
+	return s.author
+}
+
+func (s *BookSales) Units() int {
+	return s.units
+}
+
+func (s *BookSales) Net() Money {
+	return s.net
+}
+
+// BasketSize sums up the orders of a period in a currency.
+type BasketSize struct {
+	period time.Time
+	orders int
+	units  int
+	total  Money
+}
+
+func NewBasketSize(period time.Time, orders, units int, total Money) BasketSize {
+	return BasketSize{period: period, orders: orders, units: units, total: total}
+}
+
+func (b *BasketSize) Period() time.Time {
+	return b.period
+}
+
+func (b *BasketSize) Orders() int {
+	return b.orders
+}
+
+func (b *BasketSize) Units() int {
+	return b.units
+}
+
+// Total is what the orders came to, tax included.
+func (b *BasketSize) Total() Money {
+	return b.total
+}
+
+func (b *BasketSize) AverageUnits() float64 {
+	if b.orders == 0 {
+		return 0
+	}
+	return float64(b.units) / float64(b.orders)
+}
+
+// AverageTotal is the mean order total, rounded half away from zero to the minor unit.
+func (b *BasketSize) AverageTotal() Money {
+	if b.orders == 0 {
+		return b.total
+	}
+	orders := int64(b.orders)
+	amount := (b.total.Amount() + orders/2) / orders
+	average, _ := NewMoney(amount, b.total.Currency())
+	return average
+}
+
+// StockTurnover compares the printed copies of the books of a category sold in a period
+// to the stock they were sold from. The stock at the end of a period is the current
+// stock with the copies sold since given back; restocks are not known, so earlier stock
+// levels are estimates.
+type StockTurnover struct {
+	period       time.Time
+	categoryId   int
+	categoryName string
+	units        int
+	closingStock int
+}
+
+func NewStockTurnover(period time.Time, categoryId int, categoryName string, units, closingStock int) StockTurnover {
+	return StockTurnover{
+		period: period, categoryId: categoryId, categoryName: categoryName, units: units, closingStock: closingStock,
+	}
+}
+
+func (t *StockTurnover) Period() time.Time {
+	return t.period
+}
+
+func (t *StockTurnover) CategoryId() int {
+	return t.categoryId
+}
+
+func (t *StockTurnover) CategoryName() string {
+	return t.categoryName
+}
+
+func (t *StockTurnover) Units() int {
+	return t.units
+}
+
+func (t *StockTurnover) OpeningStock() int {
+	return t.closingStock + t.units
+}
+
+func (t *StockTurnover) ClosingStock() int {
+	return t.closingStock
+}
+
+// Turnover is the copies sold over the average of the opening and closing stock, 0 when
+// there was no stock.
+func (t *StockTurnover) Turnover() float64 {
+	average := float64(t.OpeningStock()+t.ClosingStock()) / 2
+	if average == 0 {
+		return 0
+	}
+	return float64(t.units) / average
+}
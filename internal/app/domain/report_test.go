@@ -0,0 +1,50 @@
+package domain
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestReportFilter_Validate(t *testing.T) {
+	from := time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC)
+
+	assert.NoError(t, ReportFilter{From: from, To: from.AddDate(0, 1, 0), Granularity: ReportGranularityDay}.Validate())
+	assert.NoError(t, ReportFilter{From: from, To: from.AddDate(10, 0, 0), Granularity: ReportGranularityMonth}.Validate())
+
+	for name, filter := range map[string]ReportFilter{
+		"unknown granularity": {From: from, To: from.AddDate(0, 1, 0), Granularity: "hour"},
+		"empty range":         {From: from, To: from, Granularity: ReportGranularityDay},
+		"missing from":        {To: from, Granularity: ReportGranularityDay},
+		"too many periods":    {From: from, To: from.AddDate(5, 0, 0), Granularity: ReportGranularityDay},
+	} {
+		t.Run(name, func(t *testing.T) {
+			assert.ErrorIs(t, filter.Validate(), ErrInvalidReport)
+		})
+	}
+}
+
+func TestBasketSize_Averages(t *testing.T) {
+	total, err := ParseMoney("100.00", "EUR")
+	require.NoError(t, err)
+	basket := NewBasketSize(time.Time{}, 3, 7, total)
+
+	assert.InDelta(t, 7.0/3, basket.AverageUnits(), 1e-9)
+	assert.Equal(t, "33.33", basket.AverageTotal().Decimal())
+	assert.Equal(t, "EUR", basket.AverageTotal().Currency())
+
+	empty := NewBasketSize(time.Time{}, 0, 0, Money{})
+	assert.Zero(t, empty.AverageUnits())
+}
+
+func TestStockTurnover_Turnover(t *testing.T) {
+	turnover := NewStockTurnover(time.Time{}, 1, "Fiction", 10, 15)
+
+	assert.Equal(t, 25, turnover.OpeningStock())
+	assert.InDelta(t, 0.5, turnover.Turnover(), 1e-9)
+
+	none := NewStockTurnover(time.Time{}, 1, "Fiction", 0, 0)
+	assert.Zero(t, none.Turnover())
+}
@@ -0,0 +1,121 @@
+package domain
+
+import (
+	"fmt"
+	"time"
+)
+
+// Session is a login on one device. Every access token belongs to a session and stops
+// working once the session is revoked.
+type Session struct {
+	id         int
+	userId     int
+	userAgent  string
+	ipAddress  string
+	createdAt  time.Time
+	lastSeenAt time.Time
+	expiresAt  time.Time
+	revokedAt  time.Time
+}
+
+func NewSession(userId int, userAgent string, ipAddress string, expiresAt time.Time) (Session, error) {
+	session := Session{}
+	if err := session.SetUserId(userId); err != nil {
+		return session, err
+	}
+	if err := session.SetClient(userAgent, ipAddress); err != nil {
+		return session, err
+	}
+	if err := session.SetExpiresAt(expiresAt); err != nil {
+		return session, err
+	}
+	return session, nil
+}
+
+// Getter methods
+
+func (s *Session) Id() int {
+	return s.id
+}
+
+func (s *Session) UserId() int {
+	return s.userId
+}
+
+func (s *Session) UserAgent() string {
+	return s.userAgent
+}
+
+func (s *Session) IPAddress() string {
+	return s.ipAddress
+}
+
+func (s *Session) CreatedAt() time.Time {
+	return s.createdAt
+}
+
+func (s *Session) LastSeenAt() time.Time {
+	return s.lastSeenAt
+}
+
+func (s *Session) ExpiresAt() time.Time {
+	return s.expiresAt
+}
+
+func (s *Session) RevokedAt() time.Time {
+	return s.revokedAt
+}
+
+// Active reports whether the session is neither revoked nor expired at the given time.
+func (s *Session) Active(now time.Time) bool {
+	return s.revokedAt.IsZero() && now.Before(s.expiresAt)
+}
+
+// Setter methods
+
+func (s *Session) SetId(id int) error {
+	if id <= 0 {
+		return fmt.Errorf("invalid session id: %d", id)
+	}
+	s.id = id
+	return nil
+}
+
+func (s *Session) SetUserId(userId int) error {
+	if userId <= 0 {
+		return fmt.Errorf("invalid session user id: %d", userId)
+	}
+	s.userId = userId
+	return nil
+}
+
+// SetClient records the device the session was started from. Both values are
+// informational and may be empty.
+func (s *Session) SetClient(userAgent string, ipAddress string) error {
+	s.userAgent = userAgent
+	s.ipAddress = ipAddress
+	return nil
+}
+
+func (s *Session) SetCreatedAt(createdAt time.Time) error {
+	s.createdAt = createdAt
+	return nil
+}
+
+func (s *Session) SetLastSeenAt(lastSeenAt time.Time) error {
+	s.lastSeenAt = lastSeenAt
+	return nil
+}
+
+func (s *Session) SetExpiresAt(expiresAt time.Time) error {
+	if expiresAt.IsZero() {
+		return fmt.Errorf("session must expire")
+	}
+	s.expiresAt = expiresAt
+	return nil
+}
+
+func (s *Session) SetRevokedAt(revokedAt time.Time) error {
+	s.revokedAt = revokedAt
+	return nil
+}
@@ -0,0 +1,95 @@
+package domain
+
+// Books are reported as low on stock at or below their reorder threshold, and as out of
+// stock once none are left.
+const (
+	StockLevelLow = "low"
+	StockLevelOut = "out"
+)
+
+var stockLevelRank = map[string]int{
+	StockLevelLow: 1,
+	StockLevelOut: 2,
+}
+
+// ReorderPolicy decides when books run low and how many copies to reorder. Books without
+// a threshold of their own use DefaultThreshold. Sales velocity is measured over the last
+// SalesDays, and reorders are sized to cover CoverDays of sales on top of the threshold.
+type ReorderPolicy struct {
+	DefaultThreshold int
+	SalesDays        int
+	CoverDays        int
+}
+
+// StockAlert is a listed book whose stock has fallen to its reorder threshold.
+type StockAlert struct {
+	book              Book
+	threshold         int
+	sold              int
+	dailySales        float64
+	suggestedQuantity int
+	notifiedLevel     string
+}
+
+// NewStockAlert creates an alert for a book that sold the given number of copies over
+// the policy's sales window. notifiedLevel is the level the book was last reported at,
+// empty when it has not been reported since it was last restocked.
+func NewStockAlert(book Book, threshold int, sold int, notifiedLevel string, policy ReorderPolicy) StockAlert {
+	alert := StockAlert{book: book, threshold: threshold, sold: sold, notifiedLevel: notifiedLevel}
+	if policy.SalesDays > 0 {
+		alert.dailySales = float64(sold) / float64(policy.SalesDays)
+	}
+	alert.suggestedQuantity = ReorderQuantity(book.Stock(), threshold, sold, policy)
+	return alert
+}
+
+// ReorderQuantity is the number of copies that brings the stock up to the threshold plus
+// the copies expected to sell over the cover period, at the rate they sold over the sales
+// window. It is at least enough to lift the stock above the threshold.
+func ReorderQuantity(stock int, threshold int, sold int, policy ReorderPolicy) int {
+	expected := 0
+	if policy.SalesDays > 0 {
+		expected = (sold*policy.CoverDays + policy.SalesDays - 1) / policy.SalesDays
+	}
+	quantity := threshold + expected - stock
+	if minimum := threshold - stock + 1; quantity < minimum {
+		quantity = minimum
+	}
+	return max(quantity, 0)
+}
+
+func (a *StockAlert) Book() Book {
+	return a.book
+}
+
+func (a *StockAlert) Threshold() int {
+	return a.threshold
+}
+
+// Sold is the number of copies sold over the sales window.
+func (a *StockAlert) Sold() int {
+	return a.sold
+}
+
+// DailySales is the average number of copies sold a day over the sales window.
+func (a *StockAlert) DailySales() float64 {
+	return a.dailySales
+}
+
+func (a *StockAlert) SuggestedQuantity() int {
+	return a.suggestedQuantity
+}
+
+// Level is StockLevelOut when no copies are left and StockLevelLow otherwise.
+func (a *StockAlert) Level() string {
+	if a.book.Stock() <= 0 {
+		return StockLevelOut
+	}
+	return StockLevelLow
+}
+
+// NeedsNotification reports whether the book has got worse since it was last reported:
+// it was not reported yet, or it was reported as low and has now sold out.
+func (a *StockAlert) NeedsNotification() bool {
+	return stockLevelRank[a.Level()] > stockLevelRank[a.notifiedLevel]
+}
@@ -0,0 +1,46 @@
+package domain
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestReorderQuantity(t *testing.T) {
+	policy := ReorderPolicy{DefaultThreshold: 5, SalesDays: 28, CoverDays: 30}
+
+	// 56 copies in 28 days is 2 a day, 60 over the cover period, on top of the threshold.
+	assert.Equal(t, 63, ReorderQuantity(2, 5, 56, policy))
+	// One copy in 28 days is 1.07 over the cover period, which rounds up to 2.
+	assert.Equal(t, 7, ReorderQuantity(0, 5, 1, policy))
+	// Without sales the stock is lifted just above the threshold.
+	assert.Equal(t, 4, ReorderQuantity(2, 5, 0, policy))
+	assert.Equal(t, 1, ReorderQuantity(0, 0, 0, policy))
+	assert.Equal(t, 0, ReorderQuantity(10, 5, 0, policy))
+}
+
+func TestStockAlert_Levels(t *testing.T) {
+	policy := ReorderPolicy{DefaultThreshold: 5, SalesDays: 28, CoverDays: 30}
+	price, err := ParseMoney("10.00", "USD")
+	require.NoError(t, err)
+	low, err := NewBook(1, "Dune", 1965, "Frank Herbert", price, 3, 1)
+	require.NoError(t, err)
+	soldOut, err := NewBook(2, "Emma", 1815, "Jane Austen", price, 0, 1)
+	require.NoError(t, err)
+
+	alert := NewStockAlert(low, 5, 14, "", policy)
+	assert.Equal(t, StockLevelLow, alert.Level())
+	assert.InDelta(t, 0.5, alert.DailySales(), 1e-9)
+	assert.Equal(t, 17, alert.SuggestedQuantity())
+	assert.True(t, alert.NeedsNotification())
+
+	reported := NewStockAlert(low, 5, 14, StockLevelLow, policy)
+	assert.False(t, reported.NeedsNotification())
+
+	out := NewStockAlert(soldOut, 5, 0, StockLevelLow, policy)
+	assert.Equal(t, StockLevelOut, out.Level())
+	assert.True(t, out.NeedsNotification())
+	reportedOut := NewStockAlert(soldOut, 5, 0, StockLevelOut, policy)
+	assert.False(t, reportedOut.NeedsNotification())
+}
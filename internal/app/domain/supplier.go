@@ -0,0 +1,113 @@
+package domain
+
+import (
+	"fmt"
+	"time"
+)
+
+// Supplier is a publisher or wholesaler the shop buys stock from.
+type Supplier struct {
+	id        int
+	name      string
+	email     string
+	phone     string
+	createdAt time.Time
+}
+
+// NewSupplier creates a supplier. id is zero for suppliers not stored yet.
+func NewSupplier(id int, name string, email string, phone string) (Supplier, error) {
+	if id < 0 {
+		return Supplier{}, fmt.Errorf("invalid supplier id: %d", id)
+	}
+	if name == "" {
+		return Supplier{}, fmt.Errorf("supplier name cannot be empty")
+	}
+	return Supplier{id: id, name: name, email: email, phone: phone}, nil
+}
+
+func (s *Supplier) Id() int {
+	return s.id
+}
+
+func (s *Supplier) Name() string {
+	return s.name
+}
+
+func (s *Supplier) Email() string {
+	return s.email
+}
+
+func (s *Supplier) Phone() string {
+	return s.phone
+}
+
+func (s *Supplier) CreatedAt() time.Time {
+	return s.createdAt
+}
+
+func (s *Supplier) SetCreatedAt(createdAt time.Time) error {
+	s.createdAt = createdAt
+	return nil
+}
+
+// BookSupplier is a supplier a book can be bought from, at the cost price the shop pays.
+// SKU is the supplier's own code for the book, if it has one.
+type BookSupplier struct {
+	bookId       int
+	supplierId   int
+	supplierName string
+	costPrice    Money
+	sku          string
+	updatedAt    time.Time
+}
+
+func NewBookSupplier(bookId int, supplierId int, costPrice Money, sku string) (BookSupplier, error) {
+	if bookId <= 0 {
+		return BookSupplier{}, fmt.Errorf("bookId must be a positive integer")
+	}
+	if supplierId <= 0 {
+		return BookSupplier{}, fmt.Errorf("supplierId must be a positive integer")
+	}
+	if !costPrice.IsSet() {
+		return BookSupplier{}, fmt.Errorf("cost price is required")
+	}
+	if costPrice.IsNegative() {
+		return BookSupplier{}, fmt.Errorf("cost price cannot be negative")
+	}
+	return BookSupplier{bookId: bookId, supplierId: supplierId, costPrice: costPrice, sku: sku}, nil
+}
+
+func (b *BookSupplier) BookId() int {
+	return b.bookId
+}
+
+func (b *BookSupplier) SupplierId() int {
+	return b.supplierId
+}
+
+// SupplierName is set on book suppliers read from the database.
+func (b *BookSupplier) SupplierName() string {
+	return b.supplierName
+}
+
+func (b *BookSupplier) CostPrice() Money {
+	return b.costPrice
+}
+
+func (b *BookSupplier) SKU() string {
+	return b.sku
+}
+
+func (b *BookSupplier) UpdatedAt() time.Time {
+	return b.updatedAt
+}
+
+func (b *BookSupplier) SetSupplierName(name string) error {
+	b.supplierName = name
+	return nil
+}
+
+func (b *BookSupplier) SetUpdatedAt(updatedAt time.Time) error {
+	b.updatedAt = updatedAt
+	return nil
+}
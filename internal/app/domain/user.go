@@ -1,12 +1,26 @@
 package domain
 
-import "fmt"
+import (
+	"fmt"
+	"net/mail"
+	"strings"
+	"time"
+)
+
+// UnusablePasswordHash marks accounts without a password, such as users created by
+// single sign-on. No password verifies against it.
+const UnusablePasswordHash = "!"
 
 type User struct {
-	id           int
-	username     string
-	passwordHash string
-	admin        bool
+	id            int
+	username      string
+	passwordHash  string
+	admin         bool
+	totpSecret    string
+	totpEnabled   bool
+	email         string
+	emailVerified bool
+	createdAt     time.Time
 }
 
 func NewUser(id int, username string, passwordHash string, admin bool) (User, error) {
@@ -51,10 +65,37 @@ func (u *User) PasswordHash() string {
 	return u.passwordHash
 }
 
+// HasPassword reports whether the user can log in with a password.
+func (u *User) HasPassword() bool {
+	return u.passwordHash != UnusablePasswordHash
+}
+
 func (u *User) Admin() bool {
 	return u.admin
 }
 
+// TOTPSecret is set once enrollment starts; it is only used for login after TOTPEnabled.
+func (u *User) TOTPSecret() string {
+	return u.totpSecret
+}
+
+func (u *User) TOTPEnabled() bool {
+	return u.totpEnabled
+}
+
+// Email is empty for users who registered without one.
+func (u *User) Email() string {
+	return u.email
+}
+
+func (u *User) EmailVerified() bool {
+	return u.emailVerified
+}
+
+func (u *User) CreatedAt() time.Time {
+	return u.createdAt
+}
+
 // Setter methods
 
 func (u *User) SetId(id int) error {
@@ -85,3 +126,41 @@ func (u *User) SetAdmin(admin bool) error {
 	u.admin = admin
 	return nil
 }
+
+func (u *User) SetTOTP(secret string, enabled bool) error {
+	if enabled && secret == "" {
+		return fmt.Errorf("cannot enable two-factor authentication without a secret")
+	}
+	u.totpSecret = secret
+	u.totpEnabled = enabled
+	return nil
+}
+
+// SetEmail stores a normalised address. Changing the address clears its verification.
+func (u *User) SetEmail(email string) error {
+	email = strings.ToLower(strings.TrimSpace(email))
+	if email != "" {
+		address, err := mail.ParseAddress(email)
+		if err != nil || address.Address != email {
+			return fmt.Errorf("invalid user email: %s", email)
+		}
+	}
+	if email != u.email {
+		u.emailVerified = false
+	}
+	u.email = email
+	return nil
+}
+
+func (u *User) SetEmailVerified(verified bool) error {
+	if verified && u.email == "" {
+		return fmt.Errorf("cannot verify an empty email")
+	}
+	u.emailVerified = verified
+	return nil
+}
+
+func (u *User) SetCreatedAt(createdAt time.Time) error {
+	u.createdAt = createdAt
+	return nil
+}
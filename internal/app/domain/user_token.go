@@ -0,0 +1,7 @@
+package domain
+
+// Purposes of single-use tokens sent to users by email.
+const (
+	TokenPurposePasswordReset     = "password_reset"
+	TokenPurposeEmailVerification = "email_verification"
+)
@@ -0,0 +1,157 @@
+package handler
+
+import (
+	"encoding/json"
+	"errors"
+	"log/slog"
+	"net/http"
+	"toptal/internal/app/domain"
+	"toptal/internal/app/handler/model"
+	"toptal/internal/app/util"
+	"toptal/internal/pkg/validator"
+)
+
+// @Summary Request a password reset
+// @Description Email a password reset link. The response is the same whether or not the address belongs to an account.
+// @Tags account
+// @Accept json
+// @Produce json
+// @Param request body model.ForgotPasswordRequest true "Account email"
+// @Success 202 {object} model.MessageResponse
+// @Failure 400 {object} model.ProblemDetail "Bad Request"
+// @Failure 500 {object} model.ProblemDetail "Internal Server Error"
+// @Router /password/forgot [post]
+func (s *Server) handleForgotPassword(w http.ResponseWriter, r *http.Request) {
+	var request model.ForgotPasswordRequest
+	if err := json.NewDecoder(r.Body).Decode(&request); err != nil {
+		model.InvalidRequest(w, err.Error(), r.URL.Path)
+		return
+	}
+
+	if err := validator.Validate(request); err != nil {
+		model.ValidationError(w, err.Error(), r.URL.Path)
+		return
+	}
+
+	if err := s.accountService.RequestPasswordReset(r.Context(), request.Email); err != nil {
+		slog.Error("error requesting password reset", "error", err)
+		model.InternalServerError(w, r.URL.Path)
+		return
+	}
+
+	response := model.MessageResponse{Message: "If the address belongs to an account, a reset link has been sent"}
+	writeResponseAccepted(w, response)
+}
+
+// @Summary Reset password
+// @Description Set a new password using the token from a reset email
+// @Tags account
+// @Accept json
+// @Produce json
+// @Param request body model.ResetPasswordRequest true "Reset token and new password"
+// @Success 200 {object} model.MessageResponse
+// @Failure 400 {object} model.ProblemDetail "Bad Request"
+// @Failure 401 {object} model.ProblemDetail "Invalid or expired token"
+// @Failure 500 {object} model.ProblemDetail "Internal Server Error"
+// @Router /password/reset [post]
+func (s *Server) handleResetPassword(w http.ResponseWriter, r *http.Request) {
+	var request model.ResetPasswordRequest
+	if err := json.NewDecoder(r.Body).Decode(&request); err != nil {
+		model.InvalidRequest(w, err.Error(), r.URL.Path)
+		return
+	}
+
+	if err := validator.Validate(request); err != nil {
+		model.ValidationError(w, err.Error(), r.URL.Path)
+		return
+	}
+
+	if err := s.accountService.ResetPassword(r.Context(), request.Token, request.Password); err != nil {
+		switch {
+		case errors.Is(err, domain.ErrInvalidToken):
+			model.Unauthorized(w, err.Error(), r.URL.Path)
+		case errors.Is(err, domain.ErrWeakPassword):
+			model.ValidationError(w, err.Error(), r.URL.Path)
+		default:
+			slog.Error("error resetting password", "error", err)
+			model.InternalServerError(w, r.URL.Path)
+		}
+		return
+	}
+
+	response := model.MessageResponse{Message: "Password updated"}
+	writeResponseOK(w, response)
+}
+
+// @Summary Request email verification
+// @Description Email a verification link to the current user's address
+// @Tags account
+// @Accept json
+// @Produce json
+// @Success 202 {object} model.MessageResponse
+// @Failure 400 {object} model.ProblemDetail "No email set"
+// @Failure 401 {object} model.ProblemDetail "Unauthorized"
+// @Failure 409 {object} model.ProblemDetail "Already verified"
+// @Failure 500 {object} model.ProblemDetail "Internal Server Error"
+// @Security ApiKeyAuth
+// @Router /me/email/verification [post]
+func (s *Server) handleRequestEmailVerification(w http.ResponseWriter, r *http.Request) {
+	userId, err := util.GetUserID(r.Context())
+	if err != nil {
+		model.Unauthorized(w, "unauthorized", r.URL.Path)
+		return
+	}
+
+	if err := s.accountService.RequestEmailVerification(r.Context(), userId); err != nil {
+		switch {
+		case errors.Is(err, domain.ErrEmailNotSet):
+			model.ValidationError(w, err.Error(), r.URL.Path)
+		case errors.Is(err, domain.ErrEmailVerified):
+			model.AlreadyExists(w, err.Error(), r.URL.Path)
+		default:
+			slog.Error("error requesting email verification", "error", err)
+			model.InternalServerError(w, r.URL.Path)
+		}
+		return
+	}
+
+	response := model.MessageResponse{Message: "Verification email sent"}
+	writeResponseAccepted(w, response)
+}
+
+// @Summary Verify email
+// @Description Confirm an email address using the token from a verification email
+// @Tags account
+// @Accept json
+// @Produce json
+// @Param request body model.VerifyEmailRequest true "Verification token"
+// @Success 200 {object} model.MessageResponse
+// @Failure 400 {object} model.ProblemDetail "Bad Request"
+// @Failure 401 {object} model.ProblemDetail "Invalid or expired token"
+// @Failure 500 {object} model.ProblemDetail "Internal Server Error"
+// @Router /email/verify [post]
+func (s *Server) handleVerifyEmail(w http.ResponseWriter, r *http.Request) {
+	var request model.VerifyEmailRequest
+	if err := json.NewDecoder(r.Body).Decode(&request); err != nil {
+		model.InvalidRequest(w, err.Error(), r.URL.Path)
+		return
+	}
+
+	if err := validator.Validate(request); err != nil {
+		model.ValidationError(w, err.Error(), r.URL.Path)
+		return
+	}
+
+	if err := s.accountService.VerifyEmail(r.Context(), request.Token); err != nil {
+		if errors.Is(err, domain.ErrInvalidToken) {
+			model.Unauthorized(w, err.Error(), r.URL.Path)
+		} else {
+			slog.Error("error verifying email", "error", err)
+			model.InternalServerError(w, r.URL.Path)
+		}
+		return
+	}
+
+	response := model.MessageResponse{Message: "Email verified"}
+	writeResponseOK(w, response)
+}
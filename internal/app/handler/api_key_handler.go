@@ -0,0 +1,167 @@
+package handler
+
+import (
+	"encoding/json"
+	"errors"
+	"log/slog"
+	"net/http"
+	"strconv"
+	"toptal/internal/app/domain"
+	"toptal/internal/app/handler/model"
+	"toptal/internal/app/util"
+	"toptal/internal/pkg/validator"
+)
+
+// @Summary Create API key
+// @Description Create a scoped API key for a service integration. The key is only returned once. Each scope grants one area: catalog:write, catalog:export, pricing:write, stock:write or purchasing:write.
+// @Tags api-keys
+// @Accept json
+// @Produce json
+// @Param request body model.APIKeyCreateRequest true "API key details"
+// @Success 201 {object} model.APIKeyCreatedResponse
+// @Failure 400 {object} model.ProblemDetail "Bad Request"
+// @Failure 401 {object} model.ProblemDetail "Unauthorized"
+// @Failure 403 {object} model.ProblemDetail "Forbidden"
+// @Failure 500 {object} model.ProblemDetail "Internal Server Error"
+// @Security ApiKeyAuth
+// @Router /api-keys [post]
+func (s *Server) handleCreateAPIKey(w http.ResponseWriter, r *http.Request) {
+	userId, err := util.GetUserID(r.Context())
+	if err != nil {
+		model.Unauthorized(w, "unauthorized", r.URL.Path)
+		return
+	}
+
+	var request model.APIKeyCreateRequest
+	if err := json.NewDecoder(r.Body).Decode(&request); err != nil {
+		model.InvalidRequest(w, err.Error(), r.URL.Path)
+		return
+	}
+
+	if err := validator.Validate(request); err != nil {
+		model.ValidationError(w, err.Error(), r.URL.Path)
+		return
+	}
+
+	key, err := toAPIKey(request, userId)
+	if err != nil {
+		model.ValidationError(w, err.Error(), r.URL.Path)
+		return
+	}
+
+	created, rawKey, err := s.apiKeyService.CreateAPIKey(r.Context(), key)
+	if err != nil {
+		if errors.Is(err, domain.ErrInvalidAPIKey) {
+			model.ValidationError(w, err.Error(), r.URL.Path)
+		} else {
+			slog.Error("error creating api key", "error", err)
+			model.InternalServerError(w, r.URL.Path)
+		}
+		return
+	}
+
+	response := model.APIKeyCreatedResponse{APIKeyResponse: toAPIKeyResponse(created), Key: rawKey}
+	writeResponseCreated(w, response)
+}
+
+// @Summary List API keys
+// @Description List all API keys. Secrets are never returned.
+// @Tags api-keys
+// @Accept json
+// @Produce json
+// @Success 200 {array} model.APIKeyResponse
+// @Failure 401 {object} model.ProblemDetail "Unauthorized"
+// @Failure 403 {object} model.ProblemDetail "Forbidden"
+// @Failure 500 {object} model.ProblemDetail "Internal Server Error"
+// @Security ApiKeyAuth
+// @Router /api-keys [get]
+func (s *Server) handleGetAPIKeys(w http.ResponseWriter, r *http.Request) {
+	keys, err := s.apiKeyService.GetAPIKeys(r.Context())
+	if err != nil {
+		slog.Error("error getting api keys", "error", err)
+		model.InternalServerError(w, r.URL.Path)
+		return
+	}
+
+	response := toAPIKeysResponse(keys)
+	writeResponseOK(w, response)
+}
+
+// @Summary Rotate API key
+// @Description Issue a new secret for an API key. The old secret stays valid for a grace period.
+// @Tags api-keys
+// @Accept json
+// @Produce json
+// @Param id path int true "API key ID"
+// @Success 201 {object} model.APIKeyCreatedResponse
+// @Failure 400 {object} model.ProblemDetail "Bad Request"
+// @Failure 401 {object} model.ProblemDetail "Unauthorized"
+// @Failure 403 {object} model.ProblemDetail "Forbidden"
+// @Failure 404 {object} model.ProblemDetail "Not Found"
+// @Failure 500 {object} model.ProblemDetail "Internal Server Error"
+// @Security ApiKeyAuth
+// @Router /api-keys/{id}/rotate [post]
+func (s *Server) handleRotateAPIKey(w http.ResponseWriter, r *http.Request) {
+	userId, err := util.GetUserID(r.Context())
+	if err != nil {
+		model.Unauthorized(w, "unauthorized", r.URL.Path)
+		return
+	}
+
+	id, err := strconv.Atoi(r.PathValue("id"))
+	if err != nil {
+		model.InvalidRequest(w, "Invalid API Key ID", r.URL.Path)
+		return
+	}
+
+	created, rawKey, err := s.apiKeyService.RotateAPIKey(r.Context(), id, userId)
+	if err != nil {
+		switch {
+		case errors.Is(err, domain.ErrNotFound):
+			model.NotFound(w, "API Key Not Found", r.URL.Path)
+		case errors.Is(err, domain.ErrInvalidAPIKey):
+			model.ValidationError(w, err.Error(), r.URL.Path)
+		default:
+			slog.Error("error rotating api key", "error", err)
+			model.InternalServerError(w, r.URL.Path)
+		}
+		return
+	}
+
+	response := model.APIKeyCreatedResponse{APIKeyResponse: toAPIKeyResponse(created), Key: rawKey}
+	writeResponseCreated(w, response)
+}
+
+// @Summary Revoke API key
+// @Description Revoke an API key immediately
+// @Tags api-keys
+// @Accept json
+// @Produce json
+// @Param id path int true "API key ID"
+// @Success 200 {string} string "OK"
+// @Failure 400 {object} model.ProblemDetail "Bad Request"
+// @Failure 401 {object} model.ProblemDetail "Unauthorized"
+// @Failure 403 {object} model.ProblemDetail "Forbidden"
+// @Failure 404 {object} model.ProblemDetail "Not Found"
+// @Failure 500 {object} model.ProblemDetail "Internal Server Error"
+// @Security ApiKeyAuth
+// @Router /api-keys/{id} [delete]
+func (s *Server) handleRevokeAPIKey(w http.ResponseWriter, r *http.Request) {
+	id, err := strconv.Atoi(r.PathValue("id"))
+	if err != nil {
+		model.InvalidRequest(w, "Invalid API Key ID", r.URL.Path)
+		return
+	}
+
+	if err := s.apiKeyService.RevokeAPIKey(r.Context(), id); err != nil {
+		if errors.Is(err, domain.ErrNotFound) {
+			model.NotFound(w, "API Key Not Found", r.URL.Path)
+		} else {
+			slog.Error("error revoking api key", "error", err)
+			model.InternalServerError(w, r.URL.Path)
+		}
+		return
+	}
+
+	w.WriteHeader(http.StatusOK)
+}
@@ -0,0 +1,74 @@
+package handler
+
+import (
+	"log/slog"
+	"net/http"
+	"strconv"
+	"time"
+	"toptal/internal/app/domain"
+	"toptal/internal/app/handler/model"
+)
+
+// @Summary Query audit log
+// @Description List administrative changes, newest first
+// @Tags audit
+// @Produce json
+// @Param entity_type query string false "Entity type, e.g. book, category, api_key or user"
+// @Param entity_id query int false "Entity ID"
+// @Param actor_id query int false "ID of the user who made the change"
+// @Param api_key_id query int false "ID of the API key the change was made with"
+// @Param from query string false "Earliest time (RFC 3339), inclusive"
+// @Param to query string false "Latest time (RFC 3339), exclusive"
+// @Param limit query int false "Maximum number of entries (default 50, at most 500)"
+// @Param offset query int false "Number of entries to skip"
+// @Success 200 {array} model.AuditEntryResponse
+// @Failure 400 {object} model.ProblemDetail "Bad Request"
+// @Failure 401 {object} model.ProblemDetail "Unauthorized"
+// @Failure 403 {object} model.ProblemDetail "Forbidden"
+// @Failure 500 {object} model.ProblemDetail "Internal Server Error"
+// @Security ApiKeyAuth
+// @Router /audit-log [get]
+func (s *Server) handleGetAuditLog(w http.ResponseWriter, r *http.Request) {
+	query := r.URL.Query()
+	filter := domain.AuditFilter{EntityType: query.Get("entity_type")}
+
+	ints := map[string]*int{
+		"entity_id":  &filter.EntityId,
+		"actor_id":   &filter.ActorUserId,
+		"api_key_id": &filter.ActorAPIKeyId,
+		"limit":      &filter.Limit,
+		"offset":     &filter.Offset,
+	}
+	for name, target := range ints {
+		if value := query.Get(name); value != "" {
+			parsed, err := strconv.Atoi(value)
+			if err != nil || parsed < 0 {
+				model.InvalidRequest(w, "invalid "+name, r.URL.Path)
+				return
+			}
+			*target = parsed
+		}
+	}
+
+	times := map[string]*time.Time{"from": &filter.From, "to": &filter.To}
+	for name, target := range times {
+		if value := query.Get(name); value != "" {
+			parsed, err := time.Parse(time.RFC3339, value)
+			if err != nil {
+				model.InvalidRequest(w, "invalid "+name+": expected RFC 3339 time", r.URL.Path)
+				return
+			}
+			*target = parsed
+		}
+	}
+
+	entries, err := s.auditService.GetAuditLog(r.Context(), filter)
+	if err != nil {
+		slog.Error("error getting audit log", "error", err)
+		model.InternalServerError(w, r.URL.Path)
+		return
+	}
+
+	response := toAuditEntriesResponse(entries)
+	writeResponseOK(w, response)
+}
@@ -3,6 +3,7 @@ package handler
 import (
 	"encoding/json"
 	"errors"
+	"log/slog"
 	"net/http"
 	"toptal/internal/app/domain"
 	"toptal/internal/app/handler/model"
@@ -10,7 +11,7 @@ import (
 )
 
 // @Summary User login
-// @Description Authenticate user and get JWT token
+// @Description Authenticate user and get JWT token. Users with two-factor authentication get a challenge token instead.
 // @Tags auth
 // @Accept json
 // @Produce json
@@ -32,12 +33,51 @@ func (s *Server) handleLogin(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	token, err := s.authService.Login(r.Context(), request.Username, request.Password)
+	result, err := s.authService.Login(r.Context(), request.Username, request.Password)
 	if err != nil {
 		model.Unauthorized(w, domain.ErrUnauthorized.Error(), err.Error())
 		return
 	}
 
+	response := toLoginResponse(result)
+	writeResponseOK(w, response)
+}
+
+// @Summary Complete two-factor login
+// @Description Exchange the challenge token from /login and a TOTP or recovery code for a JWT token. A challenge token completes one login and is refused after 5 codes; log in again to get a new one
+// @Tags auth
+// @Accept json
+// @Produce json
+// @Param request body model.TwoFactorLoginRequest true "Challenge token and code"
+// @Success 200 {object} model.LoginResponse "Returns JWT token"
+// @Failure 400 {object} model.ProblemDetail "Bad Request"
+// @Failure 401 {object} model.ProblemDetail "Unauthorized"
+// @Failure 500 {object} model.ProblemDetail "Internal Server Error"
+// @Router /login/2fa [post]
+func (s *Server) handleTwoFactorLogin(w http.ResponseWriter, r *http.Request) {
+	var request model.TwoFactorLoginRequest
+	if err := json.NewDecoder(r.Body).Decode(&request); err != nil {
+		model.InvalidRequest(w, err.Error(), r.URL.Path)
+		return
+	}
+
+	if err := validator.Validate(request); err != nil {
+		model.ValidationError(w, err.Error(), r.URL.Path)
+		return
+	}
+
+	token, err := s.authService.VerifyTwoFactor(r.Context(), request.ChallengeToken, request.Code)
+	if err != nil {
+		switch {
+		case errors.Is(err, domain.ErrUnauthorized), errors.Is(err, domain.ErrInvalidTwoFactorCode), errors.Is(err, domain.ErrTwoFactorNotEnabled):
+			model.Unauthorized(w, err.Error(), r.URL.Path)
+		default:
+			slog.Error("error verifying two-factor login", "error", err)
+			model.InternalServerError(w, r.URL.Path)
+		}
+		return
+	}
+
 	response := model.LoginResponse{Token: token}
 	writeResponseOK(w, response)
 }
@@ -47,14 +87,14 @@ func (s *Server) handleLogin(w http.ResponseWriter, r *http.Request) {
 // @Tags auth
 // @Accept json
 // @Produce json
-// @Param request body model.AuthRequest true "Registration details"
+// @Param request body model.RegisterRequest true "Registration details"
 // @Success 201 {object} model.RegisterResponse "User created successfully"
 // @Failure 400 {object} model.ProblemDetail "Bad Request"
-// @Failure 409 {object} model.ProblemDetail "Username already exists"
+// @Failure 409 {object} model.ProblemDetail "Username or email already exists"
 // @Failure 500 {object} model.ProblemDetail "Internal Server Error"
 // @Router /register [post]
 func (s *Server) handleRegister(w http.ResponseWriter, r *http.Request) {
-	var request model.AuthRequest
+	var request model.RegisterRequest
 	if err := json.NewDecoder(r.Body).Decode(&request); err != nil {
 		model.InvalidRequest(w, err.Error(), r.URL.Path)
 		return
@@ -65,9 +105,13 @@ func (s *Server) handleRegister(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	if err := s.authService.Register(r.Context(), request.Username, request.Password); err != nil {
+	if err := s.authService.Register(r.Context(), request.Username, request.Password, request.Email); err != nil {
 		if errors.Is(err, domain.ErrAlreadyExists) {
-			model.AlreadyExists(w, "Username already exists", r.URL.Path)
+			model.AlreadyExists(w, "Username or email already exists", r.URL.Path)
+			return
+		}
+		if errors.Is(err, domain.ErrWeakPassword) {
+			model.ValidationError(w, err.Error(), r.URL.Path)
 			return
 		}
 		model.InternalServerError(w, r.URL.Path)
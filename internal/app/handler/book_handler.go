@@ -4,19 +4,25 @@ import (
 	"encoding/json"
 	"errors"
 	"log/slog"
+	"mime"
 	"net/http"
 	"strconv"
+	"strings"
 	"toptal/internal/app/domain"
 	"toptal/internal/app/handler/model"
 	"toptal/internal/pkg/validator"
 )
 
+// maxImportSize limits the catalogue files accepted by the import endpoint.
+const maxImportSize = 64 << 20
+
 // @Summary Get book by ID
 // @Description Get a book's details by its ID
 // @Tags books
 // @Accept json
 // @Produce json
 // @Param id path int true "Book ID"
+// @Param currency query string false "ISO 4217 currency to show the price in, when the book has a price in it"
 // @Success 200 {object} model.BookResponse
 // @Failure 400 {object} model.ProblemDetail "Bad Request"
 // @Failure 404 {object} model.ProblemDetail "Not Found"
@@ -29,7 +35,12 @@ func (s *Server) handleGetBookById(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	book, err := s.bookService.GetBookById(r.Context(), id)
+	currency, ok := currencyParam(w, r)
+	if !ok {
+		return
+	}
+
+	book, err := s.bookService.GetBookById(r.Context(), id, currency)
 	if err != nil {
 		if errors.Is(err, domain.ErrNotFound) {
 			model.NotFound(w, "Book Not Found", r.URL.Path)
@@ -45,11 +56,14 @@ func (s *Server) handleGetBookById(w http.ResponseWriter, r *http.Request) {
 }
 
 // @Summary Get available books
-// @Description Get a list of all available books, optionally filtered by category IDs
+// @Description Get a list of all available books, optionally filtered by category IDs, language and series
 // @Tags books
 // @Accept json
 // @Produce json
 // @Param categoryId query []int false "Category IDs to filter by"
+// @Param language query string false "BCP 47 language to filter by; en also matches en-GB"
+// @Param series query string false "Series to filter by, listed in order"
+// @Param currency query string false "ISO 4217 currency to show prices in, for books that have a price in it"
 // @Success 200 {array} model.BookResponse
 // @Failure 400 {object} model.ProblemDetail "Bad Request"
 // @Failure 500 {object} model.ProblemDetail "Internal Server Error"
@@ -65,6 +79,15 @@ func (s *Server) handleGetBooks(w http.ResponseWriter, r *http.Request) {
 		}
 		ids[i] = id
 	}
+	filter := domain.BookFilter{CategoryIds: ids, Series: strings.TrimSpace(r.URL.Query().Get("series"))}
+	if tag := r.URL.Query().Get("language"); tag != "" {
+		language, err := domain.NormalizeLanguage(tag)
+		if err != nil {
+			model.WriteProblemDetail(w, http.StatusBadRequest, "Invalid Language", err.Error(), r.URL.Path)
+			return
+		}
+		filter.Language = language
+	}
 
 	limit, err := strconv.Atoi(r.URL.Query().Get("limit"))
 	if err != nil || limit <= 0 {
@@ -76,7 +99,12 @@ func (s *Server) handleGetBooks(w http.ResponseWriter, r *http.Request) {
 		offset = 0
 	}
 
-	books, err := s.bookService.GetAvailableBooks(r.Context(), ids, limit, offset)
+	currency, ok := currencyParam(w, r)
+	if !ok {
+		return
+	}
+
+	books, err := s.bookService.GetAvailableBooks(r.Context(), filter, limit, offset, currency)
 	if err != nil {
 		model.InternalServerError(w, r.URL.Path)
 		return
@@ -110,7 +138,11 @@ func (s *Server) handleCreateBook(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	book := toBook(bookRequest)
+	book, err := toBook(bookRequest)
+	if err != nil {
+		model.ValidationError(w, err.Error(), r.URL.Path)
+		return
+	}
 	if err := s.bookService.CreateBook(r.Context(), book); err != nil {
 		if errors.Is(err, domain.ErrAlreadyExists) {
 			model.AlreadyExists(w, "Book Already Exists", r.URL.Path)
@@ -138,6 +170,7 @@ func (s *Server) handleCreateBook(w http.ResponseWriter, r *http.Request) {
 // @Failure 400 {object} model.ProblemDetail "Bad Request"
 // @Failure 401 {object} model.ProblemDetail "Unauthorized"
 // @Failure 404 {object} model.ProblemDetail "Not Found"
+// @Failure 409 {object} model.ProblemDetail "ISBN already used by another book"
 // @Failure 500 {object} model.ProblemDetail "Internal Server Error"
 // @Security ApiKeyAuth
 // @Router /book [put]
@@ -153,10 +186,20 @@ func (s *Server) handleUpdateBook(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	book := toBookWithId(bookRequest)
+	book, err := toBookWithId(bookRequest)
+	if err != nil {
+		model.ValidationError(w, err.Error(), r.URL.Path)
+		return
+	}
 	if err := s.bookService.UpdateBook(r.Context(), book); err != nil {
 		if errors.Is(err, domain.ErrNotFound) {
 			model.NotFound(w, "Book Not Found", r.URL.Path)
+		} else if errors.Is(err, domain.ErrInvalidCategory) {
+			model.InvalidRequest(w, "Invalid Category ID", r.URL.Path)
+		} else if errors.Is(err, domain.ErrCurrencyMismatch) {
+			model.ValidationError(w, err.Error(), r.URL.Path)
+		} else if errors.Is(err, domain.ErrAlreadyExists) {
+			model.AlreadyExists(w, err.Error(), r.URL.Path)
 		} else {
 			slog.Error("error updating book", "error", err)
 			model.InternalServerError(w, r.URL.Path)
@@ -169,7 +212,7 @@ func (s *Server) handleUpdateBook(w http.ResponseWriter, r *http.Request) {
 }
 
 // @Summary Delete a book
-// @Description Delete a book by its ID
+// @Description Archive a book by its ID. It is hidden from the catalogue until restored or purged
 // @Tags books
 // @Accept json
 // @Produce json
@@ -200,3 +243,142 @@ func (s *Server) handleDeleteBook(w http.ResponseWriter, r *http.Request) {
 
 	w.WriteHeader(http.StatusOK)
 }
+
+// @Summary Get archived books
+// @Description Get books removed from the catalogue, most recently archived first
+// @Tags books
+// @Accept json
+// @Produce json
+// @Param limit query int false "Page size" default(10)
+// @Param offset query int false "Page offset" default(0)
+// @Success 200 {array} model.ArchivedBookResponse
+// @Failure 401 {object} model.ProblemDetail "Unauthorized"
+// @Failure 500 {object} model.ProblemDetail "Internal Server Error"
+// @Security ApiKeyAuth
+// @Router /book/archived [get]
+func (s *Server) handleGetArchivedBooks(w http.ResponseWriter, r *http.Request) {
+	limit, err := strconv.Atoi(r.URL.Query().Get("limit"))
+	if err != nil || limit <= 0 {
+		limit = 10
+	}
+
+	offset, err := strconv.Atoi(r.URL.Query().Get("offset"))
+	if err != nil || offset < 0 {
+		offset = 0
+	}
+
+	books, err := s.bookService.GetArchivedBooks(r.Context(), limit, offset)
+	if err != nil {
+		slog.Error("error getting archived books", "error", err)
+		model.InternalServerError(w, r.URL.Path)
+		return
+	}
+
+	writeResponseOK(w, toArchivedBooksResponse(books))
+}
+
+// @Summary Restore a book
+// @Description Put an archived book back in the catalogue. Its category must not be archived
+// @Tags books
+// @Accept json
+// @Produce json
+// @Param id path int true "Book ID"
+// @Success 200 {object} model.BookResponse
+// @Failure 400 {object} model.ProblemDetail "Bad Request"
+// @Failure 401 {object} model.ProblemDetail "Unauthorized"
+// @Failure 404 {object} model.ProblemDetail "Not Found"
+// @Failure 500 {object} model.ProblemDetail "Internal Server Error"
+// @Security ApiKeyAuth
+// @Router /book/{id}/restore [post]
+func (s *Server) handleRestoreBook(w http.ResponseWriter, r *http.Request) {
+	id, err := strconv.Atoi(r.PathValue("id"))
+	if err != nil {
+		model.InvalidRequest(w, "Invalid Book ID", r.URL.Path)
+		return
+	}
+
+	book, err := s.bookService.RestoreBook(r.Context(), id)
+	if err != nil {
+		if errors.Is(err, domain.ErrNotFound) {
+			model.NotFound(w, "Archived Book Not Found", r.URL.Path)
+		} else if errors.Is(err, domain.ErrInvalidCategory) {
+			model.InvalidRequest(w, "The book's category is archived", r.URL.Path)
+		} else {
+			slog.Error("error restoring book", "error", err)
+			model.InternalServerError(w, r.URL.Path)
+		}
+		return
+	}
+
+	writeResponseOK(w, toBookResponse(book))
+}
+
+// @Summary Import books
+// @Description Create or update books from a CSV, JSON Lines or ONIX 3.0 catalogue sent as the request body. CSV files need a header with the columns title, author, year, price, currency, stock, category and optionally isbn; JSON Lines rows are objects like a book create request with a category name instead of its ID. ONIX products are mapped to categories by their subjects, and deletion notices archive their book. Books are matched by ISBN, or by title and author when the row or the book has no ISBN. Invalid rows are skipped and listed in the report.
+// @Tags books
+// @Accept text/csv
+// @Accept application/x-ndjson
+// @Accept application/xml
+// @Produce json
+// @Param format query string false "csv, jsonl or onix; taken from the Content-Type when missing"
+// @Param dry_run query bool false "Report what the import would do without changing anything"
+// @Success 200 {object} model.ImportReportResponse
+// @Failure 400 {object} model.ProblemDetail "Bad Request"
+// @Failure 401 {object} model.ProblemDetail "Unauthorized"
+// @Failure 413 {object} model.ProblemDetail "File too large"
+// @Failure 422 {object} model.ProblemDetail "Unreadable file"
+// @Failure 500 {object} model.ProblemDetail "Internal Server Error"
+// @Security ApiKeyAuth
+// @Router /book/import [post]
+func (s *Server) handleImportBooks(w http.ResponseWriter, r *http.Request) {
+	format := r.URL.Query().Get("format")
+	if format == "" {
+		format = importFormat(r.Header.Get("Content-Type"))
+	}
+	if format == "" {
+		model.InvalidRequest(w, "Unknown import format, set format to csv, jsonl or onix", r.URL.Path)
+		return
+	}
+	dryRun := false
+	if value := r.URL.Query().Get("dry_run"); value != "" {
+		var err error
+		if dryRun, err = strconv.ParseBool(value); err != nil {
+			model.InvalidRequest(w, "Invalid dry_run", r.URL.Path)
+			return
+		}
+	}
+
+	body := http.MaxBytesReader(w, r.Body, maxImportSize)
+	report, err := s.importService.ImportBooks(r.Context(), body, format, dryRun)
+	if err != nil {
+		var tooLarge *http.MaxBytesError
+		switch {
+		case errors.As(err, &tooLarge):
+			model.WriteProblemDetail(w, http.StatusRequestEntityTooLarge, "Request Entity Too Large",
+				"The import file is too large", r.URL.Path)
+		case errors.Is(err, domain.ErrInvalidImport):
+			model.ValidationError(w, err.Error(), r.URL.Path)
+		default:
+			slog.Error("error importing books", "error", err)
+			model.InternalServerError(w, r.URL.Path)
+		}
+		return
+	}
+
+	writeResponseOK(w, toImportReportResponse(report))
+}
+
+// importFormat maps the content type of an import to its format, empty when unknown.
+func importFormat(contentType string) string {
+	mediaType, _, _ := mime.ParseMediaType(contentType)
+	switch mediaType {
+	case "text/csv":
+		return domain.ImportFormatCSV
+	case "application/x-ndjson", "application/jsonl", "application/x-jsonlines":
+		return domain.ImportFormatJSONL
+	case "application/xml", "text/xml":
+		return domain.ImportFormatONIX
+	default:
+		return ""
+	}
+}
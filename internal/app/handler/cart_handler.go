@@ -7,6 +7,7 @@ import (
 	"toptal/internal/app/domain"
 	"toptal/internal/app/handler/model"
 	"toptal/internal/app/util"
+	"toptal/internal/pkg/validator"
 )
 
 // @Summary Get user's cart
@@ -14,6 +15,7 @@ import (
 // @Tags cart
 // @Accept json
 // @Produce json
+// @Param currency query string false "ISO 4217 currency to show prices in, for books that have a price in it"
 // @Success 200 {array} model.BookResponse
 // @Failure 400 {object} model.ProblemDetail "Bad Request"
 // @Failure 401 {object} model.ProblemDetail "Unauthorized"
@@ -27,7 +29,12 @@ func (s *Server) handleGetCart(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	books, err := s.cartService.GetCart(r.Context(), userId)
+	currency, ok := currencyParam(w, r)
+	if !ok {
+		return
+	}
+
+	books, err := s.cartService.GetCart(r.Context(), userId, currency)
 	if err != nil {
 		model.InternalServerError(w, r.URL.Path)
 		return
@@ -38,7 +45,7 @@ func (s *Server) handleGetCart(w http.ResponseWriter, r *http.Request) {
 }
 
 // @Summary Add book to cart
-// @Description Add a book to the current user's shopping cart
+// @Description Add a book, or one of its formats, to the current user's shopping cart
 // @Tags cart
 // @Accept json
 // @Produce json
@@ -47,6 +54,7 @@ func (s *Server) handleGetCart(w http.ResponseWriter, r *http.Request) {
 // @Failure 400 {object} model.ProblemDetail "Bad Request"
 // @Failure 401 {object} model.ProblemDetail "Unauthorized"
 // @Failure 404 {object} model.ProblemDetail "Book not found"
+// @Failure 422 {object} model.ProblemDetail "Book out of stock or format not for sale"
 // @Failure 500 {object} model.ProblemDetail "Internal Server Error"
 // @Security ApiKeyAuth
 // @Router /cart/add [post]
@@ -63,11 +71,13 @@ func (s *Server) handleAddToCart(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	if err := s.cartService.AddToCart(r.Context(), userId, cartRequest.BookId); err != nil {
+	if err := s.cartService.AddToCart(r.Context(), userId, cartRequest.BookId, cartRequest.Format); err != nil {
 		if errors.Is(err, domain.ErrBookNotFound) {
 			model.NotFound(w, "Book not found", r.URL.Path)
 		} else if errors.Is(err, domain.ErrBookOutOfStock) {
 			model.ValidationError(w, "Book out of stock", r.URL.Path)
+		} else if errors.Is(err, domain.ErrInvalidFormat) || errors.Is(err, domain.ErrFormatFileMissing) {
+			model.ValidationError(w, err.Error(), r.URL.Path)
 		} else {
 			model.InternalServerError(w, r.URL.Path)
 		}
@@ -103,7 +113,7 @@ func (s *Server) handleRemoveFromCart(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	if err := s.cartService.RemoveFromCart(r.Context(), userId, cartRequest.BookId); err != nil {
+	if err := s.cartService.RemoveFromCart(r.Context(), userId, cartRequest.BookId, cartRequest.Format); err != nil {
 		if errors.Is(err, domain.ErrBookNotInCart) {
 			model.NotFound(w, "Book not found in cart", r.URL.Path)
 		} else {
@@ -116,14 +126,16 @@ func (s *Server) handleRemoveFromCart(w http.ResponseWriter, r *http.Request) {
 }
 
 // @Summary Purchase cart
-// @Description Purchase all books in the current user's shopping cart
+// @Description Purchase all books in the current user's shopping cart and ship them to the given address. Without a currency the order is in the currency the books are priced in; a cart with books in several currencies needs one. Tax is charged by the tax class of each book's category and the region of the shipping address.
 // @Tags cart
 // @Accept json
 // @Produce json
-// @Success 202 {string} string "Accepted"
+// @Param currency query string false "ISO 4217 currency to pay in"
+// @Param purchase body model.PurchaseRequest true "Shipping address"
+// @Success 202 {object} model.OrderResponse
 // @Failure 400 {object} model.ProblemDetail "Bad Request"
 // @Failure 401 {object} model.ProblemDetail "Unauthorized"
-// @Failure 422 {object} model.ProblemDetail "Insufficient stock"
+// @Failure 422 {object} model.ProblemDetail "Invalid address, insufficient stock or no price in the currency"
 // @Failure 500 {object} model.ProblemDetail "Internal Server Error"
 // @Security ApiKeyAuth
 // @Router /cart/purchase [post]
@@ -134,10 +146,33 @@ func (s *Server) handlePurchase(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	if err := s.cartService.Purchase(r.Context(), userId); err != nil {
+	currency, ok := currencyParam(w, r)
+	if !ok {
+		return
+	}
+
+	var request model.PurchaseRequest
+	if err := json.NewDecoder(r.Body).Decode(&request); err != nil {
+		model.InvalidRequest(w, err.Error(), r.URL.Path)
+		return
+	}
+	if err := validator.Validate(request); err != nil {
+		model.ValidationError(w, err.Error(), r.URL.Path)
+		return
+	}
+	address, err := toShippingAddress(*request.ShippingAddress)
+	if err != nil {
+		model.ValidationError(w, err.Error(), r.URL.Path)
+		return
+	}
+
+	order, err := s.cartService.Purchase(r.Context(), userId, currency, address)
+	if err != nil {
 		switch {
 		case errors.Is(err, domain.ErrBookOutOfStock):
 			model.ValidationError(w, "Book out of stock", r.URL.Path)
+		case errors.Is(err, domain.ErrPriceUnavailable):
+			model.ValidationError(w, err.Error(), r.URL.Path)
 		case errors.Is(err, domain.ErrCartEmpty):
 			model.NotFound(w, "Cart empty", r.URL.Path)
 		default:
@@ -146,5 +181,5 @@ func (s *Server) handlePurchase(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	w.WriteHeader(http.StatusAccepted)
+	writeResponseAccepted(w, toOrderResponse(order))
 }
@@ -3,6 +3,7 @@ package handler
 import (
 	"encoding/json"
 	"errors"
+	"log/slog"
 	"net/http"
 	"strconv"
 	"toptal/internal/app/domain"
@@ -30,7 +31,12 @@ func (s *Server) handleGetCategoryById(w http.ResponseWriter, r *http.Request) {
 
 	category, err := s.categoryService.GetCategoryById(r.Context(), id)
 	if err != nil {
-		model.InternalServerError(w, r.URL.Path)
+		if errors.Is(err, domain.ErrNotFound) {
+			model.NotFound(w, "Category Not Found", r.URL.Path)
+		} else {
+			slog.Error("error getting category by id", "error", err)
+			model.InternalServerError(w, r.URL.Path)
+		}
 		return
 	}
 
@@ -109,6 +115,7 @@ func (s *Server) handleCreateCategory(w http.ResponseWriter, r *http.Request) {
 // @Failure 400 {object} model.ProblemDetail "Bad Request"
 // @Failure 401 {object} model.ProblemDetail "Unauthorized"
 // @Failure 404 {object} model.ProblemDetail "Not Found"
+// @Failure 409 {object} model.ProblemDetail "Category already exists"
 // @Failure 500 {object} model.ProblemDetail "Internal Server Error"
 // @Security ApiKeyAuth
 // @Router /category [put]
@@ -130,7 +137,15 @@ func (s *Server) handleUpdateCategory(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 	if err := s.categoryService.UpdateCategory(r.Context(), category); err != nil {
-		model.InternalServerError(w, r.URL.Path)
+		switch {
+		case errors.Is(err, domain.ErrNotFound):
+			model.NotFound(w, "Category Not Found", r.URL.Path)
+		case errors.Is(err, domain.ErrAlreadyExists):
+			model.AlreadyExists(w, "Category Already Exists", r.URL.Path)
+		default:
+			slog.Error("error updating category", "error", err)
+			model.InternalServerError(w, r.URL.Path)
+		}
 		return
 	}
 
@@ -138,7 +153,7 @@ func (s *Server) handleUpdateCategory(w http.ResponseWriter, r *http.Request) {
 }
 
 // @Summary Delete a category
-// @Description Delete a category by its ID
+// @Description Archive a category by its ID. Categories with books in the catalogue are refused
 // @Tags categories
 // @Accept json
 // @Produce json
@@ -147,6 +162,7 @@ func (s *Server) handleUpdateCategory(w http.ResponseWriter, r *http.Request) {
 // @Failure 400 {object} model.ProblemDetail "Bad Request"
 // @Failure 401 {object} model.ProblemDetail "Unauthorized"
 // @Failure 404 {object} model.ProblemDetail "Not Found"
+// @Failure 409 {object} model.ProblemDetail "Conflict"
 // @Failure 500 {object} model.ProblemDetail "Internal Server Error"
 // @Security ApiKeyAuth
 // @Router /category/{id} [delete]
@@ -158,9 +174,72 @@ func (s *Server) handleDeleteCategory(w http.ResponseWriter, r *http.Request) {
 	}
 
 	if err := s.categoryService.DeleteCategory(r.Context(), id); err != nil {
-		model.InternalServerError(w, r.URL.Path)
+		if errors.Is(err, domain.ErrNotFound) {
+			model.NotFound(w, "Category Not Found", r.URL.Path)
+		} else if errors.Is(err, domain.ErrCategoryInUse) {
+			model.WriteProblemDetail(w, http.StatusConflict, "Category In Use",
+				"Archive or move the category's books first", r.URL.Path)
+		} else {
+			slog.Error("error deleting category", "error", err)
+			model.InternalServerError(w, r.URL.Path)
+		}
 		return
 	}
 
 	w.WriteHeader(http.StatusOK)
 }
+
+// @Summary Get archived categories
+// @Description Get categories removed from the catalogue, most recently archived first
+// @Tags categories
+// @Accept json
+// @Produce json
+// @Success 200 {array} model.ArchivedCategoryResponse
+// @Failure 401 {object} model.ProblemDetail "Unauthorized"
+// @Failure 500 {object} model.ProblemDetail "Internal Server Error"
+// @Security ApiKeyAuth
+// @Router /category/archived [get]
+func (s *Server) handleGetArchivedCategories(w http.ResponseWriter, r *http.Request) {
+	categories, err := s.categoryService.GetArchivedCategories(r.Context())
+	if err != nil {
+		slog.Error("error getting archived categories", "error", err)
+		model.InternalServerError(w, r.URL.Path)
+		return
+	}
+
+	writeResponseOK(w, toArchivedCategoriesResponse(categories))
+}
+
+// @Summary Restore a category
+// @Description Put an archived category back in the catalogue
+// @Tags categories
+// @Accept json
+// @Produce json
+// @Param id path int true "Category ID"
+// @Success 200 {object} model.CategoryResponse
+// @Failure 400 {object} model.ProblemDetail "Bad Request"
+// @Failure 401 {object} model.ProblemDetail "Unauthorized"
+// @Failure 404 {object} model.ProblemDetail "Not Found"
+// @Failure 500 {object} model.ProblemDetail "Internal Server Error"
+// @Security ApiKeyAuth
+// @Router /category/{id}/restore [post]
+func (s *Server) handleRestoreCategory(w http.ResponseWriter, r *http.Request) {
+	id, err := strconv.Atoi(r.PathValue("id"))
+	if err != nil {
+		model.WriteProblemDetail(w, http.StatusBadRequest, "Invalid Category ID", err.Error(), r.URL.Path)
+		return
+	}
+
+	category, err := s.categoryService.RestoreCategory(r.Context(), id)
+	if err != nil {
+		if errors.Is(err, domain.ErrNotFound) {
+			model.NotFound(w, "Archived Category Not Found", r.URL.Path)
+		} else {
+			slog.Error("error restoring category", "error", err)
+			model.InternalServerError(w, r.URL.Path)
+		}
+		return
+	}
+
+	writeResponseOK(w, toCategoryResponse(category))
+}
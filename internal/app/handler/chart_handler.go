@@ -0,0 +1,121 @@
+package handler
+
+import (
+	"log/slog"
+	"net/http"
+	"strconv"
+	"toptal/internal/app/domain"
+	"toptal/internal/app/handler/model"
+)
+
+// chartParams reads the category, offset and currency query parameters shared by the
+// charts. It writes a problem detail and returns false when one is invalid.
+func chartParams(w http.ResponseWriter, r *http.Request) (categoryId, offset int, currency string, ok bool) {
+	if value := r.URL.Query().Get("categoryId"); value != "" {
+		id, err := strconv.Atoi(value)
+		if err != nil || id <= 0 {
+			model.InvalidRequest(w, "Invalid Category ID", r.URL.Path)
+			return 0, 0, "", false
+		}
+		categoryId = id
+	}
+	offset, err := strconv.Atoi(r.URL.Query().Get("offset"))
+	if err != nil || offset < 0 {
+		offset = 0
+	}
+	currency, ok = currencyParam(w, r)
+	return categoryId, offset, currency, ok
+}
+
+// @Summary Get bestsellers
+// @Description List the books that sold most copies over the last week, month or year, overall or in a category. The chart is ranked every few minutes, not on request.
+// @Tags charts
+// @Produce json
+// @Param period query string false "Period to count sales over" Enums(week, month, year) default(month)
+// @Param categoryId query int false "Category ID to filter by"
+// @Param limit query int false "Number of books, at most 50" default(10)
+// @Param offset query int false "Offset for pagination" default(0)
+// @Param currency query string false "ISO 4217 currency to show prices in, for books that have a price in it"
+// @Success 200 {array} model.ChartBookResponse
+// @Failure 400 {object} model.ProblemDetail "Bad Request"
+// @Failure 500 {object} model.ProblemDetail "Internal Server Error"
+// @Router /book/bestsellers [get]
+func (s *Server) handleGetBestsellers(w http.ResponseWriter, r *http.Request) {
+	period := r.URL.Query().Get("period")
+	if period == "" {
+		period = domain.ChartPeriodMonth
+	}
+	if !domain.IsChartPeriod(period) {
+		model.InvalidRequest(w, "Invalid Period", r.URL.Path)
+		return
+	}
+	categoryId, offset, currency, ok := chartParams(w, r)
+	if !ok {
+		return
+	}
+
+	books, err := s.chartService.GetBestsellers(r.Context(), period, categoryId, listLimitParam(r), offset, currency)
+	if err != nil {
+		slog.Error("error getting bestsellers", "error", err)
+		model.InternalServerError(w, r.URL.Path)
+		return
+	}
+
+	writeResponseOK(w, toChartResponse(books, offset))
+}
+
+// @Summary Get trending books
+// @Description List the books put in carts more often a day lately than over the weeks before, fastest rising first, overall or in a category. The chart is ranked every few minutes, not on request.
+// @Tags charts
+// @Produce json
+// @Param categoryId query int false "Category ID to filter by"
+// @Param limit query int false "Number of books, at most 50" default(10)
+// @Param offset query int false "Offset for pagination" default(0)
+// @Param currency query string false "ISO 4217 currency to show prices in, for books that have a price in it"
+// @Success 200 {array} model.ChartBookResponse
+// @Failure 400 {object} model.ProblemDetail "Bad Request"
+// @Failure 500 {object} model.ProblemDetail "Internal Server Error"
+// @Router /book/trending [get]
+func (s *Server) handleGetTrending(w http.ResponseWriter, r *http.Request) {
+	categoryId, offset, currency, ok := chartParams(w, r)
+	if !ok {
+		return
+	}
+
+	books, err := s.chartService.GetTrending(r.Context(), categoryId, listLimitParam(r), offset, currency)
+	if err != nil {
+		slog.Error("error getting trending books", "error", err)
+		model.InternalServerError(w, r.URL.Path)
+		return
+	}
+
+	writeResponseOK(w, toChartResponse(books, offset))
+}
+
+// @Summary Get new arrivals
+// @Description List the books most recently added to the catalogue, overall or in a category.
+// @Tags charts
+// @Produce json
+// @Param categoryId query int false "Category ID to filter by"
+// @Param limit query int false "Number of books, at most 50" default(10)
+// @Param offset query int false "Offset for pagination" default(0)
+// @Param currency query string false "ISO 4217 currency to show prices in, for books that have a price in it"
+// @Success 200 {array} model.ChartBookResponse
+// @Failure 400 {object} model.ProblemDetail "Bad Request"
+// @Failure 500 {object} model.ProblemDetail "Internal Server Error"
+// @Router /book/new-arrivals [get]
+func (s *Server) handleGetNewArrivals(w http.ResponseWriter, r *http.Request) {
+	categoryId, offset, currency, ok := chartParams(w, r)
+	if !ok {
+		return
+	}
+
+	books, err := s.chartService.GetNewArrivals(r.Context(), categoryId, listLimitParam(r), offset, currency)
+	if err != nil {
+		slog.Error("error getting new arrivals", "error", err)
+		model.InternalServerError(w, r.URL.Path)
+		return
+	}
+
+	writeResponseOK(w, toChartResponse(books, offset))
+}
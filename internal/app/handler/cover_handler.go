@@ -0,0 +1,165 @@
+package handler
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"mime"
+	"net/http"
+	"strconv"
+	"toptal/internal/app/domain"
+	"toptal/internal/app/handler/model"
+)
+
+// coverFormField is the field of the multipart form a cover is uploaded in.
+const coverFormField = "cover"
+
+// coverMaxAge is how long clients may cache a cover requested without its current
+// version. Versioned URLs change with the cover and are cached for a year.
+const coverMaxAge = 3600
+
+// @Summary Upload a book cover
+// @Description Upload a JPEG, PNG or GIF image as the cover of a book, in the "cover" field of a multipart form. Medium and thumbnail JPEG renditions are made from it. It replaces the cover the book had.
+// @Tags covers
+// @Accept multipart/form-data
+// @Produce json
+// @Param id path int true "Book ID"
+// @Param cover formData file true "Cover image"
+// @Success 200 {object} model.CoverResponse
+// @Failure 400 {object} model.ProblemDetail "Bad Request"
+// @Failure 401 {object} model.ProblemDetail "Unauthorized"
+// @Failure 404 {object} model.ProblemDetail "Not Found"
+// @Failure 413 {object} model.ProblemDetail "File Too Large"
+// @Failure 422 {object} model.ProblemDetail "Unsupported or invalid image"
+// @Failure 500 {object} model.ProblemDetail "Internal Server Error"
+// @Security ApiKeyAuth
+// @Router /book/{id}/cover [put]
+func (s *Server) handleUploadCover(w http.ResponseWriter, r *http.Request) {
+	bookId, err := strconv.Atoi(r.PathValue("id"))
+	if err != nil {
+		model.InvalidRequest(w, "Invalid Book ID", r.URL.Path)
+		return
+	}
+
+	reader, err := r.MultipartReader()
+	if err != nil {
+		model.InvalidRequest(w, "Covers are uploaded as multipart/form-data", r.URL.Path)
+		return
+	}
+	for {
+		part, err := reader.NextPart()
+		if errors.Is(err, io.EOF) {
+			model.InvalidRequest(w, fmt.Sprintf("The form has no %q file", coverFormField), r.URL.Path)
+			return
+		}
+		if err != nil {
+			model.InvalidRequest(w, err.Error(), r.URL.Path)
+			return
+		}
+		if part.FormName() != coverFormField || part.FileName() == "" {
+			continue
+		}
+
+		contentType, _, _ := mime.ParseMediaType(part.Header.Get("Content-Type"))
+		cover, err := s.coverService.UploadCover(r.Context(), bookId, contentType, part)
+		if err != nil {
+			switch {
+			case errors.Is(err, domain.ErrFileTooLarge):
+				model.WriteProblemDetail(w, http.StatusRequestEntityTooLarge, "File Too Large", err.Error(), r.URL.Path)
+			case errors.Is(err, domain.ErrInvalidImage):
+				model.ValidationError(w, err.Error(), r.URL.Path)
+			case errors.Is(err, domain.ErrNotFound):
+				model.NotFound(w, "Book Not Found", r.URL.Path)
+			default:
+				slog.Error("error uploading cover", "error", err)
+				model.InternalServerError(w, r.URL.Path)
+			}
+			return
+		}
+
+		writeResponseOK(w, toCoverResponse(bookId, cover))
+		return
+	}
+}
+
+// @Summary Remove a book cover
+// @Description Remove the cover image of a book and its renditions
+// @Tags covers
+// @Produce json
+// @Param id path int true "Book ID"
+// @Success 200
+// @Failure 400 {object} model.ProblemDetail "Bad Request"
+// @Failure 401 {object} model.ProblemDetail "Unauthorized"
+// @Failure 404 {object} model.ProblemDetail "Not Found"
+// @Failure 500 {object} model.ProblemDetail "Internal Server Error"
+// @Security ApiKeyAuth
+// @Router /book/{id}/cover [delete]
+func (s *Server) handleDeleteCover(w http.ResponseWriter, r *http.Request) {
+	bookId, err := strconv.Atoi(r.PathValue("id"))
+	if err != nil {
+		model.InvalidRequest(w, "Invalid Book ID", r.URL.Path)
+		return
+	}
+
+	if err := s.coverService.DeleteCover(r.Context(), bookId); err != nil {
+		switch {
+		case errors.Is(err, domain.ErrNotFound):
+			model.NotFound(w, "Cover Not Found", r.URL.Path)
+		default:
+			slog.Error("error deleting cover", "error", err)
+			model.InternalServerError(w, r.URL.Path)
+		}
+		return
+	}
+
+	w.WriteHeader(http.StatusOK)
+}
+
+// @Summary Get a book cover
+// @Description Get a rendition of the cover of a book: the uploaded original, or a medium or thumbnail JPEG. The URLs in BookResponse carry the version of the cover and may be cached for good.
+// @Tags covers
+// @Produce image/jpeg,image/png,image/gif
+// @Param id path int true "Book ID"
+// @Param rendition path string true "Rendition" Enums(original, medium, thumbnail)
+// @Param v query string false "Version of the cover"
+// @Success 200 {file} file
+// @Success 304 "Not Modified"
+// @Failure 400 {object} model.ProblemDetail "Bad Request"
+// @Failure 404 {object} model.ProblemDetail "Not Found"
+// @Failure 500 {object} model.ProblemDetail "Internal Server Error"
+// @Router /book/{id}/cover/{rendition} [get]
+func (s *Server) handleGetCover(w http.ResponseWriter, r *http.Request) {
+	bookId, err := strconv.Atoi(r.PathValue("id"))
+	if err != nil {
+		model.InvalidRequest(w, "Invalid Book ID", r.URL.Path)
+		return
+	}
+
+	rendition := r.PathValue("rendition")
+	cover, file, err := s.coverService.OpenCover(r.Context(), bookId, rendition)
+	if err != nil {
+		switch {
+		case errors.Is(err, domain.ErrNotFound):
+			model.NotFound(w, "Cover Not Found", r.URL.Path)
+		default:
+			slog.Error("error opening cover", "error", err)
+			model.InternalServerError(w, r.URL.Path)
+		}
+		return
+	}
+	defer func() {
+		if err := file.Close(); err != nil {
+			slog.Error("failed to close cover", "error", err)
+		}
+	}()
+
+	w.Header().Set("Content-Type", cover.RenditionContentType(rendition))
+	w.Header().Set("ETag", strconv.Quote(cover.Version()+"-"+rendition))
+	if r.URL.Query().Get("v") == cover.Version() {
+		w.Header().Set("Cache-Control", "public, max-age=31536000, immutable")
+	} else {
+		w.Header().Set("Cache-Control", "public, max-age="+strconv.Itoa(coverMaxAge))
+	}
+	http.ServeContent(w, r, "", cover.UpdatedAt(), file)
+}
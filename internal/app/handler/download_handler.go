@@ -0,0 +1,134 @@
+package handler
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+	"mime"
+	"net/http"
+	"strconv"
+	"time"
+	"toptal/internal/app/domain"
+	"toptal/internal/app/handler/model"
+	"toptal/internal/app/util"
+)
+
+// @Summary List downloads
+// @Description List the digital formats the current user bought, each with a signed download link that expires after a while. Listing again gives fresh links. Each purchase can be downloaded a limited number of times.
+// @Tags profile
+// @Produce json
+// @Success 200 {array} model.DownloadResponse
+// @Failure 401 {object} model.ProblemDetail "Unauthorized"
+// @Failure 500 {object} model.ProblemDetail "Internal Server Error"
+// @Security ApiKeyAuth
+// @Router /me/downloads [get]
+func (s *Server) handleGetDownloads(w http.ResponseWriter, r *http.Request) {
+	userId, err := util.GetUserID(r.Context())
+	if err != nil {
+		model.Unauthorized(w, "unauthorized", r.URL.Path)
+		return
+	}
+
+	links, err := s.downloadService.GetDownloads(r.Context(), userId)
+	if err != nil {
+		slog.Error("error getting downloads", "error", err)
+		model.InternalServerError(w, r.URL.Path)
+		return
+	}
+
+	writeResponseOK(w, toDownloadsResponse(links))
+}
+
+// @Summary Download a digital format
+// @Description Download a bought epub or pdf through a signed link from GET /me/downloads. Each transfer counts against the limit of the purchase, except a range request continuing an interrupted transfer from the byte where it stopped.
+// @Tags profile
+// @Produce application/epub+zip,application/pdf,application/octet-stream
+// @Param id path int true "Download ID"
+// @Param expires query int true "Expiry of the link, in Unix seconds"
+// @Param signature query string true "Signature of the link"
+// @Success 200 {file} file
+// @Success 206 {file} file
+// @Failure 400 {object} model.ProblemDetail "Bad Request"
+// @Failure 403 {object} model.ProblemDetail "Invalid or expired link, or download limit reached"
+// @Failure 404 {object} model.ProblemDetail "Not Found"
+// @Failure 500 {object} model.ProblemDetail "Internal Server Error"
+// @Router /downloads/{id} [get]
+func (s *Server) handleDownload(w http.ResponseWriter, r *http.Request) {
+	id, err := strconv.Atoi(r.PathValue("id"))
+	if err != nil {
+		model.InvalidRequest(w, "Invalid Download ID", r.URL.Path)
+		return
+	}
+	expires, err := strconv.ParseInt(r.URL.Query().Get("expires"), 10, 64)
+	if err != nil {
+		model.InvalidRequest(w, "Invalid Expiry", r.URL.Path)
+		return
+	}
+
+	// The range is always served, so that a resume cannot turn into a free full download.
+	r.Header.Del("If-Range")
+	rangeHeader := r.Header.Get("Range")
+	download, file, err := s.downloadService.OpenDownload(r.Context(), id, expires, r.URL.Query().Get("signature"), rangeHeader)
+	if err != nil {
+		switch {
+		case errors.Is(err, domain.ErrInvalidDownloadLink):
+			model.Forbidden(w, "Invalid or expired download link", r.URL.Path)
+		case errors.Is(err, domain.ErrDownloadLimitReached):
+			model.WriteProblemDetail(w, http.StatusForbidden, "Download Limit Reached", err.Error(), r.URL.Path)
+		case errors.Is(err, domain.ErrNotFound):
+			model.NotFound(w, "Download Not Found", r.URL.Path)
+		default:
+			slog.Error("error opening download", "error", err)
+			model.InternalServerError(w, r.URL.Path)
+		}
+		return
+	}
+	defer func() {
+		if err := file.Close(); err != nil {
+			slog.Error("failed to close download", "error", err)
+		}
+	}()
+
+	// A large file on a slow connection can outlast the server's write timeout.
+	if err := http.NewResponseController(w).SetWriteDeadline(time.Time{}); err != nil {
+		slog.Warn("failed to clear the write deadline of a download", "error", err)
+	}
+
+	info, _ := download.File()
+	w.Header().Set("Content-Type", info.ContentType())
+	w.Header().Set("Content-Disposition", mime.FormatMediaType("attachment", map[string]string{"filename": info.Name()}))
+	w.Header().Set("Cache-Control", "private, no-store")
+	counter := &countingWriter{ResponseWriter: w, status: http.StatusOK}
+	http.ServeContent(counter, r, "", download.CreatedAt(), file)
+
+	// The transfer is recorded even when the client went away, which is when it matters.
+	if counter.status == http.StatusOK || counter.status == http.StatusPartialContent {
+		err := s.downloadService.RecordTransfer(context.WithoutCancel(r.Context()), download, rangeHeader, counter.written)
+		if err != nil {
+			slog.Error("failed to record download transfer", "error", err)
+		}
+	}
+}
+
+// countingWriter counts the bytes of the response body that were sent.
+type countingWriter struct {
+	http.ResponseWriter
+	status  int
+	written int64
+}
+
+func (w *countingWriter) WriteHeader(code int) {
+	w.status = code
+	w.ResponseWriter.WriteHeader(code)
+}
+
+func (w *countingWriter) Write(b []byte) (int, error) {
+	n, err := w.ResponseWriter.Write(b)
+	w.written += int64(n)
+	return n, err
+}
+
+// Unwrap lets http.ResponseController reach the underlying writer.
+func (w *countingWriter) Unwrap() http.ResponseWriter {
+	return w.ResponseWriter
+}
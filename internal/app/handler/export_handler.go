@@ -0,0 +1,164 @@
+package handler
+
+import (
+	"fmt"
+	"log/slog"
+	"mime"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+	"toptal/internal/app/domain"
+	"toptal/internal/app/handler/model"
+	"toptal/internal/pkg/xlsx"
+)
+
+// exportContentTypes maps the export formats to the content type they are sent with.
+var exportContentTypes = map[string]string{
+	domain.ExportFormatCSV:   "text/csv; charset=utf-8",
+	domain.ExportFormatJSONL: "application/x-ndjson",
+	domain.ExportFormatXLSX:  xlsx.ContentType,
+}
+
+// exportMediaTypes maps the media types clients may ask for to an export format.
+var exportMediaTypes = map[string]string{
+	"text/csv":                 domain.ExportFormatCSV,
+	"text/*":                   domain.ExportFormatCSV,
+	"*/*":                      domain.ExportFormatCSV,
+	"application/x-ndjson":     domain.ExportFormatJSONL,
+	"application/jsonl":        domain.ExportFormatJSONL,
+	"application/x-jsonlines":  domain.ExportFormatJSONL,
+	xlsx.ContentType:           domain.ExportFormatXLSX,
+	"application/vnd.ms-excel": domain.ExportFormatXLSX,
+}
+
+// @Summary Export books
+// @Description Stream the catalogue, or the books of some categories, as CSV, JSON Lines or XLSX. The format is taken from the format parameter or else from the Accept header, and defaults to CSV. Books carry the name of their category, and CSV exports can be imported again.
+// @Tags books
+// @Produce text/csv
+// @Produce application/x-ndjson
+// @Produce application/vnd.openxmlformats-officedocument.spreadsheetml.sheet
+// @Param format query string false "csv, jsonl or xlsx"
+// @Param categoryId query []int false "Category IDs to export"
+// @Param in_stock query bool false "Only export books in stock"
+// @Param include_archived query bool false "Also export archived books"
+// @Success 200 {file} file
+// @Failure 400 {object} model.ProblemDetail "Bad Request"
+// @Failure 401 {object} model.ProblemDetail "Unauthorized"
+// @Failure 406 {object} model.ProblemDetail "Format not supported"
+// @Failure 500 {object} model.ProblemDetail "Internal Server Error"
+// @Security ApiKeyAuth
+// @Router /export/books [get]
+func (s *Server) handleExportBooks(w http.ResponseWriter, r *http.Request) {
+	query := r.URL.Query()
+	format := query.Get("format")
+	if format == "" {
+		var ok bool
+		if format, ok = negotiateExportFormat(r.Header.Get("Accept")); !ok {
+			model.WriteProblemDetail(w, http.StatusNotAcceptable, "Not Acceptable",
+				"Accept text/csv, application/x-ndjson or "+xlsx.ContentType, r.URL.Path)
+			return
+		}
+	}
+	contentType, ok := exportContentTypes[format]
+	if !ok {
+		model.InvalidRequest(w, "Unknown export format, use csv, jsonl or xlsx", r.URL.Path)
+		return
+	}
+
+	var filter domain.BookExportFilter
+	for _, v := range query["categoryId"] {
+		id, err := strconv.Atoi(v)
+		if err != nil {
+			model.WriteProblemDetail(w, http.StatusBadRequest, "Invalid Category ID", err.Error(), r.URL.Path)
+			return
+		}
+		filter.CategoryIds = append(filter.CategoryIds, id)
+	}
+	var err error
+	if filter.InStockOnly, err = boolQuery(r, "in_stock"); err != nil {
+		model.InvalidRequest(w, err.Error(), r.URL.Path)
+		return
+	}
+	if filter.IncludeArchived, err = boolQuery(r, "include_archived"); err != nil {
+		model.InvalidRequest(w, err.Error(), r.URL.Path)
+		return
+	}
+
+	// An export of the whole catalogue can outlast the server's write timeout.
+	if err := http.NewResponseController(w).SetWriteDeadline(time.Time{}); err != nil {
+		slog.Warn("failed to clear the write deadline of an export", "error", err)
+	}
+	out := &exportWriter{ResponseWriter: w}
+	w.Header().Set("Content-Type", contentType)
+	w.Header().Set("Content-Disposition",
+		fmt.Sprintf(`attachment; filename="books-%s.%s"`, time.Now().UTC().Format("2006-01-02"), format))
+
+	if err := s.exportService.ExportBooks(r.Context(), out, format, filter); err != nil {
+		if !out.written {
+			w.Header().Del("Content-Disposition")
+			slog.Error("error exporting books", "error", err)
+			model.InternalServerError(w, r.URL.Path)
+			return
+		}
+		// Part of the file is sent already; break the connection so the client does not
+		// take it for the whole export.
+		slog.Error("export failed after it started", "error", err)
+		panic(http.ErrAbortHandler)
+	}
+}
+
+// exportWriter records whether anything of an export reached the client.
+type exportWriter struct {
+	http.ResponseWriter
+	written bool
+}
+
+func (w *exportWriter) Write(b []byte) (int, error) {
+	w.written = true
+	return w.ResponseWriter.Write(b)
+}
+
+// negotiateExportFormat picks the export format from an Accept header, preferring the
+// media type with the highest quality. An empty header means CSV.
+func negotiateExportFormat(accept string) (string, bool) {
+	if strings.TrimSpace(accept) == "" {
+		return domain.ExportFormatCSV, true
+	}
+	format, best, bestSpecific := "", 0.0, false
+	for _, part := range strings.Split(accept, ",") {
+		mediaType, params, err := mime.ParseMediaType(strings.TrimSpace(part))
+		if err != nil {
+			continue
+		}
+		candidate, ok := exportMediaTypes[mediaType]
+		if !ok {
+			continue
+		}
+		quality := 1.0
+		if q, ok := params["q"]; ok {
+			if quality, err = strconv.ParseFloat(q, 64); err != nil {
+				continue
+			}
+		}
+		// A type named outright beats a wildcard of the same quality.
+		specific := !strings.HasSuffix(mediaType, "/*")
+		if quality > best || (quality == best && specific && !bestSpecific) {
+			format, best, bestSpecific = candidate, quality, specific
+		}
+	}
+	return format, format != ""
+}
+
+// boolQuery reads an optional boolean query parameter, false when it is missing.
+func boolQuery(r *http.Request, name string) (bool, error) {
+	value := r.URL.Query().Get(name)
+	if value == "" {
+		return false, nil
+	}
+	b, err := strconv.ParseBool(value)
+	if err != nil {
+		return false, fmt.Errorf("invalid %s", name)
+	}
+	return b, nil
+}
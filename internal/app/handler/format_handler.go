@@ -0,0 +1,183 @@
+package handler
+
+import (
+	"encoding/json"
+	"errors"
+	"log/slog"
+	"net/http"
+	"strconv"
+	"time"
+	"toptal/internal/app/domain"
+	"toptal/internal/app/handler/model"
+	"toptal/internal/pkg/validator"
+)
+
+// @Summary Get the formats of a book
+// @Description List the formats a book is sold in besides the book itself: hardcover, paperback, epub and pdf, each with its own price. Digital formats have no stock and are available once their file is uploaded.
+// @Tags formats
+// @Accept json
+// @Produce json
+// @Param id path int true "Book ID"
+// @Success 200 {array} model.BookFormatResponse
+// @Failure 400 {object} model.ProblemDetail "Bad Request"
+// @Failure 500 {object} model.ProblemDetail "Internal Server Error"
+// @Router /book/{id}/formats [get]
+func (s *Server) handleGetFormats(w http.ResponseWriter, r *http.Request) {
+	bookId, err := strconv.Atoi(r.PathValue("id"))
+	if err != nil {
+		model.InvalidRequest(w, "Invalid Book ID", r.URL.Path)
+		return
+	}
+
+	formats, err := s.formatService.GetFormats(r.Context(), bookId)
+	if err != nil {
+		slog.Error("error getting book formats", "error", err)
+		model.InternalServerError(w, r.URL.Path)
+		return
+	}
+
+	writeResponseOK(w, toBookFormatsResponse(formats))
+}
+
+// @Summary Sell a book in a format
+// @Description Add or replace a format of a book with its price and, for hardcover and paperback, its stock. The stock of epub and pdf is ignored; they can be sold once their file is uploaded.
+// @Tags formats
+// @Accept json
+// @Produce json
+// @Param id path int true "Book ID"
+// @Param format path string true "Format" Enums(hardcover, paperback, epub, pdf)
+// @Param request body model.BookFormatRequest true "Price and stock"
+// @Success 200 {object} model.BookFormatResponse
+// @Failure 400 {object} model.ProblemDetail "Bad Request"
+// @Failure 401 {object} model.ProblemDetail "Unauthorized"
+// @Failure 404 {object} model.ProblemDetail "Not Found"
+// @Failure 422 {object} model.ProblemDetail "Unprocessable Entity"
+// @Failure 500 {object} model.ProblemDetail "Internal Server Error"
+// @Security ApiKeyAuth
+// @Router /book/{id}/formats/{format} [put]
+func (s *Server) handleSaveFormat(w http.ResponseWriter, r *http.Request) {
+	bookId, err := strconv.Atoi(r.PathValue("id"))
+	if err != nil {
+		model.InvalidRequest(w, "Invalid Book ID", r.URL.Path)
+		return
+	}
+
+	var request model.BookFormatRequest
+	if err := json.NewDecoder(r.Body).Decode(&request); err != nil {
+		model.InvalidRequest(w, err.Error(), r.URL.Path)
+		return
+	}
+	if err := validator.Validate(request); err != nil {
+		model.ValidationError(w, err.Error(), r.URL.Path)
+		return
+	}
+	format, err := domain.NewBookFormat(bookId, r.PathValue("format"), request.Price, request.Stock)
+	if err != nil {
+		model.ValidationError(w, err.Error(), r.URL.Path)
+		return
+	}
+
+	saved, err := s.formatService.SaveFormat(r.Context(), format)
+	if err != nil {
+		switch {
+		case errors.Is(err, domain.ErrNotFound):
+			model.NotFound(w, "Book Not Found", r.URL.Path)
+		default:
+			slog.Error("error saving book format", "error", err)
+			model.InternalServerError(w, r.URL.Path)
+		}
+		return
+	}
+
+	writeResponseOK(w, toBookFormatResponse(saved))
+}
+
+// @Summary Stop selling a book in a format
+// @Description Remove a format of a book, and its file for digital formats. Buyers can no longer download removed digital formats.
+// @Tags formats
+// @Accept json
+// @Produce json
+// @Param id path int true "Book ID"
+// @Param format path string true "Format" Enums(hardcover, paperback, epub, pdf)
+// @Success 200
+// @Failure 400 {object} model.ProblemDetail "Bad Request"
+// @Failure 401 {object} model.ProblemDetail "Unauthorized"
+// @Failure 404 {object} model.ProblemDetail "Not Found"
+// @Failure 500 {object} model.ProblemDetail "Internal Server Error"
+// @Security ApiKeyAuth
+// @Router /book/{id}/formats/{format} [delete]
+func (s *Server) handleDeleteFormat(w http.ResponseWriter, r *http.Request) {
+	bookId, err := strconv.Atoi(r.PathValue("id"))
+	if err != nil {
+		model.InvalidRequest(w, "Invalid Book ID", r.URL.Path)
+		return
+	}
+
+	if err := s.formatService.DeleteFormat(r.Context(), bookId, r.PathValue("format")); err != nil {
+		switch {
+		case errors.Is(err, domain.ErrNotFound):
+			model.NotFound(w, "Format Not Found", r.URL.Path)
+		default:
+			slog.Error("error deleting book format", "error", err)
+			model.InternalServerError(w, r.URL.Path)
+		}
+		return
+	}
+
+	w.WriteHeader(http.StatusOK)
+}
+
+// @Summary Upload the file of a digital format
+// @Description Upload the file buyers of an epub or pdf format download, as the raw request body. It replaces the file the format had.
+// @Tags formats
+// @Accept application/epub+zip,application/pdf,application/octet-stream
+// @Produce json
+// @Param id path int true "Book ID"
+// @Param format path string true "Format" Enums(epub, pdf)
+// @Param filename query string false "File name buyers download the file as"
+// @Success 200 {object} model.BookFormatResponse
+// @Failure 400 {object} model.ProblemDetail "Bad Request"
+// @Failure 401 {object} model.ProblemDetail "Unauthorized"
+// @Failure 404 {object} model.ProblemDetail "Not Found"
+// @Failure 413 {object} model.ProblemDetail "File Too Large"
+// @Failure 422 {object} model.ProblemDetail "Unprocessable Entity"
+// @Failure 500 {object} model.ProblemDetail "Internal Server Error"
+// @Security ApiKeyAuth
+// @Router /book/{id}/formats/{format}/file [put]
+func (s *Server) handleUploadFormatFile(w http.ResponseWriter, r *http.Request) {
+	bookId, err := strconv.Atoi(r.PathValue("id"))
+	if err != nil {
+		model.InvalidRequest(w, "Invalid Book ID", r.URL.Path)
+		return
+	}
+
+	contentType := r.Header.Get("Content-Type")
+	if contentType == "application/octet-stream" {
+		contentType = ""
+	}
+
+	// A large file on a slow connection can outlast the server's read timeout.
+	if err := http.NewResponseController(w).SetReadDeadline(time.Time{}); err != nil {
+		slog.Warn("failed to clear the read deadline of an upload", "error", err)
+	}
+
+	format, err := s.formatService.UploadFile(
+		r.Context(), bookId, r.PathValue("format"), r.URL.Query().Get("filename"), contentType, r.Body,
+	)
+	if err != nil {
+		switch {
+		case errors.Is(err, domain.ErrFileTooLarge):
+			model.WriteProblemDetail(w, http.StatusRequestEntityTooLarge, "File Too Large", err.Error(), r.URL.Path)
+		case errors.Is(err, domain.ErrInvalidFormat):
+			model.ValidationError(w, err.Error(), r.URL.Path)
+		case errors.Is(err, domain.ErrNotFound):
+			model.NotFound(w, "Format Not Found", r.URL.Path)
+		default:
+			slog.Error("error uploading format file", "error", err)
+			model.InternalServerError(w, r.URL.Path)
+		}
+		return
+	}
+
+	writeResponseOK(w, toBookFormatResponse(format))
+}
@@ -4,6 +4,9 @@ import (
 	"encoding/json"
 	"log/slog"
 	"net/http"
+	"strconv"
+	"toptal/internal/app/domain"
+	"toptal/internal/app/handler/model"
 )
 
 func writeResponse(w http.ResponseWriter, status int, response interface{}) {
@@ -22,3 +25,33 @@ func writeResponseOK(w http.ResponseWriter, response any) {
 func writeResponseCreated(w http.ResponseWriter, response interface{}) {
 	writeResponse(w, http.StatusCreated, response)
 }
+
+func writeResponseAccepted(w http.ResponseWriter, response any) {
+	writeResponse(w, http.StatusAccepted, response)
+}
+
+// currencyParam reads the optional currency query parameter. It writes a problem detail
+// and returns false when the currency is unknown.
+func currencyParam(w http.ResponseWriter, r *http.Request) (string, bool) {
+	currency := r.URL.Query().Get("currency")
+	if currency != "" && !domain.IsCurrency(currency) {
+		model.InvalidRequest(w, "Invalid Currency", r.URL.Path)
+		return "", false
+	}
+	return currency, true
+}
+
+// Storefront lists such as recommendations and charts are short: they return
+// defaultListLimit books unless asked for more, and at most maxListLimit.
+const (
+	defaultListLimit = 10
+	maxListLimit     = 50
+)
+
+func listLimitParam(r *http.Request) int {
+	limit, err := strconv.Atoi(r.URL.Query().Get("limit"))
+	if err != nil || limit <= 0 {
+		return defaultListLimit
+	}
+	return min(limit, maxListLimit)
+}
@@ -2,15 +2,27 @@ package handler
 
 import (
 	"context"
+	"io"
 	"toptal/internal/app/domain"
 )
 
 type BookService interface {
-	GetBookById(ctx context.Context, id int) (domain.Book, error)
-	GetAvailableBooks(ctx context.Context, categoryIds []int, limit, offset int) ([]domain.Book, error)
+	GetBookById(ctx context.Context, id int, currency string) (domain.Book, error)
+	GetAvailableBooks(ctx context.Context, filter domain.BookFilter, limit, offset int, currency string) ([]domain.Book, error)
+	SearchBooks(ctx context.Context, query string, limit, offset int, currency string) ([]domain.Book, error)
 	CreateBook(ctx context.Context, book domain.Book) error
 	UpdateBook(ctx context.Context, book domain.Book) error
 	DeleteBook(ctx context.Context, id int) error
+	RestoreBook(ctx context.Context, id int) (domain.Book, error)
+	GetArchivedBooks(ctx context.Context, limit, offset int) ([]domain.Book, error)
+}
+
+type ImportService interface {
+	ImportBooks(ctx context.Context, r io.Reader, format string, dryRun bool) (domain.ImportReport, error)
+}
+
+type ExportService interface {
+	ExportBooks(ctx context.Context, w io.Writer, format string, filter domain.BookExportFilter) error
 }
 
 type CategoryService interface {
@@ -19,21 +31,138 @@ type CategoryService interface {
 	CreateCategory(ctx context.Context, book domain.Category) error
 	UpdateCategory(ctx context.Context, book domain.Category) error
 	DeleteCategory(ctx context.Context, id int) error
+	RestoreCategory(ctx context.Context, id int) (domain.Category, error)
+	GetArchivedCategories(ctx context.Context) ([]domain.Category, error)
 }
 
 type AuthService interface {
-	Login(ctx context.Context, username string, password string) (string, error)
-	Register(ctx context.Context, username string, password string) error
+	Login(ctx context.Context, username string, password string) (domain.LoginResult, error)
+	VerifyTwoFactor(ctx context.Context, challengeToken string, code string) (string, error)
+	EnrollTOTP(ctx context.Context, userId int) (secret string, provisioningURI string, err error)
+	ConfirmTOTP(ctx context.Context, userId int, code string) ([]string, error)
+	DisableTOTP(ctx context.Context, userId int, code string) error
+	RequiresTwoFactorEnrollment(user domain.User) bool
+	Register(ctx context.Context, username string, password string, email string) error
 	GetUserById(ctx context.Context, id int) (domain.User, error)
 }
 
 type CartService interface {
-	GetCart(ctx context.Context, userId int) ([]domain.Book, error)
-	AddToCart(ctx context.Context, userId int, bookId int) error
-	RemoveFromCart(ctx context.Context, userId int, bookId int) error
-	Purchase(ctx context.Context, userId int) error
+	GetCart(ctx context.Context, userId int, currency string) ([]domain.Book, error)
+	AddToCart(ctx context.Context, userId int, bookId int, format string) error
+	RemoveFromCart(ctx context.Context, userId int, bookId int, format string) error
+	Purchase(ctx context.Context, userId int, currency string, address domain.ShippingAddress) (domain.Order, error)
+}
+
+type FormatService interface {
+	GetFormats(ctx context.Context, bookId int) ([]domain.BookFormat, error)
+	SaveFormat(ctx context.Context, format domain.BookFormat) (domain.BookFormat, error)
+	DeleteFormat(ctx context.Context, bookId int, format string) error
+	UploadFile(ctx context.Context, bookId int, format string, name string, contentType string, content io.Reader) (domain.BookFormat, error)
+}
+
+type CoverService interface {
+	UploadCover(ctx context.Context, bookId int, contentType string, content io.Reader) (domain.Cover, error)
+	DeleteCover(ctx context.Context, bookId int) error
+	OpenCover(ctx context.Context, bookId int, rendition string) (domain.Cover, io.ReadSeekCloser, error)
+}
+
+type RecommendationService interface {
+	GetBookRecommendations(ctx context.Context, bookId int, limit int, currency string) ([]domain.Recommendation, error)
+	GetUserRecommendations(ctx context.Context, userId int, limit int, currency string) ([]domain.Recommendation, error)
+}
+
+type ChartService interface {
+	GetBestsellers(ctx context.Context, period string, categoryId, limit, offset int, currency string) ([]domain.Book, error)
+	GetTrending(ctx context.Context, categoryId, limit, offset int, currency string) ([]domain.Book, error)
+	GetNewArrivals(ctx context.Context, categoryId, limit, offset int, currency string) ([]domain.Book, error)
+}
+
+type ReportService interface {
+	GetCategoryRevenue(ctx context.Context, filter domain.ReportFilter) ([]domain.CategoryRevenue, error)
+	GetBookSales(ctx context.Context, filter domain.ReportFilter) ([]domain.BookSales, error)
+	GetBasketSize(ctx context.Context, filter domain.ReportFilter) ([]domain.BasketSize, error)
+	GetStockTurnover(ctx context.Context, filter domain.ReportFilter) ([]domain.StockTurnover, error)
+}
+
+type StockAlertService interface {
+	GetLowStock(ctx context.Context) ([]domain.StockAlert, error)
+	SetReorderThreshold(ctx context.Context, bookId int, threshold *int) (int, error)
+}
+
+type DownloadService interface {
+	GetDownloads(ctx context.Context, userId int) ([]domain.DownloadLink, error)
+	OpenDownload(ctx context.Context, id int, expires int64, signature string, rangeHeader string) (domain.Download, io.ReadSeekCloser, error)
+	RecordTransfer(ctx context.Context, download domain.Download, rangeHeader string, written int64) error
 }
 
 type HealthService interface {
 	CheckDatabase(ctx context.Context) error
 }
+
+type APIKeyService interface {
+	CreateAPIKey(ctx context.Context, key domain.APIKey) (domain.APIKey, string, error)
+	GetAPIKeys(ctx context.Context) ([]domain.APIKey, error)
+	RevokeAPIKey(ctx context.Context, id int) error
+	RotateAPIKey(ctx context.Context, id int, rotatedBy int) (domain.APIKey, string, error)
+	Authenticate(ctx context.Context, rawKey string) (domain.APIKey, error)
+}
+
+type SessionService interface {
+	Authenticate(ctx context.Context, userId int, sessionId int) error
+	GetSessions(ctx context.Context, userId int) ([]domain.Session, error)
+	RevokeSession(ctx context.Context, userId int, sessionId int) error
+	RevokeUserSessions(ctx context.Context, userId int) (int, error)
+}
+
+type AuditService interface {
+	GetAuditLog(ctx context.Context, filter domain.AuditFilter) ([]domain.AuditEntry, error)
+}
+
+type PriceService interface {
+	SchedulePriceChange(ctx context.Context, change domain.PriceChange) (domain.PriceChange, error)
+	GetPriceChanges(ctx context.Context, bookId int) ([]domain.PriceChange, error)
+	CancelPriceChange(ctx context.Context, bookId int, id int) error
+	GetPriceHistory(ctx context.Context, bookId int, limit, offset int) ([]domain.PriceHistoryEntry, error)
+	GetListPrices(ctx context.Context, bookId int) ([]domain.Money, error)
+	SetListPrice(ctx context.Context, bookId int, price domain.Money) error
+	DeleteListPrice(ctx context.Context, bookId int, currency string) error
+}
+
+type OIDCService interface {
+	StartLogin(ctx context.Context, provider string) (authURL string, state string, err error)
+	CompleteLogin(ctx context.Context, provider string, state string, code string) (domain.LoginResult, error)
+}
+
+type AccountService interface {
+	RequestPasswordReset(ctx context.Context, email string) error
+	ResetPassword(ctx context.Context, token string, password string) error
+	RequestEmailVerification(ctx context.Context, userId int) error
+	VerifyEmail(ctx context.Context, token string) error
+	GetProfile(ctx context.Context, userId int) (domain.User, error)
+	UpdateProfile(ctx context.Context, userId int, username *string, email *string) (domain.User, error)
+	ChangePassword(ctx context.Context, userId int, currentPassword string, newPassword string) error
+	DeleteAccount(ctx context.Context, userId int, password string) error
+	ExportAccount(ctx context.Context, userId int) (domain.AccountExport, error)
+}
+
+type SupplierService interface {
+	GetSuppliers(ctx context.Context) ([]domain.Supplier, error)
+	GetSupplier(ctx context.Context, id int) (domain.Supplier, error)
+	CreateSupplier(ctx context.Context, supplier domain.Supplier) (domain.Supplier, error)
+	UpdateSupplier(ctx context.Context, supplier domain.Supplier) (domain.Supplier, error)
+	DeleteSupplier(ctx context.Context, id int) error
+	GetBookSuppliers(ctx context.Context, bookId int) ([]domain.BookSupplier, error)
+	SaveBookSupplier(ctx context.Context, bookSupplier domain.BookSupplier) (domain.BookSupplier, error)
+	DeleteBookSupplier(ctx context.Context, bookId int, supplierId int) error
+}
+
+type PurchaseOrderService interface {
+	CreatePurchaseOrder(ctx context.Context, supplierId int, quantities []domain.PurchaseQuantity) (domain.PurchaseOrder, error)
+	UpdatePurchaseOrder(ctx context.Context, id int, quantities []domain.PurchaseQuantity) (domain.PurchaseOrder, error)
+	SendPurchaseOrder(ctx context.Context, id int) (domain.PurchaseOrder, error)
+	ReceivePurchaseOrder(ctx context.Context, id int, quantities []domain.PurchaseQuantity) (domain.PurchaseOrder, error)
+	DeletePurchaseOrder(ctx context.Context, id int) error
+	GetPurchaseOrder(ctx context.Context, id int) (domain.PurchaseOrder, error)
+	GetPurchaseOrders(ctx context.Context, filter domain.PurchaseOrderFilter) ([]domain.PurchaseOrder, error)
+	GetStockMovements(ctx context.Context, bookId int, limit, offset int) ([]domain.StockMovement, error)
+}
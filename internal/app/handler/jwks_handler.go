@@ -0,0 +1,18 @@
+package handler
+
+import (
+	"net/http"
+	"toptal/internal/app/auth"
+)
+
+// @Summary JSON Web Key Set
+// @Description Public keys used to sign access tokens, for services that verify our tokens
+// @Tags auth
+// @Produce json
+// @Success 200 {object} model.JWKSResponse
+// @Router /.well-known/jwks.json [get]
+func (s *Server) handleJWKS(w http.ResponseWriter, _ *http.Request) {
+	w.Header().Set("Cache-Control", "public, max-age=300")
+	response := toJWKSResponse(auth.PublicJWKS())
+	writeResponseOK(w, response)
+}
@@ -1,36 +1,116 @@
 package handler
 
 import (
-	"log"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+	"toptal/internal/app/auth"
 	"toptal/internal/app/domain"
 	"toptal/internal/app/handler/model"
 )
 
-func toBookWithId(request model.BookUpdateRequest) domain.Book {
-	b, err := domain.NewBook(request.Id, request.Title, request.Year, request.Author, request.Price, request.Stock, request.CategoryId)
+// toBookWithId and toBook fail on the price, ISBN and metadata, which the validator cannot
+// check.
+func toBookWithId(request model.BookUpdateRequest) (domain.Book, error) {
+	book, err := domain.NewBook(request.Id, request.Title, request.Year, request.Author, request.Price, request.Stock, request.CategoryId)
 	if err != nil {
-		log.Fatalf("failed to convert BookUpdateRequest to domain.Book: %v", err)
+		return book, err
 	}
-	return b
+	if err := book.SetISBN(request.ISBN); err != nil {
+		return book, err
+	}
+	err = setBookMetadata(&book, request.BookMetadata)
+	return book, err
 }
 
-func toBook(request model.BookCreateRequest) domain.Book {
-	b, err := domain.NewBook(1, request.Title, request.Year, request.Author, request.Price, request.Stock, request.CategoryId)
+func toBook(request model.BookCreateRequest) (domain.Book, error) {
+	book, err := domain.NewBook(1, request.Title, request.Year, request.Author, request.Price, request.Stock, request.CategoryId)
 	if err != nil {
-		log.Fatalf("failed to convert BookCreateRequest to domain.Book: %v", err)
+		return book, err
+	}
+	if err := book.SetISBN(request.ISBN); err != nil {
+		return book, err
+	}
+	err = setBookMetadata(&book, request.BookMetadata)
+	return book, err
+}
+
+func setBookMetadata(book *domain.Book, metadata model.BookMetadata) error {
+	var publicationDate time.Time
+	if metadata.PublicationDate != "" {
+		var err error
+		if publicationDate, err = time.Parse(time.DateOnly, metadata.PublicationDate); err != nil {
+			return fmt.Errorf("publication date must be a date such as 2024-05-31")
+		}
 	}
-	return b
+	return errors.Join(
+		book.SetDescription(metadata.Description),
+		book.SetPageCount(metadata.PageCount),
+		book.SetLanguage(metadata.Language),
+		book.SetPublisher(metadata.Publisher),
+		book.SetPublicationDate(publicationDate),
+		book.SetSeries(metadata.Series, metadata.SeriesPosition),
+		book.SetDimensions(metadata.WidthMm, metadata.HeightMm, metadata.DepthMm),
+		book.SetWeightGrams(metadata.WeightGrams),
+	)
+}
+
+func toBookMetadata(book domain.Book) model.BookMetadata {
+	series, position := book.Series()
+	width, height, depth := book.Dimensions()
+	metadata := model.BookMetadata{
+		Description:    book.Description(),
+		PageCount:      book.PageCount(),
+		Language:       book.Language(),
+		Publisher:      book.Publisher(),
+		Series:         series,
+		SeriesPosition: position,
+		WidthMm:        width,
+		HeightMm:       height,
+		DepthMm:        depth,
+		WeightGrams:    book.WeightGrams(),
+	}
+	if !book.PublicationDate().IsZero() {
+		metadata.PublicationDate = book.PublicationDate().Format(time.DateOnly)
+	}
+	return metadata
 }
 
 func toBookResponse(book domain.Book) model.BookResponse {
-	return model.BookResponse{
-		Title:      book.Title(),
-		Year:       book.Year(),
-		Author:     book.Author(),
-		Price:      book.Price(),
-		Stock:      book.Stock(),
-		CategoryId: book.CategoryId(),
+	response := model.BookResponse{
+		Title:        book.Title(),
+		Year:         book.Year(),
+		Author:       book.Author(),
+		Price:        book.Price(),
+		Stock:        book.Stock(),
+		CategoryId:   book.CategoryId(),
+		ISBN:         book.ISBN(),
+		Format:       book.Format(),
+		BookMetadata: toBookMetadata(book),
+	}
+	if salePrice, ok := book.SalePrice(); ok {
+		response.SalePrice = &salePrice
+		response.SaleEndsAt = timePtr(book.SaleEndsAt())
+	}
+	if cover, ok := book.Cover(); ok {
+		response.Cover = toCoverResponse(book.Id(), cover)
 	}
+	return response
+}
+
+func toCoverResponse(bookId int, cover domain.Cover) *model.CoverResponse {
+	return &model.CoverResponse{
+		Original:  coverURL(bookId, cover, domain.CoverOriginal),
+		Medium:    coverURL(bookId, cover, domain.CoverMedium),
+		Thumbnail: coverURL(bookId, cover, domain.CoverThumbnail),
+	}
+}
+
+// coverURL is the path of a rendition of a cover. It carries the version of the cover, so
+// it can be cached for good and changes when a new cover is uploaded.
+func coverURL(bookId int, cover domain.Cover, rendition string) string {
+	return fmt.Sprintf("/book/%d/cover/%s?v=%s", bookId, rendition, cover.Version())
 }
 
 func toBooksResponse(books []domain.Book) []model.BookResponse {
@@ -41,10 +121,23 @@ func toBooksResponse(books []domain.Book) []model.BookResponse {
 	return responses
 }
 
+func toArchivedBooksResponse(books []domain.Book) []model.ArchivedBookResponse {
+	responses := make([]model.ArchivedBookResponse, len(books))
+	for i, book := range books {
+		responses[i] = model.ArchivedBookResponse{
+			Id:           book.Id(),
+			BookResponse: toBookResponse(book),
+			ArchivedAt:   book.ArchivedAt(),
+		}
+	}
+	return responses
+}
+
 func toCategoryResponse(category domain.Category) model.CategoryResponse {
 	return model.CategoryResponse{
-		Id:   category.Id(),
-		Name: category.Name(),
+		Id:       category.Id(),
+		Name:     category.Name(),
+		TaxClass: category.TaxClass(),
 	}
 }
 
@@ -56,9 +149,27 @@ func toCategoriesResponse(categories []domain.Category) []model.CategoryResponse
 	return responses
 }
 
+func toArchivedCategoriesResponse(categories []domain.Category) []model.ArchivedCategoryResponse {
+	responses := make([]model.ArchivedCategoryResponse, len(categories))
+	for i, category := range categories {
+		responses[i] = model.ArchivedCategoryResponse{
+			CategoryResponse: toCategoryResponse(category),
+			ArchivedAt:       category.ArchivedAt(),
+		}
+	}
+	return responses
+}
+
 func toCategory(request model.CategoryRequest) (domain.Category, error) {
 	var category domain.Category
-	err := category.SetName(request.Name)
+	if err := category.SetName(request.Name); err != nil {
+		return category, err
+	}
+	taxClass := request.TaxClass
+	if taxClass == "" {
+		taxClass = domain.TaxClassStandard
+	}
+	err := category.SetTaxClass(taxClass)
 	return category, err
 }
 
@@ -67,6 +178,521 @@ func toCategoryWithId(request model.CategoryUpdateRequest) (domain.Category, err
 	if err := category.SetId(request.Id); err != nil {
 		return category, err
 	}
-	err := category.SetName(request.Name)
+	if err := category.SetName(request.Name); err != nil {
+		return category, err
+	}
+	if request.TaxClass == "" {
+		return category, nil
+	}
+	err := category.SetTaxClass(request.TaxClass)
 	return category, err
 }
+
+func toAPIKey(request model.APIKeyCreateRequest, createdBy int) (domain.APIKey, error) {
+	var expiresAt time.Time
+	if request.ExpiresAt != nil {
+		expiresAt = *request.ExpiresAt
+	}
+	return domain.NewAPIKey(request.Name, request.Scopes, expiresAt, createdBy)
+}
+
+func toAPIKeyResponse(key domain.APIKey) model.APIKeyResponse {
+	return model.APIKeyResponse{
+		Id:         key.Id(),
+		Name:       key.Name(),
+		Prefix:     key.Prefix(),
+		Scopes:     key.Scopes(),
+		CreatedBy:  key.CreatedBy(),
+		CreatedAt:  key.CreatedAt(),
+		ExpiresAt:  timePtr(key.ExpiresAt()),
+		LastUsedAt: timePtr(key.LastUsedAt()),
+		RevokedAt:  timePtr(key.RevokedAt()),
+	}
+}
+
+func toAPIKeysResponse(keys []domain.APIKey) []model.APIKeyResponse {
+	responses := make([]model.APIKeyResponse, len(keys))
+	for i, key := range keys {
+		responses[i] = toAPIKeyResponse(key)
+	}
+	return responses
+}
+
+func toSessionsResponse(sessions []domain.Session, currentSessionId int) []model.SessionResponse {
+	responses := make([]model.SessionResponse, len(sessions))
+	for i, session := range sessions {
+		responses[i] = model.SessionResponse{
+			Id:         session.Id(),
+			UserAgent:  session.UserAgent(),
+			IPAddress:  session.IPAddress(),
+			CreatedAt:  session.CreatedAt(),
+			LastSeenAt: session.LastSeenAt(),
+			ExpiresAt:  session.ExpiresAt(),
+			Current:    session.Id() == currentSessionId,
+		}
+	}
+	return responses
+}
+
+func toAuditEntriesResponse(entries []domain.AuditEntry) []model.AuditEntryResponse {
+	responses := make([]model.AuditEntryResponse, len(entries))
+	for i, entry := range entries {
+		actor := entry.Actor()
+		responses[i] = model.AuditEntryResponse{
+			Id:            entry.Id(),
+			ActorUserId:   intPtr(actor.UserId()),
+			ActorAPIKeyId: intPtr(actor.APIKeyId()),
+			Action:        entry.Action(),
+			EntityType:    entry.EntityType(),
+			EntityId:      entry.EntityId(),
+			Before:        entry.Before(),
+			After:         entry.After(),
+			RequestId:     actor.RequestId(),
+			IPAddress:     actor.IPAddress(),
+			CreatedAt:     entry.CreatedAt(),
+		}
+	}
+	return responses
+}
+
+func intPtr(i int) *int {
+	if i == 0 {
+		return nil
+	}
+	return &i
+}
+
+func timePtr(t time.Time) *time.Time {
+	if t.IsZero() {
+		return nil
+	}
+	return &t
+}
+
+func toJWKSResponse(keys []auth.JWK) model.JWKSResponse {
+	response := model.JWKSResponse{Keys: make([]model.JWK, len(keys))}
+	for i, key := range keys {
+		response.Keys[i] = model.JWK{
+			Kty: key.Kty,
+			Kid: key.Kid,
+			Use: key.Use,
+			Alg: key.Alg,
+			N:   key.N,
+			E:   key.E,
+			Crv: key.Crv,
+			X:   key.X,
+		}
+	}
+	return response
+}
+
+func toLoginResponse(result domain.LoginResult) model.LoginResponse {
+	if result.TwoFactorRequired() {
+		return model.LoginResponse{ChallengeToken: result.ChallengeToken(), TwoFactorRequired: true}
+	}
+	return model.LoginResponse{Token: result.Token()}
+}
+
+func toProfileResponse(user domain.User) model.ProfileResponse {
+	return model.ProfileResponse{
+		Id:               user.Id(),
+		Username:         user.Username(),
+		Email:            user.Email(),
+		EmailVerified:    user.EmailVerified(),
+		Admin:            user.Admin(),
+		TwoFactorEnabled: user.TOTPEnabled(),
+		CreatedAt:        user.CreatedAt(),
+	}
+}
+
+func toOrdersResponse(orders []domain.Order) []model.OrderResponse {
+	response := make([]model.OrderResponse, len(orders))
+	for i, order := range orders {
+		response[i] = toOrderResponse(order)
+	}
+	return response
+}
+
+func toOrderResponse(order domain.Order) model.OrderResponse {
+	items := make([]model.OrderItemResponse, len(order.Items()))
+	for i, item := range order.Items() {
+		items[i] = model.OrderItemResponse{
+			Title:    item.Title(),
+			Author:   item.Author(),
+			Price:    item.Price(),
+			TaxClass: item.TaxClass(),
+			Tax:      item.Tax(),
+			Format:   item.Format(),
+		}
+		if item.BookId() != 0 {
+			bookId := item.BookId()
+			items[i].BookId = &bookId
+		}
+	}
+	taxLines := make([]model.OrderTaxLineResponse, len(order.TaxLines()))
+	for i, line := range order.TaxLines() {
+		taxLines[i] = model.OrderTaxLineResponse{
+			TaxClass: line.TaxClass(),
+			Rate:     fmt.Sprintf("%d.%02d", line.BasisPoints()/100, line.BasisPoints()%100),
+			Net:      line.Net(),
+			Tax:      line.Tax(),
+		}
+	}
+	response := model.OrderResponse{
+		Id:               order.Id(),
+		Subtotal:         order.Subtotal(),
+		Tax:              order.TaxTotal(),
+		Total:            order.Total(),
+		PricesIncludeTax: order.PricesIncludeTax(),
+		CreatedAt:        order.CreatedAt(),
+		Items:            items,
+		TaxLines:         taxLines,
+	}
+	if address := order.ShippingAddress(); address.IsSet() {
+		response.ShippingAddress = &model.ShippingAddress{
+			Name:       address.Name(),
+			Line1:      address.Line1(),
+			Line2:      address.Line2(),
+			City:       address.City(),
+			PostalCode: address.PostalCode(),
+			Region:     address.Region(),
+			Country:    address.Country(),
+		}
+	}
+	return response
+}
+
+func toShippingAddress(request model.ShippingAddress) (domain.ShippingAddress, error) {
+	return domain.NewShippingAddress(
+		request.Name, request.Line1, request.Line2, request.City, request.PostalCode,
+		strings.ToUpper(request.Region), strings.ToUpper(request.Country),
+	)
+}
+
+func toAccountExportResponse(export domain.AccountExport) model.AccountExportResponse {
+	return model.AccountExportResponse{
+		ExportedAt: export.ExportedAt(),
+		Profile:    toProfileResponse(export.User()),
+		Cart:       toBooksResponse(export.Cart()),
+		Orders:     toOrdersResponse(export.Orders()),
+	}
+}
+
+func toPriceChange(request model.PriceChangeRequest, bookId int, createdBy int) (domain.PriceChange, error) {
+	var endsAt time.Time
+	if request.EndsAt != nil {
+		endsAt = *request.EndsAt
+	}
+	return domain.NewPriceChange(bookId, request.Price, request.StartsAt, endsAt, createdBy)
+}
+
+func toPriceChangeResponse(change domain.PriceChange) model.PriceChangeResponse {
+	return model.PriceChangeResponse{
+		Id:          change.Id(),
+		BookId:      change.BookId(),
+		Price:       change.Price(),
+		Sale:        change.IsSale(),
+		StartsAt:    change.StartsAt(),
+		EndsAt:      timePtr(change.EndsAt()),
+		Status:      change.Status(),
+		CreatedBy:   intPtr(change.CreatedBy()),
+		CreatedAt:   change.CreatedAt(),
+		AppliedAt:   timePtr(change.AppliedAt()),
+		EndedAt:     timePtr(change.EndedAt()),
+		CancelledAt: timePtr(change.CancelledAt()),
+	}
+}
+
+func toPriceChangesResponse(changes []domain.PriceChange) []model.PriceChangeResponse {
+	responses := make([]model.PriceChangeResponse, len(changes))
+	for i, change := range changes {
+		responses[i] = toPriceChangeResponse(change)
+	}
+	return responses
+}
+
+func toPriceHistoryResponse(entries []domain.PriceHistoryEntry) []model.PriceHistoryResponse {
+	responses := make([]model.PriceHistoryResponse, len(entries))
+	for i, entry := range entries {
+		responses[i] = model.PriceHistoryResponse{
+			Price:         entry.Price(),
+			Reason:        entry.Reason(),
+			PriceChangeId: intPtr(entry.PriceChangeId()),
+			ChangedAt:     entry.ChangedAt(),
+		}
+		if salePrice, ok := entry.SalePrice(); ok {
+			responses[i].SalePrice = &salePrice
+		}
+	}
+	return responses
+}
+
+func toImportReportResponse(report domain.ImportReport) model.ImportReportResponse {
+	errors := make([]model.ImportRowErrorResponse, len(report.Errors()))
+	for i, rowErr := range report.Errors() {
+		errors[i] = model.ImportRowErrorResponse{Line: rowErr.Line(), Error: rowErr.Message()}
+	}
+	return model.ImportReportResponse{
+		DryRun:    report.DryRun(),
+		Rows:      report.Rows(),
+		Created:   report.Created(),
+		Updated:   report.Updated(),
+		Unchanged: report.Unchanged(),
+		Archived:  report.Archived(),
+		Failed:    len(errors),
+		Errors:    errors,
+	}
+}
+
+func toBookFormatResponse(format domain.BookFormat) model.BookFormatResponse {
+	response := model.BookFormatResponse{
+		Format:    format.Format(),
+		Price:     format.Price(),
+		Digital:   format.IsDigital(),
+		Available: format.Stock() > 0,
+	}
+	if !format.IsDigital() {
+		stock := format.Stock()
+		response.Stock = &stock
+	}
+	if file, ok := format.File(); ok {
+		response.Available = true
+		response.File = &model.FormatFileResponse{Name: file.Name(), ContentType: file.ContentType(), Size: file.Size()}
+	}
+	return response
+}
+
+func toBookFormatsResponse(formats []domain.BookFormat) []model.BookFormatResponse {
+	responses := make([]model.BookFormatResponse, len(formats))
+	for i, format := range formats {
+		responses[i] = toBookFormatResponse(format)
+	}
+	return responses
+}
+
+func toDownloadsResponse(links []domain.DownloadLink) []model.DownloadResponse {
+	responses := make([]model.DownloadResponse, len(links))
+	for i, link := range links {
+		download := link.Download()
+		responses[i] = model.DownloadResponse{
+			Id:             download.Id(),
+			OrderId:        download.OrderId(),
+			Title:          download.Title(),
+			Author:         download.Author(),
+			Format:         download.Format(),
+			Downloads:      download.Count(),
+			Remaining:      link.Remaining(),
+			URL:            link.URL(),
+			LastDownloadAt: timePtr(download.LastDownloadAt()),
+			CreatedAt:      download.CreatedAt(),
+		}
+		if link.URL() != "" {
+			responses[i].ExpiresAt = timePtr(link.ExpiresAt())
+		}
+	}
+	return responses
+}
+
+func toRecommendationsResponse(recommendations []domain.Recommendation) []model.RecommendationResponse {
+	responses := make([]model.RecommendationResponse, len(recommendations))
+	for i, recommendation := range recommendations {
+		book := recommendation.Book()
+		responses[i] = model.RecommendationResponse{
+			BookId:       book.Id(),
+			Reason:       recommendation.Reason(),
+			BookResponse: toBookResponse(book),
+		}
+	}
+	return responses
+}
+
+// toChartResponse ranks the books of a chart page that starts at offset.
+func toChartResponse(books []domain.Book, offset int) []model.ChartBookResponse {
+	responses := make([]model.ChartBookResponse, len(books))
+	for i, book := range books {
+		responses[i] = model.ChartBookResponse{
+			BookId:       book.Id(),
+			Rank:         offset + i + 1,
+			AddedAt:      book.AddedAt(),
+			BookResponse: toBookResponse(book),
+		}
+	}
+	return responses
+}
+
+func toCategoryRevenueResponse(report []domain.CategoryRevenue) []model.CategoryRevenueResponse {
+	responses := make([]model.CategoryRevenueResponse, len(report))
+	for i, row := range report {
+		responses[i] = model.CategoryRevenueResponse{
+			Period:       row.Period().UTC(),
+			CategoryId:   row.CategoryId(),
+			CategoryName: row.CategoryName(),
+			Orders:       row.Orders(),
+			Units:        row.Units(),
+			Net:          row.Net(),
+			Tax:          row.Tax(),
+		}
+	}
+	return responses
+}
+
+func toBookSalesResponse(report []domain.BookSales) []model.BookSalesResponse {
+	responses := make([]model.BookSalesResponse, len(report))
+	for i, row := range report {
+		responses[i] = model.BookSalesResponse{
+			Period: row.Period().UTC(),
+			BookId: row.BookId(),
+			Title:  row.Title(),
+			Author: row.Author(),
+			Units:  row.Units(),
+			Net:    row.Net(),
+		}
+	}
+	return responses
+}
+
+func toBasketSizeResponse(report []domain.BasketSize) []model.BasketSizeResponse {
+	responses := make([]model.BasketSizeResponse, len(report))
+	for i, row := range report {
+		responses[i] = model.BasketSizeResponse{
+			Period:       row.Period().UTC(),
+			Orders:       row.Orders(),
+			Units:        row.Units(),
+			Total:        row.Total(),
+			AverageUnits: row.AverageUnits(),
+			AverageTotal: row.AverageTotal(),
+		}
+	}
+	return responses
+}
+
+func toStockTurnoverResponse(report []domain.StockTurnover) []model.StockTurnoverResponse {
+	responses := make([]model.StockTurnoverResponse, len(report))
+	for i, row := range report {
+		responses[i] = model.StockTurnoverResponse{
+			Period:       row.Period().UTC(),
+			CategoryId:   row.CategoryId(),
+			CategoryName: row.CategoryName(),
+			Units:        row.Units(),
+			OpeningStock: row.OpeningStock(),
+			ClosingStock: row.ClosingStock(),
+			Turnover:     row.Turnover(),
+		}
+	}
+	return responses
+}
+
+func toStockAlertsResponse(alerts []domain.StockAlert) []model.StockAlertResponse {
+	responses := make([]model.StockAlertResponse, len(alerts))
+	for i, alert := range alerts {
+		book := alert.Book()
+		responses[i] = model.StockAlertResponse{
+			BookId:            book.Id(),
+			Title:             book.Title(),
+			Author:            book.Author(),
+			ISBN:              book.ISBN(),
+			Level:             alert.Level(),
+			Stock:             book.Stock(),
+			Threshold:         alert.Threshold(),
+			Sold:              alert.Sold(),
+			DailySales:        alert.DailySales(),
+			SuggestedQuantity: alert.SuggestedQuantity(),
+		}
+	}
+	return responses
+}
+
+func toSupplierResponse(supplier domain.Supplier) model.SupplierResponse {
+	return model.SupplierResponse{
+		Id:        supplier.Id(),
+		Name:      supplier.Name(),
+		Email:     supplier.Email(),
+		Phone:     supplier.Phone(),
+		CreatedAt: supplier.CreatedAt(),
+	}
+}
+
+func toSuppliersResponse(suppliers []domain.Supplier) []model.SupplierResponse {
+	responses := make([]model.SupplierResponse, len(suppliers))
+	for i, supplier := range suppliers {
+		responses[i] = toSupplierResponse(supplier)
+	}
+	return responses
+}
+
+func toBookSupplierResponse(bookSupplier domain.BookSupplier) model.BookSupplierResponse {
+	return model.BookSupplierResponse{
+		BookId:       bookSupplier.BookId(),
+		SupplierId:   bookSupplier.SupplierId(),
+		SupplierName: bookSupplier.SupplierName(),
+		CostPrice:    bookSupplier.CostPrice(),
+		SKU:          bookSupplier.SKU(),
+		UpdatedAt:    bookSupplier.UpdatedAt(),
+	}
+}
+
+func toBookSuppliersResponse(bookSuppliers []domain.BookSupplier) []model.BookSupplierResponse {
+	responses := make([]model.BookSupplierResponse, len(bookSuppliers))
+	for i, bookSupplier := range bookSuppliers {
+		responses[i] = toBookSupplierResponse(bookSupplier)
+	}
+	return responses
+}
+
+func toPurchaseQuantities(requests []model.PurchaseQuantityRequest) []domain.PurchaseQuantity {
+	quantities := make([]domain.PurchaseQuantity, len(requests))
+	for i, request := range requests {
+		quantities[i] = domain.PurchaseQuantity{BookId: request.BookId, Quantity: request.Quantity}
+	}
+	return quantities
+}
+
+func toPurchaseOrderResponse(order domain.PurchaseOrder) model.PurchaseOrderResponse {
+	items := make([]model.PurchaseOrderItemResponse, len(order.Lines()))
+	for i, line := range order.Lines() {
+		items[i] = model.PurchaseOrderItemResponse{
+			BookId:      intPtr(line.BookId()),
+			Title:       line.Title(),
+			Quantity:    line.Quantity(),
+			Received:    line.Received(),
+			Outstanding: line.Outstanding(),
+			CostPrice:   line.CostPrice(),
+			Total:       line.Total(),
+		}
+	}
+	return model.PurchaseOrderResponse{
+		Id:           order.Id(),
+		SupplierId:   order.SupplierId(),
+		SupplierName: order.SupplierName(),
+		Status:       order.Status(),
+		Items:        items,
+		Total:        order.Total(),
+		CreatedAt:    order.CreatedAt(),
+		SentAt:       timePtr(order.SentAt()),
+		ReceivedAt:   timePtr(order.ReceivedAt()),
+	}
+}
+
+func toPurchaseOrdersResponse(orders []domain.PurchaseOrder) []model.PurchaseOrderResponse {
+	responses := make([]model.PurchaseOrderResponse, len(orders))
+	for i, order := range orders {
+		responses[i] = toPurchaseOrderResponse(order)
+	}
+	return responses
+}
+
+func toStockMovementsResponse(movements []domain.StockMovement) []model.StockMovementResponse {
+	responses := make([]model.StockMovementResponse, len(movements))
+	for i, movement := range movements {
+		responses[i] = model.StockMovementResponse{
+			Id:              movement.Id(),
+			BookId:          movement.BookId(),
+			Title:           movement.Title(),
+			Quantity:        movement.Quantity(),
+			Reason:          movement.Reason(),
+			PurchaseOrderId: intPtr(movement.PurchaseOrderId()),
+			CreatedAt:       movement.CreatedAt(),
+		}
+	}
+	return responses
+}
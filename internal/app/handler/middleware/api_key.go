@@ -0,0 +1,54 @@
+package middleware
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+	"net/http"
+	"toptal/internal/app/domain"
+	"toptal/internal/app/handler/model"
+	"toptal/internal/app/util"
+)
+
+const APIKeyHeader = "X-API-Key"
+
+type APIKeyService interface {
+	Authenticate(ctx context.Context, rawKey string) (domain.APIKey, error)
+}
+
+type APIKeyMiddleware struct {
+	apiKeyService APIKeyService
+}
+
+func NewAPIKeyMiddleware(apiKeyService APIKeyService) *APIKeyMiddleware {
+	return &APIKeyMiddleware{apiKeyService}
+}
+
+// APIKeyMiddleware authenticates requests carrying an X-API-Key header and requires the key
+// to hold the given scope. Requests without the header are handed to fallback, which is
+// usually the JWT chain protecting the same handler.
+func (m *APIKeyMiddleware) APIKeyMiddleware(scope string, next, fallback func(w http.ResponseWriter, r *http.Request)) func(w http.ResponseWriter, r *http.Request) {
+	return func(w http.ResponseWriter, r *http.Request) {
+		rawKey := r.Header.Get(APIKeyHeader)
+		if rawKey == "" {
+			fallback(w, r)
+			return
+		}
+
+		key, err := m.apiKeyService.Authenticate(r.Context(), rawKey)
+		if err != nil {
+			if !errors.Is(err, domain.ErrInvalidAPIKey) {
+				slog.Error("failed to authenticate api key", "error", err)
+			}
+			model.Unauthorized(w, "invalid api key", r.URL.Path)
+			return
+		}
+		if !key.HasScope(scope) {
+			model.Forbidden(w, "api key does not have scope "+scope, r.URL.Path)
+			return
+		}
+
+		ctx := util.WithAPIKeyID(r.Context(), key.Id())
+		next(w, r.WithContext(ctx))
+	}
+}
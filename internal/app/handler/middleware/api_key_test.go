@@ -0,0 +1,58 @@
+package middleware
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+
+	"toptal/internal/app/domain"
+)
+
+type MockAPIKeyService struct {
+	mock.Mock
+}
+
+func (m *MockAPIKeyService) Authenticate(ctx context.Context, rawKey string) (domain.APIKey, error) {
+	args := m.Called(ctx, rawKey)
+	return args.Get(0).(domain.APIKey), args.Error(1)
+}
+
+func TestAPIKeyMiddleware_Scopes(t *testing.T) {
+	key, err := domain.NewAPIKey("catalogue sync", []string{domain.ScopeCatalogWrite}, time.Time{}, 1)
+	require.NoError(t, err)
+	require.NoError(t, key.SetId(3))
+
+	service := new(MockAPIKeyService)
+	service.On("Authenticate", mock.Anything, "raw-key").Return(key, nil)
+	middleware := NewAPIKeyMiddleware(service)
+
+	ok := func(w http.ResponseWriter, r *http.Request) { w.WriteHeader(http.StatusOK) }
+	fallback := func(w http.ResponseWriter, r *http.Request) { w.WriteHeader(http.StatusUnauthorized) }
+
+	tests := []struct {
+		scope  string
+		status int
+	}{
+		{scope: domain.ScopeCatalogWrite, status: http.StatusOK},
+		{scope: domain.ScopeCatalogExport, status: http.StatusForbidden},
+		{scope: domain.ScopePricingWrite, status: http.StatusForbidden},
+		{scope: domain.ScopeStockWrite, status: http.StatusForbidden},
+		{scope: domain.ScopePurchasingWrite, status: http.StatusForbidden},
+	}
+	for _, tt := range tests {
+		t.Run(tt.scope, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodGet, "/", nil)
+			req.Header.Set(APIKeyHeader, "raw-key")
+			rec := httptest.NewRecorder()
+
+			middleware.APIKeyMiddleware(tt.scope, ok, fallback)(rec, req)
+			assert.Equal(t, tt.status, rec.Code)
+		})
+	}
+}
@@ -0,0 +1,41 @@
+package middleware
+
+import (
+	"net"
+	"net/http"
+	"strings"
+	"toptal/internal/app/util"
+)
+
+const maxUserAgentLength = 512
+
+// ClientInfoMiddleware records the user agent and address of the caller in the request
+// context. X-Forwarded-For is only honoured when trustProxy is set, since clients can
+// send any value.
+func ClientInfoMiddleware(trustProxy bool, next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		userAgent := r.UserAgent()
+		if len(userAgent) > maxUserAgentLength {
+			userAgent = userAgent[:maxUserAgentLength]
+		}
+
+		info := util.ClientInfo{UserAgent: userAgent, IPAddress: clientIP(r, trustProxy)}
+		next.ServeHTTP(w, r.WithContext(util.WithClientInfo(r.Context(), info)))
+	})
+}
+
+func clientIP(r *http.Request, trustProxy bool) string {
+	if trustProxy {
+		if forwarded := r.Header.Get("X-Forwarded-For"); forwarded != "" {
+			first, _, _ := strings.Cut(forwarded, ",")
+			if ip := net.ParseIP(strings.TrimSpace(first)); ip != nil {
+				return ip.String()
+			}
+		}
+	}
+	host, _, err := net.SplitHostPort(r.RemoteAddr)
+	if err != nil {
+		return r.RemoteAddr
+	}
+	return host
+}
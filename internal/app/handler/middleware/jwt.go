@@ -1,14 +1,32 @@
 package middleware
 
 import (
+	"context"
+	"errors"
+	"log/slog"
 	"net/http"
 	"strings"
 	"toptal/internal/app/auth"
+	"toptal/internal/app/domain"
 	"toptal/internal/app/handler/model"
 	"toptal/internal/app/util"
 )
 
-func JWTMiddleware(next func(w http.ResponseWriter, r *http.Request)) func(w http.ResponseWriter, r *http.Request) {
+type SessionService interface {
+	Authenticate(ctx context.Context, userId int, sessionId int) error
+}
+
+type JWTMiddleware struct {
+	sessionService SessionService
+}
+
+func NewJWTMiddleware(sessionService SessionService) *JWTMiddleware {
+	return &JWTMiddleware{sessionService}
+}
+
+// JWTMiddleware accepts access tokens whose session is still active and puts the user
+// and session ids into the request context.
+func (m *JWTMiddleware) JWTMiddleware(next func(w http.ResponseWriter, r *http.Request)) func(w http.ResponseWriter, r *http.Request) {
 	return func(w http.ResponseWriter, r *http.Request) {
 		authHeader := r.Header.Get("Authorization")
 		if authHeader == "" {
@@ -28,7 +46,16 @@ func JWTMiddleware(next func(w http.ResponseWriter, r *http.Request)) func(w htt
 			return
 		}
 
+		if err := m.sessionService.Authenticate(r.Context(), claims.UserID, claims.SessionID); err != nil {
+			if !errors.Is(err, domain.ErrSessionRevoked) {
+				slog.Error("failed to check session", "session_id", claims.SessionID, "error", err)
+			}
+			model.Unauthorized(w, "session revoked or expired", r.URL.Path)
+			return
+		}
+
 		ctx := util.WithUserID(r.Context(), claims.UserID)
+		ctx = util.WithSessionID(ctx, claims.SessionID)
 		next(w, r.WithContext(ctx))
 	}
 }
@@ -11,6 +11,7 @@ import (
 
 type AuthService interface {
 	GetUserById(ctx context.Context, id int) (domain.User, error)
+	RequiresTwoFactorEnrollment(user domain.User) bool
 }
 
 type RoleMiddleware struct {
@@ -39,6 +40,10 @@ func (m *RoleMiddleware) RoleMiddleware(next func(w http.ResponseWriter, r *http
 			model.Forbidden(w, "user does not have admin role", r.URL.Path)
 			return
 		}
+		if m.authService.RequiresTwoFactorEnrollment(user) {
+			model.Forbidden(w, "two-factor authentication is required for admin access", r.URL.Path)
+			return
+		}
 
 		next(w, r)
 	}
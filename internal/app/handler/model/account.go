@@ -0,0 +1,105 @@
+package model
+
+import (
+	"time"
+	"toptal/internal/app/domain"
+)
+
+type ForgotPasswordRequest struct {
+	Email string `json:"email" validate:"required,email,max=255"`
+}
+
+type ResetPasswordRequest struct {
+	Token    string `json:"token" validate:"required,max=128"`
+	Password string `json:"password" validate:"required,maxbytes=72"`
+}
+
+type VerifyEmailRequest struct {
+	Token string `json:"token" validate:"required,max=128"`
+}
+
+type MessageResponse struct {
+	Message string `json:"message"`
+}
+
+type ProfileResponse struct {
+	Id               int       `json:"id"`
+	Username         string    `json:"username"`
+	Email            string    `json:"email,omitempty"`
+	EmailVerified    bool      `json:"email_verified"`
+	Admin            bool      `json:"admin"`
+	TwoFactorEnabled bool      `json:"two_factor_enabled"`
+	CreatedAt        time.Time `json:"created_at"`
+}
+
+// ProfileUpdateRequest changes only the fields that are present. An empty email removes it.
+type ProfileUpdateRequest struct {
+	Username *string `json:"username,omitempty" validate:"omitempty,min=3,max=50"`
+	Email    *string `json:"email,omitempty" validate:"omitempty,email,max=255"`
+}
+
+// ChangePasswordRequest has no current password for users who do not have one yet.
+type ChangePasswordRequest struct {
+	CurrentPassword string `json:"current_password" validate:"maxbytes=72"`
+	NewPassword     string `json:"new_password" validate:"required,maxbytes=72"`
+}
+
+// DeleteAccountRequest has no password for users who do not have one.
+type DeleteAccountRequest struct {
+	Password string `json:"password" validate:"maxbytes=72"`
+}
+
+type OrderItemResponse struct {
+	// BookId is omitted when the book no longer exists.
+	BookId   *int         `json:"book_id,omitempty"`
+	Title    string       `json:"title"`
+	Author   string       `json:"author"`
+	Price    domain.Money `json:"price"`
+	TaxClass string       `json:"tax_class"`
+	Tax      domain.Money `json:"tax"`
+	// Format is the format the book was bought in, omitted for the book itself.
+	Format string `json:"format,omitempty"`
+}
+
+type OrderTaxLineResponse struct {
+	TaxClass string `json:"tax_class"`
+	// Rate is a percentage such as "7.25".
+	Rate string       `json:"rate"`
+	Net  domain.Money `json:"net"`
+	Tax  domain.Money `json:"tax"`
+}
+
+type OrderResponse struct {
+	Id int `json:"id"`
+	// Subtotal adds up the item prices, which include tax when PricesIncludeTax is set.
+	Subtotal         domain.Money           `json:"subtotal"`
+	Tax              domain.Money           `json:"tax"`
+	Total            domain.Money           `json:"total"`
+	PricesIncludeTax bool                   `json:"prices_include_tax"`
+	ShippingAddress  *ShippingAddress       `json:"shipping_address,omitempty"`
+	CreatedAt        time.Time              `json:"created_at"`
+	Items            []OrderItemResponse    `json:"items"`
+	TaxLines         []OrderTaxLineResponse `json:"tax_lines"`
+}
+
+type AccountExportResponse struct {
+	ExportedAt time.Time       `json:"exported_at"`
+	Profile    ProfileResponse `json:"profile"`
+	Cart       []BookResponse  `json:"cart"`
+	Orders     []OrderResponse `json:"orders"`
+}
+
+type SessionResponse struct {
+	Id         int       `json:"id"`
+	UserAgent  string    `json:"user_agent,omitempty"`
+	IPAddress  string    `json:"ip_address,omitempty"`
+	CreatedAt  time.Time `json:"created_at"`
+	LastSeenAt time.Time `json:"last_seen_at"`
+	ExpiresAt  time.Time `json:"expires_at"`
+	// Current marks the session of the token used for the request.
+	Current bool `json:"current"`
+}
+
+type SessionsRevokedResponse struct {
+	Revoked int `json:"revoked"`
+}
@@ -0,0 +1,17 @@
+package model
+
+import "time"
+
+type AuditEntryResponse struct {
+	Id            int            `json:"id"`
+	ActorUserId   *int           `json:"actor_user_id,omitempty"`
+	ActorAPIKeyId *int           `json:"actor_api_key_id,omitempty"`
+	Action        string         `json:"action"`
+	EntityType    string         `json:"entity_type"`
+	EntityId      int            `json:"entity_id"`
+	Before        map[string]any `json:"before,omitempty"`
+	After         map[string]any `json:"after,omitempty"`
+	RequestId     string         `json:"request_id,omitempty"`
+	IPAddress     string         `json:"ip_address,omitempty"`
+	CreatedAt     time.Time      `json:"created_at"`
+}
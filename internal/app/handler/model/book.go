@@ -1,29 +1,105 @@
 package model
 
+import (
+	"time"
+	"toptal/internal/app/domain"
+)
+
+// BookDetails are the fields describing a book however it reaches the shop. The book
+// requests and the rows of catalogue imports embed them, so both are checked by the same
+// rules.
+type BookDetails struct {
+	Title  string       `json:"title" validate:"required,min=1,max=255"`
+	Year   int          `json:"year" validate:"required,min=1800,max=2100"`
+	Author string       `json:"author" validate:"required,min=1,max=255"`
+	Price  domain.Money `json:"price"`
+	ISBN   string       `json:"isbn,omitempty" validate:"omitempty,max=17"`
+}
+
 type BookCreateRequest struct {
-	Title      string `json:"title" validate:"required,min=1,max=255"`
-	Year       int    `json:"year" validate:"required,min=1800,max=2100"`
-	Author     string `json:"author" validate:"required,min=1,max=255"`
-	Price      int    `json:"price" validate:"required,min=0"`
-	Stock      int    `json:"stock" validate:"required,min=0"`
-	CategoryId int    `json:"category_id" validate:"required,min=1"`
+	BookDetails
+	Stock      int `json:"stock" validate:"required,min=0"`
+	CategoryId int `json:"category_id" validate:"required,min=1"`
+	BookMetadata
 }
 
+// BookUpdateRequest replaces a book. Without an ISBN the current one is kept; metadata
+// left out is cleared.
 type BookUpdateRequest struct {
-	Id         int    `json:"id" validate:"required,min=1"`
-	Title      string `json:"title" validate:"required,min=1,max=255"`
-	Year       int    `json:"year" validate:"required,min=1800,max=2100"`
-	Author     string `json:"author" validate:"required,min=1,max=255"`
-	Price      int    `json:"price" validate:"required,min=0"`
-	Stock      int    `json:"stock" validate:"required,min=0"`
-	CategoryId int    `json:"category_id" validate:"required,min=1"`
+	Id int `json:"id" validate:"required,min=1"`
+	BookDetails
+	Stock      int `json:"stock" validate:"required,min=0"`
+	CategoryId int `json:"category_id" validate:"required,min=1"`
+	BookMetadata
 }
 
+// BookMetadata describes a book for the storefront and for shipping; everything is
+// optional. The description is Markdown, from which raw HTML and unsafe links are removed.
+// Language is a BCP 47 tag such as "en" or "pt-BR", the publication date a date such as
+// "2024-05-31". Dimensions are in millimetres and the weight in grams.
+type BookMetadata struct {
+	Description     string `json:"description,omitempty"`
+	PageCount       int    `json:"page_count,omitempty" validate:"min=0"`
+	Language        string `json:"language,omitempty" validate:"omitempty,max=35"`
+	Publisher       string `json:"publisher,omitempty" validate:"omitempty,max=255"`
+	PublicationDate string `json:"publication_date,omitempty"`
+	Series          string `json:"series,omitempty" validate:"omitempty,max=255"`
+	SeriesPosition  int    `json:"series_position,omitempty" validate:"min=0"`
+	WidthMm         int    `json:"width_mm,omitempty" validate:"min=0"`
+	HeightMm        int    `json:"height_mm,omitempty" validate:"min=0"`
+	DepthMm         int    `json:"depth_mm,omitempty" validate:"min=0"`
+	WeightGrams     int    `json:"weight_g,omitempty" validate:"min=0"`
+}
+
+// BookResponse carries the regular price and, while a sale runs, the sale price
+// charged instead. Prices are objects such as {"amount":"12.50","currency":"EUR"}.
+// In a cart, Format tells which format of the book it holds, with the format's price;
+// digital formats show a stock of 0 as they never run out. Cover is missing for books
+// without a cover image.
 type BookResponse struct {
-	Title      string `json:"title"`
-	Year       int    `json:"year"`
-	Author     string `json:"author"`
-	Price      int    `json:"price"`
-	Stock      int    `json:"stock"`
-	CategoryId int    `json:"category_id"`
+	Title      string         `json:"title"`
+	Year       int            `json:"year"`
+	Author     string         `json:"author"`
+	Price      domain.Money   `json:"price"`
+	SalePrice  *domain.Money  `json:"sale_price,omitempty"`
+	SaleEndsAt *time.Time     `json:"sale_ends_at,omitempty"`
+	Stock      int            `json:"stock"`
+	CategoryId int            `json:"category_id"`
+	ISBN       string         `json:"isbn,omitempty"`
+	Format     string         `json:"format,omitempty"`
+	Cover      *CoverResponse `json:"cover,omitempty"`
+	BookMetadata
+}
+
+// CoverResponse has the URLs of the renditions of a cover image: the uploaded original,
+// and JPEGs scaled down to medium and thumbnail widths.
+type CoverResponse struct {
+	Original  string `json:"original"`
+	Medium    string `json:"medium"`
+	Thumbnail string `json:"thumbnail"`
+}
+
+// ArchivedBookResponse is a book removed from the catalogue, as shown to admins.
+type ArchivedBookResponse struct {
+	Id int `json:"id"`
+	BookResponse
+	ArchivedAt time.Time `json:"archived_at"`
+}
+
+type ImportRowErrorResponse struct {
+	Line  int    `json:"line"`
+	Error string `json:"error"`
+}
+
+// ImportReportResponse counts what a catalogue import did, or would have done in a dry run.
+// Rows listed in errors were skipped.
+type ImportReportResponse struct {
+	DryRun    bool                     `json:"dry_run"`
+	Rows      int                      `json:"rows"`
+	Created   int                      `json:"created"`
+	Updated   int                      `json:"updated"`
+	Unchanged int                      `json:"unchanged"`
+	Archived  int                      `json:"archived"`
+	Failed    int                      `json:"failed"`
+	Errors    []ImportRowErrorResponse `json:"errors"`
 }
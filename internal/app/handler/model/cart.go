@@ -1,5 +1,26 @@
 package model
 
+// AddToCartRequest names a book and, to buy one of its formats rather than the book
+// itself, the format: hardcover, paperback, epub or pdf.
 type AddToCartRequest struct {
-	BookId int `json:"book_id"`
+	BookId int    `json:"book_id"`
+	Format string `json:"format,omitempty"`
+}
+
+// PurchaseRequest says where the order goes. Its tax is worked out for the country and
+// region of the shipping address.
+type PurchaseRequest struct {
+	ShippingAddress *ShippingAddress `json:"shipping_address" validate:"required"`
+}
+
+type ShippingAddress struct {
+	Name       string `json:"name" validate:"required,max=255"`
+	Line1      string `json:"line1" validate:"required,max=255"`
+	Line2      string `json:"line2,omitempty" validate:"max=255"`
+	City       string `json:"city" validate:"required,max=255"`
+	PostalCode string `json:"postal_code,omitempty" validate:"max=32"`
+	// Region is the ISO 3166-2 subdivision within the country, such as "CA" in the US.
+	Region string `json:"region,omitempty" validate:"omitempty,max=3"`
+	// Country is an ISO 3166-1 alpha-2 code such as "DE".
+	Country string `json:"country" validate:"required,len=2"`
 }
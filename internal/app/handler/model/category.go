@@ -1,15 +1,29 @@
 package model
 
+import "time"
+
+// CategoryRequest creates a category. Without a tax class its books are taxed at the
+// standard rate.
 type CategoryRequest struct {
-	Name string `json:"name" validate:"required,min=1,max=100"`
+	Name     string `json:"name" validate:"required,min=1,max=100"`
+	TaxClass string `json:"tax_class,omitempty" validate:"omitempty,max=32"`
 }
 
+// CategoryUpdateRequest changes a category. Without a tax class the current one is kept.
 type CategoryUpdateRequest struct {
-	Id   int    `json:"id" validate:"required"`
-	Name string `json:"name" validate:"required,min=1,max=100"`
+	Id       int    `json:"id" validate:"required"`
+	Name     string `json:"name" validate:"required,min=1,max=100"`
+	TaxClass string `json:"tax_class,omitempty" validate:"omitempty,max=32"`
 }
 
 type CategoryResponse struct {
-	Id   int    `json:"id"`
-	Name string `json:"name"`
+	Id       int    `json:"id"`
+	Name     string `json:"name"`
+	TaxClass string `json:"tax_class"`
+}
+
+// ArchivedCategoryResponse is a category removed from the catalogue, as shown to admins.
+type ArchivedCategoryResponse struct {
+	CategoryResponse
+	ArchivedAt time.Time `json:"archived_at"`
 }
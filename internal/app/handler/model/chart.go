@@ -0,0 +1,11 @@
+package model
+
+import "time"
+
+// ChartBookResponse is a book on a storefront chart, at its rank from 1.
+type ChartBookResponse struct {
+	BookId  int       `json:"book_id"`
+	Rank    int       `json:"rank"`
+	AddedAt time.Time `json:"added_at"`
+	BookResponse
+}
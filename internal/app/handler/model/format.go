@@ -0,0 +1,45 @@
+package model
+
+import (
+	"time"
+	"toptal/internal/app/domain"
+)
+
+// BookFormatRequest sets the price of a format and, for printed formats, its stock.
+type BookFormatRequest struct {
+	Price domain.Money `json:"price"`
+	Stock int          `json:"stock" validate:"min=0"`
+}
+
+// BookFormatResponse is a format a book is sold in. Digital formats have no stock and are
+// available once their file is uploaded.
+type BookFormatResponse struct {
+	Format    string              `json:"format"`
+	Price     domain.Money        `json:"price"`
+	Digital   bool                `json:"digital"`
+	Stock     *int                `json:"stock,omitempty"`
+	Available bool                `json:"available"`
+	File      *FormatFileResponse `json:"file,omitempty"`
+}
+
+type FormatFileResponse struct {
+	Name        string `json:"name"`
+	ContentType string `json:"content_type"`
+	Size        int64  `json:"size"`
+}
+
+// DownloadResponse is a digital format the user bought. URL is a signed link that works
+// until ExpiresAt; it is missing once no downloads remain or the file was removed.
+type DownloadResponse struct {
+	Id             int        `json:"id"`
+	OrderId        int        `json:"order_id"`
+	Title          string     `json:"title"`
+	Author         string     `json:"author"`
+	Format         string     `json:"format"`
+	Downloads      int        `json:"downloads"`
+	Remaining      int        `json:"remaining"`
+	URL            string     `json:"url,omitempty"`
+	ExpiresAt      *time.Time `json:"expires_at,omitempty"`
+	LastDownloadAt *time.Time `json:"last_download_at,omitempty"`
+	CreatedAt      time.Time  `json:"created_at"`
+}
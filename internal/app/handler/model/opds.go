@@ -0,0 +1,84 @@
+package model
+
+import "encoding/xml"
+
+// Media types of OPDS 1.2 catalogs.
+const (
+	OPDSNavigationType  = "application/atom+xml;profile=opds-catalog;kind=navigation"
+	OPDSAcquisitionType = "application/atom+xml;profile=opds-catalog;kind=acquisition"
+	OpenSearchType      = "application/opensearchdescription+xml"
+)
+
+// OPDSFeed is an Atom feed of an OPDS catalog, listing either catalogs to browse
+// (navigation) or books (acquisition).
+type OPDSFeed struct {
+	XMLName   xml.Name    `xml:"feed"`
+	Xmlns     string      `xml:"xmlns,attr"`
+	XmlnsDC   string      `xml:"xmlns:dc,attr"`
+	XmlnsOPDS string      `xml:"xmlns:opds,attr"`
+	ID        string      `xml:"id"`
+	Title     string      `xml:"title"`
+	Updated   string      `xml:"updated"`
+	Author    OPDSAuthor  `xml:"author"`
+	Links     []OPDSLink  `xml:"link"`
+	Entries   []OPDSEntry `xml:"entry"`
+}
+
+type OPDSAuthor struct {
+	Name string `xml:"name"`
+}
+
+type OPDSLink struct {
+	Rel   string     `xml:"rel,attr"`
+	Href  string     `xml:"href,attr"`
+	Type  string     `xml:"type,attr,omitempty"`
+	Title string     `xml:"title,attr,omitempty"`
+	Price *OPDSPrice `xml:"opds:price,omitempty"`
+}
+
+// OPDSPrice is the price of an acquisition link, such as 12.50 in currency EUR.
+type OPDSPrice struct {
+	CurrencyCode string `xml:"currencycode,attr"`
+	Value        string `xml:",chardata"`
+}
+
+type OPDSCategory struct {
+	Term  string `xml:"term,attr"`
+	Label string `xml:"label,attr,omitempty"`
+}
+
+type OPDSContent struct {
+	Type  string `xml:"type,attr"`
+	Value string `xml:",chardata"`
+}
+
+type OPDSEntry struct {
+	ID         string         `xml:"id"`
+	Title      string         `xml:"title"`
+	Updated    string         `xml:"updated"`
+	Authors    []OPDSAuthor   `xml:"author,omitempty"`
+	Identifier string         `xml:"dc:identifier,omitempty"`
+	Issued     string         `xml:"dc:issued,omitempty"`
+	Language   string         `xml:"dc:language,omitempty"`
+	Publisher  string         `xml:"dc:publisher,omitempty"`
+	Summary    string         `xml:"summary,omitempty"`
+	Categories []OPDSCategory `xml:"category,omitempty"`
+	Content    *OPDSContent   `xml:"content,omitempty"`
+	Links      []OPDSLink     `xml:"link"`
+}
+
+// OpenSearchDescription tells OPDS clients how to search the catalog.
+type OpenSearchDescription struct {
+	XMLName        xml.Name        `xml:"OpenSearchDescription"`
+	Xmlns          string          `xml:"xmlns,attr"`
+	ShortName      string          `xml:"ShortName"`
+	Description    string          `xml:"Description"`
+	InputEncoding  string          `xml:"InputEncoding"`
+	OutputEncoding string          `xml:"OutputEncoding"`
+	URL            []OpenSearchURL `xml:"Url"`
+}
+
+type OpenSearchURL struct {
+	Type     string `xml:"type,attr"`
+	Template string `xml:"template,attr"`
+}
@@ -0,0 +1,36 @@
+package model
+
+import (
+	"time"
+	"toptal/internal/app/domain"
+)
+
+// PriceChangeRequest schedules a new price. With ends_at it is a sale that ends on its own.
+type PriceChangeRequest struct {
+	Price    domain.Money `json:"price"`
+	StartsAt time.Time    `json:"starts_at" validate:"required"`
+	EndsAt   *time.Time   `json:"ends_at"`
+}
+
+type PriceChangeResponse struct {
+	Id          int          `json:"id"`
+	BookId      int          `json:"book_id"`
+	Price       domain.Money `json:"price"`
+	Sale        bool         `json:"sale"`
+	StartsAt    time.Time    `json:"starts_at"`
+	EndsAt      *time.Time   `json:"ends_at,omitempty"`
+	Status      string       `json:"status"`
+	CreatedBy   *int         `json:"created_by,omitempty"`
+	CreatedAt   time.Time    `json:"created_at"`
+	AppliedAt   *time.Time   `json:"applied_at,omitempty"`
+	EndedAt     *time.Time   `json:"ended_at,omitempty"`
+	CancelledAt *time.Time   `json:"cancelled_at,omitempty"`
+}
+
+type PriceHistoryResponse struct {
+	Price         domain.Money  `json:"price"`
+	SalePrice     *domain.Money `json:"sale_price,omitempty"`
+	Reason        string        `json:"reason"`
+	PriceChangeId *int          `json:"price_change_id,omitempty"`
+	ChangedAt     time.Time     `json:"changed_at"`
+}
@@ -0,0 +1,96 @@
+package model
+
+import (
+	"time"
+	"toptal/internal/app/domain"
+)
+
+type SupplierRequest struct {
+	Name  string `json:"name" validate:"required,min=1,max=255"`
+	Email string `json:"email,omitempty" validate:"omitempty,email,max=255"`
+	Phone string `json:"phone,omitempty" validate:"omitempty,max=50"`
+}
+
+type SupplierResponse struct {
+	Id        int       `json:"id"`
+	Name      string    `json:"name"`
+	Email     string    `json:"email,omitempty"`
+	Phone     string    `json:"phone,omitempty"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+// BookSupplierRequest sets the price the shop pays a supplier for a book. SKU is the
+// supplier's own code for it.
+type BookSupplierRequest struct {
+	CostPrice domain.Money `json:"cost_price"`
+	SKU       string       `json:"sku,omitempty" validate:"omitempty,max=64"`
+}
+
+type BookSupplierResponse struct {
+	BookId       int          `json:"book_id"`
+	SupplierId   int          `json:"supplier_id"`
+	SupplierName string       `json:"supplier_name,omitempty"`
+	CostPrice    domain.Money `json:"cost_price"`
+	SKU          string       `json:"sku,omitempty"`
+	UpdatedAt    time.Time    `json:"updated_at"`
+}
+
+// PurchaseQuantityRequest is a number of copies of a book ordered or received.
+type PurchaseQuantityRequest struct {
+	BookId   int `json:"book_id" validate:"required,min=1"`
+	Quantity int `json:"quantity" validate:"required,min=1"`
+}
+
+// PurchaseOrderRequest drafts an order of books from a supplier, at the cost prices it
+// charges.
+type PurchaseOrderRequest struct {
+	SupplierId int                       `json:"supplier_id" validate:"required,min=1"`
+	Items      []PurchaseQuantityRequest `json:"items" validate:"required,min=1,dive"`
+}
+
+// PurchaseOrderUpdateRequest replaces the books a draft orders.
+type PurchaseOrderUpdateRequest struct {
+	Items []PurchaseQuantityRequest `json:"items" validate:"required,min=1,dive"`
+}
+
+// ReceivePurchaseOrderRequest books a delivery. Without items everything outstanding is
+// received.
+type ReceivePurchaseOrderRequest struct {
+	Items []PurchaseQuantityRequest `json:"items,omitempty" validate:"dive"`
+}
+
+// PurchaseOrderItemResponse is a book on a purchase order. BookId is missing once the
+// book has been purged.
+type PurchaseOrderItemResponse struct {
+	BookId      *int         `json:"book_id,omitempty"`
+	Title       string       `json:"title"`
+	Quantity    int          `json:"quantity"`
+	Received    int          `json:"received"`
+	Outstanding int          `json:"outstanding"`
+	CostPrice   domain.Money `json:"cost_price"`
+	Total       domain.Money `json:"total"`
+}
+
+type PurchaseOrderResponse struct {
+	Id           int                         `json:"id"`
+	SupplierId   int                         `json:"supplier_id"`
+	SupplierName string                      `json:"supplier_name"`
+	Status       string                      `json:"status"`
+	Items        []PurchaseOrderItemResponse `json:"items"`
+	Total        domain.Money                `json:"total"`
+	CreatedAt    time.Time                   `json:"created_at"`
+	SentAt       *time.Time                  `json:"sent_at,omitempty"`
+	ReceivedAt   *time.Time                  `json:"received_at,omitempty"`
+}
+
+// StockMovementResponse is a change to the stock of a book other than a sale. Positive
+// quantities add stock.
+type StockMovementResponse struct {
+	Id              int       `json:"id"`
+	BookId          int       `json:"book_id"`
+	Title           string    `json:"title"`
+	Quantity        int       `json:"quantity"`
+	Reason          string    `json:"reason"`
+	PurchaseOrderId *int      `json:"purchase_order_id,omitempty"`
+	CreatedAt       time.Time `json:"created_at"`
+}
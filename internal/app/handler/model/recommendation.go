@@ -0,0 +1,9 @@
+package model
+
+// RecommendationResponse is a recommended book. Reason is co_purchase for books bought by
+// the same customers, bestseller for those filling in where purchases are too few.
+type RecommendationResponse struct {
+	BookId int    `json:"book_id"`
+	Reason string `json:"reason"`
+	BookResponse
+}
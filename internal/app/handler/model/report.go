@@ -0,0 +1,50 @@
+package model
+
+import (
+	"time"
+	"toptal/internal/app/domain"
+)
+
+// Report rows start with the first moment of their period, in UTC. Amounts are in the
+// currency of the orders; orders in other currencies are on rows of their own.
+
+type CategoryRevenueResponse struct {
+	Period       time.Time    `json:"period"`
+	CategoryId   int          `json:"category_id"`
+	CategoryName string       `json:"category_name"`
+	Orders       int          `json:"orders"`
+	Units        int          `json:"units"`
+	Net          domain.Money `json:"net"`
+	Tax          domain.Money `json:"tax"`
+}
+
+// BookSalesResponse has a book id of 0 for books purged from the catalogue.
+type BookSalesResponse struct {
+	Period time.Time    `json:"period"`
+	BookId int          `json:"book_id"`
+	Title  string       `json:"title"`
+	Author string       `json:"author"`
+	Units  int          `json:"units"`
+	Net    domain.Money `json:"net"`
+}
+
+type BasketSizeResponse struct {
+	Period       time.Time    `json:"period"`
+	Orders       int          `json:"orders"`
+	Units        int          `json:"units"`
+	Total        domain.Money `json:"total"`
+	AverageUnits float64      `json:"average_units"`
+	AverageTotal domain.Money `json:"average_total"`
+}
+
+// StockTurnoverResponse estimates the opening stock from the current stock and later
+// sales; restocks are not known.
+type StockTurnoverResponse struct {
+	Period       time.Time `json:"period"`
+	CategoryId   int       `json:"category_id"`
+	CategoryName string    `json:"category_name"`
+	Units        int       `json:"units"`
+	OpeningStock int       `json:"opening_stock"`
+	ClosingStock int       `json:"closing_stock"`
+	Turnover     float64   `json:"turnover"`
+}
@@ -0,0 +1,28 @@
+package model
+
+// ReorderThresholdRequest sets the stock at or below which a book is reported as low. A
+// null threshold makes the book use the default one.
+type ReorderThresholdRequest struct {
+	Threshold *int `json:"threshold" validate:"omitempty,min=0"`
+}
+
+type ReorderThresholdResponse struct {
+	BookId    int  `json:"book_id"`
+	Threshold int  `json:"threshold"`
+	Default   bool `json:"default"`
+}
+
+// StockAlertResponse is a book at or below its reorder threshold. Sold and DailySales are
+// measured over the recent sales window the suggested quantity is based on.
+type StockAlertResponse struct {
+	BookId            int     `json:"book_id"`
+	Title             string  `json:"title"`
+	Author            string  `json:"author"`
+	ISBN              string  `json:"isbn,omitempty"`
+	Level             string  `json:"level"`
+	Stock             int     `json:"stock"`
+	Threshold         int     `json:"threshold"`
+	Sold              int     `json:"sold"`
+	DailySales        float64 `json:"daily_sales"`
+	SuggestedQuantity int     `json:"suggested_quantity"`
+}
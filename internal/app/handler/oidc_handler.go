@@ -0,0 +1,110 @@
+package handler
+
+import (
+	"crypto/subtle"
+	"errors"
+	"log/slog"
+	"net/http"
+	"time"
+	"toptal/internal/app/domain"
+	"toptal/internal/app/handler/model"
+)
+
+const (
+	oidcStateCookie = "oidc_state"
+	oidcCookiePath  = "/oidc/"
+)
+
+// @Summary Start single sign-on
+// @Description Redirect to the OpenID Connect provider. The state is bound to the browser with a cookie.
+// @Tags auth
+// @Param provider path string true "Provider name"
+// @Success 302 "Redirect to the provider"
+// @Failure 404 {object} model.ProblemDetail "Unknown provider"
+// @Failure 500 {object} model.ProblemDetail "Internal Server Error"
+// @Router /oidc/{provider}/login [get]
+func (s *Server) handleOIDCLogin(w http.ResponseWriter, r *http.Request) {
+	provider := r.PathValue("provider")
+
+	authURL, state, err := s.oidcService.StartLogin(r.Context(), provider)
+	if err != nil {
+		if errors.Is(err, domain.ErrNotFound) {
+			model.NotFound(w, "unknown identity provider", r.URL.Path)
+			return
+		}
+		slog.Error("error starting oidc login", "provider", provider, "error", err)
+		model.InternalServerError(w, r.URL.Path)
+		return
+	}
+
+	http.SetCookie(w, &http.Cookie{
+		Name:     oidcStateCookie,
+		Value:    state,
+		Path:     oidcCookiePath,
+		HttpOnly: true,
+		Secure:   r.TLS != nil,
+		SameSite: http.SameSiteLaxMode,
+	})
+	http.Redirect(w, r, authURL, http.StatusFound)
+}
+
+// @Summary Complete single sign-on
+// @Description Handle the provider redirect and log in the linked user, creating an account on first sign-in. Users with two-factor authentication get a challenge token instead.
+// @Tags auth
+// @Produce json
+// @Param provider path string true "Provider name"
+// @Param state query string true "State from the login redirect"
+// @Param code query string true "Authorization code"
+// @Success 200 {object} model.LoginResponse "Returns JWT token"
+// @Failure 400 {object} model.ProblemDetail "Bad Request"
+// @Failure 401 {object} model.ProblemDetail "Unauthorized"
+// @Failure 404 {object} model.ProblemDetail "Unknown provider"
+// @Failure 500 {object} model.ProblemDetail "Internal Server Error"
+// @Router /oidc/{provider}/callback [get]
+func (s *Server) handleOIDCCallback(w http.ResponseWriter, r *http.Request) {
+	provider := r.PathValue("provider")
+	query := r.URL.Query()
+
+	// The state is single use whatever the outcome.
+	http.SetCookie(w, &http.Cookie{
+		Name:     oidcStateCookie,
+		Path:     oidcCookiePath,
+		Expires:  time.Unix(0, 0),
+		MaxAge:   -1,
+		HttpOnly: true,
+	})
+
+	if providerError := query.Get("error"); providerError != "" {
+		model.Unauthorized(w, "sign-in was not completed: "+providerError, r.URL.Path)
+		return
+	}
+
+	state, code := query.Get("state"), query.Get("code")
+	if state == "" || code == "" {
+		model.InvalidRequest(w, "state and code are required", r.URL.Path)
+		return
+	}
+
+	cookie, err := r.Cookie(oidcStateCookie)
+	if err != nil || subtle.ConstantTimeCompare([]byte(cookie.Value), []byte(state)) != 1 {
+		model.Unauthorized(w, "sign-in was started in a different browser", r.URL.Path)
+		return
+	}
+
+	result, err := s.oidcService.CompleteLogin(r.Context(), provider, state, code)
+	if err != nil {
+		switch {
+		case errors.Is(err, domain.ErrNotFound):
+			model.NotFound(w, "unknown identity provider", r.URL.Path)
+		case errors.Is(err, domain.ErrInvalidToken), errors.Is(err, domain.ErrUnauthorized):
+			model.Unauthorized(w, domain.ErrUnauthorized.Error(), r.URL.Path)
+		default:
+			slog.Error("error completing oidc login", "provider", provider, "error", err)
+			model.InternalServerError(w, r.URL.Path)
+		}
+		return
+	}
+
+	response := toLoginResponse(result)
+	writeResponseOK(w, response)
+}
@@ -0,0 +1,332 @@
+package handler
+
+import (
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+	"toptal/internal/app/domain"
+	"toptal/internal/app/handler/model"
+)
+
+// opdsPageSize is the number of books in a page of an acquisition feed.
+const opdsPageSize = 25
+
+const (
+	atomNamespace       = "http://www.w3.org/2005/Atom"
+	dcNamespace         = "http://purl.org/dc/terms/"
+	opdsNamespace       = "http://opds-spec.org/2010/catalog"
+	openSearchNamespace = "http://a9.com/-/spec/opensearch/1.1/"
+	opdsBuyRel          = "http://opds-spec.org/acquisition/buy"
+	opdsImageRel        = "http://opds-spec.org/image"
+	opdsThumbnailRel    = "http://opds-spec.org/image/thumbnail"
+)
+
+// @Summary OPDS root catalog
+// @Description OPDS 1.2 navigation feed for e-reader apps, with a catalog of all books and one per category.
+// @Tags opds
+// @Produce application/atom+xml
+// @Success 200 {object} model.OPDSFeed
+// @Failure 500 {object} model.ProblemDetail "Internal Server Error"
+// @Router /opds [get]
+func (s *Server) handleOPDSRoot(w http.ResponseWriter, r *http.Request) {
+	categories, err := s.categoryService.GetCategories(r.Context())
+	if err != nil {
+		slog.Error("error getting categories for OPDS", "error", err)
+		model.InternalServerError(w, r.URL.Path)
+		return
+	}
+
+	base := requestBaseURL(r)
+	feed := newOPDSFeed(base, "urn:bookshop:opds", "Book Shop")
+	feed.Links = append(feed.Links, model.OPDSLink{Rel: "self", Href: base + "/opds", Type: model.OPDSNavigationType})
+	feed.Entries = append(feed.Entries, opdsNavigationEntry(feed.Updated, "urn:bookshop:opds:books", "All books",
+		"Every book in stock.", base+"/opds/books"))
+	for _, category := range categories {
+		feed.Entries = append(feed.Entries, opdsNavigationEntry(feed.Updated,
+			fmt.Sprintf("urn:bookshop:opds:category:%d", category.Id()), category.Name(),
+			"Books in "+category.Name()+".", fmt.Sprintf("%s/opds/categories/%d", base, category.Id())))
+	}
+	writeXML(w, model.OPDSNavigationType, feed)
+}
+
+// @Summary OPDS catalog of all books
+// @Description OPDS 1.2 acquisition feed of the books in stock, 25 to a page.
+// @Tags opds
+// @Produce application/atom+xml
+// @Param page query int false "Page, starting at 1"
+// @Success 200 {object} model.OPDSFeed
+// @Failure 400 {object} model.ProblemDetail "Bad Request"
+// @Failure 500 {object} model.ProblemDetail "Internal Server Error"
+// @Router /opds/books [get]
+func (s *Server) handleOPDSBooks(w http.ResponseWriter, r *http.Request) {
+	page, ok := pageParam(w, r)
+	if !ok {
+		return
+	}
+	books, err := s.bookService.GetAvailableBooks(r.Context(), domain.BookFilter{}, opdsPageSize+1, (page-1)*opdsPageSize, "")
+	if err != nil {
+		slog.Error("error getting books for OPDS", "error", err)
+		model.InternalServerError(w, r.URL.Path)
+		return
+	}
+	s.writeAcquisitionFeed(w, r, "urn:bookshop:opds:books", "All books", books, page)
+}
+
+// @Summary OPDS catalog of a category
+// @Description OPDS 1.2 acquisition feed of the books in stock in a category, 25 to a page.
+// @Tags opds
+// @Produce application/atom+xml
+// @Param id path int true "Category ID"
+// @Param page query int false "Page, starting at 1"
+// @Success 200 {object} model.OPDSFeed
+// @Failure 400 {object} model.ProblemDetail "Bad Request"
+// @Failure 404 {object} model.ProblemDetail "Category Not Found"
+// @Failure 500 {object} model.ProblemDetail "Internal Server Error"
+// @Router /opds/categories/{id} [get]
+func (s *Server) handleOPDSCategory(w http.ResponseWriter, r *http.Request) {
+	id, err := strconv.Atoi(r.PathValue("id"))
+	if err != nil {
+		model.InvalidRequest(w, "Invalid category ID", r.URL.Path)
+		return
+	}
+	page, ok := pageParam(w, r)
+	if !ok {
+		return
+	}
+	category, err := s.categoryService.GetCategoryById(r.Context(), id)
+	if err != nil {
+		if errors.Is(err, domain.ErrNotFound) {
+			model.NotFound(w, "Category Not Found", r.URL.Path)
+		} else {
+			slog.Error("error getting category for OPDS", "error", err)
+			model.InternalServerError(w, r.URL.Path)
+		}
+		return
+	}
+	books, err := s.bookService.GetAvailableBooks(r.Context(), domain.BookFilter{CategoryIds: []int{id}}, opdsPageSize+1, (page-1)*opdsPageSize, "")
+	if err != nil {
+		slog.Error("error getting books for OPDS", "error", err)
+		model.InternalServerError(w, r.URL.Path)
+		return
+	}
+	s.writeAcquisitionFeed(w, r, fmt.Sprintf("urn:bookshop:opds:category:%d", id), category.Name(), books, page)
+}
+
+// @Summary Search the OPDS catalog
+// @Description OPDS 1.2 acquisition feed of the books in stock whose title or author contains the search terms, or that have them as ISBN.
+// @Tags opds
+// @Produce application/atom+xml
+// @Param q query string true "Search terms"
+// @Param page query int false "Page, starting at 1"
+// @Success 200 {object} model.OPDSFeed
+// @Failure 400 {object} model.ProblemDetail "Bad Request"
+// @Failure 500 {object} model.ProblemDetail "Internal Server Error"
+// @Router /opds/search [get]
+func (s *Server) handleOPDSSearch(w http.ResponseWriter, r *http.Request) {
+	query := strings.TrimSpace(r.URL.Query().Get("q"))
+	if query == "" || len(query) > 255 {
+		model.InvalidRequest(w, "Search terms must have 1 to 255 characters", r.URL.Path)
+		return
+	}
+	page, ok := pageParam(w, r)
+	if !ok {
+		return
+	}
+	books, err := s.bookService.SearchBooks(r.Context(), query, opdsPageSize+1, (page-1)*opdsPageSize, "")
+	if err != nil {
+		slog.Error("error searching books for OPDS", "error", err)
+		model.InternalServerError(w, r.URL.Path)
+		return
+	}
+	s.writeAcquisitionFeed(w, r, "urn:bookshop:opds:search:"+url.QueryEscape(query), "Search: "+query, books, page)
+}
+
+// @Summary OPDS search description
+// @Description OpenSearch description of the OPDS catalog search.
+// @Tags opds
+// @Produce application/opensearchdescription+xml
+// @Success 200 {object} model.OpenSearchDescription
+// @Router /opds/opensearch.xml [get]
+func (s *Server) handleOPDSOpenSearch(w http.ResponseWriter, r *http.Request) {
+	writeXML(w, model.OpenSearchType, model.OpenSearchDescription{
+		Xmlns:          openSearchNamespace,
+		ShortName:      "Book Shop",
+		Description:    "Search the books of the shop by title, author or ISBN.",
+		InputEncoding:  "UTF-8",
+		OutputEncoding: "UTF-8",
+		URL: []model.OpenSearchURL{{
+			Type:     model.OPDSAcquisitionType,
+			Template: requestBaseURL(r) + "/opds/search?q={searchTerms}",
+		}},
+	})
+}
+
+// writeAcquisitionFeed writes a page of books. books may hold one book more than a page,
+// which only tells that there is a next page.
+func (s *Server) writeAcquisitionFeed(
+	w http.ResponseWriter, r *http.Request, id string, title string, books []domain.Book, page int,
+) {
+	categories, err := s.categoryService.GetCategories(r.Context())
+	if err != nil {
+		slog.Error("error getting categories for OPDS", "error", err)
+		model.InternalServerError(w, r.URL.Path)
+		return
+	}
+	categoryNames := make(map[int]string, len(categories))
+	for _, category := range categories {
+		categoryNames[category.Id()] = category.Name()
+	}
+
+	base := requestBaseURL(r)
+	feed := newOPDSFeed(base, id, title)
+	feed.Links = append(feed.Links,
+		model.OPDSLink{Rel: "self", Href: opdsPageURL(base, r, page), Type: model.OPDSAcquisitionType},
+		model.OPDSLink{Rel: "up", Href: base + "/opds", Type: model.OPDSNavigationType},
+		model.OPDSLink{Rel: "first", Href: opdsPageURL(base, r, 1), Type: model.OPDSAcquisitionType},
+	)
+	if page > 1 {
+		feed.Links = append(feed.Links,
+			model.OPDSLink{Rel: "previous", Href: opdsPageURL(base, r, page-1), Type: model.OPDSAcquisitionType})
+	}
+	if len(books) > opdsPageSize {
+		books = books[:opdsPageSize]
+		feed.Links = append(feed.Links,
+			model.OPDSLink{Rel: "next", Href: opdsPageURL(base, r, page+1), Type: model.OPDSAcquisitionType})
+	}
+	for _, book := range books {
+		feed.Entries = append(feed.Entries, opdsBookEntry(base, feed.Updated, book, categoryNames[book.CategoryId()]))
+	}
+	writeXML(w, model.OPDSAcquisitionType, feed)
+}
+
+func newOPDSFeed(base, id, title string) model.OPDSFeed {
+	return model.OPDSFeed{
+		Xmlns:     atomNamespace,
+		XmlnsDC:   dcNamespace,
+		XmlnsOPDS: opdsNamespace,
+		ID:        id,
+		Title:     title,
+		Updated:   time.Now().UTC().Format(time.RFC3339),
+		Author:    model.OPDSAuthor{Name: "Book Shop"},
+		Links: []model.OPDSLink{
+			{Rel: "start", Href: base + "/opds", Type: model.OPDSNavigationType},
+			{Rel: "search", Href: base + "/opds/opensearch.xml", Type: model.OpenSearchType},
+		},
+	}
+}
+
+func opdsNavigationEntry(updated, id, title, content, href string) model.OPDSEntry {
+	return model.OPDSEntry{
+		ID:      id,
+		Title:   title,
+		Updated: updated,
+		Content: &model.OPDSContent{Type: "text", Value: content},
+		Links:   []model.OPDSLink{{Rel: "subsection", Href: href, Type: model.OPDSAcquisitionType}},
+	}
+}
+
+// opdsBookEntry describes a book. Its links lead to the book in the JSON API, where it can
+// be put in a cart.
+func opdsBookEntry(base, updated string, book domain.Book, categoryName string) model.OPDSEntry {
+	entry := model.OPDSEntry{
+		ID:        fmt.Sprintf("urn:bookshop:book:%d", book.Id()),
+		Title:     book.Title(),
+		Updated:   updated,
+		Authors:   []model.OPDSAuthor{{Name: book.Author()}},
+		Issued:    strconv.Itoa(book.Year()),
+		Summary:   book.Description(),
+		Language:  book.Language(),
+		Publisher: book.Publisher(),
+	}
+	if !book.PublicationDate().IsZero() {
+		entry.Issued = book.PublicationDate().Format(time.DateOnly)
+	}
+	if book.ISBN() != "" {
+		entry.ID = "urn:isbn:" + book.ISBN()
+		entry.Identifier = entry.ID
+	}
+	if categoryName != "" {
+		entry.Categories = []model.OPDSCategory{{Term: categoryName, Label: categoryName}}
+	}
+
+	price := book.Price()
+	if sale, ok := book.SalePrice(); ok {
+		price = sale
+	}
+	href := fmt.Sprintf("%s/book/%d", base, book.Id())
+	entry.Links = []model.OPDSLink{
+		{Rel: "alternate", Href: href, Type: "application/json"},
+		{
+			Rel:   opdsBuyRel,
+			Href:  href,
+			Type:  "application/json",
+			Price: &model.OPDSPrice{CurrencyCode: price.Currency(), Value: price.Decimal()},
+		},
+	}
+	if cover, ok := book.Cover(); ok {
+		entry.Links = append(entry.Links,
+			model.OPDSLink{
+				Rel:  opdsImageRel,
+				Href: base + coverURL(book.Id(), cover, domain.CoverMedium),
+				Type: cover.RenditionContentType(domain.CoverMedium),
+			},
+			model.OPDSLink{
+				Rel:  opdsThumbnailRel,
+				Href: base + coverURL(book.Id(), cover, domain.CoverThumbnail),
+				Type: cover.RenditionContentType(domain.CoverThumbnail),
+			},
+		)
+	}
+	return entry
+}
+
+// pageParam reads the optional page query parameter, 1 when it is missing. It writes a
+// problem detail and returns false when the page is invalid.
+func pageParam(w http.ResponseWriter, r *http.Request) (int, bool) {
+	value := r.URL.Query().Get("page")
+	if value == "" {
+		return 1, true
+	}
+	page, err := strconv.Atoi(value)
+	if err != nil || page < 1 || page > 100000 {
+		model.InvalidRequest(w, "Invalid page", r.URL.Path)
+		return 0, false
+	}
+	return page, true
+}
+
+func opdsPageURL(base string, r *http.Request, page int) string {
+	query := r.URL.Query()
+	query.Set("page", strconv.Itoa(page))
+	return base + r.URL.Path + "?" + query.Encode()
+}
+
+// requestBaseURL is the scheme and host the client used, so feeds link back to the
+// same server.
+func requestBaseURL(r *http.Request) string {
+	scheme := "http"
+	if r.TLS != nil {
+		scheme = "https"
+	}
+	if proto := r.Header.Get("X-Forwarded-Proto"); proto == "http" || proto == "https" {
+		scheme = proto
+	}
+	return scheme + "://" + r.Host
+}
+
+func writeXML(w http.ResponseWriter, contentType string, v any) {
+	w.Header().Set("Content-Type", contentType+";charset=utf-8")
+	w.WriteHeader(http.StatusOK)
+	if _, err := w.Write([]byte(xml.Header)); err != nil {
+		return
+	}
+	if err := xml.NewEncoder(w).Encode(v); err != nil {
+		slog.Error("Failed to encode response", "error", err)
+	}
+}
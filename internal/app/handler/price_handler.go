@@ -0,0 +1,294 @@
+package handler
+
+import (
+	"encoding/json"
+	"errors"
+	"log/slog"
+	"net/http"
+	"strconv"
+	"toptal/internal/app/domain"
+	"toptal/internal/app/handler/model"
+	"toptal/internal/app/util"
+	"toptal/internal/pkg/validator"
+)
+
+// @Summary Schedule a price change
+// @Description Schedule a new price for a book, in the book's currency. Without ends_at it replaces the price from starts_at on; with ends_at it is a sale shown next to the regular price. Sales of a book may not overlap.
+// @Tags prices
+// @Accept json
+// @Produce json
+// @Param id path int true "Book ID"
+// @Param request body model.PriceChangeRequest true "Price change"
+// @Success 201 {object} model.PriceChangeResponse
+// @Failure 400 {object} model.ProblemDetail "Bad Request"
+// @Failure 401 {object} model.ProblemDetail "Unauthorized"
+// @Failure 404 {object} model.ProblemDetail "Not Found"
+// @Failure 409 {object} model.ProblemDetail "Conflict"
+// @Failure 500 {object} model.ProblemDetail "Internal Server Error"
+// @Security ApiKeyAuth
+// @Router /book/{id}/price-changes [post]
+func (s *Server) handleSchedulePriceChange(w http.ResponseWriter, r *http.Request) {
+	bookId, err := strconv.Atoi(r.PathValue("id"))
+	if err != nil {
+		model.InvalidRequest(w, "Invalid Book ID", r.URL.Path)
+		return
+	}
+
+	var request model.PriceChangeRequest
+	if err := json.NewDecoder(r.Body).Decode(&request); err != nil {
+		model.InvalidRequest(w, err.Error(), r.URL.Path)
+		return
+	}
+
+	if err := validator.Validate(request); err != nil {
+		model.ValidationError(w, err.Error(), r.URL.Path)
+		return
+	}
+
+	// changes made with an API key have no user behind them
+	userId, _ := util.GetUserID(r.Context())
+	change, err := toPriceChange(request, bookId, userId)
+	if err != nil {
+		model.ValidationError(w, err.Error(), r.URL.Path)
+		return
+	}
+
+	created, err := s.priceService.SchedulePriceChange(r.Context(), change)
+	if err != nil {
+		switch {
+		case errors.Is(err, domain.ErrInvalidPriceChange), errors.Is(err, domain.ErrCurrencyMismatch):
+			model.ValidationError(w, err.Error(), r.URL.Path)
+		case errors.Is(err, domain.ErrNotFound):
+			model.NotFound(w, "Book Not Found", r.URL.Path)
+		case errors.Is(err, domain.ErrSaleOverlap):
+			model.WriteProblemDetail(w, http.StatusConflict, "Sale Overlap", err.Error(), r.URL.Path)
+		default:
+			slog.Error("error scheduling price change", "error", err)
+			model.InternalServerError(w, r.URL.Path)
+		}
+		return
+	}
+
+	writeResponseCreated(w, toPriceChangeResponse(created))
+}
+
+// @Summary List price changes
+// @Description List the scheduled, running and past price changes of a book
+// @Tags prices
+// @Accept json
+// @Produce json
+// @Param id path int true "Book ID"
+// @Success 200 {array} model.PriceChangeResponse
+// @Failure 400 {object} model.ProblemDetail "Bad Request"
+// @Failure 401 {object} model.ProblemDetail "Unauthorized"
+// @Failure 500 {object} model.ProblemDetail "Internal Server Error"
+// @Security ApiKeyAuth
+// @Router /book/{id}/price-changes [get]
+func (s *Server) handleGetPriceChanges(w http.ResponseWriter, r *http.Request) {
+	bookId, err := strconv.Atoi(r.PathValue("id"))
+	if err != nil {
+		model.InvalidRequest(w, "Invalid Book ID", r.URL.Path)
+		return
+	}
+
+	changes, err := s.priceService.GetPriceChanges(r.Context(), bookId)
+	if err != nil {
+		slog.Error("error getting price changes", "error", err)
+		model.InternalServerError(w, r.URL.Path)
+		return
+	}
+
+	writeResponseOK(w, toPriceChangesResponse(changes))
+}
+
+// @Summary Cancel a price change
+// @Description Cancel a scheduled price change or end a running sale early. Changes that already replaced the price cannot be cancelled.
+// @Tags prices
+// @Accept json
+// @Produce json
+// @Param id path int true "Book ID"
+// @Param changeId path int true "Price change ID"
+// @Success 200
+// @Failure 400 {object} model.ProblemDetail "Bad Request"
+// @Failure 401 {object} model.ProblemDetail "Unauthorized"
+// @Failure 404 {object} model.ProblemDetail "Not Found"
+// @Failure 409 {object} model.ProblemDetail "Conflict"
+// @Failure 500 {object} model.ProblemDetail "Internal Server Error"
+// @Security ApiKeyAuth
+// @Router /book/{id}/price-changes/{changeId} [delete]
+func (s *Server) handleCancelPriceChange(w http.ResponseWriter, r *http.Request) {
+	bookId, err := strconv.Atoi(r.PathValue("id"))
+	if err != nil {
+		model.InvalidRequest(w, "Invalid Book ID", r.URL.Path)
+		return
+	}
+	changeId, err := strconv.Atoi(r.PathValue("changeId"))
+	if err != nil {
+		model.InvalidRequest(w, "Invalid Price Change ID", r.URL.Path)
+		return
+	}
+
+	if err := s.priceService.CancelPriceChange(r.Context(), bookId, changeId); err != nil {
+		switch {
+		case errors.Is(err, domain.ErrNotFound):
+			model.NotFound(w, "Price Change Not Found", r.URL.Path)
+		case errors.Is(err, domain.ErrPriceChangeClosed):
+			model.WriteProblemDetail(w, http.StatusConflict, "Price Change Closed", err.Error(), r.URL.Path)
+		default:
+			slog.Error("error cancelling price change", "error", err)
+			model.InternalServerError(w, r.URL.Path)
+		}
+		return
+	}
+
+	w.WriteHeader(http.StatusOK)
+}
+
+// @Summary Get price history
+// @Description Get the prices a book had over time, newest first
+// @Tags prices
+// @Accept json
+// @Produce json
+// @Param id path int true "Book ID"
+// @Param limit query int false "Maximum number of entries (default 50, at most 500)"
+// @Param offset query int false "Number of entries to skip"
+// @Success 200 {array} model.PriceHistoryResponse
+// @Failure 400 {object} model.ProblemDetail "Bad Request"
+// @Failure 401 {object} model.ProblemDetail "Unauthorized"
+// @Failure 500 {object} model.ProblemDetail "Internal Server Error"
+// @Security ApiKeyAuth
+// @Router /book/{id}/price-history [get]
+func (s *Server) handleGetPriceHistory(w http.ResponseWriter, r *http.Request) {
+	bookId, err := strconv.Atoi(r.PathValue("id"))
+	if err != nil {
+		model.InvalidRequest(w, "Invalid Book ID", r.URL.Path)
+		return
+	}
+
+	limit, _ := strconv.Atoi(r.URL.Query().Get("limit"))
+	offset, _ := strconv.Atoi(r.URL.Query().Get("offset"))
+
+	entries, err := s.priceService.GetPriceHistory(r.Context(), bookId, limit, offset)
+	if err != nil {
+		slog.Error("error getting price history", "error", err)
+		model.InternalServerError(w, r.URL.Path)
+		return
+	}
+
+	writeResponseOK(w, toPriceHistoryResponse(entries))
+}
+
+// @Summary List prices in other currencies
+// @Description List the prices of a book in currencies other than its own
+// @Tags prices
+// @Accept json
+// @Produce json
+// @Param id path int true "Book ID"
+// @Success 200 {array} object "Prices such as {\"amount\":\"12.50\",\"currency\":\"EUR\"}"
+// @Failure 400 {object} model.ProblemDetail "Bad Request"
+// @Failure 401 {object} model.ProblemDetail "Unauthorized"
+// @Failure 500 {object} model.ProblemDetail "Internal Server Error"
+// @Security ApiKeyAuth
+// @Router /book/{id}/prices [get]
+func (s *Server) handleGetListPrices(w http.ResponseWriter, r *http.Request) {
+	bookId, err := strconv.Atoi(r.PathValue("id"))
+	if err != nil {
+		model.InvalidRequest(w, "Invalid Book ID", r.URL.Path)
+		return
+	}
+
+	prices, err := s.priceService.GetListPrices(r.Context(), bookId)
+	if err != nil {
+		slog.Error("error getting list prices", "error", err)
+		model.InternalServerError(w, r.URL.Path)
+		return
+	}
+
+	writeResponseOK(w, prices)
+}
+
+// @Summary Set a price in another currency
+// @Description Add or replace the price of a book in a currency other than its own. Customers asking for that currency see and pay this price.
+// @Tags prices
+// @Accept json
+// @Produce json
+// @Param id path int true "Book ID"
+// @Param price body object true "Price such as {\"amount\":\"12.50\",\"currency\":\"EUR\"}"
+// @Success 200
+// @Failure 400 {object} model.ProblemDetail "Bad Request"
+// @Failure 401 {object} model.ProblemDetail "Unauthorized"
+// @Failure 404 {object} model.ProblemDetail "Not Found"
+// @Failure 500 {object} model.ProblemDetail "Internal Server Error"
+// @Security ApiKeyAuth
+// @Router /book/{id}/prices [put]
+func (s *Server) handleSetListPrice(w http.ResponseWriter, r *http.Request) {
+	bookId, err := strconv.Atoi(r.PathValue("id"))
+	if err != nil {
+		model.InvalidRequest(w, "Invalid Book ID", r.URL.Path)
+		return
+	}
+
+	var price domain.Money
+	if err := json.NewDecoder(r.Body).Decode(&price); err != nil {
+		model.InvalidRequest(w, err.Error(), r.URL.Path)
+		return
+	}
+	if !price.IsSet() {
+		model.ValidationError(w, "price is required", r.URL.Path)
+		return
+	}
+
+	if err := s.priceService.SetListPrice(r.Context(), bookId, price); err != nil {
+		switch {
+		case errors.Is(err, domain.ErrInvalidMoney):
+			model.ValidationError(w, err.Error(), r.URL.Path)
+		case errors.Is(err, domain.ErrNotFound):
+			model.NotFound(w, "Book Not Found", r.URL.Path)
+		default:
+			slog.Error("error setting list price", "error", err)
+			model.InternalServerError(w, r.URL.Path)
+		}
+		return
+	}
+
+	w.WriteHeader(http.StatusOK)
+}
+
+// @Summary Remove a price in another currency
+// @Description Remove the price of a book in a currency other than its own
+// @Tags prices
+// @Accept json
+// @Produce json
+// @Param id path int true "Book ID"
+// @Param currency path string true "ISO 4217 currency"
+// @Success 200
+// @Failure 400 {object} model.ProblemDetail "Bad Request"
+// @Failure 401 {object} model.ProblemDetail "Unauthorized"
+// @Failure 404 {object} model.ProblemDetail "Not Found"
+// @Failure 500 {object} model.ProblemDetail "Internal Server Error"
+// @Security ApiKeyAuth
+// @Router /book/{id}/prices/{currency} [delete]
+func (s *Server) handleDeleteListPrice(w http.ResponseWriter, r *http.Request) {
+	bookId, err := strconv.Atoi(r.PathValue("id"))
+	if err != nil {
+		model.InvalidRequest(w, "Invalid Book ID", r.URL.Path)
+		return
+	}
+	currency := r.PathValue("currency")
+	if !domain.IsCurrency(currency) {
+		model.InvalidRequest(w, "Invalid Currency", r.URL.Path)
+		return
+	}
+
+	if err := s.priceService.DeleteListPrice(r.Context(), bookId, currency); err != nil {
+		if errors.Is(err, domain.ErrNotFound) {
+			model.NotFound(w, "Price Not Found", r.URL.Path)
+		} else {
+			slog.Error("error deleting list price", "error", err)
+			model.InternalServerError(w, r.URL.Path)
+		}
+		return
+	}
+
+	w.WriteHeader(http.StatusOK)
+}
@@ -0,0 +1,200 @@
+package handler
+
+import (
+	"encoding/json"
+	"errors"
+	"log/slog"
+	"net/http"
+	"toptal/internal/app/domain"
+	"toptal/internal/app/handler/model"
+	"toptal/internal/app/util"
+	"toptal/internal/pkg/validator"
+)
+
+// @Summary Get current user
+// @Description Get the profile of the current user
+// @Tags profile
+// @Accept json
+// @Produce json
+// @Success 200 {object} model.ProfileResponse
+// @Failure 401 {object} model.ProblemDetail "Unauthorized"
+// @Failure 404 {object} model.ProblemDetail "Not Found"
+// @Failure 500 {object} model.ProblemDetail "Internal Server Error"
+// @Security ApiKeyAuth
+// @Router /me [get]
+func (s *Server) handleGetProfile(w http.ResponseWriter, r *http.Request) {
+	userId, err := util.GetUserID(r.Context())
+	if err != nil {
+		model.Unauthorized(w, "unauthorized", r.URL.Path)
+		return
+	}
+
+	user, err := s.accountService.GetProfile(r.Context(), userId)
+	if err != nil {
+		writeProfileError(w, r, err)
+		return
+	}
+
+	writeResponseOK(w, toProfileResponse(user))
+}
+
+// @Summary Update current user
+// @Description Change the username or email of the current user. A new email has to be verified again.
+// @Tags profile
+// @Accept json
+// @Produce json
+// @Param request body model.ProfileUpdateRequest true "Fields to change"
+// @Success 200 {object} model.ProfileResponse
+// @Failure 400 {object} model.ProblemDetail "Bad Request"
+// @Failure 401 {object} model.ProblemDetail "Unauthorized"
+// @Failure 409 {object} model.ProblemDetail "Username or email already exists"
+// @Failure 500 {object} model.ProblemDetail "Internal Server Error"
+// @Security ApiKeyAuth
+// @Router /me [patch]
+func (s *Server) handleUpdateProfile(w http.ResponseWriter, r *http.Request) {
+	userId, err := util.GetUserID(r.Context())
+	if err != nil {
+		model.Unauthorized(w, "unauthorized", r.URL.Path)
+		return
+	}
+
+	var request model.ProfileUpdateRequest
+	if err := json.NewDecoder(r.Body).Decode(&request); err != nil {
+		model.InvalidRequest(w, err.Error(), r.URL.Path)
+		return
+	}
+
+	if err := validator.Validate(request); err != nil {
+		model.ValidationError(w, err.Error(), r.URL.Path)
+		return
+	}
+
+	user, err := s.accountService.UpdateProfile(r.Context(), userId, request.Username, request.Email)
+	if err != nil {
+		writeProfileError(w, r, err)
+		return
+	}
+
+	writeResponseOK(w, toProfileResponse(user))
+}
+
+// @Summary Change password
+// @Description Change the password of the current user. Users without a password, such as those created through OIDC, set their first one without current_password after logging in within REAUTHENTICATION_MAX_AGE
+// @Tags profile
+// @Accept json
+// @Produce json
+// @Param request body model.ChangePasswordRequest true "Current and new password"
+// @Success 200 {object} model.MessageResponse
+// @Failure 400 {object} model.ProblemDetail "Bad Request"
+// @Failure 401 {object} model.ProblemDetail "Unauthorized"
+// @Failure 403 {object} model.ProblemDetail "Wrong password or login too old"
+// @Failure 500 {object} model.ProblemDetail "Internal Server Error"
+// @Security ApiKeyAuth
+// @Router /me/password [post]
+func (s *Server) handleChangePassword(w http.ResponseWriter, r *http.Request) {
+	userId, err := util.GetUserID(r.Context())
+	if err != nil {
+		model.Unauthorized(w, "unauthorized", r.URL.Path)
+		return
+	}
+
+	var request model.ChangePasswordRequest
+	if err := json.NewDecoder(r.Body).Decode(&request); err != nil {
+		model.InvalidRequest(w, err.Error(), r.URL.Path)
+		return
+	}
+
+	if err := validator.Validate(request); err != nil {
+		model.ValidationError(w, err.Error(), r.URL.Path)
+		return
+	}
+
+	if err := s.accountService.ChangePassword(r.Context(), userId, request.CurrentPassword, request.NewPassword); err != nil {
+		writeProfileError(w, r, err)
+		return
+	}
+
+	writeResponseOK(w, model.MessageResponse{Message: "Password changed"})
+}
+
+// @Summary Delete current user
+// @Description Delete the account of the current user. Personal data is removed; orders are kept without it. Users without a password confirm by having logged in within REAUTHENTICATION_MAX_AGE
+// @Tags profile
+// @Accept json
+// @Produce json
+// @Param request body model.DeleteAccountRequest true "Password confirmation"
+// @Success 200 {string} string "OK"
+// @Failure 400 {object} model.ProblemDetail "Bad Request"
+// @Failure 401 {object} model.ProblemDetail "Unauthorized"
+// @Failure 403 {object} model.ProblemDetail "Wrong password or login too old"
+// @Failure 500 {object} model.ProblemDetail "Internal Server Error"
+// @Security ApiKeyAuth
+// @Router /me [delete]
+func (s *Server) handleDeleteAccount(w http.ResponseWriter, r *http.Request) {
+	userId, err := util.GetUserID(r.Context())
+	if err != nil {
+		model.Unauthorized(w, "unauthorized", r.URL.Path)
+		return
+	}
+
+	var request model.DeleteAccountRequest
+	if err := json.NewDecoder(r.Body).Decode(&request); err != nil {
+		model.InvalidRequest(w, err.Error(), r.URL.Path)
+		return
+	}
+
+	if err := validator.Validate(request); err != nil {
+		model.ValidationError(w, err.Error(), r.URL.Path)
+		return
+	}
+
+	if err := s.accountService.DeleteAccount(r.Context(), userId, request.Password); err != nil {
+		writeProfileError(w, r, err)
+		return
+	}
+
+	w.WriteHeader(http.StatusOK)
+}
+
+// @Summary Export account data
+// @Description Download everything stored about the current user: profile, cart and orders
+// @Tags profile
+// @Accept json
+// @Produce json
+// @Success 200 {object} model.AccountExportResponse
+// @Failure 401 {object} model.ProblemDetail "Unauthorized"
+// @Failure 500 {object} model.ProblemDetail "Internal Server Error"
+// @Security ApiKeyAuth
+// @Router /me/export [get]
+func (s *Server) handleExportAccount(w http.ResponseWriter, r *http.Request) {
+	userId, err := util.GetUserID(r.Context())
+	if err != nil {
+		model.Unauthorized(w, "unauthorized", r.URL.Path)
+		return
+	}
+
+	export, err := s.accountService.ExportAccount(r.Context(), userId)
+	if err != nil {
+		writeProfileError(w, r, err)
+		return
+	}
+
+	w.Header().Set("Content-Disposition", `attachment; filename="account-export.json"`)
+	writeResponseOK(w, toAccountExportResponse(export))
+}
+
+func writeProfileError(w http.ResponseWriter, r *http.Request, err error) {
+	switch {
+	case errors.Is(err, domain.ErrNotFound):
+		model.NotFound(w, "user not found", r.URL.Path)
+	case errors.Is(err, domain.ErrAlreadyExists):
+		model.AlreadyExists(w, "Username or email already exists", r.URL.Path)
+	case errors.Is(err, domain.ErrWrongPassword), errors.Is(err, domain.ErrReauthenticationRequired):
+		model.Forbidden(w, err.Error(), r.URL.Path)
+	case errors.Is(err, domain.ErrWeakPassword):
+		model.ValidationError(w, err.Error(), r.URL.Path)
+	default:
+		slog.Error("error handling profile request", "path", r.URL.Path, "error", err)
+		model.InternalServerError(w, r.URL.Path)
+	}
+}
@@ -0,0 +1,336 @@
+package handler
+
+import (
+	"encoding/json"
+	"errors"
+	"io"
+	"log/slog"
+	"net/http"
+	"strconv"
+	"toptal/internal/app/domain"
+	"toptal/internal/app/handler/model"
+	"toptal/internal/pkg/validator"
+)
+
+// @Summary List purchase orders
+// @Description List purchase orders newest first, optionally of one supplier or in one status
+// @Tags purchase orders
+// @Produce json
+// @Param supplier_id query int false "Supplier ID"
+// @Param status query string false "Status" Enums(draft, sent, partially_received, received)
+// @Param limit query int false "Maximum number of orders (default 50, at most 500)"
+// @Param offset query int false "Number of orders to skip"
+// @Success 200 {array} model.PurchaseOrderResponse
+// @Failure 400 {object} model.ProblemDetail "Bad Request"
+// @Failure 401 {object} model.ProblemDetail "Unauthorized"
+// @Failure 500 {object} model.ProblemDetail "Internal Server Error"
+// @Security ApiKeyAuth
+// @Router /purchase-orders [get]
+func (s *Server) handleGetPurchaseOrders(w http.ResponseWriter, r *http.Request) {
+	query := r.URL.Query()
+	var filter domain.PurchaseOrderFilter
+	if supplierId := query.Get("supplier_id"); supplierId != "" {
+		var err error
+		if filter.SupplierId, err = strconv.Atoi(supplierId); err != nil {
+			model.InvalidRequest(w, "Invalid Supplier ID", r.URL.Path)
+			return
+		}
+	}
+	filter.Status = query.Get("status")
+	filter.Limit, _ = strconv.Atoi(query.Get("limit"))
+	filter.Offset, _ = strconv.Atoi(query.Get("offset"))
+
+	orders, err := s.purchaseOrderService.GetPurchaseOrders(r.Context(), filter)
+	if err != nil {
+		if errors.Is(err, domain.ErrInvalidPurchaseOrder) {
+			model.InvalidRequest(w, "Invalid Status", r.URL.Path)
+		} else {
+			slog.Error("error getting purchase orders", "error", err)
+			model.InternalServerError(w, r.URL.Path)
+		}
+		return
+	}
+
+	writeResponseOK(w, toPurchaseOrdersResponse(orders))
+}
+
+// @Summary Get a purchase order
+// @Tags purchase orders
+// @Produce json
+// @Param id path int true "Purchase order ID"
+// @Success 200 {object} model.PurchaseOrderResponse
+// @Failure 400 {object} model.ProblemDetail "Bad Request"
+// @Failure 401 {object} model.ProblemDetail "Unauthorized"
+// @Failure 404 {object} model.ProblemDetail "Not Found"
+// @Failure 500 {object} model.ProblemDetail "Internal Server Error"
+// @Security ApiKeyAuth
+// @Router /purchase-orders/{id} [get]
+func (s *Server) handleGetPurchaseOrder(w http.ResponseWriter, r *http.Request) {
+	id, err := strconv.Atoi(r.PathValue("id"))
+	if err != nil {
+		model.InvalidRequest(w, "Invalid Purchase Order ID", r.URL.Path)
+		return
+	}
+
+	order, err := s.purchaseOrderService.GetPurchaseOrder(r.Context(), id)
+	if err != nil {
+		if errors.Is(err, domain.ErrNotFound) {
+			model.NotFound(w, "Purchase Order Not Found", r.URL.Path)
+		} else {
+			slog.Error("error getting purchase order", "error", err)
+			model.InternalServerError(w, r.URL.Path)
+		}
+		return
+	}
+
+	writeResponseOK(w, toPurchaseOrderResponse(order))
+}
+
+// @Summary Draft a purchase order
+// @Description Draft an order of books from a supplier. Every book must be supplied by it and is ordered at the cost price it charges.
+// @Tags purchase orders
+// @Accept json
+// @Produce json
+// @Param request body model.PurchaseOrderRequest true "Purchase order"
+// @Success 201 {object} model.PurchaseOrderResponse
+// @Failure 400 {object} model.ProblemDetail "Bad Request"
+// @Failure 401 {object} model.ProblemDetail "Unauthorized"
+// @Failure 404 {object} model.ProblemDetail "Not Found"
+// @Failure 500 {object} model.ProblemDetail "Internal Server Error"
+// @Security ApiKeyAuth
+// @Router /purchase-orders [post]
+func (s *Server) handleCreatePurchaseOrder(w http.ResponseWriter, r *http.Request) {
+	var request model.PurchaseOrderRequest
+	if err := json.NewDecoder(r.Body).Decode(&request); err != nil {
+		model.InvalidRequest(w, err.Error(), r.URL.Path)
+		return
+	}
+	if err := validator.Validate(request); err != nil {
+		model.ValidationError(w, err.Error(), r.URL.Path)
+		return
+	}
+
+	order, err := s.purchaseOrderService.CreatePurchaseOrder(r.Context(), request.SupplierId, toPurchaseQuantities(request.Items))
+	if err != nil {
+		switch {
+		case errors.Is(err, domain.ErrInvalidPurchaseOrder):
+			model.ValidationError(w, err.Error(), r.URL.Path)
+		case errors.Is(err, domain.ErrNotFound):
+			model.NotFound(w, "Supplier or Book Not Found", r.URL.Path)
+		default:
+			slog.Error("error creating purchase order", "error", err)
+			model.InternalServerError(w, r.URL.Path)
+		}
+		return
+	}
+
+	writeResponseCreated(w, toPurchaseOrderResponse(order))
+}
+
+// @Summary Change a draft purchase order
+// @Description Replace the books a draft orders, at the current cost prices of its supplier. Orders that were sent cannot be changed.
+// @Tags purchase orders
+// @Accept json
+// @Produce json
+// @Param id path int true "Purchase order ID"
+// @Param request body model.PurchaseOrderUpdateRequest true "Books to order"
+// @Success 200 {object} model.PurchaseOrderResponse
+// @Failure 400 {object} model.ProblemDetail "Bad Request"
+// @Failure 401 {object} model.ProblemDetail "Unauthorized"
+// @Failure 404 {object} model.ProblemDetail "Not Found"
+// @Failure 409 {object} model.ProblemDetail "Conflict"
+// @Failure 500 {object} model.ProblemDetail "Internal Server Error"
+// @Security ApiKeyAuth
+// @Router /purchase-orders/{id} [put]
+func (s *Server) handleUpdatePurchaseOrder(w http.ResponseWriter, r *http.Request) {
+	id, err := strconv.Atoi(r.PathValue("id"))
+	if err != nil {
+		model.InvalidRequest(w, "Invalid Purchase Order ID", r.URL.Path)
+		return
+	}
+
+	var request model.PurchaseOrderUpdateRequest
+	if err := json.NewDecoder(r.Body).Decode(&request); err != nil {
+		model.InvalidRequest(w, err.Error(), r.URL.Path)
+		return
+	}
+	if err := validator.Validate(request); err != nil {
+		model.ValidationError(w, err.Error(), r.URL.Path)
+		return
+	}
+
+	order, err := s.purchaseOrderService.UpdatePurchaseOrder(r.Context(), id, toPurchaseQuantities(request.Items))
+	if err != nil {
+		switch {
+		case errors.Is(err, domain.ErrInvalidPurchaseOrder):
+			model.ValidationError(w, err.Error(), r.URL.Path)
+		case errors.Is(err, domain.ErrNotFound):
+			model.NotFound(w, "Purchase Order or Book Not Found", r.URL.Path)
+		case errors.Is(err, domain.ErrPurchaseOrderStatus):
+			model.WriteProblemDetail(w, http.StatusConflict, "Purchase Order Not a Draft", err.Error(), r.URL.Path)
+		default:
+			slog.Error("error updating purchase order", "error", err)
+			model.InternalServerError(w, r.URL.Path)
+		}
+		return
+	}
+
+	writeResponseOK(w, toPurchaseOrderResponse(order))
+}
+
+// @Summary Delete a draft purchase order
+// @Description Delete a purchase order that was not sent yet
+// @Tags purchase orders
+// @Produce json
+// @Param id path int true "Purchase order ID"
+// @Success 200
+// @Failure 400 {object} model.ProblemDetail "Bad Request"
+// @Failure 401 {object} model.ProblemDetail "Unauthorized"
+// @Failure 404 {object} model.ProblemDetail "Not Found"
+// @Failure 409 {object} model.ProblemDetail "Conflict"
+// @Failure 500 {object} model.ProblemDetail "Internal Server Error"
+// @Security ApiKeyAuth
+// @Router /purchase-orders/{id} [delete]
+func (s *Server) handleDeletePurchaseOrder(w http.ResponseWriter, r *http.Request) {
+	id, err := strconv.Atoi(r.PathValue("id"))
+	if err != nil {
+		model.InvalidRequest(w, "Invalid Purchase Order ID", r.URL.Path)
+		return
+	}
+
+	if err := s.purchaseOrderService.DeletePurchaseOrder(r.Context(), id); err != nil {
+		switch {
+		case errors.Is(err, domain.ErrNotFound):
+			model.NotFound(w, "Purchase Order Not Found", r.URL.Path)
+		case errors.Is(err, domain.ErrPurchaseOrderStatus):
+			model.WriteProblemDetail(w, http.StatusConflict, "Purchase Order Not a Draft", err.Error(), r.URL.Path)
+		default:
+			slog.Error("error deleting purchase order", "error", err)
+			model.InternalServerError(w, r.URL.Path)
+		}
+		return
+	}
+
+	w.WriteHeader(http.StatusOK)
+}
+
+// @Summary Send a purchase order
+// @Description Mark a draft as sent to the supplier. It can no longer be changed, and its deliveries can be received.
+// @Tags purchase orders
+// @Produce json
+// @Param id path int true "Purchase order ID"
+// @Success 200 {object} model.PurchaseOrderResponse
+// @Failure 400 {object} model.ProblemDetail "Bad Request"
+// @Failure 401 {object} model.ProblemDetail "Unauthorized"
+// @Failure 404 {object} model.ProblemDetail "Not Found"
+// @Failure 409 {object} model.ProblemDetail "Conflict"
+// @Failure 500 {object} model.ProblemDetail "Internal Server Error"
+// @Security ApiKeyAuth
+// @Router /purchase-orders/{id}/send [post]
+func (s *Server) handleSendPurchaseOrder(w http.ResponseWriter, r *http.Request) {
+	id, err := strconv.Atoi(r.PathValue("id"))
+	if err != nil {
+		model.InvalidRequest(w, "Invalid Purchase Order ID", r.URL.Path)
+		return
+	}
+
+	order, err := s.purchaseOrderService.SendPurchaseOrder(r.Context(), id)
+	if err != nil {
+		switch {
+		case errors.Is(err, domain.ErrNotFound):
+			model.NotFound(w, "Purchase Order Not Found", r.URL.Path)
+		case errors.Is(err, domain.ErrPurchaseOrderStatus):
+			model.WriteProblemDetail(w, http.StatusConflict, "Purchase Order Already Sent", err.Error(), r.URL.Path)
+		default:
+			slog.Error("error sending purchase order", "error", err)
+			model.InternalServerError(w, r.URL.Path)
+		}
+		return
+	}
+
+	writeResponseOK(w, toPurchaseOrderResponse(order))
+}
+
+// @Summary Receive a purchase order
+// @Description Book a delivery of a sent purchase order. The copies received are added to the stock of their books and recorded as stock movements. Without items everything outstanding is received.
+// @Tags purchase orders
+// @Accept json
+// @Produce json
+// @Param id path int true "Purchase order ID"
+// @Param request body model.ReceivePurchaseOrderRequest false "Copies received"
+// @Success 200 {object} model.PurchaseOrderResponse
+// @Failure 400 {object} model.ProblemDetail "Bad Request"
+// @Failure 401 {object} model.ProblemDetail "Unauthorized"
+// @Failure 404 {object} model.ProblemDetail "Not Found"
+// @Failure 409 {object} model.ProblemDetail "Conflict"
+// @Failure 500 {object} model.ProblemDetail "Internal Server Error"
+// @Security ApiKeyAuth
+// @Router /purchase-orders/{id}/receive [post]
+func (s *Server) handleReceivePurchaseOrder(w http.ResponseWriter, r *http.Request) {
+	id, err := strconv.Atoi(r.PathValue("id"))
+	if err != nil {
+		model.InvalidRequest(w, "Invalid Purchase Order ID", r.URL.Path)
+		return
+	}
+
+	// an empty body receives everything outstanding
+	var request model.ReceivePurchaseOrderRequest
+	if err := json.NewDecoder(r.Body).Decode(&request); err != nil && !errors.Is(err, io.EOF) {
+		model.InvalidRequest(w, err.Error(), r.URL.Path)
+		return
+	}
+	if err := validator.Validate(request); err != nil {
+		model.ValidationError(w, err.Error(), r.URL.Path)
+		return
+	}
+
+	order, err := s.purchaseOrderService.ReceivePurchaseOrder(r.Context(), id, toPurchaseQuantities(request.Items))
+	if err != nil {
+		switch {
+		case errors.Is(err, domain.ErrInvalidPurchaseOrder):
+			model.ValidationError(w, err.Error(), r.URL.Path)
+		case errors.Is(err, domain.ErrNotFound):
+			model.NotFound(w, "Purchase Order Not Found", r.URL.Path)
+		case errors.Is(err, domain.ErrPurchaseOrderStatus):
+			model.WriteProblemDetail(w, http.StatusConflict, "Purchase Order Not Receivable", err.Error(), r.URL.Path)
+		default:
+			slog.Error("error receiving purchase order", "error", err)
+			model.InternalServerError(w, r.URL.Path)
+		}
+		return
+	}
+
+	writeResponseOK(w, toPurchaseOrderResponse(order))
+}
+
+// @Summary List stock movements
+// @Description List the changes made to the stock of a book other than sales, such as purchase order receipts, newest first
+// @Tags purchase orders
+// @Produce json
+// @Param id path int true "Book ID"
+// @Param limit query int false "Maximum number of movements (default 50, at most 500)"
+// @Param offset query int false "Number of movements to skip"
+// @Success 200 {array} model.StockMovementResponse
+// @Failure 400 {object} model.ProblemDetail "Bad Request"
+// @Failure 401 {object} model.ProblemDetail "Unauthorized"
+// @Failure 500 {object} model.ProblemDetail "Internal Server Error"
+// @Security ApiKeyAuth
+// @Router /book/{id}/stock-movements [get]
+func (s *Server) handleGetStockMovements(w http.ResponseWriter, r *http.Request) {
+	bookId, err := strconv.Atoi(r.PathValue("id"))
+	if err != nil {
+		model.InvalidRequest(w, "Invalid Book ID", r.URL.Path)
+		return
+	}
+	limit, _ := strconv.Atoi(r.URL.Query().Get("limit"))
+	offset, _ := strconv.Atoi(r.URL.Query().Get("offset"))
+
+	movements, err := s.purchaseOrderService.GetStockMovements(r.Context(), bookId, limit, offset)
+	if err != nil {
+		slog.Error("error getting stock movements", "error", err)
+		model.InternalServerError(w, r.URL.Path)
+		return
+	}
+
+	writeResponseOK(w, toStockMovementsResponse(movements))
+}
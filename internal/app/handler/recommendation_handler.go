@@ -0,0 +1,86 @@
+package handler
+
+import (
+	"errors"
+	"log/slog"
+	"net/http"
+	"strconv"
+	"toptal/internal/app/domain"
+	"toptal/internal/app/handler/model"
+	"toptal/internal/app/util"
+)
+
+// @Summary Get recommendations for a book
+// @Description List books customers of a book also bought, most bought first. Where too few customers bought them together, the bestsellers of the book's category fill in the list.
+// @Tags recommendations
+// @Produce json
+// @Param id path int true "Book ID"
+// @Param limit query int false "Number of books to recommend, at most 50" default(10)
+// @Param currency query string false "ISO 4217 currency to show prices in, for books that have a price in it"
+// @Success 200 {array} model.RecommendationResponse
+// @Failure 400 {object} model.ProblemDetail "Bad Request"
+// @Failure 404 {object} model.ProblemDetail "Not Found"
+// @Failure 500 {object} model.ProblemDetail "Internal Server Error"
+// @Router /book/{id}/recommendations [get]
+func (s *Server) handleGetBookRecommendations(w http.ResponseWriter, r *http.Request) {
+	bookId, err := strconv.Atoi(r.PathValue("id"))
+	if err != nil {
+		model.InvalidRequest(w, "Invalid Book ID", r.URL.Path)
+		return
+	}
+	currency, ok := currencyParam(w, r)
+	if !ok {
+		return
+	}
+
+	recommendations, err := s.recommendationService.GetBookRecommendations(
+		r.Context(), bookId, listLimitParam(r), currency,
+	)
+	if err != nil {
+		switch {
+		case errors.Is(err, domain.ErrNotFound):
+			model.NotFound(w, "Book Not Found", r.URL.Path)
+		default:
+			slog.Error("error getting book recommendations", "error", err)
+			model.InternalServerError(w, r.URL.Path)
+		}
+		return
+	}
+
+	writeResponseOK(w, toRecommendationsResponse(recommendations))
+}
+
+// @Summary Get recommendations for the current user
+// @Description List books bought by customers who bought the same books as the current user, leaving out those the user has. The bestsellers of the categories the user buys from fill in the list, or those of the whole shop for users who have bought nothing yet.
+// @Tags recommendations
+// @Produce json
+// @Param limit query int false "Number of books to recommend, at most 50" default(10)
+// @Param currency query string false "ISO 4217 currency to show prices in, for books that have a price in it"
+// @Success 200 {array} model.RecommendationResponse
+// @Failure 400 {object} model.ProblemDetail "Bad Request"
+// @Failure 401 {object} model.ProblemDetail "Unauthorized"
+// @Failure 500 {object} model.ProblemDetail "Internal Server Error"
+// @Security ApiKeyAuth
+// @Router /me/recommendations [get]
+func (s *Server) handleGetUserRecommendations(w http.ResponseWriter, r *http.Request) {
+	userId, err := util.GetUserID(r.Context())
+	if err != nil {
+		model.Unauthorized(w, "unauthorized", r.URL.Path)
+		return
+	}
+	currency, ok := currencyParam(w, r)
+	if !ok {
+		return
+	}
+
+	recommendations, err := s.recommendationService.GetUserRecommendations(
+		r.Context(), userId, listLimitParam(r), currency,
+	)
+	if err != nil {
+		slog.Error("error getting user recommendations", "error", err)
+		model.InternalServerError(w, r.URL.Path)
+		return
+	}
+
+	writeResponseOK(w, toRecommendationsResponse(recommendations))
+}
@@ -0,0 +1,265 @@
+package handler
+
+import (
+	"encoding/csv"
+	"errors"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"strconv"
+	"time"
+	"toptal/internal/app/domain"
+	"toptal/internal/app/handler/model"
+)
+
+const (
+	reportFormatJSON = "json"
+	reportFormatCSV  = "csv"
+	// reportDefaultDays is how far back reports go when no range is given.
+	reportDefaultDays = 30
+)
+
+// reportParams reads the range, granularity and format of a report. From and to are
+// dates or RFC 3339 times; without them a report covers the last 30 days up to today.
+// It writes a problem detail and returns false when a parameter is invalid.
+func reportParams(w http.ResponseWriter, r *http.Request) (domain.ReportFilter, string, bool) {
+	query := r.URL.Query()
+	now := time.Now().UTC()
+	filter := domain.ReportFilter{
+		To:          time.Date(now.Year(), now.Month(), now.Day()+1, 0, 0, 0, 0, time.UTC),
+		Granularity: domain.ReportGranularityDay,
+	}
+	filter.From = filter.To.AddDate(0, 0, -reportDefaultDays)
+	if granularity := query.Get("granularity"); granularity != "" {
+		filter.Granularity = granularity
+	}
+
+	times := map[string]*time.Time{"from": &filter.From, "to": &filter.To}
+	for name, target := range times {
+		if value := query.Get(name); value != "" {
+			parsed, err := parseReportTime(value)
+			if err != nil {
+				model.InvalidRequest(w, "invalid "+name+": expected a date or an RFC 3339 time", r.URL.Path)
+				return domain.ReportFilter{}, "", false
+			}
+			*target = parsed
+		}
+	}
+
+	format := query.Get("format")
+	switch format {
+	case "":
+		format = reportFormatJSON
+	case reportFormatJSON, reportFormatCSV:
+	default:
+		model.InvalidRequest(w, "Unknown report format, use json or csv", r.URL.Path)
+		return domain.ReportFilter{}, "", false
+	}
+	return filter, format, true
+}
+
+func parseReportTime(value string) (time.Time, error) {
+	if date, err := time.Parse(time.DateOnly, value); err == nil {
+		return date, nil
+	}
+	return time.Parse(time.RFC3339, value)
+}
+
+func writeReportError(w http.ResponseWriter, r *http.Request, err error) {
+	if errors.Is(err, domain.ErrInvalidReport) {
+		model.InvalidRequest(w, err.Error(), r.URL.Path)
+		return
+	}
+	slog.Error("error running report", "path", r.URL.Path, "error", err)
+	model.InternalServerError(w, r.URL.Path)
+}
+
+// writeReportCSV sends a report as a CSV attachment named after the report and its range.
+func writeReportCSV(w http.ResponseWriter, name string, filter domain.ReportFilter, header []string, rows [][]string) {
+	w.Header().Set("Content-Type", exportContentTypes[domain.ExportFormatCSV])
+	w.Header().Set("Content-Disposition", fmt.Sprintf(`attachment; filename="%s-%s-%s.csv"`,
+		name, filter.From.UTC().Format(time.DateOnly), filter.To.UTC().Format(time.DateOnly)))
+	writer := csv.NewWriter(w)
+	if err := writer.Write(header); err != nil {
+		slog.Error("failed to write report", "error", err)
+		return
+	}
+	if err := writer.WriteAll(rows); err != nil {
+		slog.Error("failed to write report", "error", err)
+	}
+}
+
+func reportPeriod(period time.Time) string {
+	return period.UTC().Format(time.DateOnly)
+}
+
+// @Summary Revenue by category
+// @Description Report the orders, copies sold and revenue of each category per day, week or month. Revenue is net of tax, with the tax next to it, and split by currency. Books sold count in the category they were in when sold.
+// @Tags reports
+// @Produce json
+// @Produce text/csv
+// @Param from query string false "Start of the range, a date or RFC 3339 time, inclusive (default 30 days ago)"
+// @Param to query string false "End of the range, a date or RFC 3339 time, exclusive (default tomorrow)"
+// @Param granularity query string false "Period to group by" Enums(day, week, month) default(day)
+// @Param format query string false "json or csv" default(json)
+// @Success 200 {array} model.CategoryRevenueResponse
+// @Failure 400 {object} model.ProblemDetail "Bad Request"
+// @Failure 401 {object} model.ProblemDetail "Unauthorized"
+// @Failure 403 {object} model.ProblemDetail "Forbidden"
+// @Failure 500 {object} model.ProblemDetail "Internal Server Error"
+// @Security ApiKeyAuth
+// @Router /reports/revenue-by-category [get]
+func (s *Server) handleGetCategoryRevenueReport(w http.ResponseWriter, r *http.Request) {
+	filter, format, ok := reportParams(w, r)
+	if !ok {
+		return
+	}
+	report, err := s.reportService.GetCategoryRevenue(r.Context(), filter)
+	if err != nil {
+		writeReportError(w, r, err)
+		return
+	}
+
+	if format == reportFormatCSV {
+		rows := make([][]string, len(report))
+		for i, row := range report {
+			rows[i] = []string{
+				reportPeriod(row.Period()), strconv.Itoa(row.CategoryId()), row.CategoryName(),
+				strconv.Itoa(row.Orders()), strconv.Itoa(row.Units()),
+				row.Net().Decimal(), row.Tax().Decimal(), row.Net().Currency(),
+			}
+		}
+		writeReportCSV(w, "revenue-by-category", filter,
+			[]string{"period", "category_id", "category", "orders", "units", "net", "tax", "currency"}, rows)
+		return
+	}
+	writeResponseOK(w, toCategoryRevenueResponse(report))
+}
+
+// @Summary Units sold per book
+// @Description Report the copies sold of each book per day, week or month, in all its formats, best selling first, with the revenue net of tax split by currency.
+// @Tags reports
+// @Produce json
+// @Produce text/csv
+// @Param from query string false "Start of the range, a date or RFC 3339 time, inclusive (default 30 days ago)"
+// @Param to query string false "End of the range, a date or RFC 3339 time, exclusive (default tomorrow)"
+// @Param granularity query string false "Period to group by" Enums(day, week, month) default(day)
+// @Param format query string false "json or csv" default(json)
+// @Success 200 {array} model.BookSalesResponse
+// @Failure 400 {object} model.ProblemDetail "Bad Request"
+// @Failure 401 {object} model.ProblemDetail "Unauthorized"
+// @Failure 403 {object} model.ProblemDetail "Forbidden"
+// @Failure 500 {object} model.ProblemDetail "Internal Server Error"
+// @Security ApiKeyAuth
+// @Router /reports/book-sales [get]
+func (s *Server) handleGetBookSalesReport(w http.ResponseWriter, r *http.Request) {
+	filter, format, ok := reportParams(w, r)
+	if !ok {
+		return
+	}
+	report, err := s.reportService.GetBookSales(r.Context(), filter)
+	if err != nil {
+		writeReportError(w, r, err)
+		return
+	}
+
+	if format == reportFormatCSV {
+		rows := make([][]string, len(report))
+		for i, row := range report {
+			rows[i] = []string{
+				reportPeriod(row.Period()), strconv.Itoa(row.BookId()), row.Title(), row.Author(),
+				strconv.Itoa(row.Units()), row.Net().Decimal(), row.Net().Currency(),
+			}
+		}
+		writeReportCSV(w, "book-sales", filter,
+			[]string{"period", "book_id", "title", "author", "units", "net", "currency"}, rows)
+		return
+	}
+	writeResponseOK(w, toBookSalesResponse(report))
+}
+
+// @Summary Average basket size
+// @Description Report the number of orders per day, week or month with the copies and the total, tax included, of the average order, split by currency.
+// @Tags reports
+// @Produce json
+// @Produce text/csv
+// @Param from query string false "Start of the range, a date or RFC 3339 time, inclusive (default 30 days ago)"
+// @Param to query string false "End of the range, a date or RFC 3339 time, exclusive (default tomorrow)"
+// @Param granularity query string false "Period to group by" Enums(day, week, month) default(day)
+// @Param format query string false "json or csv" default(json)
+// @Success 200 {array} model.BasketSizeResponse
+// @Failure 400 {object} model.ProblemDetail "Bad Request"
+// @Failure 401 {object} model.ProblemDetail "Unauthorized"
+// @Failure 403 {object} model.ProblemDetail "Forbidden"
+// @Failure 500 {object} model.ProblemDetail "Internal Server Error"
+// @Security ApiKeyAuth
+// @Router /reports/basket-size [get]
+func (s *Server) handleGetBasketSizeReport(w http.ResponseWriter, r *http.Request) {
+	filter, format, ok := reportParams(w, r)
+	if !ok {
+		return
+	}
+	report, err := s.reportService.GetBasketSize(r.Context(), filter)
+	if err != nil {
+		writeReportError(w, r, err)
+		return
+	}
+
+	if format == reportFormatCSV {
+		rows := make([][]string, len(report))
+		for i, row := range report {
+			rows[i] = []string{
+				reportPeriod(row.Period()), strconv.Itoa(row.Orders()), strconv.Itoa(row.Units()),
+				row.Total().Decimal(), strconv.FormatFloat(row.AverageUnits(), 'f', 2, 64),
+				row.AverageTotal().Decimal(), row.Total().Currency(),
+			}
+		}
+		writeReportCSV(w, "basket-size", filter,
+			[]string{"period", "orders", "units", "total", "average_units", "average_total", "currency"}, rows)
+		return
+	}
+	writeResponseOK(w, toBasketSizeResponse(report))
+}
+
+// @Summary Stock turnover
+// @Description Report per day, week or month how many copies of the books of each category sold against the stock they had: the copies sold over the average of the opening and closing stock. Only the books themselves count, not their other formats. Earlier stock levels are estimated from the current stock and the sales since, without restocks.
+// @Tags reports
+// @Produce json
+// @Produce text/csv
+// @Param from query string false "Start of the range, a date or RFC 3339 time, inclusive (default 30 days ago)"
+// @Param to query string false "End of the range, a date or RFC 3339 time, exclusive (default tomorrow)"
+// @Param granularity query string false "Period to group by" Enums(day, week, month) default(day)
+// @Param format query string false "json or csv" default(json)
+// @Success 200 {array} model.StockTurnoverResponse
+// @Failure 400 {object} model.ProblemDetail "Bad Request"
+// @Failure 401 {object} model.ProblemDetail "Unauthorized"
+// @Failure 403 {object} model.ProblemDetail "Forbidden"
+// @Failure 500 {object} model.ProblemDetail "Internal Server Error"
+// @Security ApiKeyAuth
+// @Router /reports/stock-turnover [get]
+func (s *Server) handleGetStockTurnoverReport(w http.ResponseWriter, r *http.Request) {
+	filter, format, ok := reportParams(w, r)
+	if !ok {
+		return
+	}
+	report, err := s.reportService.GetStockTurnover(r.Context(), filter)
+	if err != nil {
+		writeReportError(w, r, err)
+		return
+	}
+
+	if format == reportFormatCSV {
+		rows := make([][]string, len(report))
+		for i, row := range report {
+			rows[i] = []string{
+				reportPeriod(row.Period()), strconv.Itoa(row.CategoryId()), row.CategoryName(),
+				strconv.Itoa(row.Units()), strconv.Itoa(row.OpeningStock()), strconv.Itoa(row.ClosingStock()),
+				strconv.FormatFloat(row.Turnover(), 'f', 4, 64),
+			}
+		}
+		writeReportCSV(w, "stock-turnover", filter,
+			[]string{"period", "category_id", "category", "units", "opening_stock", "closing_stock", "turnover"}, rows)
+		return
+	}
+	writeResponseOK(w, toStockTurnoverResponse(report))
+}
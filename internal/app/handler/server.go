@@ -2,18 +2,36 @@ package handler
 
 import (
 	"net/http"
+	"toptal/internal/app/domain"
 	"toptal/internal/app/handler/middleware"
 
 	httpSwagger "github.com/swaggo/http-swagger"
 )
 
 type Server struct {
-	router          *http.ServeMux
-	bookService     BookService
-	categoryService CategoryService
-	authService     AuthService
-	cartService     CartService
-	healthService   HealthService
+	router                *http.ServeMux
+	bookService           BookService
+	categoryService       CategoryService
+	authService           AuthService
+	cartService           CartService
+	healthService         HealthService
+	apiKeyService         APIKeyService
+	accountService        AccountService
+	oidcService           OIDCService
+	sessionService        SessionService
+	auditService          AuditService
+	priceService          PriceService
+	importService         ImportService
+	exportService         ExportService
+	formatService         FormatService
+	downloadService       DownloadService
+	coverService          CoverService
+	recommendationService RecommendationService
+	chartService          ChartService
+	reportService         ReportService
+	stockAlertService     StockAlertService
+	supplierService       SupplierService
+	purchaseOrderService  PurchaseOrderService
 }
 
 func NewServer(
@@ -22,14 +40,48 @@ func NewServer(
 	authService AuthService,
 	cartService CartService,
 	healthService HealthService,
+	apiKeyService APIKeyService,
+	accountService AccountService,
+	oidcService OIDCService,
+	sessionService SessionService,
+	auditService AuditService,
+	priceService PriceService,
+	importService ImportService,
+	exportService ExportService,
+	formatService FormatService,
+	downloadService DownloadService,
+	coverService CoverService,
+	recommendationService RecommendationService,
+	chartService ChartService,
+	reportService ReportService,
+	stockAlertService StockAlertService,
+	supplierService SupplierService,
+	purchaseOrderService PurchaseOrderService,
 ) *Server {
 	server := &Server{
-		router:          http.NewServeMux(),
-		bookService:     bookService,
-		categoryService: categoryService,
-		authService:     authService,
-		cartService:     cartService,
-		healthService:   healthService,
+		router:                http.NewServeMux(),
+		bookService:           bookService,
+		categoryService:       categoryService,
+		authService:           authService,
+		cartService:           cartService,
+		healthService:         healthService,
+		apiKeyService:         apiKeyService,
+		accountService:        accountService,
+		oidcService:           oidcService,
+		sessionService:        sessionService,
+		auditService:          auditService,
+		priceService:          priceService,
+		importService:         importService,
+		exportService:         exportService,
+		formatService:         formatService,
+		downloadService:       downloadService,
+		coverService:          coverService,
+		recommendationService: recommendationService,
+		chartService:          chartService,
+		reportService:         reportService,
+		stockAlertService:     stockAlertService,
+		supplierService:       supplierService,
+		purchaseOrderService:  purchaseOrderService,
 	}
 
 	server.setupRoutes()
@@ -54,30 +106,151 @@ func (s *Server) setupRoutes() {
 	s.router.HandleFunc("GET /swagger/doc.json", s.handleSwaggerJSON)
 
 	role := middleware.NewRoleMiddleware(s.authService)
+	apiKey := middleware.NewAPIKeyMiddleware(s.apiKeyService)
+	jwt := middleware.NewJWTMiddleware(s.sessionService)
+
+	// admin accepts either an admin JWT or an API key holding the given scope
+	admin := func(scope string, next func(w http.ResponseWriter, r *http.Request)) func(w http.ResponseWriter, r *http.Request) {
+		return apiKey.APIKeyMiddleware(scope, next, jwt.JWTMiddleware(role.RoleMiddleware(next)))
+	}
 
 	// Book routes
 	s.router.HandleFunc("GET /book/{id}", s.handleGetBookById)
 	s.router.HandleFunc("GET /book", s.handleGetBooks)
-	s.router.HandleFunc("POST /book", middleware.JWTMiddleware(role.RoleMiddleware(s.handleCreateBook)))
-	s.router.HandleFunc("PUT /book", middleware.JWTMiddleware(role.RoleMiddleware(s.handleUpdateBook)))
-	s.router.HandleFunc("DELETE /book/{id}", middleware.JWTMiddleware(role.RoleMiddleware(s.handleDeleteBook)))
+	s.router.HandleFunc("POST /book", admin(domain.ScopeCatalogWrite, s.handleCreateBook))
+	s.router.HandleFunc("PUT /book", admin(domain.ScopeCatalogWrite, s.handleUpdateBook))
+	s.router.HandleFunc("DELETE /book/{id}", admin(domain.ScopeCatalogWrite, s.handleDeleteBook))
+	s.router.HandleFunc("GET /book/archived", admin(domain.ScopeCatalogWrite, s.handleGetArchivedBooks))
+	s.router.HandleFunc("POST /book/{id}/restore", admin(domain.ScopeCatalogWrite, s.handleRestoreBook))
+	s.router.HandleFunc("POST /book/import", admin(domain.ScopeCatalogWrite, s.handleImportBooks))
+	s.router.HandleFunc("GET /export/books", admin(domain.ScopeCatalogExport, s.handleExportBooks))
+
+	// Price routes
+	s.router.HandleFunc("GET /book/{id}/price-history", admin(domain.ScopePricingWrite, s.handleGetPriceHistory))
+	s.router.HandleFunc("GET /book/{id}/price-changes", admin(domain.ScopePricingWrite, s.handleGetPriceChanges))
+	s.router.HandleFunc("POST /book/{id}/price-changes", admin(domain.ScopePricingWrite, s.handleSchedulePriceChange))
+	s.router.HandleFunc("DELETE /book/{id}/price-changes/{changeId}", admin(domain.ScopePricingWrite, s.handleCancelPriceChange))
+	s.router.HandleFunc("GET /book/{id}/prices", admin(domain.ScopePricingWrite, s.handleGetListPrices))
+	s.router.HandleFunc("PUT /book/{id}/prices", admin(domain.ScopePricingWrite, s.handleSetListPrice))
+	s.router.HandleFunc("DELETE /book/{id}/prices/{currency}", admin(domain.ScopePricingWrite, s.handleDeleteListPrice))
+
+	// Format routes
+	s.router.HandleFunc("GET /book/{id}/formats", s.handleGetFormats)
+	s.router.HandleFunc("PUT /book/{id}/formats/{format}", admin(domain.ScopeCatalogWrite, s.handleSaveFormat))
+	s.router.HandleFunc("DELETE /book/{id}/formats/{format}", admin(domain.ScopeCatalogWrite, s.handleDeleteFormat))
+	s.router.HandleFunc("PUT /book/{id}/formats/{format}/file", admin(domain.ScopeCatalogWrite, s.handleUploadFormatFile))
+
+	// Cover routes
+	s.router.HandleFunc("GET /book/{id}/cover/{rendition}", s.handleGetCover)
+	s.router.HandleFunc("PUT /book/{id}/cover", admin(domain.ScopeCatalogWrite, s.handleUploadCover))
+	s.router.HandleFunc("DELETE /book/{id}/cover", admin(domain.ScopeCatalogWrite, s.handleDeleteCover))
+
+	// Stock routes
+	s.router.HandleFunc("GET /stock/alerts", admin(domain.ScopeStockWrite, s.handleGetStockAlerts))
+	s.router.HandleFunc("PUT /book/{id}/reorder-threshold", admin(domain.ScopeStockWrite, s.handleSetReorderThreshold))
+	s.router.HandleFunc("GET /book/{id}/stock-movements", admin(domain.ScopeStockWrite, s.handleGetStockMovements))
+
+	// Supplier routes
+	s.router.HandleFunc("GET /suppliers", admin(domain.ScopePurchasingWrite, s.handleGetSuppliers))
+	s.router.HandleFunc("POST /suppliers", admin(domain.ScopePurchasingWrite, s.handleCreateSupplier))
+	s.router.HandleFunc("GET /suppliers/{id}", admin(domain.ScopePurchasingWrite, s.handleGetSupplier))
+	s.router.HandleFunc("PUT /suppliers/{id}", admin(domain.ScopePurchasingWrite, s.handleUpdateSupplier))
+	s.router.HandleFunc("DELETE /suppliers/{id}", admin(domain.ScopePurchasingWrite, s.handleDeleteSupplier))
+	s.router.HandleFunc("GET /book/{id}/suppliers", admin(domain.ScopePurchasingWrite, s.handleGetBookSuppliers))
+	s.router.HandleFunc("PUT /book/{id}/suppliers/{supplierId}", admin(domain.ScopePurchasingWrite, s.handleSaveBookSupplier))
+	s.router.HandleFunc("DELETE /book/{id}/suppliers/{supplierId}", admin(domain.ScopePurchasingWrite, s.handleDeleteBookSupplier))
+
+	// Purchase order routes
+	s.router.HandleFunc("GET /purchase-orders", admin(domain.ScopePurchasingWrite, s.handleGetPurchaseOrders))
+	s.router.HandleFunc("POST /purchase-orders", admin(domain.ScopePurchasingWrite, s.handleCreatePurchaseOrder))
+	s.router.HandleFunc("GET /purchase-orders/{id}", admin(domain.ScopePurchasingWrite, s.handleGetPurchaseOrder))
+	s.router.HandleFunc("PUT /purchase-orders/{id}", admin(domain.ScopePurchasingWrite, s.handleUpdatePurchaseOrder))
+	s.router.HandleFunc("DELETE /purchase-orders/{id}", admin(domain.ScopePurchasingWrite, s.handleDeletePurchaseOrder))
+	s.router.HandleFunc("POST /purchase-orders/{id}/send", admin(domain.ScopePurchasingWrite, s.handleSendPurchaseOrder))
+	s.router.HandleFunc("POST /purchase-orders/{id}/receive", admin(domain.ScopePurchasingWrite, s.handleReceivePurchaseOrder))
+
+	// Recommendation routes
+	s.router.HandleFunc("GET /book/{id}/recommendations", s.handleGetBookRecommendations)
+	s.router.HandleFunc("GET /me/recommendations", jwt.JWTMiddleware(s.handleGetUserRecommendations))
+
+	// Chart routes
+	s.router.HandleFunc("GET /book/bestsellers", s.handleGetBestsellers)
+	s.router.HandleFunc("GET /book/trending", s.handleGetTrending)
+	s.router.HandleFunc("GET /book/new-arrivals", s.handleGetNewArrivals)
+
+	// Download routes
+	s.router.HandleFunc("GET /me/downloads", jwt.JWTMiddleware(s.handleGetDownloads))
+	s.router.HandleFunc("GET /downloads/{id}", s.handleDownload)
 
 	// Category routes
 	s.router.HandleFunc("GET /category/{id}", s.handleGetCategoryById)
 	s.router.HandleFunc("GET /category", s.handleGetCategories)
-	s.router.HandleFunc("POST /category", middleware.JWTMiddleware(role.RoleMiddleware(s.handleCreateCategory)))
-	s.router.HandleFunc("PUT /category", middleware.JWTMiddleware(role.RoleMiddleware(s.handleUpdateCategory)))
-	s.router.HandleFunc("DELETE /category/{id}", middleware.JWTMiddleware(role.RoleMiddleware(s.handleDeleteCategory)))
+	s.router.HandleFunc("POST /category", admin(domain.ScopeCatalogWrite, s.handleCreateCategory))
+	s.router.HandleFunc("PUT /category", admin(domain.ScopeCatalogWrite, s.handleUpdateCategory))
+	s.router.HandleFunc("DELETE /category/{id}", admin(domain.ScopeCatalogWrite, s.handleDeleteCategory))
+	s.router.HandleFunc("GET /category/archived", admin(domain.ScopeCatalogWrite, s.handleGetArchivedCategories))
+	s.router.HandleFunc("POST /category/{id}/restore", admin(domain.ScopeCatalogWrite, s.handleRestoreCategory))
+
+	// OPDS routes
+	s.router.HandleFunc("GET /opds", s.handleOPDSRoot)
+	s.router.HandleFunc("GET /opds/books", s.handleOPDSBooks)
+	s.router.HandleFunc("GET /opds/categories/{id}", s.handleOPDSCategory)
+	s.router.HandleFunc("GET /opds/search", s.handleOPDSSearch)
+	s.router.HandleFunc("GET /opds/opensearch.xml", s.handleOPDSOpenSearch)
 
 	// Cart routes
-	s.router.HandleFunc("GET /cart", middleware.JWTMiddleware(s.handleGetCart))
-	s.router.HandleFunc("POST /cart/add", middleware.JWTMiddleware(s.handleAddToCart))
-	s.router.HandleFunc("POST /cart/remove", middleware.JWTMiddleware(s.handleRemoveFromCart))
-	s.router.HandleFunc("POST /cart/purchase", middleware.JWTMiddleware(s.handlePurchase))
+	s.router.HandleFunc("GET /cart", jwt.JWTMiddleware(s.handleGetCart))
+	s.router.HandleFunc("POST /cart/add", jwt.JWTMiddleware(s.handleAddToCart))
+	s.router.HandleFunc("POST /cart/remove", jwt.JWTMiddleware(s.handleRemoveFromCart))
+	s.router.HandleFunc("POST /cart/purchase", jwt.JWTMiddleware(s.handlePurchase))
 
 	// User routes
 	s.router.HandleFunc("POST /login", s.handleLogin)
+	s.router.HandleFunc("POST /login/2fa", s.handleTwoFactorLogin)
 	s.router.HandleFunc("POST /register", s.handleRegister)
+	s.router.HandleFunc("GET /.well-known/jwks.json", s.handleJWKS)
+
+	// Single sign-on routes
+	s.router.HandleFunc("GET /oidc/{provider}/login", s.handleOIDCLogin)
+	s.router.HandleFunc("GET /oidc/{provider}/callback", s.handleOIDCCallback)
+
+	// Profile routes
+	s.router.HandleFunc("GET /me", jwt.JWTMiddleware(s.handleGetProfile))
+	s.router.HandleFunc("PATCH /me", jwt.JWTMiddleware(s.handleUpdateProfile))
+	s.router.HandleFunc("DELETE /me", jwt.JWTMiddleware(s.handleDeleteAccount))
+	s.router.HandleFunc("POST /me/password", jwt.JWTMiddleware(s.handleChangePassword))
+	s.router.HandleFunc("GET /me/export", jwt.JWTMiddleware(s.handleExportAccount))
+
+	// Session routes
+	s.router.HandleFunc("GET /me/sessions", jwt.JWTMiddleware(s.handleGetSessions))
+	s.router.HandleFunc("DELETE /me/sessions/{id}", jwt.JWTMiddleware(s.handleRevokeSession))
+	s.router.HandleFunc("DELETE /users/{id}/sessions", jwt.JWTMiddleware(role.RoleMiddleware(s.handleRevokeUserSessions)))
+
+	// Account recovery routes
+	s.router.HandleFunc("POST /password/forgot", s.handleForgotPassword)
+	s.router.HandleFunc("POST /password/reset", s.handleResetPassword)
+	s.router.HandleFunc("POST /email/verify", s.handleVerifyEmail)
+	s.router.HandleFunc("POST /me/email/verification", jwt.JWTMiddleware(s.handleRequestEmailVerification))
+
+	// Two-factor routes
+	s.router.HandleFunc("POST /me/2fa/enroll", jwt.JWTMiddleware(s.handleEnrollTOTP))
+	s.router.HandleFunc("POST /me/2fa/confirm", jwt.JWTMiddleware(s.handleConfirmTOTP))
+	s.router.HandleFunc("POST /me/2fa/disable", jwt.JWTMiddleware(s.handleDisableTOTP))
+
+	// Audit routes
+	s.router.HandleFunc("GET /audit-log", jwt.JWTMiddleware(role.RoleMiddleware(s.handleGetAuditLog)))
+
+	// Report routes
+	s.router.HandleFunc("GET /reports/revenue-by-category", jwt.JWTMiddleware(role.RoleMiddleware(s.handleGetCategoryRevenueReport)))
+	s.router.HandleFunc("GET /reports/book-sales", jwt.JWTMiddleware(role.RoleMiddleware(s.handleGetBookSalesReport)))
+	s.router.HandleFunc("GET /reports/basket-size", jwt.JWTMiddleware(role.RoleMiddleware(s.handleGetBasketSizeReport)))
+	s.router.HandleFunc("GET /reports/stock-turnover", jwt.JWTMiddleware(role.RoleMiddleware(s.handleGetStockTurnoverReport)))
+
+	// API key routes
+	s.router.HandleFunc("GET /api-keys", jwt.JWTMiddleware(role.RoleMiddleware(s.handleGetAPIKeys)))
+	s.router.HandleFunc("POST /api-keys", jwt.JWTMiddleware(role.RoleMiddleware(s.handleCreateAPIKey)))
+	s.router.HandleFunc("POST /api-keys/{id}/rotate", jwt.JWTMiddleware(role.RoleMiddleware(s.handleRotateAPIKey)))
+	s.router.HandleFunc("DELETE /api-keys/{id}", jwt.JWTMiddleware(role.RoleMiddleware(s.handleRevokeAPIKey)))
 }
 
 func (s *Server) handleRoot(w http.ResponseWriter, _ *http.Request) {
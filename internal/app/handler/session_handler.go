@@ -0,0 +1,106 @@
+package handler
+
+import (
+	"errors"
+	"log/slog"
+	"net/http"
+	"strconv"
+	"toptal/internal/app/domain"
+	"toptal/internal/app/handler/model"
+	"toptal/internal/app/util"
+)
+
+// @Summary List sessions
+// @Description List the devices the current user is logged in on
+// @Tags profile
+// @Produce json
+// @Success 200 {array} model.SessionResponse
+// @Failure 401 {object} model.ProblemDetail "Unauthorized"
+// @Failure 500 {object} model.ProblemDetail "Internal Server Error"
+// @Security ApiKeyAuth
+// @Router /me/sessions [get]
+func (s *Server) handleGetSessions(w http.ResponseWriter, r *http.Request) {
+	userId, err := util.GetUserID(r.Context())
+	if err != nil {
+		model.Unauthorized(w, "unauthorized", r.URL.Path)
+		return
+	}
+	sessionId, _ := util.GetSessionID(r.Context())
+
+	sessions, err := s.sessionService.GetSessions(r.Context(), userId)
+	if err != nil {
+		slog.Error("error getting sessions", "error", err)
+		model.InternalServerError(w, r.URL.Path)
+		return
+	}
+
+	response := toSessionsResponse(sessions, sessionId)
+	writeResponseOK(w, response)
+}
+
+// @Summary Revoke session
+// @Description Log out one of the current user's sessions. Its token stops working immediately.
+// @Tags profile
+// @Param id path int true "Session ID"
+// @Success 200 {string} string "OK"
+// @Failure 400 {object} model.ProblemDetail "Bad Request"
+// @Failure 401 {object} model.ProblemDetail "Unauthorized"
+// @Failure 404 {object} model.ProblemDetail "Not Found"
+// @Failure 500 {object} model.ProblemDetail "Internal Server Error"
+// @Security ApiKeyAuth
+// @Router /me/sessions/{id} [delete]
+func (s *Server) handleRevokeSession(w http.ResponseWriter, r *http.Request) {
+	userId, err := util.GetUserID(r.Context())
+	if err != nil {
+		model.Unauthorized(w, "unauthorized", r.URL.Path)
+		return
+	}
+
+	id, err := strconv.Atoi(r.PathValue("id"))
+	if err != nil {
+		model.InvalidRequest(w, "Invalid Session ID", r.URL.Path)
+		return
+	}
+
+	if err := s.sessionService.RevokeSession(r.Context(), userId, id); err != nil {
+		if errors.Is(err, domain.ErrNotFound) {
+			model.NotFound(w, "Session Not Found", r.URL.Path)
+		} else {
+			slog.Error("error revoking session", "error", err)
+			model.InternalServerError(w, r.URL.Path)
+		}
+		return
+	}
+
+	w.WriteHeader(http.StatusOK)
+}
+
+// @Summary Log out user everywhere
+// @Description Revoke every session of a user, for example after a compromised account
+// @Tags users
+// @Produce json
+// @Param id path int true "User ID"
+// @Success 200 {object} model.SessionsRevokedResponse
+// @Failure 400 {object} model.ProblemDetail "Bad Request"
+// @Failure 401 {object} model.ProblemDetail "Unauthorized"
+// @Failure 403 {object} model.ProblemDetail "Forbidden"
+// @Failure 500 {object} model.ProblemDetail "Internal Server Error"
+// @Security ApiKeyAuth
+// @Router /users/{id}/sessions [delete]
+func (s *Server) handleRevokeUserSessions(w http.ResponseWriter, r *http.Request) {
+	id, err := strconv.Atoi(r.PathValue("id"))
+	if err != nil {
+		model.InvalidRequest(w, "Invalid User ID", r.URL.Path)
+		return
+	}
+
+	revoked, err := s.sessionService.RevokeUserSessions(r.Context(), id)
+	if err != nil {
+		slog.Error("error revoking user sessions", "user_id", id, "error", err)
+		model.InternalServerError(w, r.URL.Path)
+		return
+	}
+
+	response := model.SessionsRevokedResponse{Revoked: revoked}
+	writeResponseOK(w, response)
+}
@@ -0,0 +1,77 @@
+package handler
+
+import (
+	"encoding/json"
+	"errors"
+	"log/slog"
+	"net/http"
+	"strconv"
+	"toptal/internal/app/domain"
+	"toptal/internal/app/handler/model"
+	"toptal/internal/pkg/validator"
+)
+
+// @Summary List low stock books
+// @Description List the listed books at or below their reorder threshold, out of stock first, with the copies sold lately and a suggested reorder quantity based on that sales velocity
+// @Tags stock
+// @Produce json
+// @Success 200 {array} model.StockAlertResponse
+// @Failure 401 {object} model.ProblemDetail "Unauthorized"
+// @Failure 500 {object} model.ProblemDetail "Internal Server Error"
+// @Security ApiKeyAuth
+// @Router /stock/alerts [get]
+func (s *Server) handleGetStockAlerts(w http.ResponseWriter, r *http.Request) {
+	alerts, err := s.stockAlertService.GetLowStock(r.Context())
+	if err != nil {
+		slog.Error("error getting low stock books", "error", err)
+		model.InternalServerError(w, r.URL.Path)
+		return
+	}
+
+	writeResponseOK(w, toStockAlertsResponse(alerts))
+}
+
+// @Summary Set a reorder threshold
+// @Description Set the stock at or below which a book is reported as low. A null threshold makes the book use the default threshold.
+// @Tags stock
+// @Accept json
+// @Produce json
+// @Param id path int true "Book ID"
+// @Param threshold body model.ReorderThresholdRequest true "Reorder threshold"
+// @Success 200 {object} model.ReorderThresholdResponse
+// @Failure 400 {object} model.ProblemDetail "Bad Request"
+// @Failure 401 {object} model.ProblemDetail "Unauthorized"
+// @Failure 404 {object} model.ProblemDetail "Not Found"
+// @Failure 500 {object} model.ProblemDetail "Internal Server Error"
+// @Security ApiKeyAuth
+// @Router /book/{id}/reorder-threshold [put]
+func (s *Server) handleSetReorderThreshold(w http.ResponseWriter, r *http.Request) {
+	bookId, err := strconv.Atoi(r.PathValue("id"))
+	if err != nil {
+		model.InvalidRequest(w, "Invalid Book ID", r.URL.Path)
+		return
+	}
+
+	var request model.ReorderThresholdRequest
+	if err := json.NewDecoder(r.Body).Decode(&request); err != nil {
+		model.InvalidRequest(w, err.Error(), r.URL.Path)
+		return
+	}
+	if err := validator.Validate(request); err != nil {
+		model.ValidationError(w, err.Error(), r.URL.Path)
+		return
+	}
+
+	threshold, err := s.stockAlertService.SetReorderThreshold(r.Context(), bookId, request.Threshold)
+	if err != nil {
+		if errors.Is(err, domain.ErrNotFound) {
+			model.NotFound(w, "Book Not Found", r.URL.Path)
+		} else {
+			slog.Error("error setting reorder threshold", "error", err)
+			model.InternalServerError(w, r.URL.Path)
+		}
+		return
+	}
+
+	writeResponseOK(w, model.ReorderThresholdResponse{BookId: bookId, Threshold: threshold, Default: request.Threshold == nil})
+}
@@ -0,0 +1,317 @@
+package handler
+
+import (
+	"encoding/json"
+	"errors"
+	"log/slog"
+	"net/http"
+	"strconv"
+	"toptal/internal/app/domain"
+	"toptal/internal/app/handler/model"
+	"toptal/internal/pkg/validator"
+)
+
+// @Summary List suppliers
+// @Description List the suppliers the shop restocks from, by name
+// @Tags suppliers
+// @Produce json
+// @Success 200 {array} model.SupplierResponse
+// @Failure 401 {object} model.ProblemDetail "Unauthorized"
+// @Failure 500 {object} model.ProblemDetail "Internal Server Error"
+// @Security ApiKeyAuth
+// @Router /suppliers [get]
+func (s *Server) handleGetSuppliers(w http.ResponseWriter, r *http.Request) {
+	suppliers, err := s.supplierService.GetSuppliers(r.Context())
+	if err != nil {
+		slog.Error("error getting suppliers", "error", err)
+		model.InternalServerError(w, r.URL.Path)
+		return
+	}
+
+	writeResponseOK(w, toSuppliersResponse(suppliers))
+}
+
+// @Summary Get a supplier
+// @Tags suppliers
+// @Produce json
+// @Param id path int true "Supplier ID"
+// @Success 200 {object} model.SupplierResponse
+// @Failure 400 {object} model.ProblemDetail "Bad Request"
+// @Failure 401 {object} model.ProblemDetail "Unauthorized"
+// @Failure 404 {object} model.ProblemDetail "Not Found"
+// @Failure 500 {object} model.ProblemDetail "Internal Server Error"
+// @Security ApiKeyAuth
+// @Router /suppliers/{id} [get]
+func (s *Server) handleGetSupplier(w http.ResponseWriter, r *http.Request) {
+	id, err := strconv.Atoi(r.PathValue("id"))
+	if err != nil {
+		model.InvalidRequest(w, "Invalid Supplier ID", r.URL.Path)
+		return
+	}
+
+	supplier, err := s.supplierService.GetSupplier(r.Context(), id)
+	if err != nil {
+		if errors.Is(err, domain.ErrNotFound) {
+			model.NotFound(w, "Supplier Not Found", r.URL.Path)
+		} else {
+			slog.Error("error getting supplier", "error", err)
+			model.InternalServerError(w, r.URL.Path)
+		}
+		return
+	}
+
+	writeResponseOK(w, toSupplierResponse(supplier))
+}
+
+// @Summary Create a supplier
+// @Tags suppliers
+// @Accept json
+// @Produce json
+// @Param supplier body model.SupplierRequest true "Supplier"
+// @Success 201 {object} model.SupplierResponse
+// @Failure 400 {object} model.ProblemDetail "Bad Request"
+// @Failure 401 {object} model.ProblemDetail "Unauthorized"
+// @Failure 409 {object} model.ProblemDetail "Conflict"
+// @Failure 500 {object} model.ProblemDetail "Internal Server Error"
+// @Security ApiKeyAuth
+// @Router /suppliers [post]
+func (s *Server) handleCreateSupplier(w http.ResponseWriter, r *http.Request) {
+	var request model.SupplierRequest
+	if err := json.NewDecoder(r.Body).Decode(&request); err != nil {
+		model.InvalidRequest(w, err.Error(), r.URL.Path)
+		return
+	}
+	if err := validator.Validate(request); err != nil {
+		model.ValidationError(w, err.Error(), r.URL.Path)
+		return
+	}
+	supplier, err := domain.NewSupplier(0, request.Name, request.Email, request.Phone)
+	if err != nil {
+		model.ValidationError(w, err.Error(), r.URL.Path)
+		return
+	}
+
+	created, err := s.supplierService.CreateSupplier(r.Context(), supplier)
+	if err != nil {
+		if errors.Is(err, domain.ErrAlreadyExists) {
+			model.AlreadyExists(w, "Supplier Already Exists", r.URL.Path)
+		} else {
+			slog.Error("error creating supplier", "error", err)
+			model.InternalServerError(w, r.URL.Path)
+		}
+		return
+	}
+
+	writeResponseCreated(w, toSupplierResponse(created))
+}
+
+// @Summary Update a supplier
+// @Tags suppliers
+// @Accept json
+// @Produce json
+// @Param id path int true "Supplier ID"
+// @Param supplier body model.SupplierRequest true "Supplier"
+// @Success 200 {object} model.SupplierResponse
+// @Failure 400 {object} model.ProblemDetail "Bad Request"
+// @Failure 401 {object} model.ProblemDetail "Unauthorized"
+// @Failure 404 {object} model.ProblemDetail "Not Found"
+// @Failure 409 {object} model.ProblemDetail "Conflict"
+// @Failure 500 {object} model.ProblemDetail "Internal Server Error"
+// @Security ApiKeyAuth
+// @Router /suppliers/{id} [put]
+func (s *Server) handleUpdateSupplier(w http.ResponseWriter, r *http.Request) {
+	id, err := strconv.Atoi(r.PathValue("id"))
+	if err != nil {
+		model.InvalidRequest(w, "Invalid Supplier ID", r.URL.Path)
+		return
+	}
+
+	var request model.SupplierRequest
+	if err := json.NewDecoder(r.Body).Decode(&request); err != nil {
+		model.InvalidRequest(w, err.Error(), r.URL.Path)
+		return
+	}
+	if err := validator.Validate(request); err != nil {
+		model.ValidationError(w, err.Error(), r.URL.Path)
+		return
+	}
+	supplier, err := domain.NewSupplier(id, request.Name, request.Email, request.Phone)
+	if err != nil {
+		model.ValidationError(w, err.Error(), r.URL.Path)
+		return
+	}
+
+	updated, err := s.supplierService.UpdateSupplier(r.Context(), supplier)
+	if err != nil {
+		switch {
+		case errors.Is(err, domain.ErrNotFound):
+			model.NotFound(w, "Supplier Not Found", r.URL.Path)
+		case errors.Is(err, domain.ErrAlreadyExists):
+			model.AlreadyExists(w, "Supplier Already Exists", r.URL.Path)
+		default:
+			slog.Error("error updating supplier", "error", err)
+			model.InternalServerError(w, r.URL.Path)
+		}
+		return
+	}
+
+	writeResponseOK(w, toSupplierResponse(updated))
+}
+
+// @Summary Delete a supplier
+// @Description Delete a supplier and the books it supplies. Suppliers with purchase orders are kept for their history.
+// @Tags suppliers
+// @Produce json
+// @Param id path int true "Supplier ID"
+// @Success 200
+// @Failure 400 {object} model.ProblemDetail "Bad Request"
+// @Failure 401 {object} model.ProblemDetail "Unauthorized"
+// @Failure 404 {object} model.ProblemDetail "Not Found"
+// @Failure 409 {object} model.ProblemDetail "Conflict"
+// @Failure 500 {object} model.ProblemDetail "Internal Server Error"
+// @Security ApiKeyAuth
+// @Router /suppliers/{id} [delete]
+func (s *Server) handleDeleteSupplier(w http.ResponseWriter, r *http.Request) {
+	id, err := strconv.Atoi(r.PathValue("id"))
+	if err != nil {
+		model.InvalidRequest(w, "Invalid Supplier ID", r.URL.Path)
+		return
+	}
+
+	if err := s.supplierService.DeleteSupplier(r.Context(), id); err != nil {
+		switch {
+		case errors.Is(err, domain.ErrNotFound):
+			model.NotFound(w, "Supplier Not Found", r.URL.Path)
+		case errors.Is(err, domain.ErrSupplierInUse):
+			model.WriteProblemDetail(w, http.StatusConflict, "Supplier In Use", err.Error(), r.URL.Path)
+		default:
+			slog.Error("error deleting supplier", "error", err)
+			model.InternalServerError(w, r.URL.Path)
+		}
+		return
+	}
+
+	w.WriteHeader(http.StatusOK)
+}
+
+// @Summary List the suppliers of a book
+// @Description List the suppliers a book can be restocked from, cheapest first
+// @Tags suppliers
+// @Produce json
+// @Param id path int true "Book ID"
+// @Success 200 {array} model.BookSupplierResponse
+// @Failure 400 {object} model.ProblemDetail "Bad Request"
+// @Failure 401 {object} model.ProblemDetail "Unauthorized"
+// @Failure 500 {object} model.ProblemDetail "Internal Server Error"
+// @Security ApiKeyAuth
+// @Router /book/{id}/suppliers [get]
+func (s *Server) handleGetBookSuppliers(w http.ResponseWriter, r *http.Request) {
+	bookId, err := strconv.Atoi(r.PathValue("id"))
+	if err != nil {
+		model.InvalidRequest(w, "Invalid Book ID", r.URL.Path)
+		return
+	}
+
+	bookSuppliers, err := s.supplierService.GetBookSuppliers(r.Context(), bookId)
+	if err != nil {
+		slog.Error("error getting book suppliers", "error", err)
+		model.InternalServerError(w, r.URL.Path)
+		return
+	}
+
+	writeResponseOK(w, toBookSuppliersResponse(bookSuppliers))
+}
+
+// @Summary Set the cost price of a book at a supplier
+// @Description Add a supplier to a book or change the cost price it charges for it
+// @Tags suppliers
+// @Accept json
+// @Produce json
+// @Param id path int true "Book ID"
+// @Param supplierId path int true "Supplier ID"
+// @Param request body model.BookSupplierRequest true "Cost price"
+// @Success 200 {object} model.BookSupplierResponse
+// @Failure 400 {object} model.ProblemDetail "Bad Request"
+// @Failure 401 {object} model.ProblemDetail "Unauthorized"
+// @Failure 404 {object} model.ProblemDetail "Not Found"
+// @Failure 500 {object} model.ProblemDetail "Internal Server Error"
+// @Security ApiKeyAuth
+// @Router /book/{id}/suppliers/{supplierId} [put]
+func (s *Server) handleSaveBookSupplier(w http.ResponseWriter, r *http.Request) {
+	bookId, err := strconv.Atoi(r.PathValue("id"))
+	if err != nil {
+		model.InvalidRequest(w, "Invalid Book ID", r.URL.Path)
+		return
+	}
+	supplierId, err := strconv.Atoi(r.PathValue("supplierId"))
+	if err != nil {
+		model.InvalidRequest(w, "Invalid Supplier ID", r.URL.Path)
+		return
+	}
+
+	var request model.BookSupplierRequest
+	if err := json.NewDecoder(r.Body).Decode(&request); err != nil {
+		model.InvalidRequest(w, err.Error(), r.URL.Path)
+		return
+	}
+	if err := validator.Validate(request); err != nil {
+		model.ValidationError(w, err.Error(), r.URL.Path)
+		return
+	}
+	bookSupplier, err := domain.NewBookSupplier(bookId, supplierId, request.CostPrice, request.SKU)
+	if err != nil {
+		model.ValidationError(w, err.Error(), r.URL.Path)
+		return
+	}
+
+	saved, err := s.supplierService.SaveBookSupplier(r.Context(), bookSupplier)
+	if err != nil {
+		switch {
+		case errors.Is(err, domain.ErrNotFound):
+			model.NotFound(w, "Book or Supplier Not Found", r.URL.Path)
+		default:
+			slog.Error("error saving book supplier", "error", err)
+			model.InternalServerError(w, r.URL.Path)
+		}
+		return
+	}
+
+	writeResponseOK(w, toBookSupplierResponse(saved))
+}
+
+// @Summary Remove a supplier from a book
+// @Tags suppliers
+// @Produce json
+// @Param id path int true "Book ID"
+// @Param supplierId path int true "Supplier ID"
+// @Success 200
+// @Failure 400 {object} model.ProblemDetail "Bad Request"
+// @Failure 401 {object} model.ProblemDetail "Unauthorized"
+// @Failure 404 {object} model.ProblemDetail "Not Found"
+// @Failure 500 {object} model.ProblemDetail "Internal Server Error"
+// @Security ApiKeyAuth
+// @Router /book/{id}/suppliers/{supplierId} [delete]
+func (s *Server) handleDeleteBookSupplier(w http.ResponseWriter, r *http.Request) {
+	bookId, err := strconv.Atoi(r.PathValue("id"))
+	if err != nil {
+		model.InvalidRequest(w, "Invalid Book ID", r.URL.Path)
+		return
+	}
+	supplierId, err := strconv.Atoi(r.PathValue("supplierId"))
+	if err != nil {
+		model.InvalidRequest(w, "Invalid Supplier ID", r.URL.Path)
+		return
+	}
+
+	if err := s.supplierService.DeleteBookSupplier(r.Context(), bookId, supplierId); err != nil {
+		if errors.Is(err, domain.ErrNotFound) {
+			model.NotFound(w, "Book Supplier Not Found", r.URL.Path)
+		} else {
+			slog.Error("error deleting book supplier", "error", err)
+			model.InternalServerError(w, r.URL.Path)
+		}
+		return
+	}
+
+	w.WriteHeader(http.StatusOK)
+}
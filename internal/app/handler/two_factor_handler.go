@@ -0,0 +1,138 @@
+package handler
+
+import (
+	"encoding/json"
+	"errors"
+	"log/slog"
+	"net/http"
+	"toptal/internal/app/domain"
+	"toptal/internal/app/handler/model"
+	"toptal/internal/app/util"
+	"toptal/internal/pkg/validator"
+)
+
+// @Summary Start TOTP enrollment
+// @Description Generate a TOTP secret and the otpauth:// URI to render as a QR code
+// @Tags two-factor
+// @Accept json
+// @Produce json
+// @Success 200 {object} model.TOTPEnrollmentResponse
+// @Failure 401 {object} model.ProblemDetail "Unauthorized"
+// @Failure 409 {object} model.ProblemDetail "Already enabled"
+// @Failure 500 {object} model.ProblemDetail "Internal Server Error"
+// @Security ApiKeyAuth
+// @Router /me/2fa/enroll [post]
+func (s *Server) handleEnrollTOTP(w http.ResponseWriter, r *http.Request) {
+	userId, err := util.GetUserID(r.Context())
+	if err != nil {
+		model.Unauthorized(w, "unauthorized", r.URL.Path)
+		return
+	}
+
+	secret, uri, err := s.authService.EnrollTOTP(r.Context(), userId)
+	if err != nil {
+		if errors.Is(err, domain.ErrTwoFactorAlreadyEnabled) {
+			model.AlreadyExists(w, err.Error(), r.URL.Path)
+		} else {
+			slog.Error("error enrolling totp", "error", err)
+			model.InternalServerError(w, r.URL.Path)
+		}
+		return
+	}
+
+	response := model.TOTPEnrollmentResponse{Secret: secret, ProvisioningURI: uri}
+	writeResponseOK(w, response)
+}
+
+// @Summary Confirm TOTP enrollment
+// @Description Enable two-factor authentication with a code from the authenticator app. Returns one-time recovery codes.
+// @Tags two-factor
+// @Accept json
+// @Produce json
+// @Param request body model.TwoFactorCodeRequest true "TOTP code"
+// @Success 200 {object} model.RecoveryCodesResponse
+// @Failure 400 {object} model.ProblemDetail "Bad Request"
+// @Failure 401 {object} model.ProblemDetail "Unauthorized"
+// @Failure 409 {object} model.ProblemDetail "Already enabled"
+// @Failure 500 {object} model.ProblemDetail "Internal Server Error"
+// @Security ApiKeyAuth
+// @Router /me/2fa/confirm [post]
+func (s *Server) handleConfirmTOTP(w http.ResponseWriter, r *http.Request) {
+	userId, err := util.GetUserID(r.Context())
+	if err != nil {
+		model.Unauthorized(w, "unauthorized", r.URL.Path)
+		return
+	}
+
+	var request model.TwoFactorCodeRequest
+	if err := json.NewDecoder(r.Body).Decode(&request); err != nil {
+		model.InvalidRequest(w, err.Error(), r.URL.Path)
+		return
+	}
+
+	if err := validator.Validate(request); err != nil {
+		model.ValidationError(w, err.Error(), r.URL.Path)
+		return
+	}
+
+	codes, err := s.authService.ConfirmTOTP(r.Context(), userId, request.Code)
+	if err != nil {
+		switch {
+		case errors.Is(err, domain.ErrTwoFactorAlreadyEnabled):
+			model.AlreadyExists(w, err.Error(), r.URL.Path)
+		case errors.Is(err, domain.ErrInvalidTwoFactorCode), errors.Is(err, domain.ErrTwoFactorNotEnabled):
+			model.ValidationError(w, err.Error(), r.URL.Path)
+		default:
+			slog.Error("error confirming totp", "error", err)
+			model.InternalServerError(w, r.URL.Path)
+		}
+		return
+	}
+
+	response := model.RecoveryCodesResponse{RecoveryCodes: codes}
+	writeResponseOK(w, response)
+}
+
+// @Summary Disable two-factor authentication
+// @Description Turn off two-factor authentication after checking a TOTP or recovery code
+// @Tags two-factor
+// @Accept json
+// @Produce json
+// @Param request body model.TwoFactorCodeRequest true "TOTP or recovery code"
+// @Success 200 {string} string "OK"
+// @Failure 400 {object} model.ProblemDetail "Bad Request"
+// @Failure 401 {object} model.ProblemDetail "Unauthorized"
+// @Failure 500 {object} model.ProblemDetail "Internal Server Error"
+// @Security ApiKeyAuth
+// @Router /me/2fa/disable [post]
+func (s *Server) handleDisableTOTP(w http.ResponseWriter, r *http.Request) {
+	userId, err := util.GetUserID(r.Context())
+	if err != nil {
+		model.Unauthorized(w, "unauthorized", r.URL.Path)
+		return
+	}
+
+	var request model.TwoFactorCodeRequest
+	if err := json.NewDecoder(r.Body).Decode(&request); err != nil {
+		model.InvalidRequest(w, err.Error(), r.URL.Path)
+		return
+	}
+
+	if err := validator.Validate(request); err != nil {
+		model.ValidationError(w, err.Error(), r.URL.Path)
+		return
+	}
+
+	if err := s.authService.DisableTOTP(r.Context(), userId, request.Code); err != nil {
+		switch {
+		case errors.Is(err, domain.ErrInvalidTwoFactorCode), errors.Is(err, domain.ErrTwoFactorNotEnabled):
+			model.ValidationError(w, err.Error(), r.URL.Path)
+		default:
+			slog.Error("error disabling totp", "error", err)
+			model.InternalServerError(w, r.URL.Path)
+		}
+		return
+	}
+
+	w.WriteHeader(http.StatusOK)
+}
@@ -0,0 +1,169 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"time"
+	"toptal/internal/app/domain"
+	"toptal/internal/app/repository/model"
+	"toptal/internal/pkg/pg"
+
+	"github.com/jmoiron/sqlx"
+	"github.com/lib/pq"
+)
+
+const (
+	sqlInsertAPIKey = `
+		INSERT INTO api_keys (name, prefix, key_hash, scopes, created_by, expires_at)
+		VALUES ($1, $2, $3, $4, $5, $6)
+		RETURNING *
+	`
+	sqlFindAPIKeyById     = `SELECT * FROM api_keys WHERE id = $1`
+	sqlLockAPIKey         = `SELECT * FROM api_keys WHERE id = $1 FOR UPDATE`
+	sqlFindAPIKeyByPrefix = `SELECT * FROM api_keys WHERE prefix = $1`
+	sqlFindAPIKeys        = `SELECT * FROM api_keys ORDER BY id`
+	sqlRevokeAPIKey       = `UPDATE api_keys SET revoked_at = now() WHERE id = $1 AND revoked_at IS NULL RETURNING *`
+	sqlTouchAPIKey        = `
+		UPDATE api_keys
+		SET last_used_at = now()
+		WHERE id = $1
+			AND (last_used_at IS NULL OR last_used_at < now() - interval '1 minute')
+	`
+	sqlExpireAPIKey = `
+		UPDATE api_keys
+		SET expires_at = LEAST(COALESCE(expires_at, $2), $2)
+		WHERE id = $1 AND revoked_at IS NULL
+		RETURNING *
+	`
+)
+
+type APIKeyRepository struct {
+	db *pg.DB
+}
+
+func NewAPIKeyRepository(db *pg.DB) *APIKeyRepository {
+	return &APIKeyRepository{db}
+}
+
+func (r *APIKeyRepository) InsertAPIKey(ctx context.Context, key domain.APIKey, actor domain.AuditActor) (domain.APIKey, error) {
+	var created model.APIKey
+	err := r.db.WithTransaction(ctx, func(tx *sqlx.Tx) error {
+		err := tx.GetContext(ctx, &created, sqlInsertAPIKey,
+			key.Name(), key.Prefix(), key.KeyHash(), pq.StringArray(key.Scopes()), key.CreatedBy(), toNullTime(key.ExpiresAt()),
+		)
+		if err != nil {
+			if pg.IsUniqueViolationErr(err) {
+				return domain.ErrAlreadyExists
+			}
+			return model.WrapDatabaseError(err, "failed to insert api key")
+		}
+		return writeAudit(ctx, tx, actor, domain.AuditActionCreate, domain.AuditEntityAPIKey, created.Id, nil, created)
+	})
+	if err != nil {
+		return domain.APIKey{}, err
+	}
+	return toDomainAPIKey(created)
+}
+
+func (r *APIKeyRepository) FindAPIKeyById(ctx context.Context, id int) (domain.APIKey, error) {
+	var key model.APIKey
+	err := r.db.Get(ctx, "find_api_key_by_id", &key, sqlFindAPIKeyById, id)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return domain.APIKey{}, domain.ErrNotFound
+		}
+		return domain.APIKey{}, model.WrapDatabaseError(err, "failed to find api key")
+	}
+	return toDomainAPIKey(key)
+}
+
+func (r *APIKeyRepository) FindAPIKeyByPrefix(ctx context.Context, prefix string) (domain.APIKey, error) {
+	var key model.APIKey
+	err := r.db.Get(ctx, "find_api_key_by_prefix", &key, sqlFindAPIKeyByPrefix, prefix)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return domain.APIKey{}, domain.ErrNotFound
+		}
+		return domain.APIKey{}, model.WrapDatabaseError(err, "failed to find api key")
+	}
+	return toDomainAPIKey(key)
+}
+
+func (r *APIKeyRepository) FindAPIKeys(ctx context.Context) ([]domain.APIKey, error) {
+	var keys []model.APIKey
+	err := r.db.Select(ctx, "find_api_keys", &keys, sqlFindAPIKeys)
+	if err != nil {
+		return nil, model.WrapDatabaseError(err, "failed to find api keys")
+	}
+	return toDomainAPIKeys(keys)
+}
+
+func (r *APIKeyRepository) RevokeAPIKey(ctx context.Context, id int, actor domain.AuditActor) error {
+	return r.db.WithTransaction(ctx, func(tx *sqlx.Tx) error {
+		var before, after model.APIKey
+		if err := tx.GetContext(ctx, &before, sqlLockAPIKey, id); err != nil {
+			if errors.Is(err, sql.ErrNoRows) {
+				return domain.ErrNotFound
+			}
+			return model.WrapDatabaseError(err, "failed to find api key")
+		}
+		if err := tx.GetContext(ctx, &after, sqlRevokeAPIKey, id); err != nil {
+			if errors.Is(err, sql.ErrNoRows) {
+				return domain.ErrNotFound
+			}
+			return model.WrapDatabaseError(err, "failed to revoke api key")
+		}
+		return writeAudit(ctx, tx, actor, domain.AuditActionRevoke, domain.AuditEntityAPIKey, id, before, after)
+	})
+}
+
+// TouchAPIKey records that the key was used. Updates are throttled to once a minute
+// so that busy integrations don't turn every request into a write.
+func (r *APIKeyRepository) TouchAPIKey(ctx context.Context, id int) error {
+	if _, err := r.db.Exec(ctx, "touch_api_key", sqlTouchAPIKey, id); err != nil {
+		return model.WrapDatabaseError(err, "failed to update api key last used time")
+	}
+	return nil
+}
+
+// RotateAPIKey stores the replacement key and shortens the lifetime of the old one
+// to graceUntil, so both keys are accepted while clients switch over.
+func (r *APIKeyRepository) RotateAPIKey(
+	ctx context.Context, id int, replacement domain.APIKey, graceUntil time.Time, actor domain.AuditActor,
+) (domain.APIKey, error) {
+	var created model.APIKey
+	err := r.db.WithTransaction(ctx, func(tx *sqlx.Tx) error {
+		var before, after model.APIKey
+		if err := tx.GetContext(ctx, &before, sqlLockAPIKey, id); err != nil {
+			if errors.Is(err, sql.ErrNoRows) {
+				return domain.ErrNotFound
+			}
+			return model.WrapDatabaseError(err, "failed to find api key")
+		}
+		if err := tx.GetContext(ctx, &after, sqlExpireAPIKey, id, graceUntil); err != nil {
+			if errors.Is(err, sql.ErrNoRows) {
+				return domain.ErrNotFound
+			}
+			return model.WrapDatabaseError(err, "failed to expire rotated api key")
+		}
+
+		err := tx.GetContext(ctx, &created, sqlInsertAPIKey,
+			replacement.Name(), replacement.Prefix(), replacement.KeyHash(), pq.StringArray(replacement.Scopes()),
+			replacement.CreatedBy(), toNullTime(replacement.ExpiresAt()),
+		)
+		if err != nil {
+			return model.WrapDatabaseError(err, "failed to insert api key")
+		}
+
+		if err := writeAudit(ctx, tx, actor, domain.AuditActionRotate, domain.AuditEntityAPIKey, id, before, after); err != nil {
+			return err
+		}
+		return writeAudit(ctx, tx, actor, domain.AuditActionCreate, domain.AuditEntityAPIKey, created.Id, nil, created)
+	})
+	if err != nil {
+		return domain.APIKey{}, fmt.Errorf("failed to rotate api key: %w", err)
+	}
+	return toDomainAPIKey(created)
+}
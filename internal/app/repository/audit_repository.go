@@ -0,0 +1,153 @@
+package repository
+
+import (
+	"bytes"
+	"context"
+	"database/sql/driver"
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"slices"
+	"toptal/internal/app/domain"
+	"toptal/internal/app/repository/model"
+	"toptal/internal/pkg/pg"
+
+	"github.com/jmoiron/sqlx"
+)
+
+const (
+	sqlInsertAuditEntry = `
+		INSERT INTO audit_log
+			(actor_user_id, actor_api_key_id, action, entity_type, entity_id, before, after, request_id, ip_address)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9)
+	`
+	sqlFindAuditEntries = `
+		SELECT * FROM audit_log
+		WHERE ($1 = '' OR entity_type = $1)
+			AND ($2 = 0 OR entity_id = $2)
+			AND ($3 = 0 OR actor_user_id = $3)
+			AND ($4 = 0 OR actor_api_key_id = $4)
+			AND ($5::timestamptz IS NULL OR created_at >= $5)
+			AND ($6::timestamptz IS NULL OR created_at < $6)
+		ORDER BY id DESC
+		LIMIT $7 OFFSET $8
+	`
+)
+
+// auditHiddenColumns are never copied into the audit log.
+var auditHiddenColumns = []string{"key_hash", "password_hash", "totp_secret"}
+
+type AuditRepository struct {
+	db *pg.DB
+}
+
+func NewAuditRepository(db *pg.DB) *AuditRepository {
+	return &AuditRepository{db}
+}
+
+// FindAuditEntries returns the entries matching the filter, newest first.
+func (r *AuditRepository) FindAuditEntries(ctx context.Context, filter domain.AuditFilter) ([]domain.AuditEntry, error) {
+	var entries []model.AuditEntry
+	err := r.db.Select(ctx, "find_audit_entries", &entries, sqlFindAuditEntries,
+		filter.EntityType, filter.EntityId, filter.ActorUserId, filter.ActorAPIKeyId,
+		toNullTime(filter.From), toNullTime(filter.To), filter.Limit, filter.Offset,
+	)
+	if err != nil {
+		return nil, model.WrapDatabaseError(err, "failed to find audit entries")
+	}
+	return toDomainAuditEntries(entries)
+}
+
+// writeAudit records a change to an entity inside the transaction making it. before
+// and after are model structs, or nil for a create or delete; only the columns that
+// differ between them are stored.
+func writeAudit(ctx context.Context, tx *sqlx.Tx, actor domain.AuditActor, action, entityType string, entityId int, before, after any) error {
+	beforeValues, afterValues, err := auditDiff(auditSnapshot(before), auditSnapshot(after))
+	if err != nil {
+		return err
+	}
+	entry, err := domain.NewAuditEntry(actor, action, entityType, entityId, beforeValues, afterValues)
+	if err != nil {
+		return err
+	}
+	return insertAuditEntry(ctx, tx, entry)
+}
+
+func insertAuditEntry(ctx context.Context, tx *sqlx.Tx, entry domain.AuditEntry) error {
+	before, err := marshalAuditValues(entry.Before())
+	if err != nil {
+		return err
+	}
+	after, err := marshalAuditValues(entry.After())
+	if err != nil {
+		return err
+	}
+
+	actor := entry.Actor()
+	_, err = tx.ExecContext(ctx, sqlInsertAuditEntry,
+		toNullInt64(actor.UserId()), toNullInt64(actor.APIKeyId()), entry.Action(), entry.EntityType(), entry.EntityId(),
+		before, after, toNullString(actor.RequestId()), toNullString(actor.IPAddress()),
+	)
+	if err != nil {
+		return model.WrapDatabaseError(err, "failed to insert audit entry")
+	}
+	return nil
+}
+
+// auditSnapshot maps the db columns of a model struct to their values.
+func auditSnapshot(row any) map[string]any {
+	if row == nil {
+		return nil
+	}
+	value := reflect.Indirect(reflect.ValueOf(row))
+	snapshot := make(map[string]any, value.NumField())
+	for i := 0; i < value.NumField(); i++ {
+		column := value.Type().Field(i).Tag.Get("db")
+		if column == "" || slices.Contains(auditHiddenColumns, column) {
+			continue
+		}
+		field := value.Field(i).Interface()
+		// Arrays encode fine as JSON; scalars wrapped in sql.Null* are unwrapped.
+		if valuer, ok := field.(driver.Valuer); ok && value.Field(i).Kind() != reflect.Slice {
+			if v, err := valuer.Value(); err == nil {
+				field = v
+			}
+		}
+		snapshot[column] = field
+	}
+	return snapshot
+}
+
+// auditDiff drops the columns whose JSON encoding is the same before and after.
+func auditDiff(before, after map[string]any) (map[string]any, map[string]any, error) {
+	if before == nil || after == nil {
+		return before, after, nil
+	}
+	changedBefore, changedAfter := make(map[string]any), make(map[string]any)
+	for column, old := range before {
+		oldJSON, err := json.Marshal(old)
+		if err != nil {
+			return nil, nil, fmt.Errorf("failed to encode audit value: %w", err)
+		}
+		newJSON, err := json.Marshal(after[column])
+		if err != nil {
+			return nil, nil, fmt.Errorf("failed to encode audit value: %w", err)
+		}
+		if !bytes.Equal(oldJSON, newJSON) {
+			changedBefore[column] = old
+			changedAfter[column] = after[column]
+		}
+	}
+	return changedBefore, changedAfter, nil
+}
+
+func marshalAuditValues(values map[string]any) ([]byte, error) {
+	if values == nil {
+		return nil, nil
+	}
+	encoded, err := json.Marshal(values)
+	if err != nil {
+		return nil, fmt.Errorf("failed to encode audit values: %w", err)
+	}
+	return encoded, nil
+}
@@ -0,0 +1,95 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"testing"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/jmoiron/sqlx"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"toptal/internal/app/domain"
+	"toptal/internal/app/repository/model"
+	"toptal/internal/pkg/pg"
+)
+
+func TestAuditDiff(t *testing.T) {
+	before := model.Book{Id: 1, Title: "Dune", Author: "Herbert", Year: 1965, Price: 1000, Stock: 3, CategoryId: 2}
+	after := before
+	after.Price = 1200
+
+	changedBefore, changedAfter, err := auditDiff(auditSnapshot(before), auditSnapshot(after))
+	require.NoError(t, err)
+	assert.Equal(t, map[string]any{"price": int64(1000)}, changedBefore)
+	assert.Equal(t, map[string]any{"price": int64(1200)}, changedAfter)
+
+	created, deleted, err := auditDiff(nil, auditSnapshot(after))
+	require.NoError(t, err)
+	assert.Nil(t, created)
+	assert.Equal(t, "Dune", deleted["title"])
+}
+
+func TestAuditSnapshot_HidesSecrets(t *testing.T) {
+	snapshot := auditSnapshot(model.APIKey{Id: 1, Name: "ci", KeyHash: "secret", RevokedAt: sql.NullTime{}})
+	assert.NotContains(t, snapshot, "key_hash")
+	assert.Equal(t, "ci", snapshot["name"])
+	assert.Nil(t, snapshot["revoked_at"])
+}
+
+func TestBookRepository_UpdateWritesAudit(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	require.NoError(t, err)
+	defer db.Close()
+
+	repo := NewBookRepository(pg.NewDB(sqlx.NewDb(db, "sqlmock")))
+	columns := []string{"id", "title", "author", "year", "price", "currency", "stock", "category_id"}
+	book, err := domain.NewBook(1, "Dune", 1965, "Herbert", usd(1200), 3, 2)
+	require.NoError(t, err)
+	actor := domain.NewAuditActor(7, 0, "req-1", "10.0.0.1")
+
+	mock.ExpectBegin()
+	mock.ExpectQuery("SELECT \\* FROM books WHERE id = \\$1 AND deleted_at IS NULL FOR UPDATE").
+		WithArgs(1).
+		WillReturnRows(sqlmock.NewRows(columns).AddRow(1, "Dune", "Herbert", 1965, 1000, "USD", 3, 2))
+	mock.ExpectQuery("UPDATE books SET").
+		WithArgs(1, "Dune", "Herbert", 1965, int64(1200), 2, "",
+			sql.NullString{}, sql.NullInt64{}, sql.NullString{}, sql.NullString{}, sql.NullTime{}, sql.NullString{},
+			sql.NullInt64{}, sql.NullInt64{}, sql.NullInt64{}, sql.NullInt64{}, sql.NullInt64{}).
+		WillReturnRows(sqlmock.NewRows(columns).AddRow(1, "Dune", "Herbert", 1965, 1200, "USD", 3, 2))
+	mock.ExpectExec("INSERT INTO book_price_history").
+		WithArgs(1, domain.PriceReasonUpdate, sql.NullInt64{}).
+		WillReturnResult(sqlmock.NewResult(1, 1))
+	mock.ExpectExec("INSERT INTO audit_log").
+		WithArgs(
+			sql.NullInt64{Int64: 7, Valid: true}, sql.NullInt64{}, domain.AuditActionUpdate, domain.AuditEntityBook, 1,
+			[]byte(`{"price":1000}`), []byte(`{"price":1200}`),
+			sql.NullString{String: "req-1", Valid: true}, sql.NullString{String: "10.0.0.1", Valid: true},
+		).
+		WillReturnResult(sqlmock.NewResult(1, 1))
+	mock.ExpectCommit()
+
+	require.NoError(t, repo.Update(context.Background(), book, actor))
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestBookRepository_UpdateNotFound(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	require.NoError(t, err)
+	defer db.Close()
+
+	repo := NewBookRepository(pg.NewDB(sqlx.NewDb(db, "sqlmock")))
+	book, err := domain.NewBook(9, "Dune", 1965, "Herbert", usd(1200), 3, 2)
+	require.NoError(t, err)
+
+	mock.ExpectBegin()
+	mock.ExpectQuery("SELECT \\* FROM books WHERE id = \\$1 AND deleted_at IS NULL FOR UPDATE").
+		WithArgs(9).
+		WillReturnError(sql.ErrNoRows)
+	mock.ExpectRollback()
+
+	err = repo.Update(context.Background(), book, domain.AuditActor{})
+	assert.ErrorIs(t, err, domain.ErrNotFound)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
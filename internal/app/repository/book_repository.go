@@ -4,26 +4,111 @@ import (
 	"context"
 	"database/sql"
 	"errors"
+	"fmt"
+	"strings"
+	"time"
 	"toptal/internal/app/domain"
 	"toptal/internal/app/repository/model"
 	"toptal/internal/pkg/pg"
 
 	"github.com/jmoiron/sqlx"
+	"github.com/lib/pq"
 )
 
 const (
-	sqlCreateBook           = `INSERT INTO books (title, author, year, price, stock, category_id) VALUES ($1, $2, $3, $4, $5, $6)`
-	sqlGetBookById          = `SELECT * FROM books WHERE id = $1`
-	sqlUpdateBook           = `UPDATE books SET title = $2, author = $3, year = $4, price = $5, category_id = $6 WHERE id = $1`
-	sqlDeleteBook           = `DELETE FROM books WHERE id = $1`
-	sqlGetBooks             = `SELECT * FROM books WHERE stock > 0 LIMIT $1 OFFSET $2`
-	sqlGetBooksByCategories = `
+	// sqlCreateBook only accepts a category that is still listed.
+	sqlCreateBook = `
+		INSERT INTO books (
+			title, author, year, price, stock, category_id, currency, isbn,
+			description, page_count, language, publisher, publication_date, series, series_position,
+			width_mm, height_mm, depth_mm, weight_g
+		)
+		SELECT $1::varchar, $2::varchar, $3::int, $4::bigint, $5::int, $6::int, $7::char(3), $8::varchar,
+			$9::text, $10::int, $11::varchar, $12::varchar, $13::date, $14::varchar, $15::int,
+			$16::int, $17::int, $18::int, $19::int
+		WHERE EXISTS (SELECT 1 FROM categories WHERE id = $6 AND deleted_at IS NULL)
+		RETURNING *
+	`
+	sqlGetBookById = `SELECT * FROM books WHERE id = $1 AND deleted_at IS NULL`
+	sqlLockBook    = `SELECT * FROM books WHERE id = $1 AND deleted_at IS NULL FOR UPDATE`
+	// sqlUpdateBook keeps the ISBN when none is given, and replaces the metadata.
+	sqlUpdateBook = `
+		UPDATE books
+		SET title = $2, author = $3, year = $4, price = $5, category_id = $6, isbn = COALESCE(NULLIF($7, ''), isbn),
+			description = $8, page_count = $9, language = $10, publisher = $11, publication_date = $12,
+			series = $13, series_position = $14, width_mm = $15, height_mm = $16, depth_mm = $17, weight_g = $18
+		WHERE id = $1 AND EXISTS (SELECT 1 FROM categories WHERE id = $6 AND deleted_at IS NULL)
+		RETURNING *
+	`
+	sqlArchiveBook      = `UPDATE books SET deleted_at = now() WHERE id = $1 AND deleted_at IS NULL RETURNING *`
+	sqlLockArchivedBook = `SELECT * FROM books WHERE id = $1 AND deleted_at IS NOT NULL FOR UPDATE`
+	sqlRestoreBook      = `
+		UPDATE books
+		SET deleted_at = NULL
+		WHERE id = $1 AND EXISTS (SELECT 1 FROM categories WHERE id = books.category_id AND deleted_at IS NULL)
+		RETURNING *
+	`
+	sqlGetArchivedBooks = `SELECT * FROM books WHERE deleted_at IS NOT NULL ORDER BY deleted_at DESC, id LIMIT $1 OFFSET $2`
+	// sqlPurgeBooks removes books archived before $1 that were never sold, ordered from a
+	// supplier or restocked, recording each one in the audit log. Books with such a history
+	// stay archived, so reports, price history and stock movements keep referring to them.
+	// It returns the purged books with the keys of their format files, read before the
+	// formats are deleted with them.
+	sqlPurgeBooks = `
+		WITH purged AS (
+			DELETE FROM books b
+			WHERE b.deleted_at < $1
+				AND NOT EXISTS (SELECT 1 FROM order_items oi WHERE oi.book_id = b.id)
+				AND NOT EXISTS (SELECT 1 FROM purchase_order_items poi WHERE poi.book_id = b.id)
+				AND NOT EXISTS (SELECT 1 FROM stock_movements sm WHERE sm.book_id = b.id)
+			RETURNING b.*
+		), audited AS (
+			INSERT INTO audit_log (action, entity_type, entity_id, before)
+			SELECT $2::varchar, $3::varchar, purged.id, to_jsonb(purged)
+			FROM purged
+		)
+		SELECT purged.*, ARRAY(
+			SELECT f.file_key FROM book_formats f WHERE f.book_id = purged.id AND f.file_key IS NOT NULL
+		) AS file_keys
+		FROM purged
+	`
+	// sqlGetBooks lists available books. An empty $1 matches every category, an empty $2
+	// every language and an empty $3 every series. Books of a series come in order.
+	sqlGetBooks = `
+		SELECT *
+		FROM books
+		WHERE stock > 0
+			AND deleted_at IS NULL
+			AND (cardinality($1::int[]) = 0 OR category_id = ANY($1))
+			AND ($2 = '' OR lower(language) = lower($2) OR lower(language) LIKE lower($2) || '-%')
+			AND ($3 = '' OR lower(series) = lower($3))
+		ORDER BY CASE WHEN $3 <> '' THEN series_position END NULLS LAST, id
+		LIMIT $4 OFFSET $5
+	`
+	// sqlSearchBooks finds available books whose title or author contains the pattern $1,
+	// or that have the ISBN $2.
+	sqlSearchBooks = `
 		SELECT *
 		FROM books
 		WHERE stock > 0
-			AND category_id IN (:categoryIds)
-		LIMIT :limit OFFSET :offset
+			AND deleted_at IS NULL
+			AND (title ILIKE $1 ESCAPE '\' OR author ILIKE $1 ESCAPE '\' OR isbn = $2)
+		ORDER BY title, id
+		LIMIT $3 OFFSET $4
+	`
+	// sqlDeclareBookExport opens a cursor over the books of an export, so they can be read
+	// in batches instead of all at once. An empty $1 matches every category.
+	sqlDeclareBookExport = `
+		DECLARE book_export NO SCROLL CURSOR FOR
+		SELECT b.*, c.name AS category_name
+		FROM books b
+		JOIN categories c ON c.id = b.category_id
+		WHERE (cardinality($1::int[]) = 0 OR b.category_id = ANY($1))
+			AND (NOT $2::boolean OR b.stock > 0)
+			AND ($3::boolean OR b.deleted_at IS NULL)
+		ORDER BY b.id
 	`
+	sqlFetchBookExport = `FETCH 500 FROM book_export`
 )
 
 type BookRepository struct {
@@ -50,97 +135,210 @@ func (r *BookRepository) GetById(ctx context.Context, id int) (domain.Book, erro
 	return toDomainBook(book), nil
 }
 
-func (r *BookRepository) GetAll(ctx context.Context, limit, offset int) ([]domain.Book, error) {
-	var books []model.Book
-	err := r.db.Select(ctx, "get_all_books", &books, sqlGetBooks, limit, offset)
+// GetAvailable lists the books in stock that the filter selects.
+func (r *BookRepository) GetAvailable(ctx context.Context, filter domain.BookFilter, limit, offset int) ([]domain.Book, error) {
+	categoryIds := pq.Int64Array{}
+	for _, id := range filter.CategoryIds {
+		categoryIds = append(categoryIds, int64(id))
+	}
 
+	var books []model.Book
+	err := r.db.Select(ctx, "get_books", &books, sqlGetBooks,
+		categoryIds, filter.Language, filter.Series, limit, offset)
 	if err != nil {
 		return nil, model.WrapDatabaseError(err, "failed to get books")
 	}
-
 	return toDomainBooks(books), nil
 }
 
-func (r *BookRepository) GetByCategories(ctx context.Context, categoryIds []int, limit, offset int) ([]domain.Book, error) {
-	var books []model.Book
-	if len(categoryIds) == 0 {
-		return r.GetAll(ctx, limit, offset)
+// ExportBooks calls fn with every book the filter selects, in order of ID, reading them
+// from a server-side cursor. It stops at the first error fn returns.
+func (r *BookRepository) ExportBooks(ctx context.Context, filter domain.BookExportFilter, fn func(domain.ExportedBook) error) error {
+	categoryIds := pq.Int64Array{}
+	for _, id := range filter.CategoryIds {
+		categoryIds = append(categoryIds, int64(id))
 	}
 
-	arg := map[string]interface{}{
-		"categoryIds": categoryIds,
-		"limit":       limit,
-		"offset":      offset,
-	}
-	query, args, err := sqlx.Named(sqlGetBooksByCategories, arg)
-	if err != nil {
-		return nil, model.WrapDatabaseError(err, "failed to build named query")
-	}
-	query, args, err = sqlx.In(query, args...)
-	if err != nil {
-		return nil, model.WrapDatabaseError(err, "failed to build IN query")
-	}
-	query = r.db.Rebind(query)
+	return r.db.WithTransaction(ctx, func(tx *sqlx.Tx) error {
+		_, err := tx.ExecContext(ctx, sqlDeclareBookExport, categoryIds, filter.InStockOnly, filter.IncludeArchived)
+		if err != nil {
+			return model.WrapDatabaseError(err, "failed to open book export")
+		}
+		for {
+			var books []model.ExportedBook
+			if err := tx.SelectContext(ctx, &books, sqlFetchBookExport); err != nil {
+				return model.WrapDatabaseError(err, "failed to export books")
+			}
+			if len(books) == 0 {
+				return nil
+			}
+			for _, book := range books {
+				if err := fn(domain.NewExportedBook(toDomainBook(book.Book), book.CategoryName)); err != nil {
+					return err
+				}
+			}
+		}
+	})
+}
 
-	err = r.db.Select(ctx, "get_books_by_categories", &books, query, args...)
-	if err != nil {
-		return nil, model.WrapDatabaseError(err, "failed to get books by categories")
-	}
+// Search returns the available books whose title or author contains query, ignoring case,
+// or whose ISBN it is.
+func (r *BookRepository) Search(ctx context.Context, query string, limit, offset int) ([]domain.Book, error) {
+	pattern := "%" + likeEscaper.Replace(query) + "%"
+	isbn, _ := domain.NormalizeISBN(query)
 
+	var books []model.Book
+	if err := r.db.Select(ctx, "search_books", &books, sqlSearchBooks, pattern, isbn, limit, offset); err != nil {
+		return nil, model.WrapDatabaseError(err, "failed to search books")
+	}
 	return toDomainBooks(books), nil
 }
 
-func (r *BookRepository) Create(ctx context.Context, book domain.Book) error {
-	_, err := r.db.Exec(ctx, "create_book", sqlCreateBook,
-		book.Title(), book.Author(), book.Year(), book.Price(), book.Stock(), book.CategoryId(),
-	)
+// likeEscaper escapes the wildcards of a LIKE pattern.
+var likeEscaper = strings.NewReplacer(`\`, `\\`, `%`, `\%`, `_`, `\_`)
 
-	if err != nil {
-		if pg.IsForeignKeyViolationErr(err) {
-			return domain.ErrInvalidCategory
+func (r *BookRepository) Create(ctx context.Context, book domain.Book, actor domain.AuditActor) error {
+	return r.db.WithTransaction(ctx, func(tx *sqlx.Tx) error {
+		var created model.Book
+		args := append([]any{
+			book.Title(), book.Author(), book.Year(), book.Price().Amount(), book.Stock(), book.CategoryId(),
+			book.Price().Currency(), toNullString(book.ISBN()),
+		}, metadataArgs(book)...)
+		err := tx.GetContext(ctx, &created, sqlCreateBook, args...)
+		if err != nil {
+			if errors.Is(err, sql.ErrNoRows) || pg.IsForeignKeyViolationErr(err) {
+				return domain.ErrInvalidCategory
+			}
+			if pg.IsUniqueViolationErr(err) {
+				return fmt.Errorf("%w: a book with ISBN %s exists", domain.ErrAlreadyExists, book.ISBN())
+			}
+			return model.WrapDatabaseError(err, "failed to create book")
+		}
+
+		if err := insertPriceHistory(ctx, tx, created.Id, domain.PriceReasonCreate, 0); err != nil {
+			return err
 		}
-		return model.WrapDatabaseError(err, "failed to create book")
-	}
 
-	return nil
+		return writeAudit(ctx, tx, actor, domain.AuditActionCreate, domain.AuditEntityBook, created.Id, nil, created)
+	})
 }
 
-func (r *BookRepository) Update(ctx context.Context, book domain.Book) error {
-	result, err := r.db.Exec(ctx, "update_book", sqlUpdateBook,
-		book.Id(), book.Title(), book.Author(), book.Year(), book.Price(), book.CategoryId(),
-	)
+func (r *BookRepository) Update(ctx context.Context, book domain.Book, actor domain.AuditActor) error {
+	return r.db.WithTransaction(ctx, func(tx *sqlx.Tx) error {
+		var before, after model.Book
+		if err := tx.GetContext(ctx, &before, sqlLockBook, book.Id()); err != nil {
+			if errors.Is(err, sql.ErrNoRows) {
+				return domain.ErrNotFound
+			}
+			return model.WrapDatabaseError(err, "failed to get book")
+		}
+		// The currency of a book is fixed; other currencies go on its price list.
+		if book.Price().Currency() != before.Currency {
+			return fmt.Errorf("%w: book is priced in %s", domain.ErrCurrencyMismatch, before.Currency)
+		}
 
-	if err != nil {
-		return model.WrapDatabaseError(err, "failed to update book")
-	}
+		args := append([]any{
+			book.Id(), book.Title(), book.Author(), book.Year(), book.Price().Amount(), book.CategoryId(), book.ISBN(),
+		}, metadataArgs(book)...)
+		err := tx.GetContext(ctx, &after, sqlUpdateBook, args...)
+		if err != nil {
+			if errors.Is(err, sql.ErrNoRows) || pg.IsForeignKeyViolationErr(err) {
+				return domain.ErrInvalidCategory
+			}
+			if pg.IsUniqueViolationErr(err) {
+				return fmt.Errorf("%w: a book with ISBN %s exists", domain.ErrAlreadyExists, book.ISBN())
+			}
+			return model.WrapDatabaseError(err, "failed to update book")
+		}
 
-	affected, err := result.RowsAffected()
-	if err != nil {
-		return model.WrapDatabaseError(err, "failed to get affected rows")
-	}
+		if after.Price != before.Price {
+			if err := insertPriceHistory(ctx, tx, book.Id(), domain.PriceReasonUpdate, 0); err != nil {
+				return err
+			}
+		}
+
+		return writeAudit(ctx, tx, actor, domain.AuditActionUpdate, domain.AuditEntityBook, book.Id(), before, after)
+	})
+}
 
-	if affected == 0 {
-		return domain.ErrNotFound
+// metadataArgs are the metadata columns of a book in the order sqlCreateBook and
+// sqlUpdateBook take them, NULL where unknown.
+func metadataArgs(book domain.Book) []any {
+	series, position := book.Series()
+	width, height, depth := book.Dimensions()
+	return []any{
+		toNullString(book.Description()), toNullInt64(book.PageCount()), toNullString(book.Language()),
+		toNullString(book.Publisher()), toNullTime(book.PublicationDate()), toNullString(series), toNullInt64(position),
+		toNullInt64(width), toNullInt64(height), toNullInt64(depth), toNullInt64(book.WeightGrams()),
 	}
+}
+
+// Delete archives the book. It disappears from the catalogue but stays in carts and
+// order history until it is purged.
+func (r *BookRepository) Delete(ctx context.Context, id int, actor domain.AuditActor) error {
+	return r.db.WithTransaction(ctx, func(tx *sqlx.Tx) error {
+		var archived model.Book
+		if err := tx.GetContext(ctx, &archived, sqlArchiveBook, id); err != nil {
+			if errors.Is(err, sql.ErrNoRows) {
+				return domain.ErrNotFound
+			}
+			return model.WrapDatabaseError(err, "failed to archive book")
+		}
+		before := archived
+		before.DeletedAt = sql.NullTime{}
 
-	return nil
+		return writeAudit(ctx, tx, actor, domain.AuditActionArchive, domain.AuditEntityBook, id, before, archived)
+	})
 }
 
-func (r *BookRepository) Delete(ctx context.Context, id int) error {
-	result, err := r.db.Exec(ctx, "delete_book", sqlDeleteBook, id)
+// Restore lists an archived book again. Its category has to be listed as well.
+func (r *BookRepository) Restore(ctx context.Context, id int, actor domain.AuditActor) (domain.Book, error) {
+	var restored model.Book
+	err := r.db.WithTransaction(ctx, func(tx *sqlx.Tx) error {
+		var before model.Book
+		if err := tx.GetContext(ctx, &before, sqlLockArchivedBook, id); err != nil {
+			if errors.Is(err, sql.ErrNoRows) {
+				return domain.ErrNotFound
+			}
+			return model.WrapDatabaseError(err, "failed to get archived book")
+		}
+		if err := tx.GetContext(ctx, &restored, sqlRestoreBook, id); err != nil {
+			if errors.Is(err, sql.ErrNoRows) {
+				return domain.ErrInvalidCategory
+			}
+			return model.WrapDatabaseError(err, "failed to restore book")
+		}
 
+		return writeAudit(ctx, tx, actor, domain.AuditActionRestore, domain.AuditEntityBook, id, before, restored)
+	})
 	if err != nil {
-		return model.WrapDatabaseError(err, "failed to delete book")
+		return domain.Book{}, err
 	}
+	return toDomainBook(restored), nil
+}
 
-	affected, err := result.RowsAffected()
-	if err != nil {
-		return model.WrapDatabaseError(err, "failed to get affected rows")
+func (r *BookRepository) GetArchived(ctx context.Context, limit, offset int) ([]domain.Book, error) {
+	var books []model.Book
+	if err := r.db.Select(ctx, "get_archived_books", &books, sqlGetArchivedBooks, limit, offset); err != nil {
+		return nil, model.WrapDatabaseError(err, "failed to get archived books")
 	}
+	return toDomainBooks(books), nil
+}
 
-	if affected == 0 {
-		return domain.ErrNotFound
+// PurgeArchived permanently deletes the books archived before the given time that have
+// no sales or stock history. The purge is committed when it returns, and the files of the
+// purged books are left for the caller to remove from the blob store.
+func (r *BookRepository) PurgeArchived(ctx context.Context, archivedBefore time.Time) ([]domain.PurgedBook, error) {
+	var books []model.PurgedBook
+	err := r.db.Select(ctx, "purge_archived_books", &books, sqlPurgeBooks,
+		archivedBefore, domain.AuditActionPurge, domain.AuditEntityBook,
+	)
+	if err != nil {
+		return nil, model.WrapDatabaseError(err, "failed to purge archived books")
 	}
-
-	return nil
+	purged := make([]domain.PurgedBook, len(books))
+	for i, book := range books {
+		purged[i] = domain.NewPurgedBook(toDomainBook(book.Book), book.FileKeys)
+	}
+	return purged, nil
 }
@@ -0,0 +1,193 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/jmoiron/sqlx"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"toptal/internal/app/domain"
+	"toptal/internal/pkg/pg"
+)
+
+var bookColumns = []string{"id", "title", "author", "year", "price", "currency", "stock", "category_id", "deleted_at"}
+
+func TestBookRepository_DeleteArchives(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	require.NoError(t, err)
+	defer db.Close()
+
+	repo := NewBookRepository(pg.NewDB(sqlx.NewDb(db, "sqlmock")))
+	archivedAt := time.Date(2025, 3, 1, 12, 0, 0, 0, time.UTC)
+
+	t.Run("Book archived", func(t *testing.T) {
+		mock.ExpectBegin()
+		mock.ExpectQuery("UPDATE books SET deleted_at = now\\(\\) WHERE id = \\$1 AND deleted_at IS NULL RETURNING \\*").
+			WithArgs(1).
+			WillReturnRows(sqlmock.NewRows(bookColumns).AddRow(1, "Dune", "Herbert", 1965, 1000, "USD", 3, 2, archivedAt))
+		mock.ExpectExec("INSERT INTO audit_log").
+			WithArgs(
+				sql.NullInt64{Int64: 7, Valid: true}, sql.NullInt64{}, domain.AuditActionArchive, domain.AuditEntityBook, 1,
+				[]byte(`{"deleted_at":null}`), []byte(`{"deleted_at":"2025-03-01T12:00:00Z"}`),
+				sql.NullString{}, sql.NullString{},
+			).
+			WillReturnResult(sqlmock.NewResult(1, 1))
+		mock.ExpectCommit()
+
+		err := repo.Delete(context.Background(), 1, domain.NewAuditActor(7, 0, "", ""))
+		require.NoError(t, err)
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("Already archived", func(t *testing.T) {
+		mock.ExpectBegin()
+		mock.ExpectQuery("UPDATE books SET deleted_at = now\\(\\)").
+			WithArgs(2).
+			WillReturnError(sql.ErrNoRows)
+		mock.ExpectRollback()
+
+		err := repo.Delete(context.Background(), 2, domain.AuditActor{})
+		assert.ErrorIs(t, err, domain.ErrNotFound)
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+}
+
+func TestBookRepository_Restore(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	require.NoError(t, err)
+	defer db.Close()
+
+	repo := NewBookRepository(pg.NewDB(sqlx.NewDb(db, "sqlmock")))
+	archivedAt := time.Date(2025, 3, 1, 12, 0, 0, 0, time.UTC)
+
+	t.Run("Book restored", func(t *testing.T) {
+		mock.ExpectBegin()
+		mock.ExpectQuery("SELECT \\* FROM books WHERE id = \\$1 AND deleted_at IS NOT NULL FOR UPDATE").
+			WithArgs(1).
+			WillReturnRows(sqlmock.NewRows(bookColumns).AddRow(1, "Dune", "Herbert", 1965, 1000, "USD", 3, 2, archivedAt))
+		mock.ExpectQuery("UPDATE books\\s+SET deleted_at = NULL").
+			WithArgs(1).
+			WillReturnRows(sqlmock.NewRows(bookColumns).AddRow(1, "Dune", "Herbert", 1965, 1000, "USD", 3, 2, nil))
+		mock.ExpectExec("INSERT INTO audit_log").
+			WithArgs(
+				sql.NullInt64{}, sql.NullInt64{}, domain.AuditActionRestore, domain.AuditEntityBook, 1,
+				[]byte(`{"deleted_at":"2025-03-01T12:00:00Z"}`), []byte(`{"deleted_at":null}`),
+				sql.NullString{}, sql.NullString{},
+			).
+			WillReturnResult(sqlmock.NewResult(1, 1))
+		mock.ExpectCommit()
+
+		book, err := repo.Restore(context.Background(), 1, domain.AuditActor{})
+		require.NoError(t, err)
+		assert.Equal(t, "Dune", book.Title())
+		assert.True(t, book.ArchivedAt().IsZero())
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("Category archived", func(t *testing.T) {
+		mock.ExpectBegin()
+		mock.ExpectQuery("SELECT \\* FROM books WHERE id = \\$1 AND deleted_at IS NOT NULL FOR UPDATE").
+			WithArgs(1).
+			WillReturnRows(sqlmock.NewRows(bookColumns).AddRow(1, "Dune", "Herbert", 1965, 1000, "USD", 3, 2, archivedAt))
+		mock.ExpectQuery("UPDATE books\\s+SET deleted_at = NULL").
+			WithArgs(1).
+			WillReturnError(sql.ErrNoRows)
+		mock.ExpectRollback()
+
+		_, err := repo.Restore(context.Background(), 1, domain.AuditActor{})
+		assert.ErrorIs(t, err, domain.ErrInvalidCategory)
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+}
+
+func TestBookRepository_ExportBooks(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	require.NoError(t, err)
+	defer db.Close()
+
+	repo := NewBookRepository(pg.NewDB(sqlx.NewDb(db, "sqlmock")))
+	columns := append(append([]string{}, bookColumns...), "category_name")
+
+	mock.ExpectBegin()
+	mock.ExpectExec("DECLARE book_export NO SCROLL CURSOR FOR").
+		WithArgs(sqlmock.AnyArg(), true, false).
+		WillReturnResult(sqlmock.NewResult(0, 0))
+	mock.ExpectQuery("FETCH 500 FROM book_export").
+		WillReturnRows(sqlmock.NewRows(columns).
+			AddRow(1, "Dune", "Herbert", 1965, 1000, "USD", 3, 2, nil, "Fiction").
+			AddRow(4, "Emma", "Austen", 1815, 450, "EUR", 1, 2, nil, "Fiction"))
+	mock.ExpectQuery("FETCH 500 FROM book_export").
+		WillReturnRows(sqlmock.NewRows(columns))
+	mock.ExpectCommit()
+
+	var exported []domain.ExportedBook
+	filter := domain.BookExportFilter{CategoryIds: []int{2}, InStockOnly: true}
+	err = repo.ExportBooks(context.Background(), filter, func(book domain.ExportedBook) error {
+		exported = append(exported, book)
+		return nil
+	})
+	require.NoError(t, err)
+	require.Len(t, exported, 2)
+	emma := exported[1].Book()
+	assert.Equal(t, "Emma", emma.Title())
+	assert.Equal(t, "EUR", emma.Price().Currency())
+	assert.Equal(t, "Fiction", exported[1].CategoryName())
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestBookRepository_PurgeArchived(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	require.NoError(t, err)
+	defer db.Close()
+
+	repo := NewBookRepository(pg.NewDB(sqlx.NewDb(db, "sqlmock")))
+	archivedBefore := time.Date(2025, 3, 1, 12, 0, 0, 0, time.UTC)
+	columns := append(append([]string{}, bookColumns...), "cover_key", "cover_type", "file_keys")
+
+	mock.ExpectQuery("NOT EXISTS \\(SELECT 1 FROM order_items oi WHERE oi.book_id = b.id\\)").
+		WithArgs(archivedBefore, domain.AuditActionPurge, domain.AuditEntityBook).
+		WillReturnRows(sqlmock.NewRows(columns).
+			AddRow(1, "Dune", "Herbert", 1965, 1000, "USD", 0, 2, archivedBefore, "covers/1/abc", "image/png", "{formats/1/epub}").
+			AddRow(4, "Emma", "Austen", 1815, 450, "EUR", 0, 2, archivedBefore, nil, nil, "{}"))
+
+	purged, err := repo.PurgeArchived(context.Background(), archivedBefore)
+	require.NoError(t, err)
+	require.Len(t, purged, 2)
+	dune := purged[0].Book()
+	cover, ok := dune.Cover()
+	require.True(t, ok)
+	assert.Equal(t, "covers/1/abc", cover.Key())
+	assert.Equal(t, []string{"formats/1/epub"}, purged[0].FileKeys())
+	emma := purged[1].Book()
+	_, ok = emma.Cover()
+	assert.False(t, ok)
+	assert.Empty(t, purged[1].FileKeys())
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestBookRepository_Search(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	require.NoError(t, err)
+	defer db.Close()
+
+	repo := NewBookRepository(pg.NewDB(sqlx.NewDb(db, "sqlmock")))
+
+	mock.ExpectQuery("SELECT \\*\\s+FROM books\\s+WHERE stock > 0").
+		WithArgs(`%100\%\_dune%`, "", 10, 0).
+		WillReturnRows(sqlmock.NewRows(bookColumns))
+	_, err = repo.Search(context.Background(), "100%_dune", 10, 0)
+	require.NoError(t, err)
+
+	mock.ExpectQuery("SELECT \\*\\s+FROM books\\s+WHERE stock > 0").
+		WithArgs("%0-441-17271-7%", "9780441172719", 10, 20).
+		WillReturnRows(sqlmock.NewRows(bookColumns).AddRow(1, "Dune", "Herbert", 1965, 1000, "USD", 3, 2, nil))
+	books, err := repo.Search(context.Background(), "0-441-17271-7", 10, 20)
+	require.NoError(t, err)
+	require.Len(t, books, 1)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
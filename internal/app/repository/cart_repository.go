@@ -17,17 +17,40 @@ import (
 
 const (
 	sqlGetCart = `
-  		SELECT b.id, b.title, b.author, b.year, b.price, b.stock, b.category_id
+  		SELECT b.id, b.title, b.author, b.year, b.price, b.currency, b.stock, b.category_id, b.sale_price, b.sale_ends_at,
+  			f.id AS format_id, f.format, f.price AS format_price, f.currency AS format_currency, f.stock AS format_stock
   		FROM books b
   		JOIN cart_items ci ON b.id = ci.book_id
   		JOIN cart c ON ci.cart_id = c.id
-  		WHERE c.user_id = $1
+  		LEFT JOIN book_formats f ON f.id = ci.format_id
+  		WHERE c.user_id = $1 AND b.deleted_at IS NULL
+	`
+	sqlSelectBookStock = `SELECT stock FROM books WHERE id = $1 AND deleted_at IS NULL FOR UPDATE`
+	// sqlSelectFormatForCart locks a format of a listed book. Its stock is NULL for digital
+	// formats, as is its file key until the file is uploaded.
+	sqlSelectFormatForCart = `
+		SELECT f.id, f.stock, f.file_key
+		FROM book_formats f
+		JOIN books b ON b.id = f.book_id
+		WHERE f.book_id = $1 AND f.format = $2 AND b.deleted_at IS NULL
+		FOR UPDATE OF f
+	`
+	sqlCheckItemInCart    = `SELECT COUNT(1) FROM cart_items WHERE cart_id = $1 AND book_id = $2 AND format_id IS NOT DISTINCT FROM $3`
+	sqlUpdateCartItemTime = `UPDATE cart_items SET updated_at = now() WHERE cart_id = $1 AND book_id = $2 AND format_id IS NOT DISTINCT FROM $3`
+	// sqlInsertCartItem also records the add for the trending chart.
+	sqlInsertCartItem = `
+		WITH item AS (
+			INSERT INTO cart_items (cart_id, book_id, format_id, updated_at) VALUES ($1, $2, $3, now())
+			RETURNING book_id
+		)
+		INSERT INTO book_cart_adds (book_id) SELECT book_id FROM item
+	`
+	// sqlRemoveFromCart removes the book itself when $3 is empty, as no format matches then.
+	sqlRemoveFromCart = `
+		DELETE FROM cart_items WHERE cart_id = $1 AND book_id = $2 AND format_id IS NOT DISTINCT FROM (
+			SELECT id FROM book_formats WHERE book_id = $2 AND format = $3
+		)
 	`
-	sqlSelectBookStock        = `SELECT stock FROM books WHERE id = $1 FOR UPDATE`
-	sqlCheckItemInCart        = `SELECT COUNT(1) FROM cart_items WHERE cart_id = $1 AND book_id = $2`
-	sqlUpdateCartItemTime     = `UPDATE cart_items SET updated_at = now() WHERE cart_id = $1 AND book_id = $2`
-	sqlInsertCartItem         = `INSERT INTO cart_items (cart_id, book_id, updated_at) VALUES ($1, $2, now())`
-	sqlRemoveFromCart         = `DELETE FROM cart_items WHERE cart_id = $1 AND book_id = $2`
 	sqlGetCartByUser          = `SELECT id FROM cart WHERE user_id = $1`
 	sqlInsertCart             = `INSERT INTO cart (user_id, updated_at) VALUES ($1, now()) RETURNING id`
 	sqlUpdateCartTime         = `UPDATE cart SET updated_at = now() WHERE id = $1`
@@ -35,14 +58,110 @@ const (
 	sqlDeleteCart             = `DELETE FROM cart WHERE id = $1`
 	sqlDeleteExpiredCartItems = `DELETE FROM cart_items WHERE cart_id IN (SELECT id FROM cart WHERE updated_at < $1)`
 	sqlDeleteExpiredCarts     = `DELETE FROM cart WHERE updated_at < $1`
-	sqlSelectCartItemsCount   = `SELECT COUNT(*) FROM cart_items WHERE cart_id = $1`
-	sqlUpdateBooksStock       = `
+	// Archived books stay in the cart but are not sold.
+	sqlSelectCartItemsCount = `
+		SELECT COUNT(*)
+		FROM cart_items ci
+		JOIN books b ON b.id = ci.book_id
+		WHERE ci.cart_id = $1 AND b.deleted_at IS NULL
+	`
+	sqlUpdateBooksStock = `
 		UPDATE books
 		SET stock = stock - 1
 		WHERE id IN (
-			SELECT book_id FROM cart_items WHERE cart_id = $1
+			SELECT book_id FROM cart_items WHERE cart_id = $1 AND format_id IS NULL
+		) AND stock > 0 AND deleted_at IS NULL
+	`
+	// sqlUpdateFormatsStock takes the printed formats in the cart from stock. Digital
+	// formats have no stock and are counted by sqlCountDigitalCartItems instead.
+	sqlUpdateFormatsStock = `
+		UPDATE book_formats
+		SET stock = stock - 1
+		WHERE id IN (
+			SELECT ci.format_id
+			FROM cart_items ci
+			JOIN books b ON b.id = ci.book_id
+			WHERE ci.cart_id = $1 AND b.deleted_at IS NULL
 		) AND stock > 0
 	`
+	sqlCountDigitalCartItems = `
+		SELECT COUNT(*)
+		FROM cart_items ci
+		JOIN books b ON b.id = ci.book_id
+		JOIN book_formats f ON f.id = ci.format_id
+		WHERE ci.cart_id = $1 AND b.deleted_at IS NULL AND f.stock IS NULL AND f.file_key IS NOT NULL
+	`
+	sqlSelectCartCurrencies = `
+		SELECT DISTINCT COALESCE(f.currency, b.currency)
+		FROM cart_items ci
+		JOIN books b ON b.id = ci.book_id
+		LEFT JOIN book_formats f ON f.id = ci.format_id
+		WHERE ci.cart_id = $1 AND b.deleted_at IS NULL
+	`
+	// sqlCountUnpricedCartItems counts the books in the cart that have no price in $2.
+	// Formats are only sold in their own currency.
+	sqlCountUnpricedCartItems = `
+		SELECT COUNT(*)
+		FROM cart_items ci
+		JOIN books b ON b.id = ci.book_id
+		LEFT JOIN book_price_list pl ON pl.book_id = b.id AND pl.currency = $2
+		LEFT JOIN book_formats f ON f.id = ci.format_id
+		WHERE ci.cart_id = $1 AND b.deleted_at IS NULL
+			AND CASE WHEN f.id IS NULL THEN b.currency <> $2 AND pl.price IS NULL ELSE f.currency <> $2 END
+	`
+	// sqlSelectCheckoutLines prices the books in the cart in the currency of the order $2.
+	// Orders are charged the sale price while a sale runs, and not once it has ended, even
+	// before the price scheduler clears it. In another currency than the book's own the
+	// price list applies. Formats are charged their own price.
+	sqlSelectCheckoutLines = `
+		SELECT b.id AS book_id, b.title, b.author,
+			CASE
+				WHEN f.id IS NOT NULL THEN f.price
+				WHEN b.currency = $2 AND b.sale_price IS NOT NULL
+					AND (b.sale_ends_at IS NULL OR b.sale_ends_at > now()) THEN b.sale_price
+				WHEN b.currency = $2 THEN b.price
+				ELSE pl.price
+			END AS price,
+			c.tax_class, f.id AS format_id, f.format
+		FROM cart_items ci
+		JOIN books b ON b.id = ci.book_id
+		JOIN categories c ON c.id = b.category_id
+		LEFT JOIN book_price_list pl ON pl.book_id = b.id AND pl.currency = $2
+		LEFT JOIN book_formats f ON f.id = ci.format_id
+		WHERE ci.cart_id = $1 AND b.deleted_at IS NULL
+		ORDER BY b.id, f.id NULLS FIRST
+	`
+	sqlInsertOrder = `
+		INSERT INTO orders (
+			user_id, total, currency, subtotal, tax_total, prices_include_tax,
+			shipping_name, shipping_line1, shipping_line2, shipping_city, shipping_postal_code,
+			shipping_region, shipping_country
+		)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13)
+		RETURNING *
+	`
+	sqlInsertOrderItems = `
+		INSERT INTO order_items (
+			order_id, book_id, title, author, price, currency, tax_class, tax, format_id, format, category_id
+		)
+		VALUES (
+			:order_id, :book_id, :title, :author, :price, :currency, :tax_class, :tax, :format_id, :format,
+			(SELECT category_id FROM books WHERE id = :book_id)
+		)
+	`
+	// sqlInsertDownloads lets the buyer download the digital items of an order. Each
+	// download keeps the file that was sold, whatever becomes of the format later.
+	sqlInsertDownloads = `
+		INSERT INTO downloads (order_item_id, user_id, file_key, file_name, file_type, file_size)
+		SELECT oi.id, $2, f.file_key, f.file_name, f.file_type, f.file_size
+		FROM order_items oi
+		JOIN book_formats f ON f.id = oi.format_id
+		WHERE oi.order_id = $1 AND oi.format IN ('epub', 'pdf')
+	`
+	sqlInsertOrderTaxLines = `
+		INSERT INTO order_tax_lines (order_id, tax_class, rate, net, tax, currency)
+		VALUES (:order_id, :tax_class, :rate, :net, :tax, :currency)
+	`
 )
 
 type CartRepository struct {
@@ -78,25 +197,35 @@ func (r *CartRepository) ensureCart(ctx context.Context, tx *sqlx.Tx, userId int
 	return cartId, nil
 }
 
+// GetCart returns the books in the user's cart. Books in the cart in one of their formats
+// carry the format's price and stock.
 func (r *CartRepository) GetCart(ctx context.Context, userId int) ([]domain.Book, error) {
-	var books []model.Book
+	var books []model.CartBook
 	err := r.db.Select(ctx, "get_cart", &books, sqlGetCart, userId)
 	if err != nil {
 		return nil, model.WrapDatabaseError(err, "failed to get cart")
 	}
-	return toDomainBooks(books), nil
+	return toDomainCartBooks(books)
 }
 
-func (r *CartRepository) AddToCart(ctx context.Context, userId int, bookId int) error {
+// AddToCart puts a book in the user's cart, in the given format or, when format is empty,
+// the book itself.
+func (r *CartRepository) AddToCart(ctx context.Context, userId int, bookId int, format string) error {
 	return r.db.WithTransaction(ctx, func(tx *sqlx.Tx) error {
 		cartId, err := r.ensureCart(ctx, tx, userId)
 		if err != nil {
 			return fmt.Errorf("failed to ensure cart: %w", err)
 		}
-		if err := r.checkBookAvailability(ctx, tx, bookId); err != nil {
+		var formatId sql.NullInt64
+		if format == "" {
+			err = r.checkBookAvailability(ctx, tx, bookId)
+		} else {
+			formatId, err = r.checkFormatAvailability(ctx, tx, bookId, format)
+		}
+		if err != nil {
 			return fmt.Errorf("book not available: %w", err)
 		}
-		if err := r.addOrUpdateCartItem(ctx, tx, cartId, bookId); err != nil {
+		if err := r.addOrUpdateCartItem(ctx, tx, cartId, bookId, formatId); err != nil {
 			return fmt.Errorf("failed to add book to cart: %w", err)
 		}
 		return nil
@@ -120,22 +249,47 @@ func (r *CartRepository) checkBookAvailability(ctx context.Context, tx *sqlx.Tx,
 	return nil
 }
 
-func (r *CartRepository) addOrUpdateCartItem(ctx context.Context, tx *sqlx.Tx, cartId int, bookId int) error {
+// checkFormatAvailability returns the id of a format that can be sold: a printed one in
+// stock or a digital one with a file.
+func (r *CartRepository) checkFormatAvailability(ctx context.Context, tx *sqlx.Tx, bookId int, format string) (sql.NullInt64, error) {
+	var row struct {
+		Id      int64          `db:"id"`
+		Stock   sql.NullInt64  `db:"stock"`
+		FileKey sql.NullString `db:"file_key"`
+	}
+	err := tx.GetContext(ctx, &row, sqlSelectFormatForCart, bookId, format)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return sql.NullInt64{}, fmt.Errorf("%w: no %s edition", domain.ErrBookNotFound, format)
+		}
+		return sql.NullInt64{}, model.WrapDatabaseError(err, "failed to get book format")
+	}
+
+	switch {
+	case row.Stock.Valid && row.Stock.Int64 <= 0:
+		return sql.NullInt64{}, domain.ErrBookOutOfStock
+	case !row.Stock.Valid && !row.FileKey.Valid:
+		return sql.NullInt64{}, domain.ErrFormatFileMissing
+	}
+	return sql.NullInt64{Int64: row.Id, Valid: true}, nil
+}
+
+func (r *CartRepository) addOrUpdateCartItem(ctx context.Context, tx *sqlx.Tx, cartId int, bookId int, formatId sql.NullInt64) error {
 	var count int
-	err := tx.GetContext(ctx, &count, sqlCheckItemInCart, cartId, bookId)
+	err := tx.GetContext(ctx, &count, sqlCheckItemInCart, cartId, bookId, formatId)
 	if err != nil {
 		return model.WrapDatabaseError(err, "failed to check if book already in cart")
 	}
 
 	if count > 0 {
-		_, err = tx.ExecContext(ctx, sqlUpdateCartItemTime, cartId, bookId)
+		_, err = tx.ExecContext(ctx, sqlUpdateCartItemTime, cartId, bookId, formatId)
 		if err != nil {
 			return model.WrapDatabaseError(err, "failed to update cart item timestamp")
 		}
 		return nil
 	}
 
-	_, err = tx.ExecContext(ctx, sqlInsertCartItem, cartId, bookId)
+	_, err = tx.ExecContext(ctx, sqlInsertCartItem, cartId, bookId, formatId)
 	if err != nil {
 		return model.WrapDatabaseError(err, "failed to add book to cart")
 	}
@@ -143,13 +297,15 @@ func (r *CartRepository) addOrUpdateCartItem(ctx context.Context, tx *sqlx.Tx, c
 	return nil
 }
 
-func (r *CartRepository) RemoveFromCart(ctx context.Context, userId int, bookId int) error {
+// RemoveFromCart takes a book out of the user's cart, in the given format or, when format
+// is empty, the book itself.
+func (r *CartRepository) RemoveFromCart(ctx context.Context, userId int, bookId int, format string) error {
 	cartId, err := r.getCartId(ctx, userId)
 	if err != nil {
 		return fmt.Errorf("failed to get user cart: %w", err)
 	}
 
-	result, err := r.db.Exec(ctx, "remove_from_cart", sqlRemoveFromCart, cartId, bookId)
+	result, err := r.db.Exec(ctx, "remove_from_cart", sqlRemoveFromCart, cartId, bookId, format)
 	if err != nil {
 		return model.WrapDatabaseError(err, "failed to remove book from cart")
 	}
@@ -177,8 +333,16 @@ func (r *CartRepository) getCartId(ctx context.Context, userId int) (int, error)
 	return cartId, nil
 }
 
-func (r *CartRepository) Purchase(ctx context.Context, userId int) error {
-	return r.db.WithTransaction(ctx, func(tx *sqlx.Tx) error {
+// Purchase orders the cart in the given currency and ships it to address. Without a
+// currency the cart is ordered in the currency its books are priced in, which fails when
+// they are priced in several. taxOrder works out the tax on the items before the order is
+// stored. Digital items can be downloaded by the user once the order is placed.
+func (r *CartRepository) Purchase(
+	ctx context.Context, userId int, currency string, address domain.ShippingAddress,
+	taxOrder func([]domain.OrderItem) (domain.OrderTax, error),
+) (domain.Order, error) {
+	var order domain.Order
+	err := r.db.WithTransaction(ctx, func(tx *sqlx.Tx) error {
 		cartId, err := r.ensureCart(ctx, tx, userId)
 		if err != nil {
 			return fmt.Errorf("failed to ensure cart: %w", err)
@@ -192,18 +356,57 @@ func (r *CartRepository) Purchase(ctx context.Context, userId int) error {
 			return domain.ErrCartEmpty
 		}
 
-		result, err := tx.ExecContext(ctx, sqlUpdateBooksStock, cartId)
+		currency, err := r.resolveCurrency(ctx, tx, cartId, currency)
 		if err != nil {
-			return model.WrapDatabaseError(err, "failed to update book stock")
+			return err
 		}
-		rows, err := result.RowsAffected()
+
+		available, err := r.takeFromStock(ctx, tx, cartId)
 		if err != nil {
-			return model.WrapDatabaseError(err, "failed to get affected rows")
+			return err
 		}
-		if rows != totalItems {
+		if available != totalItems {
 			return domain.ErrBookOutOfStock
 		}
 
+		var lines []model.CheckoutLine
+		if err := tx.SelectContext(ctx, &lines, sqlSelectCheckoutLines, cartId, currency); err != nil {
+			return model.WrapDatabaseError(err, "failed to price cart items")
+		}
+		items, err := toDomainCheckoutItems(lines, currency)
+		if err != nil {
+			return err
+		}
+		tax, err := taxOrder(items)
+		if err != nil {
+			return fmt.Errorf("failed to tax order: %w", err)
+		}
+
+		var created model.Order
+		err = tx.GetContext(ctx, &created, sqlInsertOrder,
+			userId, tax.Total().Amount(), currency, tax.Subtotal().Amount(), tax.TaxTotal().Amount(),
+			tax.PricesIncludeTax(),
+			toNullString(address.Name()), toNullString(address.Line1()), toNullString(address.Line2()),
+			toNullString(address.City()), toNullString(address.PostalCode()), toNullString(address.Region()),
+			toNullString(address.Country()),
+		)
+		if err != nil {
+			return model.WrapDatabaseError(err, "failed to create order")
+		}
+		if _, err := tx.NamedExecContext(ctx, sqlInsertOrderItems, toModelOrderItems(created.Id, tax.Items())); err != nil {
+			return model.WrapDatabaseError(err, "failed to create order items")
+		}
+		if len(tax.Lines()) > 0 {
+			if _, err := tx.NamedExecContext(ctx, sqlInsertOrderTaxLines, toModelOrderTaxLines(created.Id, tax.Lines())); err != nil {
+				return model.WrapDatabaseError(err, "failed to create order tax lines")
+			}
+		}
+		if hasDigitalItems(tax.Items()) {
+			if _, err := tx.ExecContext(ctx, sqlInsertDownloads, created.Id, userId); err != nil {
+				return model.WrapDatabaseError(err, "failed to create downloads")
+			}
+		}
+
 		// clear cart
 		if _, err := tx.ExecContext(ctx, sqlClearCartItems, cartId); err != nil {
 			return model.WrapDatabaseError(err, "failed to clear cart items")
@@ -212,9 +415,76 @@ func (r *CartRepository) Purchase(ctx context.Context, userId int) error {
 			return model.WrapDatabaseError(err, fmt.Sprintf("failed to delete cart %d", cartId))
 		}
 
-		slog.Info("Purchase completed", "user_id", userId, "books_count", totalItems)
+		order, err = domain.NewOrder(created.Id, userId, tax.Total(), created.CreatedAt, tax.Items())
+		if err != nil {
+			return err
+		}
+		_ = order.SetTax(tax.Subtotal(), tax.TaxTotal(), tax.PricesIncludeTax(), tax.Lines())
+		_ = order.SetShippingAddress(address)
+
+		slog.Info("Purchase completed", "user_id", userId, "order_id", created.Id, "books_count", totalItems)
 		return nil
 	})
+	if err != nil {
+		return domain.Order{}, err
+	}
+	return order, nil
+}
+
+// takeFromStock takes the printed books and formats in the cart from stock and returns how
+// many items of the cart can be sold, counting digital formats that have a file.
+func (r *CartRepository) takeFromStock(ctx context.Context, tx *sqlx.Tx, cartId int) (int64, error) {
+	var available int64
+	for _, query := range []string{sqlUpdateBooksStock, sqlUpdateFormatsStock} {
+		result, err := tx.ExecContext(ctx, query, cartId)
+		if err != nil {
+			return 0, model.WrapDatabaseError(err, "failed to update book stock")
+		}
+		rows, err := result.RowsAffected()
+		if err != nil {
+			return 0, model.WrapDatabaseError(err, "failed to get affected rows")
+		}
+		available += rows
+	}
+
+	var digital int64
+	if err := tx.GetContext(ctx, &digital, sqlCountDigitalCartItems, cartId); err != nil {
+		return 0, model.WrapDatabaseError(err, "failed to count digital cart items")
+	}
+	return available + digital, nil
+}
+
+func hasDigitalItems(items []domain.OrderItem) bool {
+	for _, item := range items {
+		if domain.IsDigitalFormat(item.Format()) {
+			return true
+		}
+	}
+	return false
+}
+
+// resolveCurrency picks the currency of the order and checks that every book in the cart
+// has a price in it.
+func (r *CartRepository) resolveCurrency(ctx context.Context, tx *sqlx.Tx, cartId int, currency string) (string, error) {
+	if currency == "" {
+		var currencies []string
+		if err := tx.SelectContext(ctx, &currencies, sqlSelectCartCurrencies, cartId); err != nil {
+			return "", model.WrapDatabaseError(err, "failed to get cart currencies")
+		}
+		if len(currencies) != 1 {
+			return "", fmt.Errorf("%w: cart has books priced in %v, choose a currency", domain.ErrPriceUnavailable, currencies)
+		}
+		return currencies[0], nil
+	}
+
+	var unpriced int
+	if err := tx.GetContext(ctx, &unpriced, sqlCountUnpricedCartItems, cartId, currency); err != nil {
+		return "", model.WrapDatabaseError(err, "failed to check cart prices")
+	}
+	if unpriced > 0 {
+		return "", fmt.Errorf("%w: %d books in the cart have no price in %s", domain.ErrPriceUnavailable, unpriced, currency)
+	}
+	return currency, nil
 }
 
 func (r *CartRepository) CleanExpiredCarts(ctx context.Context) error {
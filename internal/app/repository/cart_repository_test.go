@@ -11,6 +11,7 @@ import (
 	"github.com/stretchr/testify/require"
 
 	"toptal/internal/app/config"
+	"toptal/internal/app/domain"
 	"toptal/internal/pkg/pg"
 )
 
@@ -34,11 +35,11 @@ func TestCartRepository_GetCart(t *testing.T) {
 	repo, mock := setupCartTest(t)
 
 	t.Run("Success", func(t *testing.T) {
-		rows := sqlmock.NewRows([]string{"id", "title", "author", "year", "price", "stock", "category_id"}).
-			AddRow(1, "Book 1", "Author 1", 2020, 1000, 5, 1).
-			AddRow(2, "Book 2", "Author 2", 2021, 2000, 3, 2)
+		rows := sqlmock.NewRows([]string{"id", "title", "author", "year", "price", "currency", "stock", "category_id"}).
+			AddRow(1, "Book 1", "Author 1", 2020, 1000, "USD", 5, 1).
+			AddRow(2, "Book 2", "Author 2", 2021, 2000, "EUR", 3, 2)
 
-		mock.ExpectQuery(`SELECT b\.id, b\.title, b\.author, b\.year, b\.price, b\.stock, b\.category_id`).
+		mock.ExpectQuery(`SELECT b\.id, b\.title, b\.author, b\.year, b\.price, b\.currency, b\.stock, b\.category_id`).
 			WithArgs(1).
 			WillReturnRows(rows)
 
@@ -50,9 +51,9 @@ func TestCartRepository_GetCart(t *testing.T) {
 	})
 
 	t.Run("Empty cart", func(t *testing.T) {
-		mock.ExpectQuery(`SELECT b\.id, b\.title, b\.author, b\.year, b\.price, b\.stock, b\.category_id`).
+		mock.ExpectQuery(`SELECT b\.id, b\.title, b\.author, b\.year, b\.price, b\.currency, b\.stock, b\.category_id`).
 			WithArgs(1).
-			WillReturnRows(sqlmock.NewRows([]string{"id", "title", "author", "year", "price", "stock", "category_id"}))
+			WillReturnRows(sqlmock.NewRows([]string{"id", "title", "author", "year", "price", "currency", "stock", "category_id"}))
 
 		books, err := repo.GetCart(context.Background(), 1)
 		assert.NoError(t, err)
@@ -79,15 +80,15 @@ func TestCartRepository_AddToCart(t *testing.T) {
 			WillReturnRows(sqlmock.NewRows([]string{"stock"}).AddRow(5))
 		// Check if book already in cart_items
 		mock.ExpectQuery(`SELECT COUNT\(1\) FROM cart_items WHERE cart_id = \$1 AND book_id = \$2`).
-			WithArgs(1, 1).
+			WithArgs(1, 1, nil).
 			WillReturnRows(sqlmock.NewRows([]string{"count"}).AddRow(0))
 		// Insert into cart_items for new item
 		mock.ExpectExec(`INSERT INTO cart_items`).
-			WithArgs(1, 1).
+			WithArgs(1, 1, nil).
 			WillReturnResult(sqlmock.NewResult(1, 1))
 		mock.ExpectCommit()
 
-		err := repo.AddToCart(context.Background(), 1, 1)
+		err := repo.AddToCart(context.Background(), 1, 1, "")
 		assert.NoError(t, err)
 	})
 
@@ -104,7 +105,7 @@ func TestCartRepository_AddToCart(t *testing.T) {
 			WillReturnRows(sqlmock.NewRows([]string{"stock"}).AddRow(0))
 		mock.ExpectRollback()
 
-		err := repo.AddToCart(context.Background(), 1, 1)
+		err := repo.AddToCart(context.Background(), 1, 1, "")
 		assert.Error(t, err)
 	})
 
@@ -120,17 +121,58 @@ func TestCartRepository_AddToCart(t *testing.T) {
 			WithArgs(1).
 			WillReturnRows(sqlmock.NewRows([]string{"stock"}).AddRow(5))
 		mock.ExpectQuery(`SELECT COUNT\(1\) FROM cart_items WHERE cart_id = \$1 AND book_id = \$2`).
-			WithArgs(1, 1).
+			WithArgs(1, 1, nil).
 			WillReturnRows(sqlmock.NewRows([]string{"count"}).AddRow(1))
 		// Update the existing cart item timestamp
 		mock.ExpectExec(`UPDATE cart_items SET updated_at = now\(\) WHERE cart_id = \$1 AND book_id = \$2`).
-			WithArgs(1, 1).
+			WithArgs(1, 1, nil).
 			WillReturnResult(sqlmock.NewResult(1, 1))
 		mock.ExpectCommit()
 
-		err := repo.AddToCart(context.Background(), 1, 1)
+		err := repo.AddToCart(context.Background(), 1, 1, "")
 		assert.NoError(t, err)
 	})
+
+	t.Run("Digital format without a file", func(t *testing.T) {
+		mock.ExpectBegin()
+		mock.ExpectQuery(`SELECT id FROM cart WHERE user_id = \$1`).
+			WithArgs(1).
+			WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow(1))
+		mock.ExpectExec(`UPDATE cart SET updated_at = now\(\) WHERE id = \$1`).
+			WithArgs(1).
+			WillReturnResult(sqlmock.NewResult(1, 1))
+		mock.ExpectQuery(`SELECT f\.id, f\.stock, f\.file_key\s+FROM book_formats f`).
+			WithArgs(1, "epub").
+			WillReturnRows(sqlmock.NewRows([]string{"id", "stock", "file_key"}).AddRow(3, nil, nil))
+		mock.ExpectRollback()
+
+		err := repo.AddToCart(context.Background(), 1, 1, "epub")
+		assert.ErrorIs(t, err, domain.ErrFormatFileMissing)
+	})
+
+	t.Run("Success - digital format", func(t *testing.T) {
+		mock.ExpectBegin()
+		mock.ExpectQuery(`SELECT id FROM cart WHERE user_id = \$1`).
+			WithArgs(1).
+			WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow(1))
+		mock.ExpectExec(`UPDATE cart SET updated_at = now\(\) WHERE id = \$1`).
+			WithArgs(1).
+			WillReturnResult(sqlmock.NewResult(1, 1))
+		mock.ExpectQuery(`SELECT f\.id, f\.stock, f\.file_key\s+FROM book_formats f`).
+			WithArgs(1, "epub").
+			WillReturnRows(sqlmock.NewRows([]string{"id", "stock", "file_key"}).AddRow(3, nil, "books/1/epub/a.epub"))
+		mock.ExpectQuery(`SELECT COUNT\(1\) FROM cart_items WHERE cart_id = \$1 AND book_id = \$2`).
+			WithArgs(1, 1, int64(3)).
+			WillReturnRows(sqlmock.NewRows([]string{"count"}).AddRow(0))
+		mock.ExpectExec(`INSERT INTO cart_items`).
+			WithArgs(1, 1, int64(3)).
+			WillReturnResult(sqlmock.NewResult(1, 1))
+		mock.ExpectCommit()
+
+		err := repo.AddToCart(context.Background(), 1, 1, "epub")
+		assert.NoError(t, err)
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
 }
 
 func TestCartRepository_RemoveFromCart(t *testing.T) {
@@ -142,10 +184,10 @@ func TestCartRepository_RemoveFromCart(t *testing.T) {
 			WithArgs(1).
 			WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow(1))
 		mock.ExpectExec(`DELETE FROM cart_items WHERE cart_id = \$1 AND book_id = \$2`).
-			WithArgs(1, 1).
+			WithArgs(1, 1, "").
 			WillReturnResult(sqlmock.NewResult(1, 1))
 
-		err := repo.RemoveFromCart(context.Background(), 1, 1)
+		err := repo.RemoveFromCart(context.Background(), 1, 1, "")
 		assert.NoError(t, err)
 	})
 
@@ -154,10 +196,10 @@ func TestCartRepository_RemoveFromCart(t *testing.T) {
 			WithArgs(1).
 			WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow(1))
 		mock.ExpectExec(`DELETE FROM cart_items WHERE cart_id = \$1 AND book_id = \$2`).
-			WithArgs(1, 1).
+			WithArgs(1, 1, "").
 			WillReturnResult(sqlmock.NewResult(0, 0))
 
-		err := repo.RemoveFromCart(context.Background(), 1, 1)
+		err := repo.RemoveFromCart(context.Background(), 1, 1, "")
 		assert.Error(t, err)
 	})
 }
@@ -173,11 +215,39 @@ func TestCartRepository_Purchase(t *testing.T) {
 		mock.ExpectExec(`UPDATE cart SET updated_at = now\(\) WHERE id = \$1`).
 			WithArgs(1).
 			WillReturnResult(sqlmock.NewResult(1, 1))
-		mock.ExpectQuery(`SELECT COUNT\(\*\) FROM cart_items WHERE cart_id = \$1`).
+		mock.ExpectQuery(`SELECT COUNT\(\*\)\s+FROM cart_items ci\s+JOIN books b ON b\.id = ci\.book_id\s+WHERE ci\.cart_id = \$1 AND b\.deleted_at IS NULL`).
 			WithArgs(1).
 			WillReturnRows(sqlmock.NewRows([]string{"count"}).AddRow(2))
-		mock.ExpectExec(`UPDATE books\s+SET stock = stock - 1\s+WHERE id IN \(\s*SELECT book_id FROM cart_items WHERE cart_id = \$1\s*\) AND stock > 0`).
+		mock.ExpectQuery(`SELECT DISTINCT COALESCE\(f\.currency, b\.currency\)`).
+			WithArgs(1).
+			WillReturnRows(sqlmock.NewRows([]string{"currency"}).AddRow("USD"))
+		mock.ExpectExec(`UPDATE books\s+SET stock = stock - 1\s+WHERE id IN \(\s*SELECT book_id FROM cart_items WHERE cart_id = \$1 AND format_id IS NULL\s*\) AND stock > 0`).
+			WithArgs(1).
+			WillReturnResult(sqlmock.NewResult(0, 2))
+		mock.ExpectExec(`UPDATE book_formats\s+SET stock = stock - 1`).
 			WithArgs(1).
+			WillReturnResult(sqlmock.NewResult(0, 0))
+		mock.ExpectQuery(`f\.stock IS NULL AND f\.file_key IS NOT NULL`).
+			WithArgs(1).
+			WillReturnRows(sqlmock.NewRows([]string{"count"}).AddRow(0))
+		mock.ExpectQuery(`SELECT b\.id AS book_id, b\.title, b\.author,`).
+			WithArgs(1, "USD").
+			WillReturnRows(sqlmock.NewRows([]string{"book_id", "title", "author", "price", "tax_class"}).
+				AddRow(1, "Book 1", "Author 1", 1000, "reduced").
+				AddRow(2, "Book 2", "Author 2", 2000, "standard"))
+		mock.ExpectQuery(`INSERT INTO orders \(`).
+			WithArgs(1, int64(3270), "USD", int64(3000), int64(270), false,
+				"Jane Doe", "1 Main St", nil, "Berlin", "10115", nil, "DE").
+			WillReturnRows(sqlmock.NewRows([]string{"id", "user_id", "total", "currency", "created_at"}).
+				AddRow(10, 1, 3270, "USD", time.Now()))
+		mock.ExpectExec(`INSERT INTO order_items \(\s*order_id, book_id, title, author, price, currency, tax_class, tax, format_id, format, category_id\s*\)`).
+			WithArgs(
+				10, int64(1), "Book 1", "Author 1", int64(1000), "USD", "reduced", int64(70), nil, nil, int64(1),
+				10, int64(2), "Book 2", "Author 2", int64(2000), "USD", "standard", int64(200), nil, nil, int64(2),
+			).
+			WillReturnResult(sqlmock.NewResult(0, 2))
+		mock.ExpectExec(`INSERT INTO order_tax_lines \(order_id, tax_class, rate, net, tax, currency\)`).
+			WithArgs(10, "reduced", 700, int64(1000), int64(70), "USD", 10, "standard", 1000, int64(2000), int64(200), "USD").
 			WillReturnResult(sqlmock.NewResult(0, 2))
 		mock.ExpectExec(`DELETE FROM cart_items WHERE cart_id = \$1`).
 			WithArgs(1).
@@ -187,8 +257,15 @@ func TestCartRepository_Purchase(t *testing.T) {
 			WillReturnResult(sqlmock.NewResult(1, 1))
 		mock.ExpectCommit()
 
-		err := repo.Purchase(context.Background(), 1)
-		assert.NoError(t, err)
+		order, err := repo.Purchase(context.Background(), 1, "", shippingAddress(t), taxAtTenAndSevenPercent(t))
+		require.NoError(t, err)
+		assert.Equal(t, 10, order.Id())
+		assert.Equal(t, int64(3270), order.Total().Amount())
+		assert.Equal(t, int64(270), order.TaxTotal().Amount())
+		assert.Len(t, order.TaxLines(), 2)
+		shipping := order.ShippingAddress()
+		assert.Equal(t, "DE", shipping.TaxRegion())
+		assert.NoError(t, mock.ExpectationsWereMet())
 	})
 
 	t.Run("Empty cart", func(t *testing.T) {
@@ -199,12 +276,12 @@ func TestCartRepository_Purchase(t *testing.T) {
 		mock.ExpectExec(`UPDATE cart SET updated_at = now\(\) WHERE id = \$1`).
 			WithArgs(1).
 			WillReturnResult(sqlmock.NewResult(1, 1))
-		mock.ExpectQuery(`SELECT COUNT\(\*\) FROM cart_items WHERE cart_id = \$1`).
+		mock.ExpectQuery(`SELECT COUNT\(\*\)\s+FROM cart_items ci\s+JOIN books b ON b\.id = ci\.book_id\s+WHERE ci\.cart_id = \$1 AND b\.deleted_at IS NULL`).
 			WithArgs(1).
 			WillReturnRows(sqlmock.NewRows([]string{"count"}))
 		mock.ExpectRollback()
 
-		err := repo.Purchase(context.Background(), 1)
+		_, err := repo.Purchase(context.Background(), 1, "", shippingAddress(t), taxAtTenAndSevenPercent(t))
 		assert.Error(t, err)
 	})
 
@@ -216,17 +293,75 @@ func TestCartRepository_Purchase(t *testing.T) {
 		mock.ExpectExec(`UPDATE cart SET updated_at = now\(\) WHERE id = \$1`).
 			WithArgs(1).
 			WillReturnResult(sqlmock.NewResult(1, 1))
-		mock.ExpectQuery(`SELECT COUNT\(\*\) FROM cart_items WHERE cart_id = \$1`).
+		mock.ExpectQuery(`SELECT COUNT\(\*\)\s+FROM cart_items ci\s+JOIN books b ON b\.id = ci\.book_id\s+WHERE ci\.cart_id = \$1 AND b\.deleted_at IS NULL`).
 			WithArgs(1).
 			WillReturnRows(sqlmock.NewRows([]string{"count"}).AddRow(1))
-		mock.ExpectExec(`UPDATE books\s+SET stock = stock - 1\s+WHERE id IN \(\s*SELECT book_id FROM cart_items WHERE cart_id = \$1\s*\) AND stock > 0`).
+		mock.ExpectQuery(`SELECT DISTINCT COALESCE\(f\.currency, b\.currency\)`).
+			WithArgs(1).
+			WillReturnRows(sqlmock.NewRows([]string{"currency"}).AddRow("USD"))
+		mock.ExpectExec(`UPDATE books\s+SET stock = stock - 1\s+WHERE id IN \(\s*SELECT book_id FROM cart_items WHERE cart_id = \$1 AND format_id IS NULL\s*\) AND stock > 0`).
+			WithArgs(1).
+			WillReturnResult(sqlmock.NewResult(0, 0))
+		mock.ExpectExec(`UPDATE book_formats\s+SET stock = stock - 1`).
 			WithArgs(1).
 			WillReturnResult(sqlmock.NewResult(0, 0))
+		mock.ExpectQuery(`f\.stock IS NULL AND f\.file_key IS NOT NULL`).
+			WithArgs(1).
+			WillReturnRows(sqlmock.NewRows([]string{"count"}).AddRow(0))
 		mock.ExpectRollback()
 
-		err := repo.Purchase(context.Background(), 1)
+		_, err := repo.Purchase(context.Background(), 1, "", shippingAddress(t), taxAtTenAndSevenPercent(t))
 		assert.Error(t, err)
 	})
+
+	t.Run("Book without a price in the currency", func(t *testing.T) {
+		mock.ExpectBegin()
+		mock.ExpectQuery(`SELECT id FROM cart WHERE user_id = \$1`).
+			WithArgs(1).
+			WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow(1))
+		mock.ExpectExec(`UPDATE cart SET updated_at = now\(\) WHERE id = \$1`).
+			WithArgs(1).
+			WillReturnResult(sqlmock.NewResult(1, 1))
+		mock.ExpectQuery(`SELECT COUNT\(\*\)\s+FROM cart_items ci\s+JOIN books b ON b\.id = ci\.book_id\s+WHERE ci\.cart_id = \$1 AND b\.deleted_at IS NULL`).
+			WithArgs(1).
+			WillReturnRows(sqlmock.NewRows([]string{"count"}).AddRow(2))
+		mock.ExpectQuery(`LEFT JOIN book_price_list pl ON pl\.book_id = b\.id AND pl\.currency = \$2`).
+			WithArgs(1, "EUR").
+			WillReturnRows(sqlmock.NewRows([]string{"count"}).AddRow(1))
+		mock.ExpectRollback()
+
+		_, err := repo.Purchase(context.Background(), 1, "EUR", shippingAddress(t), taxAtTenAndSevenPercent(t))
+		assert.ErrorIs(t, err, domain.ErrPriceUnavailable)
+	})
+}
+
+func shippingAddress(t *testing.T) domain.ShippingAddress {
+	address, err := domain.NewShippingAddress("Jane Doe", "1 Main St", "", "Berlin", "10115", "", "DE")
+	require.NoError(t, err)
+	return address
+}
+
+// taxAtTenAndSevenPercent adds 7% to reduced items and 10% to all others.
+func taxAtTenAndSevenPercent(t *testing.T) func([]domain.OrderItem) (domain.OrderTax, error) {
+	return func(items []domain.OrderItem) (domain.OrderTax, error) {
+		var subtotal, taxTotal int64
+		var lines []domain.OrderTaxLine
+		for i := range items {
+			rate := int64(1000)
+			if items[i].TaxClass() == "reduced" {
+				rate = 700
+			}
+			net := items[i].Price()
+			tax := usd(net.Amount() * rate / 10000)
+			require.NoError(t, items[i].SetTax(tax))
+			line, err := domain.NewOrderTaxLine(items[i].TaxClass(), int(rate), net, tax)
+			require.NoError(t, err)
+			lines = append(lines, line)
+			subtotal += net.Amount()
+			taxTotal += tax.Amount()
+		}
+		return domain.NewOrderTax(items, lines, usd(subtotal), usd(taxTotal), usd(subtotal+taxTotal), false)
+	}
 }
 
 func TestCartRepository_CleanExpiredCarts(t *testing.T) {
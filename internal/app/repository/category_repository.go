@@ -2,19 +2,44 @@ package repository
 
 import (
 	"context"
+	"database/sql"
+	"errors"
 	"fmt"
 	"log/slog"
+	"time"
 	"toptal/internal/app/domain"
 	"toptal/internal/app/repository/model"
 	"toptal/internal/pkg/pg"
+
+	"github.com/jmoiron/sqlx"
 )
 
 const (
-	sqlFindCategoryById = `SELECT * FROM categories WHERE id = $1`
-	sqlFindCategories   = `SELECT * FROM categories`
-	sqlInsertCategory   = `INSERT INTO categories (name) VALUES ($1)`
-	sqlUpdateCategory   = `UPDATE categories SET name = $1 WHERE id = $2`
-	sqlDeleteCategory   = `DELETE FROM categories WHERE id = $1`
+	sqlFindCategoryById = `SELECT * FROM categories WHERE id = $1 AND deleted_at IS NULL`
+	sqlFindCategories   = `SELECT * FROM categories WHERE deleted_at IS NULL`
+	sqlInsertCategory   = `INSERT INTO categories (name, tax_class) VALUES ($1, $2) RETURNING *`
+	sqlLockCategory     = `SELECT * FROM categories WHERE id = $1 AND deleted_at IS NULL FOR UPDATE`
+	// sqlUpdateCategory keeps the tax class when none is given.
+	sqlUpdateCategory = `
+		UPDATE categories SET name = $1, tax_class = COALESCE(NULLIF($3, ''), tax_class) WHERE id = $2 RETURNING *
+	`
+	sqlCountListedBooks       = `SELECT COUNT(*) FROM books WHERE category_id = $1 AND deleted_at IS NULL`
+	sqlArchiveCategory        = `UPDATE categories SET deleted_at = now() WHERE id = $1 RETURNING *`
+	sqlLockArchivedCategory   = `SELECT * FROM categories WHERE id = $1 AND deleted_at IS NOT NULL FOR UPDATE`
+	sqlRestoreCategory        = `UPDATE categories SET deleted_at = NULL WHERE id = $1 RETURNING *`
+	sqlFindArchivedCategories = `SELECT * FROM categories WHERE deleted_at IS NOT NULL ORDER BY deleted_at DESC, id`
+	// sqlPurgeCategories removes categories archived before $1 that no book refers to
+	// any more, recording each one in the audit log.
+	sqlPurgeCategories = `
+		WITH purged AS (
+			DELETE FROM categories c
+			WHERE c.deleted_at < $1 AND NOT EXISTS (SELECT 1 FROM books b WHERE b.category_id = c.id)
+			RETURNING c.*
+		)
+		INSERT INTO audit_log (action, entity_type, entity_id, before)
+		SELECT $2::varchar, $3::varchar, purged.id, to_jsonb(purged)
+		FROM purged
+	`
 )
 
 type CategoryRepository struct {
@@ -30,6 +55,9 @@ func (r *CategoryRepository) FindCategoryById(ctx context.Context, id int) (doma
 	row := r.db.QueryRow(ctx, "find_category_by_id", sqlFindCategoryById, id)
 	err := row.StructScan(&category)
 	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return domain.Category{}, domain.ErrNotFound
+		}
 		return domain.Category{}, fmt.Errorf("failed to find category by id: %w", err)
 	}
 	return toDomainCategory(category)
@@ -44,44 +72,113 @@ func (r *CategoryRepository) FindCategories(ctx context.Context) ([]domain.Categ
 	return toDomainCategories(categories)
 }
 
-func (r *CategoryRepository) InsertCategory(ctx context.Context, category domain.Category) error {
-	result, err := r.db.Exec(ctx, "insert_category", sqlInsertCategory, category.Name())
-	if err != nil {
-		if pg.IsUniqueViolationErr(err) {
-			return domain.ErrAlreadyExists
+func (r *CategoryRepository) InsertCategory(ctx context.Context, category domain.Category, actor domain.AuditActor) error {
+	return r.db.WithTransaction(ctx, func(tx *sqlx.Tx) error {
+		var created model.Category
+		if err := tx.GetContext(ctx, &created, sqlInsertCategory, category.Name(), category.TaxClass()); err != nil {
+			if pg.IsUniqueViolationErr(err) {
+				return domain.ErrAlreadyExists
+			}
+			return fmt.Errorf("failed to insert category: %w", err)
 		}
-		return fmt.Errorf("failed to insert category: %w", err)
-	}
-	affect, err := result.RowsAffected()
-	if err != nil {
-		return fmt.Errorf("failed to get affected rows: %w", err)
-	}
-	slog.Info("CategoryRepository.InsertCategory", "affect", affect)
-	return nil
+		slog.Info("CategoryRepository.InsertCategory", "id", created.Id)
+
+		return writeAudit(ctx, tx, actor, domain.AuditActionCreate, domain.AuditEntityCategory, created.Id, nil, created)
+	})
+}
+
+func (r *CategoryRepository) UpdateCategory(ctx context.Context, category domain.Category, actor domain.AuditActor) error {
+	return r.db.WithTransaction(ctx, func(tx *sqlx.Tx) error {
+		var before, after model.Category
+		if err := tx.GetContext(ctx, &before, sqlLockCategory, category.Id()); err != nil {
+			if errors.Is(err, sql.ErrNoRows) {
+				return domain.ErrNotFound
+			}
+			return fmt.Errorf("failed to get category: %w", err)
+		}
+		if err := tx.GetContext(ctx, &after, sqlUpdateCategory, category.Name(), category.Id(), category.TaxClass()); err != nil {
+			if pg.IsUniqueViolationErr(err) {
+				return domain.ErrAlreadyExists
+			}
+			return fmt.Errorf("failed to update category: %w", err)
+		}
+		slog.Info("CategoryRepository.UpdateCategory", "id", category.Id())
+
+		return writeAudit(ctx, tx, actor, domain.AuditActionUpdate, domain.AuditEntityCategory, category.Id(), before, after)
+	})
+}
+
+// DeleteCategory archives the category. Categories that still list books are refused
+// so no listed book points to a hidden category.
+func (r *CategoryRepository) DeleteCategory(ctx context.Context, id int, actor domain.AuditActor) error {
+	return r.db.WithTransaction(ctx, func(tx *sqlx.Tx) error {
+		var before, archived model.Category
+		if err := tx.GetContext(ctx, &before, sqlLockCategory, id); err != nil {
+			if errors.Is(err, sql.ErrNoRows) {
+				return domain.ErrNotFound
+			}
+			return fmt.Errorf("failed to get category: %w", err)
+		}
+		var books int
+		if err := tx.GetContext(ctx, &books, sqlCountListedBooks, id); err != nil {
+			return fmt.Errorf("failed to count category books: %w", err)
+		}
+		if books > 0 {
+			return domain.ErrCategoryInUse
+		}
+		if err := tx.GetContext(ctx, &archived, sqlArchiveCategory, id); err != nil {
+			return fmt.Errorf("failed to archive category: %w", err)
+		}
+		slog.Info("CategoryRepository.DeleteCategory", "id", id)
+
+		return writeAudit(ctx, tx, actor, domain.AuditActionArchive, domain.AuditEntityCategory, id, before, archived)
+	})
 }
 
-func (r *CategoryRepository) UpdateCategory(ctx context.Context, category domain.Category) error {
-	result, err := r.db.Exec(ctx, "update_category", sqlUpdateCategory, category.Name(), category.Id())
+func (r *CategoryRepository) RestoreCategory(ctx context.Context, id int, actor domain.AuditActor) (domain.Category, error) {
+	var restored model.Category
+	err := r.db.WithTransaction(ctx, func(tx *sqlx.Tx) error {
+		var before model.Category
+		if err := tx.GetContext(ctx, &before, sqlLockArchivedCategory, id); err != nil {
+			if errors.Is(err, sql.ErrNoRows) {
+				return domain.ErrNotFound
+			}
+			return fmt.Errorf("failed to get archived category: %w", err)
+		}
+		if err := tx.GetContext(ctx, &restored, sqlRestoreCategory, id); err != nil {
+			return fmt.Errorf("failed to restore category: %w", err)
+		}
+		slog.Info("CategoryRepository.RestoreCategory", "id", id)
+
+		return writeAudit(ctx, tx, actor, domain.AuditActionRestore, domain.AuditEntityCategory, id, before, restored)
+	})
 	if err != nil {
-		return fmt.Errorf("failed to update category: %w", err)
+		return domain.Category{}, err
 	}
-	affect, err := result.RowsAffected()
+	return toDomainCategory(restored)
+}
+
+func (r *CategoryRepository) FindArchivedCategories(ctx context.Context) ([]domain.Category, error) {
+	var categories []model.Category
+	err := r.db.Select(ctx, "find_archived_categories", &categories, sqlFindArchivedCategories)
 	if err != nil {
-		return fmt.Errorf("failed to get affected rows: %w", err)
+		return nil, fmt.Errorf("failed to find archived categories: %w", err)
 	}
-	slog.Info("CategoryRepository.UpdateCategory", "affect", affect)
-	return nil
+	return toDomainCategories(categories)
 }
 
-func (r *CategoryRepository) DeleteCategory(ctx context.Context, id int) error {
-	result, err := r.db.Exec(ctx, "delete_category", sqlDeleteCategory, id)
+// PurgeArchived permanently deletes categories archived before the given time once none
+// of their books are left.
+func (r *CategoryRepository) PurgeArchived(ctx context.Context, archivedBefore time.Time) (int, error) {
+	result, err := r.db.Exec(ctx, "purge_archived_categories", sqlPurgeCategories,
+		archivedBefore, domain.AuditActionPurge, domain.AuditEntityCategory,
+	)
 	if err != nil {
-		return fmt.Errorf("failed to delete category: %w", err)
+		return 0, fmt.Errorf("failed to purge archived categories: %w", err)
 	}
-	affect, err := result.RowsAffected()
+	affected, err := result.RowsAffected()
 	if err != nil {
-		return fmt.Errorf("failed to get affected rows: %w", err)
+		return 0, fmt.Errorf("failed to get affected rows: %w", err)
 	}
-	slog.Info("CategoryRepository.DeleteCategory", "affect", affect)
-	return nil
+	return int(affected), nil
 }
@@ -0,0 +1,35 @@
+package repository
+
+import (
+	"context"
+	"testing"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/jmoiron/sqlx"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"toptal/internal/app/domain"
+	"toptal/internal/pkg/pg"
+)
+
+func TestCategoryRepository_DeleteCategoryInUse(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	require.NoError(t, err)
+	defer db.Close()
+
+	repo := NewCategoryRepository(pg.NewDB(sqlx.NewDb(db, "sqlmock")))
+
+	mock.ExpectBegin()
+	mock.ExpectQuery("SELECT \\* FROM categories WHERE id = \\$1 AND deleted_at IS NULL FOR UPDATE").
+		WithArgs(2).
+		WillReturnRows(sqlmock.NewRows([]string{"id", "name", "tax_class", "deleted_at"}).AddRow(2, "Fiction", "standard", nil))
+	mock.ExpectQuery("SELECT COUNT\\(\\*\\) FROM books WHERE category_id = \\$1 AND deleted_at IS NULL").
+		WithArgs(2).
+		WillReturnRows(sqlmock.NewRows([]string{"count"}).AddRow(3))
+	mock.ExpectRollback()
+
+	err = repo.DeleteCategory(context.Background(), 2, domain.AuditActor{})
+	assert.ErrorIs(t, err, domain.ErrCategoryInUse)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
@@ -0,0 +1,182 @@
+package repository
+
+import (
+	"context"
+	"toptal/internal/app/domain"
+	"toptal/internal/app/repository/model"
+	"toptal/internal/pkg/pg"
+
+	"github.com/jmoiron/sqlx"
+)
+
+// chartTrending is the chart of books put in carts more often lately than before.
+const chartTrending = "trending"
+
+const (
+	sqlLockChartsState = `SELECT last_order_id FROM book_charts_state FOR UPDATE`
+	// sqlLastChartOrder finds the last order after $1. Orders of the last minute are left
+	// for the next run, so ones still being committed are not skipped.
+	sqlLastChartOrder = `
+		SELECT COALESCE(MAX(id), $1) AS last_order_id, COUNT(*) AS orders
+		FROM orders
+		WHERE id > $1 AND created_at < now() - interval '1 minute'
+	`
+	sqlAddDailySales = `
+		INSERT INTO book_sales_daily (book_id, day, copies)
+		SELECT oi.book_id, (o.created_at AT TIME ZONE 'UTC')::date, COUNT(*)
+		FROM orders o
+		JOIN order_items oi ON oi.order_id = o.id
+		WHERE o.id > $1 AND o.id <= $2 AND oi.book_id IS NOT NULL
+		GROUP BY 1, 2
+		ON CONFLICT (book_id, day) DO UPDATE SET copies = book_sales_daily.copies + EXCLUDED.copies
+	`
+	// sqlAddDailyCartAdds moves the recorded cart adds into their daily counts. Adds still
+	// being committed are not seen, so they stay for the next run.
+	sqlAddDailyCartAdds = `
+		WITH added AS (
+			DELETE FROM book_cart_adds RETURNING book_id, added_at
+		)
+		INSERT INTO book_cart_adds_daily (book_id, day, adds)
+		SELECT book_id, (added_at AT TIME ZONE 'UTC')::date, COUNT(*)
+		FROM added
+		GROUP BY 1, 2
+		ON CONFLICT (book_id, day) DO UPDATE SET adds = book_cart_adds_daily.adds + EXCLUDED.adds
+	`
+	sqlPruneDailyCartAdds = `DELETE FROM book_cart_adds_daily WHERE day <= (now() AT TIME ZONE 'UTC')::date - $1::int`
+	sqlClearCharts        = `DELETE FROM book_charts`
+	// sqlInsertBestsellers ranks books on chart $1 by the copies sold over the last $2 days.
+	sqlInsertBestsellers = `
+		INSERT INTO book_charts (chart, book_id, score)
+		SELECT $1, book_id, SUM(copies)
+		FROM book_sales_daily
+		WHERE day > (now() AT TIME ZONE 'UTC')::date - $2::int
+		GROUP BY book_id
+	`
+	// sqlInsertTrending ranks books by how many more times a day they were put in carts
+	// over the last $2 days than over the $3 days before.
+	sqlInsertTrending = `
+		INSERT INTO book_charts (chart, book_id, score)
+		SELECT $1, book_id, velocity
+		FROM (
+			SELECT book_id,
+				COALESCE(SUM(adds) FILTER (WHERE day > today - $2::int), 0)::float8 / $2::int -
+				COALESCE(SUM(adds) FILTER (WHERE day <= today - $2::int), 0)::float8 / $3::int AS velocity
+			FROM book_cart_adds_daily, (SELECT (now() AT TIME ZONE 'UTC')::date AS today) t
+			WHERE day > today - ($2::int + $3::int)
+			GROUP BY book_id
+		) rates
+		WHERE velocity > 0
+	`
+	sqlSaveChartsState = `UPDATE book_charts_state SET last_order_id = $1, refreshed_at = now()`
+
+	// sqlFindChart lists the available books on chart $1, of category $2 unless it is 0.
+	sqlFindChart = `
+		SELECT b.*
+		FROM book_charts c
+		JOIN books b ON b.id = c.book_id
+		WHERE c.chart = $1 AND ($2 = 0 OR b.category_id = $2) AND b.stock > 0 AND b.deleted_at IS NULL
+		ORDER BY c.score DESC, b.id
+		LIMIT $3 OFFSET $4
+	`
+	sqlFindNewArrivals = `
+		SELECT *
+		FROM books
+		WHERE ($1 = 0 OR category_id = $1) AND stock > 0 AND deleted_at IS NULL
+		ORDER BY created_at DESC, id DESC
+		LIMIT $2 OFFSET $3
+	`
+)
+
+type ChartRepository struct {
+	db *pg.DB
+}
+
+func NewChartRepository(db *pg.DB) *ChartRepository {
+	return &ChartRepository{db}
+}
+
+// RefreshCharts adds the orders and cart adds since the last refresh to the daily counts
+// and ranks the books again. Trending books are those put in carts more often over the
+// last trendingDays than over the baselineDays before. It returns how many orders it added.
+func (r *ChartRepository) RefreshCharts(ctx context.Context, trendingDays, baselineDays int) (int, error) {
+	var added int
+	err := r.db.WithTransaction(ctx, func(tx *sqlx.Tx) error {
+		var lastOrderId int
+		if err := tx.GetContext(ctx, &lastOrderId, sqlLockChartsState); err != nil {
+			return model.WrapDatabaseError(err, "failed to get chart state")
+		}
+		var next struct {
+			LastOrderId int `db:"last_order_id"`
+			Orders      int `db:"orders"`
+		}
+		if err := tx.GetContext(ctx, &next, sqlLastChartOrder, lastOrderId); err != nil {
+			return model.WrapDatabaseError(err, "failed to find orders for charts")
+		}
+		if next.Orders > 0 {
+			if _, err := tx.ExecContext(ctx, sqlAddDailySales, lastOrderId, next.LastOrderId); err != nil {
+				return model.WrapDatabaseError(err, "failed to add daily sales")
+			}
+			added = next.Orders
+		}
+		if _, err := tx.ExecContext(ctx, sqlAddDailyCartAdds); err != nil {
+			return model.WrapDatabaseError(err, "failed to add daily cart adds")
+		}
+		if _, err := tx.ExecContext(ctx, sqlPruneDailyCartAdds, trendingDays+baselineDays); err != nil {
+			return model.WrapDatabaseError(err, "failed to prune daily cart adds")
+		}
+
+		if _, err := tx.ExecContext(ctx, sqlClearCharts); err != nil {
+			return model.WrapDatabaseError(err, "failed to clear charts")
+		}
+		for _, period := range domain.ChartPeriods {
+			days, _ := domain.ChartPeriodDays(period)
+			if _, err := tx.ExecContext(ctx, sqlInsertBestsellers, bestsellerChart(period), days); err != nil {
+				return model.WrapDatabaseError(err, "failed to rank bestsellers")
+			}
+		}
+		if _, err := tx.ExecContext(ctx, sqlInsertTrending, chartTrending, trendingDays, baselineDays); err != nil {
+			return model.WrapDatabaseError(err, "failed to rank trending books")
+		}
+
+		if _, err := tx.ExecContext(ctx, sqlSaveChartsState, next.LastOrderId); err != nil {
+			return model.WrapDatabaseError(err, "failed to save chart state")
+		}
+		return nil
+	})
+	return added, err
+}
+
+// FindBestsellers lists the available books that sold most over the period, of the
+// category unless categoryId is 0.
+func (r *ChartRepository) FindBestsellers(ctx context.Context, period string, categoryId, limit, offset int) ([]domain.Book, error) {
+	var books []model.Book
+	err := r.db.Select(ctx, "find_bestsellers", &books, sqlFindChart, bestsellerChart(period), categoryId, limit, offset)
+	if err != nil {
+		return nil, model.WrapDatabaseError(err, "failed to find bestsellers")
+	}
+	return toDomainBooks(books), nil
+}
+
+func (r *ChartRepository) FindTrending(ctx context.Context, categoryId, limit, offset int) ([]domain.Book, error) {
+	var books []model.Book
+	err := r.db.Select(ctx, "find_trending", &books, sqlFindChart, chartTrending, categoryId, limit, offset)
+	if err != nil {
+		return nil, model.WrapDatabaseError(err, "failed to find trending books")
+	}
+	return toDomainBooks(books), nil
+}
+
+// FindNewArrivals lists the available books most recently added to the catalogue, of the
+// category unless categoryId is 0.
+func (r *ChartRepository) FindNewArrivals(ctx context.Context, categoryId, limit, offset int) ([]domain.Book, error) {
+	var books []model.Book
+	err := r.db.Select(ctx, "find_new_arrivals", &books, sqlFindNewArrivals, categoryId, limit, offset)
+	if err != nil {
+		return nil, model.WrapDatabaseError(err, "failed to find new arrivals")
+	}
+	return toDomainBooks(books), nil
+}
+
+func bestsellerChart(period string) string {
+	return "bestsellers_" + period
+}
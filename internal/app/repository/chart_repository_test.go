@@ -0,0 +1,58 @@
+package repository
+
+import (
+	"context"
+	"testing"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/jmoiron/sqlx"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"toptal/internal/pkg/pg"
+)
+
+func TestChartRepository_RefreshCharts(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	require.NoError(t, err)
+	defer db.Close()
+
+	repo := NewChartRepository(pg.NewDB(sqlx.NewDb(db, "sqlmock")))
+
+	mock.ExpectBegin()
+	mock.ExpectQuery("SELECT last_order_id FROM book_charts_state FOR UPDATE").
+		WillReturnRows(sqlmock.NewRows([]string{"last_order_id"}).AddRow(10))
+	mock.ExpectQuery("SELECT COALESCE\\(MAX\\(id\\), \\$1\\) AS last_order_id, COUNT\\(\\*\\) AS orders").
+		WithArgs(10).
+		WillReturnRows(sqlmock.NewRows([]string{"last_order_id", "orders"}).AddRow(14, 4))
+	mock.ExpectExec("INSERT INTO book_sales_daily").
+		WithArgs(10, 14).
+		WillReturnResult(sqlmock.NewResult(0, 3))
+	mock.ExpectExec("DELETE FROM book_cart_adds RETURNING").
+		WillReturnResult(sqlmock.NewResult(0, 2))
+	mock.ExpectExec("DELETE FROM book_cart_adds_daily WHERE day <=").
+		WithArgs(31).
+		WillReturnResult(sqlmock.NewResult(0, 0))
+	mock.ExpectExec("DELETE FROM book_charts").
+		WillReturnResult(sqlmock.NewResult(0, 12))
+	for _, chart := range []struct {
+		name string
+		days int
+	}{{"bestsellers_week", 7}, {"bestsellers_month", 30}, {"bestsellers_year", 365}} {
+		mock.ExpectExec("INSERT INTO book_charts").
+			WithArgs(chart.name, chart.days).
+			WillReturnResult(sqlmock.NewResult(0, 3))
+	}
+	mock.ExpectExec("INSERT INTO book_charts").
+		WithArgs("trending", 3, 28).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectExec("UPDATE book_charts_state SET last_order_id = \\$1").
+		WithArgs(14).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectCommit()
+
+	added, err := repo.RefreshCharts(context.Background(), 3, 28)
+	require.NoError(t, err)
+	assert.Equal(t, 4, added)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
@@ -0,0 +1,101 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"toptal/internal/app/domain"
+	"toptal/internal/app/repository/model"
+	"toptal/internal/pkg/pg"
+
+	"github.com/jmoiron/sqlx"
+)
+
+const (
+	sqlFindCover = `
+		SELECT cover_key, cover_type, cover_updated_at
+		FROM books
+		WHERE id = $1 AND deleted_at IS NULL AND cover_key IS NOT NULL
+	`
+	sqlSetCover = `
+		UPDATE books SET cover_key = $2, cover_type = $3, cover_updated_at = now()
+		WHERE id = $1
+		RETURNING *
+	`
+	sqlRemoveCover = `
+		UPDATE books SET cover_key = NULL, cover_type = NULL, cover_updated_at = NULL
+		WHERE id = $1
+		RETURNING *
+	`
+)
+
+type CoverRepository struct {
+	db *pg.DB
+}
+
+func NewCoverRepository(db *pg.DB) *CoverRepository {
+	return &CoverRepository{db}
+}
+
+// FindCover returns the cover of a listed book, or domain.ErrNotFound when it has none.
+func (r *CoverRepository) FindCover(ctx context.Context, bookId int) (domain.Cover, error) {
+	var book model.Book
+	if err := r.db.Get(ctx, "find_cover", &book, sqlFindCover, bookId); err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return domain.Cover{}, domain.ErrNotFound
+		}
+		return domain.Cover{}, model.WrapDatabaseError(err, "failed to find cover")
+	}
+	return domain.NewCover(book.CoverKey.String, book.CoverType.String, fromNullTime(book.CoverUpdatedAt))
+}
+
+// SetCover points a listed book at a cover in the blob store and returns the book as it
+// was before, so the caller can remove the cover it replaced.
+func (r *CoverRepository) SetCover(ctx context.Context, bookId int, cover domain.Cover, actor domain.AuditActor) (domain.Book, error) {
+	var before, after model.Book
+	err := r.db.WithTransaction(ctx, func(tx *sqlx.Tx) error {
+		if err := tx.GetContext(ctx, &before, sqlLockBook, bookId); err != nil {
+			if errors.Is(err, sql.ErrNoRows) {
+				return domain.ErrNotFound
+			}
+			return model.WrapDatabaseError(err, "failed to get book")
+		}
+
+		if err := tx.GetContext(ctx, &after, sqlSetCover, bookId, cover.Key(), cover.ContentType()); err != nil {
+			return model.WrapDatabaseError(err, "failed to set cover")
+		}
+
+		return writeAudit(ctx, tx, actor, domain.AuditActionUpdate, domain.AuditEntityBook, bookId, before, after)
+	})
+	if err != nil {
+		return domain.Book{}, err
+	}
+	return toDomainBook(before), nil
+}
+
+// RemoveCover takes the cover off a listed book and returns the book as it was before.
+// It returns domain.ErrNotFound when the book has no cover.
+func (r *CoverRepository) RemoveCover(ctx context.Context, bookId int, actor domain.AuditActor) (domain.Book, error) {
+	var before, after model.Book
+	err := r.db.WithTransaction(ctx, func(tx *sqlx.Tx) error {
+		if err := tx.GetContext(ctx, &before, sqlLockBook, bookId); err != nil {
+			if errors.Is(err, sql.ErrNoRows) {
+				return domain.ErrNotFound
+			}
+			return model.WrapDatabaseError(err, "failed to get book")
+		}
+		if !before.CoverKey.Valid {
+			return domain.ErrNotFound
+		}
+
+		if err := tx.GetContext(ctx, &after, sqlRemoveCover, bookId); err != nil {
+			return model.WrapDatabaseError(err, "failed to remove cover")
+		}
+
+		return writeAudit(ctx, tx, actor, domain.AuditActionUpdate, domain.AuditEntityBook, bookId, before, after)
+	})
+	if err != nil {
+		return domain.Book{}, err
+	}
+	return toDomainBook(before), nil
+}
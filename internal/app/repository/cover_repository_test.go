@@ -0,0 +1,71 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/jmoiron/sqlx"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"toptal/internal/app/domain"
+	"toptal/internal/pkg/pg"
+)
+
+var coverBookColumns = append(append([]string{}, bookColumns...), "cover_key", "cover_type", "cover_updated_at")
+
+func TestCoverRepository_SetCover(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	require.NoError(t, err)
+	defer db.Close()
+
+	repo := NewCoverRepository(pg.NewDB(sqlx.NewDb(db, "sqlmock")))
+	cover, err := domain.NewCover("covers/1/b2c3", "image/png", time.Time{})
+	require.NoError(t, err)
+
+	mock.ExpectBegin()
+	mock.ExpectQuery("SELECT \\* FROM books WHERE id = \\$1 AND deleted_at IS NULL FOR UPDATE").
+		WithArgs(1).
+		WillReturnRows(sqlmock.NewRows(coverBookColumns).
+			AddRow(1, "Book", "Author", 2020, 1000, "USD", 3, 1, nil, "covers/1/a1b2", "image/jpeg", time.Now()))
+	mock.ExpectQuery("UPDATE books SET cover_key = \\$2, cover_type = \\$3, cover_updated_at = now\\(\\)").
+		WithArgs(1, "covers/1/b2c3", "image/png").
+		WillReturnRows(sqlmock.NewRows(coverBookColumns).
+			AddRow(1, "Book", "Author", 2020, 1000, "USD", 3, 1, nil, "covers/1/b2c3", "image/png", time.Now()))
+	mock.ExpectExec("INSERT INTO audit_log").
+		WithArgs(
+			sql.NullInt64{}, sql.NullInt64{}, domain.AuditActionUpdate, domain.AuditEntityBook, 1,
+			sqlmock.AnyArg(), sqlmock.AnyArg(), sql.NullString{}, sql.NullString{},
+		).
+		WillReturnResult(sqlmock.NewResult(1, 1))
+	mock.ExpectCommit()
+
+	before, err := repo.SetCover(context.Background(), 1, cover, domain.AuditActor{})
+	require.NoError(t, err)
+	previous, ok := before.Cover()
+	require.True(t, ok)
+	assert.Equal(t, "covers/1/a1b2", previous.Key())
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestCoverRepository_RemoveCover(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	require.NoError(t, err)
+	defer db.Close()
+
+	repo := NewCoverRepository(pg.NewDB(sqlx.NewDb(db, "sqlmock")))
+
+	mock.ExpectBegin()
+	mock.ExpectQuery("SELECT \\* FROM books WHERE id = \\$1 AND deleted_at IS NULL FOR UPDATE").
+		WithArgs(1).
+		WillReturnRows(sqlmock.NewRows(coverBookColumns).
+			AddRow(1, "Book", "Author", 2020, 1000, "USD", 3, 1, nil, nil, nil, nil))
+	mock.ExpectRollback()
+
+	_, err = repo.RemoveCover(context.Background(), 1, domain.AuditActor{})
+	assert.ErrorIs(t, err, domain.ErrNotFound)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
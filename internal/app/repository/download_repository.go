@@ -0,0 +1,99 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"toptal/internal/app/domain"
+	"toptal/internal/app/repository/model"
+	"toptal/internal/pkg/pg"
+)
+
+const (
+	sqlSelectDownloads = `
+		SELECT d.id, d.user_id, d.download_count, d.last_download, d.created_at,
+			oi.order_id, oi.title, oi.author, oi.format,
+			d.file_key, d.file_name, d.file_type, d.file_size
+		FROM downloads d
+		JOIN order_items oi ON oi.id = d.order_item_id
+	`
+	sqlFindDownloadsByUser = sqlSelectDownloads + `WHERE d.user_id = $1 ORDER BY d.created_at DESC, d.id DESC`
+	sqlFindDownload        = sqlSelectDownloads + `WHERE d.id = $1`
+	sqlCountDownload       = `
+		UPDATE downloads
+		SET download_count = download_count + 1, last_download = now(), resume_offset = NULL
+		WHERE id = $1 AND download_count < $2
+	`
+	// sqlResumeDownload claims the interrupted transfer of a download, so it is resumed once.
+	sqlResumeDownload = `
+		UPDATE downloads SET resume_offset = NULL, last_download = now() WHERE id = $1 AND resume_offset = $2
+	`
+	sqlSetResumeOffset = `UPDATE downloads SET resume_offset = $2 WHERE id = $1`
+)
+
+type DownloadRepository struct {
+	db *pg.DB
+}
+
+func NewDownloadRepository(db *pg.DB) *DownloadRepository {
+	return &DownloadRepository{db}
+}
+
+func (r *DownloadRepository) FindDownloadsByUser(ctx context.Context, userId int) ([]domain.Download, error) {
+	var downloads []model.Download
+	if err := r.db.Select(ctx, "find_downloads_by_user", &downloads, sqlFindDownloadsByUser, userId); err != nil {
+		return nil, model.WrapDatabaseError(err, "failed to find downloads")
+	}
+	return toDomainDownloads(downloads)
+}
+
+func (r *DownloadRepository) FindDownload(ctx context.Context, id int) (domain.Download, error) {
+	var download model.Download
+	if err := r.db.Get(ctx, "find_download", &download, sqlFindDownload, id); err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return domain.Download{}, domain.ErrNotFound
+		}
+		return domain.Download{}, model.WrapDatabaseError(err, "failed to find download")
+	}
+	return toDomainDownload(download)
+}
+
+// CountDownload records a download unless the download has been used limit times already.
+func (r *DownloadRepository) CountDownload(ctx context.Context, id int, limit int) error {
+	result, err := r.db.Exec(ctx, "count_download", sqlCountDownload, id, limit)
+	if err != nil {
+		return model.WrapDatabaseError(err, "failed to count download")
+	}
+	rows, err := result.RowsAffected()
+	if err != nil {
+		return model.WrapDatabaseError(err, "failed to get affected rows")
+	}
+	if rows == 0 {
+		return domain.ErrDownloadLimitReached
+	}
+	return nil
+}
+
+// ResumeDownload reports whether the last transfer of a download stopped at offset, and
+// claims it so that no other request can resume it.
+func (r *DownloadRepository) ResumeDownload(ctx context.Context, id int, offset int64) (bool, error) {
+	result, err := r.db.Exec(ctx, "resume_download", sqlResumeDownload, id, offset)
+	if err != nil {
+		return false, model.WrapDatabaseError(err, "failed to resume download")
+	}
+	rows, err := result.RowsAffected()
+	if err != nil {
+		return false, model.WrapDatabaseError(err, "failed to get affected rows")
+	}
+	return rows == 1, nil
+}
+
+// SetResumeOffset records where a transfer of a download stopped. Zero records a transfer
+// that reached the end of the file, which cannot be resumed.
+func (r *DownloadRepository) SetResumeOffset(ctx context.Context, id int, offset int64) error {
+	resumeOffset := sql.NullInt64{Int64: offset, Valid: offset > 0}
+	if _, err := r.db.Exec(ctx, "set_resume_offset", sqlSetResumeOffset, id, resumeOffset); err != nil {
+		return model.WrapDatabaseError(err, "failed to set download resume offset")
+	}
+	return nil
+}
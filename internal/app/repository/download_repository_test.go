@@ -0,0 +1,56 @@
+package repository
+
+import (
+	"context"
+	"testing"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/jmoiron/sqlx"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"toptal/internal/app/domain"
+	"toptal/internal/pkg/pg"
+)
+
+func TestDownloadRepository_CountDownload(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	require.NoError(t, err)
+	defer db.Close()
+
+	repo := NewDownloadRepository(pg.NewDB(sqlx.NewDb(db, "sqlmock")))
+
+	mock.ExpectExec("UPDATE downloads\\s+SET download_count = download_count \\+ 1").
+		WithArgs(7, 5).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	assert.NoError(t, repo.CountDownload(context.Background(), 7, 5))
+
+	mock.ExpectExec("UPDATE downloads\\s+SET download_count = download_count \\+ 1").
+		WithArgs(7, 5).
+		WillReturnResult(sqlmock.NewResult(0, 0))
+	assert.ErrorIs(t, repo.CountDownload(context.Background(), 7, 5), domain.ErrDownloadLimitReached)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestDownloadRepository_ResumeDownload(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	require.NoError(t, err)
+	defer db.Close()
+
+	repo := NewDownloadRepository(pg.NewDB(sqlx.NewDb(db, "sqlmock")))
+
+	mock.ExpectExec("UPDATE downloads SET resume_offset = NULL").
+		WithArgs(7, int64(3)).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	resumed, err := repo.ResumeDownload(context.Background(), 7, 3)
+	require.NoError(t, err)
+	assert.True(t, resumed)
+
+	mock.ExpectExec("UPDATE downloads SET resume_offset = NULL").
+		WithArgs(7, int64(3)).
+		WillReturnResult(sqlmock.NewResult(0, 0))
+	resumed, err = repo.ResumeDownload(context.Background(), 7, 3)
+	require.NoError(t, err)
+	assert.False(t, resumed, "a transfer is resumed once")
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
@@ -0,0 +1,159 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"toptal/internal/app/domain"
+	"toptal/internal/app/repository/model"
+	"toptal/internal/pkg/pg"
+
+	"github.com/jmoiron/sqlx"
+)
+
+const (
+	sqlFindFormats  = `SELECT * FROM book_formats WHERE book_id = $1 ORDER BY id`
+	sqlFindFormat   = `SELECT * FROM book_formats WHERE book_id = $1 AND format = $2`
+	sqlLockFormat   = `SELECT * FROM book_formats WHERE book_id = $1 AND format = $2 FOR UPDATE`
+	sqlUpsertFormat = `
+		INSERT INTO book_formats (book_id, format, price, currency, stock)
+		VALUES ($1, $2, $3, $4, $5)
+		ON CONFLICT (book_id, format) DO UPDATE
+		SET price = EXCLUDED.price, currency = EXCLUDED.currency, stock = EXCLUDED.stock, updated_at = now()
+		RETURNING *
+	`
+	sqlDeleteFormat  = `DELETE FROM book_formats WHERE book_id = $1 AND format = $2 RETURNING *`
+	sqlSetFormatFile = `
+		UPDATE book_formats
+		SET file_key = $3, file_name = $4, file_type = $5, file_size = $6, updated_at = now()
+		WHERE book_id = $1 AND format = $2
+		RETURNING *
+	`
+	sqlFileInUse = `
+		SELECT EXISTS (SELECT 1 FROM book_formats WHERE file_key = $1)
+			OR EXISTS (SELECT 1 FROM downloads WHERE file_key = $1)
+	`
+)
+
+type FormatRepository struct {
+	db *pg.DB
+}
+
+func NewFormatRepository(db *pg.DB) *FormatRepository {
+	return &FormatRepository{db}
+}
+
+func (r *FormatRepository) FindFormats(ctx context.Context, bookId int) ([]domain.BookFormat, error) {
+	var formats []model.BookFormat
+	if err := r.db.Select(ctx, "find_formats", &formats, sqlFindFormats, bookId); err != nil {
+		return nil, model.WrapDatabaseError(err, "failed to find book formats")
+	}
+	return toDomainBookFormats(formats)
+}
+
+func (r *FormatRepository) FindFormat(ctx context.Context, bookId int, format string) (domain.BookFormat, error) {
+	var found model.BookFormat
+	if err := r.db.Get(ctx, "find_format", &found, sqlFindFormat, bookId, format); err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return domain.BookFormat{}, domain.ErrNotFound
+		}
+		return domain.BookFormat{}, model.WrapDatabaseError(err, "failed to find book format")
+	}
+	return toDomainBookFormat(found)
+}
+
+// SaveFormat adds a format to a listed book or replaces its price and stock. The file of a
+// digital format is kept.
+func (r *FormatRepository) SaveFormat(ctx context.Context, format domain.BookFormat, actor domain.AuditActor) (domain.BookFormat, error) {
+	var after model.BookFormat
+	err := r.db.WithTransaction(ctx, func(tx *sqlx.Tx) error {
+		if _, err := lockListedBook(ctx, tx, format.BookId()); err != nil {
+			return err
+		}
+
+		var existing model.BookFormat
+		var before any
+		action := domain.AuditActionCreate
+		err := tx.GetContext(ctx, &existing, sqlLockFormat, format.BookId(), format.Format())
+		switch {
+		case err == nil:
+			before, action = existing, domain.AuditActionUpdate
+		case !errors.Is(err, sql.ErrNoRows):
+			return model.WrapDatabaseError(err, "failed to get book format")
+		}
+
+		var stock sql.NullInt64
+		if !format.IsDigital() {
+			stock = sql.NullInt64{Int64: int64(format.Stock()), Valid: true}
+		}
+		err = tx.GetContext(ctx, &after, sqlUpsertFormat,
+			format.BookId(), format.Format(), format.Price().Amount(), format.Price().Currency(), stock)
+		if err != nil {
+			return model.WrapDatabaseError(err, "failed to save book format")
+		}
+
+		return writeAudit(ctx, tx, actor, action, domain.AuditEntityBookFormat, format.BookId(), before, after)
+	})
+	if err != nil {
+		return domain.BookFormat{}, err
+	}
+	return toDomainBookFormat(after)
+}
+
+// DeleteFormat removes a format and returns it, so the caller can remove its file once no
+// download uses it. Orders and downloads of the format keep their lines and files.
+func (r *FormatRepository) DeleteFormat(ctx context.Context, bookId int, format string, actor domain.AuditActor) (domain.BookFormat, error) {
+	var deleted model.BookFormat
+	err := r.db.WithTransaction(ctx, func(tx *sqlx.Tx) error {
+		if err := tx.GetContext(ctx, &deleted, sqlDeleteFormat, bookId, format); err != nil {
+			if errors.Is(err, sql.ErrNoRows) {
+				return domain.ErrNotFound
+			}
+			return model.WrapDatabaseError(err, "failed to delete book format")
+		}
+
+		return writeAudit(ctx, tx, actor, domain.AuditActionDelete, domain.AuditEntityBookFormat, bookId, deleted, nil)
+	})
+	if err != nil {
+		return domain.BookFormat{}, err
+	}
+	return toDomainBookFormat(deleted)
+}
+
+// SetFormatFile points a digital format at a file in the blob store and returns the format
+// as it was before, so the caller can remove the file it replaced.
+func (r *FormatRepository) SetFormatFile(
+	ctx context.Context, bookId int, format string, file domain.FormatFile, actor domain.AuditActor,
+) (domain.BookFormat, error) {
+	var before, after model.BookFormat
+	err := r.db.WithTransaction(ctx, func(tx *sqlx.Tx) error {
+		if err := tx.GetContext(ctx, &before, sqlLockFormat, bookId, format); err != nil {
+			if errors.Is(err, sql.ErrNoRows) {
+				return domain.ErrNotFound
+			}
+			return model.WrapDatabaseError(err, "failed to get book format")
+		}
+
+		err := tx.GetContext(ctx, &after, sqlSetFormatFile,
+			bookId, format, file.Key(), file.Name(), file.ContentType(), file.Size())
+		if err != nil {
+			return model.WrapDatabaseError(err, "failed to set book format file")
+		}
+
+		return writeAudit(ctx, tx, actor, domain.AuditActionUpdate, domain.AuditEntityBookFormat, bookId, before, after)
+	})
+	if err != nil {
+		return domain.BookFormat{}, err
+	}
+	return toDomainBookFormat(before)
+}
+
+// IsFileInUse reports whether a format or a download still points at a file of the blob
+// store.
+func (r *FormatRepository) IsFileInUse(ctx context.Context, key string) (bool, error) {
+	var inUse bool
+	if err := r.db.Get(ctx, "file_in_use", &inUse, sqlFileInUse, key); err != nil {
+		return false, model.WrapDatabaseError(err, "failed to check file use")
+	}
+	return inUse, nil
+}
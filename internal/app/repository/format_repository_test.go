@@ -0,0 +1,76 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/jmoiron/sqlx"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"toptal/internal/app/domain"
+	"toptal/internal/pkg/pg"
+)
+
+var bookFormatColumns = []string{
+	"id", "book_id", "format", "price", "currency", "stock",
+	"file_key", "file_name", "file_type", "file_size", "created_at", "updated_at",
+}
+
+func TestFormatRepository_SaveFormat(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	require.NoError(t, err)
+	defer db.Close()
+
+	repo := NewFormatRepository(pg.NewDB(sqlx.NewDb(db, "sqlmock")))
+
+	t.Run("Digital format has no stock", func(t *testing.T) {
+		format, err := domain.NewBookFormat(10, domain.FormatEPUB, usd(899), 4)
+		require.NoError(t, err)
+
+		mock.ExpectBegin()
+		mock.ExpectQuery("SELECT currency FROM books WHERE id = \\$1 AND deleted_at IS NULL FOR UPDATE").
+			WithArgs(10).
+			WillReturnRows(sqlmock.NewRows([]string{"currency"}).AddRow("USD"))
+		mock.ExpectQuery("SELECT \\* FROM book_formats WHERE book_id = \\$1 AND format = \\$2 FOR UPDATE").
+			WithArgs(10, "epub").
+			WillReturnError(sql.ErrNoRows)
+		mock.ExpectQuery("INSERT INTO book_formats").
+			WithArgs(10, "epub", int64(899), "USD", nil).
+			WillReturnRows(sqlmock.NewRows(bookFormatColumns).
+				AddRow(3, 10, "epub", 899, "USD", nil, nil, nil, nil, nil, time.Now(), time.Now()))
+		mock.ExpectExec("INSERT INTO audit_log").
+			WithArgs(
+				sql.NullInt64{}, sql.NullInt64{}, domain.AuditActionCreate, domain.AuditEntityBookFormat, 10,
+				sqlmock.AnyArg(), sqlmock.AnyArg(), sql.NullString{}, sql.NullString{},
+			).
+			WillReturnResult(sqlmock.NewResult(1, 1))
+		mock.ExpectCommit()
+
+		saved, err := repo.SaveFormat(context.Background(), format, domain.AuditActor{})
+		require.NoError(t, err)
+		assert.Equal(t, 3, saved.Id())
+		assert.True(t, saved.IsDigital())
+		_, hasFile := saved.File()
+		assert.False(t, hasFile)
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("Archived book", func(t *testing.T) {
+		format, err := domain.NewBookFormat(10, domain.FormatHardcover, usd(2999), 4)
+		require.NoError(t, err)
+
+		mock.ExpectBegin()
+		mock.ExpectQuery("SELECT currency FROM books WHERE id = \\$1 AND deleted_at IS NULL FOR UPDATE").
+			WithArgs(10).
+			WillReturnError(sql.ErrNoRows)
+		mock.ExpectRollback()
+
+		_, err = repo.SaveFormat(context.Background(), format, domain.AuditActor{})
+		assert.ErrorIs(t, err, domain.ErrNotFound)
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+}
@@ -0,0 +1,352 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"strings"
+	"toptal/internal/app/domain"
+	"toptal/internal/app/repository/model"
+	"toptal/internal/pkg/pg"
+
+	"github.com/jmoiron/sqlx"
+	"github.com/lib/pq"
+)
+
+// importBatchSize is how many rows of an import are matched and written at once.
+const importBatchSize = 500
+
+const (
+	// sqlLockImportMatches finds the books rows of an import may update: those with one of
+	// the ISBNs $1, archived or not, and listed books with one of the title and author keys
+	// $2. Keys are built by importKey.
+	sqlLockImportMatches = `
+		SELECT *
+		FROM books
+		WHERE isbn = ANY($1) OR (deleted_at IS NULL AND lower(title) || chr(31) || lower(author) = ANY($2))
+		ORDER BY id
+		FOR UPDATE
+	`
+	sqlImportInsertBooks = `
+		INSERT INTO books (title, author, year, price, stock, category_id, currency, isbn)
+		SELECT *
+		FROM unnest($1::varchar[], $2::varchar[], $3::int[], $4::bigint[], $5::int[], $6::int[], $7::char(3)[], $8::varchar[])
+		RETURNING id
+	`
+	sqlImportUpdateBooks = `
+		UPDATE books AS b
+		SET title = v.title, author = v.author, year = v.year, price = v.price, stock = v.stock,
+			category_id = v.category_id, isbn = COALESCE(v.isbn, b.isbn)
+		FROM unnest($1::int[], $2::varchar[], $3::varchar[], $4::int[], $5::bigint[], $6::int[], $7::int[], $8::varchar[])
+			AS v(id, title, author, year, price, stock, category_id, isbn)
+		WHERE b.id = v.id
+		RETURNING b.*
+	`
+	sqlImportPriceHistory = `
+		INSERT INTO book_price_history (book_id, price, sale_price, currency, reason)
+		SELECT id, price, sale_price, currency, $2
+		FROM books
+		WHERE id = ANY($1)
+	`
+	// sqlImportArchiveBooks archives the listed books with one of the ISBNs $1.
+	sqlImportArchiveBooks = `
+		UPDATE books
+		SET deleted_at = now()
+		WHERE isbn = ANY($1) AND deleted_at IS NULL
+		RETURNING *
+	`
+	// sqlImportAuditCreated records the books created by an import like writeAudit would,
+	// in one statement.
+	sqlImportAuditCreated = `
+		INSERT INTO audit_log (actor_user_id, actor_api_key_id, action, entity_type, entity_id, after, request_id, ip_address)
+		SELECT $2, $3, $4::varchar, $5::varchar, b.id, to_jsonb(b), $6, $7
+		FROM books b
+		WHERE b.id = ANY($1)
+	`
+)
+
+// errImportDryRun rolls back the transaction of a dry run.
+var errImportDryRun = errors.New("import dry run")
+
+type ImportRepository struct {
+	db *pg.DB
+}
+
+func NewImportRepository(db *pg.DB) *ImportRepository {
+	return &ImportRepository{db}
+}
+
+// ImportBooks creates or updates the books of an import and archives its removals in one
+// transaction, counting the outcome of every row in report. A book is updated when it has
+// the ISBN of the row or, failing that, the same title and author and no ISBN of its own.
+// A dry run does all of it and rolls back.
+func (r *ImportRepository) ImportBooks(
+	ctx context.Context, books []domain.BookImport, removals []domain.BookRemoval,
+	report *domain.ImportReport, actor domain.AuditActor,
+) error {
+	err := r.db.WithTransaction(ctx, func(tx *sqlx.Tx) error {
+		matched := make(map[int]int)
+		for start := 0; start < len(books); start += importBatchSize {
+			end := min(start+importBatchSize, len(books))
+			if err := r.importBatch(ctx, tx, books[start:end], matched, report, actor); err != nil {
+				return err
+			}
+		}
+		if err := r.archiveRemoved(ctx, tx, removals, report, actor); err != nil {
+			return err
+		}
+		if report.DryRun() {
+			return errImportDryRun
+		}
+		return nil
+	})
+	if errors.Is(err, errImportDryRun) {
+		return nil
+	}
+	return err
+}
+
+// importBatch writes a batch of rows. matched maps the books updated so far to the line
+// that updated them, so two rows cannot update the same book.
+func (r *ImportRepository) importBatch(
+	ctx context.Context, tx *sqlx.Tx, batch []domain.BookImport, matched map[int]int,
+	report *domain.ImportReport, actor domain.AuditActor,
+) error {
+	isbns := pq.StringArray{}
+	keys := make(pq.StringArray, len(batch))
+	for i, row := range batch {
+		book := row.Book()
+		if book.ISBN() != "" {
+			isbns = append(isbns, book.ISBN())
+		}
+		keys[i] = importKey(book.Title(), book.Author())
+	}
+	var existing []model.Book
+	if err := tx.SelectContext(ctx, &existing, sqlLockImportMatches, isbns, keys); err != nil {
+		return model.WrapDatabaseError(err, "failed to find imported books")
+	}
+	byISBN := make(map[string]model.Book)
+	byKey := make(map[string][]model.Book)
+	for _, book := range existing {
+		if book.ISBN.Valid {
+			byISBN[book.ISBN.String] = book
+		}
+		if !book.DeletedAt.Valid {
+			key := importKey(book.Title, book.Author)
+			byKey[key] = append(byKey[key], book)
+		}
+	}
+
+	var creates []domain.Book
+	var updates []model.Book
+	before := make(map[int]model.Book)
+	for _, row := range batch {
+		book := row.Book()
+		match, err := matchImport(book, byISBN, byKey)
+		if err != nil {
+			report.AddError(row.Line(), "%v", err)
+			continue
+		}
+		if match == nil {
+			creates = append(creates, book)
+			continue
+		}
+		if line, ok := matched[match.Id]; ok {
+			report.AddError(row.Line(), "updates book %d, which line %d already updates", match.Id, line)
+			continue
+		}
+		matched[match.Id] = row.Line()
+		if match.Currency != book.Price().Currency() {
+			report.AddError(row.Line(), "%v: book %d is priced in %s", domain.ErrCurrencyMismatch, match.Id, match.Currency)
+			continue
+		}
+
+		updated := *match
+		updated.Title = book.Title()
+		updated.Author = book.Author()
+		updated.Year = book.Year()
+		updated.Price = book.Price().Amount()
+		if !row.KeepsStock() {
+			updated.Stock = book.Stock()
+		}
+		updated.CategoryId = book.CategoryId()
+		if book.ISBN() != "" {
+			updated.ISBN = toNullString(book.ISBN())
+		}
+		if updated == *match {
+			report.AddUnchanged()
+			continue
+		}
+		before[match.Id] = *match
+		updates = append(updates, updated)
+	}
+
+	if err := r.insertImported(ctx, tx, creates, report, actor); err != nil {
+		return err
+	}
+	return r.updateImported(ctx, tx, updates, before, report, actor)
+}
+
+// matchImport finds the book a row updates, nil when it creates one.
+func matchImport(book domain.Book, byISBN map[string]model.Book, byKey map[string][]model.Book) (*model.Book, error) {
+	if existing, ok := byISBN[book.ISBN()]; ok {
+		if existing.DeletedAt.Valid {
+			return nil, fmt.Errorf("book %d with ISBN %s is archived, restore it first", existing.Id, book.ISBN())
+		}
+		return &existing, nil
+	}
+
+	var candidates []model.Book
+	for _, existing := range byKey[importKey(book.Title(), book.Author())] {
+		// Another edition of the same book has an ISBN of its own.
+		if book.ISBN() == "" || !existing.ISBN.Valid {
+			candidates = append(candidates, existing)
+		}
+	}
+	switch len(candidates) {
+	case 0:
+		return nil, nil
+	case 1:
+		return &candidates[0], nil
+	default:
+		return nil, fmt.Errorf("title and author match %d books, add an ISBN", len(candidates))
+	}
+}
+
+func importKey(title, author string) string {
+	return strings.ToLower(title) + "\x1f" + strings.ToLower(author)
+}
+
+func (r *ImportRepository) insertImported(
+	ctx context.Context, tx *sqlx.Tx, books []domain.Book, report *domain.ImportReport, actor domain.AuditActor,
+) error {
+	if len(books) == 0 {
+		return nil
+	}
+	var (
+		titles, authors, currencies        pq.StringArray
+		years, stocks, categoryIds, prices pq.Int64Array
+		isbns                              []sql.NullString
+	)
+	for _, book := range books {
+		titles = append(titles, book.Title())
+		authors = append(authors, book.Author())
+		years = append(years, int64(book.Year()))
+		prices = append(prices, book.Price().Amount())
+		stocks = append(stocks, int64(book.Stock()))
+		categoryIds = append(categoryIds, int64(book.CategoryId()))
+		currencies = append(currencies, book.Price().Currency())
+		isbns = append(isbns, toNullString(book.ISBN()))
+	}
+
+	var created []int64
+	err := tx.SelectContext(ctx, &created, sqlImportInsertBooks,
+		titles, authors, years, prices, stocks, categoryIds, currencies, pq.Array(isbns),
+	)
+	if err != nil {
+		if pg.IsForeignKeyViolationErr(err) {
+			return domain.ErrInvalidCategory
+		}
+		return model.WrapDatabaseError(err, "failed to insert imported books")
+	}
+	ids := pq.Int64Array(created)
+
+	if _, err := tx.ExecContext(ctx, sqlImportPriceHistory, ids, domain.PriceReasonCreate); err != nil {
+		return model.WrapDatabaseError(err, "failed to record price history")
+	}
+	_, err = tx.ExecContext(ctx, sqlImportAuditCreated,
+		ids, toNullInt64(actor.UserId()), toNullInt64(actor.APIKeyId()), domain.AuditActionCreate, domain.AuditEntityBook,
+		toNullString(actor.RequestId()), toNullString(actor.IPAddress()),
+	)
+	if err != nil {
+		return model.WrapDatabaseError(err, "failed to insert audit entries")
+	}
+	for range ids {
+		report.AddCreated()
+	}
+	return nil
+}
+
+func (r *ImportRepository) updateImported(
+	ctx context.Context, tx *sqlx.Tx, books []model.Book, before map[int]model.Book,
+	report *domain.ImportReport, actor domain.AuditActor,
+) error {
+	if len(books) == 0 {
+		return nil
+	}
+	var (
+		titles, authors                         pq.StringArray
+		ids, years, stocks, categoryIds, prices pq.Int64Array
+		isbns                                   []sql.NullString
+	)
+	for _, book := range books {
+		ids = append(ids, int64(book.Id))
+		titles = append(titles, book.Title)
+		authors = append(authors, book.Author)
+		years = append(years, int64(book.Year))
+		prices = append(prices, book.Price)
+		stocks = append(stocks, int64(book.Stock))
+		categoryIds = append(categoryIds, int64(book.CategoryId))
+		isbns = append(isbns, book.ISBN)
+	}
+
+	var updated []model.Book
+	err := tx.SelectContext(ctx, &updated, sqlImportUpdateBooks,
+		ids, titles, authors, years, prices, stocks, categoryIds, pq.Array(isbns),
+	)
+	if err != nil {
+		if pg.IsForeignKeyViolationErr(err) {
+			return domain.ErrInvalidCategory
+		}
+		return model.WrapDatabaseError(err, "failed to update imported books")
+	}
+
+	repriced := pq.Int64Array{}
+	for _, after := range updated {
+		if after.Price != before[after.Id].Price {
+			repriced = append(repriced, int64(after.Id))
+		}
+		err := writeAudit(ctx, tx, actor, domain.AuditActionUpdate, domain.AuditEntityBook, after.Id, before[after.Id], after)
+		if err != nil {
+			return err
+		}
+		report.AddUpdated()
+	}
+	if len(repriced) > 0 {
+		if _, err := tx.ExecContext(ctx, sqlImportPriceHistory, repriced, domain.PriceReasonUpdate); err != nil {
+			return model.WrapDatabaseError(err, "failed to record price history")
+		}
+	}
+	return nil
+}
+
+// archiveRemoved archives the listed books removals name. Books that are unknown or
+// archived already are counted as unchanged.
+func (r *ImportRepository) archiveRemoved(
+	ctx context.Context, tx *sqlx.Tx, removals []domain.BookRemoval, report *domain.ImportReport, actor domain.AuditActor,
+) error {
+	if len(removals) == 0 {
+		return nil
+	}
+	isbns := make(pq.StringArray, len(removals))
+	for i, removal := range removals {
+		isbns[i] = removal.ISBN()
+	}
+	var archived []model.Book
+	if err := tx.SelectContext(ctx, &archived, sqlImportArchiveBooks, isbns); err != nil {
+		return model.WrapDatabaseError(err, "failed to archive removed books")
+	}
+	for _, after := range archived {
+		before := after
+		before.DeletedAt = sql.NullTime{}
+		if err := writeAudit(ctx, tx, actor, domain.AuditActionArchive, domain.AuditEntityBook, after.Id, before, after); err != nil {
+			return err
+		}
+		report.AddArchived()
+	}
+	for range len(removals) - len(archived) {
+		report.AddUnchanged()
+	}
+	return nil
+}
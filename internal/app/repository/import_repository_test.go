@@ -0,0 +1,118 @@
+package repository
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/jmoiron/sqlx"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"toptal/internal/app/domain"
+	"toptal/internal/pkg/pg"
+)
+
+func newImportedBook(t *testing.T, isbn, title string, cents int64) domain.Book {
+	book, err := domain.NewBook(1, title, 1965, "Frank Herbert", usd(cents), 3, 2)
+	require.NoError(t, err)
+	require.NoError(t, book.SetISBN(isbn))
+	return book
+}
+
+func TestImportRepository_ImportBooks(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	require.NoError(t, err)
+	defer db.Close()
+
+	repo := NewImportRepository(pg.NewDB(sqlx.NewDb(db, "sqlmock")))
+	columns := []string{"id", "title", "author", "year", "price", "currency", "stock", "category_id", "isbn", "deleted_at"}
+	books := []domain.BookImport{
+		domain.NewBookImport(2, newImportedBook(t, "9780441172719", "Dune", 1200)),
+		domain.NewBookImport(3, newImportedBook(t, "", "Dune Messiah", 900)),
+		domain.NewBookImport(4, newImportedBook(t, "", "Children of Dune", 1000)),
+		domain.NewBookImport(5, newImportedBook(t, "9780399128967", "God Emperor of Dune", 1100)),
+	}
+
+	mock.ExpectBegin()
+	mock.ExpectQuery("SELECT \\*\\s+FROM books\\s+WHERE isbn = ANY\\(\\$1\\)").
+		WithArgs(sqlmock.AnyArg(), sqlmock.AnyArg()).
+		WillReturnRows(sqlmock.NewRows(columns).
+			AddRow(1, "Dune", "Frank Herbert", 1965, 1000, "USD", 3, 2, "9780441172719", nil).
+			AddRow(7, "Children of Dune", "Frank Herbert", 1965, 1000, "USD", 3, 2, nil, nil).
+			AddRow(8, "God Emperor of Dune", "Frank Herbert", 1981, 1100, "USD", 3, 2, "9780399128967", time.Now()))
+	mock.ExpectQuery("INSERT INTO books \\(title, author, year, price, stock, category_id, currency, isbn\\)").
+		WithArgs(sqlmock.AnyArg(), sqlmock.AnyArg(), sqlmock.AnyArg(), sqlmock.AnyArg(),
+			sqlmock.AnyArg(), sqlmock.AnyArg(), sqlmock.AnyArg(), sqlmock.AnyArg()).
+		WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow(9))
+	mock.ExpectExec("INSERT INTO book_price_history").
+		WithArgs(sqlmock.AnyArg(), domain.PriceReasonCreate).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectExec("INSERT INTO audit_log").
+		WithArgs(sqlmock.AnyArg(), nil, nil, domain.AuditActionCreate, domain.AuditEntityBook, nil, nil).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectQuery("UPDATE books AS b").
+		WithArgs(sqlmock.AnyArg(), sqlmock.AnyArg(), sqlmock.AnyArg(), sqlmock.AnyArg(),
+			sqlmock.AnyArg(), sqlmock.AnyArg(), sqlmock.AnyArg(), sqlmock.AnyArg()).
+		WillReturnRows(sqlmock.NewRows(columns).
+			AddRow(1, "Dune", "Frank Herbert", 1965, 1200, "USD", 3, 2, "9780441172719", nil))
+	mock.ExpectExec("INSERT INTO audit_log").
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectExec("INSERT INTO book_price_history").
+		WithArgs(sqlmock.AnyArg(), domain.PriceReasonUpdate).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectRollback()
+
+	report := domain.NewImportReport(true)
+	require.NoError(t, repo.ImportBooks(context.Background(), books, nil, &report, domain.AuditActor{}))
+
+	assert.Equal(t, 1, report.Created())
+	assert.Equal(t, 1, report.Updated())
+	assert.Equal(t, 1, report.Unchanged())
+	errs := report.Errors()
+	require.Len(t, errs, 1)
+	assert.Equal(t, 5, errs[0].Line())
+	assert.Contains(t, errs[0].Message(), "archived")
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestImportRepository_ImportBooksRemovals(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	require.NoError(t, err)
+	defer db.Close()
+
+	repo := NewImportRepository(pg.NewDB(sqlx.NewDb(db, "sqlmock")))
+	columns := []string{"id", "title", "author", "year", "price", "currency", "stock", "category_id", "isbn", "deleted_at"}
+	books := []domain.BookImport{
+		domain.NewBookImport(2, newImportedBook(t, "9780441172719", "Dune", 1000)).WithoutStock(),
+	}
+	removals := []domain.BookRemoval{
+		domain.NewBookRemoval(3, "9780399128967"),
+		domain.NewBookRemoval(4, "9780441013593"),
+	}
+
+	mock.ExpectBegin()
+	mock.ExpectQuery("SELECT \\*\\s+FROM books\\s+WHERE isbn = ANY\\(\\$1\\)").
+		WithArgs(sqlmock.AnyArg(), sqlmock.AnyArg()).
+		WillReturnRows(sqlmock.NewRows(columns).
+			AddRow(1, "Dune", "Frank Herbert", 1965, 1000, "USD", 9, 2, "9780441172719", nil))
+	mock.ExpectQuery("UPDATE books\\s+SET deleted_at = now\\(\\)\\s+WHERE isbn = ANY\\(\\$1\\)").
+		WithArgs(sqlmock.AnyArg()).
+		WillReturnRows(sqlmock.NewRows(columns).
+			AddRow(8, "God Emperor of Dune", "Frank Herbert", 1981, 1100, "USD", 3, 2, "9780399128967", time.Now()))
+	mock.ExpectExec("INSERT INTO audit_log").
+		WithArgs(sqlmock.AnyArg(), sqlmock.AnyArg(), domain.AuditActionArchive, domain.AuditEntityBook, 8,
+			sqlmock.AnyArg(), sqlmock.AnyArg(), sqlmock.AnyArg(), sqlmock.AnyArg()).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectCommit()
+
+	report := domain.NewImportReport(false)
+	require.NoError(t, repo.ImportBooks(context.Background(), books, removals, &report, domain.AuditActor{}))
+
+	// The stock of 9 is kept, so the book is as imported.
+	assert.Equal(t, 2, report.Unchanged())
+	assert.Equal(t, 1, report.Archived())
+	assert.Empty(t, report.Errors())
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
@@ -1,17 +1,50 @@
 package repository
 
 import (
+	"database/sql"
+	"encoding/json"
+	"fmt"
 	"log"
 	"log/slog"
+	"time"
 	"toptal/internal/app/domain"
 	"toptal/internal/app/repository/model"
 )
 
 func toDomainBook(book model.Book) domain.Book {
-	b, err := domain.NewBook(book.Id, book.Title, book.Year, book.Author, book.Price, book.Stock, book.CategoryId)
+	price, err := domain.NewMoney(book.Price, book.Currency)
 	if err != nil {
 		log.Fatalf("failed to map model.Book to domain.Book: %v", err)
 	}
+	b, err := domain.NewBook(book.Id, book.Title, book.Year, book.Author, price, book.Stock, book.CategoryId)
+	if err != nil {
+		log.Fatalf("failed to map model.Book to domain.Book: %v", err)
+	}
+	if err := b.SetISBN(book.ISBN.String); err != nil {
+		log.Fatalf("failed to map model.Book to domain.Book: %v", err)
+	}
+	if book.SalePrice.Valid {
+		salePrice, _ := domain.NewMoney(book.SalePrice.Int64, book.Currency)
+		_ = b.SetSale(salePrice, fromNullTime(book.SaleEndsAt))
+	}
+	_ = b.SetArchivedAt(fromNullTime(book.DeletedAt))
+	_ = b.SetAddedAt(fromNullTime(book.CreatedAt))
+	if book.CoverKey.Valid {
+		cover, err := domain.NewCover(book.CoverKey.String, book.CoverType.String, fromNullTime(book.CoverUpdatedAt))
+		if err != nil {
+			log.Fatalf("failed to map model.Book to domain.Book: %v", err)
+		}
+		_ = b.SetCover(cover)
+	}
+	// The metadata was validated when it was stored.
+	_ = b.SetDescription(book.Description.String)
+	_ = b.SetPageCount(int(book.PageCount.Int64))
+	_ = b.SetLanguage(book.Language.String)
+	_ = b.SetPublisher(book.Publisher.String)
+	_ = b.SetPublicationDate(fromNullTime(book.PublicationDate))
+	_ = b.SetSeries(book.Series.String, int(book.SeriesPosition.Int64))
+	_ = b.SetDimensions(int(book.WidthMm.Int64), int(book.HeightMm.Int64), int(book.DepthMm.Int64))
+	_ = b.SetWeightGrams(int(book.WeightG.Int64))
 	return b
 }
 
@@ -24,7 +57,15 @@ func toDomainBooks(books []model.Book) []domain.Book {
 }
 
 func toDomainCategory(category model.Category) (domain.Category, error) {
-	return domain.NewCategory(category.Id, category.Name)
+	c, err := domain.NewCategory(category.Id, category.Name)
+	if err != nil {
+		return c, err
+	}
+	if err := c.SetTaxClass(category.TaxClass); err != nil {
+		return c, err
+	}
+	_ = c.SetArchivedAt(fromNullTime(category.DeletedAt))
+	return c, nil
 }
 
 func toDomainCategories(categories []model.Category) ([]domain.Category, error) {
@@ -41,14 +82,609 @@ func toDomainCategories(categories []model.Category) ([]domain.Category, error)
 }
 
 func toDomainUser(user model.User) (domain.User, error) {
-	return domain.NewUser(user.Id, user.Username, user.PasswordHash, user.Admin)
+	u, err := domain.NewUser(user.Id, user.Username, user.PasswordHash, user.Admin)
+	if err != nil {
+		return u, err
+	}
+	if err := u.SetTOTP(user.TotpSecret.String, user.TotpEnabled); err != nil {
+		return u, err
+	}
+	if err := u.SetEmail(user.Email.String); err != nil {
+		return u, err
+	}
+	if err := u.SetEmailVerified(user.EmailVerified); err != nil {
+		return u, err
+	}
+	_ = u.SetCreatedAt(user.CreatedAt)
+	return u, nil
 }
 
 func toModelUser(user domain.User) model.User {
 	return model.User{
-		Id:           user.Id(),
-		Username:     user.Username(),
-		PasswordHash: user.PasswordHash(),
-		Admin:        user.Admin(),
+		Id:            user.Id(),
+		Username:      user.Username(),
+		PasswordHash:  user.PasswordHash(),
+		Admin:         user.Admin(),
+		Email:         toNullString(user.Email()),
+		EmailVerified: user.EmailVerified(),
+	}
+}
+
+func toDomainAPIKey(key model.APIKey) (domain.APIKey, error) {
+	k := domain.APIKey{}
+	if err := k.SetId(key.Id); err != nil {
+		return k, err
+	}
+	if err := k.SetName(key.Name); err != nil {
+		return k, err
+	}
+	if err := k.SetCredentials(key.Prefix, key.KeyHash); err != nil {
+		return k, err
+	}
+	if err := k.SetScopes(key.Scopes); err != nil {
+		return k, err
+	}
+	if err := k.SetCreatedBy(key.CreatedBy); err != nil {
+		return k, err
+	}
+	_ = k.SetCreatedAt(key.CreatedAt)
+	_ = k.SetExpiresAt(fromNullTime(key.ExpiresAt))
+	_ = k.SetLastUsedAt(fromNullTime(key.LastUsedAt))
+	_ = k.SetRevokedAt(fromNullTime(key.RevokedAt))
+	return k, nil
+}
+
+func toDomainAPIKeys(keys []model.APIKey) ([]domain.APIKey, error) {
+	domains := make([]domain.APIKey, len(keys))
+	var err error
+	for i, key := range keys {
+		domains[i], err = toDomainAPIKey(key)
+		if err != nil {
+			slog.Error("failed to map model.APIKey to domain.APIKey", "error", err)
+			return nil, err
+		}
 	}
+	return domains, nil
+}
+
+func fromNullTime(t sql.NullTime) time.Time {
+	if !t.Valid {
+		return time.Time{}
+	}
+	return t.Time
+}
+
+func toNullTime(t time.Time) sql.NullTime {
+	return sql.NullTime{Time: t, Valid: !t.IsZero()}
+}
+
+func toNullString(s string) sql.NullString {
+	return sql.NullString{String: s, Valid: s != ""}
+}
+
+func toNullInt64(i int) sql.NullInt64 {
+	return sql.NullInt64{Int64: int64(i), Valid: i != 0}
+}
+
+func toDomainOutboxEmail(email model.OutboxEmail) (domain.OutboxEmail, error) {
+	e, err := domain.NewOutboxEmail(email.Recipient, email.Subject, email.Body)
+	if err != nil {
+		return e, err
+	}
+	if err := e.SetId(email.Id); err != nil {
+		return e, err
+	}
+	if err := e.SetAttempts(email.Attempts); err != nil {
+		return e, err
+	}
+	return e, nil
+}
+
+func toDomainOutboxEmails(emails []model.OutboxEmail) ([]domain.OutboxEmail, error) {
+	domains := make([]domain.OutboxEmail, len(emails))
+	var err error
+	for i, email := range emails {
+		domains[i], err = toDomainOutboxEmail(email)
+		if err != nil {
+			slog.Error("failed to map model.OutboxEmail to domain.OutboxEmail", "error", err)
+			return nil, err
+		}
+	}
+	return domains, nil
+}
+
+func toDomainOrders(orders []model.Order, items []model.OrderItem, taxLines []model.OrderTaxLine) ([]domain.Order, error) {
+	itemsByOrder := make(map[int][]domain.OrderItem, len(orders))
+	for _, item := range items {
+		i, err := toDomainOrderItem(item)
+		if err != nil {
+			slog.Error("failed to map model.OrderItem to domain.OrderItem", "error", err)
+			return nil, err
+		}
+		itemsByOrder[item.OrderId] = append(itemsByOrder[item.OrderId], i)
+	}
+
+	linesByOrder := make(map[int][]domain.OrderTaxLine, len(orders))
+	for _, line := range taxLines {
+		net, err := domain.NewMoney(line.Net, line.Currency)
+		if err != nil {
+			slog.Error("failed to map model.OrderTaxLine to domain.OrderTaxLine", "error", err)
+			return nil, err
+		}
+		tax, _ := domain.NewMoney(line.Tax, line.Currency)
+		l, err := domain.NewOrderTaxLine(line.TaxClass, line.Rate, net, tax)
+		if err != nil {
+			slog.Error("failed to map model.OrderTaxLine to domain.OrderTaxLine", "error", err)
+			return nil, err
+		}
+		linesByOrder[line.OrderId] = append(linesByOrder[line.OrderId], l)
+	}
+
+	domains := make([]domain.Order, len(orders))
+	for i, order := range orders {
+		o, err := toDomainOrder(order, itemsByOrder[order.Id], linesByOrder[order.Id])
+		if err != nil {
+			slog.Error("failed to map model.Order to domain.Order", "error", err)
+			return nil, err
+		}
+		domains[i] = o
+	}
+	return domains, nil
+}
+
+func toDomainOrder(order model.Order, items []domain.OrderItem, taxLines []domain.OrderTaxLine) (domain.Order, error) {
+	total, err := domain.NewMoney(order.Total, order.Currency)
+	if err != nil {
+		return domain.Order{}, err
+	}
+	o, err := domain.NewOrder(order.Id, order.UserId, total, order.CreatedAt, items)
+	if err != nil {
+		return o, err
+	}
+	subtotal, _ := domain.NewMoney(order.Subtotal, order.Currency)
+	taxTotal, _ := domain.NewMoney(order.TaxTotal, order.Currency)
+	if err := o.SetTax(subtotal, taxTotal, order.PricesIncludeTax, taxLines); err != nil {
+		return o, err
+	}
+	// Orders of deleted accounts keep only the region and country they were taxed for.
+	if order.ShippingName.Valid {
+		address, err := domain.NewShippingAddress(
+			order.ShippingName.String, order.ShippingLine1.String, order.ShippingLine2.String,
+			order.ShippingCity.String, order.ShippingPostal.String, order.ShippingRegion.String,
+			order.ShippingCountry.String,
+		)
+		if err != nil {
+			return o, err
+		}
+		_ = o.SetShippingAddress(address)
+	}
+	return o, nil
+}
+
+func toDomainOrderItem(item model.OrderItem) (domain.OrderItem, error) {
+	price, err := domain.NewMoney(item.Price, item.Currency)
+	if err != nil {
+		return domain.OrderItem{}, err
+	}
+	i, err := domain.NewOrderItem(int(item.BookId.Int64), item.Title, item.Author, price)
+	if err != nil {
+		return i, err
+	}
+	if err := i.SetTaxClass(item.TaxClass); err != nil {
+		return i, err
+	}
+	tax, _ := domain.NewMoney(item.Tax, item.Currency)
+	if err := i.SetTax(tax); err != nil {
+		return i, err
+	}
+	if err := i.SetFormat(int(item.FormatId.Int64), item.Format.String); err != nil {
+		return i, err
+	}
+	return i, nil
+}
+
+// toDomainCheckoutItems turns the priced cart lines into the items of an order in currency.
+func toDomainCheckoutItems(lines []model.CheckoutLine, currency string) ([]domain.OrderItem, error) {
+	items := make([]domain.OrderItem, len(lines))
+	for i, line := range lines {
+		price, err := domain.NewMoney(line.Price, currency)
+		if err != nil {
+			return nil, err
+		}
+		items[i], err = domain.NewOrderItem(line.BookId, line.Title, line.Author, price)
+		if err != nil {
+			return nil, err
+		}
+		if err := items[i].SetTaxClass(line.TaxClass); err != nil {
+			return nil, err
+		}
+		if err := items[i].SetFormat(int(line.FormatId.Int64), line.Format.String); err != nil {
+			return nil, err
+		}
+	}
+	return items, nil
+}
+
+func toModelOrderItems(orderId int, items []domain.OrderItem) []model.OrderItem {
+	models := make([]model.OrderItem, len(items))
+	for i, item := range items {
+		models[i] = model.OrderItem{
+			OrderId:  orderId,
+			BookId:   toNullInt64(item.BookId()),
+			Title:    item.Title(),
+			Author:   item.Author(),
+			Price:    item.Price().Amount(),
+			Currency: item.Price().Currency(),
+			TaxClass: item.TaxClass(),
+			Tax:      item.Tax().Amount(),
+			FormatId: toNullInt64(item.FormatId()),
+			Format:   toNullString(item.Format()),
+		}
+	}
+	return models
+}
+
+func toModelOrderTaxLines(orderId int, lines []domain.OrderTaxLine) []model.OrderTaxLine {
+	models := make([]model.OrderTaxLine, len(lines))
+	for i, line := range lines {
+		models[i] = model.OrderTaxLine{
+			OrderId:  orderId,
+			TaxClass: line.TaxClass(),
+			Rate:     line.BasisPoints(),
+			Net:      line.Net().Amount(),
+			Tax:      line.Tax().Amount(),
+			Currency: line.Net().Currency(),
+		}
+	}
+	return models
+}
+
+func toDomainSession(session model.Session) (domain.Session, error) {
+	s, err := domain.NewSession(session.UserId, session.UserAgent.String, session.IPAddress.String, session.ExpiresAt)
+	if err != nil {
+		return s, err
+	}
+	if err := s.SetId(session.Id); err != nil {
+		return s, err
+	}
+	_ = s.SetCreatedAt(session.CreatedAt)
+	_ = s.SetLastSeenAt(session.LastSeenAt)
+	_ = s.SetRevokedAt(fromNullTime(session.RevokedAt))
+	return s, nil
+}
+
+func toDomainSessions(sessions []model.Session) ([]domain.Session, error) {
+	domains := make([]domain.Session, len(sessions))
+	var err error
+	for i, session := range sessions {
+		domains[i], err = toDomainSession(session)
+		if err != nil {
+			slog.Error("failed to map model.Session to domain.Session", "error", err)
+			return nil, err
+		}
+	}
+	return domains, nil
+}
+
+func toDomainAuditEntry(entry model.AuditEntry) (domain.AuditEntry, error) {
+	var before, after map[string]any
+	if len(entry.Before) > 0 {
+		if err := json.Unmarshal(entry.Before, &before); err != nil {
+			return domain.AuditEntry{}, fmt.Errorf("failed to decode audit entry %d: %w", entry.Id, err)
+		}
+	}
+	if len(entry.After) > 0 {
+		if err := json.Unmarshal(entry.After, &after); err != nil {
+			return domain.AuditEntry{}, fmt.Errorf("failed to decode audit entry %d: %w", entry.Id, err)
+		}
+	}
+
+	actor := domain.NewAuditActor(
+		int(entry.ActorUserId.Int64), int(entry.ActorAPIKeyId.Int64), entry.RequestId.String, entry.IPAddress.String,
+	)
+	e, err := domain.NewAuditEntry(actor, entry.Action, entry.EntityType, entry.EntityId, before, after)
+	if err != nil {
+		return e, err
+	}
+	if err := e.SetId(entry.Id); err != nil {
+		return e, err
+	}
+	_ = e.SetCreatedAt(entry.CreatedAt)
+	return e, nil
+}
+
+func toDomainAuditEntries(entries []model.AuditEntry) ([]domain.AuditEntry, error) {
+	domains := make([]domain.AuditEntry, len(entries))
+	var err error
+	for i, entry := range entries {
+		domains[i], err = toDomainAuditEntry(entry)
+		if err != nil {
+			slog.Error("failed to map model.AuditEntry to domain.AuditEntry", "error", err)
+			return nil, err
+		}
+	}
+	return domains, nil
+}
+
+func toDomainPriceChange(change model.PriceChange) (domain.PriceChange, error) {
+	price, err := domain.NewMoney(change.Price, change.Currency)
+	if err != nil {
+		return domain.PriceChange{}, err
+	}
+	c, err := domain.NewPriceChange(
+		change.BookId, price, change.StartsAt, fromNullTime(change.EndsAt), int(change.CreatedBy.Int64),
+	)
+	if err != nil {
+		return c, err
+	}
+	if err := c.SetId(change.Id); err != nil {
+		return c, err
+	}
+	_ = c.SetCreatedAt(change.CreatedAt)
+	_ = c.SetAppliedAt(fromNullTime(change.AppliedAt))
+	_ = c.SetEndedAt(fromNullTime(change.EndedAt))
+	_ = c.SetCancelledAt(fromNullTime(change.CancelledAt))
+	return c, nil
+}
+
+func toDomainPriceChanges(changes []model.PriceChange) ([]domain.PriceChange, error) {
+	domains := make([]domain.PriceChange, len(changes))
+	var err error
+	for i, change := range changes {
+		domains[i], err = toDomainPriceChange(change)
+		if err != nil {
+			slog.Error("failed to map model.PriceChange to domain.PriceChange", "error", err)
+			return nil, err
+		}
+	}
+	return domains, nil
+}
+
+func toDomainPriceHistory(entries []model.PriceHistoryEntry) ([]domain.PriceHistoryEntry, error) {
+	domains := make([]domain.PriceHistoryEntry, len(entries))
+	for i, entry := range entries {
+		price, err := domain.NewMoney(entry.Price, entry.Currency)
+		if err != nil {
+			slog.Error("failed to map model.PriceHistoryEntry to domain.PriceHistoryEntry", "error", err)
+			return nil, err
+		}
+		var salePrice domain.Money
+		if entry.SalePrice.Valid {
+			salePrice, _ = domain.NewMoney(entry.SalePrice.Int64, entry.Currency)
+		}
+		domains[i] = domain.NewPriceHistoryEntry(
+			entry.BookId, price, salePrice, entry.SalePrice.Valid, entry.Reason,
+			int(entry.PriceChangeId.Int64), entry.ChangedAt,
+		)
+	}
+	return domains, nil
+}
+
+func toDomainListPrices(prices []model.ListPrice) ([]domain.Money, error) {
+	domains := make([]domain.Money, len(prices))
+	var err error
+	for i, price := range prices {
+		domains[i], err = domain.NewMoney(price.Price, price.Currency)
+		if err != nil {
+			slog.Error("failed to map model.ListPrice to domain.Money", "error", err)
+			return nil, err
+		}
+	}
+	return domains, nil
+}
+
+// toDomainCartBooks maps the books in a cart. Lines for a format carry its price and stock.
+func toDomainCartBooks(books []model.CartBook) ([]domain.Book, error) {
+	domains := make([]domain.Book, len(books))
+	for i, book := range books {
+		domains[i] = toDomainBook(book.Book)
+		if !book.FormatId.Valid {
+			continue
+		}
+		price, err := domain.NewMoney(book.FormatPrice.Int64, book.FormatCurrency.String)
+		if err != nil {
+			return nil, err
+		}
+		format, err := domain.NewBookFormat(book.Id, book.Format.String, price, int(book.FormatStock.Int64))
+		if err != nil {
+			slog.Error("failed to map model.CartBook to domain.Book", "error", err)
+			return nil, err
+		}
+		domains[i] = domains[i].WithFormat(format)
+	}
+	return domains, nil
+}
+
+func toDomainBookFormat(format model.BookFormat) (domain.BookFormat, error) {
+	price, err := domain.NewMoney(format.Price, format.Currency)
+	if err != nil {
+		return domain.BookFormat{}, err
+	}
+	f, err := domain.NewBookFormat(format.BookId, format.Format, price, int(format.Stock.Int64))
+	if err != nil {
+		return f, err
+	}
+	if err := f.SetId(format.Id); err != nil {
+		return f, err
+	}
+	if format.FileKey.Valid {
+		file, err := domain.NewFormatFile(format.FileKey.String, format.FileName.String, format.FileType.String, format.FileSize.Int64)
+		if err != nil {
+			return f, err
+		}
+		if err := f.SetFile(file); err != nil {
+			return f, err
+		}
+	}
+	_ = f.SetUpdatedAt(format.UpdatedAt)
+	return f, nil
+}
+
+func toDomainBookFormats(formats []model.BookFormat) ([]domain.BookFormat, error) {
+	domains := make([]domain.BookFormat, len(formats))
+	var err error
+	for i, format := range formats {
+		domains[i], err = toDomainBookFormat(format)
+		if err != nil {
+			slog.Error("failed to map model.BookFormat to domain.BookFormat", "error", err)
+			return nil, err
+		}
+	}
+	return domains, nil
+}
+
+func toDomainDownload(download model.Download) (domain.Download, error) {
+	d, err := domain.NewDownload(
+		download.Id, download.UserId, download.OrderId, download.Title, download.Author, download.Format,
+		download.DownloadCount, download.CreatedAt,
+	)
+	if err != nil {
+		return d, err
+	}
+	_ = d.SetLastDownloadAt(fromNullTime(download.LastDownload))
+	if download.FileKey.Valid {
+		file, err := domain.NewFormatFile(
+			download.FileKey.String, download.FileName.String, download.FileType.String, download.FileSize.Int64,
+		)
+		if err != nil {
+			return d, err
+		}
+		_ = d.SetFile(file)
+	}
+	return d, nil
+}
+
+func toDomainDownloads(downloads []model.Download) ([]domain.Download, error) {
+	domains := make([]domain.Download, len(downloads))
+	var err error
+	for i, download := range downloads {
+		domains[i], err = toDomainDownload(download)
+		if err != nil {
+			slog.Error("failed to map model.Download to domain.Download", "error", err)
+			return nil, err
+		}
+	}
+	return domains, nil
+}
+
+func toDomainSupplier(supplier model.Supplier) (domain.Supplier, error) {
+	s, err := domain.NewSupplier(supplier.Id, supplier.Name, supplier.Email.String, supplier.Phone.String)
+	if err != nil {
+		return s, err
+	}
+	_ = s.SetCreatedAt(supplier.CreatedAt)
+	return s, nil
+}
+
+func toDomainSuppliers(suppliers []model.Supplier) ([]domain.Supplier, error) {
+	domains := make([]domain.Supplier, len(suppliers))
+	var err error
+	for i, supplier := range suppliers {
+		domains[i], err = toDomainSupplier(supplier)
+		if err != nil {
+			slog.Error("failed to map model.Supplier to domain.Supplier", "error", err)
+			return nil, err
+		}
+	}
+	return domains, nil
+}
+
+func toDomainBookSupplier(bookSupplier model.BookSupplier) (domain.BookSupplier, error) {
+	costPrice, err := domain.NewMoney(bookSupplier.CostPrice, bookSupplier.Currency)
+	if err != nil {
+		return domain.BookSupplier{}, err
+	}
+	b, err := domain.NewBookSupplier(bookSupplier.BookId, bookSupplier.SupplierId, costPrice, bookSupplier.SupplierSKU.String)
+	if err != nil {
+		return b, err
+	}
+	_ = b.SetSupplierName(bookSupplier.SupplierName)
+	_ = b.SetUpdatedAt(bookSupplier.UpdatedAt)
+	return b, nil
+}
+
+func toDomainBookSuppliers(bookSuppliers []model.BookSupplier) ([]domain.BookSupplier, error) {
+	domains := make([]domain.BookSupplier, len(bookSuppliers))
+	var err error
+	for i, bookSupplier := range bookSuppliers {
+		domains[i], err = toDomainBookSupplier(bookSupplier)
+		if err != nil {
+			slog.Error("failed to map model.BookSupplier to domain.BookSupplier", "error", err)
+			return nil, err
+		}
+	}
+	return domains, nil
+}
+
+func toDomainPurchaseOrder(order model.PurchaseOrder, items []model.PurchaseOrderItem) (domain.PurchaseOrder, error) {
+	lines := make([]domain.PurchaseOrderLine, len(items))
+	for i, item := range items {
+		costPrice, err := domain.NewMoney(item.CostPrice, order.Currency)
+		if err != nil {
+			return domain.PurchaseOrder{}, err
+		}
+		line, err := domain.NewPurchaseOrderLine(int(item.BookId.Int64), item.Title, item.Quantity, costPrice)
+		if err != nil {
+			return domain.PurchaseOrder{}, err
+		}
+		if err := line.SetReceived(item.Received); err != nil {
+			return domain.PurchaseOrder{}, err
+		}
+		lines[i] = line
+	}
+	o, err := domain.NewPurchaseOrder(order.Id, order.SupplierId, order.Status, lines)
+	if err != nil {
+		return o, err
+	}
+	_ = o.SetSupplierName(order.SupplierName)
+	_ = o.SetCreatedAt(order.CreatedAt)
+	_ = o.SetSentAt(fromNullTime(order.SentAt))
+	_ = o.SetReceivedAt(fromNullTime(order.ReceivedAt))
+	return o, nil
+}
+
+// toDomainPurchaseOrders maps orders with their items, which are grouped by order.
+func toDomainPurchaseOrders(orders []model.PurchaseOrder, items []model.PurchaseOrderItem) ([]domain.PurchaseOrder, error) {
+	itemsByOrder := make(map[int][]model.PurchaseOrderItem, len(orders))
+	for _, item := range items {
+		itemsByOrder[item.PurchaseOrderId] = append(itemsByOrder[item.PurchaseOrderId], item)
+	}
+	domains := make([]domain.PurchaseOrder, len(orders))
+	var err error
+	for i, order := range orders {
+		domains[i], err = toDomainPurchaseOrder(order, itemsByOrder[order.Id])
+		if err != nil {
+			slog.Error("failed to map model.PurchaseOrder to domain.PurchaseOrder", "error", err)
+			return nil, err
+		}
+	}
+	return domains, nil
+}
+
+func toDomainStockMovement(movement model.StockMovement) (domain.StockMovement, error) {
+	m, err := domain.NewStockMovement(
+		int(movement.BookId.Int64), movement.Quantity, movement.Reason, int(movement.PurchaseOrderId.Int64),
+	)
+	if err != nil {
+		return m, err
+	}
+	if err := m.SetId(movement.Id); err != nil {
+		return m, err
+	}
+	_ = m.SetTitle(movement.Title)
+	_ = m.SetCreatedAt(movement.CreatedAt)
+	return m, nil
+}
+
+func toDomainStockMovements(movements []model.StockMovement) ([]domain.StockMovement, error) {
+	domains := make([]domain.StockMovement, len(movements))
+	var err error
+	for i, movement := range movements {
+		domains[i], err = toDomainStockMovement(movement)
+		if err != nil {
+			slog.Error("failed to map model.StockMovement to domain.StockMovement", "error", err)
+			return nil, err
+		}
+	}
+	return domains, nil
 }
@@ -0,0 +1,20 @@
+package model
+
+import (
+	"database/sql"
+	"time"
+)
+
+type AuditEntry struct {
+	Id            int            `db:"id"`
+	ActorUserId   sql.NullInt64  `db:"actor_user_id"`
+	ActorAPIKeyId sql.NullInt64  `db:"actor_api_key_id"`
+	Action        string         `db:"action"`
+	EntityType    string         `db:"entity_type"`
+	EntityId      int            `db:"entity_id"`
+	Before        []byte         `db:"before"`
+	After         []byte         `db:"after"`
+	RequestId     sql.NullString `db:"request_id"`
+	IPAddress     sql.NullString `db:"ip_address"`
+	CreatedAt     time.Time      `db:"created_at"`
+}
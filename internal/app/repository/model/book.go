@@ -1,11 +1,74 @@
 package model
 
+import (
+	"database/sql"
+
+	"github.com/lib/pq"
+)
+
 type Book struct {
-	Id         int    `db:"id"`
-	Title      string `db:"title"`
-	Year       int    `db:"year"`
-	Author     string `db:"author"`
-	Price      int    `db:"price"`
-	Stock      int    `db:"stock"`
-	CategoryId int    `db:"category_id"`
+	Id         int            `db:"id"`
+	Title      string         `db:"title"`
+	Year       int            `db:"year"`
+	Author     string         `db:"author"`
+	Price      int64          `db:"price"`
+	Currency   string         `db:"currency"`
+	Stock      int            `db:"stock"`
+	CategoryId int            `db:"category_id"`
+	ISBN       sql.NullString `db:"isbn"`
+	DeletedAt  sql.NullTime   `db:"deleted_at"`
+	SalePrice  sql.NullInt64  `db:"sale_price"`
+	SaleEndsAt sql.NullTime   `db:"sale_ends_at"`
+	CreatedAt  sql.NullTime   `db:"created_at"`
+	// CoverKey, CoverType and CoverUpdatedAt are set once a cover is uploaded.
+	CoverKey       sql.NullString `db:"cover_key"`
+	CoverType      sql.NullString `db:"cover_type"`
+	CoverUpdatedAt sql.NullTime   `db:"cover_updated_at"`
+	// The metadata columns are NULL when unknown.
+	Description     sql.NullString `db:"description"`
+	PageCount       sql.NullInt64  `db:"page_count"`
+	Language        sql.NullString `db:"language"`
+	Publisher       sql.NullString `db:"publisher"`
+	PublicationDate sql.NullTime   `db:"publication_date"`
+	Series          sql.NullString `db:"series"`
+	SeriesPosition  sql.NullInt64  `db:"series_position"`
+	WidthMm         sql.NullInt64  `db:"width_mm"`
+	HeightMm        sql.NullInt64  `db:"height_mm"`
+	DepthMm         sql.NullInt64  `db:"depth_mm"`
+	WeightG         sql.NullInt64  `db:"weight_g"`
+	// ReorderThreshold is NULL when the book is reported as low on stock at the default
+	// threshold.
+	ReorderThreshold sql.NullInt64 `db:"reorder_threshold"`
+}
+
+// ExportedBook is a book read by a catalogue export, with the name of its category.
+type ExportedBook struct {
+	Book
+	CategoryName string `db:"category_name"`
+}
+
+// PurgedBook is a book deleted by the archive purge, with the blob keys of its format files.
+type PurgedBook struct {
+	Book
+	FileKeys pq.StringArray `db:"file_keys"`
+}
+
+// CartBook is a book in a cart. The format columns are set when the cart holds one of its
+// formats rather than the book itself.
+type CartBook struct {
+	Book
+	FormatId       sql.NullInt64  `db:"format_id"`
+	Format         sql.NullString `db:"format"`
+	FormatPrice    sql.NullInt64  `db:"format_price"`
+	FormatCurrency sql.NullString `db:"format_currency"`
+	FormatStock    sql.NullInt64  `db:"format_stock"`
+}
+
+// LowStockBook is a listed book at or below its reorder threshold, with the copies sold
+// over the sales window and the level it was last reported at.
+type LowStockBook struct {
+	Book
+	Threshold     int            `db:"threshold"`
+	Sold          int            `db:"sold"`
+	NotifiedLevel sql.NullString `db:"notified_level"`
 }
@@ -1,6 +1,10 @@
 package model
 
+import "database/sql"
+
 type Category struct {
-	Id   int    `db:"id"`
-	Name string `db:"name"`
+	Id        int          `db:"id"`
+	Name      string       `db:"name"`
+	TaxClass  string       `db:"tax_class"`
+	DeletedAt sql.NullTime `db:"deleted_at"`
 }
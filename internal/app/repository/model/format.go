@@ -0,0 +1,39 @@
+package model
+
+import (
+	"database/sql"
+	"time"
+)
+
+type BookFormat struct {
+	Id        int            `db:"id"`
+	BookId    int            `db:"book_id"`
+	Format    string         `db:"format"`
+	Price     int64          `db:"price"`
+	Currency  string         `db:"currency"`
+	Stock     sql.NullInt64  `db:"stock"`
+	FileKey   sql.NullString `db:"file_key"`
+	FileName  sql.NullString `db:"file_name"`
+	FileType  sql.NullString `db:"file_type"`
+	FileSize  sql.NullInt64  `db:"file_size"`
+	CreatedAt time.Time      `db:"created_at"`
+	UpdatedAt time.Time      `db:"updated_at"`
+}
+
+// Download is a download with the order line it was bought with and the file of its
+// format, if there still is one.
+type Download struct {
+	Id            int            `db:"id"`
+	UserId        int            `db:"user_id"`
+	DownloadCount int            `db:"download_count"`
+	LastDownload  sql.NullTime   `db:"last_download"`
+	CreatedAt     time.Time      `db:"created_at"`
+	OrderId       int            `db:"order_id"`
+	Title         string         `db:"title"`
+	Author        string         `db:"author"`
+	Format        string         `db:"format"`
+	FileKey       sql.NullString `db:"file_key"`
+	FileName      sql.NullString `db:"file_name"`
+	FileType      sql.NullString `db:"file_type"`
+	FileSize      sql.NullInt64  `db:"file_size"`
+}
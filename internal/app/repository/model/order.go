@@ -0,0 +1,61 @@
+package model
+
+import (
+	"database/sql"
+	"time"
+)
+
+type Order struct {
+	Id               int            `db:"id"`
+	UserId           int            `db:"user_id"`
+	Total            int64          `db:"total"`
+	Currency         string         `db:"currency"`
+	CreatedAt        time.Time      `db:"created_at"`
+	Subtotal         int64          `db:"subtotal"`
+	TaxTotal         int64          `db:"tax_total"`
+	PricesIncludeTax bool           `db:"prices_include_tax"`
+	ShippingName     sql.NullString `db:"shipping_name"`
+	ShippingLine1    sql.NullString `db:"shipping_line1"`
+	ShippingLine2    sql.NullString `db:"shipping_line2"`
+	ShippingCity     sql.NullString `db:"shipping_city"`
+	ShippingPostal   sql.NullString `db:"shipping_postal_code"`
+	ShippingRegion   sql.NullString `db:"shipping_region"`
+	ShippingCountry  sql.NullString `db:"shipping_country"`
+}
+
+type OrderItem struct {
+	Id       int            `db:"id"`
+	OrderId  int            `db:"order_id"`
+	BookId   sql.NullInt64  `db:"book_id"`
+	Title    string         `db:"title"`
+	Author   string         `db:"author"`
+	Price    int64          `db:"price"`
+	Currency string         `db:"currency"`
+	TaxClass string         `db:"tax_class"`
+	Tax      int64          `db:"tax"`
+	FormatId sql.NullInt64  `db:"format_id"`
+	Format   sql.NullString `db:"format"`
+	// CategoryId is the category of the book when it was sold.
+	CategoryId sql.NullInt64 `db:"category_id"`
+}
+
+type OrderTaxLine struct {
+	Id       int    `db:"id"`
+	OrderId  int    `db:"order_id"`
+	TaxClass string `db:"tax_class"`
+	Rate     int    `db:"rate"`
+	Net      int64  `db:"net"`
+	Tax      int64  `db:"tax"`
+	Currency string `db:"currency"`
+}
+
+// CheckoutLine is a book in a cart being ordered, priced in the currency of the order.
+type CheckoutLine struct {
+	BookId   int            `db:"book_id"`
+	Title    string         `db:"title"`
+	Author   string         `db:"author"`
+	Price    int64          `db:"price"`
+	TaxClass string         `db:"tax_class"`
+	FormatId sql.NullInt64  `db:"format_id"`
+	Format   sql.NullString `db:"format"`
+}
@@ -0,0 +1,18 @@
+package model
+
+import (
+	"database/sql"
+	"time"
+)
+
+type OutboxEmail struct {
+	Id            int            `db:"id"`
+	Recipient     string         `db:"recipient"`
+	Subject       string         `db:"subject"`
+	Body          string         `db:"body"`
+	CreatedAt     time.Time      `db:"created_at"`
+	NextAttemptAt time.Time      `db:"next_attempt_at"`
+	Attempts      int            `db:"attempts"`
+	LastError     sql.NullString `db:"last_error"`
+	SentAt        sql.NullTime   `db:"sent_at"`
+}
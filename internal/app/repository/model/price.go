@@ -0,0 +1,39 @@
+package model
+
+import (
+	"database/sql"
+	"time"
+)
+
+type PriceChange struct {
+	Id          int           `db:"id"`
+	BookId      int           `db:"book_id"`
+	Price       int64         `db:"price"`
+	Currency    string        `db:"currency"`
+	StartsAt    time.Time     `db:"starts_at"`
+	EndsAt      sql.NullTime  `db:"ends_at"`
+	CreatedBy   sql.NullInt64 `db:"created_by"`
+	CreatedAt   time.Time     `db:"created_at"`
+	AppliedAt   sql.NullTime  `db:"applied_at"`
+	EndedAt     sql.NullTime  `db:"ended_at"`
+	CancelledAt sql.NullTime  `db:"cancelled_at"`
+}
+
+type PriceHistoryEntry struct {
+	Id            int64         `db:"id"`
+	BookId        int           `db:"book_id"`
+	Price         int64         `db:"price"`
+	Currency      string        `db:"currency"`
+	SalePrice     sql.NullInt64 `db:"sale_price"`
+	Reason        string        `db:"reason"`
+	PriceChangeId sql.NullInt64 `db:"price_change_id"`
+	ChangedAt     time.Time     `db:"changed_at"`
+}
+
+// ListPrice is the price of a book in a currency other than its own.
+type ListPrice struct {
+	BookId    int       `db:"book_id"`
+	Currency  string    `db:"currency"`
+	Price     int64     `db:"price"`
+	UpdatedAt time.Time `db:"updated_at"`
+}
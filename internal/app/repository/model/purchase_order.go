@@ -0,0 +1,61 @@
+package model
+
+import (
+	"database/sql"
+	"time"
+)
+
+type Supplier struct {
+	Id        int            `db:"id"`
+	Name      string         `db:"name"`
+	Email     sql.NullString `db:"email"`
+	Phone     sql.NullString `db:"phone"`
+	CreatedAt time.Time      `db:"created_at"`
+}
+
+// BookSupplier is a book_suppliers row. SupplierName is only set when the supplier is
+// joined in.
+type BookSupplier struct {
+	BookId       int            `db:"book_id"`
+	SupplierId   int            `db:"supplier_id"`
+	SupplierName string         `db:"supplier_name"`
+	CostPrice    int64          `db:"cost_price"`
+	Currency     string         `db:"currency"`
+	SupplierSKU  sql.NullString `db:"supplier_sku"`
+	UpdatedAt    time.Time      `db:"updated_at"`
+}
+
+// PurchaseOrder is a purchase_orders row. SupplierName is only set when the supplier is
+// joined in.
+type PurchaseOrder struct {
+	Id           int          `db:"id"`
+	SupplierId   int          `db:"supplier_id"`
+	SupplierName string       `db:"supplier_name"`
+	Status       string       `db:"status"`
+	Currency     string       `db:"currency"`
+	CreatedAt    time.Time    `db:"created_at"`
+	SentAt       sql.NullTime `db:"sent_at"`
+	ReceivedAt   sql.NullTime `db:"received_at"`
+}
+
+// PurchaseOrderItem is a line of a purchase order. BookId is NULL once the book is purged.
+type PurchaseOrderItem struct {
+	Id              int           `db:"id"`
+	PurchaseOrderId int           `db:"purchase_order_id"`
+	BookId          sql.NullInt64 `db:"book_id"`
+	Title           string        `db:"title"`
+	Quantity        int           `db:"quantity"`
+	Received        int           `db:"received"`
+	CostPrice       int64         `db:"cost_price"`
+}
+
+// StockMovement is a stock_movements row. BookId is NULL once the book is purged.
+type StockMovement struct {
+	Id              int           `db:"id"`
+	BookId          sql.NullInt64 `db:"book_id"`
+	Title           string        `db:"title"`
+	Quantity        int           `db:"quantity"`
+	Reason          string        `db:"reason"`
+	PurchaseOrderId sql.NullInt64 `db:"purchase_order_id"`
+	CreatedAt       time.Time     `db:"created_at"`
+}
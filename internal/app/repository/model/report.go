@@ -0,0 +1,40 @@
+package model
+
+import "time"
+
+type CategoryRevenue struct {
+	Period       time.Time `db:"period"`
+	CategoryId   int       `db:"category_id"`
+	CategoryName string    `db:"category_name"`
+	Currency     string    `db:"currency"`
+	Orders       int       `db:"orders"`
+	Units        int       `db:"units"`
+	Net          int64     `db:"net"`
+	Tax          int64     `db:"tax"`
+}
+
+type BookSales struct {
+	Period   time.Time `db:"period"`
+	BookId   int       `db:"book_id"`
+	Title    string    `db:"title"`
+	Author   string    `db:"author"`
+	Currency string    `db:"currency"`
+	Units    int       `db:"units"`
+	Net      int64     `db:"net"`
+}
+
+type BasketSize struct {
+	Period   time.Time `db:"period"`
+	Currency string    `db:"currency"`
+	Orders   int       `db:"orders"`
+	Units    int       `db:"units"`
+	Total    int64     `db:"total"`
+}
+
+type StockTurnover struct {
+	Period       time.Time `db:"period"`
+	CategoryId   int       `db:"category_id"`
+	CategoryName string    `db:"category_name"`
+	Units        int       `db:"units"`
+	ClosingStock int       `db:"closing_stock"`
+}
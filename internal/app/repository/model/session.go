@@ -0,0 +1,17 @@
+package model
+
+import (
+	"database/sql"
+	"time"
+)
+
+type Session struct {
+	Id         int            `db:"id"`
+	UserId     int            `db:"user_id"`
+	UserAgent  sql.NullString `db:"user_agent"`
+	IPAddress  sql.NullString `db:"ip_address"`
+	CreatedAt  time.Time      `db:"created_at"`
+	LastSeenAt time.Time      `db:"last_seen_at"`
+	ExpiresAt  time.Time      `db:"expires_at"`
+	RevokedAt  sql.NullTime   `db:"revoked_at"`
+}
@@ -1,13 +1,22 @@
 package model
 
-import "time"
+import (
+	"database/sql"
+	"time"
+)
 
 type User struct {
-	Id           int       `db:"id"`
-	Username     string    `db:"username"`
-	PasswordHash string    `db:"password_hash"`
-	Admin        bool      `db:"admin"`
-	CreatedAt    time.Time `db:"created_at"`
+	Id            int            `db:"id"`
+	Username      string         `db:"username"`
+	PasswordHash  string         `db:"password_hash"`
+	Admin         bool           `db:"admin"`
+	TotpSecret    sql.NullString `db:"totp_secret"`
+	TotpEnabled   bool           `db:"totp_enabled"`
+	TotpLastStep  sql.NullInt64  `db:"totp_last_step"`
+	Email         sql.NullString `db:"email"`
+	EmailVerified bool           `db:"email_verified"`
+	CreatedAt     time.Time      `db:"created_at"`
+	DeletedAt     sql.NullTime   `db:"deleted_at"`
 	// TODO rename to cart_updated_at
 	UpdatedAt time.Time `db:"updated_at"`
 }
@@ -0,0 +1,137 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"toptal/internal/app/domain"
+	"toptal/internal/app/repository/model"
+	"toptal/internal/pkg/pg"
+
+	"github.com/jmoiron/sqlx"
+)
+
+const (
+	sqlDeleteExpiredOIDCAuthRequests = `DELETE FROM oidc_auth_requests WHERE expires_at < now()`
+	sqlInsertOIDCAuthRequest         = `
+		INSERT INTO oidc_auth_requests (state_hash, provider, nonce, code_verifier, expires_at)
+		VALUES ($1, $2, $3, $4, $5)
+	`
+	sqlConsumeOIDCAuthRequest = `
+		DELETE FROM oidc_auth_requests
+		WHERE state_hash = $1 AND provider = $2 AND expires_at > now()
+		RETURNING *
+	`
+	sqlFindUserByIdentity = `
+		SELECT u.*
+		FROM users u
+		JOIN user_identities i ON i.user_id = u.id
+		WHERE i.provider = $1 AND i.subject = $2 AND u.deleted_at IS NULL
+	`
+	sqlInsertUserWithEmailVerified = `
+		INSERT INTO users (username, password_hash, admin, email, email_verified)
+		VALUES ($1, $2, false, $3, $4)
+		RETURNING id
+	`
+	sqlInsertUserIdentity = `
+		INSERT INTO user_identities (user_id, provider, subject, email, last_login_at)
+		VALUES ($1, $2, $3, $4, now())
+	`
+	sqlTouchUserIdentity = `UPDATE user_identities SET last_login_at = now(), email = $3 WHERE provider = $1 AND subject = $2`
+)
+
+type OIDCRepository struct {
+	db *pg.DB
+}
+
+func NewOIDCRepository(db *pg.DB) *OIDCRepository {
+	return &OIDCRepository{db}
+}
+
+// InsertOIDCAuthRequest stores a pending login and drops the ones that expired.
+func (r *OIDCRepository) InsertOIDCAuthRequest(ctx context.Context, request domain.OIDCAuthRequest) error {
+	if _, err := r.db.Exec(ctx, "delete_expired_oidc_auth_requests", sqlDeleteExpiredOIDCAuthRequests); err != nil {
+		return model.WrapDatabaseError(err, "failed to delete expired oidc auth requests")
+	}
+	_, err := r.db.Exec(ctx, "insert_oidc_auth_request", sqlInsertOIDCAuthRequest,
+		request.StateHash(), request.Provider(), request.Nonce(), request.CodeVerifier(), request.ExpiresAt())
+	if err != nil {
+		return model.WrapDatabaseError(err, "failed to insert oidc auth request")
+	}
+	return nil
+}
+
+// ConsumeOIDCAuthRequest removes and returns a pending login, so each state is used once.
+func (r *OIDCRepository) ConsumeOIDCAuthRequest(ctx context.Context, provider string, stateHash string) (domain.OIDCAuthRequest, error) {
+	var request model.OIDCAuthRequest
+	err := r.db.Get(ctx, "consume_oidc_auth_request", &request, sqlConsumeOIDCAuthRequest, stateHash, provider)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return domain.OIDCAuthRequest{}, domain.ErrInvalidToken
+		}
+		return domain.OIDCAuthRequest{}, model.WrapDatabaseError(err, "failed to consume oidc auth request")
+	}
+	return domain.NewOIDCAuthRequest(request.Provider, request.StateHash, request.Nonce, request.CodeVerifier, request.ExpiresAt)
+}
+
+func (r *OIDCRepository) FindUserByIdentity(ctx context.Context, provider string, subject string) (domain.User, error) {
+	var user model.User
+	err := r.db.Get(ctx, "find_user_by_identity", &user, sqlFindUserByIdentity, provider, subject)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return domain.User{}, domain.ErrNotFound
+		}
+		return domain.User{}, model.WrapDatabaseError(err, "failed to find user by identity")
+	}
+	return toDomainUser(user)
+}
+
+// CreateUserWithIdentity inserts a user together with the identity they signed in with.
+func (r *OIDCRepository) CreateUserWithIdentity(ctx context.Context, user domain.User, identity domain.UserIdentity) (domain.User, error) {
+	err := r.db.WithTransaction(ctx, func(tx *sqlx.Tx) error {
+		var userId int
+		err := tx.GetContext(ctx, &userId, sqlInsertUserWithEmailVerified,
+			user.Username(), user.PasswordHash(), toNullString(user.Email()), user.EmailVerified())
+		if err != nil {
+			if pg.IsUniqueViolationErr(err) {
+				return domain.ErrAlreadyExists
+			}
+			return model.WrapDatabaseError(err, "failed to create user")
+		}
+		if err := user.SetId(userId); err != nil {
+			return err
+		}
+		_, err = tx.ExecContext(ctx, sqlInsertUserIdentity,
+			userId, identity.Provider(), identity.Subject(), toNullString(identity.Email()))
+		if err != nil {
+			if pg.IsUniqueViolationErr(err) {
+				return domain.ErrAlreadyExists
+			}
+			return model.WrapDatabaseError(err, "failed to link identity")
+		}
+		return nil
+	})
+	return user, err
+}
+
+func (r *OIDCRepository) LinkIdentity(ctx context.Context, identity domain.UserIdentity) error {
+	_, err := r.db.Exec(ctx, "link_identity", sqlInsertUserIdentity,
+		identity.UserId(), identity.Provider(), identity.Subject(), toNullString(identity.Email()))
+	if err != nil {
+		if pg.IsUniqueViolationErr(err) {
+			return domain.ErrAlreadyExists
+		}
+		return model.WrapDatabaseError(err, "failed to link identity")
+	}
+	return nil
+}
+
+// TouchIdentity records a login and the email the provider reported with it.
+func (r *OIDCRepository) TouchIdentity(ctx context.Context, identity domain.UserIdentity) error {
+	_, err := r.db.Exec(ctx, "touch_identity", sqlTouchUserIdentity,
+		identity.Provider(), identity.Subject(), toNullString(identity.Email()))
+	if err != nil {
+		return model.WrapDatabaseError(err, "failed to update identity")
+	}
+	return nil
+}
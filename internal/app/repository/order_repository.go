@@ -0,0 +1,50 @@
+package repository
+
+import (
+	"context"
+	"toptal/internal/app/domain"
+	"toptal/internal/app/repository/model"
+	"toptal/internal/pkg/pg"
+
+	"github.com/lib/pq"
+)
+
+const (
+	sqlFindOrdersByUser  = `SELECT * FROM orders WHERE user_id = $1 ORDER BY created_at, id`
+	sqlFindOrderItems    = `SELECT * FROM order_items WHERE order_id = ANY($1) ORDER BY order_id, id`
+	sqlFindOrderTaxLines = `SELECT * FROM order_tax_lines WHERE order_id = ANY($1) ORDER BY order_id, tax_class, rate`
+)
+
+type OrderRepository struct {
+	db *pg.DB
+}
+
+func NewOrderRepository(db *pg.DB) *OrderRepository {
+	return &OrderRepository{db}
+}
+
+func (r *OrderRepository) FindOrdersByUser(ctx context.Context, userId int) ([]domain.Order, error) {
+	var orders []model.Order
+	if err := r.db.Select(ctx, "find_orders_by_user", &orders, sqlFindOrdersByUser, userId); err != nil {
+		return nil, model.WrapDatabaseError(err, "failed to find orders")
+	}
+	if len(orders) == 0 {
+		return []domain.Order{}, nil
+	}
+
+	orderIds := make(pq.Int64Array, len(orders))
+	for i, order := range orders {
+		orderIds[i] = int64(order.Id)
+	}
+	var items []model.OrderItem
+	if err := r.db.Select(ctx, "find_order_items", &items, sqlFindOrderItems, orderIds); err != nil {
+		return nil, model.WrapDatabaseError(err, "failed to find order items")
+	}
+
+	var taxLines []model.OrderTaxLine
+	if err := r.db.Select(ctx, "find_order_tax_lines", &taxLines, sqlFindOrderTaxLines, orderIds); err != nil {
+		return nil, model.WrapDatabaseError(err, "failed to find order tax lines")
+	}
+
+	return toDomainOrders(orders, items, taxLines)
+}
@@ -0,0 +1,91 @@
+package repository
+
+import (
+	"context"
+	"time"
+	"toptal/internal/app/domain"
+	"toptal/internal/app/repository/model"
+	"toptal/internal/pkg/pg"
+
+	"github.com/jmoiron/sqlx"
+)
+
+const (
+	sqlInsertOutboxEmail = `INSERT INTO email_outbox (recipient, subject, body) VALUES ($1, $2, $3)`
+	// sqlClaimOutboxEmails leases due emails by pushing next_attempt_at forward, so
+	// several instances can dispatch concurrently without sending an email twice.
+	sqlClaimOutboxEmails = `
+		UPDATE email_outbox
+		SET next_attempt_at = now() + make_interval(secs => $3)
+		WHERE id IN (
+			SELECT id
+			FROM email_outbox
+			WHERE sent_at IS NULL
+				AND attempts < $2
+				AND next_attempt_at <= now()
+			ORDER BY id
+			LIMIT $1
+			FOR UPDATE SKIP LOCKED
+		)
+		RETURNING *
+	`
+	sqlMarkOutboxEmailSent   = `UPDATE email_outbox SET sent_at = now(), attempts = attempts + 1, last_error = NULL WHERE id = $1`
+	sqlMarkOutboxEmailFailed = `
+		UPDATE email_outbox
+		SET attempts = attempts + 1, last_error = $2, next_attempt_at = now() + make_interval(secs => $3)
+		WHERE id = $1
+	`
+)
+
+type OutboxRepository struct {
+	db *pg.DB
+}
+
+func NewOutboxRepository(db *pg.DB) *OutboxRepository {
+	return &OutboxRepository{db}
+}
+
+// ClaimOutboxEmails returns up to limit due emails and hides them from other
+// dispatchers for the lease duration.
+func (r *OutboxRepository) ClaimOutboxEmails(ctx context.Context, limit int, maxAttempts int, lease time.Duration) ([]domain.OutboxEmail, error) {
+	var emails []model.OutboxEmail
+	err := r.db.Select(ctx, "claim_outbox_emails", &emails, sqlClaimOutboxEmails, limit, maxAttempts, lease.Seconds())
+	if err != nil {
+		return nil, model.WrapDatabaseError(err, "failed to claim outbox emails")
+	}
+	return toDomainOutboxEmails(emails)
+}
+
+func (r *OutboxRepository) MarkOutboxEmailSent(ctx context.Context, id int) error {
+	if _, err := r.db.Exec(ctx, "mark_outbox_email_sent", sqlMarkOutboxEmailSent, id); err != nil {
+		return model.WrapDatabaseError(err, "failed to mark outbox email sent")
+	}
+	return nil
+}
+
+func (r *OutboxRepository) MarkOutboxEmailFailed(ctx context.Context, id int, reason string, retryIn time.Duration) error {
+	if _, err := r.db.Exec(ctx, "mark_outbox_email_failed", sqlMarkOutboxEmailFailed, id, reason, retryIn.Seconds()); err != nil {
+		return model.WrapDatabaseError(err, "failed to mark outbox email failed")
+	}
+	return nil
+}
+
+// QueueOutboxEmails queues emails that are not part of another change.
+func (r *OutboxRepository) QueueOutboxEmails(ctx context.Context, emails []domain.OutboxEmail) error {
+	return r.db.WithTransaction(ctx, func(tx *sqlx.Tx) error {
+		for _, email := range emails {
+			if err := insertOutboxEmail(ctx, tx, email); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+}
+
+// insertOutboxEmail queues an email inside the caller's transaction.
+func insertOutboxEmail(ctx context.Context, tx *sqlx.Tx, email domain.OutboxEmail) error {
+	if _, err := tx.ExecContext(ctx, sqlInsertOutboxEmail, email.Recipient(), email.Subject(), email.Body()); err != nil {
+		return model.WrapDatabaseError(err, "failed to queue email")
+	}
+	return nil
+}
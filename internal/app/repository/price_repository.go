@@ -0,0 +1,341 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"log/slog"
+	"time"
+	"toptal/internal/app/domain"
+	"toptal/internal/app/repository/model"
+	"toptal/internal/pkg/pg"
+
+	"github.com/jmoiron/sqlx"
+	"github.com/lib/pq"
+)
+
+const (
+	sqlLockListedBook        = `SELECT currency FROM books WHERE id = $1 AND deleted_at IS NULL FOR UPDATE`
+	sqlCountOverlappingSales = `
+		SELECT COUNT(*)
+		FROM book_price_changes
+		WHERE book_id = $1 AND ends_at IS NOT NULL AND cancelled_at IS NULL AND ended_at IS NULL
+			AND starts_at < $3 AND ends_at > $2
+	`
+	sqlInsertPriceChange = `
+		INSERT INTO book_price_changes (book_id, price, currency, starts_at, ends_at, created_by)
+		VALUES ($1, $2, $3, $4, $5, $6)
+		RETURNING *
+	`
+	sqlFindPriceChanges  = `SELECT * FROM book_price_changes WHERE book_id = $1 ORDER BY starts_at DESC, id DESC`
+	sqlLockPriceChange   = `SELECT * FROM book_price_changes WHERE id = $1 AND book_id = $2 FOR UPDATE`
+	sqlCancelPriceChange = `
+		UPDATE book_price_changes
+		SET cancelled_at = now(), ended_at = CASE WHEN applied_at IS NOT NULL THEN now() END
+		WHERE id = $1
+		RETURNING *
+	`
+	// sqlLockDuePriceChanges returns the changes that have to start or, for sales, end,
+	// in the order they are due. A sale ending at the moment the next one starts is
+	// handled first.
+	sqlLockDuePriceChanges = `
+		SELECT *
+		FROM book_price_changes
+		WHERE cancelled_at IS NULL AND ended_at IS NULL
+			AND ((applied_at IS NULL AND starts_at <= $1) OR (applied_at IS NOT NULL AND ends_at <= $1))
+		ORDER BY CASE WHEN applied_at IS NULL THEN starts_at ELSE ends_at END, applied_at IS NULL, id
+		FOR UPDATE SKIP LOCKED
+	`
+	sqlSetBookPrice           = `UPDATE books SET price = $2 WHERE id = $1`
+	sqlStartSale              = `UPDATE books SET sale_price = $2, sale_ends_at = $3 WHERE id = $1`
+	sqlEndSale                = `UPDATE books SET sale_price = NULL, sale_ends_at = NULL WHERE id = $1 AND sale_ends_at = $2`
+	sqlMarkPriceChangeApplied = `UPDATE book_price_changes SET applied_at = $2 WHERE id = $1`
+	sqlMarkPriceChangeEnded   = `UPDATE book_price_changes SET ended_at = $2 WHERE id = $1`
+	// sqlInsertPriceHistory copies the book's current prices into the history.
+	sqlInsertPriceHistory = `
+		INSERT INTO book_price_history (book_id, price, sale_price, currency, reason, price_change_id)
+		SELECT id, price, sale_price, currency, $2, $3
+		FROM books
+		WHERE id = $1
+	`
+	sqlFindPriceHistory = `
+		SELECT *
+		FROM book_price_history
+		WHERE book_id = $1
+		ORDER BY changed_at DESC, id DESC
+		LIMIT $2 OFFSET $3
+	`
+	sqlFindListPrices        = `SELECT * FROM book_price_list WHERE book_id = $1 ORDER BY currency`
+	sqlFindListPricesByBooks = `SELECT * FROM book_price_list WHERE book_id = ANY($1) AND currency = $2`
+	sqlLockListPrice         = `SELECT * FROM book_price_list WHERE book_id = $1 AND currency = $2 FOR UPDATE`
+	sqlUpsertListPrice       = `
+		INSERT INTO book_price_list (book_id, currency, price)
+		VALUES ($1, $2, $3)
+		ON CONFLICT (book_id, currency) DO UPDATE SET price = EXCLUDED.price, updated_at = now()
+		RETURNING *
+	`
+	sqlDeleteListPrice = `DELETE FROM book_price_list WHERE book_id = $1 AND currency = $2 RETURNING *`
+)
+
+type PriceRepository struct {
+	db *pg.DB
+}
+
+func NewPriceRepository(db *pg.DB) *PriceRepository {
+	return &PriceRepository{db}
+}
+
+// InsertPriceChange schedules a price change for a listed book. Sales of the same book
+// may not overlap.
+func (r *PriceRepository) InsertPriceChange(ctx context.Context, change domain.PriceChange, actor domain.AuditActor) (domain.PriceChange, error) {
+	var created model.PriceChange
+	err := r.db.WithTransaction(ctx, func(tx *sqlx.Tx) error {
+		currency, err := lockListedBook(ctx, tx, change.BookId())
+		if err != nil {
+			return err
+		}
+		if change.Price().Currency() != currency {
+			return fmt.Errorf("%w: book is priced in %s", domain.ErrCurrencyMismatch, currency)
+		}
+		if change.IsSale() {
+			var overlapping int
+			err := tx.GetContext(ctx, &overlapping, sqlCountOverlappingSales, change.BookId(), change.StartsAt(), change.EndsAt())
+			if err != nil {
+				return model.WrapDatabaseError(err, "failed to check overlapping sales")
+			}
+			if overlapping > 0 {
+				return domain.ErrSaleOverlap
+			}
+		}
+
+		err = tx.GetContext(ctx, &created, sqlInsertPriceChange,
+			change.BookId(), change.Price().Amount(), change.Price().Currency(), change.StartsAt(),
+			toNullTime(change.EndsAt()), toNullInt64(change.CreatedBy()),
+		)
+		if err != nil {
+			return model.WrapDatabaseError(err, "failed to insert price change")
+		}
+
+		return writeAudit(ctx, tx, actor, domain.AuditActionCreate, domain.AuditEntityPriceChange, created.Id, nil, created)
+	})
+	if err != nil {
+		return domain.PriceChange{}, err
+	}
+	return toDomainPriceChange(created)
+}
+
+func (r *PriceRepository) FindPriceChanges(ctx context.Context, bookId int) ([]domain.PriceChange, error) {
+	var changes []model.PriceChange
+	if err := r.db.Select(ctx, "find_price_changes", &changes, sqlFindPriceChanges, bookId); err != nil {
+		return nil, model.WrapDatabaseError(err, "failed to find price changes")
+	}
+	return toDomainPriceChanges(changes)
+}
+
+// CancelPriceChange cancels a scheduled change or ends a running sale early. Changes
+// that already replaced the price stay in place.
+func (r *PriceRepository) CancelPriceChange(ctx context.Context, bookId int, id int, actor domain.AuditActor) error {
+	return r.db.WithTransaction(ctx, func(tx *sqlx.Tx) error {
+		var before, after model.PriceChange
+		if err := tx.GetContext(ctx, &before, sqlLockPriceChange, id, bookId); err != nil {
+			if errors.Is(err, sql.ErrNoRows) {
+				return domain.ErrNotFound
+			}
+			return model.WrapDatabaseError(err, "failed to get price change")
+		}
+		change, err := toDomainPriceChange(before)
+		if err != nil {
+			return err
+		}
+
+		status := change.Status()
+		if status != domain.PriceChangeScheduled && status != domain.PriceChangeActive {
+			return domain.ErrPriceChangeClosed
+		}
+		if err := tx.GetContext(ctx, &after, sqlCancelPriceChange, id); err != nil {
+			return model.WrapDatabaseError(err, "failed to cancel price change")
+		}
+		if status == domain.PriceChangeActive {
+			if _, err := tx.ExecContext(ctx, sqlEndSale, bookId, change.EndsAt()); err != nil {
+				return model.WrapDatabaseError(err, "failed to end sale")
+			}
+			if err := insertPriceHistory(ctx, tx, bookId, domain.PriceReasonSaleCancel, id); err != nil {
+				return err
+			}
+		}
+
+		return writeAudit(ctx, tx, actor, domain.AuditActionCancel, domain.AuditEntityPriceChange, id, before, after)
+	})
+}
+
+func (r *PriceRepository) FindPriceHistory(ctx context.Context, bookId int, limit, offset int) ([]domain.PriceHistoryEntry, error) {
+	var entries []model.PriceHistoryEntry
+	if err := r.db.Select(ctx, "find_price_history", &entries, sqlFindPriceHistory, bookId, limit, offset); err != nil {
+		return nil, model.WrapDatabaseError(err, "failed to find price history")
+	}
+	return toDomainPriceHistory(entries)
+}
+
+// FindListPrices returns the prices of a book in currencies other than its own.
+func (r *PriceRepository) FindListPrices(ctx context.Context, bookId int) ([]domain.Money, error) {
+	var prices []model.ListPrice
+	if err := r.db.Select(ctx, "find_list_prices", &prices, sqlFindListPrices, bookId); err != nil {
+		return nil, model.WrapDatabaseError(err, "failed to find list prices")
+	}
+	return toDomainListPrices(prices)
+}
+
+// FindListPricesByBooks returns the list prices of the given books in one currency, by
+// book id. Books without a price in that currency are left out.
+func (r *PriceRepository) FindListPricesByBooks(ctx context.Context, bookIds []int, currency string) (map[int]domain.Money, error) {
+	ids := make(pq.Int64Array, len(bookIds))
+	for i, id := range bookIds {
+		ids[i] = int64(id)
+	}
+	var prices []model.ListPrice
+	if err := r.db.Select(ctx, "find_list_prices_by_books", &prices, sqlFindListPricesByBooks, ids, currency); err != nil {
+		return nil, model.WrapDatabaseError(err, "failed to find list prices")
+	}
+
+	byBook := make(map[int]domain.Money, len(prices))
+	for _, price := range prices {
+		money, err := domain.NewMoney(price.Price, price.Currency)
+		if err != nil {
+			return nil, err
+		}
+		byBook[price.BookId] = money
+	}
+	return byBook, nil
+}
+
+// SetListPrice adds or replaces the price of a listed book in a currency other than its own.
+func (r *PriceRepository) SetListPrice(ctx context.Context, bookId int, price domain.Money, actor domain.AuditActor) error {
+	return r.db.WithTransaction(ctx, func(tx *sqlx.Tx) error {
+		currency, err := lockListedBook(ctx, tx, bookId)
+		if err != nil {
+			return err
+		}
+		if price.Currency() == currency {
+			return fmt.Errorf("%w: %s is the book's own currency, change its price instead", domain.ErrInvalidMoney, currency)
+		}
+
+		var existing, after model.ListPrice
+		var before any
+		action := domain.AuditActionCreate
+		err = tx.GetContext(ctx, &existing, sqlLockListPrice, bookId, price.Currency())
+		switch {
+		case err == nil:
+			before, action = existing, domain.AuditActionUpdate
+		case !errors.Is(err, sql.ErrNoRows):
+			return model.WrapDatabaseError(err, "failed to get list price")
+		}
+
+		if err := tx.GetContext(ctx, &after, sqlUpsertListPrice, bookId, price.Currency(), price.Amount()); err != nil {
+			return model.WrapDatabaseError(err, "failed to set list price")
+		}
+
+		return writeAudit(ctx, tx, actor, action, domain.AuditEntityListPrice, bookId, before, after)
+	})
+}
+
+func (r *PriceRepository) DeleteListPrice(ctx context.Context, bookId int, currency string, actor domain.AuditActor) error {
+	return r.db.WithTransaction(ctx, func(tx *sqlx.Tx) error {
+		var deleted model.ListPrice
+		if err := tx.GetContext(ctx, &deleted, sqlDeleteListPrice, bookId, currency); err != nil {
+			if errors.Is(err, sql.ErrNoRows) {
+				return domain.ErrNotFound
+			}
+			return model.WrapDatabaseError(err, "failed to delete list price")
+		}
+
+		return writeAudit(ctx, tx, actor, domain.AuditActionDelete, domain.AuditEntityListPrice, bookId, deleted, nil)
+	})
+}
+
+// ApplyDuePriceChanges starts and ends the price changes due at the given time and returns
+// how many it handled. Changes locked by another instance are left to it.
+func (r *PriceRepository) ApplyDuePriceChanges(ctx context.Context, now time.Time) (int, error) {
+	var applied int
+	err := r.db.WithTransaction(ctx, func(tx *sqlx.Tx) error {
+		var due []model.PriceChange
+		if err := tx.SelectContext(ctx, &due, sqlLockDuePriceChanges, now); err != nil {
+			return model.WrapDatabaseError(err, "failed to get due price changes")
+		}
+		for _, row := range due {
+			change, err := toDomainPriceChange(row)
+			if err != nil {
+				return err
+			}
+			if err := applyPriceChange(ctx, tx, change, now); err != nil {
+				return fmt.Errorf("failed to apply price change %d: %w", change.Id(), err)
+			}
+		}
+		applied = len(due)
+		return nil
+	})
+	return applied, err
+}
+
+func applyPriceChange(ctx context.Context, tx *sqlx.Tx, change domain.PriceChange, now time.Time) error {
+	switch {
+	case change.Status() == domain.PriceChangeActive:
+		if _, err := tx.ExecContext(ctx, sqlEndSale, change.BookId(), change.EndsAt()); err != nil {
+			return model.WrapDatabaseError(err, "failed to end sale")
+		}
+		if _, err := tx.ExecContext(ctx, sqlMarkPriceChangeEnded, change.Id(), now); err != nil {
+			return model.WrapDatabaseError(err, "failed to mark price change ended")
+		}
+		return insertPriceHistory(ctx, tx, change.BookId(), domain.PriceReasonSaleEnd, change.Id())
+
+	case change.IsSale() && !change.EndsAt().After(now):
+		// The whole sale passed while the scheduler was not running.
+		slog.Warn("Skipping sale that ended before it was applied", "price_change_id", change.Id())
+		if _, err := tx.ExecContext(ctx, sqlMarkPriceChangeApplied, change.Id(), now); err != nil {
+			return model.WrapDatabaseError(err, "failed to mark price change applied")
+		}
+		if _, err := tx.ExecContext(ctx, sqlMarkPriceChangeEnded, change.Id(), now); err != nil {
+			return model.WrapDatabaseError(err, "failed to mark price change ended")
+		}
+		return nil
+
+	case change.IsSale():
+		if _, err := tx.ExecContext(ctx, sqlStartSale, change.BookId(), change.Price().Amount(), change.EndsAt()); err != nil {
+			return model.WrapDatabaseError(err, "failed to start sale")
+		}
+		if _, err := tx.ExecContext(ctx, sqlMarkPriceChangeApplied, change.Id(), now); err != nil {
+			return model.WrapDatabaseError(err, "failed to mark price change applied")
+		}
+		return insertPriceHistory(ctx, tx, change.BookId(), domain.PriceReasonSaleStart, change.Id())
+
+	default:
+		if _, err := tx.ExecContext(ctx, sqlSetBookPrice, change.BookId(), change.Price().Amount()); err != nil {
+			return model.WrapDatabaseError(err, "failed to set book price")
+		}
+		if _, err := tx.ExecContext(ctx, sqlMarkPriceChangeApplied, change.Id(), now); err != nil {
+			return model.WrapDatabaseError(err, "failed to mark price change applied")
+		}
+		return insertPriceHistory(ctx, tx, change.BookId(), domain.PriceReasonScheduled, change.Id())
+	}
+}
+
+// lockListedBook locks a book that is not archived and returns its currency.
+func lockListedBook(ctx context.Context, tx *sqlx.Tx, bookId int) (string, error) {
+	var currency string
+	if err := tx.GetContext(ctx, &currency, sqlLockListedBook, bookId); err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return "", domain.ErrNotFound
+		}
+		return "", model.WrapDatabaseError(err, "failed to get book")
+	}
+	return currency, nil
+}
+
+// insertPriceHistory records the book's prices after a change. priceChangeId is zero
+// when the change was not scheduled.
+func insertPriceHistory(ctx context.Context, tx *sqlx.Tx, bookId int, reason string, priceChangeId int) error {
+	if _, err := tx.ExecContext(ctx, sqlInsertPriceHistory, bookId, reason, toNullInt64(priceChangeId)); err != nil {
+		return model.WrapDatabaseError(err, "failed to record price history")
+	}
+	return nil
+}
@@ -0,0 +1,201 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/jmoiron/sqlx"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"toptal/internal/app/domain"
+	"toptal/internal/pkg/pg"
+)
+
+var priceChangeColumns = []string{
+	"id", "book_id", "price", "currency", "starts_at", "ends_at", "created_by", "created_at", "applied_at", "ended_at", "cancelled_at",
+}
+
+func usd(cents int64) domain.Money {
+	money, _ := domain.NewMoney(cents, "USD")
+	return money
+}
+
+func TestPriceRepository_ApplyDuePriceChanges(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	require.NoError(t, err)
+	defer db.Close()
+
+	repo := NewPriceRepository(pg.NewDB(sqlx.NewDb(db, "sqlmock")))
+	now := time.Date(2025, 6, 9, 0, 0, 0, 0, time.UTC)
+	friday := now.Add(-72 * time.Hour)
+	monday := now
+
+	mock.ExpectBegin()
+	mock.ExpectQuery("SELECT \\*\\s+FROM book_price_changes\\s+WHERE cancelled_at IS NULL AND ended_at IS NULL").
+		WithArgs(now).
+		WillReturnRows(sqlmock.NewRows(priceChangeColumns).
+			// a weekend sale that just ended
+			AddRow(1, 10, 800, "USD", friday, monday, 7, friday, friday, nil, nil).
+			// the next sale, starting as the first one ends
+			AddRow(2, 10, 900, "USD", monday, monday.Add(48*time.Hour), 7, friday, nil, nil, nil).
+			// a new regular price for another book
+			AddRow(3, 11, 1500, "USD", monday, nil, 7, friday, nil, nil, nil))
+
+	mock.ExpectExec("UPDATE books SET sale_price = NULL, sale_ends_at = NULL WHERE id = \\$1 AND sale_ends_at = \\$2").
+		WithArgs(10, monday).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectExec("UPDATE book_price_changes SET ended_at = \\$2 WHERE id = \\$1").
+		WithArgs(1, now).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectExec("INSERT INTO book_price_history").
+		WithArgs(10, domain.PriceReasonSaleEnd, sql.NullInt64{Int64: 1, Valid: true}).
+		WillReturnResult(sqlmock.NewResult(1, 1))
+
+	mock.ExpectExec("UPDATE books SET sale_price = \\$2, sale_ends_at = \\$3 WHERE id = \\$1").
+		WithArgs(10, 900, monday.Add(48*time.Hour)).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectExec("UPDATE book_price_changes SET applied_at = \\$2 WHERE id = \\$1").
+		WithArgs(2, now).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectExec("INSERT INTO book_price_history").
+		WithArgs(10, domain.PriceReasonSaleStart, sql.NullInt64{Int64: 2, Valid: true}).
+		WillReturnResult(sqlmock.NewResult(2, 1))
+
+	mock.ExpectExec("UPDATE books SET price = \\$2 WHERE id = \\$1").
+		WithArgs(11, 1500).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectExec("UPDATE book_price_changes SET applied_at = \\$2 WHERE id = \\$1").
+		WithArgs(3, now).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectExec("INSERT INTO book_price_history").
+		WithArgs(11, domain.PriceReasonScheduled, sql.NullInt64{Int64: 3, Valid: true}).
+		WillReturnResult(sqlmock.NewResult(3, 1))
+	mock.ExpectCommit()
+
+	applied, err := repo.ApplyDuePriceChanges(context.Background(), now)
+	require.NoError(t, err)
+	assert.Equal(t, 3, applied)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestPriceRepository_CancelPriceChange(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	require.NoError(t, err)
+	defer db.Close()
+
+	repo := NewPriceRepository(pg.NewDB(sqlx.NewDb(db, "sqlmock")))
+	startsAt := time.Date(2025, 6, 1, 0, 0, 0, 0, time.UTC)
+
+	t.Run("Applied price change", func(t *testing.T) {
+		mock.ExpectBegin()
+		mock.ExpectQuery("SELECT \\* FROM book_price_changes WHERE id = \\$1 AND book_id = \\$2 FOR UPDATE").
+			WithArgs(3, 11).
+			WillReturnRows(sqlmock.NewRows(priceChangeColumns).
+				AddRow(3, 11, 1500, "USD", startsAt, nil, 7, startsAt, startsAt, nil, nil))
+		mock.ExpectRollback()
+
+		err := repo.CancelPriceChange(context.Background(), 11, 3, domain.AuditActor{})
+		assert.ErrorIs(t, err, domain.ErrPriceChangeClosed)
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("Running sale", func(t *testing.T) {
+		endsAt := startsAt.Add(48 * time.Hour)
+		mock.ExpectBegin()
+		mock.ExpectQuery("SELECT \\* FROM book_price_changes WHERE id = \\$1 AND book_id = \\$2 FOR UPDATE").
+			WithArgs(2, 10).
+			WillReturnRows(sqlmock.NewRows(priceChangeColumns).
+				AddRow(2, 10, 900, "USD", startsAt, endsAt, 7, startsAt, startsAt, nil, nil))
+		mock.ExpectQuery("UPDATE book_price_changes\\s+SET cancelled_at = now\\(\\)").
+			WithArgs(2).
+			WillReturnRows(sqlmock.NewRows(priceChangeColumns).
+				AddRow(2, 10, 900, "USD", startsAt, endsAt, 7, startsAt, startsAt, startsAt.Add(time.Hour), startsAt.Add(time.Hour)))
+		mock.ExpectExec("UPDATE books SET sale_price = NULL").
+			WithArgs(10, endsAt).
+			WillReturnResult(sqlmock.NewResult(0, 1))
+		mock.ExpectExec("INSERT INTO book_price_history").
+			WithArgs(10, domain.PriceReasonSaleCancel, sql.NullInt64{Int64: 2, Valid: true}).
+			WillReturnResult(sqlmock.NewResult(1, 1))
+		mock.ExpectExec("INSERT INTO audit_log").
+			WillReturnResult(sqlmock.NewResult(1, 1))
+		mock.ExpectCommit()
+
+		err := repo.CancelPriceChange(context.Background(), 10, 2, domain.AuditActor{})
+		require.NoError(t, err)
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+}
+
+func TestPriceRepository_InsertPriceChangeOverlap(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	require.NoError(t, err)
+	defer db.Close()
+
+	repo := NewPriceRepository(pg.NewDB(sqlx.NewDb(db, "sqlmock")))
+	startsAt := time.Date(2025, 6, 6, 18, 0, 0, 0, time.UTC)
+	change, err := domain.NewPriceChange(10, usd(800), startsAt, startsAt.Add(54*time.Hour), 7)
+	require.NoError(t, err)
+
+	mock.ExpectBegin()
+	mock.ExpectQuery("SELECT currency FROM books WHERE id = \\$1 AND deleted_at IS NULL FOR UPDATE").
+		WithArgs(10).
+		WillReturnRows(sqlmock.NewRows([]string{"currency"}).AddRow("USD"))
+	mock.ExpectQuery("SELECT COUNT\\(\\*\\)\\s+FROM book_price_changes").
+		WithArgs(10, startsAt, startsAt.Add(54*time.Hour)).
+		WillReturnRows(sqlmock.NewRows([]string{"count"}).AddRow(1))
+	mock.ExpectRollback()
+
+	_, err = repo.InsertPriceChange(context.Background(), change, domain.AuditActor{})
+	assert.ErrorIs(t, err, domain.ErrSaleOverlap)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestPriceRepository_SetListPrice(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	require.NoError(t, err)
+	defer db.Close()
+
+	repo := NewPriceRepository(pg.NewDB(sqlx.NewDb(db, "sqlmock")))
+	eur, err := domain.NewMoney(1100, "EUR")
+	require.NoError(t, err)
+
+	t.Run("Book's own currency", func(t *testing.T) {
+		mock.ExpectBegin()
+		mock.ExpectQuery("SELECT currency FROM books WHERE id = \\$1 AND deleted_at IS NULL FOR UPDATE").
+			WithArgs(10).
+			WillReturnRows(sqlmock.NewRows([]string{"currency"}).AddRow("USD"))
+		mock.ExpectRollback()
+
+		err := repo.SetListPrice(context.Background(), 10, usd(1200), domain.AuditActor{})
+		assert.ErrorIs(t, err, domain.ErrInvalidMoney)
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("New currency", func(t *testing.T) {
+		mock.ExpectBegin()
+		mock.ExpectQuery("SELECT currency FROM books WHERE id = \\$1 AND deleted_at IS NULL FOR UPDATE").
+			WithArgs(10).
+			WillReturnRows(sqlmock.NewRows([]string{"currency"}).AddRow("USD"))
+		mock.ExpectQuery("SELECT \\* FROM book_price_list WHERE book_id = \\$1 AND currency = \\$2 FOR UPDATE").
+			WithArgs(10, "EUR").
+			WillReturnError(sql.ErrNoRows)
+		mock.ExpectQuery("INSERT INTO book_price_list").
+			WithArgs(10, "EUR", int64(1100)).
+			WillReturnRows(sqlmock.NewRows([]string{"book_id", "currency", "price", "updated_at"}).
+				AddRow(10, "EUR", 1100, time.Now()))
+		mock.ExpectExec("INSERT INTO audit_log").
+			WithArgs(
+				sql.NullInt64{}, sql.NullInt64{}, domain.AuditActionCreate, domain.AuditEntityListPrice, 10,
+				sqlmock.AnyArg(), sqlmock.AnyArg(), sql.NullString{}, sql.NullString{},
+			).
+			WillReturnResult(sqlmock.NewResult(1, 1))
+		mock.ExpectCommit()
+
+		require.NoError(t, repo.SetListPrice(context.Background(), 10, eur, domain.AuditActor{}))
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+}
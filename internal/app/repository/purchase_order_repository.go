@@ -0,0 +1,324 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"toptal/internal/app/domain"
+	"toptal/internal/app/repository/model"
+	"toptal/internal/pkg/pg"
+
+	"github.com/jmoiron/sqlx"
+)
+
+const (
+	sqlInsertPurchaseOrder = `INSERT INTO purchase_orders (supplier_id, status, currency) VALUES ($1, $2, $3) RETURNING *`
+	// sqlInsertPurchaseOrderItem copies the title of the book, so the line outlives it.
+	sqlInsertPurchaseOrderItem = `
+		INSERT INTO purchase_order_items (purchase_order_id, book_id, title, quantity, cost_price)
+		SELECT $1, id, title, $3, $4 FROM books WHERE id = $2 AND deleted_at IS NULL
+	`
+	sqlFindPurchaseOrder = `
+		SELECT po.*, s.name AS supplier_name
+		FROM purchase_orders po
+		JOIN suppliers s ON s.id = po.supplier_id
+		WHERE po.id = $1
+	`
+	sqlLockPurchaseOrder = `SELECT * FROM purchase_orders WHERE id = $1 FOR UPDATE`
+	// sqlFindPurchaseOrders lists purchase orders newest first, of supplier $1 unless it is
+	// 0 and in status $2 unless it is empty.
+	sqlFindPurchaseOrders = `
+		SELECT po.*, s.name AS supplier_name
+		FROM purchase_orders po
+		JOIN suppliers s ON s.id = po.supplier_id
+		WHERE ($1 = 0 OR po.supplier_id = $1) AND ($2 = '' OR po.status = $2)
+		ORDER BY po.id DESC
+		LIMIT $3 OFFSET $4
+	`
+	sqlFindPurchaseOrderItems      = `SELECT * FROM purchase_order_items WHERE purchase_order_id = ANY($1) ORDER BY purchase_order_id, id`
+	sqlUpdatePurchaseOrderCurrency = `UPDATE purchase_orders SET currency = $2 WHERE id = $1`
+	sqlDeletePurchaseOrderItems    = `DELETE FROM purchase_order_items WHERE purchase_order_id = $1`
+	sqlDeletePurchaseOrder         = `DELETE FROM purchase_orders WHERE id = $1`
+	sqlSendPurchaseOrder           = `UPDATE purchase_orders SET status = $2, sent_at = now() WHERE id = $1`
+	// sqlReceivePurchaseOrder sets the status after a delivery, and when the order was
+	// received in full.
+	sqlReceivePurchaseOrder = `
+		UPDATE purchase_orders
+		SET status = $2::varchar, received_at = CASE WHEN $2::varchar = 'received' THEN now() END
+		WHERE id = $1
+	`
+	sqlReceivePurchaseOrderItem = `
+		UPDATE purchase_order_items SET received = received + $3 WHERE purchase_order_id = $1 AND book_id = $2
+	`
+	sqlAddBookStock = `UPDATE books SET stock = stock + $2 WHERE id = $1`
+	// sqlInsertStockMovement copies the title of the book, so the movement outlives it.
+	sqlInsertStockMovement = `
+		INSERT INTO stock_movements (book_id, title, quantity, reason, purchase_order_id)
+		SELECT id, title, $2, $3, $4 FROM books WHERE id = $1
+	`
+	sqlFindStockMovements = `SELECT * FROM stock_movements WHERE book_id = $1 ORDER BY id DESC LIMIT $2 OFFSET $3`
+)
+
+type PurchaseOrderRepository struct {
+	db *pg.DB
+}
+
+func NewPurchaseOrderRepository(db *pg.DB) *PurchaseOrderRepository {
+	return &PurchaseOrderRepository{db}
+}
+
+// purchaseOrderAudit is what the audit log records of a purchase order: its status and
+// its lines.
+type purchaseOrderAudit struct {
+	SupplierId int                      `db:"supplier_id"`
+	Status     string                   `db:"status"`
+	Items      []purchaseOrderAuditItem `db:"items"`
+}
+
+type purchaseOrderAuditItem struct {
+	BookId    int   `json:"book_id"`
+	Quantity  int   `json:"quantity"`
+	Received  int   `json:"received"`
+	CostPrice int64 `json:"cost_price"`
+}
+
+func toPurchaseOrderAudit(order domain.PurchaseOrder) purchaseOrderAudit {
+	audit := purchaseOrderAudit{SupplierId: order.SupplierId(), Status: order.Status()}
+	for _, line := range order.Lines() {
+		audit.Items = append(audit.Items, purchaseOrderAuditItem{
+			BookId:    line.BookId(),
+			Quantity:  line.Quantity(),
+			Received:  line.Received(),
+			CostPrice: line.CostPrice().Amount(),
+		})
+	}
+	return audit
+}
+
+// InsertPurchaseOrder stores a new purchase order with its lines.
+func (r *PurchaseOrderRepository) InsertPurchaseOrder(
+	ctx context.Context, order domain.PurchaseOrder, actor domain.AuditActor,
+) (domain.PurchaseOrder, error) {
+	var id int
+	err := r.db.WithTransaction(ctx, func(tx *sqlx.Tx) error {
+		var created model.PurchaseOrder
+		if err := tx.GetContext(ctx, &created, sqlInsertPurchaseOrder, order.SupplierId(), order.Status(), order.Currency()); err != nil {
+			if pg.IsForeignKeyViolationErr(err) {
+				return domain.ErrNotFound
+			}
+			return model.WrapDatabaseError(err, "failed to insert purchase order")
+		}
+		id = created.Id
+		if err := insertPurchaseOrderItems(ctx, tx, id, order.Lines()); err != nil {
+			return err
+		}
+
+		return writeAudit(ctx, tx, actor, domain.AuditActionCreate, domain.AuditEntityPurchaseOrder, id, nil,
+			toPurchaseOrderAudit(order))
+	})
+	if err != nil {
+		return domain.PurchaseOrder{}, err
+	}
+	return r.FindPurchaseOrder(ctx, id)
+}
+
+func (r *PurchaseOrderRepository) FindPurchaseOrder(ctx context.Context, id int) (domain.PurchaseOrder, error) {
+	var order model.PurchaseOrder
+	if err := r.db.Get(ctx, "find_purchase_order", &order, sqlFindPurchaseOrder, id); err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return domain.PurchaseOrder{}, domain.ErrNotFound
+		}
+		return domain.PurchaseOrder{}, model.WrapDatabaseError(err, "failed to find purchase order")
+	}
+	var items []model.PurchaseOrderItem
+	if err := r.db.Select(ctx, "find_purchase_order_items", &items, sqlFindPurchaseOrderItems, toInt64Array([]int{id})); err != nil {
+		return domain.PurchaseOrder{}, model.WrapDatabaseError(err, "failed to find purchase order items")
+	}
+	return toDomainPurchaseOrder(order, items)
+}
+
+func (r *PurchaseOrderRepository) FindPurchaseOrders(ctx context.Context, filter domain.PurchaseOrderFilter) ([]domain.PurchaseOrder, error) {
+	var orders []model.PurchaseOrder
+	err := r.db.Select(ctx, "find_purchase_orders", &orders, sqlFindPurchaseOrders,
+		filter.SupplierId, filter.Status, filter.Limit, filter.Offset)
+	if err != nil {
+		return nil, model.WrapDatabaseError(err, "failed to find purchase orders")
+	}
+	ids := make([]int, len(orders))
+	for i, order := range orders {
+		ids[i] = order.Id
+	}
+	var items []model.PurchaseOrderItem
+	if err := r.db.Select(ctx, "find_purchase_order_items", &items, sqlFindPurchaseOrderItems, toInt64Array(ids)); err != nil {
+		return nil, model.WrapDatabaseError(err, "failed to find purchase order items")
+	}
+	return toDomainPurchaseOrders(orders, items)
+}
+
+// ReplacePurchaseOrderLines changes what a draft orders. Orders that were sent are
+// refused with domain.ErrPurchaseOrderStatus.
+func (r *PurchaseOrderRepository) ReplacePurchaseOrderLines(
+	ctx context.Context, id int, lines []domain.PurchaseOrderLine, actor domain.AuditActor,
+) (domain.PurchaseOrder, error) {
+	err := r.db.WithTransaction(ctx, func(tx *sqlx.Tx) error {
+		before, err := lockPurchaseOrder(ctx, tx, id)
+		if err != nil {
+			return err
+		}
+		if !before.IsDraft() {
+			return domain.ErrPurchaseOrderStatus
+		}
+		after, err := domain.NewPurchaseOrder(id, before.SupplierId(), before.Status(), lines)
+		if err != nil {
+			return err
+		}
+
+		if _, err := tx.ExecContext(ctx, sqlUpdatePurchaseOrderCurrency, id, after.Currency()); err != nil {
+			return model.WrapDatabaseError(err, "failed to update purchase order")
+		}
+		if _, err := tx.ExecContext(ctx, sqlDeletePurchaseOrderItems, id); err != nil {
+			return model.WrapDatabaseError(err, "failed to delete purchase order items")
+		}
+		if err := insertPurchaseOrderItems(ctx, tx, id, lines); err != nil {
+			return err
+		}
+
+		return writeAudit(ctx, tx, actor, domain.AuditActionUpdate, domain.AuditEntityPurchaseOrder, id,
+			toPurchaseOrderAudit(before), toPurchaseOrderAudit(after))
+	})
+	if err != nil {
+		return domain.PurchaseOrder{}, err
+	}
+	return r.FindPurchaseOrder(ctx, id)
+}
+
+// SendPurchaseOrder marks a draft as sent to the supplier.
+func (r *PurchaseOrderRepository) SendPurchaseOrder(ctx context.Context, id int, actor domain.AuditActor) (domain.PurchaseOrder, error) {
+	err := r.db.WithTransaction(ctx, func(tx *sqlx.Tx) error {
+		order, err := lockPurchaseOrder(ctx, tx, id)
+		if err != nil {
+			return err
+		}
+		before := toPurchaseOrderAudit(order)
+		if err := order.Send(); err != nil {
+			return err
+		}
+		if _, err := tx.ExecContext(ctx, sqlSendPurchaseOrder, id, order.Status()); err != nil {
+			return model.WrapDatabaseError(err, "failed to send purchase order")
+		}
+
+		return writeAudit(ctx, tx, actor, domain.AuditActionUpdate, domain.AuditEntityPurchaseOrder, id,
+			before, toPurchaseOrderAudit(order))
+	})
+	if err != nil {
+		return domain.PurchaseOrder{}, err
+	}
+	return r.FindPurchaseOrder(ctx, id)
+}
+
+// ReceivePurchaseOrder books a delivery of a sent purchase order: the copies received are
+// added to the stock of their books and recorded as stock movements, all in one
+// transaction. quantities maps book ids to copies; an empty map receives everything
+// outstanding.
+func (r *PurchaseOrderRepository) ReceivePurchaseOrder(
+	ctx context.Context, id int, quantities map[int]int, actor domain.AuditActor,
+) (domain.PurchaseOrder, error) {
+	err := r.db.WithTransaction(ctx, func(tx *sqlx.Tx) error {
+		order, err := lockPurchaseOrder(ctx, tx, id)
+		if err != nil {
+			return err
+		}
+		before := toPurchaseOrderAudit(order)
+		movements, err := order.Receive(quantities)
+		if err != nil {
+			return err
+		}
+
+		for _, movement := range movements {
+			if _, err := tx.ExecContext(ctx, sqlReceivePurchaseOrderItem, id, movement.BookId(), movement.Quantity()); err != nil {
+				return model.WrapDatabaseError(err, "failed to receive purchase order item")
+			}
+			if _, err := tx.ExecContext(ctx, sqlAddBookStock, movement.BookId(), movement.Quantity()); err != nil {
+				return model.WrapDatabaseError(err, "failed to add book stock")
+			}
+			_, err := tx.ExecContext(ctx, sqlInsertStockMovement,
+				movement.BookId(), movement.Quantity(), movement.Reason(), toNullInt64(movement.PurchaseOrderId()))
+			if err != nil {
+				return model.WrapDatabaseError(err, "failed to record stock movement")
+			}
+		}
+		if _, err := tx.ExecContext(ctx, sqlReceivePurchaseOrder, id, order.Status()); err != nil {
+			return model.WrapDatabaseError(err, "failed to receive purchase order")
+		}
+
+		return writeAudit(ctx, tx, actor, domain.AuditActionUpdate, domain.AuditEntityPurchaseOrder, id,
+			before, toPurchaseOrderAudit(order))
+	})
+	if err != nil {
+		return domain.PurchaseOrder{}, err
+	}
+	return r.FindPurchaseOrder(ctx, id)
+}
+
+// DeletePurchaseOrder deletes a draft. Orders that were sent are refused with
+// domain.ErrPurchaseOrderStatus.
+func (r *PurchaseOrderRepository) DeletePurchaseOrder(ctx context.Context, id int, actor domain.AuditActor) error {
+	return r.db.WithTransaction(ctx, func(tx *sqlx.Tx) error {
+		order, err := lockPurchaseOrder(ctx, tx, id)
+		if err != nil {
+			return err
+		}
+		if !order.IsDraft() {
+			return domain.ErrPurchaseOrderStatus
+		}
+		if _, err := tx.ExecContext(ctx, sqlDeletePurchaseOrder, id); err != nil {
+			return model.WrapDatabaseError(err, "failed to delete purchase order")
+		}
+
+		return writeAudit(ctx, tx, actor, domain.AuditActionDelete, domain.AuditEntityPurchaseOrder, id,
+			toPurchaseOrderAudit(order), nil)
+	})
+}
+
+// FindStockMovements lists the stock movements of a book, newest first.
+func (r *PurchaseOrderRepository) FindStockMovements(ctx context.Context, bookId int, limit, offset int) ([]domain.StockMovement, error) {
+	var movements []model.StockMovement
+	if err := r.db.Select(ctx, "find_stock_movements", &movements, sqlFindStockMovements, bookId, limit, offset); err != nil {
+		return nil, model.WrapDatabaseError(err, "failed to find stock movements")
+	}
+	return toDomainStockMovements(movements)
+}
+
+// lockPurchaseOrder reads a purchase order with its lines and locks it until the end of
+// the transaction.
+func lockPurchaseOrder(ctx context.Context, tx *sqlx.Tx, id int) (domain.PurchaseOrder, error) {
+	var order model.PurchaseOrder
+	if err := tx.GetContext(ctx, &order, sqlLockPurchaseOrder, id); err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return domain.PurchaseOrder{}, domain.ErrNotFound
+		}
+		return domain.PurchaseOrder{}, model.WrapDatabaseError(err, "failed to get purchase order")
+	}
+	var items []model.PurchaseOrderItem
+	if err := tx.SelectContext(ctx, &items, sqlFindPurchaseOrderItems, toInt64Array([]int{id})); err != nil {
+		return domain.PurchaseOrder{}, model.WrapDatabaseError(err, "failed to get purchase order items")
+	}
+	return toDomainPurchaseOrder(order, items)
+}
+
+// insertPurchaseOrderItems adds the lines to a purchase order. It returns
+// domain.ErrNotFound when one of the books does not exist.
+func insertPurchaseOrderItems(ctx context.Context, tx *sqlx.Tx, id int, lines []domain.PurchaseOrderLine) error {
+	for _, line := range lines {
+		result, err := tx.ExecContext(ctx, sqlInsertPurchaseOrderItem, id, line.BookId(), line.Quantity(), line.CostPrice().Amount())
+		if err != nil {
+			return model.WrapDatabaseError(err, "failed to insert purchase order item")
+		}
+		if inserted, err := result.RowsAffected(); err != nil {
+			return model.WrapDatabaseError(err, "failed to insert purchase order item")
+		} else if inserted == 0 {
+			return domain.ErrNotFound
+		}
+	}
+	return nil
+}
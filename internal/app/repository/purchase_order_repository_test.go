@@ -0,0 +1,110 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/jmoiron/sqlx"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"toptal/internal/app/domain"
+	"toptal/internal/pkg/pg"
+)
+
+var (
+	purchaseOrderColumns     = []string{"id", "supplier_id", "status", "currency", "created_at", "sent_at", "received_at"}
+	purchaseOrderItemColumns = []string{"id", "purchase_order_id", "book_id", "title", "quantity", "received", "cost_price"}
+)
+
+func TestPurchaseOrderRepository_ReceivePurchaseOrder(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	require.NoError(t, err)
+	defer db.Close()
+
+	repo := NewPurchaseOrderRepository(pg.NewDB(sqlx.NewDb(db, "sqlmock")))
+	now := time.Now()
+
+	mock.ExpectBegin()
+	mock.ExpectQuery("SELECT \\* FROM purchase_orders WHERE id = \\$1 FOR UPDATE").
+		WithArgs(7).
+		WillReturnRows(sqlmock.NewRows(purchaseOrderColumns).AddRow(7, 3, "sent", "EUR", now, now, nil))
+	mock.ExpectQuery("SELECT \\* FROM purchase_order_items WHERE purchase_order_id = ANY\\(\\$1\\)").
+		WillReturnRows(sqlmock.NewRows(purchaseOrderItemColumns).
+			AddRow(1, 7, 1, "Dune", 10, 0, 450).
+			AddRow(2, 7, 2, "Emma", 4, 0, 450))
+	mock.ExpectExec("UPDATE purchase_order_items SET received = received \\+ \\$3").
+		WithArgs(7, 1, 6).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectExec("UPDATE books SET stock = stock \\+ \\$2 WHERE id = \\$1").
+		WithArgs(1, 6).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectExec("INSERT INTO stock_movements").
+		WithArgs(1, 6, domain.StockMovementReceipt, sql.NullInt64{Int64: 7, Valid: true}).
+		WillReturnResult(sqlmock.NewResult(1, 1))
+	mock.ExpectExec("UPDATE purchase_orders").
+		WithArgs(7, domain.PurchaseOrderPartiallyReceived).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectExec("INSERT INTO audit_log").
+		WillReturnResult(sqlmock.NewResult(1, 1))
+	mock.ExpectCommit()
+	mock.ExpectQuery("SELECT po.\\*, s.name AS supplier_name").
+		WithArgs(7).
+		WillReturnRows(sqlmock.NewRows(append(append([]string{}, purchaseOrderColumns...), "supplier_name")).
+			AddRow(7, 3, "partially_received", "EUR", now, now, nil, "Penguin"))
+	mock.ExpectQuery("SELECT \\* FROM purchase_order_items WHERE purchase_order_id = ANY\\(\\$1\\)").
+		WillReturnRows(sqlmock.NewRows(purchaseOrderItemColumns).
+			AddRow(1, 7, 1, "Dune", 10, 6, 450).
+			AddRow(2, 7, 2, "Emma", 4, 0, 450))
+
+	order, err := repo.ReceivePurchaseOrder(context.Background(), 7, map[int]int{1: 6}, domain.AuditActor{})
+	require.NoError(t, err)
+	assert.Equal(t, domain.PurchaseOrderPartiallyReceived, order.Status())
+	assert.Equal(t, "Penguin", order.SupplierName())
+	assert.Equal(t, 6, order.Lines()[0].Received())
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestPurchaseOrderRepository_ReceivePurchaseOrder_Draft(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	require.NoError(t, err)
+	defer db.Close()
+
+	repo := NewPurchaseOrderRepository(pg.NewDB(sqlx.NewDb(db, "sqlmock")))
+
+	mock.ExpectBegin()
+	mock.ExpectQuery("SELECT \\* FROM purchase_orders WHERE id = \\$1 FOR UPDATE").
+		WithArgs(7).
+		WillReturnRows(sqlmock.NewRows(purchaseOrderColumns).AddRow(7, 3, "draft", "EUR", time.Now(), nil, nil))
+	mock.ExpectQuery("SELECT \\* FROM purchase_order_items WHERE purchase_order_id = ANY\\(\\$1\\)").
+		WillReturnRows(sqlmock.NewRows(purchaseOrderItemColumns).AddRow(1, 7, 1, "Dune", 10, 0, 450))
+	mock.ExpectRollback()
+
+	_, err = repo.ReceivePurchaseOrder(context.Background(), 7, nil, domain.AuditActor{})
+	assert.ErrorIs(t, err, domain.ErrPurchaseOrderStatus)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestPurchaseOrderRepository_DeletePurchaseOrder_Sent(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	require.NoError(t, err)
+	defer db.Close()
+
+	repo := NewPurchaseOrderRepository(pg.NewDB(sqlx.NewDb(db, "sqlmock")))
+	now := time.Now()
+
+	mock.ExpectBegin()
+	mock.ExpectQuery("SELECT \\* FROM purchase_orders WHERE id = \\$1 FOR UPDATE").
+		WithArgs(7).
+		WillReturnRows(sqlmock.NewRows(purchaseOrderColumns).AddRow(7, 3, "sent", "EUR", now, now, nil))
+	mock.ExpectQuery("SELECT \\* FROM purchase_order_items WHERE purchase_order_id = ANY\\(\\$1\\)").
+		WillReturnRows(sqlmock.NewRows(purchaseOrderItemColumns).AddRow(1, 7, 1, "Dune", 10, 0, 450))
+	mock.ExpectRollback()
+
+	err = repo.DeletePurchaseOrder(context.Background(), 7, domain.AuditActor{})
+	assert.ErrorIs(t, err, domain.ErrPurchaseOrderStatus)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
@@ -0,0 +1,199 @@
+package repository
+
+import (
+	"context"
+	"toptal/internal/app/domain"
+	"toptal/internal/app/repository/model"
+	"toptal/internal/pkg/pg"
+
+	"github.com/jmoiron/sqlx"
+	"github.com/lib/pq"
+)
+
+const (
+	sqlLockAffinityState = `SELECT last_order_id FROM book_affinity_state FOR UPDATE`
+	// sqlNextAffinityBatch finds the last of the next $2 orders after $1. Orders of the last
+	// minute are left for the next run, so ones still being committed are not skipped.
+	sqlNextAffinityBatch = `
+		SELECT COALESCE(MAX(id), $1) AS last_order_id, COUNT(*) AS orders
+		FROM (
+			SELECT id FROM orders
+			WHERE id > $1 AND created_at < now() - interval '1 minute'
+			ORDER BY id
+			LIMIT $2
+		) batch
+	`
+	// sqlAddAffinity counts the customers who bought both books of a pair with the orders
+	// $1 < id <= $2. A customer counts for a pair once, in the batch of the order that
+	// completed it.
+	sqlAddAffinity = `
+		WITH buyers AS (
+			SELECT DISTINCT user_id FROM orders WHERE id > $1 AND id <= $2
+		),
+		owned AS (
+			SELECT o.user_id, oi.book_id, MIN(o.id) AS first_order_id
+			FROM orders o
+			JOIN order_items oi ON oi.order_id = o.id
+			WHERE o.user_id IN (SELECT user_id FROM buyers) AND o.id <= $2 AND oi.book_id IS NOT NULL
+			GROUP BY o.user_id, oi.book_id
+		)
+		INSERT INTO book_affinity (book_id, related_book_id, score)
+		SELECT a.book_id, b.book_id, COUNT(*)
+		FROM owned a
+		JOIN owned b ON b.user_id = a.user_id AND b.book_id <> a.book_id
+		WHERE GREATEST(a.first_order_id, b.first_order_id) > $1
+		GROUP BY a.book_id, b.book_id
+		ON CONFLICT (book_id, related_book_id) DO UPDATE SET score = book_affinity.score + EXCLUDED.score
+	`
+	sqlSaveAffinityState = `UPDATE book_affinity_state SET last_order_id = $1, refreshed_at = now()`
+	// sqlFindRelatedBooks lists available books bought by at least $2 customers of book $1.
+	sqlFindRelatedBooks = `
+		SELECT b.*
+		FROM book_affinity a
+		JOIN books b ON b.id = a.related_book_id
+		WHERE a.book_id = $1 AND a.score >= $2 AND b.stock > 0 AND b.deleted_at IS NULL
+		ORDER BY a.score DESC, b.id
+		LIMIT $3
+	`
+	// sqlFindUserRecommendations lists available books the customer $1 has not bought,
+	// by the customers who bought them along with the customer's books.
+	sqlFindUserRecommendations = `
+		WITH owned AS (
+			SELECT DISTINCT oi.book_id
+			FROM orders o
+			JOIN order_items oi ON oi.order_id = o.id
+			WHERE o.user_id = $1 AND oi.book_id IS NOT NULL
+		)
+		SELECT b.*
+		FROM book_affinity a
+		JOIN books b ON b.id = a.related_book_id
+		WHERE a.book_id IN (SELECT book_id FROM owned)
+			AND a.related_book_id NOT IN (SELECT book_id FROM owned)
+			AND a.score >= $2
+			AND b.stock > 0
+			AND b.deleted_at IS NULL
+		GROUP BY b.id
+		ORDER BY SUM(a.score) DESC, b.id
+		LIMIT $3
+	`
+	// sqlFindBestsellers lists the best selling available books of the categories $1, or
+	// of all when $1 is empty, leaving out the books $2 and those customer $3 has bought.
+	sqlFindBestsellers = `
+		WITH owned AS (
+			SELECT DISTINCT oi.book_id
+			FROM orders o
+			JOIN order_items oi ON oi.order_id = o.id
+			WHERE o.user_id = $3 AND oi.book_id IS NOT NULL
+		),
+		sold AS (
+			SELECT book_id, COUNT(*) AS copies FROM order_items WHERE book_id IS NOT NULL GROUP BY book_id
+		)
+		SELECT b.*
+		FROM books b
+		LEFT JOIN sold s ON s.book_id = b.id
+		WHERE b.stock > 0
+			AND b.deleted_at IS NULL
+			AND (cardinality($1::int[]) = 0 OR b.category_id = ANY($1))
+			AND NOT b.id = ANY($2)
+			AND b.id NOT IN (SELECT book_id FROM owned)
+		ORDER BY COALESCE(s.copies, 0) DESC, b.id
+		LIMIT $4
+	`
+	sqlFindPurchasedCategories = `
+		SELECT DISTINCT b.category_id
+		FROM orders o
+		JOIN order_items oi ON oi.order_id = o.id
+		JOIN books b ON b.id = oi.book_id
+		WHERE o.user_id = $1
+		ORDER BY b.category_id
+	`
+)
+
+type RecommendationRepository struct {
+	db *pg.DB
+}
+
+func NewRecommendationRepository(db *pg.DB) *RecommendationRepository {
+	return &RecommendationRepository{db}
+}
+
+// RefreshAffinity adds the next batchSize orders to the book affinity and returns how
+// many it added. Concurrent refreshes wait for each other.
+func (r *RecommendationRepository) RefreshAffinity(ctx context.Context, batchSize int) (int, error) {
+	var added int
+	err := r.db.WithTransaction(ctx, func(tx *sqlx.Tx) error {
+		var lastOrderId int
+		if err := tx.GetContext(ctx, &lastOrderId, sqlLockAffinityState); err != nil {
+			return model.WrapDatabaseError(err, "failed to get book affinity state")
+		}
+		var batch struct {
+			LastOrderId int `db:"last_order_id"`
+			Orders      int `db:"orders"`
+		}
+		if err := tx.GetContext(ctx, &batch, sqlNextAffinityBatch, lastOrderId, batchSize); err != nil {
+			return model.WrapDatabaseError(err, "failed to find orders for book affinity")
+		}
+		if batch.Orders == 0 {
+			return nil
+		}
+		nextOrderId := batch.LastOrderId
+
+		if _, err := tx.ExecContext(ctx, sqlAddAffinity, lastOrderId, nextOrderId); err != nil {
+			return model.WrapDatabaseError(err, "failed to add book affinity")
+		}
+		if _, err := tx.ExecContext(ctx, sqlSaveAffinityState, nextOrderId); err != nil {
+			return model.WrapDatabaseError(err, "failed to save book affinity state")
+		}
+		added = batch.Orders
+		return nil
+	})
+	return added, err
+}
+
+func (r *RecommendationRepository) FindRelatedBooks(ctx context.Context, bookId int, minScore, limit int) ([]domain.Book, error) {
+	var books []model.Book
+	if err := r.db.Select(ctx, "find_related_books", &books, sqlFindRelatedBooks, bookId, minScore, limit); err != nil {
+		return nil, model.WrapDatabaseError(err, "failed to find related books")
+	}
+	return toDomainBooks(books), nil
+}
+
+func (r *RecommendationRepository) FindUserRecommendations(ctx context.Context, userId int, minScore, limit int) ([]domain.Book, error) {
+	var books []model.Book
+	err := r.db.Select(ctx, "find_user_recommendations", &books, sqlFindUserRecommendations, userId, minScore, limit)
+	if err != nil {
+		return nil, model.WrapDatabaseError(err, "failed to find recommendations")
+	}
+	return toDomainBooks(books), nil
+}
+
+// FindBestsellers lists the best selling available books of the categories, or of all
+// categories when there are none. It leaves out the excluded books and, when userId is
+// not zero, the books the user has bought.
+func (r *RecommendationRepository) FindBestsellers(
+	ctx context.Context, categoryIds []int, excludeIds []int, userId int, limit int,
+) ([]domain.Book, error) {
+	var books []model.Book
+	err := r.db.Select(ctx, "find_bestsellers", &books, sqlFindBestsellers,
+		toInt64Array(categoryIds), toInt64Array(excludeIds), userId, limit)
+	if err != nil {
+		return nil, model.WrapDatabaseError(err, "failed to find bestsellers")
+	}
+	return toDomainBooks(books), nil
+}
+
+func (r *RecommendationRepository) FindPurchasedCategories(ctx context.Context, userId int) ([]int, error) {
+	var categoryIds []int
+	if err := r.db.Select(ctx, "find_purchased_categories", &categoryIds, sqlFindPurchasedCategories, userId); err != nil {
+		return nil, model.WrapDatabaseError(err, "failed to find purchased categories")
+	}
+	return categoryIds, nil
+}
+
+func toInt64Array(ids []int) pq.Int64Array {
+	array := pq.Int64Array{}
+	for _, id := range ids {
+		array = append(array, int64(id))
+	}
+	return array
+}
@@ -0,0 +1,61 @@
+package repository
+
+import (
+	"context"
+	"testing"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/jmoiron/sqlx"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"toptal/internal/pkg/pg"
+)
+
+func TestRecommendationRepository_RefreshAffinity(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	require.NoError(t, err)
+	defer db.Close()
+
+	repo := NewRecommendationRepository(pg.NewDB(sqlx.NewDb(db, "sqlmock")))
+
+	mock.ExpectBegin()
+	mock.ExpectQuery("SELECT last_order_id FROM book_affinity_state FOR UPDATE").
+		WillReturnRows(sqlmock.NewRows([]string{"last_order_id"}).AddRow(40))
+	mock.ExpectQuery("SELECT COALESCE\\(MAX\\(id\\), \\$1\\) AS last_order_id, COUNT\\(\\*\\) AS orders").
+		WithArgs(40, 100).
+		WillReturnRows(sqlmock.NewRows([]string{"last_order_id", "orders"}).AddRow(45, 3))
+	mock.ExpectExec("INSERT INTO book_affinity").
+		WithArgs(40, 45).
+		WillReturnResult(sqlmock.NewResult(0, 6))
+	mock.ExpectExec("UPDATE book_affinity_state SET last_order_id = \\$1").
+		WithArgs(45).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectCommit()
+
+	added, err := repo.RefreshAffinity(context.Background(), 100)
+	require.NoError(t, err)
+	assert.Equal(t, 3, added)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestRecommendationRepository_RefreshAffinityWithoutNewOrders(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	require.NoError(t, err)
+	defer db.Close()
+
+	repo := NewRecommendationRepository(pg.NewDB(sqlx.NewDb(db, "sqlmock")))
+
+	mock.ExpectBegin()
+	mock.ExpectQuery("SELECT last_order_id FROM book_affinity_state FOR UPDATE").
+		WillReturnRows(sqlmock.NewRows([]string{"last_order_id"}).AddRow(45))
+	mock.ExpectQuery("SELECT COALESCE\\(MAX\\(id\\), \\$1\\) AS last_order_id, COUNT\\(\\*\\) AS orders").
+		WithArgs(45, 100).
+		WillReturnRows(sqlmock.NewRows([]string{"last_order_id", "orders"}).AddRow(45, 0))
+	mock.ExpectCommit()
+
+	added, err := repo.RefreshAffinity(context.Background(), 100)
+	require.NoError(t, err)
+	assert.Zero(t, added)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
@@ -0,0 +1,180 @@
+package repository
+
+import (
+	"context"
+	"fmt"
+	"time"
+	"toptal/internal/app/domain"
+	"toptal/internal/app/repository/model"
+	"toptal/internal/pkg/pg"
+
+	"github.com/jmoiron/sqlx"
+)
+
+// The report queries take the range [$1, $2) and the granularity $3, and group orders
+// into periods in UTC. Net amounts leave out the tax, which prices include when the
+// order says so.
+const (
+	sqlCategoryRevenueReport = `
+		SELECT date_trunc($3, o.created_at, 'UTC') AS period,
+			COALESCE(c.id, 0) AS category_id, COALESCE(c.name, '') AS category_name, oi.currency,
+			COUNT(DISTINCT o.id) AS orders, COUNT(*) AS units,
+			SUM(CASE WHEN o.prices_include_tax THEN oi.price - oi.tax ELSE oi.price END) AS net,
+			SUM(oi.tax) AS tax
+		FROM orders o
+		JOIN order_items oi ON oi.order_id = o.id
+		LEFT JOIN categories c ON c.id = oi.category_id
+		WHERE o.created_at >= $1 AND o.created_at < $2
+		GROUP BY 1, 2, 3, 4
+		ORDER BY 1, 2, 4
+	`
+	// sqlBookSalesReport keeps purged books apart by their title and author.
+	sqlBookSalesReport = `
+		SELECT date_trunc($3, o.created_at, 'UTC') AS period,
+			COALESCE(oi.book_id, 0) AS book_id, MAX(oi.title) AS title, MAX(oi.author) AS author, oi.currency,
+			COUNT(*) AS units,
+			SUM(CASE WHEN o.prices_include_tax THEN oi.price - oi.tax ELSE oi.price END) AS net
+		FROM orders o
+		JOIN order_items oi ON oi.order_id = o.id
+		WHERE o.created_at >= $1 AND o.created_at < $2
+		GROUP BY 1, oi.book_id, oi.currency,
+			CASE WHEN oi.book_id IS NULL THEN oi.title END, CASE WHEN oi.book_id IS NULL THEN oi.author END
+		ORDER BY 1, units DESC, 2, 3
+	`
+	sqlBasketSizeReport = `
+		SELECT date_trunc($3, o.created_at, 'UTC') AS period, o.currency,
+			COUNT(*) AS orders, SUM(items.units) AS units, SUM(o.total) AS total
+		FROM orders o
+		CROSS JOIN LATERAL (SELECT COUNT(*) AS units FROM order_items WHERE order_id = o.id) items
+		WHERE o.created_at >= $1 AND o.created_at < $2
+		GROUP BY 1, 2
+		ORDER BY 1, 2
+	`
+	// sqlStockTurnoverReport counts the books themselves sold in each period of each listed
+	// category, not their formats. The stock at the end of a period is the current stock
+	// plus what was sold after it, up to now.
+	sqlStockTurnoverReport = `
+		WITH periods AS (
+			SELECT generate_series(
+				date_trunc($3, $1::timestamptz, 'UTC') AT TIME ZONE 'UTC',
+				$2::timestamptz AT TIME ZONE 'UTC' - interval '1 microsecond',
+				('1 ' || $3)::interval
+			) AT TIME ZONE 'UTC' AS period
+		),
+		sales AS (
+			SELECT oi.category_id, date_trunc($3, o.created_at, 'UTC') AS period, COUNT(*) AS units
+			FROM orders o
+			JOIN order_items oi ON oi.order_id = o.id
+			WHERE o.created_at >= $1 AND oi.format IS NULL AND oi.category_id IS NOT NULL
+			GROUP BY 1, 2
+		),
+		stock AS (
+			SELECT c.id AS category_id, c.name AS category_name, COALESCE(SUM(b.stock), 0) AS stock
+			FROM categories c
+			LEFT JOIN books b ON b.category_id = c.id AND b.deleted_at IS NULL
+			WHERE c.deleted_at IS NULL
+			GROUP BY c.id, c.name
+		)
+		SELECT p.period, st.category_id, st.category_name,
+			COALESCE(SUM(s.units) FILTER (WHERE s.period = p.period), 0) AS units,
+			st.stock + COALESCE(SUM(s.units) FILTER (WHERE s.period > p.period), 0) AS closing_stock
+		FROM periods p
+		CROSS JOIN stock st
+		LEFT JOIN sales s ON s.category_id = st.category_id AND s.period >= p.period
+		GROUP BY p.period, st.category_id, st.category_name, st.stock
+		ORDER BY 1, 2
+	`
+)
+
+// ReportRepository runs the sales reports in read-only transactions that give up after
+// the statement timeout, so a large report cannot hold up orders.
+type ReportRepository struct {
+	db               *pg.DB
+	statementTimeout time.Duration
+}
+
+func NewReportRepository(db *pg.DB, statementTimeout time.Duration) *ReportRepository {
+	return &ReportRepository{db: db, statementTimeout: statementTimeout}
+}
+
+func (r *ReportRepository) selectReport(ctx context.Context, dest any, query string, filter domain.ReportFilter) error {
+	return r.db.WithTransaction(ctx, func(tx *sqlx.Tx) error {
+		if _, err := tx.ExecContext(ctx, "SET TRANSACTION READ ONLY"); err != nil {
+			return model.WrapDatabaseError(err, "failed to start report")
+		}
+		if r.statementTimeout > 0 {
+			timeout := fmt.Sprintf("SET LOCAL statement_timeout = %d", r.statementTimeout.Milliseconds())
+			if _, err := tx.ExecContext(ctx, timeout); err != nil {
+				return model.WrapDatabaseError(err, "failed to start report")
+			}
+		}
+		if err := tx.SelectContext(ctx, dest, query, filter.From, filter.To, filter.Granularity); err != nil {
+			return model.WrapDatabaseError(err, "failed to run report")
+		}
+		return nil
+	})
+}
+
+func (r *ReportRepository) CategoryRevenue(ctx context.Context, filter domain.ReportFilter) ([]domain.CategoryRevenue, error) {
+	var rows []model.CategoryRevenue
+	if err := r.selectReport(ctx, &rows, sqlCategoryRevenueReport, filter); err != nil {
+		return nil, err
+	}
+	report := make([]domain.CategoryRevenue, len(rows))
+	for i, row := range rows {
+		net, err := domain.NewMoney(row.Net, row.Currency)
+		if err != nil {
+			return nil, err
+		}
+		tax, err := domain.NewMoney(row.Tax, row.Currency)
+		if err != nil {
+			return nil, err
+		}
+		report[i] = domain.NewCategoryRevenue(row.Period, row.CategoryId, row.CategoryName, row.Orders, row.Units, net, tax)
+	}
+	return report, nil
+}
+
+func (r *ReportRepository) BookSales(ctx context.Context, filter domain.ReportFilter) ([]domain.BookSales, error) {
+	var rows []model.BookSales
+	if err := r.selectReport(ctx, &rows, sqlBookSalesReport, filter); err != nil {
+		return nil, err
+	}
+	report := make([]domain.BookSales, len(rows))
+	for i, row := range rows {
+		net, err := domain.NewMoney(row.Net, row.Currency)
+		if err != nil {
+			return nil, err
+		}
+		report[i] = domain.NewBookSales(row.Period, row.BookId, row.Title, row.Author, row.Units, net)
+	}
+	return report, nil
+}
+
+func (r *ReportRepository) BasketSize(ctx context.Context, filter domain.ReportFilter) ([]domain.BasketSize, error) {
+	var rows []model.BasketSize
+	if err := r.selectReport(ctx, &rows, sqlBasketSizeReport, filter); err != nil {
+		return nil, err
+	}
+	report := make([]domain.BasketSize, len(rows))
+	for i, row := range rows {
+		total, err := domain.NewMoney(row.Total, row.Currency)
+		if err != nil {
+			return nil, err
+		}
+		report[i] = domain.NewBasketSize(row.Period, row.Orders, row.Units, total)
+	}
+	return report, nil
+}
+
+func (r *ReportRepository) StockTurnover(ctx context.Context, filter domain.ReportFilter) ([]domain.StockTurnover, error) {
+	var rows []model.StockTurnover
+	if err := r.selectReport(ctx, &rows, sqlStockTurnoverReport, filter); err != nil {
+		return nil, err
+	}
+	report := make([]domain.StockTurnover, len(rows))
+	for i, row := range rows {
+		report[i] = domain.NewStockTurnover(row.Period, row.CategoryId, row.CategoryName, row.Units, row.ClosingStock)
+	}
+	return report, nil
+}
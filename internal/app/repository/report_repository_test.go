@@ -0,0 +1,44 @@
+package repository
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/jmoiron/sqlx"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"toptal/internal/app/domain"
+	"toptal/internal/pkg/pg"
+)
+
+func TestReportRepository_CategoryRevenue(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	require.NoError(t, err)
+	defer db.Close()
+
+	repo := NewReportRepository(pg.NewDB(sqlx.NewDb(db, "sqlmock")), 5*time.Second)
+	from := time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC)
+	filter := domain.ReportFilter{From: from, To: from.AddDate(0, 1, 0), Granularity: domain.ReportGranularityWeek}
+
+	mock.ExpectBegin()
+	mock.ExpectExec("SET TRANSACTION READ ONLY").WillReturnResult(sqlmock.NewResult(0, 0))
+	mock.ExpectExec("SET LOCAL statement_timeout = 5000").WillReturnResult(sqlmock.NewResult(0, 0))
+	mock.ExpectQuery("SELECT date_trunc\\(\\$3, o.created_at, 'UTC'\\) AS period").
+		WithArgs(filter.From, filter.To, "week").
+		WillReturnRows(sqlmock.NewRows([]string{
+			"period", "category_id", "category_name", "currency", "orders", "units", "net", "tax",
+		}).AddRow(from, 2, "Fiction", "EUR", 3, 4, 4000, 280))
+	mock.ExpectCommit()
+
+	report, err := repo.CategoryRevenue(context.Background(), filter)
+	require.NoError(t, err)
+	require.Len(t, report, 1)
+	assert.Equal(t, "Fiction", report[0].CategoryName())
+	assert.Equal(t, 4, report[0].Units())
+	assert.Equal(t, "40.00", report[0].Net().Decimal())
+	assert.Equal(t, "EUR", report[0].Tax().Currency())
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
@@ -0,0 +1,201 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"time"
+	"toptal/internal/app/domain"
+	"toptal/internal/app/repository/model"
+	"toptal/internal/pkg/pg"
+
+	"github.com/jmoiron/sqlx"
+)
+
+const (
+	sqlInsertSession = `
+		INSERT INTO user_sessions (user_id, user_agent, ip_address, expires_at)
+		VALUES ($1, $2, $3, $4)
+		RETURNING *
+	`
+	// sqlPurgeUserSessions drops the user's sessions whose tokens can no longer be used.
+	sqlPurgeUserSessions = `DELETE FROM user_sessions WHERE user_id = $1 AND (expires_at < now() OR revoked_at IS NOT NULL)`
+	sqlFindSessionById   = `SELECT * FROM user_sessions WHERE id = $1`
+	sqlFindUserSessions  = `
+		SELECT * FROM user_sessions
+		WHERE user_id = $1 AND revoked_at IS NULL AND expires_at > now()
+		ORDER BY last_seen_at DESC, id DESC
+	`
+	sqlTouchSession = `
+		UPDATE user_sessions
+		SET last_seen_at = now()
+		WHERE id = $1 AND last_seen_at < now() - interval '1 minute'
+	`
+	// sqlInsertTwoFactorChallenge also clears out the user's challenges that can no longer
+	// be answered.
+	sqlInsertTwoFactorChallenge = `
+		WITH purged AS (
+			DELETE FROM two_factor_challenges WHERE user_id = $1 AND (expires_at < now() OR completed_at IS NOT NULL)
+		)
+		INSERT INTO two_factor_challenges (user_id, expires_at) VALUES ($1, $2) RETURNING id
+	`
+	sqlAttemptTwoFactorChallenge = `
+		UPDATE two_factor_challenges
+		SET attempts = attempts + 1
+		WHERE id = $1 AND user_id = $2 AND attempts < $3 AND completed_at IS NULL AND expires_at > now()
+	`
+	sqlCompleteTwoFactorChallenge = `
+		UPDATE two_factor_challenges SET completed_at = now() WHERE id = $1 AND completed_at IS NULL
+	`
+	sqlRevokeSession = `UPDATE user_sessions SET revoked_at = now() WHERE id = $1 AND user_id = $2 AND revoked_at IS NULL`
+	// sqlRevokeUserSessions keeps the session $2, or none when it is 0.
+	sqlRevokeUserSessions = `
+		UPDATE user_sessions
+		SET revoked_at = now()
+		WHERE user_id = $1 AND id <> $2 AND revoked_at IS NULL AND expires_at > now()
+	`
+)
+
+type SessionRepository struct {
+	db *pg.DB
+}
+
+func NewSessionRepository(db *pg.DB) *SessionRepository {
+	return &SessionRepository{db}
+}
+
+// InsertSession stores a new session and clears out the user's dead ones.
+func (r *SessionRepository) InsertSession(ctx context.Context, session domain.Session) (domain.Session, error) {
+	var created model.Session
+	err := r.db.WithTransaction(ctx, func(tx *sqlx.Tx) error {
+		if _, err := tx.ExecContext(ctx, sqlPurgeUserSessions, session.UserId()); err != nil {
+			return model.WrapDatabaseError(err, "failed to purge sessions")
+		}
+		err := tx.GetContext(ctx, &created, sqlInsertSession,
+			session.UserId(), toNullString(session.UserAgent()), toNullString(session.IPAddress()), session.ExpiresAt(),
+		)
+		if err != nil {
+			return model.WrapDatabaseError(err, "failed to insert session")
+		}
+		return nil
+	})
+	if err != nil {
+		return domain.Session{}, err
+	}
+	return toDomainSession(created)
+}
+
+func (r *SessionRepository) FindSessionById(ctx context.Context, id int) (domain.Session, error) {
+	var session model.Session
+	err := r.db.Get(ctx, "find_session_by_id", &session, sqlFindSessionById, id)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return domain.Session{}, domain.ErrNotFound
+		}
+		return domain.Session{}, model.WrapDatabaseError(err, "failed to find session")
+	}
+	return toDomainSession(session)
+}
+
+// FindSessionsByUser returns the user's sessions that can still be used, most recently seen first.
+func (r *SessionRepository) FindSessionsByUser(ctx context.Context, userId int) ([]domain.Session, error) {
+	var sessions []model.Session
+	err := r.db.Select(ctx, "find_user_sessions", &sessions, sqlFindUserSessions, userId)
+	if err != nil {
+		return nil, model.WrapDatabaseError(err, "failed to find sessions")
+	}
+	return toDomainSessions(sessions)
+}
+
+// TouchSession records that the session was used. Like API keys, updates are
+// throttled to once a minute.
+func (r *SessionRepository) TouchSession(ctx context.Context, id int) error {
+	if _, err := r.db.Exec(ctx, "touch_session", sqlTouchSession, id); err != nil {
+		return model.WrapDatabaseError(err, "failed to update session last seen time")
+	}
+	return nil
+}
+
+// InsertTwoFactorChallenge stores a challenge for the second step of the user's login
+// and returns its id.
+func (r *SessionRepository) InsertTwoFactorChallenge(ctx context.Context, userId int, expiresAt time.Time) (int, error) {
+	var id int
+	err := r.db.Get(ctx, "insert_two_factor_challenge", &id, sqlInsertTwoFactorChallenge, userId, expiresAt)
+	if err != nil {
+		return 0, model.WrapDatabaseError(err, "failed to insert two-factor challenge")
+	}
+	return id, nil
+}
+
+// AttemptTwoFactorChallenge counts an answer to the user's challenge. It reports false,
+// without counting it, when the challenge was completed, has expired or has already been
+// answered maxAttempts times.
+func (r *SessionRepository) AttemptTwoFactorChallenge(ctx context.Context, id int, userId int, maxAttempts int) (bool, error) {
+	result, err := r.db.Exec(ctx, "attempt_two_factor_challenge", sqlAttemptTwoFactorChallenge, id, userId, maxAttempts)
+	if err != nil {
+		return false, model.WrapDatabaseError(err, "failed to count two-factor attempt")
+	}
+	affected, err := result.RowsAffected()
+	if err != nil {
+		return false, model.WrapDatabaseError(err, "failed to get affected rows")
+	}
+	return affected == 1, nil
+}
+
+// CompleteTwoFactorChallenge marks the challenge as answered. It reports false when it
+// already was, so that only one login completes it.
+func (r *SessionRepository) CompleteTwoFactorChallenge(ctx context.Context, id int) (bool, error) {
+	result, err := r.db.Exec(ctx, "complete_two_factor_challenge", sqlCompleteTwoFactorChallenge, id)
+	if err != nil {
+		return false, model.WrapDatabaseError(err, "failed to complete two-factor challenge")
+	}
+	affected, err := result.RowsAffected()
+	if err != nil {
+		return false, model.WrapDatabaseError(err, "failed to get affected rows")
+	}
+	return affected == 1, nil
+}
+
+// RevokeSession revokes one of the user's sessions. Sessions of other users are not found.
+func (r *SessionRepository) RevokeSession(ctx context.Context, userId int, id int) error {
+	result, err := r.db.Exec(ctx, "revoke_session", sqlRevokeSession, id, userId)
+	if err != nil {
+		return model.WrapDatabaseError(err, "failed to revoke session")
+	}
+	affected, err := result.RowsAffected()
+	if err != nil {
+		return model.WrapDatabaseError(err, "failed to get affected rows")
+	}
+	if affected == 0 {
+		return domain.ErrNotFound
+	}
+	return nil
+}
+
+// RevokeUserSessions revokes every active session of the user but keepSessionId, which
+// is 0 to revoke them all, and returns how many there were. It is recorded in the audit log.
+func (r *SessionRepository) RevokeUserSessions(
+	ctx context.Context, userId int, keepSessionId int, actor domain.AuditActor,
+) (int, error) {
+	var revoked int64
+	err := r.db.WithTransaction(ctx, func(tx *sqlx.Tx) error {
+		result, err := tx.ExecContext(ctx, sqlRevokeUserSessions, userId, keepSessionId)
+		if err != nil {
+			return model.WrapDatabaseError(err, "failed to revoke sessions")
+		}
+		if revoked, err = result.RowsAffected(); err != nil {
+			return model.WrapDatabaseError(err, "failed to get affected rows")
+		}
+
+		after := map[string]any{"revoked_sessions": revoked}
+		entry, err := domain.NewAuditEntry(actor, domain.AuditActionRevoke, domain.AuditEntityUser, userId, nil, after)
+		if err != nil {
+			return err
+		}
+		return insertAuditEntry(ctx, tx, entry)
+	})
+	if err != nil {
+		return 0, err
+	}
+	return int(revoked), nil
+}
@@ -0,0 +1,114 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"toptal/internal/app/domain"
+	"toptal/internal/app/repository/model"
+	"toptal/internal/pkg/pg"
+
+	"github.com/jmoiron/sqlx"
+)
+
+const (
+	// sqlFindLowStock lists the listed books at or below their threshold, or $1 when they
+	// have none, with the copies of the book itself sold over the last $2 days.
+	sqlFindLowStock = `
+		SELECT b.*, COALESCE(b.reorder_threshold, $1::int) AS threshold, s.sold, a.level AS notified_level
+		FROM books b
+		CROSS JOIN LATERAL (
+			SELECT COUNT(*) AS sold
+			FROM order_items oi
+			JOIN orders o ON o.id = oi.order_id
+			WHERE oi.book_id = b.id AND oi.format IS NULL AND o.created_at > now() - make_interval(days => $2::int)
+		) s
+		LEFT JOIN stock_alerts a ON a.book_id = b.id
+		WHERE b.deleted_at IS NULL AND b.stock <= COALESCE(b.reorder_threshold, $1::int)
+		ORDER BY b.stock, b.id
+	`
+	// sqlClearRestockedAlerts forgets the alerts of books restocked above their threshold
+	// or removed from the catalogue.
+	sqlClearRestockedAlerts = `
+		DELETE FROM stock_alerts a
+		USING books b
+		WHERE b.id = a.book_id AND (b.stock > COALESCE(b.reorder_threshold, $1::int) OR b.deleted_at IS NOT NULL)
+	`
+	sqlSaveStockAlert = `
+		INSERT INTO stock_alerts (book_id, level, stock) VALUES ($1, $2, $3)
+		ON CONFLICT (book_id) DO UPDATE SET level = EXCLUDED.level, stock = EXCLUDED.stock, notified_at = now()
+	`
+	sqlSetReorderThreshold = `UPDATE books SET reorder_threshold = $2 WHERE id = $1 RETURNING *`
+)
+
+type StockAlertRepository struct {
+	db *pg.DB
+}
+
+func NewStockAlertRepository(db *pg.DB) *StockAlertRepository {
+	return &StockAlertRepository{db}
+}
+
+// FindLowStock lists the books at or below their reorder threshold, out of stock first,
+// with reorder suggestions made by the policy.
+func (r *StockAlertRepository) FindLowStock(ctx context.Context, policy domain.ReorderPolicy) ([]domain.StockAlert, error) {
+	var books []model.LowStockBook
+	err := r.db.Select(ctx, "find_low_stock", &books, sqlFindLowStock, policy.DefaultThreshold, policy.SalesDays)
+	if err != nil {
+		return nil, model.WrapDatabaseError(err, "failed to find low stock books")
+	}
+	alerts := make([]domain.StockAlert, len(books))
+	for i, book := range books {
+		alerts[i] = domain.NewStockAlert(toDomainBook(book.Book), book.Threshold, book.Sold, book.NotifiedLevel.String, policy)
+	}
+	return alerts, nil
+}
+
+// ClearRestockedAlerts forgets the books that are no longer low on stock, so they are
+// reported again the next time they run low. It returns how many it forgot.
+func (r *StockAlertRepository) ClearRestockedAlerts(ctx context.Context, defaultThreshold int) (int, error) {
+	result, err := r.db.Exec(ctx, "clear_restocked_alerts", sqlClearRestockedAlerts, defaultThreshold)
+	if err != nil {
+		return 0, model.WrapDatabaseError(err, "failed to clear restocked alerts")
+	}
+	cleared, err := result.RowsAffected()
+	if err != nil {
+		return 0, model.WrapDatabaseError(err, "failed to clear restocked alerts")
+	}
+	return int(cleared), nil
+}
+
+// MarkNotified records that the books were reported at their current level.
+func (r *StockAlertRepository) MarkNotified(ctx context.Context, alerts []domain.StockAlert) error {
+	return r.db.WithTransaction(ctx, func(tx *sqlx.Tx) error {
+		for _, alert := range alerts {
+			book := alert.Book()
+			if _, err := tx.ExecContext(ctx, sqlSaveStockAlert, book.Id(), alert.Level(), book.Stock()); err != nil {
+				return model.WrapDatabaseError(err, "failed to save stock alert")
+			}
+		}
+		return nil
+	})
+}
+
+// SetReorderThreshold sets the threshold of a listed book, or makes it use the default
+// threshold when threshold is nil.
+func (r *StockAlertRepository) SetReorderThreshold(ctx context.Context, bookId int, threshold *int, actor domain.AuditActor) error {
+	var value sql.NullInt64
+	if threshold != nil {
+		value = sql.NullInt64{Int64: int64(*threshold), Valid: true}
+	}
+	return r.db.WithTransaction(ctx, func(tx *sqlx.Tx) error {
+		var before, after model.Book
+		if err := tx.GetContext(ctx, &before, sqlLockBook, bookId); err != nil {
+			if errors.Is(err, sql.ErrNoRows) {
+				return domain.ErrNotFound
+			}
+			return model.WrapDatabaseError(err, "failed to get book")
+		}
+		if err := tx.GetContext(ctx, &after, sqlSetReorderThreshold, bookId, value); err != nil {
+			return model.WrapDatabaseError(err, "failed to set reorder threshold")
+		}
+		return writeAudit(ctx, tx, actor, domain.AuditActionUpdate, domain.AuditEntityBook, bookId, before, after)
+	})
+}
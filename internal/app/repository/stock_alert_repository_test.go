@@ -0,0 +1,103 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"testing"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/jmoiron/sqlx"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"toptal/internal/app/domain"
+	"toptal/internal/pkg/pg"
+)
+
+var lowStockColumns = append(append([]string{}, bookColumns...), "threshold", "sold", "notified_level")
+
+func TestStockAlertRepository_FindLowStock(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	require.NoError(t, err)
+	defer db.Close()
+
+	repo := NewStockAlertRepository(pg.NewDB(sqlx.NewDb(db, "sqlmock")))
+	policy := domain.ReorderPolicy{DefaultThreshold: 5, SalesDays: 28, CoverDays: 30}
+
+	mock.ExpectQuery("SELECT b.\\*, COALESCE\\(b.reorder_threshold, \\$1::int\\) AS threshold").
+		WithArgs(5, 28).
+		WillReturnRows(sqlmock.NewRows(lowStockColumns).
+			AddRow(1, "Dune", "Frank Herbert", 1965, 1000, "USD", 0, 1, nil, 5, 28, "low").
+			AddRow(2, "Emma", "Jane Austen", 1815, 800, "USD", 2, 1, nil, 3, 0, nil))
+
+	alerts, err := repo.FindLowStock(context.Background(), policy)
+	require.NoError(t, err)
+	require.Len(t, alerts, 2)
+
+	assert.Equal(t, domain.StockLevelOut, alerts[0].Level())
+	assert.True(t, alerts[0].NeedsNotification())
+	assert.Equal(t, 35, alerts[0].SuggestedQuantity())
+	assert.Equal(t, domain.StockLevelLow, alerts[1].Level())
+	assert.Equal(t, 3, alerts[1].Threshold())
+	assert.Equal(t, 2, alerts[1].SuggestedQuantity())
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestStockAlertRepository_ClearRestockedAlerts(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	require.NoError(t, err)
+	defer db.Close()
+
+	repo := NewStockAlertRepository(pg.NewDB(sqlx.NewDb(db, "sqlmock")))
+
+	mock.ExpectExec("DELETE FROM stock_alerts a").
+		WithArgs(5).
+		WillReturnResult(sqlmock.NewResult(0, 2))
+
+	cleared, err := repo.ClearRestockedAlerts(context.Background(), 5)
+	require.NoError(t, err)
+	assert.Equal(t, 2, cleared)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestStockAlertRepository_SetReorderThreshold(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	require.NoError(t, err)
+	defer db.Close()
+
+	repo := NewStockAlertRepository(pg.NewDB(sqlx.NewDb(db, "sqlmock")))
+	threshold := 10
+	columns := append(append([]string{}, bookColumns...), "reorder_threshold")
+
+	mock.ExpectBegin()
+	mock.ExpectQuery("SELECT \\* FROM books WHERE id = \\$1 AND deleted_at IS NULL FOR UPDATE").
+		WithArgs(1).
+		WillReturnRows(sqlmock.NewRows(columns).AddRow(1, "Dune", "Frank Herbert", 1965, 1000, "USD", 3, 1, nil, nil))
+	mock.ExpectQuery("UPDATE books SET reorder_threshold = \\$2 WHERE id = \\$1").
+		WithArgs(1, sql.NullInt64{Int64: 10, Valid: true}).
+		WillReturnRows(sqlmock.NewRows(columns).AddRow(1, "Dune", "Frank Herbert", 1965, 1000, "USD", 3, 1, nil, 10))
+	mock.ExpectExec("INSERT INTO audit_log").
+		WillReturnResult(sqlmock.NewResult(1, 1))
+	mock.ExpectCommit()
+
+	require.NoError(t, repo.SetReorderThreshold(context.Background(), 1, &threshold, domain.AuditActor{}))
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestStockAlertRepository_SetReorderThreshold_NotFound(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	require.NoError(t, err)
+	defer db.Close()
+
+	repo := NewStockAlertRepository(pg.NewDB(sqlx.NewDb(db, "sqlmock")))
+
+	mock.ExpectBegin()
+	mock.ExpectQuery("SELECT \\* FROM books WHERE id = \\$1 AND deleted_at IS NULL FOR UPDATE").
+		WithArgs(1).
+		WillReturnError(sql.ErrNoRows)
+	mock.ExpectRollback()
+
+	err = repo.SetReorderThreshold(context.Background(), 1, nil, domain.AuditActor{})
+	assert.ErrorIs(t, err, domain.ErrNotFound)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
@@ -0,0 +1,215 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"toptal/internal/app/domain"
+	"toptal/internal/app/repository/model"
+	"toptal/internal/pkg/pg"
+
+	"github.com/jmoiron/sqlx"
+)
+
+const (
+	sqlFindSuppliers    = `SELECT * FROM suppliers ORDER BY name`
+	sqlFindSupplierById = `SELECT * FROM suppliers WHERE id = $1`
+	sqlLockSupplier     = `SELECT * FROM suppliers WHERE id = $1 FOR UPDATE`
+	sqlInsertSupplier   = `INSERT INTO suppliers (name, email, phone) VALUES ($1, $2, $3) RETURNING *`
+	sqlUpdateSupplier   = `UPDATE suppliers SET name = $2, email = $3, phone = $4 WHERE id = $1 RETURNING *`
+	sqlDeleteSupplier   = `DELETE FROM suppliers WHERE id = $1`
+
+	sqlFindBookSuppliers = `
+		SELECT bs.*, s.name AS supplier_name
+		FROM book_suppliers bs
+		JOIN suppliers s ON s.id = bs.supplier_id
+		WHERE bs.book_id = $1
+		ORDER BY bs.cost_price, s.name
+	`
+	sqlFindSupplierBooks = `
+		SELECT bs.*, s.name AS supplier_name
+		FROM book_suppliers bs
+		JOIN suppliers s ON s.id = bs.supplier_id
+		WHERE bs.supplier_id = $1 AND bs.book_id = ANY($2)
+	`
+	sqlLockBookSupplier   = `SELECT * FROM book_suppliers WHERE book_id = $1 AND supplier_id = $2 FOR UPDATE`
+	sqlUpsertBookSupplier = `
+		INSERT INTO book_suppliers (book_id, supplier_id, cost_price, currency, supplier_sku)
+		VALUES ($1, $2, $3, $4, $5)
+		ON CONFLICT (book_id, supplier_id) DO UPDATE
+		SET cost_price = EXCLUDED.cost_price, currency = EXCLUDED.currency, supplier_sku = EXCLUDED.supplier_sku,
+			updated_at = now()
+		RETURNING *
+	`
+	sqlDeleteBookSupplier = `DELETE FROM book_suppliers WHERE book_id = $1 AND supplier_id = $2 RETURNING *`
+)
+
+type SupplierRepository struct {
+	db *pg.DB
+}
+
+func NewSupplierRepository(db *pg.DB) *SupplierRepository {
+	return &SupplierRepository{db}
+}
+
+func (r *SupplierRepository) FindSuppliers(ctx context.Context) ([]domain.Supplier, error) {
+	var suppliers []model.Supplier
+	if err := r.db.Select(ctx, "find_suppliers", &suppliers, sqlFindSuppliers); err != nil {
+		return nil, model.WrapDatabaseError(err, "failed to find suppliers")
+	}
+	return toDomainSuppliers(suppliers)
+}
+
+func (r *SupplierRepository) FindSupplierById(ctx context.Context, id int) (domain.Supplier, error) {
+	var supplier model.Supplier
+	if err := r.db.Get(ctx, "find_supplier_by_id", &supplier, sqlFindSupplierById, id); err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return domain.Supplier{}, domain.ErrNotFound
+		}
+		return domain.Supplier{}, model.WrapDatabaseError(err, "failed to find supplier")
+	}
+	return toDomainSupplier(supplier)
+}
+
+// InsertSupplier stores a new supplier, or returns domain.ErrAlreadyExists when another
+// one has its name.
+func (r *SupplierRepository) InsertSupplier(ctx context.Context, supplier domain.Supplier, actor domain.AuditActor) (domain.Supplier, error) {
+	var created model.Supplier
+	err := r.db.WithTransaction(ctx, func(tx *sqlx.Tx) error {
+		err := tx.GetContext(ctx, &created, sqlInsertSupplier,
+			supplier.Name(), toNullString(supplier.Email()), toNullString(supplier.Phone()))
+		if err != nil {
+			if pg.IsUniqueViolationErr(err) {
+				return domain.ErrAlreadyExists
+			}
+			return model.WrapDatabaseError(err, "failed to insert supplier")
+		}
+
+		return writeAudit(ctx, tx, actor, domain.AuditActionCreate, domain.AuditEntitySupplier, created.Id, nil, created)
+	})
+	if err != nil {
+		return domain.Supplier{}, err
+	}
+	return toDomainSupplier(created)
+}
+
+func (r *SupplierRepository) UpdateSupplier(ctx context.Context, supplier domain.Supplier, actor domain.AuditActor) (domain.Supplier, error) {
+	var before, after model.Supplier
+	err := r.db.WithTransaction(ctx, func(tx *sqlx.Tx) error {
+		if err := tx.GetContext(ctx, &before, sqlLockSupplier, supplier.Id()); err != nil {
+			if errors.Is(err, sql.ErrNoRows) {
+				return domain.ErrNotFound
+			}
+			return model.WrapDatabaseError(err, "failed to get supplier")
+		}
+		err := tx.GetContext(ctx, &after, sqlUpdateSupplier,
+			supplier.Id(), supplier.Name(), toNullString(supplier.Email()), toNullString(supplier.Phone()))
+		if err != nil {
+			if pg.IsUniqueViolationErr(err) {
+				return domain.ErrAlreadyExists
+			}
+			return model.WrapDatabaseError(err, "failed to update supplier")
+		}
+
+		return writeAudit(ctx, tx, actor, domain.AuditActionUpdate, domain.AuditEntitySupplier, supplier.Id(), before, after)
+	})
+	if err != nil {
+		return domain.Supplier{}, err
+	}
+	return toDomainSupplier(after)
+}
+
+// DeleteSupplier removes a supplier and the books it supplies. Suppliers with purchase
+// orders are kept for their history and refused with domain.ErrSupplierInUse.
+func (r *SupplierRepository) DeleteSupplier(ctx context.Context, id int, actor domain.AuditActor) error {
+	return r.db.WithTransaction(ctx, func(tx *sqlx.Tx) error {
+		var before model.Supplier
+		if err := tx.GetContext(ctx, &before, sqlLockSupplier, id); err != nil {
+			if errors.Is(err, sql.ErrNoRows) {
+				return domain.ErrNotFound
+			}
+			return model.WrapDatabaseError(err, "failed to get supplier")
+		}
+		if _, err := tx.ExecContext(ctx, sqlDeleteSupplier, id); err != nil {
+			if pg.IsForeignKeyViolationErr(err) {
+				return domain.ErrSupplierInUse
+			}
+			return model.WrapDatabaseError(err, "failed to delete supplier")
+		}
+
+		return writeAudit(ctx, tx, actor, domain.AuditActionDelete, domain.AuditEntitySupplier, id, before, nil)
+	})
+}
+
+// FindBookSuppliers lists the suppliers of a book, cheapest first.
+func (r *SupplierRepository) FindBookSuppliers(ctx context.Context, bookId int) ([]domain.BookSupplier, error) {
+	var bookSuppliers []model.BookSupplier
+	if err := r.db.Select(ctx, "find_book_suppliers", &bookSuppliers, sqlFindBookSuppliers, bookId); err != nil {
+		return nil, model.WrapDatabaseError(err, "failed to find book suppliers")
+	}
+	return toDomainBookSuppliers(bookSuppliers)
+}
+
+// FindSupplierBooks returns the ones of the books that the supplier supplies.
+func (r *SupplierRepository) FindSupplierBooks(ctx context.Context, supplierId int, bookIds []int) ([]domain.BookSupplier, error) {
+	var bookSuppliers []model.BookSupplier
+	err := r.db.Select(ctx, "find_supplier_books", &bookSuppliers, sqlFindSupplierBooks, supplierId, toInt64Array(bookIds))
+	if err != nil {
+		return nil, model.WrapDatabaseError(err, "failed to find supplier books")
+	}
+	return toDomainBookSuppliers(bookSuppliers)
+}
+
+// SaveBookSupplier adds a supplier to a listed book or changes its cost price. It returns
+// domain.ErrNotFound when the book or the supplier does not exist.
+func (r *SupplierRepository) SaveBookSupplier(
+	ctx context.Context, bookSupplier domain.BookSupplier, actor domain.AuditActor,
+) (domain.BookSupplier, error) {
+	var after model.BookSupplier
+	err := r.db.WithTransaction(ctx, func(tx *sqlx.Tx) error {
+		if _, err := lockListedBook(ctx, tx, bookSupplier.BookId()); err != nil {
+			return err
+		}
+
+		var existing model.BookSupplier
+		var before any
+		action := domain.AuditActionCreate
+		err := tx.GetContext(ctx, &existing, sqlLockBookSupplier, bookSupplier.BookId(), bookSupplier.SupplierId())
+		switch {
+		case err == nil:
+			before, action = existing, domain.AuditActionUpdate
+		case !errors.Is(err, sql.ErrNoRows):
+			return model.WrapDatabaseError(err, "failed to get book supplier")
+		}
+
+		costPrice := bookSupplier.CostPrice()
+		err = tx.GetContext(ctx, &after, sqlUpsertBookSupplier, bookSupplier.BookId(), bookSupplier.SupplierId(),
+			costPrice.Amount(), costPrice.Currency(), toNullString(bookSupplier.SKU()))
+		if err != nil {
+			if pg.IsForeignKeyViolationErr(err) {
+				return domain.ErrNotFound
+			}
+			return model.WrapDatabaseError(err, "failed to save book supplier")
+		}
+
+		return writeAudit(ctx, tx, actor, action, domain.AuditEntityBookSupplier, bookSupplier.BookId(), before, after)
+	})
+	if err != nil {
+		return domain.BookSupplier{}, err
+	}
+	return toDomainBookSupplier(after)
+}
+
+func (r *SupplierRepository) DeleteBookSupplier(ctx context.Context, bookId int, supplierId int, actor domain.AuditActor) error {
+	return r.db.WithTransaction(ctx, func(tx *sqlx.Tx) error {
+		var deleted model.BookSupplier
+		if err := tx.GetContext(ctx, &deleted, sqlDeleteBookSupplier, bookId, supplierId); err != nil {
+			if errors.Is(err, sql.ErrNoRows) {
+				return domain.ErrNotFound
+			}
+			return model.WrapDatabaseError(err, "failed to delete book supplier")
+		}
+
+		return writeAudit(ctx, tx, actor, domain.AuditActionDelete, domain.AuditEntityBookSupplier, bookId, deleted, nil)
+	})
+}
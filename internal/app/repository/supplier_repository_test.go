@@ -0,0 +1,59 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/jmoiron/sqlx"
+	"github.com/lib/pq"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"toptal/internal/app/domain"
+	"toptal/internal/pkg/pg"
+)
+
+func TestSupplierRepository_InsertSupplier_AlreadyExists(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	require.NoError(t, err)
+	defer db.Close()
+
+	repo := NewSupplierRepository(pg.NewDB(sqlx.NewDb(db, "sqlmock")))
+	supplier, err := domain.NewSupplier(0, "Penguin", "orders@penguin.example", "")
+	require.NoError(t, err)
+
+	mock.ExpectBegin()
+	mock.ExpectQuery("INSERT INTO suppliers").
+		WithArgs("Penguin", sql.NullString{String: "orders@penguin.example", Valid: true}, sql.NullString{}).
+		WillReturnError(&pq.Error{Code: "23505"})
+	mock.ExpectRollback()
+
+	_, err = repo.InsertSupplier(context.Background(), supplier, domain.AuditActor{})
+	assert.ErrorIs(t, err, domain.ErrAlreadyExists)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestSupplierRepository_DeleteSupplier_InUse(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	require.NoError(t, err)
+	defer db.Close()
+
+	repo := NewSupplierRepository(pg.NewDB(sqlx.NewDb(db, "sqlmock")))
+
+	mock.ExpectBegin()
+	mock.ExpectQuery("SELECT \\* FROM suppliers WHERE id = \\$1 FOR UPDATE").
+		WithArgs(3).
+		WillReturnRows(sqlmock.NewRows([]string{"id", "name", "email", "phone", "created_at"}).
+			AddRow(3, "Penguin", nil, nil, time.Now()))
+	mock.ExpectExec("DELETE FROM suppliers WHERE id = \\$1").
+		WithArgs(3).
+		WillReturnError(&pq.Error{Code: "23503"})
+	mock.ExpectRollback()
+
+	err = repo.DeleteSupplier(context.Background(), 3, domain.AuditActor{})
+	assert.ErrorIs(t, err, domain.ErrSupplierInUse)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
@@ -2,17 +2,70 @@ package repository
 
 import (
 	"context"
+	"crypto/rand"
+	"database/sql"
+	"encoding/hex"
 	"errors"
+	"fmt"
 	"log/slog"
 	"toptal/internal/app/domain"
 	"toptal/internal/app/repository/model"
 	"toptal/internal/pkg/pg"
+
+	"github.com/jmoiron/sqlx"
 )
 
 const (
-	sqlFindUserByName = `SELECT * FROM users WHERE username = $1`
-	sqlFindUserById   = `SELECT * FROM users WHERE id = $1`
-	sqlCreateUser     = `INSERT INTO users (username, password_hash, admin) VALUES (:username, :password_hash, false)`
+	sqlFindUserByName  = `SELECT * FROM users WHERE username = $1 AND deleted_at IS NULL`
+	sqlFindUserById    = `SELECT * FROM users WHERE id = $1 AND deleted_at IS NULL`
+	sqlFindUserByEmail = `SELECT * FROM users WHERE email = $1 AND deleted_at IS NULL`
+	sqlCreateUser      = `INSERT INTO users (username, password_hash, admin, email) VALUES (:username, :password_hash, false, :email)`
+	// sqlUpgradePasswordHash only replaces the hash it was computed from, so a password
+	// changed in the meantime is never overwritten.
+	sqlUpgradePasswordHash = `UPDATE users SET password_hash = $3, updated_at = now() WHERE id = $1 AND password_hash = $2`
+	sqlUpdateUserProfile   = `
+		UPDATE users
+		SET username = :username, email = :email, email_verified = :email_verified, updated_at = now()
+		WHERE id = :id AND deleted_at IS NULL
+	`
+	sqlChangePassword = `UPDATE users SET password_hash = $2, updated_at = now() WHERE id = $1 AND deleted_at IS NULL`
+	// sqlAnonymiseUser keeps the row so orders still reference it, but removes
+	// everything that identifies the person and makes the account unusable. The
+	// password hash is domain.UnusablePasswordHash. The new username is random, so no
+	// username anyone chose can stand in its way.
+	sqlAnonymiseUser = `
+		UPDATE users
+		SET username = $2, email = NULL, email_verified = FALSE, password_hash = '!',
+			admin = FALSE, totp_secret = NULL, totp_enabled = FALSE, totp_last_step = NULL,
+			deleted_at = now(), updated_at = now()
+		WHERE id = $1 AND deleted_at IS NULL
+	`
+	sqlDeleteUserTokens = `DELETE FROM user_tokens WHERE user_id = $1`
+	sqlDeleteUserCart   = `DELETE FROM cart WHERE user_id = $1`
+	sqlDeleteIdentities = `DELETE FROM user_identities WHERE user_id = $1`
+	sqlDeleteSessions   = `DELETE FROM user_sessions WHERE user_id = $1`
+	// sqlAnonymiseOrders keeps the region and country an order was taxed for.
+	sqlAnonymiseOrders = `
+		UPDATE orders
+		SET shipping_name = NULL, shipping_line1 = NULL, shipping_line2 = NULL, shipping_city = NULL,
+			shipping_postal_code = NULL
+		WHERE user_id = $1
+	`
+	sqlSetTOTPSecret = `
+		UPDATE users
+		SET totp_secret = $2, totp_last_step = NULL, updated_at = now()
+		WHERE id = $1 AND totp_enabled = FALSE
+	`
+	sqlEnableTOTP          = `UPDATE users SET totp_enabled = TRUE, updated_at = now() WHERE id = $1 AND totp_secret IS NOT NULL`
+	sqlDisableTOTP         = `UPDATE users SET totp_enabled = FALSE, totp_secret = NULL, totp_last_step = NULL, updated_at = now() WHERE id = $1`
+	sqlConsumeTOTPStep     = `UPDATE users SET totp_last_step = $2 WHERE id = $1 AND (totp_last_step IS NULL OR totp_last_step < $2)`
+	sqlDeleteRecoveryCodes = `DELETE FROM user_recovery_codes WHERE user_id = $1`
+	sqlInsertRecoveryCode  = `INSERT INTO user_recovery_codes (user_id, code_hash) VALUES ($1, $2)`
+	sqlConsumeRecoveryCode = `
+		UPDATE user_recovery_codes
+		SET used_at = now()
+		WHERE user_id = $1 AND code_hash = $2 AND used_at IS NULL
+	`
 )
 
 type UserRepository struct {
@@ -43,6 +96,18 @@ func (r *UserRepository) FindUserById(ctx context.Context, id int) (domain.User,
 	return toDomainUser(user)
 }
 
+func (r *UserRepository) FindUserByEmail(ctx context.Context, email string) (domain.User, error) {
+	var user model.User
+	err := r.db.Get(ctx, "find_user_by_email", &user, sqlFindUserByEmail, email)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return domain.User{}, domain.ErrNotFound
+		}
+		return domain.User{}, model.WrapDatabaseError(err, "failed to find user by email")
+	}
+	return toDomainUser(user)
+}
+
 func (r *UserRepository) CreateUser(ctx context.Context, user domain.User) error {
 	_, err := r.db.NamedExec(ctx, "create_user", sqlCreateUser, toModelUser(user))
 	if err != nil {
@@ -54,3 +119,179 @@ func (r *UserRepository) CreateUser(ctx context.Context, user domain.User) error
 	}
 	return nil
 }
+
+// UpgradePasswordHash stores a rehash of the user's password made under a stronger policy.
+func (r *UserRepository) UpgradePasswordHash(ctx context.Context, userId int, oldHash string, newHash string) error {
+	if _, err := r.db.Exec(ctx, "upgrade_password_hash", sqlUpgradePasswordHash, userId, oldHash, newHash); err != nil {
+		return model.WrapDatabaseError(err, "failed to upgrade password hash")
+	}
+	return nil
+}
+
+// UpdateUserProfile saves the username and email of an existing user.
+func (r *UserRepository) UpdateUserProfile(ctx context.Context, user domain.User) error {
+	result, err := r.db.NamedExec(ctx, "update_user_profile", sqlUpdateUserProfile, toModelUser(user))
+	if err != nil {
+		if pg.IsUniqueViolationErr(err) {
+			return domain.ErrAlreadyExists
+		}
+		return model.WrapDatabaseError(err, "failed to update user profile")
+	}
+	affected, err := result.RowsAffected()
+	if err != nil {
+		return model.WrapDatabaseError(err, "failed to get affected rows")
+	}
+	if affected == 0 {
+		return domain.ErrNotFound
+	}
+	return nil
+}
+
+// ChangePassword stores a new password hash and cancels outstanding reset links.
+func (r *UserRepository) ChangePassword(ctx context.Context, userId int, passwordHash string) error {
+	return r.db.WithTransaction(ctx, func(tx *sqlx.Tx) error {
+		result, err := tx.ExecContext(ctx, sqlChangePassword, userId, passwordHash)
+		if err != nil {
+			return model.WrapDatabaseError(err, "failed to change password")
+		}
+		affected, err := result.RowsAffected()
+		if err != nil {
+			return model.WrapDatabaseError(err, "failed to get affected rows")
+		}
+		if affected == 0 {
+			return domain.ErrNotFound
+		}
+		if _, err := tx.ExecContext(ctx, sqlInvalidateUserTokens, userId, domain.TokenPurposePasswordReset); err != nil {
+			return model.WrapDatabaseError(err, "failed to invalidate reset tokens")
+		}
+		return nil
+	})
+}
+
+// DeleteUser anonymises the user and removes their credentials, tokens, cart, sessions
+// and linked sign-in identities.
+// Orders are kept for accounting, without the street address they were shipped to.
+func (r *UserRepository) DeleteUser(ctx context.Context, userId int) error {
+	return r.db.WithTransaction(ctx, func(tx *sqlx.Tx) error {
+		username, err := anonymousUsername()
+		if err != nil {
+			return err
+		}
+		result, err := tx.ExecContext(ctx, sqlAnonymiseUser, userId, username)
+		if err != nil {
+			return model.WrapDatabaseError(err, "failed to anonymise user")
+		}
+		affected, err := result.RowsAffected()
+		if err != nil {
+			return model.WrapDatabaseError(err, "failed to get affected rows")
+		}
+		if affected == 0 {
+			return domain.ErrNotFound
+		}
+		for _, query := range []string{sqlDeleteRecoveryCodes, sqlDeleteUserTokens, sqlDeleteUserCart, sqlDeleteIdentities, sqlDeleteSessions, sqlAnonymiseOrders} {
+			if _, err := tx.ExecContext(ctx, query, userId); err != nil {
+				return model.WrapDatabaseError(err, "failed to delete user data")
+			}
+		}
+		slog.Info("User deleted", "user_id", userId)
+		return nil
+	})
+}
+
+// anonymousUsername names a deleted user "deleted-" followed by 128 random bits.
+func anonymousUsername() (string, error) {
+	raw := make([]byte, 16)
+	if _, err := rand.Read(raw); err != nil {
+		return "", fmt.Errorf("failed to generate anonymous username: %w", err)
+	}
+	return "deleted-" + hex.EncodeToString(raw), nil
+}
+
+// SetTOTPSecret stores the secret of a pending enrollment. It has no effect once
+// two-factor authentication is enabled.
+func (r *UserRepository) SetTOTPSecret(ctx context.Context, userId int, secret string) error {
+	result, err := r.db.Exec(ctx, "set_totp_secret", sqlSetTOTPSecret, userId, secret)
+	if err != nil {
+		return model.WrapDatabaseError(err, "failed to set totp secret")
+	}
+	affected, err := result.RowsAffected()
+	if err != nil {
+		return model.WrapDatabaseError(err, "failed to get affected rows")
+	}
+	if affected == 0 {
+		return domain.ErrTwoFactorAlreadyEnabled
+	}
+	return nil
+}
+
+// EnableTOTP turns on two-factor authentication and replaces the user's recovery codes.
+func (r *UserRepository) EnableTOTP(ctx context.Context, userId int, recoveryCodeHashes []string) error {
+	return r.db.WithTransaction(ctx, func(tx *sqlx.Tx) error {
+		result, err := tx.ExecContext(ctx, sqlEnableTOTP, userId)
+		if err != nil {
+			return model.WrapDatabaseError(err, "failed to enable totp")
+		}
+		affected, err := result.RowsAffected()
+		if err != nil {
+			return model.WrapDatabaseError(err, "failed to get affected rows")
+		}
+		if affected == 0 {
+			return domain.ErrTwoFactorNotEnabled
+		}
+		if err := r.replaceRecoveryCodes(ctx, tx, userId, recoveryCodeHashes); err != nil {
+			return fmt.Errorf("failed to store recovery codes: %w", err)
+		}
+		return nil
+	})
+}
+
+func (r *UserRepository) DisableTOTP(ctx context.Context, userId int) error {
+	return r.db.WithTransaction(ctx, func(tx *sqlx.Tx) error {
+		if _, err := tx.ExecContext(ctx, sqlDisableTOTP, userId); err != nil {
+			return model.WrapDatabaseError(err, "failed to disable totp")
+		}
+		if _, err := tx.ExecContext(ctx, sqlDeleteRecoveryCodes, userId); err != nil {
+			return model.WrapDatabaseError(err, "failed to delete recovery codes")
+		}
+		return nil
+	})
+}
+
+// ConsumeTOTPStep records the time step of an accepted code. It returns false when a
+// code from the same or a later step was already used, which stops replays.
+func (r *UserRepository) ConsumeTOTPStep(ctx context.Context, userId int, step int64) (bool, error) {
+	result, err := r.db.Exec(ctx, "consume_totp_step", sqlConsumeTOTPStep, userId, step)
+	if err != nil {
+		return false, model.WrapDatabaseError(err, "failed to record totp step")
+	}
+	affected, err := result.RowsAffected()
+	if err != nil {
+		return false, model.WrapDatabaseError(err, "failed to get affected rows")
+	}
+	return affected == 1, nil
+}
+
+// ConsumeRecoveryCode marks an unused recovery code as used and reports whether it existed.
+func (r *UserRepository) ConsumeRecoveryCode(ctx context.Context, userId int, codeHash string) (bool, error) {
+	result, err := r.db.Exec(ctx, "consume_recovery_code", sqlConsumeRecoveryCode, userId, codeHash)
+	if err != nil {
+		return false, model.WrapDatabaseError(err, "failed to consume recovery code")
+	}
+	affected, err := result.RowsAffected()
+	if err != nil {
+		return false, model.WrapDatabaseError(err, "failed to get affected rows")
+	}
+	return affected > 0, nil
+}
+
+func (r *UserRepository) replaceRecoveryCodes(ctx context.Context, tx *sqlx.Tx, userId int, codeHashes []string) error {
+	if _, err := tx.ExecContext(ctx, sqlDeleteRecoveryCodes, userId); err != nil {
+		return model.WrapDatabaseError(err, "failed to delete recovery codes")
+	}
+	for _, codeHash := range codeHashes {
+		if _, err := tx.ExecContext(ctx, sqlInsertRecoveryCode, userId, codeHash); err != nil {
+			return model.WrapDatabaseError(err, "failed to insert recovery code")
+		}
+	}
+	return nil
+}
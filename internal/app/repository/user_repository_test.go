@@ -3,7 +3,9 @@ package repository
 import (
 	"context"
 	"database/sql"
+	"database/sql/driver"
 	"errors"
+	"regexp"
 	"testing"
 
 	"github.com/DATA-DOG/go-sqlmock"
@@ -98,7 +100,7 @@ func TestUserRepository_CreateUser(t *testing.T) {
 		}
 
 		mock.ExpectExec("INSERT INTO users").
-			WithArgs(user.Username(), user.PasswordHash()).
+			WithArgs(user.Username(), user.PasswordHash(), nil).
 			WillReturnResult(sqlmock.NewResult(1, 1))
 
 		err = repo.CreateUser(context.Background(), user)
@@ -120,3 +122,94 @@ func TestUserRepository_CreateUser(t *testing.T) {
 		assert.Equal(t, "failed to create user", err.Error())
 	})
 }
+
+func TestUserRepository_UpgradePasswordHash(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("an error '%s' was not expected when opening a stub database connection", err)
+	}
+	defer db.Close()
+
+	pgDB := pg.NewDB(sqlx.NewDb(db, "sqlmock"))
+	repo := NewUserRepository(pgDB)
+
+	mock.ExpectExec("UPDATE users SET password_hash = \\$3, updated_at = now\\(\\) WHERE id = \\$1 AND password_hash = \\$2").
+		WithArgs(1, "old", "new").
+		WillReturnResult(sqlmock.NewResult(0, 1))
+
+	err = repo.UpgradePasswordHash(context.Background(), 1, "old", "new")
+	assert.NoError(t, err)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestUserRepository_DeleteUser(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("an error '%s' was not expected when opening a stub database connection", err)
+	}
+	defer db.Close()
+
+	pgDB := pg.NewDB(sqlx.NewDb(db, "sqlmock"))
+	repo := NewUserRepository(pgDB)
+
+	t.Run("User anonymised", func(t *testing.T) {
+		mock.ExpectBegin()
+		mock.ExpectExec("UPDATE users\\s+SET username = \\$2").
+			WithArgs(1, anonymousUsernameArg{}).
+			WillReturnResult(sqlmock.NewResult(0, 1))
+		mock.ExpectExec("DELETE FROM user_recovery_codes WHERE user_id = \\$1").
+			WithArgs(1).
+			WillReturnResult(sqlmock.NewResult(0, 0))
+		mock.ExpectExec("DELETE FROM user_tokens WHERE user_id = \\$1").
+			WithArgs(1).
+			WillReturnResult(sqlmock.NewResult(0, 2))
+		mock.ExpectExec("DELETE FROM cart WHERE user_id = \\$1").
+			WithArgs(1).
+			WillReturnResult(sqlmock.NewResult(0, 1))
+		mock.ExpectExec("DELETE FROM user_identities WHERE user_id = \\$1").
+			WithArgs(1).
+			WillReturnResult(sqlmock.NewResult(0, 0))
+		mock.ExpectExec("DELETE FROM user_sessions WHERE user_id = \\$1").
+			WithArgs(1).
+			WillReturnResult(sqlmock.NewResult(0, 3))
+		mock.ExpectExec("UPDATE orders\\s+SET shipping_name = NULL").
+			WithArgs(1).
+			WillReturnResult(sqlmock.NewResult(0, 2))
+		mock.ExpectCommit()
+
+		err := repo.DeleteUser(context.Background(), 1)
+		assert.NoError(t, err)
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("Already deleted", func(t *testing.T) {
+		mock.ExpectBegin()
+		mock.ExpectExec("UPDATE users\\s+SET username = \\$2").
+			WithArgs(2, anonymousUsernameArg{}).
+			WillReturnResult(sqlmock.NewResult(0, 0))
+		mock.ExpectRollback()
+
+		err := repo.DeleteUser(context.Background(), 2)
+		assert.ErrorIs(t, err, domain.ErrNotFound)
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+}
+
+// anonymousUsernameArg matches a random username rather than one derived from the user
+// id, which anyone could have registered before the account was deleted.
+type anonymousUsernameArg struct{}
+
+func (anonymousUsernameArg) Match(v driver.Value) bool {
+	username, ok := v.(string)
+	return ok && regexp.MustCompile(`^deleted-[0-9a-f]{32}$`).MatchString(username)
+}
+
+func TestAnonymousUsername(t *testing.T) {
+	first, err := anonymousUsername()
+	assert.NoError(t, err)
+	second, err := anonymousUsername()
+	assert.NoError(t, err)
+
+	assert.True(t, anonymousUsernameArg{}.Match(first), first)
+	assert.NotEqual(t, first, second)
+}
@@ -0,0 +1,117 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"time"
+	"toptal/internal/app/domain"
+	"toptal/internal/app/repository/model"
+	"toptal/internal/pkg/pg"
+
+	"github.com/jmoiron/sqlx"
+)
+
+const (
+	sqlInvalidateUserTokens = `UPDATE user_tokens SET used_at = now() WHERE user_id = $1 AND purpose = $2 AND used_at IS NULL`
+	sqlInsertUserToken      = `
+		INSERT INTO user_tokens (user_id, purpose, token_hash, email, expires_at)
+		VALUES ($1, $2, $3, $4, $5)
+	`
+	sqlConsumeUserToken = `
+		UPDATE user_tokens
+		SET used_at = now()
+		WHERE token_hash = $1
+			AND purpose = $2
+			AND used_at IS NULL
+			AND expires_at > now()
+		RETURNING user_id, COALESCE(email, '')
+	`
+	sqlUpdateUserPassword = `UPDATE users SET password_hash = $2, updated_at = now() WHERE id = $1`
+	sqlMarkEmailVerified  = `UPDATE users SET email_verified = TRUE, updated_at = now() WHERE id = $1 AND email = $2`
+)
+
+type UserTokenRepository struct {
+	db *pg.DB
+}
+
+func NewUserTokenRepository(db *pg.DB) *UserTokenRepository {
+	return &UserTokenRepository{db}
+}
+
+// CreateUserToken stores a token and queues the email carrying it in one transaction.
+// Earlier unused tokens with the same purpose stop working.
+func (r *UserTokenRepository) CreateUserToken(
+	ctx context.Context, userId int, purpose string, tokenHash string, email string, expiresAt time.Time, message domain.OutboxEmail,
+) error {
+	return r.db.WithTransaction(ctx, func(tx *sqlx.Tx) error {
+		if _, err := tx.ExecContext(ctx, sqlInvalidateUserTokens, userId, purpose); err != nil {
+			return model.WrapDatabaseError(err, "failed to invalidate previous tokens")
+		}
+		if _, err := tx.ExecContext(ctx, sqlInsertUserToken, userId, purpose, tokenHash, toNullString(email), expiresAt); err != nil {
+			return model.WrapDatabaseError(err, "failed to insert user token")
+		}
+		if err := insertOutboxEmail(ctx, tx, message); err != nil {
+			return fmt.Errorf("failed to queue %s email: %w", purpose, err)
+		}
+		return nil
+	})
+}
+
+// ResetPassword consumes a password reset token and stores the new hash. It returns the
+// id of the user whose password changed.
+func (r *UserTokenRepository) ResetPassword(ctx context.Context, tokenHash string, passwordHash string) (int, error) {
+	var userId int
+	err := r.db.WithTransaction(ctx, func(tx *sqlx.Tx) error {
+		var err error
+		userId, _, err = consumeUserToken(ctx, tx, tokenHash, domain.TokenPurposePasswordReset)
+		if err != nil {
+			return err
+		}
+		if _, err := tx.ExecContext(ctx, sqlUpdateUserPassword, userId, passwordHash); err != nil {
+			return model.WrapDatabaseError(err, "failed to update password")
+		}
+		if _, err := tx.ExecContext(ctx, sqlInvalidateUserTokens, userId, domain.TokenPurposePasswordReset); err != nil {
+			return model.WrapDatabaseError(err, "failed to invalidate reset tokens")
+		}
+		return nil
+	})
+	return userId, err
+}
+
+// VerifyEmail consumes a verification token. It fails if the user changed their email
+// after the token was sent.
+func (r *UserTokenRepository) VerifyEmail(ctx context.Context, tokenHash string) error {
+	return r.db.WithTransaction(ctx, func(tx *sqlx.Tx) error {
+		userId, email, err := consumeUserToken(ctx, tx, tokenHash, domain.TokenPurposeEmailVerification)
+		if err != nil {
+			return err
+		}
+		result, err := tx.ExecContext(ctx, sqlMarkEmailVerified, userId, email)
+		if err != nil {
+			return model.WrapDatabaseError(err, "failed to mark email verified")
+		}
+		affected, err := result.RowsAffected()
+		if err != nil {
+			return model.WrapDatabaseError(err, "failed to get affected rows")
+		}
+		if affected == 0 {
+			return domain.ErrInvalidToken
+		}
+		return nil
+	})
+}
+
+func consumeUserToken(ctx context.Context, tx *sqlx.Tx, tokenHash string, purpose string) (int, string, error) {
+	var userId int
+	var email string
+	err := tx.QueryRowxContext(ctx, sqlConsumeUserToken, tokenHash, purpose).Scan(&userId, &email)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return 0, "", domain.ErrInvalidToken
+		}
+		return 0, "", model.WrapDatabaseError(err, "failed to consume user token")
+	}
+	return userId, email, nil
+}
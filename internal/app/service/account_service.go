@@ -0,0 +1,268 @@
+package service
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"log/slog"
+	"net/url"
+	"strings"
+	"time"
+	"toptal/internal/app/config"
+	"toptal/internal/app/domain"
+)
+
+const userTokenSize = 32
+
+type AccountService struct {
+	userRepository      UserRepository
+	userTokenRepository UserTokenRepository
+	orderRepository     OrderRepository
+	cartRepository      CartRepository
+	passwords           PasswordHasher
+	sessions            *SessionService
+	security            *config.SecurityConfig
+	mail                *config.MailConfig
+}
+
+func NewAccountService(
+	userRepository UserRepository,
+	userTokenRepository UserTokenRepository,
+	orderRepository OrderRepository,
+	cartRepository CartRepository,
+	passwords PasswordHasher,
+	sessions *SessionService,
+	security *config.SecurityConfig,
+	mail *config.MailConfig,
+) *AccountService {
+	return &AccountService{
+		userRepository:      userRepository,
+		userTokenRepository: userTokenRepository,
+		orderRepository:     orderRepository,
+		cartRepository:      cartRepository,
+		passwords:           passwords,
+		sessions:            sessions,
+		security:            security,
+		mail:                mail,
+	}
+}
+
+// RequestPasswordReset emails a reset link to the user owning the address. Unknown
+// addresses are ignored so the response does not reveal which accounts exist.
+func (s *AccountService) RequestPasswordReset(ctx context.Context, email string) error {
+	user, err := s.userRepository.FindUserByEmail(ctx, strings.ToLower(strings.TrimSpace(email)))
+	if err != nil {
+		if errors.Is(err, domain.ErrNotFound) {
+			slog.Info("Password reset requested for unknown email")
+			return nil
+		}
+		return err
+	}
+
+	body := "Someone asked to reset the password of your account %q.\n\n" +
+		"Open the link below to choose a new password. It expires in %s.\n\n%s\n\n" +
+		"If you did not ask for this, you can ignore this email."
+	return s.sendToken(ctx, user, domain.TokenPurposePasswordReset, s.security.PasswordResetTTL,
+		"Reset your password", "/password/reset", body)
+}
+
+// ResetPassword sets a new password using a token from RequestPasswordReset and logs the
+// user out everywhere. The username is not known before the token is consumed, so the
+// policy is applied without it.
+func (s *AccountService) ResetPassword(ctx context.Context, token string, password string) error {
+	if err := checkPasswordPolicy(s.security, password, ""); err != nil {
+		return err
+	}
+
+	hash, err := s.passwords.Hash(password)
+	if err != nil {
+		return err
+	}
+
+	userId, err := s.userTokenRepository.ResetPassword(ctx, hashUserToken(token), hash)
+	if err != nil {
+		return err
+	}
+	slog.Info("Password reset", "user_id", userId)
+
+	// Whoever knew the old password may still be logged in.
+	_, err = s.sessions.RevokeUserSessions(ctx, userId)
+	return err
+}
+
+// RequestEmailVerification emails a verification link to the user's current address.
+func (s *AccountService) RequestEmailVerification(ctx context.Context, userId int) error {
+	user, err := s.userRepository.FindUserById(ctx, userId)
+	if err != nil {
+		return err
+	}
+	if user.Email() == "" {
+		return domain.ErrEmailNotSet
+	}
+	if user.EmailVerified() {
+		return domain.ErrEmailVerified
+	}
+
+	body := "Please confirm that this address belongs to the account %q.\n\n" +
+		"The link below expires in %s.\n\n%s"
+	return s.sendToken(ctx, user, domain.TokenPurposeEmailVerification, s.security.EmailVerificationTTL,
+		"Verify your email address", "/email/verify", body)
+}
+
+// VerifyEmail marks the address the token was sent to as verified.
+func (s *AccountService) VerifyEmail(ctx context.Context, token string) error {
+	return s.userTokenRepository.VerifyEmail(ctx, hashUserToken(token))
+}
+
+func (s *AccountService) GetProfile(ctx context.Context, userId int) (domain.User, error) {
+	return s.userRepository.FindUserById(ctx, userId)
+}
+
+// UpdateProfile changes the fields that are not nil. A new email address has to be
+// verified again.
+func (s *AccountService) UpdateProfile(ctx context.Context, userId int, username *string, email *string) (domain.User, error) {
+	user, err := s.userRepository.FindUserById(ctx, userId)
+	if err != nil {
+		return domain.User{}, err
+	}
+
+	if username != nil {
+		if err := user.SetUsername(strings.TrimSpace(*username)); err != nil {
+			return domain.User{}, err
+		}
+	}
+	if email != nil {
+		if err := user.SetEmail(*email); err != nil {
+			return domain.User{}, err
+		}
+	}
+
+	if err := s.userRepository.UpdateUserProfile(ctx, user); err != nil {
+		return domain.User{}, err
+	}
+	return user, nil
+}
+
+// ChangePassword replaces the password after checking the current one. Users without a
+// password, such as those created through OIDC, set their first one after a recent login
+// instead. The user's other sessions are logged out.
+func (s *AccountService) ChangePassword(ctx context.Context, userId int, currentPassword string, newPassword string) error {
+	user, err := s.reauthenticate(ctx, userId, currentPassword)
+	if err != nil {
+		return err
+	}
+	if err := checkPasswordPolicy(s.security, newPassword, user.Username()); err != nil {
+		return err
+	}
+
+	hash, err := s.passwords.Hash(newPassword)
+	if err != nil {
+		return err
+	}
+	if err := s.userRepository.ChangePassword(ctx, userId, hash); err != nil {
+		return err
+	}
+	slog.Info("Password changed", "user_id", userId)
+
+	_, err = s.sessions.RevokeOtherSessions(ctx, userId)
+	return err
+}
+
+// DeleteAccount anonymises the account after checking the password, or a recent login
+// for users without one. Orders are kept but no longer identify the user.
+func (s *AccountService) DeleteAccount(ctx context.Context, userId int, password string) error {
+	if _, err := s.reauthenticate(ctx, userId, password); err != nil {
+		return err
+	}
+	return s.userRepository.DeleteUser(ctx, userId)
+}
+
+// ExportAccount collects the user's profile, cart and orders.
+func (s *AccountService) ExportAccount(ctx context.Context, userId int) (domain.AccountExport, error) {
+	user, err := s.userRepository.FindUserById(ctx, userId)
+	if err != nil {
+		return domain.AccountExport{}, err
+	}
+	cart, err := s.cartRepository.GetCart(ctx, userId)
+	if err != nil {
+		return domain.AccountExport{}, err
+	}
+	orders, err := s.orderRepository.FindOrdersByUser(ctx, userId)
+	if err != nil {
+		return domain.AccountExport{}, err
+	}
+
+	slog.Info("Account exported", "user_id", userId)
+	return domain.NewAccountExport(user, cart, orders, time.Now().UTC()), nil
+}
+
+// reauthenticate confirms a sensitive change with the user's password. Users without a
+// password confirm it by having logged in within the configured maximum age, and are
+// asked to log in again with domain.ErrReauthenticationRequired otherwise.
+func (s *AccountService) reauthenticate(ctx context.Context, userId int, password string) (domain.User, error) {
+	user, err := s.userRepository.FindUserById(ctx, userId)
+	if err != nil {
+		return domain.User{}, err
+	}
+	if !user.HasPassword() {
+		recent, err := s.sessions.recentLogin(ctx, userId, s.security.ReauthenticationMaxAge)
+		if err != nil {
+			return domain.User{}, err
+		}
+		if !recent {
+			return domain.User{}, domain.ErrReauthenticationRequired
+		}
+		return user, nil
+	}
+	ok, err := s.passwords.Verify(user.PasswordHash(), password)
+	if err != nil {
+		slog.Error("failed to verify password hash", "user_id", userId, "error", err)
+	}
+	if !ok {
+		return domain.User{}, domain.ErrWrongPassword
+	}
+	return user, nil
+}
+
+// sendToken stores a new single-use token and queues an email with a link carrying it.
+// body is a format string receiving the username, the lifetime and the link.
+func (s *AccountService) sendToken(
+	ctx context.Context, user domain.User, purpose string, ttl time.Duration, subject string, path string, body string,
+) error {
+	token, hash, err := generateUserToken()
+	if err != nil {
+		return err
+	}
+
+	link := strings.TrimRight(s.mail.BaseURL, "/") + path + "?token=" + url.QueryEscape(token)
+	message, err := domain.NewOutboxEmail(user.Email(), subject, fmt.Sprintf(body, user.Username(), ttl, link))
+	if err != nil {
+		return err
+	}
+
+	expiresAt := time.Now().Add(ttl)
+	if err := s.userTokenRepository.CreateUserToken(ctx, user.Id(), purpose, hash, user.Email(), expiresAt, message); err != nil {
+		return err
+	}
+	slog.Info("User token issued", "user_id", user.Id(), "purpose", purpose)
+	return nil
+}
+
+// generateUserToken returns a random URL-safe token and the hash stored in its place.
+func generateUserToken() (token string, hash string, err error) {
+	raw := make([]byte, userTokenSize)
+	if _, err := rand.Read(raw); err != nil {
+		return "", "", fmt.Errorf("failed to generate token: %w", err)
+	}
+	token = base64.RawURLEncoding.EncodeToString(raw)
+	return token, hashUserToken(token), nil
+}
+
+func hashUserToken(token string) string {
+	sum := sha256.Sum256([]byte(strings.TrimSpace(token)))
+	return hex.EncodeToString(sum[:])
+}
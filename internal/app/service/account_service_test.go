@@ -0,0 +1,287 @@
+package service
+
+import (
+	"context"
+	"strings"
+	"testing"
+	"time"
+	"toptal/internal/app/config"
+	"toptal/internal/app/domain"
+	"toptal/internal/app/util"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+	"golang.org/x/crypto/bcrypt"
+)
+
+type MockUserTokenRepository struct {
+	mock.Mock
+}
+
+func (m *MockUserTokenRepository) CreateUserToken(
+	ctx context.Context, userId int, purpose string, tokenHash string, email string, expiresAt time.Time, message domain.OutboxEmail,
+) error {
+	args := m.Called(ctx, userId, purpose, tokenHash, email, expiresAt, message)
+	return args.Error(0)
+}
+
+func (m *MockUserTokenRepository) ResetPassword(ctx context.Context, tokenHash string, passwordHash string) (int, error) {
+	args := m.Called(ctx, tokenHash, passwordHash)
+	return args.Int(0), args.Error(1)
+}
+
+func (m *MockUserTokenRepository) VerifyEmail(ctx context.Context, tokenHash string) error {
+	args := m.Called(ctx, tokenHash)
+	return args.Error(0)
+}
+
+type MockOrderRepository struct {
+	mock.Mock
+}
+
+func (m *MockOrderRepository) FindOrdersByUser(ctx context.Context, userId int) ([]domain.Order, error) {
+	args := m.Called(ctx, userId)
+	return args.Get(0).([]domain.Order), args.Error(1)
+}
+
+type MockCartRepository struct {
+	mock.Mock
+}
+
+func (m *MockCartRepository) GetCart(ctx context.Context, userId int) ([]domain.Book, error) {
+	args := m.Called(ctx, userId)
+	return args.Get(0).([]domain.Book), args.Error(1)
+}
+
+func (m *MockCartRepository) AddToCart(ctx context.Context, userId int, bookId int, format string) error {
+	return m.Called(ctx, userId, bookId, format).Error(0)
+}
+
+func (m *MockCartRepository) RemoveFromCart(ctx context.Context, userId int, bookId int, format string) error {
+	return m.Called(ctx, userId, bookId, format).Error(0)
+}
+
+func (m *MockCartRepository) Purchase(
+	ctx context.Context, userId int, currency string, address domain.ShippingAddress,
+	taxOrder func([]domain.OrderItem) (domain.OrderTax, error),
+) (domain.Order, error) {
+	args := m.Called(ctx, userId, currency, address)
+	return args.Get(0).(domain.Order), args.Error(1)
+}
+
+func (m *MockCartRepository) CleanExpiredCarts(ctx context.Context) error {
+	return m.Called(ctx).Error(0)
+}
+
+func newAccountTestUser(t *testing.T, email string) domain.User {
+	user, err := domain.NewUser(7, "alice", "hash", false)
+	require.NoError(t, err)
+	require.NoError(t, user.SetEmail(email))
+	return user
+}
+
+func TestAccountService_RequestPasswordReset(t *testing.T) {
+	ctx := context.Background()
+	security := &config.SecurityConfig{PasswordResetTTL: time.Hour}
+	mail := &config.MailConfig{BaseURL: "https://shop.example/"}
+
+	t.Run("Unknown email is ignored", func(t *testing.T) {
+		users := new(MockUserRepository)
+		tokens := new(MockUserTokenRepository)
+		service := NewAccountService(users, tokens, nil, nil, newTestHasher(t), nil, security, mail)
+
+		users.On("FindUserByEmail", ctx, "nobody@example.com").Return(domain.User{}, domain.ErrNotFound).Once()
+
+		assert.NoError(t, service.RequestPasswordReset(ctx, " Nobody@Example.com "))
+		tokens.AssertNotCalled(t, "CreateUserToken")
+	})
+
+	t.Run("Link carries a token whose hash is stored", func(t *testing.T) {
+		users := new(MockUserRepository)
+		tokens := new(MockUserTokenRepository)
+		service := NewAccountService(users, tokens, nil, nil, newTestHasher(t), nil, security, mail)
+
+		users.On("FindUserByEmail", ctx, "alice@example.com").Return(newAccountTestUser(t, "alice@example.com"), nil).Once()
+
+		var storedHash string
+		var message domain.OutboxEmail
+		tokens.On("CreateUserToken", ctx, 7, domain.TokenPurposePasswordReset, mock.Anything, "alice@example.com", mock.Anything, mock.Anything).
+			Run(func(args mock.Arguments) {
+				storedHash = args.String(3)
+				message = args.Get(6).(domain.OutboxEmail)
+			}).Return(nil).Once()
+
+		require.NoError(t, service.RequestPasswordReset(ctx, "alice@example.com"))
+		tokens.AssertExpectations(t)
+
+		assert.Equal(t, "alice@example.com", message.Recipient())
+		_, link, found := strings.Cut(message.Body(), "https://shop.example/password/reset?token=")
+		require.True(t, found)
+		token := strings.Fields(link)[0]
+		assert.Equal(t, hashUserToken(token), storedHash)
+		assert.NotContains(t, storedHash, token)
+	})
+}
+
+func TestAccountService_ResetPassword(t *testing.T) {
+	ctx := context.Background()
+	tokens := new(MockUserTokenRepository)
+	sessions := new(MockSessionRepository)
+	service := NewAccountService(new(MockUserRepository), tokens, nil, nil, newTestHasher(t), NewSessionService(sessions),
+		&config.SecurityConfig{}, &config.MailConfig{})
+
+	tokens.On("ResetPassword", ctx, hashUserToken("good"), mock.Anything).Return(7, nil).Once()
+	tokens.On("ResetPassword", ctx, hashUserToken("bad"), mock.Anything).Return(0, domain.ErrInvalidToken).Once()
+	sessions.On("RevokeUserSessions", ctx, 7, 0, mock.Anything).Return(2, nil).Once()
+
+	assert.NoError(t, service.ResetPassword(ctx, "good", "new-password"))
+	assert.ErrorIs(t, service.ResetPassword(ctx, "bad", "new-password"), domain.ErrInvalidToken)
+	sessions.AssertExpectations(t)
+}
+
+func TestAccountService_RequestEmailVerification(t *testing.T) {
+	ctx := context.Background()
+	users := new(MockUserRepository)
+	tokens := new(MockUserTokenRepository)
+	service := NewAccountService(users, tokens, nil, nil, newTestHasher(t), nil, &config.SecurityConfig{EmailVerificationTTL: time.Hour}, &config.MailConfig{})
+
+	noEmail, err := domain.NewUser(8, "bob", "hash", false)
+	require.NoError(t, err)
+	verified := newAccountTestUser(t, "carol@example.com")
+	require.NoError(t, verified.SetEmailVerified(true))
+
+	users.On("FindUserById", ctx, 8).Return(noEmail, nil).Once()
+	users.On("FindUserById", ctx, 9).Return(verified, nil).Once()
+	users.On("FindUserById", ctx, 7).Return(newAccountTestUser(t, "alice@example.com"), nil).Once()
+	tokens.On("CreateUserToken", ctx, 7, domain.TokenPurposeEmailVerification, mock.Anything, "alice@example.com", mock.Anything, mock.Anything).
+		Return(nil).Once()
+
+	assert.ErrorIs(t, service.RequestEmailVerification(ctx, 8), domain.ErrEmailNotSet)
+	assert.ErrorIs(t, service.RequestEmailVerification(ctx, 9), domain.ErrEmailVerified)
+	assert.NoError(t, service.RequestEmailVerification(ctx, 7))
+	tokens.AssertExpectations(t)
+}
+
+func TestAccountService_ChangePassword(t *testing.T) {
+	ctx := util.WithSessionID(context.Background(), 3)
+	hash, err := HashPassword("old-password1")
+	require.NoError(t, err)
+	user, err := domain.NewUser(7, "alice", string(hash), false)
+	require.NoError(t, err)
+
+	users := new(MockUserRepository)
+	sessions := new(MockSessionRepository)
+	service := NewAccountService(users, nil, nil, nil, newTestHasher(t), NewSessionService(sessions),
+		&config.SecurityConfig{PasswordMinLength: 8}, &config.MailConfig{})
+	users.On("FindUserById", ctx, 7).Return(user, nil)
+
+	assert.ErrorIs(t, service.ChangePassword(ctx, 7, "wrong", "new-password1"), domain.ErrWrongPassword)
+	assert.ErrorIs(t, service.ChangePassword(ctx, 7, "old-password1", "short"), domain.ErrWeakPassword)
+	sessions.AssertNotCalled(t, "RevokeUserSessions", mock.Anything, mock.Anything, mock.Anything, mock.Anything)
+
+	users.On("ChangePassword", ctx, 7, mock.MatchedBy(func(hash string) bool {
+		return bcrypt.CompareHashAndPassword([]byte(hash), []byte("new-password1")) == nil
+	})).Return(nil).Once()
+	sessions.On("RevokeUserSessions", ctx, 7, 3, mock.Anything).Return(1, nil).Once()
+	assert.NoError(t, service.ChangePassword(ctx, 7, "old-password1", "new-password1"))
+	users.AssertExpectations(t)
+	sessions.AssertExpectations(t)
+}
+
+func TestAccountService_DeleteAccount(t *testing.T) {
+	ctx := context.Background()
+	hash, err := HashPassword("password1")
+	require.NoError(t, err)
+	user, err := domain.NewUser(7, "alice", string(hash), false)
+	require.NoError(t, err)
+
+	users := new(MockUserRepository)
+	service := NewAccountService(users, nil, nil, nil, newTestHasher(t), nil, &config.SecurityConfig{}, &config.MailConfig{})
+	users.On("FindUserById", ctx, 7).Return(user, nil)
+
+	assert.ErrorIs(t, service.DeleteAccount(ctx, 7, "wrong"), domain.ErrWrongPassword)
+	users.AssertNotCalled(t, "DeleteUser", ctx, 7)
+
+	users.On("DeleteUser", ctx, 7).Return(nil).Once()
+	assert.NoError(t, service.DeleteAccount(ctx, 7, "password1"))
+	users.AssertExpectations(t)
+}
+
+func TestAccountService_DeleteAccountWithoutPassword(t *testing.T) {
+	user, err := domain.NewUser(7, "alice", domain.UnusablePasswordHash, false)
+	require.NoError(t, err)
+
+	recent := newTestSession(t, 1, 7, time.Now().Add(time.Hour))
+	require.NoError(t, recent.SetCreatedAt(time.Now().Add(-time.Minute)))
+	old := newTestSession(t, 2, 7, time.Now().Add(time.Hour))
+	require.NoError(t, old.SetCreatedAt(time.Now().Add(-time.Hour)))
+
+	users := new(MockUserRepository)
+	sessions := new(MockSessionRepository)
+	service := NewAccountService(users, nil, nil, nil, newTestHasher(t), NewSessionService(sessions),
+		&config.SecurityConfig{ReauthenticationMaxAge: 10 * time.Minute}, &config.MailConfig{})
+	users.On("FindUserById", mock.Anything, 7).Return(user, nil)
+	sessions.On("FindSessionById", mock.Anything, 1).Return(recent, nil)
+	sessions.On("FindSessionById", mock.Anything, 2).Return(old, nil)
+
+	err = service.DeleteAccount(context.Background(), 7, "")
+	assert.ErrorIs(t, err, domain.ErrReauthenticationRequired, "no session")
+	err = service.DeleteAccount(util.WithSessionID(context.Background(), 2), 7, "")
+	assert.ErrorIs(t, err, domain.ErrReauthenticationRequired, "login too old")
+	users.AssertNotCalled(t, "DeleteUser", mock.Anything, 7)
+
+	ctx := util.WithSessionID(context.Background(), 1)
+	users.On("DeleteUser", ctx, 7).Return(nil).Once()
+	assert.NoError(t, service.DeleteAccount(ctx, 7, ""))
+	users.AssertExpectations(t)
+}
+
+func TestAccountService_ChangePasswordSetsFirstPassword(t *testing.T) {
+	user, err := domain.NewUser(7, "alice", domain.UnusablePasswordHash, false)
+	require.NoError(t, err)
+	recent := newTestSession(t, 1, 7, time.Now().Add(time.Hour))
+	require.NoError(t, recent.SetCreatedAt(time.Now()))
+
+	users := new(MockUserRepository)
+	sessions := new(MockSessionRepository)
+	service := NewAccountService(users, nil, nil, nil, newTestHasher(t), NewSessionService(sessions),
+		&config.SecurityConfig{ReauthenticationMaxAge: 10 * time.Minute}, &config.MailConfig{})
+	ctx := util.WithSessionID(context.Background(), 1)
+	users.On("FindUserById", ctx, 7).Return(user, nil)
+	sessions.On("FindSessionById", ctx, 1).Return(recent, nil)
+	users.On("ChangePassword", ctx, 7, mock.MatchedBy(func(hash string) bool {
+		return bcrypt.CompareHashAndPassword([]byte(hash), []byte("new-password1")) == nil
+	})).Return(nil).Once()
+	sessions.On("RevokeUserSessions", ctx, 7, 1, mock.Anything).Return(0, nil).Once()
+
+	assert.NoError(t, service.ChangePassword(ctx, 7, "", "new-password1"))
+	users.AssertExpectations(t)
+}
+
+func TestAccountService_ExportAccount(t *testing.T) {
+	ctx := context.Background()
+	users := new(MockUserRepository)
+	orders := new(MockOrderRepository)
+	carts := new(MockCartRepository)
+	service := NewAccountService(users, nil, orders, carts, newTestHasher(t), nil, &config.SecurityConfig{}, &config.MailConfig{})
+
+	price, err := domain.NewMoney(500, "USD")
+	require.NoError(t, err)
+	item, err := domain.NewOrderItem(0, "Deleted Book", "Author", price)
+	require.NoError(t, err)
+	order, err := domain.NewOrder(3, 7, price, time.Now(), []domain.OrderItem{item})
+	require.NoError(t, err)
+
+	users.On("FindUserById", ctx, 7).Return(newAccountTestUser(t, "alice@example.com"), nil).Once()
+	carts.On("GetCart", ctx, 7).Return([]domain.Book{}, nil).Once()
+	orders.On("FindOrdersByUser", ctx, 7).Return([]domain.Order{order}, nil).Once()
+
+	export, err := service.ExportAccount(ctx, 7)
+	require.NoError(t, err)
+	exportedUser := export.User()
+	assert.Equal(t, "alice@example.com", exportedUser.Email())
+	require.Len(t, export.Orders(), 1)
+	assert.Empty(t, export.Cart())
+	assert.False(t, export.ExportedAt().IsZero())
+}
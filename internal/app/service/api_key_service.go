@@ -0,0 +1,107 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"time"
+	"toptal/internal/app/auth"
+	"toptal/internal/app/config"
+	"toptal/internal/app/domain"
+)
+
+type APIKeyService struct {
+	apiKeyRepository APIKeyRepository
+	config           *config.SecurityConfig
+}
+
+func NewAPIKeyService(repository APIKeyRepository, cfg *config.SecurityConfig) *APIKeyService {
+	return &APIKeyService{apiKeyRepository: repository, config: cfg}
+}
+
+// CreateAPIKey stores a new key and returns it with the raw secret, which is only available at this point.
+func (s *APIKeyService) CreateAPIKey(ctx context.Context, key domain.APIKey) (domain.APIKey, string, error) {
+	if !key.ExpiresAt().IsZero() && !key.ExpiresAt().After(time.Now()) {
+		return domain.APIKey{}, "", fmt.Errorf("%w: expiry must be in the future", domain.ErrInvalidAPIKey)
+	}
+
+	rawKey, prefix, keyHash, err := auth.GenerateAPIKey()
+	if err != nil {
+		return domain.APIKey{}, "", err
+	}
+	if err := key.SetCredentials(prefix, keyHash); err != nil {
+		return domain.APIKey{}, "", err
+	}
+
+	created, err := s.apiKeyRepository.InsertAPIKey(ctx, key, auditActor(ctx))
+	if err != nil {
+		return domain.APIKey{}, "", err
+	}
+	slog.Info("API key created", "api_key_id", created.Id(), "created_by", created.CreatedBy())
+	return created, rawKey, nil
+}
+
+func (s *APIKeyService) GetAPIKeys(ctx context.Context) ([]domain.APIKey, error) {
+	return s.apiKeyRepository.FindAPIKeys(ctx)
+}
+
+func (s *APIKeyService) RevokeAPIKey(ctx context.Context, id int) error {
+	return s.apiKeyRepository.RevokeAPIKey(ctx, id, auditActor(ctx))
+}
+
+// RotateAPIKey issues a new secret with the same name, scopes and expiry. The old key
+// keeps working for the configured grace period.
+func (s *APIKeyService) RotateAPIKey(ctx context.Context, id int, rotatedBy int) (domain.APIKey, string, error) {
+	old, err := s.apiKeyRepository.FindAPIKeyById(ctx, id)
+	if err != nil {
+		return domain.APIKey{}, "", err
+	}
+	if !old.Active(time.Now()) {
+		return domain.APIKey{}, "", fmt.Errorf("%w: key is revoked or expired", domain.ErrInvalidAPIKey)
+	}
+
+	replacement, err := domain.NewAPIKey(old.Name(), old.Scopes(), old.ExpiresAt(), rotatedBy)
+	if err != nil {
+		return domain.APIKey{}, "", err
+	}
+	rawKey, prefix, keyHash, err := auth.GenerateAPIKey()
+	if err != nil {
+		return domain.APIKey{}, "", err
+	}
+	if err := replacement.SetCredentials(prefix, keyHash); err != nil {
+		return domain.APIKey{}, "", err
+	}
+
+	graceUntil := time.Now().Add(s.config.APIKeyRotationGrace)
+	created, err := s.apiKeyRepository.RotateAPIKey(ctx, id, replacement, graceUntil, auditActor(ctx))
+	if err != nil {
+		return domain.APIKey{}, "", err
+	}
+	slog.Info("API key rotated", "old_api_key_id", id, "api_key_id", created.Id())
+	return created, rawKey, nil
+}
+
+// Authenticate resolves a raw key presented by a client and checks that it is still active.
+func (s *APIKeyService) Authenticate(ctx context.Context, rawKey string) (domain.APIKey, error) {
+	prefix, ok := auth.ParseAPIKeyPrefix(rawKey)
+	if !ok {
+		return domain.APIKey{}, domain.ErrInvalidAPIKey
+	}
+
+	key, err := s.apiKeyRepository.FindAPIKeyByPrefix(ctx, prefix)
+	if err != nil {
+		if errors.Is(err, domain.ErrNotFound) {
+			return domain.APIKey{}, domain.ErrInvalidAPIKey
+		}
+		return domain.APIKey{}, err
+	}
+	if !auth.CompareAPIKey(rawKey, key.KeyHash()) || !key.Active(time.Now()) {
+		return domain.APIKey{}, domain.ErrInvalidAPIKey
+	}
+
+	if err := s.apiKeyRepository.TouchAPIKey(ctx, key.Id()); err != nil {
+		slog.Error("failed to record api key usage", "api_key_id", key.Id(), "error", err)
+	}
+	return key, nil
+}
@@ -0,0 +1,131 @@
+package service
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+
+	"toptal/internal/app/auth"
+	"toptal/internal/app/config"
+	"toptal/internal/app/domain"
+)
+
+type MockAPIKeyRepository struct {
+	mock.Mock
+}
+
+func (m *MockAPIKeyRepository) InsertAPIKey(ctx context.Context, key domain.APIKey, actor domain.AuditActor) (domain.APIKey, error) {
+	args := m.Called(ctx, key, actor)
+	return args.Get(0).(domain.APIKey), args.Error(1)
+}
+
+func (m *MockAPIKeyRepository) FindAPIKeyById(ctx context.Context, id int) (domain.APIKey, error) {
+	args := m.Called(ctx, id)
+	return args.Get(0).(domain.APIKey), args.Error(1)
+}
+
+func (m *MockAPIKeyRepository) FindAPIKeyByPrefix(ctx context.Context, prefix string) (domain.APIKey, error) {
+	args := m.Called(ctx, prefix)
+	return args.Get(0).(domain.APIKey), args.Error(1)
+}
+
+func (m *MockAPIKeyRepository) FindAPIKeys(ctx context.Context) ([]domain.APIKey, error) {
+	args := m.Called(ctx)
+	return args.Get(0).([]domain.APIKey), args.Error(1)
+}
+
+func (m *MockAPIKeyRepository) RevokeAPIKey(ctx context.Context, id int, actor domain.AuditActor) error {
+	args := m.Called(ctx, id, actor)
+	return args.Error(0)
+}
+
+func (m *MockAPIKeyRepository) TouchAPIKey(ctx context.Context, id int) error {
+	args := m.Called(ctx, id)
+	return args.Error(0)
+}
+
+func (m *MockAPIKeyRepository) RotateAPIKey(
+	ctx context.Context, id int, replacement domain.APIKey, graceUntil time.Time, actor domain.AuditActor,
+) (domain.APIKey, error) {
+	args := m.Called(ctx, id, replacement, graceUntil, actor)
+	return args.Get(0).(domain.APIKey), args.Error(1)
+}
+
+func newStoredAPIKey(t *testing.T, prefix, keyHash string) domain.APIKey {
+	key, err := domain.NewAPIKey("warehouse", []string{domain.ScopeCatalogWrite}, time.Time{}, 1)
+	require.NoError(t, err)
+	require.NoError(t, key.SetId(7))
+	require.NoError(t, key.SetCredentials(prefix, keyHash))
+	return key
+}
+
+func TestAPIKeyService_Authenticate(t *testing.T) {
+	ctx := context.Background()
+	cfg := &config.SecurityConfig{APIKeyRotationGrace: time.Hour}
+
+	rawKey, prefix, keyHash, err := auth.GenerateAPIKey()
+	require.NoError(t, err)
+
+	t.Run("Valid key", func(t *testing.T) {
+		mockRepo := new(MockAPIKeyRepository)
+		service := NewAPIKeyService(mockRepo, cfg)
+
+		mockRepo.On("FindAPIKeyByPrefix", ctx, prefix).Return(newStoredAPIKey(t, prefix, keyHash), nil)
+		mockRepo.On("TouchAPIKey", ctx, 7).Return(nil)
+
+		key, err := service.Authenticate(ctx, rawKey)
+		assert.NoError(t, err)
+		assert.Equal(t, 7, key.Id())
+		assert.True(t, key.HasScope(domain.ScopeCatalogWrite))
+
+		mockRepo.AssertExpectations(t)
+	})
+
+	t.Run("Wrong secret", func(t *testing.T) {
+		mockRepo := new(MockAPIKeyRepository)
+		service := NewAPIKeyService(mockRepo, cfg)
+
+		mockRepo.On("FindAPIKeyByPrefix", ctx, prefix).Return(newStoredAPIKey(t, prefix, keyHash), nil)
+
+		_, err := service.Authenticate(ctx, "bsk_"+prefix+"_forged")
+		assert.ErrorIs(t, err, domain.ErrInvalidAPIKey)
+		mockRepo.AssertNotCalled(t, "TouchAPIKey", mock.Anything, mock.Anything)
+	})
+
+	t.Run("Revoked key", func(t *testing.T) {
+		mockRepo := new(MockAPIKeyRepository)
+		service := NewAPIKeyService(mockRepo, cfg)
+
+		key := newStoredAPIKey(t, prefix, keyHash)
+		require.NoError(t, key.SetRevokedAt(time.Now().Add(-time.Minute)))
+		mockRepo.On("FindAPIKeyByPrefix", ctx, prefix).Return(key, nil)
+
+		_, err := service.Authenticate(ctx, rawKey)
+		assert.ErrorIs(t, err, domain.ErrInvalidAPIKey)
+	})
+
+	t.Run("Expired key", func(t *testing.T) {
+		mockRepo := new(MockAPIKeyRepository)
+		service := NewAPIKeyService(mockRepo, cfg)
+
+		key := newStoredAPIKey(t, prefix, keyHash)
+		require.NoError(t, key.SetExpiresAt(time.Now().Add(-time.Minute)))
+		mockRepo.On("FindAPIKeyByPrefix", ctx, prefix).Return(key, nil)
+
+		_, err := service.Authenticate(ctx, rawKey)
+		assert.ErrorIs(t, err, domain.ErrInvalidAPIKey)
+	})
+
+	t.Run("Malformed key", func(t *testing.T) {
+		mockRepo := new(MockAPIKeyRepository)
+		service := NewAPIKeyService(mockRepo, cfg)
+
+		_, err := service.Authenticate(ctx, "not-a-key")
+		assert.ErrorIs(t, err, domain.ErrInvalidAPIKey)
+		mockRepo.AssertNotCalled(t, "FindAPIKeyByPrefix", mock.Anything, mock.Anything)
+	})
+}
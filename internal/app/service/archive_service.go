@@ -0,0 +1,92 @@
+package service
+
+import (
+	"context"
+	"log/slog"
+	"time"
+	"toptal/internal/app/config"
+	"toptal/internal/app/domain"
+	"toptal/internal/pkg/blob"
+)
+
+// ArchiveService deletes archived books and categories for good once the retention
+// period has passed.
+type ArchiveService struct {
+	bookRepository     BookRepository
+	categoryRepository CategoryRepository
+	blobStore          blob.Store
+	config             *config.CatalogConfig
+}
+
+func NewArchiveService(
+	bookRepository BookRepository, categoryRepository CategoryRepository, blobStore blob.Store, cfg *config.CatalogConfig,
+) *ArchiveService {
+	return &ArchiveService{
+		bookRepository:     bookRepository,
+		categoryRepository: categoryRepository,
+		blobStore:          blobStore,
+		config:             cfg,
+	}
+}
+
+// PurgeArchived removes books archived before the cutoff, then the categories left
+// without books. Books that were sold or restocked are kept for the history that refers
+// to them.
+func (s *ArchiveService) PurgeArchived(ctx context.Context) error {
+	archivedBefore := time.Now().Add(-s.config.PurgeAfter)
+	books, err := s.bookRepository.PurgeArchived(ctx, archivedBefore)
+	if err != nil {
+		return err
+	}
+	for _, book := range books {
+		s.deleteFiles(ctx, book)
+	}
+	categories, err := s.categoryRepository.PurgeArchived(ctx, archivedBefore)
+	if err != nil {
+		return err
+	}
+	if len(books) > 0 || categories > 0 {
+		slog.Info("Purged archived catalogue entries", "books", len(books), "categories", categories)
+	}
+	return nil
+}
+
+// deleteFiles removes the cover and format files of a purged book. The book was never
+// sold, so no download refers to its files. A failure only leaves orphaned files.
+func (s *ArchiveService) deleteFiles(ctx context.Context, purged domain.PurgedBook) {
+	keys := purged.FileKeys()
+	book := purged.Book()
+	if cover, ok := book.Cover(); ok {
+		for _, rendition := range domain.CoverRenditions {
+			keys = append(keys, cover.RenditionKey(rendition))
+		}
+	}
+	for _, key := range keys {
+		if err := s.blobStore.Delete(ctx, key); err != nil {
+			slog.Error("failed to delete file of purged book", "book_id", book.Id(), "key", key, "error", err)
+		}
+	}
+}
+
+func (s *ArchiveService) StartArchivePurgeJob(ctx context.Context) {
+	if s.config.PurgeAfter <= 0 {
+		slog.Info("Archive purge job disabled")
+		return
+	}
+	ticker := time.NewTicker(s.config.PurgeInterval)
+	go func() {
+		defer ticker.Stop()
+		for {
+			select {
+			case <-ticker.C:
+				if err := s.PurgeArchived(ctx); err != nil {
+					slog.Error("failed to purge archived catalogue entries", "error", err)
+				}
+			case <-ctx.Done():
+				return
+			}
+		}
+	}()
+	slog.Info("Archive purge job started", "interval minutes", s.config.PurgeInterval.Minutes(),
+		"purge after hours", s.config.PurgeAfter.Hours())
+}
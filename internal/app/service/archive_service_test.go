@@ -0,0 +1,54 @@
+package service
+
+import (
+	"context"
+	"strings"
+	"testing"
+	"time"
+	"toptal/internal/app/config"
+	"toptal/internal/app/domain"
+	"toptal/internal/pkg/blob"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+func TestArchiveService_PurgeArchivedDeletesFiles(t *testing.T) {
+	store, err := blob.NewFileStore(t.TempDir())
+	require.NoError(t, err)
+	ctx := context.Background()
+
+	cover, err := domain.NewCover("covers/1/abc", "image/png", time.Now())
+	require.NoError(t, err)
+	keys := []string{"formats/1/epub"}
+	for _, rendition := range domain.CoverRenditions {
+		keys = append(keys, cover.RenditionKey(rendition))
+	}
+	for _, key := range keys {
+		_, err := store.Put(ctx, key, strings.NewReader("blob"))
+		require.NoError(t, err)
+	}
+
+	price, err := domain.ParseMoney("9.99", "USD")
+	require.NoError(t, err)
+	book, err := domain.NewBook(1, "Dune", 1965, "Frank Herbert", price, 0, 2)
+	require.NoError(t, err)
+	require.NoError(t, book.SetCover(cover))
+
+	books := &MockBookRepository{}
+	books.On("PurgeArchived", ctx, mock.Anything).
+		Return([]domain.PurgedBook{domain.NewPurgedBook(book, []string{"formats/1/epub"})}, nil)
+	categories := &MockCategoryRepository{}
+	categories.On("PurgeArchived", ctx, mock.Anything).Return(0, nil)
+
+	service := NewArchiveService(books, categories, store, &config.CatalogConfig{PurgeAfter: time.Hour})
+	require.NoError(t, service.PurgeArchived(ctx))
+
+	for _, key := range keys {
+		_, err := store.Open(ctx, key)
+		assert.ErrorIs(t, err, blob.ErrNotFound, key)
+	}
+	books.AssertExpectations(t)
+	categories.AssertExpectations(t)
+}
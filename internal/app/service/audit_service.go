@@ -0,0 +1,41 @@
+package service
+
+import (
+	"context"
+	"toptal/internal/app/domain"
+	"toptal/internal/app/util"
+)
+
+const (
+	defaultAuditLimit = 50
+	maxAuditLimit     = 500
+)
+
+type AuditService struct {
+	auditRepository AuditRepository
+}
+
+func NewAuditService(repository AuditRepository) *AuditService {
+	return &AuditService{auditRepository: repository}
+}
+
+// GetAuditLog returns matching audit entries, newest first, at most maxAuditLimit at a time.
+func (s *AuditService) GetAuditLog(ctx context.Context, filter domain.AuditFilter) ([]domain.AuditEntry, error) {
+	if filter.Limit <= 0 {
+		filter.Limit = defaultAuditLimit
+	}
+	filter.Limit = min(filter.Limit, maxAuditLimit)
+	filter.Offset = max(filter.Offset, 0)
+	if !filter.From.IsZero() && !filter.To.IsZero() && !filter.From.Before(filter.To) {
+		return []domain.AuditEntry{}, nil
+	}
+	return s.auditRepository.FindAuditEntries(ctx, filter)
+}
+
+// auditActor describes who is making the request in ctx for the audit log. Requests
+// authenticated with an API key carry the key, JWT requests the user.
+func auditActor(ctx context.Context) domain.AuditActor {
+	userId, _ := util.GetUserID(ctx)
+	apiKeyId, _ := util.GetAPIKeyID(ctx)
+	return domain.NewAuditActor(userId, apiKeyId, util.GetRequestID(ctx), util.GetClientInfo(ctx).IPAddress)
+}
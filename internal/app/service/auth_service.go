@@ -2,52 +2,207 @@ package service
 
 import (
 	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base32"
+	"encoding/hex"
 	"errors"
 	"fmt"
-	"golang.org/x/crypto/bcrypt"
+	"log/slog"
+	"strings"
+	"time"
 	"toptal/internal/app/auth"
+	"toptal/internal/app/config"
 	"toptal/internal/app/domain"
+	"toptal/internal/pkg/password"
+	"toptal/internal/pkg/totp"
+)
+
+const (
+	recoveryCodeCount = 10
+	// totpSkew accepts codes from one step before and after the current one.
+	totpSkew = 1
+	// twoFactorMaxAttempts is how many codes can be tried with one challenge token before
+	// the user has to log in again.
+	twoFactorMaxAttempts = 5
 )
 
 type AuthService struct {
 	userRepository UserRepository
+	passwords      PasswordHasher
+	sessions       *SessionService
+	config         *config.SecurityConfig
 }
 
-func NewAuthService(repository UserRepository) *AuthService {
-	return &AuthService{repository}
+func NewAuthService(
+	repository UserRepository, passwords PasswordHasher, sessions *SessionService, cfg *config.SecurityConfig,
+) *AuthService {
+	return &AuthService{userRepository: repository, passwords: passwords, sessions: sessions, config: cfg}
 }
 
-func (s *AuthService) Login(ctx context.Context, username string, password string) (string, error) {
+// Login checks the password. Users with two-factor authentication receive a challenge
+// token to pass to VerifyTwoFactor instead of an access token.
+func (s *AuthService) Login(ctx context.Context, username string, password string) (domain.LoginResult, error) {
 	user, err := s.userRepository.FindUserByName(ctx, username)
+	if err != nil {
+		return domain.LoginResult{}, err
+	}
+
+	if !s.verifyPassword(user, password) {
+		return domain.LoginResult{}, errors.New("invalid password")
+	}
+	s.upgradePasswordHash(ctx, user, password)
+
+	return s.sessions.loginResult(ctx, user)
+}
+
+// VerifyTwoFactor completes a two-factor login with either a TOTP code or an unused recovery code.
+// A challenge token completes one login and is refused after twoFactorMaxAttempts codes.
+func (s *AuthService) VerifyTwoFactor(ctx context.Context, challengeToken string, code string) (string, error) {
+	claims, err := auth.ParseChallengeToken(challengeToken)
+	if err != nil {
+		return "", domain.ErrUnauthorized
+	}
+	open, err := s.sessions.attemptChallenge(ctx, claims.ChallengeID, claims.UserID)
 	if err != nil {
 		return "", err
 	}
+	if !open {
+		return "", domain.ErrUnauthorized
+	}
+
+	user, err := s.userRepository.FindUserById(ctx, claims.UserID)
+	if err != nil {
+		return "", err
+	}
+	if !user.TOTPEnabled() {
+		return "", domain.ErrTwoFactorNotEnabled
+	}
+
+	if err := s.checkTwoFactorCode(ctx, user, code); err != nil {
+		return "", err
+	}
+	completed, err := s.sessions.completeChallenge(ctx, claims.ChallengeID)
+	if err != nil {
+		return "", err
+	}
+	if !completed {
+		return "", domain.ErrUnauthorized
+	}
+
+	return s.sessions.StartSession(ctx, user)
+}
+
+// EnrollTOTP starts enrollment by generating a new secret. Two-factor authentication is
+// only switched on once ConfirmTOTP receives a code generated from it.
+func (s *AuthService) EnrollTOTP(ctx context.Context, userId int) (secret string, provisioningURI string, err error) {
+	user, err := s.userRepository.FindUserById(ctx, userId)
+	if err != nil {
+		return "", "", err
+	}
+	if user.TOTPEnabled() {
+		return "", "", domain.ErrTwoFactorAlreadyEnabled
+	}
+
+	secret, err = totp.GenerateSecret()
+	if err != nil {
+		return "", "", err
+	}
+	if err := s.userRepository.SetTOTPSecret(ctx, userId, secret); err != nil {
+		return "", "", err
+	}
+
+	return secret, totp.ProvisioningURI(s.config.TOTPIssuer, user.Username(), secret), nil
+}
+
+// ConfirmTOTP enables two-factor authentication and returns one-time recovery codes.
+// The codes are stored hashed and cannot be shown again.
+func (s *AuthService) ConfirmTOTP(ctx context.Context, userId int, code string) ([]string, error) {
+	user, err := s.userRepository.FindUserById(ctx, userId)
+	if err != nil {
+		return nil, err
+	}
+	if user.TOTPEnabled() {
+		return nil, domain.ErrTwoFactorAlreadyEnabled
+	}
+	if user.TOTPSecret() == "" {
+		return nil, domain.ErrTwoFactorNotEnabled
+	}
+
+	step, ok := totp.Validate(user.TOTPSecret(), code, time.Now(), totpSkew)
+	if !ok {
+		return nil, domain.ErrInvalidTwoFactorCode
+	}
+	fresh, err := s.userRepository.ConsumeTOTPStep(ctx, userId, step)
+	if err != nil {
+		return nil, err
+	}
+	if !fresh {
+		return nil, domain.ErrInvalidTwoFactorCode
+	}
 
-	if err := bcrypt.CompareHashAndPassword([]byte(user.PasswordHash()), []byte(password)); err != nil {
-		return "", errors.New("invalid password")
+	codes, hashes, err := generateRecoveryCodes(recoveryCodeCount)
+	if err != nil {
+		return nil, err
+	}
+	if err := s.userRepository.EnableTOTP(ctx, userId, hashes); err != nil {
+		return nil, err
 	}
 
-	token, err := auth.GenerateToken(&user)
+	slog.Info("Two-factor authentication enabled", "user_id", userId)
+	return codes, nil
+}
+
+// DisableTOTP turns two-factor authentication off after checking a current code, and logs
+// the user's other sessions out.
+func (s *AuthService) DisableTOTP(ctx context.Context, userId int, code string) error {
+	user, err := s.userRepository.FindUserById(ctx, userId)
 	if err != nil {
-		return "", errors.New("failed to generate token")
+		return err
+	}
+	if !user.TOTPEnabled() {
+		return domain.ErrTwoFactorNotEnabled
+	}
+	if err := s.checkTwoFactorCode(ctx, user, code); err != nil {
+		return err
+	}
+
+	if err := s.userRepository.DisableTOTP(ctx, userId); err != nil {
+		return err
 	}
+	slog.Info("Two-factor authentication disabled", "user_id", userId)
 
-	return token, nil
+	_, err = s.sessions.RevokeOtherSessions(ctx, userId)
+	return err
 }
 
-func (s *AuthService) Register(ctx context.Context, username string, password string) error {
-	hash, err := bcrypt.GenerateFromPassword([]byte(password), bcrypt.DefaultCost)
+// RequiresTwoFactorEnrollment reports whether policy denies admin access to the user
+// until two-factor authentication is enabled.
+func (s *AuthService) RequiresTwoFactorEnrollment(user domain.User) bool {
+	return s.config.AdminTwoFactorRequired && !user.TOTPEnabled()
+}
+
+// Register creates a user. The email is optional and starts out unverified.
+func (s *AuthService) Register(ctx context.Context, username string, password string, email string) error {
+	if err := checkPasswordPolicy(s.config, password, username); err != nil {
+		return err
+	}
+
+	hash, err := s.passwords.Hash(password)
 	if err != nil {
-		return fmt.Errorf("failed to hash password: %w", err)
+		return err
 	}
 
 	var user domain.User
 	if err = user.SetUsername(username); err != nil {
 		return fmt.Errorf("failed to set username: %w", err)
 	}
-	if err = user.SetPasswordHash(string(hash)); err != nil {
+	if err = user.SetPasswordHash(hash); err != nil {
 		return fmt.Errorf("failed to set password hash: %w", err)
 	}
+	if err = user.SetEmail(email); err != nil {
+		return fmt.Errorf("failed to set email: %w", err)
+	}
 
 	return s.userRepository.CreateUser(ctx, user)
 }
@@ -55,3 +210,92 @@ func (s *AuthService) Register(ctx context.Context, username string, password st
 func (s *AuthService) GetUserById(ctx context.Context, id int) (domain.User, error) {
 	return s.userRepository.FindUserById(ctx, id)
 }
+
+func (s *AuthService) verifyPassword(user domain.User, password string) bool {
+	if !user.HasPassword() {
+		return false
+	}
+	ok, err := s.passwords.Verify(user.PasswordHash(), password)
+	if err != nil {
+		slog.Error("failed to verify password hash", "user_id", user.Id(), "error", err)
+	}
+	return ok
+}
+
+// upgradePasswordHash rehashes the password when the stored hash predates the current
+// hashing policy. Failures are only logged because the login itself succeeded.
+func (s *AuthService) upgradePasswordHash(ctx context.Context, user domain.User, password string) {
+	if !s.passwords.NeedsRehash(user.PasswordHash()) {
+		return
+	}
+	hash, err := s.passwords.Hash(password)
+	if err == nil {
+		err = s.userRepository.UpgradePasswordHash(ctx, user.Id(), user.PasswordHash(), hash)
+	}
+	if err != nil {
+		slog.Error("failed to upgrade password hash", "user_id", user.Id(), "error", err)
+		return
+	}
+	slog.Info("Password hash upgraded", "user_id", user.Id())
+}
+
+func (s *AuthService) checkTwoFactorCode(ctx context.Context, user domain.User, code string) error {
+	if step, ok := totp.Validate(user.TOTPSecret(), code, time.Now(), totpSkew); ok {
+		fresh, err := s.userRepository.ConsumeTOTPStep(ctx, user.Id(), step)
+		if err != nil {
+			return err
+		}
+		if !fresh {
+			return domain.ErrInvalidTwoFactorCode
+		}
+		return nil
+	}
+
+	used, err := s.userRepository.ConsumeRecoveryCode(ctx, user.Id(), hashRecoveryCode(code))
+	if err != nil {
+		return err
+	}
+	if !used {
+		return domain.ErrInvalidTwoFactorCode
+	}
+	slog.Info("Recovery code used", "user_id", user.Id())
+	return nil
+}
+
+// generateRecoveryCodes returns codes formatted as xxxxx-xxxxx together with their hashes.
+func generateRecoveryCodes(n int) (codes []string, hashes []string, err error) {
+	encoding := base32.StdEncoding.WithPadding(base32.NoPadding)
+	for i := 0; i < n; i++ {
+		raw := make([]byte, 7)
+		if _, err := rand.Read(raw); err != nil {
+			return nil, nil, fmt.Errorf("failed to generate recovery code: %w", err)
+		}
+		encoded := strings.ToLower(encoding.EncodeToString(raw))[:10]
+		code := encoded[:5] + "-" + encoded[5:]
+		codes = append(codes, code)
+		hashes = append(hashes, hashRecoveryCode(code))
+	}
+	return codes, hashes, nil
+}
+
+// hashRecoveryCode ignores case and dashes so codes can be typed loosely.
+func hashRecoveryCode(code string) string {
+	normalized := strings.ToLower(strings.ReplaceAll(strings.TrimSpace(code), "-", ""))
+	sum := sha256.Sum256([]byte(normalized))
+	return hex.EncodeToString(sum[:])
+}
+
+// checkPasswordPolicy applies the configured strength policy to a new password.
+func checkPasswordPolicy(cfg *config.SecurityConfig, newPassword string, username string) error {
+	policy := password.Policy{
+		MinLength:     cfg.PasswordMinLength,
+		RequireUpper:  cfg.PasswordRequireUpper,
+		RequireLower:  cfg.PasswordRequireLower,
+		RequireDigit:  cfg.PasswordRequireDigit,
+		RequireSymbol: cfg.PasswordRequireSymbol,
+	}
+	if err := policy.Check(newPassword, username); err != nil {
+		return fmt.Errorf("%w: %w", domain.ErrWeakPassword, err)
+	}
+	return nil
+}
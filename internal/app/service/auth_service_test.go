@@ -3,15 +3,22 @@ package service
 import (
 	"context"
 	"errors"
+	"strings"
 	"testing"
+	"time"
 
 	"golang.org/x/crypto/bcrypt"
 
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
 
+	"toptal/internal/app/auth"
+	"toptal/internal/app/config"
 	"toptal/internal/app/domain"
 	"toptal/internal/app/util"
+	"toptal/internal/pkg/password"
+	"toptal/internal/pkg/totp"
 )
 
 type MockUserRepository struct {
@@ -28,15 +35,65 @@ func (m *MockUserRepository) FindUserById(ctx context.Context, id int) (domain.U
 	return args.Get(0).(domain.User), args.Error(1)
 }
 
+func (m *MockUserRepository) FindUserByEmail(ctx context.Context, email string) (domain.User, error) {
+	args := m.Called(ctx, email)
+	return args.Get(0).(domain.User), args.Error(1)
+}
+
 func (m *MockUserRepository) CreateUser(ctx context.Context, user domain.User) error {
 	args := m.Called(ctx, user)
 	return args.Error(0)
 }
 
+func (m *MockUserRepository) UpgradePasswordHash(ctx context.Context, userId int, oldHash string, newHash string) error {
+	args := m.Called(ctx, userId, oldHash, newHash)
+	return args.Error(0)
+}
+
+func (m *MockUserRepository) UpdateUserProfile(ctx context.Context, user domain.User) error {
+	args := m.Called(ctx, user)
+	return args.Error(0)
+}
+
+func (m *MockUserRepository) ChangePassword(ctx context.Context, userId int, passwordHash string) error {
+	args := m.Called(ctx, userId, passwordHash)
+	return args.Error(0)
+}
+
+func (m *MockUserRepository) DeleteUser(ctx context.Context, userId int) error {
+	args := m.Called(ctx, userId)
+	return args.Error(0)
+}
+
+func (m *MockUserRepository) SetTOTPSecret(ctx context.Context, userId int, secret string) error {
+	args := m.Called(ctx, userId, secret)
+	return args.Error(0)
+}
+
+func (m *MockUserRepository) EnableTOTP(ctx context.Context, userId int, recoveryCodeHashes []string) error {
+	args := m.Called(ctx, userId, recoveryCodeHashes)
+	return args.Error(0)
+}
+
+func (m *MockUserRepository) DisableTOTP(ctx context.Context, userId int) error {
+	args := m.Called(ctx, userId)
+	return args.Error(0)
+}
+
+func (m *MockUserRepository) ConsumeTOTPStep(ctx context.Context, userId int, step int64) (bool, error) {
+	args := m.Called(ctx, userId, step)
+	return args.Bool(0), args.Error(1)
+}
+
+func (m *MockUserRepository) ConsumeRecoveryCode(ctx context.Context, userId int, codeHash string) (bool, error) {
+	args := m.Called(ctx, userId, codeHash)
+	return args.Bool(0), args.Error(1)
+}
+
 func TestAuthService_Login(t *testing.T) {
 	ctx := context.Background()
 	mockRepo := new(MockUserRepository)
-	service := NewAuthService(mockRepo)
+	service := NewAuthService(mockRepo, newTestHasher(t), newTestSessions(t), &config.SecurityConfig{})
 
 	t.Run("Successful login", func(t *testing.T) {
 		// Create a user with known password hash
@@ -47,9 +104,10 @@ func TestAuthService_Login(t *testing.T) {
 
 		mockRepo.On("FindUserByName", ctx, "testuser").Return(user, nil)
 
-		token, err := service.Login(ctx, "testuser", password)
+		result, err := service.Login(ctx, "testuser", password)
 		assert.NoError(t, err)
-		assert.NotEmpty(t, token)
+		assert.NotEmpty(t, result.Token())
+		assert.False(t, result.TwoFactorRequired())
 
 		mockRepo.AssertExpectations(t)
 	})
@@ -58,9 +116,9 @@ func TestAuthService_Login(t *testing.T) {
 		mockRepo.On("FindUserByName", ctx, "nonexistent").
 			Return(domain.User{}, errors.New("user not found"))
 
-		token, err := service.Login(ctx, "nonexistent", "anypassword")
+		result, err := service.Login(ctx, "nonexistent", "anypassword")
 		assert.Error(t, err)
-		assert.Empty(t, token)
+		assert.Empty(t, result.Token())
 
 		mockRepo.AssertExpectations(t)
 	})
@@ -72,26 +130,219 @@ func TestAuthService_Login(t *testing.T) {
 
 		mockRepo.On("FindUserByName", ctx, "testuser").Return(user, nil)
 
-		token, err := service.Login(ctx, "testuser", "wrongpassword")
+		result, err := service.Login(ctx, "testuser", "wrongpassword")
 		assert.Error(t, err)
-		assert.Empty(t, token)
+		assert.Empty(t, result.Token())
 		assert.Equal(t, "invalid password", err.Error())
 
 		mockRepo.AssertExpectations(t)
 	})
 }
 
+func TestAuthService_LoginUpgradesPasswordHash(t *testing.T) {
+	ctx := context.Background()
+	hashedPassword, err := bcrypt.GenerateFromPassword([]byte("testpassword"), bcrypt.MinCost)
+	require.NoError(t, err)
+	user, err := domain.NewUser(1, "testuser", string(hashedPassword), false)
+	require.NoError(t, err)
+
+	argon2, err := password.NewHasher(password.Params{
+		Algorithm: password.AlgorithmArgon2id, Argon2Memory: 64, Argon2Iterations: 1, Argon2Parallelism: 1,
+	})
+	require.NoError(t, err)
+
+	mockRepo := new(MockUserRepository)
+	service := NewAuthService(mockRepo, argon2, newTestSessions(t), &config.SecurityConfig{})
+
+	mockRepo.On("FindUserByName", ctx, "testuser").Return(user, nil).Once()
+	mockRepo.On("UpgradePasswordHash", ctx, 1, string(hashedPassword), mock.MatchedBy(func(hash string) bool {
+		ok, err := argon2.Verify(hash, "testpassword")
+		return err == nil && ok && strings.HasPrefix(hash, "$argon2id$")
+	})).Return(nil).Once()
+
+	result, err := service.Login(ctx, "testuser", "testpassword")
+	require.NoError(t, err)
+	assert.NotEmpty(t, result.Token())
+	mockRepo.AssertExpectations(t)
+}
+
+func TestAuthService_TwoFactorLogin(t *testing.T) {
+	ctx := context.Background()
+	password := "testpassword"
+	hashedPassword, _ := HashPassword(password)
+	secret, err := totp.GenerateSecret()
+	require.NoError(t, err)
+
+	user, err := domain.NewUser(5, "admin", string(hashedPassword), true)
+	require.NoError(t, err)
+	require.NoError(t, user.SetTOTP(secret, true))
+
+	login := func(t *testing.T, service *AuthService) string {
+		result, err := service.Login(ctx, "admin", password)
+		require.NoError(t, err)
+		require.True(t, result.TwoFactorRequired())
+		assert.Empty(t, result.Token())
+		return result.ChallengeToken()
+	}
+
+	t.Run("Valid TOTP code", func(t *testing.T) {
+		mockRepo := new(MockUserRepository)
+		service := NewAuthService(mockRepo, newTestHasher(t), newTestSessions(t), &config.SecurityConfig{})
+		mockRepo.On("FindUserByName", ctx, "admin").Return(user, nil)
+		mockRepo.On("FindUserById", ctx, 5).Return(user, nil)
+
+		code, err := totp.Code(secret, totp.Step(time.Now()))
+		require.NoError(t, err)
+		mockRepo.On("ConsumeTOTPStep", ctx, 5, mock.AnythingOfType("int64")).Return(true, nil)
+
+		token, err := service.VerifyTwoFactor(ctx, login(t, service), code)
+		assert.NoError(t, err)
+		assert.NotEmpty(t, token)
+		mockRepo.AssertExpectations(t)
+	})
+
+	t.Run("Replayed TOTP code", func(t *testing.T) {
+		mockRepo := new(MockUserRepository)
+		service := NewAuthService(mockRepo, newTestHasher(t), newTestSessions(t), &config.SecurityConfig{})
+		mockRepo.On("FindUserByName", ctx, "admin").Return(user, nil)
+		mockRepo.On("FindUserById", ctx, 5).Return(user, nil)
+
+		code, err := totp.Code(secret, totp.Step(time.Now()))
+		require.NoError(t, err)
+		mockRepo.On("ConsumeTOTPStep", ctx, 5, mock.AnythingOfType("int64")).Return(false, nil)
+
+		_, err = service.VerifyTwoFactor(ctx, login(t, service), code)
+		assert.ErrorIs(t, err, domain.ErrInvalidTwoFactorCode)
+	})
+
+	t.Run("Recovery code", func(t *testing.T) {
+		mockRepo := new(MockUserRepository)
+		service := NewAuthService(mockRepo, newTestHasher(t), newTestSessions(t), &config.SecurityConfig{})
+		mockRepo.On("FindUserByName", ctx, "admin").Return(user, nil)
+		mockRepo.On("FindUserById", ctx, 5).Return(user, nil)
+		mockRepo.On("ConsumeRecoveryCode", ctx, 5, hashRecoveryCode("abcde-fghij")).Return(true, nil)
+
+		token, err := service.VerifyTwoFactor(ctx, login(t, service), "ABCDE-FGHIJ")
+		assert.NoError(t, err)
+		assert.NotEmpty(t, token)
+	})
+
+	t.Run("Challenge locked after too many attempts", func(t *testing.T) {
+		mockRepo := new(MockUserRepository)
+		sessions := new(MockSessionRepository)
+		service := NewAuthService(mockRepo, newTestHasher(t), NewSessionService(sessions), &config.SecurityConfig{})
+		mockRepo.On("FindUserByName", ctx, "admin").Return(user, nil)
+		sessions.On("InsertTwoFactorChallenge", ctx, 5, mock.Anything).Return(9, nil)
+		sessions.On("AttemptTwoFactorChallenge", ctx, 9, 5, twoFactorMaxAttempts).Return(false, nil)
+
+		code, err := totp.Code(secret, totp.Step(time.Now()))
+		require.NoError(t, err)
+		_, err = service.VerifyTwoFactor(ctx, login(t, service), code)
+		assert.ErrorIs(t, err, domain.ErrUnauthorized)
+		mockRepo.AssertNotCalled(t, "ConsumeTOTPStep", mock.Anything, mock.Anything, mock.Anything)
+	})
+
+	t.Run("Challenge completes one login", func(t *testing.T) {
+		mockRepo := new(MockUserRepository)
+		sessions := new(MockSessionRepository)
+		service := NewAuthService(mockRepo, newTestHasher(t), NewSessionService(sessions), &config.SecurityConfig{})
+		mockRepo.On("FindUserByName", ctx, "admin").Return(user, nil)
+		mockRepo.On("FindUserById", ctx, 5).Return(user, nil)
+		mockRepo.On("ConsumeRecoveryCode", ctx, 5, mock.Anything).Return(true, nil)
+		sessions.On("InsertTwoFactorChallenge", ctx, 5, mock.Anything).Return(9, nil)
+		sessions.On("AttemptTwoFactorChallenge", ctx, 9, 5, twoFactorMaxAttempts).Return(true, nil)
+		sessions.On("CompleteTwoFactorChallenge", ctx, 9).Return(true, nil).Once()
+		sessions.On("CompleteTwoFactorChallenge", ctx, 9).Return(false, nil).Once()
+		sessions.On("InsertSession", ctx, mock.Anything).Return(newTestSession(t, 1, 5, time.Now().Add(time.Hour)), nil).Once()
+
+		challenge := login(t, service)
+		token, err := service.VerifyTwoFactor(ctx, challenge, "abcde-fghij")
+		require.NoError(t, err)
+		assert.NotEmpty(t, token)
+		_, err = service.VerifyTwoFactor(ctx, challenge, "klmno-pqrst")
+		assert.ErrorIs(t, err, domain.ErrUnauthorized)
+		sessions.AssertExpectations(t)
+	})
+
+	t.Run("Challenge token is not an access token", func(t *testing.T) {
+		mockRepo := new(MockUserRepository)
+		service := NewAuthService(mockRepo, newTestHasher(t), newTestSessions(t), &config.SecurityConfig{})
+		mockRepo.On("FindUserByName", ctx, "admin").Return(user, nil)
+
+		_, err := auth.ParseToken(login(t, service))
+		assert.Error(t, err)
+	})
+}
+
+func TestAuthService_ConfirmTOTPRejectsReplayedCode(t *testing.T) {
+	ctx := context.Background()
+	secret, err := totp.GenerateSecret()
+	require.NoError(t, err)
+	user, err := domain.NewUser(5, "admin", domain.UnusablePasswordHash, true)
+	require.NoError(t, err)
+	require.NoError(t, user.SetTOTP(secret, false))
+
+	users := new(MockUserRepository)
+	service := NewAuthService(users, newTestHasher(t), newTestSessions(t), &config.SecurityConfig{})
+	users.On("FindUserById", ctx, 5).Return(user, nil)
+	users.On("ConsumeTOTPStep", ctx, 5, mock.AnythingOfType("int64")).Return(false, nil).Once()
+
+	code, err := totp.Code(secret, totp.Step(time.Now()))
+	require.NoError(t, err)
+	_, err = service.ConfirmTOTP(ctx, 5, code)
+	assert.ErrorIs(t, err, domain.ErrInvalidTwoFactorCode)
+	users.AssertNotCalled(t, "EnableTOTP", mock.Anything, mock.Anything, mock.Anything)
+}
+
+func TestAuthService_DisableTOTPRevokesOtherSessions(t *testing.T) {
+	ctx := util.WithSessionID(context.Background(), 3)
+	secret, err := totp.GenerateSecret()
+	require.NoError(t, err)
+	user, err := domain.NewUser(5, "admin", domain.UnusablePasswordHash, true)
+	require.NoError(t, err)
+	require.NoError(t, user.SetTOTP(secret, true))
+
+	users := new(MockUserRepository)
+	sessions := new(MockSessionRepository)
+	service := NewAuthService(users, newTestHasher(t), NewSessionService(sessions), &config.SecurityConfig{})
+	users.On("FindUserById", ctx, 5).Return(user, nil)
+	users.On("ConsumeTOTPStep", ctx, 5, mock.AnythingOfType("int64")).Return(true, nil).Once()
+	users.On("DisableTOTP", ctx, 5).Return(nil).Once()
+	sessions.On("RevokeUserSessions", ctx, 5, 3, mock.Anything).Return(2, nil).Once()
+
+	code, err := totp.Code(secret, totp.Step(time.Now()))
+	require.NoError(t, err)
+	require.NoError(t, service.DisableTOTP(ctx, 5, code))
+	users.AssertExpectations(t)
+	sessions.AssertExpectations(t)
+}
+
+func TestAuthService_RequiresTwoFactorEnrollment(t *testing.T) {
+	admin, err := domain.NewUser(1, "admin", "hash", true)
+	require.NoError(t, err)
+
+	service := NewAuthService(new(MockUserRepository), newTestHasher(t), newTestSessions(t), &config.SecurityConfig{AdminTwoFactorRequired: true})
+	assert.True(t, service.RequiresTwoFactorEnrollment(admin))
+
+	require.NoError(t, admin.SetTOTP("SECRET", true))
+	assert.False(t, service.RequiresTwoFactorEnrollment(admin))
+
+	service = NewAuthService(new(MockUserRepository), newTestHasher(t), newTestSessions(t), &config.SecurityConfig{})
+	admin, _ = domain.NewUser(1, "admin", "hash", true)
+	assert.False(t, service.RequiresTwoFactorEnrollment(admin))
+}
+
 func TestAuthService_Register(t *testing.T) {
 	ctx := context.Background()
 	mockRepo := new(MockUserRepository)
-	service := NewAuthService(mockRepo)
+	service := NewAuthService(mockRepo, newTestHasher(t), newTestSessions(t), &config.SecurityConfig{})
 
 	t.Run("Successful registration", func(t *testing.T) {
 		mockRepo.On("CreateUser", ctx, mock.MatchedBy(func(user domain.User) bool {
-			return user.Username() == "newuser" && len(user.PasswordHash()) > 0
+			return user.Username() == "newuser" && len(user.PasswordHash()) > 0 && user.Email() == "new@example.com"
 		})).Return(nil).Once()
 
-		err := service.Register(ctx, "newuser", "password123")
+		err := service.Register(ctx, "newuser", "password123", "New@Example.com")
 		assert.NoError(t, err)
 
 		mockRepo.AssertExpectations(t)
@@ -102,11 +353,24 @@ func TestAuthService_Register(t *testing.T) {
 			return user.Username() == "newuser" && len(user.PasswordHash()) > 0
 		})).Return(errors.New("failed to create user")).Once()
 
-		err := service.Register(ctx, "newuser", "password123")
+		err := service.Register(ctx, "newuser", "password123", "")
 		assert.Error(t, err)
 
 		mockRepo.AssertExpectations(t)
 	})
+
+	t.Run("Weak password", func(t *testing.T) {
+		strictRepo := new(MockUserRepository)
+		strict := NewAuthService(strictRepo, newTestHasher(t), newTestSessions(t), &config.SecurityConfig{PasswordMinLength: 8, PasswordRequireDigit: true})
+
+		err := strict.Register(ctx, "newuser", "short", "")
+		assert.ErrorIs(t, err, domain.ErrWeakPassword)
+
+		err = strict.Register(ctx, "newuser", "newuser2024", "")
+		assert.ErrorIs(t, err, domain.ErrWeakPassword, "password containing the username")
+
+		strictRepo.AssertExpectations(t)
+	})
 }
 
 // Helper function to create a context with user ID
@@ -122,6 +386,12 @@ func TestContextWithUserId(t *testing.T) {
 	assert.Equal(t, userId, extractedId)
 }
 
+func newTestHasher(t *testing.T) *password.Hasher {
+	hasher, err := password.NewHasher(password.Params{Algorithm: password.AlgorithmBcrypt, BcryptCost: bcrypt.DefaultCost})
+	require.NoError(t, err)
+	return hasher
+}
+
 func HashPassword(password string) ([]byte, error) {
 	return bcrypt.GenerateFromPassword([]byte(password), bcrypt.DefaultCost)
 }
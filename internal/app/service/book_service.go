@@ -6,30 +6,62 @@ import (
 )
 
 type BookService struct {
-	bookRepository BookRepository
-	authService    AuthService
+	bookRepository  BookRepository
+	priceRepository PriceRepository
+	authService     AuthService
 }
 
-func NewBookService(bookRepository BookRepository, authService AuthService) *BookService {
-	return &BookService{bookRepository, authService}
+func NewBookService(bookRepository BookRepository, priceRepository PriceRepository, authService AuthService) *BookService {
+	return &BookService{bookRepository, priceRepository, authService}
 }
 
-func (s *BookService) GetBookById(ctx context.Context, id int) (domain.Book, error) {
-	return s.bookRepository.GetById(ctx, id)
+// GetBookById returns the book priced in the given currency, or in its own currency when
+// currency is empty or the book has no price in it.
+func (s *BookService) GetBookById(ctx context.Context, id int, currency string) (domain.Book, error) {
+	book, err := s.bookRepository.GetById(ctx, id)
+	if err != nil {
+		return domain.Book{}, err
+	}
+	priced, err := priceBooksIn(ctx, s.priceRepository, []domain.Book{book}, currency)
+	if err != nil {
+		return domain.Book{}, err
+	}
+	return priced[0], nil
 }
 
-func (s *BookService) GetAvailableBooks(ctx context.Context, categoryIds []int, limit, offset int) ([]domain.Book, error) {
-	return s.bookRepository.GetByCategories(ctx, categoryIds, limit, offset)
+func (s *BookService) GetAvailableBooks(ctx context.Context, filter domain.BookFilter, limit, offset int, currency string) ([]domain.Book, error) {
+	books, err := s.bookRepository.GetAvailable(ctx, filter, limit, offset)
+	if err != nil {
+		return nil, err
+	}
+	return priceBooksIn(ctx, s.priceRepository, books, currency)
+}
+
+// SearchBooks finds available books by title, author or ISBN.
+func (s *BookService) SearchBooks(ctx context.Context, query string, limit, offset int, currency string) ([]domain.Book, error) {
+	books, err := s.bookRepository.Search(ctx, query, limit, offset)
+	if err != nil {
+		return nil, err
+	}
+	return priceBooksIn(ctx, s.priceRepository, books, currency)
 }
 
 func (s *BookService) CreateBook(ctx context.Context, book domain.Book) error {
-	return s.bookRepository.Create(ctx, book)
+	return s.bookRepository.Create(ctx, book, auditActor(ctx))
 }
 
 func (s *BookService) UpdateBook(ctx context.Context, book domain.Book) error {
-	return s.bookRepository.Update(ctx, book)
+	return s.bookRepository.Update(ctx, book, auditActor(ctx))
 }
 
 func (s *BookService) DeleteBook(ctx context.Context, id int) error {
-	return s.bookRepository.Delete(ctx, id)
+	return s.bookRepository.Delete(ctx, id, auditActor(ctx))
+}
+
+func (s *BookService) RestoreBook(ctx context.Context, id int) (domain.Book, error) {
+	return s.bookRepository.Restore(ctx, id, auditActor(ctx))
+}
+
+func (s *BookService) GetArchivedBooks(ctx context.Context, limit, offset int) ([]domain.Book, error) {
+	return s.bookRepository.GetArchived(ctx, limit, offset)
 }
@@ -10,32 +10,53 @@ import (
 )
 
 type CartService struct {
-	cartRepository CartRepository
-	config         *config.CartConfig
+	cartRepository  CartRepository
+	priceRepository PriceRepository
+	taxService      *TaxService
+	config          *config.CartConfig
 }
 
-func NewCartService(repository CartRepository, cfg *config.CartConfig) *CartService {
-	return &CartService{cartRepository: repository, config: cfg}
+func NewCartService(
+	repository CartRepository, priceRepository PriceRepository, taxService *TaxService, cfg *config.CartConfig,
+) *CartService {
+	return &CartService{cartRepository: repository, priceRepository: priceRepository, taxService: taxService, config: cfg}
 }
 
-func (s *CartService) GetCart(ctx context.Context, userId int) ([]domain.Book, error) {
-	return s.cartRepository.GetCart(ctx, userId)
+func (s *CartService) GetCart(ctx context.Context, userId int, currency string) ([]domain.Book, error) {
+	books, err := s.cartRepository.GetCart(ctx, userId)
+	if err != nil {
+		return nil, err
+	}
+	return priceBooksIn(ctx, s.priceRepository, books, currency)
 }
 
-func (s *CartService) AddToCart(ctx context.Context, userId, bookId int) error {
-	if err := s.cartRepository.AddToCart(ctx, userId, bookId); err != nil {
+// AddToCart puts a book in the cart, in the given format or, when format is empty, the
+// book itself.
+func (s *CartService) AddToCart(ctx context.Context, userId, bookId int, format string) error {
+	if format != "" && !domain.IsFormat(format) {
+		return fmt.Errorf("%w: %q", domain.ErrInvalidFormat, format)
+	}
+	if err := s.cartRepository.AddToCart(ctx, userId, bookId, format); err != nil {
 		slog.Error("failed to add to cart", "error", err)
 		return fmt.Errorf("failed to add book to cart: %w", err)
 	}
 	return nil
 }
 
-func (s *CartService) RemoveFromCart(ctx context.Context, userId, bookId int) error {
-	return s.cartRepository.RemoveFromCart(ctx, userId, bookId)
+func (s *CartService) RemoveFromCart(ctx context.Context, userId, bookId int, format string) error {
+	return s.cartRepository.RemoveFromCart(ctx, userId, bookId, format)
 }
 
-func (s *CartService) Purchase(ctx context.Context, userId int) error {
-	return s.cartRepository.Purchase(ctx, userId)
+// Purchase orders the cart in the given currency, or in the currency of its books when
+// currency is empty, and taxes it for the region it ships to.
+func (s *CartService) Purchase(
+	ctx context.Context, userId int, currency string, address domain.ShippingAddress,
+) (domain.Order, error) {
+	return s.cartRepository.Purchase(ctx, userId, currency, address,
+		func(items []domain.OrderItem) (domain.OrderTax, error) {
+			return s.taxService.TaxOrder(address, items)
+		},
+	)
 }
 
 func (s *CartService) StartCartCleanerJob(ctx context.Context) {
@@ -23,13 +23,21 @@ func (s *CategoryService) GetCategories(ctx context.Context) ([]domain.Category,
 }
 
 func (s *CategoryService) CreateCategory(ctx context.Context, book domain.Category) error {
-	return s.categoryRepository.InsertCategory(ctx, book)
+	return s.categoryRepository.InsertCategory(ctx, book, auditActor(ctx))
 }
 
 func (s *CategoryService) UpdateCategory(ctx context.Context, book domain.Category) error {
-	return s.categoryRepository.UpdateCategory(ctx, book)
+	return s.categoryRepository.UpdateCategory(ctx, book, auditActor(ctx))
 }
 
 func (s *CategoryService) DeleteCategory(ctx context.Context, id int) error {
-	return s.categoryRepository.DeleteCategory(ctx, id)
+	return s.categoryRepository.DeleteCategory(ctx, id, auditActor(ctx))
+}
+
+func (s *CategoryService) RestoreCategory(ctx context.Context, id int) (domain.Category, error) {
+	return s.categoryRepository.RestoreCategory(ctx, id, auditActor(ctx))
+}
+
+func (s *CategoryService) GetArchivedCategories(ctx context.Context) ([]domain.Category, error) {
+	return s.categoryRepository.FindArchivedCategories(ctx)
 }
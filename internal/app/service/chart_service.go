@@ -0,0 +1,82 @@
+package service
+
+import (
+	"context"
+	"log/slog"
+	"time"
+	"toptal/internal/app/config"
+	"toptal/internal/app/domain"
+)
+
+// ChartService lists bestsellers, trending books and new arrivals for the storefront.
+// Bestsellers and trending books are ranked by a background job, not on request.
+type ChartService struct {
+	chartRepository ChartRepository
+	priceRepository PriceRepository
+	config          *config.ChartConfig
+}
+
+func NewChartService(chartRepository ChartRepository, priceRepository PriceRepository, cfg *config.ChartConfig) *ChartService {
+	return &ChartService{chartRepository: chartRepository, priceRepository: priceRepository, config: cfg}
+}
+
+// GetBestsellers lists the books that sold most over the period, of the category unless
+// categoryId is 0.
+func (s *ChartService) GetBestsellers(
+	ctx context.Context, period string, categoryId, limit, offset int, currency string,
+) ([]domain.Book, error) {
+	books, err := s.chartRepository.FindBestsellers(ctx, period, categoryId, limit, offset)
+	if err != nil {
+		return nil, err
+	}
+	return priceBooksIn(ctx, s.priceRepository, books, currency)
+}
+
+// GetTrending lists the books put in carts more often lately, fastest rising first.
+func (s *ChartService) GetTrending(ctx context.Context, categoryId, limit, offset int, currency string) ([]domain.Book, error) {
+	books, err := s.chartRepository.FindTrending(ctx, categoryId, limit, offset)
+	if err != nil {
+		return nil, err
+	}
+	return priceBooksIn(ctx, s.priceRepository, books, currency)
+}
+
+func (s *ChartService) GetNewArrivals(ctx context.Context, categoryId, limit, offset int, currency string) ([]domain.Book, error) {
+	books, err := s.chartRepository.FindNewArrivals(ctx, categoryId, limit, offset)
+	if err != nil {
+		return nil, err
+	}
+	return priceBooksIn(ctx, s.priceRepository, books, currency)
+}
+
+func (s *ChartService) RefreshCharts(ctx context.Context) error {
+	orders, err := s.chartRepository.RefreshCharts(ctx, s.config.TrendingDays, s.config.TrendingBaselineDays)
+	if err != nil {
+		return err
+	}
+	slog.Debug("Refreshed charts", "orders", orders)
+	return nil
+}
+
+// StartChartRefreshJob ranks the charts right away, so they are not empty until the
+// first tick, then every refresh interval.
+func (s *ChartService) StartChartRefreshJob(ctx context.Context) {
+	ticker := time.NewTicker(s.config.RefreshInterval)
+	go func() {
+		defer ticker.Stop()
+		if err := s.RefreshCharts(ctx); err != nil {
+			slog.Error("failed to refresh charts", "error", err)
+		}
+		for {
+			select {
+			case <-ticker.C:
+				if err := s.RefreshCharts(ctx); err != nil {
+					slog.Error("failed to refresh charts", "error", err)
+				}
+			case <-ctx.Done():
+				return
+			}
+		}
+	}()
+	slog.Info("Chart refresh job started", "interval minutes", s.config.RefreshInterval.Minutes())
+}
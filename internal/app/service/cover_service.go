@@ -0,0 +1,165 @@
+package service
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"image"
+	"image/color"
+	"image/jpeg"
+	"io"
+	"log/slog"
+	"time"
+	"toptal/internal/app/config"
+	"toptal/internal/app/domain"
+	"toptal/internal/pkg/blob"
+	"toptal/internal/pkg/imaging"
+
+	_ "image/gif"
+	_ "image/png"
+)
+
+// coverTypes maps the media types accepted for covers to the names the image package
+// decodes them by.
+var coverTypes = map[string]string{
+	"image/jpeg": "jpeg",
+	"image/png":  "png",
+	"image/gif":  "gif",
+}
+
+const coverJPEGQuality = 85
+
+type CoverService struct {
+	coverRepository CoverRepository
+	blobStore       blob.Store
+	config          *config.CoverConfig
+}
+
+func NewCoverService(repository CoverRepository, blobStore blob.Store, cfg *config.CoverConfig) *CoverService {
+	return &CoverService{coverRepository: repository, blobStore: blobStore, config: cfg}
+}
+
+// UploadCover stores a JPEG, PNG or GIF image as the cover of a book, with medium and
+// thumbnail JPEG renditions scaled down from it, and replaces the cover the book had.
+func (s *CoverService) UploadCover(ctx context.Context, bookId int, contentType string, content io.Reader) (domain.Cover, error) {
+	imageFormat, ok := coverTypes[contentType]
+	if !ok {
+		return domain.Cover{}, fmt.Errorf("%w: covers must be JPEG, PNG or GIF images", domain.ErrInvalidImage)
+	}
+	// One byte more than allowed tells a file at the limit from a larger one.
+	original, err := io.ReadAll(io.LimitReader(content, s.config.MaxFileSize+1))
+	if err != nil {
+		return domain.Cover{}, fmt.Errorf("failed to read cover: %w", err)
+	}
+	if int64(len(original)) > s.config.MaxFileSize {
+		return domain.Cover{}, fmt.Errorf("%w: covers may have up to %d bytes", domain.ErrFileTooLarge, s.config.MaxFileSize)
+	}
+	img, err := s.decodeCover(original, imageFormat)
+	if err != nil {
+		return domain.Cover{}, err
+	}
+
+	suffix, err := randomKey()
+	if err != nil {
+		return domain.Cover{}, err
+	}
+	cover, err := domain.NewCover(fmt.Sprintf("covers/%d/%s", bookId, suffix), contentType, time.Now())
+	if err != nil {
+		return domain.Cover{}, err
+	}
+	if err := s.storeRenditions(ctx, cover, original, img); err != nil {
+		s.deleteRenditions(ctx, cover)
+		return domain.Cover{}, err
+	}
+
+	before, err := s.coverRepository.SetCover(ctx, bookId, cover, auditActor(ctx))
+	if err != nil {
+		s.deleteRenditions(ctx, cover)
+		return domain.Cover{}, err
+	}
+	if previous, ok := before.Cover(); ok {
+		s.deleteRenditions(ctx, previous)
+	}
+
+	slog.Info("Book cover uploaded", "book_id", bookId, "size", len(original))
+	return cover, nil
+}
+
+// decodeCover checks that an upload is an image of the type it claims to be, small enough
+// to be decoded whole, and decodes it.
+func (s *CoverService) decodeCover(original []byte, imageFormat string) (image.Image, error) {
+	cfg, format, err := image.DecodeConfig(bytes.NewReader(original))
+	if err != nil || format != imageFormat {
+		return nil, fmt.Errorf("%w: the file is not a %s image", domain.ErrInvalidImage, imageFormat)
+	}
+	if cfg.Width*cfg.Height > s.config.MaxPixels {
+		return nil, fmt.Errorf("%w: covers may have up to %d pixels", domain.ErrFileTooLarge, s.config.MaxPixels)
+	}
+	img, _, err := image.Decode(bytes.NewReader(original))
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", domain.ErrInvalidImage, err)
+	}
+	return img, nil
+}
+
+func (s *CoverService) storeRenditions(ctx context.Context, cover domain.Cover, original []byte, img image.Image) error {
+	if _, err := s.blobStore.Put(ctx, cover.RenditionKey(domain.CoverOriginal), bytes.NewReader(original)); err != nil {
+		return fmt.Errorf("failed to store cover: %w", err)
+	}
+	widths := map[string]int{domain.CoverMedium: s.config.MediumWidth, domain.CoverThumbnail: s.config.ThumbnailWidth}
+	for rendition, width := range widths {
+		var scaled bytes.Buffer
+		err := jpeg.Encode(&scaled, imaging.Fit(img, width, color.White), &jpeg.Options{Quality: coverJPEGQuality})
+		if err != nil {
+			return fmt.Errorf("failed to encode %s cover: %w", rendition, err)
+		}
+		if _, err := s.blobStore.Put(ctx, cover.RenditionKey(rendition), &scaled); err != nil {
+			return fmt.Errorf("failed to store %s cover: %w", rendition, err)
+		}
+	}
+	return nil
+}
+
+// DeleteCover removes the cover of a book. It returns domain.ErrNotFound when the book has
+// no cover.
+func (s *CoverService) DeleteCover(ctx context.Context, bookId int) error {
+	before, err := s.coverRepository.RemoveCover(ctx, bookId, auditActor(ctx))
+	if err != nil {
+		return err
+	}
+	if cover, ok := before.Cover(); ok {
+		s.deleteRenditions(ctx, cover)
+	}
+	return nil
+}
+
+// OpenCover opens a rendition of the cover of a listed book. The caller closes it.
+func (s *CoverService) OpenCover(ctx context.Context, bookId int, rendition string) (domain.Cover, io.ReadSeekCloser, error) {
+	if !domain.IsCoverRendition(rendition) {
+		return domain.Cover{}, nil, domain.ErrNotFound
+	}
+	cover, err := s.coverRepository.FindCover(ctx, bookId)
+	if err != nil {
+		return domain.Cover{}, nil, err
+	}
+	file, err := s.blobStore.Open(ctx, cover.RenditionKey(rendition))
+	if err != nil {
+		if errors.Is(err, blob.ErrNotFound) {
+			return domain.Cover{}, nil, domain.ErrNotFound
+		}
+		return domain.Cover{}, nil, fmt.Errorf("failed to open cover: %w", err)
+	}
+	return cover, file, nil
+}
+
+// deleteRenditions removes the files of a cover that is no longer used. A failure only
+// leaves orphaned files.
+func (s *CoverService) deleteRenditions(ctx context.Context, cover domain.Cover) {
+	for _, rendition := range domain.CoverRenditions {
+		key := cover.RenditionKey(rendition)
+		if err := s.blobStore.Delete(ctx, key); err != nil {
+			slog.Error("failed to delete cover", "key", key, "error", err)
+		}
+	}
+}
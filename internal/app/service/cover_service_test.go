@@ -0,0 +1,133 @@
+package service
+
+import (
+	"bytes"
+	"context"
+	"image"
+	"image/jpeg"
+	"image/png"
+	"strings"
+	"testing"
+	"time"
+	"toptal/internal/app/config"
+	"toptal/internal/app/domain"
+	"toptal/internal/pkg/blob"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+type MockCoverRepository struct {
+	mock.Mock
+}
+
+func (m *MockCoverRepository) FindCover(ctx context.Context, bookId int) (domain.Cover, error) {
+	args := m.Called(ctx, bookId)
+	return args.Get(0).(domain.Cover), args.Error(1)
+}
+
+func (m *MockCoverRepository) SetCover(
+	ctx context.Context, bookId int, cover domain.Cover, actor domain.AuditActor,
+) (domain.Book, error) {
+	args := m.Called(ctx, bookId, cover, actor)
+	return args.Get(0).(domain.Book), args.Error(1)
+}
+
+func (m *MockCoverRepository) RemoveCover(ctx context.Context, bookId int, actor domain.AuditActor) (domain.Book, error) {
+	args := m.Called(ctx, bookId, actor)
+	return args.Get(0).(domain.Book), args.Error(1)
+}
+
+func newCoverTestService(t *testing.T) (*CoverService, *MockCoverRepository, blob.Store) {
+	store, err := blob.NewFileStore(t.TempDir())
+	require.NoError(t, err)
+	repository := &MockCoverRepository{}
+	cfg := &config.CoverConfig{MaxFileSize: 1 << 20, MaxPixels: 1000000, MediumWidth: 60, ThumbnailWidth: 20}
+	return NewCoverService(repository, store, cfg), repository, store
+}
+
+func pngImage(t *testing.T, width, height int) []byte {
+	img := image.NewNRGBA(image.Rect(0, 0, width, height))
+	for i := range img.Pix {
+		img.Pix[i] = 200
+	}
+	var buf bytes.Buffer
+	require.NoError(t, png.Encode(&buf, img))
+	return buf.Bytes()
+}
+
+func TestCoverService_UploadCover(t *testing.T) {
+	service, repository, store := newCoverTestService(t)
+	ctx := context.Background()
+
+	old, err := domain.NewCover("covers/1/old", "image/jpeg", time.Now())
+	require.NoError(t, err)
+	for _, rendition := range domain.CoverRenditions {
+		_, err := store.Put(ctx, old.RenditionKey(rendition), strings.NewReader("old"))
+		require.NoError(t, err)
+	}
+	price, err := domain.NewMoney(1000, "USD")
+	require.NoError(t, err)
+	book, err := domain.NewBook(1, "Dune", 1965, "Frank Herbert", price, 3, 1)
+	require.NoError(t, err)
+	require.NoError(t, book.SetCover(old))
+	repository.On("SetCover", ctx, 1, mock.Anything, mock.Anything).Return(book, nil)
+
+	original := pngImage(t, 200, 300)
+	cover, err := service.UploadCover(ctx, 1, "image/png", bytes.NewReader(original))
+	require.NoError(t, err)
+	assert.Equal(t, "image/png", cover.ContentType())
+
+	file, err := store.Open(ctx, cover.RenditionKey(domain.CoverOriginal))
+	require.NoError(t, err)
+	stored := new(bytes.Buffer)
+	_, err = stored.ReadFrom(file)
+	require.NoError(t, err)
+	require.NoError(t, file.Close())
+	assert.Equal(t, original, stored.Bytes())
+
+	for rendition, size := range map[string]image.Point{domain.CoverMedium: {60, 90}, domain.CoverThumbnail: {20, 30}} {
+		file, err := store.Open(ctx, cover.RenditionKey(rendition))
+		require.NoError(t, err)
+		scaled, err := jpeg.DecodeConfig(file)
+		require.NoError(t, err)
+		require.NoError(t, file.Close())
+		assert.Equal(t, size, image.Pt(scaled.Width, scaled.Height), rendition)
+	}
+
+	for _, rendition := range domain.CoverRenditions {
+		_, err := store.Open(ctx, old.RenditionKey(rendition))
+		assert.ErrorIs(t, err, blob.ErrNotFound, "the replaced cover is removed")
+	}
+}
+
+func TestCoverService_UploadCover_Invalid(t *testing.T) {
+	service, repository, _ := newCoverTestService(t)
+	ctx := context.Background()
+
+	_, err := service.UploadCover(ctx, 1, "image/svg+xml", strings.NewReader("<svg/>"))
+	assert.ErrorIs(t, err, domain.ErrInvalidImage)
+
+	_, err = service.UploadCover(ctx, 1, "image/jpeg", bytes.NewReader(pngImage(t, 10, 10)))
+	assert.ErrorIs(t, err, domain.ErrInvalidImage, "the content must match its type")
+
+	_, err = service.UploadCover(ctx, 1, "image/png", bytes.NewReader(pngImage(t, 2000, 1000)))
+	assert.ErrorIs(t, err, domain.ErrFileTooLarge)
+
+	_, err = service.UploadCover(ctx, 1, "image/png", bytes.NewReader(make([]byte, 2<<20)))
+	assert.ErrorIs(t, err, domain.ErrFileTooLarge)
+
+	repository.AssertNotCalled(t, "SetCover", mock.Anything, mock.Anything, mock.Anything, mock.Anything)
+}
+
+func TestCoverService_OpenCover(t *testing.T) {
+	service, repository, _ := newCoverTestService(t)
+	ctx := context.Background()
+	repository.On("FindCover", ctx, 2).Return(domain.Cover{}, domain.ErrNotFound)
+
+	_, _, err := service.OpenCover(ctx, 1, "huge")
+	assert.ErrorIs(t, err, domain.ErrNotFound)
+	_, _, err = service.OpenCover(ctx, 2, domain.CoverThumbnail)
+	assert.ErrorIs(t, err, domain.ErrNotFound)
+}
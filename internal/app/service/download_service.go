@@ -0,0 +1,161 @@
+package service
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+	"time"
+	"toptal/internal/app/config"
+	"toptal/internal/app/domain"
+	"toptal/internal/pkg/blob"
+)
+
+// DownloadService hands out signed links to the digital formats users bought and opens
+// the files behind them. A link works until it expires, each purchase for a limited number
+// of downloads.
+type DownloadService struct {
+	downloadRepository DownloadRepository
+	blobStore          blob.Store
+	config             *config.DownloadConfig
+	baseURL            string
+	now                func() time.Time
+}
+
+func NewDownloadService(
+	repository DownloadRepository, blobStore blob.Store, cfg *config.DownloadConfig, baseURL string,
+) *DownloadService {
+	return &DownloadService{
+		downloadRepository: repository,
+		blobStore:          blobStore,
+		config:             cfg,
+		baseURL:            strings.TrimRight(baseURL, "/"),
+		now:                time.Now,
+	}
+}
+
+// GetDownloads returns the user's downloads, newest first, with fresh links. Downloads
+// without a file or whose limit is used up have no URL.
+func (s *DownloadService) GetDownloads(ctx context.Context, userId int) ([]domain.DownloadLink, error) {
+	downloads, err := s.downloadRepository.FindDownloadsByUser(ctx, userId)
+	if err != nil {
+		return nil, err
+	}
+
+	expiresAt := s.now().Add(s.config.URLTTL).Truncate(time.Second)
+	links := make([]domain.DownloadLink, len(downloads))
+	for i, download := range downloads {
+		remaining := s.config.Limit - download.Count()
+		url := ""
+		if _, ok := download.File(); ok && remaining > 0 {
+			url = fmt.Sprintf("%s/downloads/%d?expires=%d&signature=%s",
+				s.baseURL, download.Id(), expiresAt.Unix(), s.sign(download.Id(), expiresAt.Unix()))
+		}
+		links[i] = domain.NewDownloadLink(download, url, expiresAt, remaining)
+	}
+	return links, nil
+}
+
+// OpenDownload checks a download link and opens the file behind it. rangeHeader is the
+// Range header of the request. Each transfer counts against the limit, except the resume
+// of an interrupted one: a request for the rest of the file from exactly where the server
+// stopped sending it, as recorded by RecordTransfer. The caller closes the file.
+func (s *DownloadService) OpenDownload(
+	ctx context.Context, id int, expires int64, signature string, rangeHeader string,
+) (domain.Download, io.ReadSeekCloser, error) {
+	if !s.verify(id, expires, signature) {
+		return domain.Download{}, nil, domain.ErrInvalidDownloadLink
+	}
+
+	download, err := s.downloadRepository.FindDownload(ctx, id)
+	if err != nil {
+		return domain.Download{}, nil, err
+	}
+	file, ok := download.File()
+	if !ok {
+		return domain.Download{}, nil, fmt.Errorf("%w: the file of download %d was removed", domain.ErrNotFound, id)
+	}
+
+	resumed := false
+	if offset, ok := rangeStart(rangeHeader); ok && offset > 0 {
+		if resumed, err = s.downloadRepository.ResumeDownload(ctx, id, offset); err != nil {
+			return domain.Download{}, nil, err
+		}
+	}
+	if !resumed {
+		if err := s.downloadRepository.CountDownload(ctx, id, s.config.Limit); err != nil {
+			return domain.Download{}, nil, err
+		}
+	}
+
+	content, err := s.blobStore.Open(ctx, file.Key())
+	if err != nil {
+		if errors.Is(err, blob.ErrNotFound) {
+			return domain.Download{}, nil, fmt.Errorf("%w: %w", domain.ErrNotFound, err)
+		}
+		return domain.Download{}, nil, err
+	}
+	return download, content, nil
+}
+
+// RecordTransfer records that written bytes of a download were sent for a request with
+// the given Range header, so that a transfer that stopped before the end of the file can
+// be resumed from there without counting it again.
+func (s *DownloadService) RecordTransfer(ctx context.Context, download domain.Download, rangeHeader string, written int64) error {
+	offset, ok := rangeStart(rangeHeader)
+	if !ok {
+		return nil
+	}
+	file, _ := download.File()
+	end := offset + written
+	if end >= file.Size() {
+		end = 0
+	}
+	return s.downloadRepository.SetResumeOffset(ctx, download.Id(), end)
+}
+
+// rangeStart returns the first byte a request asks for: 0 without a Range header, and the
+// start of a single "bytes=start-" or "bytes=start-end" range. Any other range, which
+// cannot continue a transfer, is not ok.
+func rangeStart(rangeHeader string) (int64, bool) {
+	if rangeHeader == "" {
+		return 0, true
+	}
+	spec, ok := strings.CutPrefix(rangeHeader, "bytes=")
+	if !ok {
+		return 0, false
+	}
+	first, last, ok := strings.Cut(spec, "-")
+	if !ok {
+		return 0, false
+	}
+	start, err := strconv.ParseInt(first, 10, 64)
+	if err != nil || start < 0 {
+		return 0, false
+	}
+	if last != "" {
+		if end, err := strconv.ParseInt(last, 10, 64); err != nil || end < start {
+			return 0, false
+		}
+	}
+	return start, true
+}
+
+func (s *DownloadService) sign(id int, expires int64) string {
+	mac := hmac.New(sha256.New, []byte(s.config.SigningKey))
+	_, _ = fmt.Fprintf(mac, "download:%d:%d", id, expires)
+	return base64.RawURLEncoding.EncodeToString(mac.Sum(nil))
+}
+
+// verify checks that a link was signed by us and has not expired.
+func (s *DownloadService) verify(id int, expires int64, signature string) bool {
+	if s.now().Unix() > expires {
+		return false
+	}
+	return hmac.Equal([]byte(signature), []byte(s.sign(id, expires)))
+}
@@ -0,0 +1,190 @@
+package service
+
+import (
+	"context"
+	"io"
+	"net/url"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+	"toptal/internal/app/config"
+	"toptal/internal/app/domain"
+	"toptal/internal/pkg/blob"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+type MockDownloadRepository struct {
+	mock.Mock
+}
+
+func (m *MockDownloadRepository) FindDownloadsByUser(ctx context.Context, userId int) ([]domain.Download, error) {
+	args := m.Called(ctx, userId)
+	return args.Get(0).([]domain.Download), args.Error(1)
+}
+
+func (m *MockDownloadRepository) FindDownload(ctx context.Context, id int) (domain.Download, error) {
+	args := m.Called(ctx, id)
+	return args.Get(0).(domain.Download), args.Error(1)
+}
+
+func (m *MockDownloadRepository) CountDownload(ctx context.Context, id int, limit int) error {
+	return m.Called(ctx, id, limit).Error(0)
+}
+
+func (m *MockDownloadRepository) ResumeDownload(ctx context.Context, id int, offset int64) (bool, error) {
+	args := m.Called(ctx, id, offset)
+	return args.Bool(0), args.Error(1)
+}
+
+func (m *MockDownloadRepository) SetResumeOffset(ctx context.Context, id int, offset int64) error {
+	return m.Called(ctx, id, offset).Error(0)
+}
+
+func newDownloadTestService(t *testing.T) (*DownloadService, *MockDownloadRepository, domain.Download) {
+	store, err := blob.NewFileStore(t.TempDir())
+	require.NoError(t, err)
+	_, err = store.Put(context.Background(), "books/1/epub/a.epub", strings.NewReader("ebook"))
+	require.NoError(t, err)
+
+	download, err := domain.NewDownload(7, 3, 20, "Dune", "Frank Herbert", domain.FormatEPUB, 1, time.Now())
+	require.NoError(t, err)
+	file, err := domain.NewFormatFile("books/1/epub/a.epub", "dune.epub", "application/epub+zip", 5)
+	require.NoError(t, err)
+	require.NoError(t, download.SetFile(file))
+
+	repository := &MockDownloadRepository{}
+	cfg := &config.DownloadConfig{SigningKey: "secret", URLTTL: 15 * time.Minute, Limit: 5}
+	service := NewDownloadService(repository, store, cfg, "https://shop.example/")
+	service.now = func() time.Time { return time.Date(2030, 1, 1, 12, 0, 0, 0, time.UTC) }
+	return service, repository, download
+}
+
+// linkParams reads the id, expiry and signature of a download URL.
+func linkParams(t *testing.T, link string) (int, int64, string) {
+	parsed, err := url.Parse(link)
+	require.NoError(t, err)
+	id, err := strconv.Atoi(strings.TrimPrefix(parsed.Path, "/downloads/"))
+	require.NoError(t, err)
+	expires, err := strconv.ParseInt(parsed.Query().Get("expires"), 10, 64)
+	require.NoError(t, err)
+	return id, expires, parsed.Query().Get("signature")
+}
+
+func TestDownloadService_GetDownloads(t *testing.T) {
+	service, repository, download := newDownloadTestService(t)
+	ctx := context.Background()
+	removed, err := domain.NewDownload(8, 3, 21, "Emma", "Jane Austen", domain.FormatPDF, 0, time.Now())
+	require.NoError(t, err)
+	repository.On("FindDownloadsByUser", ctx, 3).Return([]domain.Download{download, removed}, nil)
+
+	links, err := service.GetDownloads(ctx, 3)
+	require.NoError(t, err)
+	require.Len(t, links, 2)
+	assert.True(t, strings.HasPrefix(links[0].URL(), "https://shop.example/downloads/7?expires="))
+	assert.Equal(t, 4, links[0].Remaining())
+	assert.Equal(t, service.now().Add(15*time.Minute), links[0].ExpiresAt())
+	assert.Empty(t, links[1].URL(), "downloads without a file have no link")
+}
+
+func TestDownloadService_OpenDownload(t *testing.T) {
+	service, repository, download := newDownloadTestService(t)
+	ctx := context.Background()
+	repository.On("FindDownloadsByUser", ctx, 3).Return([]domain.Download{download}, nil)
+	repository.On("FindDownload", ctx, 7).Return(download, nil)
+	links, err := service.GetDownloads(ctx, 3)
+	require.NoError(t, err)
+	id, expires, signature := linkParams(t, links[0].URL())
+
+	t.Run("Valid link", func(t *testing.T) {
+		repository.On("CountDownload", ctx, 7, 5).Return(nil).Once()
+
+		_, file, err := service.OpenDownload(ctx, id, expires, signature, "")
+		require.NoError(t, err)
+		content, err := io.ReadAll(file)
+		require.NoError(t, err)
+		require.NoError(t, file.Close())
+		assert.Equal(t, "ebook", string(content))
+	})
+
+	t.Run("Resume of an interrupted transfer is not counted", func(t *testing.T) {
+		repository.On("ResumeDownload", ctx, 7, int64(3)).Return(true, nil).Once()
+
+		_, file, err := service.OpenDownload(ctx, id, expires, signature, "bytes=3-")
+		require.NoError(t, err)
+		require.NoError(t, file.Close())
+	})
+
+	t.Run("Range without an interrupted transfer is counted", func(t *testing.T) {
+		repository.On("ResumeDownload", ctx, 7, int64(1)).Return(false, nil).Once()
+		repository.On("CountDownload", ctx, 7, 5).Return(nil).Once()
+
+		_, file, err := service.OpenDownload(ctx, id, expires, signature, "bytes=1-")
+		require.NoError(t, err)
+		require.NoError(t, file.Close())
+	})
+
+	t.Run("Repeated resumes are counted", func(t *testing.T) {
+		repository.On("ResumeDownload", ctx, 7, int64(3)).Return(true, nil).Once()
+		repository.On("ResumeDownload", ctx, 7, int64(3)).Return(false, nil).Twice()
+		repository.On("CountDownload", ctx, 7, 5).Return(nil).Twice()
+
+		for range 3 {
+			_, file, err := service.OpenDownload(ctx, id, expires, signature, "bytes=3-")
+			require.NoError(t, err)
+			require.NoError(t, file.Close())
+		}
+	})
+
+	t.Run("Ranges that cannot resume are counted", func(t *testing.T) {
+		repository.On("CountDownload", ctx, 7, 5).Return(nil).Times(4)
+
+		for _, rangeHeader := range []string{"bytes=0-", "bytes= 0-", "bytes=0-0", "bytes=1-2,3-"} {
+			_, file, err := service.OpenDownload(ctx, id, expires, signature, rangeHeader)
+			require.NoError(t, err)
+			require.NoError(t, file.Close())
+		}
+	})
+
+	t.Run("Limit reached", func(t *testing.T) {
+		repository.On("CountDownload", ctx, 7, 5).Return(domain.ErrDownloadLimitReached).Once()
+
+		_, _, err := service.OpenDownload(ctx, id, expires, signature, "")
+		assert.ErrorIs(t, err, domain.ErrDownloadLimitReached)
+	})
+
+	t.Run("Tampered link", func(t *testing.T) {
+		_, _, err := service.OpenDownload(ctx, id, expires+3600, signature, "")
+		assert.ErrorIs(t, err, domain.ErrInvalidDownloadLink)
+		_, _, err = service.OpenDownload(ctx, 8, expires, signature, "")
+		assert.ErrorIs(t, err, domain.ErrInvalidDownloadLink)
+	})
+
+	t.Run("Expired link", func(t *testing.T) {
+		service.now = func() time.Time { return time.Unix(expires+1, 0) }
+		_, _, err := service.OpenDownload(ctx, id, expires, signature, "")
+		assert.ErrorIs(t, err, domain.ErrInvalidDownloadLink)
+	})
+
+	repository.AssertExpectations(t)
+}
+
+func TestDownloadService_RecordTransfer(t *testing.T) {
+	service, repository, download := newDownloadTestService(t)
+	ctx := context.Background()
+
+	// the file is 5 bytes long
+	repository.On("SetResumeOffset", ctx, 7, int64(1)).Return(nil).Once()
+	require.NoError(t, service.RecordTransfer(ctx, download, "bytes=0-0", 1))
+	repository.On("SetResumeOffset", ctx, 7, int64(3)).Return(nil).Once()
+	require.NoError(t, service.RecordTransfer(ctx, download, "", 3))
+	repository.On("SetResumeOffset", ctx, 7, int64(0)).Return(nil).Twice()
+	require.NoError(t, service.RecordTransfer(ctx, download, "bytes=1-", 4))
+	require.NoError(t, service.RecordTransfer(ctx, download, "", 5))
+	require.NoError(t, service.RecordTransfer(ctx, download, "bytes=-2", 2))
+
+	repository.AssertExpectations(t)
+}
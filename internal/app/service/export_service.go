@@ -0,0 +1,183 @@
+package service
+
+import (
+	"context"
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log/slog"
+	"strconv"
+	"time"
+	"toptal/internal/app/domain"
+	"toptal/internal/pkg/xlsx"
+)
+
+// exportColumns are the columns of CSV and XLSX exports. They are a superset of the import
+// columns, so an export can be imported again.
+var exportColumns = []string{
+	"id", "isbn", "title", "author", "year", "price", "currency", "sale_price", "sale_ends_at",
+	"stock", "category", "archived_at",
+}
+
+// exportRow is a line of a JSON Lines export, shaped like a row of a JSON Lines import.
+type exportRow struct {
+	Id         int           `json:"id"`
+	ISBN       string        `json:"isbn,omitempty"`
+	Title      string        `json:"title"`
+	Author     string        `json:"author"`
+	Year       int           `json:"year"`
+	Price      domain.Money  `json:"price"`
+	SalePrice  *domain.Money `json:"sale_price,omitempty"`
+	SaleEndsAt *time.Time    `json:"sale_ends_at,omitempty"`
+	Stock      int           `json:"stock"`
+	Category   string        `json:"category"`
+	ArchivedAt *time.Time    `json:"archived_at,omitempty"`
+}
+
+// bookWriter writes the books of an export in one format. Close finishes the file.
+type bookWriter interface {
+	Write(book domain.ExportedBook) error
+	Close() error
+}
+
+type ExportService struct {
+	bookRepository BookRepository
+}
+
+func NewExportService(bookRepository BookRepository) *ExportService {
+	return &ExportService{bookRepository: bookRepository}
+}
+
+// ExportBooks writes the books the filter selects to w as CSV, JSON Lines or XLSX. Books
+// are streamed from the database, so an error can leave w with part of the export.
+func (s *ExportService) ExportBooks(ctx context.Context, w io.Writer, format string, filter domain.BookExportFilter) error {
+	writer, err := newBookWriter(w, format)
+	if err != nil {
+		return err
+	}
+	count := 0
+	err = s.bookRepository.ExportBooks(ctx, filter, func(book domain.ExportedBook) error {
+		count++
+		return writer.Write(book)
+	})
+	if err != nil {
+		return fmt.Errorf("failed to export books: %w", err)
+	}
+	if err := writer.Close(); err != nil {
+		return fmt.Errorf("failed to export books: %w", err)
+	}
+	slog.Info("Catalog exported", "format", format, "books", count)
+	return nil
+}
+
+func newBookWriter(w io.Writer, format string) (bookWriter, error) {
+	switch format {
+	case domain.ExportFormatCSV:
+		writer := csv.NewWriter(w)
+		return &csvBookWriter{writer}, writer.Write(exportColumns)
+	case domain.ExportFormatJSONL:
+		return &jsonlBookWriter{json.NewEncoder(w)}, nil
+	case domain.ExportFormatXLSX:
+		writer, err := xlsx.NewWriter(w, "Books")
+		if err != nil {
+			return nil, err
+		}
+		header := make([]any, len(exportColumns))
+		for i, column := range exportColumns {
+			header[i] = column
+		}
+		return &xlsxBookWriter{writer}, writer.WriteRow(header...)
+	default:
+		return nil, fmt.Errorf("%w: %q, use %s, %s or %s", domain.ErrInvalidExport, format,
+			domain.ExportFormatCSV, domain.ExportFormatJSONL, domain.ExportFormatXLSX)
+	}
+}
+
+type csvBookWriter struct {
+	writer *csv.Writer
+}
+
+func (w *csvBookWriter) Write(exported domain.ExportedBook) error {
+	book := exported.Book()
+	salePrice, saleEndsAt := "", ""
+	if sale, ok := book.SalePrice(); ok {
+		salePrice = sale.Decimal()
+		saleEndsAt = formatExportTime(book.SaleEndsAt())
+	}
+	return w.writer.Write([]string{
+		strconv.Itoa(book.Id()), book.ISBN(), book.Title(), book.Author(), strconv.Itoa(book.Year()),
+		book.Price().Decimal(), book.Price().Currency(), salePrice, saleEndsAt,
+		strconv.Itoa(book.Stock()), exported.CategoryName(), formatExportTime(book.ArchivedAt()),
+	})
+}
+
+func (w *csvBookWriter) Close() error {
+	w.writer.Flush()
+	return w.writer.Error()
+}
+
+type jsonlBookWriter struct {
+	encoder *json.Encoder
+}
+
+func (w *jsonlBookWriter) Write(exported domain.ExportedBook) error {
+	book := exported.Book()
+	row := exportRow{
+		Id:         book.Id(),
+		ISBN:       book.ISBN(),
+		Title:      book.Title(),
+		Author:     book.Author(),
+		Year:       book.Year(),
+		Price:      book.Price(),
+		Stock:      book.Stock(),
+		Category:   exported.CategoryName(),
+		ArchivedAt: optionalTime(book.ArchivedAt()),
+	}
+	if sale, ok := book.SalePrice(); ok {
+		row.SalePrice = &sale
+		row.SaleEndsAt = optionalTime(book.SaleEndsAt())
+	}
+	return w.encoder.Encode(row)
+}
+
+func (w *jsonlBookWriter) Close() error {
+	return nil
+}
+
+type xlsxBookWriter struct {
+	writer *xlsx.Writer
+}
+
+func (w *xlsxBookWriter) Write(exported domain.ExportedBook) error {
+	book := exported.Book()
+	var salePrice any
+	saleEndsAt := ""
+	if sale, ok := book.SalePrice(); ok {
+		salePrice = xlsx.Number(sale.Decimal())
+		saleEndsAt = formatExportTime(book.SaleEndsAt())
+	}
+	return w.writer.WriteRow(
+		book.Id(), book.ISBN(), book.Title(), book.Author(), book.Year(),
+		xlsx.Number(book.Price().Decimal()), book.Price().Currency(), salePrice, saleEndsAt,
+		book.Stock(), exported.CategoryName(), formatExportTime(book.ArchivedAt()),
+	)
+}
+
+func (w *xlsxBookWriter) Close() error {
+	return w.writer.Close()
+}
+
+func formatExportTime(t time.Time) string {
+	if t.IsZero() {
+		return ""
+	}
+	return t.UTC().Format(time.RFC3339)
+}
+
+func optionalTime(t time.Time) *time.Time {
+	if t.IsZero() {
+		return nil
+	}
+	return &t
+}
@@ -0,0 +1,125 @@
+package service
+
+import (
+	"archive/zip"
+	"bytes"
+	"context"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+	"toptal/internal/app/domain"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+// MockBookRepository only implements the export, GetById and the purge.
+type MockBookRepository struct {
+	BookRepository
+	mock.Mock
+}
+
+func (m *MockBookRepository) ExportBooks(
+	ctx context.Context, filter domain.BookExportFilter, fn func(domain.ExportedBook) error,
+) error {
+	args := m.Called(ctx, filter)
+	for _, book := range args.Get(0).([]domain.ExportedBook) {
+		if err := fn(book); err != nil {
+			return err
+		}
+	}
+	return args.Error(1)
+}
+
+func (m *MockBookRepository) GetById(ctx context.Context, id int) (domain.Book, error) {
+	args := m.Called(ctx, id)
+	return args.Get(0).(domain.Book), args.Error(1)
+}
+
+func (m *MockBookRepository) PurgeArchived(ctx context.Context, archivedBefore time.Time) ([]domain.PurgedBook, error) {
+	args := m.Called(ctx, archivedBefore)
+	return args.Get(0).([]domain.PurgedBook), args.Error(1)
+}
+
+func newExportTestService(t *testing.T) (*ExportService, *MockBookRepository) {
+	price, err := domain.ParseMoney("9.99", "USD")
+	require.NoError(t, err)
+	dune, err := domain.NewBook(1, "Dune", 1965, "Frank Herbert", price, 3, 2)
+	require.NoError(t, err)
+	require.NoError(t, dune.SetISBN("9780441172719"))
+	sale, err := domain.ParseMoney("7.50", "USD")
+	require.NoError(t, err)
+	require.NoError(t, dune.SetSale(sale, time.Date(2030, 1, 1, 0, 0, 0, 0, time.UTC)))
+	emma, err := domain.NewBook(4, "Emma, a novel", 1815, "Jane Austen", price, 0, 2)
+	require.NoError(t, err)
+
+	books := &MockBookRepository{}
+	books.On("ExportBooks", mock.Anything, mock.Anything).Return([]domain.ExportedBook{
+		domain.NewExportedBook(dune, "Fiction"),
+		domain.NewExportedBook(emma, "Fiction"),
+	}, nil)
+	return NewExportService(books), books
+}
+
+func TestExportService_ExportBooksCSV(t *testing.T) {
+	service, books := newExportTestService(t)
+	filter := domain.BookExportFilter{CategoryIds: []int{2}}
+
+	var buf bytes.Buffer
+	require.NoError(t, service.ExportBooks(context.Background(), &buf, domain.ExportFormatCSV, filter))
+
+	assert.Equal(t, strings.Join([]string{
+		"id,isbn,title,author,year,price,currency,sale_price,sale_ends_at,stock,category,archived_at",
+		"1,9780441172719,Dune,Frank Herbert,1965,9.99,USD,7.50,2030-01-01T00:00:00Z,3,Fiction,",
+		`4,,"Emma, a novel",Jane Austen,1815,9.99,USD,,,0,Fiction,`,
+		"",
+	}, "\n"), buf.String())
+	books.AssertCalled(t, "ExportBooks", mock.Anything, filter)
+}
+
+func TestExportService_ExportBooksJSONL(t *testing.T) {
+	service, _ := newExportTestService(t)
+
+	var buf bytes.Buffer
+	require.NoError(t, service.ExportBooks(context.Background(), &buf, domain.ExportFormatJSONL, domain.BookExportFilter{}))
+
+	lines := strings.Split(strings.TrimSpace(buf.String()), "\n")
+	require.Len(t, lines, 2)
+	assert.JSONEq(t, `{"id":1,"isbn":"9780441172719","title":"Dune","author":"Frank Herbert","year":1965,
+		"price":{"amount":"9.99","currency":"USD"},"sale_price":{"amount":"7.50","currency":"USD"},
+		"sale_ends_at":"2030-01-01T00:00:00Z","stock":3,"category":"Fiction"}`, lines[0])
+	assert.JSONEq(t, `{"id":4,"title":"Emma, a novel","author":"Jane Austen","year":1815,
+		"price":{"amount":"9.99","currency":"USD"},"stock":0,"category":"Fiction"}`, lines[1])
+}
+
+func TestExportService_ExportBooksXLSX(t *testing.T) {
+	service, _ := newExportTestService(t)
+
+	var buf bytes.Buffer
+	require.NoError(t, service.ExportBooks(context.Background(), &buf, domain.ExportFormatXLSX, domain.BookExportFilter{}))
+
+	archive, err := zip.NewReader(bytes.NewReader(buf.Bytes()), int64(buf.Len()))
+	require.NoError(t, err)
+	sheet, err := archive.Open("xl/worksheets/sheet1.xml")
+	require.NoError(t, err)
+	defer sheet.Close()
+	var content bytes.Buffer
+	_, err = content.ReadFrom(sheet)
+	require.NoError(t, err)
+	assert.Contains(t, content.String(), `<c r="F2"><v>9.99</v></c>`)
+	assert.Contains(t, content.String(), `<row r="3">`)
+}
+
+func TestExportService_ExportBooksErrors(t *testing.T) {
+	service, _ := newExportTestService(t)
+	err := service.ExportBooks(context.Background(), &bytes.Buffer{}, "pdf", domain.BookExportFilter{})
+	assert.ErrorIs(t, err, domain.ErrInvalidExport)
+
+	books := &MockBookRepository{}
+	dbErr := errors.New("connection reset")
+	books.On("ExportBooks", mock.Anything, mock.Anything).Return([]domain.ExportedBook{}, dbErr)
+	err = NewExportService(books).ExportBooks(context.Background(), &bytes.Buffer{}, domain.ExportFormatCSV, domain.BookExportFilter{})
+	assert.ErrorIs(t, err, dbErr)
+}
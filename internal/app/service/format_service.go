@@ -0,0 +1,138 @@
+package service
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"log/slog"
+	"path"
+	"strings"
+	"toptal/internal/app/config"
+	"toptal/internal/app/domain"
+	"toptal/internal/pkg/blob"
+)
+
+type FormatService struct {
+	formatRepository FormatRepository
+	blobStore        blob.Store
+	config           *config.DownloadConfig
+}
+
+func NewFormatService(repository FormatRepository, blobStore blob.Store, cfg *config.DownloadConfig) *FormatService {
+	return &FormatService{formatRepository: repository, blobStore: blobStore, config: cfg}
+}
+
+func (s *FormatService) GetFormats(ctx context.Context, bookId int) ([]domain.BookFormat, error) {
+	return s.formatRepository.FindFormats(ctx, bookId)
+}
+
+// SaveFormat adds a format to a book or changes its price and stock.
+func (s *FormatService) SaveFormat(ctx context.Context, format domain.BookFormat) (domain.BookFormat, error) {
+	saved, err := s.formatRepository.SaveFormat(ctx, format, auditActor(ctx))
+	if err != nil {
+		return domain.BookFormat{}, err
+	}
+	slog.Info("Book format saved", "book_id", saved.BookId(), "format", saved.Format())
+	return saved, nil
+}
+
+// DeleteFormat stops selling a book in a format. Its file is removed unless buyers can
+// still download it.
+func (s *FormatService) DeleteFormat(ctx context.Context, bookId int, format string) error {
+	deleted, err := s.formatRepository.DeleteFormat(ctx, bookId, format, auditActor(ctx))
+	if err != nil {
+		return err
+	}
+	if file, ok := deleted.File(); ok {
+		s.releaseBlob(ctx, file.Key())
+	}
+	return nil
+}
+
+// UploadFile stores the file of a digital format, replacing the one it had. name is the
+// file name buyers download it as; contentType defaults to the one of the format. Files
+// larger than the configured maximum are refused with domain.ErrFileTooLarge.
+func (s *FormatService) UploadFile(
+	ctx context.Context, bookId int, format string, name string, contentType string, content io.Reader,
+) (domain.BookFormat, error) {
+	if !domain.IsDigitalFormat(format) {
+		return domain.BookFormat{}, fmt.Errorf("%w: %q is not a digital format", domain.ErrInvalidFormat, format)
+	}
+	if _, err := s.formatRepository.FindFormat(ctx, bookId, format); err != nil {
+		return domain.BookFormat{}, err
+	}
+
+	name = path.Base(strings.ReplaceAll(strings.TrimSpace(name), `\`, "/"))
+	if name == "." || name == "/" {
+		name = fmt.Sprintf("book-%d.%s", bookId, format)
+	}
+	if contentType == "" {
+		contentType = domain.FormatContentType(format)
+	}
+	suffix, err := randomKey()
+	if err != nil {
+		return domain.BookFormat{}, err
+	}
+	key := fmt.Sprintf("books/%d/%s/%s.%s", bookId, format, suffix, format)
+
+	// One byte more than allowed tells a file at the limit from a larger one.
+	size, err := s.blobStore.Put(ctx, key, io.LimitReader(content, s.config.MaxFileSize+1))
+	if err != nil {
+		return domain.BookFormat{}, fmt.Errorf("failed to store file: %w", err)
+	}
+	file, err := domain.NewFormatFile(key, name, contentType, size)
+	switch {
+	case err != nil:
+	case size == 0:
+		err = fmt.Errorf("%w: the file is empty", domain.ErrInvalidFormat)
+	case size > s.config.MaxFileSize:
+		err = fmt.Errorf("%w: files may have up to %d bytes", domain.ErrFileTooLarge, s.config.MaxFileSize)
+	}
+	if err != nil {
+		s.deleteBlob(ctx, key)
+		return domain.BookFormat{}, err
+	}
+
+	updated, err := s.formatRepository.SetFormatFile(ctx, bookId, format, file, auditActor(ctx))
+	if err != nil {
+		s.deleteBlob(ctx, key)
+		return domain.BookFormat{}, err
+	}
+	if previous, ok := updated.File(); ok {
+		s.releaseBlob(ctx, previous.Key())
+	}
+	_ = updated.SetFile(file)
+
+	slog.Info("Book format file uploaded", "book_id", bookId, "format", format, "size", size)
+	return updated, nil
+}
+
+// releaseBlob removes a file the format no longer points at, unless a download still
+// uses it.
+func (s *FormatService) releaseBlob(ctx context.Context, key string) {
+	inUse, err := s.formatRepository.IsFileInUse(ctx, key)
+	if err != nil {
+		slog.Error("failed to check whether a book format file is used", "key", key, "error", err)
+		return
+	}
+	if !inUse {
+		s.deleteBlob(ctx, key)
+	}
+}
+
+// deleteBlob removes a file that is no longer used. A failure only leaves an orphaned file.
+func (s *FormatService) deleteBlob(ctx context.Context, key string) {
+	if err := s.blobStore.Delete(ctx, key); err != nil {
+		slog.Error("failed to delete book format file", "key", key, "error", err)
+	}
+}
+
+func randomKey() (string, error) {
+	raw := make([]byte, 16)
+	if _, err := rand.Read(raw); err != nil {
+		return "", fmt.Errorf("failed to generate file key: %w", err)
+	}
+	return hex.EncodeToString(raw), nil
+}
@@ -0,0 +1,91 @@
+package service
+
+import (
+	"context"
+	"strings"
+	"testing"
+	"toptal/internal/app/config"
+	"toptal/internal/app/domain"
+	"toptal/internal/pkg/blob"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+type MockFormatRepository struct {
+	mock.Mock
+}
+
+func (m *MockFormatRepository) FindFormats(ctx context.Context, bookId int) ([]domain.BookFormat, error) {
+	args := m.Called(ctx, bookId)
+	return args.Get(0).([]domain.BookFormat), args.Error(1)
+}
+
+func (m *MockFormatRepository) FindFormat(ctx context.Context, bookId int, format string) (domain.BookFormat, error) {
+	args := m.Called(ctx, bookId, format)
+	return args.Get(0).(domain.BookFormat), args.Error(1)
+}
+
+func (m *MockFormatRepository) SaveFormat(ctx context.Context, format domain.BookFormat, actor domain.AuditActor) (domain.BookFormat, error) {
+	args := m.Called(ctx, format, actor)
+	return args.Get(0).(domain.BookFormat), args.Error(1)
+}
+
+func (m *MockFormatRepository) DeleteFormat(
+	ctx context.Context, bookId int, format string, actor domain.AuditActor,
+) (domain.BookFormat, error) {
+	args := m.Called(ctx, bookId, format, actor)
+	return args.Get(0).(domain.BookFormat), args.Error(1)
+}
+
+func (m *MockFormatRepository) SetFormatFile(
+	ctx context.Context, bookId int, format string, file domain.FormatFile, actor domain.AuditActor,
+) (domain.BookFormat, error) {
+	args := m.Called(ctx, bookId, format, file, actor)
+	return args.Get(0).(domain.BookFormat), args.Error(1)
+}
+
+func (m *MockFormatRepository) IsFileInUse(ctx context.Context, key string) (bool, error) {
+	args := m.Called(ctx, key)
+	return args.Bool(0), args.Error(1)
+}
+
+func TestFormatService_DeleteFormatKeepsFilesStillDownloaded(t *testing.T) {
+	store, err := blob.NewFileStore(t.TempDir())
+	require.NoError(t, err)
+	ctx := context.Background()
+
+	price, err := domain.ParseMoney("9.99", "USD")
+	require.NoError(t, err)
+	newEPUB := func(key string) domain.BookFormat {
+		_, err := store.Put(ctx, key, strings.NewReader("ebook"))
+		require.NoError(t, err)
+		format, err := domain.NewBookFormat(1, domain.FormatEPUB, price, 0)
+		require.NoError(t, err)
+		file, err := domain.NewFormatFile(key, "dune.epub", "application/epub+zip", 5)
+		require.NoError(t, err)
+		require.NoError(t, format.SetFile(file))
+		return format
+	}
+
+	repository := &MockFormatRepository{}
+	service := NewFormatService(repository, store, &config.DownloadConfig{MaxFileSize: 1 << 20})
+
+	bought := newEPUB("books/1/epub/bought.epub")
+	repository.On("DeleteFormat", ctx, 1, domain.FormatEPUB, mock.Anything).Return(bought, nil).Once()
+	repository.On("IsFileInUse", ctx, "books/1/epub/bought.epub").Return(true, nil)
+	require.NoError(t, service.DeleteFormat(ctx, 1, domain.FormatEPUB))
+	file, err := store.Open(ctx, "books/1/epub/bought.epub")
+	require.NoError(t, err, "files of downloads are kept")
+	require.NoError(t, file.Close())
+
+	unsold := newEPUB("books/1/epub/unsold.epub")
+	repository.On("DeleteFormat", ctx, 1, domain.FormatEPUB, mock.Anything).Return(unsold, nil).Once()
+	repository.On("IsFileInUse", ctx, "books/1/epub/unsold.epub").Return(false, nil)
+	require.NoError(t, service.DeleteFormat(ctx, 1, domain.FormatEPUB))
+	_, err = store.Open(ctx, "books/1/epub/unsold.epub")
+	assert.ErrorIs(t, err, blob.ErrNotFound)
+
+	repository.AssertExpectations(t)
+}
@@ -0,0 +1,242 @@
+package service
+
+import (
+	"bufio"
+	"context"
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"strconv"
+	"strings"
+	"toptal/internal/app/config"
+	"toptal/internal/app/domain"
+	"toptal/internal/app/handler/model"
+	"toptal/internal/pkg/validator"
+)
+
+// importColumns are the columns a CSV import needs in its header; isbn is optional.
+var importColumns = []string{"title", "author", "year", "price", "currency", "stock", "category"}
+
+// importRow is a row of a catalogue import. Its book details are checked like those of
+// the API, except that the category is given by name and a stock of zero is allowed.
+type importRow struct {
+	model.BookDetails
+	Stock    int    `json:"stock" validate:"min=0"`
+	Category string `json:"category" validate:"required,max=100"`
+	// Remove withdraws the book with the ISBN instead; nothing else is set.
+	Remove bool `json:"-"`
+	// KeepStock leaves the stock of an existing book as it is.
+	KeepStock bool `json:"-"`
+}
+
+type ImportService struct {
+	importRepository   ImportRepository
+	categoryRepository CategoryRepository
+	onix               *onixMapping
+}
+
+func NewImportService(
+	importRepository ImportRepository, categoryRepository CategoryRepository, cfg *config.ONIXConfig,
+) (*ImportService, error) {
+	mapping, err := newONIXMapping(cfg)
+	if err != nil {
+		return nil, err
+	}
+	return &ImportService{importRepository: importRepository, categoryRepository: categoryRepository, onix: mapping}, nil
+}
+
+// ImportBooks reads a CSV, JSON Lines or ONIX catalogue and creates, updates or archives
+// its books. Invalid rows are skipped and listed in the report; the others are imported
+// together. A dry run reports what the import would do without changing anything.
+func (s *ImportService) ImportBooks(ctx context.Context, r io.Reader, format string, dryRun bool) (domain.ImportReport, error) {
+	report := domain.NewImportReport(dryRun)
+	categories, err := s.categoryRepository.FindCategories(ctx)
+	if err != nil {
+		return report, err
+	}
+	categoryIds := make(map[string]int, len(categories))
+	for _, category := range categories {
+		categoryIds[strings.ToLower(category.Name())] = category.Id()
+	}
+
+	var books []domain.BookImport
+	var removals []domain.BookRemoval
+	isbnLines := make(map[string]int)
+	keyLines := make(map[string]int)
+	err = s.readImportRows(r, format, func(line int, row importRow, err error) {
+		report.AddRow()
+		if err != nil {
+			report.AddError(line, "%v", err)
+			return
+		}
+		if row.Remove {
+			isbn, err := domain.NormalizeISBN(row.ISBN)
+			if err != nil {
+				report.AddError(line, "%v", err)
+				return
+			}
+			if first, ok := isbnLines[isbn]; ok {
+				report.AddError(line, "ISBN %s is already on line %d", isbn, first)
+				return
+			}
+			isbnLines[isbn] = line
+			removals = append(removals, domain.NewBookRemoval(line, isbn))
+			return
+		}
+		book, err := toImportedBook(row, categoryIds)
+		if err != nil {
+			report.AddError(line, "%v", err)
+			return
+		}
+		if book.ISBN() != "" {
+			if first, ok := isbnLines[book.ISBN()]; ok {
+				report.AddError(line, "ISBN %s is already on line %d", book.ISBN(), first)
+				return
+			}
+			isbnLines[book.ISBN()] = line
+		} else {
+			key := strings.ToLower(book.Title()) + "\x1f" + strings.ToLower(book.Author())
+			if first, ok := keyLines[key]; ok {
+				report.AddError(line, "title and author are already on line %d", first)
+				return
+			}
+			keyLines[key] = line
+		}
+		imported := domain.NewBookImport(line, book)
+		if row.KeepStock {
+			imported = imported.WithoutStock()
+		}
+		books = append(books, imported)
+	})
+	if err != nil {
+		return report, err
+	}
+
+	if err := s.importRepository.ImportBooks(ctx, books, removals, &report, auditActor(ctx)); err != nil {
+		return report, fmt.Errorf("failed to import books: %w", err)
+	}
+	slog.Info("Catalog imported", "dry_run", dryRun, "rows", report.Rows(), "created", report.Created(),
+		"updated", report.Updated(), "unchanged", report.Unchanged(), "archived", report.Archived(),
+		"failed", len(report.Errors()))
+	return report, nil
+}
+
+func toImportedBook(row importRow, categoryIds map[string]int) (domain.Book, error) {
+	if err := validator.Validate(row); err != nil {
+		return domain.Book{}, err
+	}
+	categoryId, ok := categoryIds[strings.ToLower(row.Category)]
+	if !ok {
+		return domain.Book{}, fmt.Errorf("%w: no category named %q", domain.ErrInvalidCategory, row.Category)
+	}
+	book, err := domain.NewBook(1, row.Title, row.Year, row.Author, row.Price, row.Stock, categoryId)
+	if err != nil {
+		return book, err
+	}
+	err = book.SetISBN(row.ISBN)
+	return book, err
+}
+
+// readImportRows calls fn with every row of the file and the line it starts on. Rows that
+// cannot be read are passed with an error; a file that cannot be read at all returns one.
+func (s *ImportService) readImportRows(r io.Reader, format string, fn func(line int, row importRow, err error)) error {
+	switch format {
+	case domain.ImportFormatCSV:
+		return readImportCSV(r, fn)
+	case domain.ImportFormatJSONL:
+		return readImportJSONL(r, fn)
+	case domain.ImportFormatONIX:
+		return readImportONIX(r, s.onix, fn)
+	default:
+		return fmt.Errorf("%w: unknown format %q, use %s, %s or %s", domain.ErrInvalidImport, format,
+			domain.ImportFormatCSV, domain.ImportFormatJSONL, domain.ImportFormatONIX)
+	}
+}
+
+func readImportCSV(r io.Reader, fn func(line int, row importRow, err error)) error {
+	reader := csv.NewReader(r)
+	reader.FieldsPerRecord = -1
+	reader.TrimLeadingSpace = true
+
+	header, err := reader.Read()
+	if err != nil {
+		return fmt.Errorf("%w: failed to read the header: %v", domain.ErrInvalidImport, err)
+	}
+	columns := make(map[string]int, len(header))
+	for i, name := range header {
+		columns[strings.ToLower(strings.TrimSpace(name))] = i
+	}
+	for _, name := range importColumns {
+		if _, ok := columns[name]; !ok {
+			return fmt.Errorf("%w: the header has no %s column", domain.ErrInvalidImport, name)
+		}
+	}
+
+	for {
+		record, err := reader.Read()
+		if errors.Is(err, io.EOF) {
+			return nil
+		}
+		var parseErr *csv.ParseError
+		if errors.As(err, &parseErr) {
+			fn(parseErr.StartLine, importRow{}, parseErr.Err)
+			continue
+		}
+		if err != nil {
+			return fmt.Errorf("failed to read import: %w", err)
+		}
+		line, _ := reader.FieldPos(0)
+		row, err := parseImportRecord(record, columns)
+		fn(line, row, err)
+	}
+}
+
+func parseImportRecord(record []string, columns map[string]int) (importRow, error) {
+	field := func(name string) string {
+		i, ok := columns[name]
+		if !ok || i >= len(record) {
+			return ""
+		}
+		return strings.TrimSpace(record[i])
+	}
+
+	row := importRow{
+		BookDetails: model.BookDetails{ISBN: field("isbn"), Title: field("title"), Author: field("author")},
+		Category:    field("category"),
+	}
+	var err error
+	if row.Year, err = strconv.Atoi(field("year")); err != nil {
+		return row, fmt.Errorf("year %q is not a number", field("year"))
+	}
+	if row.Stock, err = strconv.Atoi(field("stock")); err != nil {
+		return row, fmt.Errorf("stock %q is not a number", field("stock"))
+	}
+	if row.Price, err = domain.ParseMoney(field("price"), strings.ToUpper(field("currency"))); err != nil {
+		return row, err
+	}
+	return row, nil
+}
+
+func readImportJSONL(r io.Reader, fn func(line int, row importRow, err error)) error {
+	reader := bufio.NewReader(r)
+	for line := 1; ; line++ {
+		data, err := reader.ReadBytes('\n')
+		if err != nil && !errors.Is(err, io.EOF) {
+			return fmt.Errorf("failed to read import: %w", err)
+		}
+		if trimmed := strings.TrimSpace(string(data)); trimmed != "" {
+			var row importRow
+			if err := json.Unmarshal([]byte(trimmed), &row); err != nil {
+				fn(line, row, fmt.Errorf("invalid JSON: %w", err))
+			} else {
+				fn(line, row, nil)
+			}
+		}
+		if errors.Is(err, io.EOF) {
+			return nil
+		}
+	}
+}
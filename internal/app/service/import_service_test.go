@@ -0,0 +1,212 @@
+package service
+
+import (
+	"context"
+	"strings"
+	"testing"
+	"time"
+	"toptal/internal/app/config"
+	"toptal/internal/app/domain"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+type MockImportRepository struct {
+	mock.Mock
+}
+
+func (m *MockImportRepository) ImportBooks(
+	ctx context.Context, books []domain.BookImport, removals []domain.BookRemoval,
+	report *domain.ImportReport, actor domain.AuditActor,
+) error {
+	args := m.Called(ctx, books, removals, report, actor)
+	for range books {
+		report.AddCreated()
+	}
+	for range removals {
+		report.AddArchived()
+	}
+	return args.Error(0)
+}
+
+// MockCategoryRepository only implements the lookups the import needs and the purge.
+type MockCategoryRepository struct {
+	CategoryRepository
+	mock.Mock
+}
+
+func (m *MockCategoryRepository) FindCategories(ctx context.Context) ([]domain.Category, error) {
+	args := m.Called(ctx)
+	return args.Get(0).([]domain.Category), args.Error(1)
+}
+
+func (m *MockCategoryRepository) PurgeArchived(ctx context.Context, archivedBefore time.Time) (int, error) {
+	args := m.Called(ctx, archivedBefore)
+	return args.Int(0), args.Error(1)
+}
+
+func newImportTestService(t *testing.T) (*ImportService, *MockImportRepository) {
+	fiction, err := domain.NewCategory(3, "Fiction")
+	require.NoError(t, err)
+	categories := &MockCategoryRepository{}
+	categories.On("FindCategories", mock.Anything).Return([]domain.Category{fiction}, nil)
+	imports := &MockImportRepository{}
+	service, err := NewImportService(imports, categories, &config.ONIXConfig{
+		SubjectCategories: []string{"10:FIC=Fiction", "10:FIC027=Romance", "93:FB=Fiction"},
+		Currency:          "USD",
+		PriceTypes:        []string{"01", "02"},
+	})
+	require.NoError(t, err)
+	return service, imports
+}
+
+func TestImportService_ImportBooksCSV(t *testing.T) {
+	service, imports := newImportTestService(t)
+	var imported []domain.BookImport
+	imports.On("ImportBooks", mock.Anything, mock.Anything, mock.Anything, mock.Anything, mock.Anything).
+		Run(func(args mock.Arguments) { imported = args.Get(1).([]domain.BookImport) }).
+		Return(nil)
+
+	file := strings.Join([]string{
+		"ISBN,Title,Author,Year,Price,Currency,Stock,Category",
+		"0-441-17271-7,Dune,Frank Herbert,1965,9.99,USD,3,fiction",
+		"9780441172719,Dune,Frank Herbert,1965,9.99,USD,3,Fiction",
+		",Emma,Jane Austen,1815,4.5,EUR,1,Fiction",
+		",Persuasion,Jane Austen,1817,abc,EUR,1,Fiction",
+		",Ulysses,James Joyce,1922,12.00,EUR,1,Poetry",
+		",Emma,Jane Austen,1815,5.00,EUR,2,Fiction",
+		",Walden,Henry David Thoreau,1854,3.00,USD,not a number,Fiction",
+	}, "\n")
+	report, err := service.ImportBooks(context.Background(), strings.NewReader(file), domain.ImportFormatCSV, true)
+	require.NoError(t, err)
+
+	assert.True(t, report.DryRun())
+	assert.Equal(t, 7, report.Rows())
+	assert.Equal(t, 2, report.Created())
+	require.Len(t, imported, 2)
+	dune := imported[0].Book()
+	assert.Equal(t, "9780441172719", dune.ISBN())
+	assert.Equal(t, 3, dune.CategoryId())
+	assert.Equal(t, int64(999), dune.Price().Amount())
+	assert.Equal(t, 4, imported[1].Line())
+
+	lines := make([]int, 0)
+	for _, rowErr := range report.Errors() {
+		lines = append(lines, rowErr.Line())
+	}
+	assert.Equal(t, []int{3, 5, 6, 7, 8}, lines)
+}
+
+func TestImportService_ImportBooksJSONL(t *testing.T) {
+	service, imports := newImportTestService(t)
+	imports.On("ImportBooks", mock.Anything, mock.Anything, mock.Anything, mock.Anything, mock.Anything).Return(nil)
+
+	file := `{"title":"Dune","author":"Frank Herbert","year":1965,"price":{"amount":"9.99","currency":"USD"},"stock":3,"category":"Fiction"}
+
+{"title":"","author":"Nobody","year":1965,"price":{"amount":"1.00","currency":"USD"},"stock":1,"category":"Fiction"}
+not json
+{"title":"Beowulf","author":"Unknown","year":1000,"price":{"amount":"1.00","currency":"USD"},"stock":1,"category":"Fiction"}
+`
+	report, err := service.ImportBooks(context.Background(), strings.NewReader(file), domain.ImportFormatJSONL, false)
+	require.NoError(t, err)
+
+	assert.Equal(t, 4, report.Rows())
+	assert.Equal(t, 1, report.Created())
+	errs := report.Errors()
+	require.Len(t, errs, 3)
+	assert.Equal(t, 3, errs[0].Line())
+	assert.Equal(t, 4, errs[1].Line())
+	assert.Equal(t, 5, errs[2].Line())
+}
+
+func TestImportService_ImportBooksMissingColumn(t *testing.T) {
+	service, _ := newImportTestService(t)
+
+	_, err := service.ImportBooks(context.Background(), strings.NewReader("title,author\nDune,Herbert\n"), domain.ImportFormatCSV, false)
+	assert.ErrorIs(t, err, domain.ErrInvalidImport)
+
+	_, err = service.ImportBooks(context.Background(), strings.NewReader(""), "xml", false)
+	assert.ErrorIs(t, err, domain.ErrInvalidImport)
+}
+
+func TestImportService_ImportBooksONIX(t *testing.T) {
+	service, imports := newImportTestService(t)
+	var imported []domain.BookImport
+	var removed []domain.BookRemoval
+	imports.On("ImportBooks", mock.Anything, mock.Anything, mock.Anything, mock.Anything, mock.Anything).
+		Run(func(args mock.Arguments) {
+			imported = args.Get(1).([]domain.BookImport)
+			removed = args.Get(2).([]domain.BookRemoval)
+		}).
+		Return(nil)
+
+	product := func(reference, isbn, notification, subject, supply string) string {
+		return `<Product><RecordReference>` + reference + `</RecordReference>
+<NotificationType>` + notification + `</NotificationType>
+<ProductIdentifier><ProductIDType>15</ProductIDType><IDValue>` + isbn + `</IDValue></ProductIdentifier>
+<DescriptiveDetail>
+<TitleDetail><TitleType>01</TitleType><TitleElement><TitleElementLevel>01</TitleElementLevel><TitleText>Dune</TitleText></TitleElement></TitleDetail>
+<Contributor><ContributorRole>A01</ContributorRole><PersonName>Frank Herbert</PersonName></Contributor>
+` + subject + `
+</DescriptiveDetail>
+<PublishingDetail><PublishingDate><PublishingDateRole>01</PublishingDateRole><Date>19650801</Date></PublishingDate></PublishingDetail>
+<ProductSupply><SupplyDetail>` + supply + `
+<Price><PriceType>02</PriceType><PriceAmount>10.99</PriceAmount><CurrencyCode>EUR</CurrencyCode></Price>
+<Price><PriceType>02</PriceType><PriceAmount>9.99</PriceAmount><CurrencyCode>USD</CurrencyCode></Price>
+</SupplyDetail></ProductSupply>
+</Product>
+`
+	}
+	file := `<?xml version="1.0" encoding="UTF-8"?>
+<ONIXMessage release="3.0">
+` + product("a", "9780441172719", "03",
+		`<Subject><SubjectSchemeIdentifier>10</SubjectSchemeIdentifier><SubjectCode>FIC028000</SubjectCode></Subject>`,
+		`<ProductAvailability>21</ProductAvailability><Stock><OnHand>4</OnHand></Stock>`) +
+		product("b", "9780399128967", "04",
+			`<Subject><SubjectSchemeIdentifier>93</SubjectSchemeIdentifier><SubjectCode>FBA</SubjectCode></Subject>`,
+			`<ProductAvailability>21</ProductAvailability>`) +
+		product("c", "9780425027066", "03",
+			`<Subject><SubjectSchemeIdentifier>10</SubjectSchemeIdentifier><SubjectCode>HIS000000</SubjectCode></Subject>`,
+			`<ProductAvailability>21</ProductAvailability>`) +
+		`<Product><RecordReference>d</RecordReference><NotificationType>05</NotificationType>
+<ProductIdentifier><ProductIDType>02</ProductIDType><IDValue>0441172717</IDValue></ProductIdentifier></Product>
+<Product><RecordReference>e</RecordReference><NotificationType>05</NotificationType>
+<ProductIdentifier><ProductIDType>15</ProductIDType><IDValue>9780441013593</IDValue></ProductIdentifier></Product>
+</ONIXMessage>`
+
+	report, err := service.ImportBooks(context.Background(), strings.NewReader(file), domain.ImportFormatONIX, false)
+	require.NoError(t, err)
+
+	assert.Equal(t, 5, report.Rows())
+	require.Len(t, imported, 2)
+	dune := imported[0].Book()
+	assert.Equal(t, "9780441172719", dune.ISBN())
+	assert.Equal(t, "Frank Herbert", dune.Author())
+	assert.Equal(t, 1965, dune.Year())
+	assert.Equal(t, 4, dune.Stock())
+	assert.Equal(t, int64(999), dune.Price().Amount())
+	assert.False(t, imported[0].KeepsStock())
+	assert.True(t, imported[1].KeepsStock())
+
+	require.Len(t, removed, 1)
+	assert.Equal(t, "9780441013593", removed[0].ISBN())
+	errs := report.Errors()
+	require.Len(t, errs, 2)
+	assert.Contains(t, errs[0].Message(), "no category")
+	assert.Contains(t, errs[1].Message(), "already on line")
+}
+
+func TestImportService_ImportBooksONIXRelease(t *testing.T) {
+	service, _ := newImportTestService(t)
+	_, err := service.ImportBooks(context.Background(),
+		strings.NewReader(`<ONIXMessage release="2.1"></ONIXMessage>`), domain.ImportFormatONIX, false)
+	assert.ErrorIs(t, err, domain.ErrInvalidImport)
+}
+
+func TestNewImportService_InvalidMapping(t *testing.T) {
+	_, err := NewImportService(&MockImportRepository{}, &MockCategoryRepository{},
+		&config.ONIXConfig{SubjectCategories: []string{"FIC=Fiction"}})
+	assert.Error(t, err)
+}
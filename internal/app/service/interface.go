@@ -2,35 +2,225 @@ package service
 
 import (
 	"context"
+	"time"
 	"toptal/internal/app/domain"
+	"toptal/internal/pkg/oidc"
 )
 
 type BookRepository interface {
-	Create(ctx context.Context, book domain.Book) error
+	Create(ctx context.Context, book domain.Book, actor domain.AuditActor) error
 	GetById(ctx context.Context, id int) (domain.Book, error)
-	GetByCategories(ctx context.Context, categoryIds []int, limit, offset int) ([]domain.Book, error)
-	Update(ctx context.Context, book domain.Book) error
-	Delete(ctx context.Context, id int) error
+	GetAvailable(ctx context.Context, filter domain.BookFilter, limit, offset int) ([]domain.Book, error)
+	Search(ctx context.Context, query string, limit, offset int) ([]domain.Book, error)
+	Update(ctx context.Context, book domain.Book, actor domain.AuditActor) error
+	Delete(ctx context.Context, id int, actor domain.AuditActor) error
+	Restore(ctx context.Context, id int, actor domain.AuditActor) (domain.Book, error)
+	GetArchived(ctx context.Context, limit, offset int) ([]domain.Book, error)
+	PurgeArchived(ctx context.Context, archivedBefore time.Time) ([]domain.PurgedBook, error)
+	ExportBooks(ctx context.Context, filter domain.BookExportFilter, fn func(domain.ExportedBook) error) error
+}
+
+type ImportRepository interface {
+	ImportBooks(
+		ctx context.Context, books []domain.BookImport, removals []domain.BookRemoval,
+		report *domain.ImportReport, actor domain.AuditActor,
+	) error
 }
 
 type CategoryRepository interface {
-	InsertCategory(ctx context.Context, book domain.Category) error
+	InsertCategory(ctx context.Context, category domain.Category, actor domain.AuditActor) error
 	FindCategoryById(ctx context.Context, id int) (domain.Category, error)
 	FindCategories(ctx context.Context) ([]domain.Category, error)
-	UpdateCategory(ctx context.Context, category domain.Category) error
-	DeleteCategory(ctx context.Context, id int) error
+	UpdateCategory(ctx context.Context, category domain.Category, actor domain.AuditActor) error
+	DeleteCategory(ctx context.Context, id int, actor domain.AuditActor) error
+	RestoreCategory(ctx context.Context, id int, actor domain.AuditActor) (domain.Category, error)
+	FindArchivedCategories(ctx context.Context) ([]domain.Category, error)
+	PurgeArchived(ctx context.Context, archivedBefore time.Time) (int, error)
 }
 
 type UserRepository interface {
 	FindUserByName(ctx context.Context, name string) (domain.User, error)
 	FindUserById(ctx context.Context, id int) (domain.User, error)
+	FindUserByEmail(ctx context.Context, email string) (domain.User, error)
 	CreateUser(ctx context.Context, user domain.User) error
+	UpgradePasswordHash(ctx context.Context, userId int, oldHash string, newHash string) error
+	UpdateUserProfile(ctx context.Context, user domain.User) error
+	ChangePassword(ctx context.Context, userId int, passwordHash string) error
+	DeleteUser(ctx context.Context, userId int) error
+	SetTOTPSecret(ctx context.Context, userId int, secret string) error
+	EnableTOTP(ctx context.Context, userId int, recoveryCodeHashes []string) error
+	DisableTOTP(ctx context.Context, userId int) error
+	ConsumeTOTPStep(ctx context.Context, userId int, step int64) (bool, error)
+	ConsumeRecoveryCode(ctx context.Context, userId int, codeHash string) (bool, error)
+}
+
+type PasswordHasher interface {
+	Hash(password string) (string, error)
+	Verify(encoded string, password string) (bool, error)
+	NeedsRehash(encoded string) bool
 }
 
 type CartRepository interface {
 	GetCart(ctx context.Context, userId int) ([]domain.Book, error)
-	AddToCart(ctx context.Context, userId int, bookId int) error
-	RemoveFromCart(ctx context.Context, userId int, bookId int) error
-	Purchase(ctx context.Context, userId int) error
+	AddToCart(ctx context.Context, userId int, bookId int, format string) error
+	RemoveFromCart(ctx context.Context, userId int, bookId int, format string) error
+	Purchase(
+		ctx context.Context, userId int, currency string, address domain.ShippingAddress,
+		taxOrder func([]domain.OrderItem) (domain.OrderTax, error),
+	) (domain.Order, error)
 	CleanExpiredCarts(ctx context.Context) error
 }
+
+type OIDCRepository interface {
+	InsertOIDCAuthRequest(ctx context.Context, request domain.OIDCAuthRequest) error
+	ConsumeOIDCAuthRequest(ctx context.Context, provider string, stateHash string) (domain.OIDCAuthRequest, error)
+	FindUserByIdentity(ctx context.Context, provider string, subject string) (domain.User, error)
+	CreateUserWithIdentity(ctx context.Context, user domain.User, identity domain.UserIdentity) (domain.User, error)
+	LinkIdentity(ctx context.Context, identity domain.UserIdentity) error
+	TouchIdentity(ctx context.Context, identity domain.UserIdentity) error
+}
+
+// OIDCProvider is an OpenID Connect provider users can sign in with.
+type OIDCProvider interface {
+	AuthCodeURL(ctx context.Context, state string, nonce string, verifier string) (string, error)
+	Exchange(ctx context.Context, code string, verifier string) (string, error)
+	VerifyIDToken(ctx context.Context, rawIDToken string, nonce string) (oidc.Claims, error)
+}
+
+type SessionRepository interface {
+	InsertSession(ctx context.Context, session domain.Session) (domain.Session, error)
+	FindSessionById(ctx context.Context, id int) (domain.Session, error)
+	FindSessionsByUser(ctx context.Context, userId int) ([]domain.Session, error)
+	TouchSession(ctx context.Context, id int) error
+	RevokeSession(ctx context.Context, userId int, id int) error
+	RevokeUserSessions(ctx context.Context, userId int, keepSessionId int, actor domain.AuditActor) (int, error)
+	InsertTwoFactorChallenge(ctx context.Context, userId int, expiresAt time.Time) (int, error)
+	AttemptTwoFactorChallenge(ctx context.Context, id int, userId int, maxAttempts int) (bool, error)
+	CompleteTwoFactorChallenge(ctx context.Context, id int) (bool, error)
+}
+
+type AuditRepository interface {
+	FindAuditEntries(ctx context.Context, filter domain.AuditFilter) ([]domain.AuditEntry, error)
+}
+
+type OrderRepository interface {
+	FindOrdersByUser(ctx context.Context, userId int) ([]domain.Order, error)
+}
+
+type APIKeyRepository interface {
+	InsertAPIKey(ctx context.Context, key domain.APIKey, actor domain.AuditActor) (domain.APIKey, error)
+	FindAPIKeyById(ctx context.Context, id int) (domain.APIKey, error)
+	FindAPIKeyByPrefix(ctx context.Context, prefix string) (domain.APIKey, error)
+	FindAPIKeys(ctx context.Context) ([]domain.APIKey, error)
+	RevokeAPIKey(ctx context.Context, id int, actor domain.AuditActor) error
+	TouchAPIKey(ctx context.Context, id int) error
+	RotateAPIKey(ctx context.Context, id int, replacement domain.APIKey, graceUntil time.Time, actor domain.AuditActor) (domain.APIKey, error)
+}
+
+type UserTokenRepository interface {
+	CreateUserToken(
+		ctx context.Context, userId int, purpose string, tokenHash string, email string, expiresAt time.Time, message domain.OutboxEmail,
+	) error
+	ResetPassword(ctx context.Context, tokenHash string, passwordHash string) (int, error)
+	VerifyEmail(ctx context.Context, tokenHash string) error
+}
+
+type OutboxRepository interface {
+	ClaimOutboxEmails(ctx context.Context, limit int, maxAttempts int, lease time.Duration) ([]domain.OutboxEmail, error)
+	MarkOutboxEmailSent(ctx context.Context, id int) error
+	MarkOutboxEmailFailed(ctx context.Context, id int, reason string, retryIn time.Duration) error
+}
+
+// EmailQueue queues emails for the outbox dispatcher.
+type EmailQueue interface {
+	QueueOutboxEmails(ctx context.Context, emails []domain.OutboxEmail) error
+}
+
+type PriceRepository interface {
+	InsertPriceChange(ctx context.Context, change domain.PriceChange, actor domain.AuditActor) (domain.PriceChange, error)
+	FindPriceChanges(ctx context.Context, bookId int) ([]domain.PriceChange, error)
+	CancelPriceChange(ctx context.Context, bookId int, id int, actor domain.AuditActor) error
+	FindPriceHistory(ctx context.Context, bookId int, limit, offset int) ([]domain.PriceHistoryEntry, error)
+	ApplyDuePriceChanges(ctx context.Context, now time.Time) (int, error)
+	FindListPrices(ctx context.Context, bookId int) ([]domain.Money, error)
+	FindListPricesByBooks(ctx context.Context, bookIds []int, currency string) (map[int]domain.Money, error)
+	SetListPrice(ctx context.Context, bookId int, price domain.Money, actor domain.AuditActor) error
+	DeleteListPrice(ctx context.Context, bookId int, currency string, actor domain.AuditActor) error
+}
+
+type FormatRepository interface {
+	FindFormats(ctx context.Context, bookId int) ([]domain.BookFormat, error)
+	FindFormat(ctx context.Context, bookId int, format string) (domain.BookFormat, error)
+	SaveFormat(ctx context.Context, format domain.BookFormat, actor domain.AuditActor) (domain.BookFormat, error)
+	DeleteFormat(ctx context.Context, bookId int, format string, actor domain.AuditActor) (domain.BookFormat, error)
+	SetFormatFile(
+		ctx context.Context, bookId int, format string, file domain.FormatFile, actor domain.AuditActor,
+	) (domain.BookFormat, error)
+	IsFileInUse(ctx context.Context, key string) (bool, error)
+}
+
+type CoverRepository interface {
+	FindCover(ctx context.Context, bookId int) (domain.Cover, error)
+	SetCover(ctx context.Context, bookId int, cover domain.Cover, actor domain.AuditActor) (domain.Book, error)
+	RemoveCover(ctx context.Context, bookId int, actor domain.AuditActor) (domain.Book, error)
+}
+
+type DownloadRepository interface {
+	FindDownloadsByUser(ctx context.Context, userId int) ([]domain.Download, error)
+	FindDownload(ctx context.Context, id int) (domain.Download, error)
+	CountDownload(ctx context.Context, id int, limit int) error
+	ResumeDownload(ctx context.Context, id int, offset int64) (bool, error)
+	SetResumeOffset(ctx context.Context, id int, offset int64) error
+}
+
+type RecommendationRepository interface {
+	RefreshAffinity(ctx context.Context, batchSize int) (int, error)
+	FindRelatedBooks(ctx context.Context, bookId int, minScore, limit int) ([]domain.Book, error)
+	FindUserRecommendations(ctx context.Context, userId int, minScore, limit int) ([]domain.Book, error)
+	FindBestsellers(ctx context.Context, categoryIds []int, excludeIds []int, userId int, limit int) ([]domain.Book, error)
+	FindPurchasedCategories(ctx context.Context, userId int) ([]int, error)
+}
+
+type ChartRepository interface {
+	RefreshCharts(ctx context.Context, trendingDays, baselineDays int) (int, error)
+	FindBestsellers(ctx context.Context, period string, categoryId, limit, offset int) ([]domain.Book, error)
+	FindTrending(ctx context.Context, categoryId, limit, offset int) ([]domain.Book, error)
+	FindNewArrivals(ctx context.Context, categoryId, limit, offset int) ([]domain.Book, error)
+}
+
+type ReportRepository interface {
+	CategoryRevenue(ctx context.Context, filter domain.ReportFilter) ([]domain.CategoryRevenue, error)
+	BookSales(ctx context.Context, filter domain.ReportFilter) ([]domain.BookSales, error)
+	BasketSize(ctx context.Context, filter domain.ReportFilter) ([]domain.BasketSize, error)
+	StockTurnover(ctx context.Context, filter domain.ReportFilter) ([]domain.StockTurnover, error)
+}
+
+type StockAlertRepository interface {
+	FindLowStock(ctx context.Context, policy domain.ReorderPolicy) ([]domain.StockAlert, error)
+	ClearRestockedAlerts(ctx context.Context, defaultThreshold int) (int, error)
+	MarkNotified(ctx context.Context, alerts []domain.StockAlert) error
+	SetReorderThreshold(ctx context.Context, bookId int, threshold *int, actor domain.AuditActor) error
+}
+
+type SupplierRepository interface {
+	FindSuppliers(ctx context.Context) ([]domain.Supplier, error)
+	FindSupplierById(ctx context.Context, id int) (domain.Supplier, error)
+	InsertSupplier(ctx context.Context, supplier domain.Supplier, actor domain.AuditActor) (domain.Supplier, error)
+	UpdateSupplier(ctx context.Context, supplier domain.Supplier, actor domain.AuditActor) (domain.Supplier, error)
+	DeleteSupplier(ctx context.Context, id int, actor domain.AuditActor) error
+	FindBookSuppliers(ctx context.Context, bookId int) ([]domain.BookSupplier, error)
+	FindSupplierBooks(ctx context.Context, supplierId int, bookIds []int) ([]domain.BookSupplier, error)
+	SaveBookSupplier(ctx context.Context, bookSupplier domain.BookSupplier, actor domain.AuditActor) (domain.BookSupplier, error)
+	DeleteBookSupplier(ctx context.Context, bookId int, supplierId int, actor domain.AuditActor) error
+}
+
+type PurchaseOrderRepository interface {
+	InsertPurchaseOrder(ctx context.Context, order domain.PurchaseOrder, actor domain.AuditActor) (domain.PurchaseOrder, error)
+	FindPurchaseOrder(ctx context.Context, id int) (domain.PurchaseOrder, error)
+	FindPurchaseOrders(ctx context.Context, filter domain.PurchaseOrderFilter) ([]domain.PurchaseOrder, error)
+	ReplacePurchaseOrderLines(ctx context.Context, id int, lines []domain.PurchaseOrderLine, actor domain.AuditActor) (domain.PurchaseOrder, error)
+	SendPurchaseOrder(ctx context.Context, id int, actor domain.AuditActor) (domain.PurchaseOrder, error)
+	ReceivePurchaseOrder(ctx context.Context, id int, quantities map[int]int, actor domain.AuditActor) (domain.PurchaseOrder, error)
+	DeletePurchaseOrder(ctx context.Context, id int, actor domain.AuditActor) error
+	FindStockMovements(ctx context.Context, bookId int, limit, offset int) ([]domain.StockMovement, error)
+}
@@ -0,0 +1,235 @@
+package service
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"log/slog"
+	"strings"
+	"time"
+	"toptal/internal/app/config"
+	"toptal/internal/app/domain"
+	"toptal/internal/pkg/oidc"
+)
+
+const (
+	maxUsernameLength = 50
+	minUsernameLength = 3
+	// usernameAttempts bounds the random suffixes tried when a derived username is taken.
+	usernameAttempts = 5
+)
+
+type OIDCService struct {
+	providers      map[string]OIDCProvider
+	oidcRepository OIDCRepository
+	userRepository UserRepository
+	sessions       *SessionService
+	config         *config.OIDCConfig
+}
+
+func NewOIDCService(
+	providers map[string]OIDCProvider, oidcRepository OIDCRepository, userRepository UserRepository,
+	sessions *SessionService, cfg *config.OIDCConfig,
+) *OIDCService {
+	return &OIDCService{
+		providers:      providers,
+		oidcRepository: oidcRepository,
+		userRepository: userRepository,
+		sessions:       sessions,
+		config:         cfg,
+	}
+}
+
+// StartLogin begins the authorization code flow. It returns the provider URL to
+// redirect the user to and the state, which the caller binds to the browser.
+func (s *OIDCService) StartLogin(ctx context.Context, providerName string) (authURL string, state string, err error) {
+	provider, ok := s.providers[providerName]
+	if !ok {
+		return "", "", domain.ErrNotFound
+	}
+
+	values := make([]string, 3)
+	for i := range values {
+		if values[i], err = oidc.GenerateRandom(); err != nil {
+			return "", "", err
+		}
+	}
+	state, nonce, verifier := values[0], values[1], values[2]
+
+	request, err := domain.NewOIDCAuthRequest(providerName, hashUserToken(state), nonce, verifier, time.Now().Add(s.config.StateTTL))
+	if err != nil {
+		return "", "", err
+	}
+	if err := s.oidcRepository.InsertOIDCAuthRequest(ctx, request); err != nil {
+		return "", "", err
+	}
+
+	authURL, err = provider.AuthCodeURL(ctx, state, nonce, verifier)
+	if err != nil {
+		return "", "", fmt.Errorf("failed to build authorization url: %w", err)
+	}
+	return authURL, state, nil
+}
+
+// CompleteLogin handles the provider callback. The user linked to the external
+// identity is logged in exactly as with a password, including two-factor
+// authentication; unknown identities get a new account.
+func (s *OIDCService) CompleteLogin(ctx context.Context, providerName string, state string, code string) (domain.LoginResult, error) {
+	provider, ok := s.providers[providerName]
+	if !ok {
+		return domain.LoginResult{}, domain.ErrNotFound
+	}
+
+	request, err := s.oidcRepository.ConsumeOIDCAuthRequest(ctx, providerName, hashUserToken(state))
+	if err != nil {
+		return domain.LoginResult{}, err
+	}
+
+	rawIDToken, err := provider.Exchange(ctx, code, request.CodeVerifier())
+	if err != nil {
+		slog.Warn("OIDC code exchange failed", "provider", providerName, "error", err)
+		return domain.LoginResult{}, domain.ErrUnauthorized
+	}
+	claims, err := provider.VerifyIDToken(ctx, rawIDToken, request.Nonce())
+	if err != nil {
+		slog.Warn("OIDC id token rejected", "provider", providerName, "error", err)
+		return domain.LoginResult{}, domain.ErrUnauthorized
+	}
+
+	user, err := s.findOrCreateUser(ctx, providerName, claims)
+	if err != nil {
+		return domain.LoginResult{}, err
+	}
+	return s.sessions.loginResult(ctx, user)
+}
+
+func (s *OIDCService) findOrCreateUser(ctx context.Context, providerName string, claims oidc.Claims) (domain.User, error) {
+	email := strings.ToLower(strings.TrimSpace(claims.Email))
+	identity, err := domain.NewUserIdentity(0, providerName, claims.Subject, email)
+	if err != nil {
+		return domain.User{}, err
+	}
+
+	user, err := s.oidcRepository.FindUserByIdentity(ctx, providerName, claims.Subject)
+	if err == nil {
+		if err := s.oidcRepository.TouchIdentity(ctx, identity); err != nil {
+			slog.Error("failed to record oidc login", "user_id", user.Id(), "error", err)
+		}
+		return user, nil
+	}
+	if !errors.Is(err, domain.ErrNotFound) {
+		return domain.User{}, err
+	}
+
+	// Link to an existing account only when both sides have verified the address;
+	// otherwise whoever controls the provider account could take over the local one.
+	if email != "" {
+		existing, err := s.userRepository.FindUserByEmail(ctx, email)
+		switch {
+		case err == nil && claims.EmailVerified && existing.EmailVerified():
+			identity, err = domain.NewUserIdentity(existing.Id(), providerName, claims.Subject, email)
+			if err != nil {
+				return domain.User{}, err
+			}
+			if err := s.oidcRepository.LinkIdentity(ctx, identity); err != nil {
+				return domain.User{}, err
+			}
+			slog.Info("OIDC identity linked", "user_id", existing.Id(), "provider", providerName)
+			return existing, nil
+		case err == nil:
+			// The address belongs to someone else; create the account without it.
+			email = ""
+		case !errors.Is(err, domain.ErrNotFound):
+			return domain.User{}, err
+		}
+	}
+
+	return s.createUser(ctx, providerName, claims, email, identity)
+}
+
+func (s *OIDCService) createUser(
+	ctx context.Context, providerName string, claims oidc.Claims, email string, identity domain.UserIdentity,
+) (domain.User, error) {
+	base := deriveUsername(providerName, claims)
+	for attempt := 0; attempt < usernameAttempts; attempt++ {
+		username := base
+		if attempt > 0 {
+			suffix, err := randomSuffix()
+			if err != nil {
+				return domain.User{}, err
+			}
+			username = truncate(base, maxUsernameLength-len(suffix)-1) + "-" + suffix
+		}
+
+		user, err := domain.NewUserWithDefaultId(username, domain.UnusablePasswordHash)
+		if err != nil {
+			return domain.User{}, err
+		}
+		if err := user.SetEmail(email); err != nil {
+			// Providers may report addresses we do not accept; the account works without one.
+			slog.Warn("Ignoring email from OIDC provider", "provider", providerName, "error", err)
+		}
+		if user.Email() != "" {
+			if err := user.SetEmailVerified(claims.EmailVerified); err != nil {
+				return domain.User{}, err
+			}
+		}
+
+		created, err := s.oidcRepository.CreateUserWithIdentity(ctx, user, identity)
+		if err == nil {
+			slog.Info("User created from OIDC login", "user_id", created.Id(), "provider", providerName)
+			return created, nil
+		}
+		if !errors.Is(err, domain.ErrAlreadyExists) {
+			return domain.User{}, err
+		}
+
+		// A concurrent callback for the same identity may have won the race.
+		if existing, err := s.oidcRepository.FindUserByIdentity(ctx, providerName, claims.Subject); err == nil {
+			return existing, nil
+		}
+	}
+	return domain.User{}, fmt.Errorf("failed to find a free username for %q", base)
+}
+
+// deriveUsername prefers the provider's username, then the local part of the email,
+// and falls back to the provider name with part of the subject.
+func deriveUsername(providerName string, claims oidc.Claims) string {
+	candidates := []string{claims.PreferredUsername}
+	if local, _, found := strings.Cut(claims.Email, "@"); found {
+		candidates = append(candidates, local)
+	}
+	for _, candidate := range candidates {
+		if username := sanitizeUsername(candidate); len(username) >= minUsernameLength {
+			return username
+		}
+	}
+	return truncate(sanitizeUsername(providerName+"-"+claims.Subject), maxUsernameLength)
+}
+
+func sanitizeUsername(value string) string {
+	var b strings.Builder
+	for _, r := range strings.ToLower(value) {
+		if (r >= 'a' && r <= 'z') || (r >= '0' && r <= '9') || r == '.' || r == '_' || r == '-' {
+			b.WriteRune(r)
+		}
+	}
+	return truncate(b.String(), maxUsernameLength)
+}
+
+func truncate(value string, length int) string {
+	if len(value) > length {
+		return value[:length]
+	}
+	return value
+}
+
+func randomSuffix() (string, error) {
+	raw := make([]byte, 3)
+	if _, err := rand.Read(raw); err != nil {
+		return "", fmt.Errorf("failed to generate username suffix: %w", err)
+	}
+	return hex.EncodeToString(raw), nil
+}
@@ -0,0 +1,196 @@
+package service
+
+import (
+	"context"
+	"net/http"
+	"testing"
+	"time"
+	"toptal/internal/app/config"
+	"toptal/internal/app/domain"
+	"toptal/internal/pkg/oidc"
+	"toptal/internal/pkg/oidc/oidctest"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+type MockOIDCRepository struct {
+	mock.Mock
+}
+
+func (m *MockOIDCRepository) InsertOIDCAuthRequest(ctx context.Context, request domain.OIDCAuthRequest) error {
+	return m.Called(ctx, request).Error(0)
+}
+
+func (m *MockOIDCRepository) ConsumeOIDCAuthRequest(ctx context.Context, provider string, stateHash string) (domain.OIDCAuthRequest, error) {
+	args := m.Called(ctx, provider, stateHash)
+	return args.Get(0).(domain.OIDCAuthRequest), args.Error(1)
+}
+
+func (m *MockOIDCRepository) FindUserByIdentity(ctx context.Context, provider string, subject string) (domain.User, error) {
+	args := m.Called(ctx, provider, subject)
+	return args.Get(0).(domain.User), args.Error(1)
+}
+
+func (m *MockOIDCRepository) CreateUserWithIdentity(ctx context.Context, user domain.User, identity domain.UserIdentity) (domain.User, error) {
+	args := m.Called(ctx, user, identity)
+	return args.Get(0).(domain.User), args.Error(1)
+}
+
+func (m *MockOIDCRepository) LinkIdentity(ctx context.Context, identity domain.UserIdentity) error {
+	return m.Called(ctx, identity).Error(0)
+}
+
+func (m *MockOIDCRepository) TouchIdentity(ctx context.Context, identity domain.UserIdentity) error {
+	return m.Called(ctx, identity).Error(0)
+}
+
+// signInWithProvider runs the browser part of the flow against the test provider and
+// returns the state and code the callback receives.
+func signInWithProvider(
+	t *testing.T, service *OIDCService, repo *MockOIDCRepository, provider *oidctest.Provider, user oidctest.User,
+) (string, string) {
+	ctx := context.Background()
+
+	var request domain.OIDCAuthRequest
+	repo.On("InsertOIDCAuthRequest", ctx, mock.Anything).
+		Run(func(args mock.Arguments) { request = args.Get(1).(domain.OIDCAuthRequest) }).
+		Return(nil).Once()
+
+	authURL, state, err := service.StartLogin(ctx, "test")
+	require.NoError(t, err)
+	assert.Equal(t, hashUserToken(state), request.StateHash())
+	assert.NotContains(t, authURL, request.CodeVerifier())
+
+	callback, err := provider.Authorize(authURL, user)
+	require.NoError(t, err)
+	require.Equal(t, state, callback.Query().Get("state"))
+
+	repo.On("ConsumeOIDCAuthRequest", ctx, "test", request.StateHash()).Return(request, nil).Once()
+	return state, callback.Query().Get("code")
+}
+
+func newOIDCTestService(t *testing.T) (*OIDCService, *MockOIDCRepository, *MockUserRepository, *oidctest.Provider) {
+	provider, err := oidctest.NewProvider("shop", "secret")
+	require.NoError(t, err)
+	t.Cleanup(provider.Close)
+
+	client := oidc.NewClient(oidc.Config{
+		Issuer:       provider.Issuer(),
+		ClientID:     "shop",
+		ClientSecret: "secret",
+		RedirectURL:  "https://shop.example/oidc/test/callback",
+		Scopes:       []string{"openid", "email", "profile"},
+	}, http.DefaultClient)
+
+	repo := new(MockOIDCRepository)
+	users := new(MockUserRepository)
+	service := NewOIDCService(map[string]OIDCProvider{"test": client}, repo, users, newTestSessions(t), &config.OIDCConfig{StateTTL: time.Minute})
+	return service, repo, users, provider
+}
+
+func TestOIDCService_StartLogin(t *testing.T) {
+	service, _, _, _ := newOIDCTestService(t)
+
+	_, _, err := service.StartLogin(context.Background(), "unknown")
+	assert.ErrorIs(t, err, domain.ErrNotFound)
+}
+
+func TestOIDCService_CompleteLogin(t *testing.T) {
+	ctx := context.Background()
+
+	t.Run("Known identity logs in", func(t *testing.T) {
+		service, repo, _, provider := newOIDCTestService(t)
+		state, code := signInWithProvider(t, service, repo, provider, oidctest.User{Subject: "sub-1"})
+
+		user, err := domain.NewUser(3, "alice", domain.UnusablePasswordHash, false)
+		require.NoError(t, err)
+		repo.On("FindUserByIdentity", ctx, "test", "sub-1").Return(user, nil).Once()
+		repo.On("TouchIdentity", ctx, mock.Anything).Return(nil).Once()
+
+		result, err := service.CompleteLogin(ctx, "test", state, code)
+		require.NoError(t, err)
+		assert.NotEmpty(t, result.Token())
+		repo.AssertExpectations(t)
+	})
+
+	t.Run("Verified email links existing account", func(t *testing.T) {
+		service, repo, users, provider := newOIDCTestService(t)
+		state, code := signInWithProvider(t, service, repo, provider, oidctest.User{
+			Subject: "sub-2", Email: "Alice@Example.com", EmailVerified: true,
+		})
+
+		existing := newAccountTestUser(t, "alice@example.com")
+		require.NoError(t, existing.SetEmailVerified(true))
+		repo.On("FindUserByIdentity", ctx, "test", "sub-2").Return(domain.User{}, domain.ErrNotFound).Once()
+		users.On("FindUserByEmail", ctx, "alice@example.com").Return(existing, nil).Once()
+		repo.On("LinkIdentity", ctx, mock.MatchedBy(func(identity domain.UserIdentity) bool {
+			return identity.UserId() == existing.Id() && identity.Subject() == "sub-2"
+		})).Return(nil).Once()
+
+		_, err := service.CompleteLogin(ctx, "test", state, code)
+		require.NoError(t, err)
+		repo.AssertExpectations(t)
+	})
+
+	t.Run("Unverified email creates a separate account", func(t *testing.T) {
+		service, repo, users, provider := newOIDCTestService(t)
+		state, code := signInWithProvider(t, service, repo, provider, oidctest.User{
+			Subject: "sub-3", Email: "alice@example.com", PreferredUsername: "Alice Smith",
+		})
+
+		repo.On("FindUserByIdentity", ctx, "test", "sub-3").Return(domain.User{}, domain.ErrNotFound).Once()
+		users.On("FindUserByEmail", ctx, "alice@example.com").Return(newAccountTestUser(t, "alice@example.com"), nil).Once()
+
+		var created domain.User
+		repo.On("CreateUserWithIdentity", ctx, mock.Anything, mock.Anything).
+			Run(func(args mock.Arguments) { created = args.Get(1).(domain.User) }).
+			Return(newAccountTestUser(t, ""), nil).Once()
+
+		_, err := service.CompleteLogin(ctx, "test", state, code)
+		require.NoError(t, err)
+		repo.AssertNotCalled(t, "LinkIdentity", mock.Anything, mock.Anything)
+		assert.Equal(t, "alicesmith", created.Username())
+		assert.Empty(t, created.Email())
+		assert.False(t, created.HasPassword())
+	})
+
+	t.Run("Taken username gets a suffix", func(t *testing.T) {
+		service, repo, _, provider := newOIDCTestService(t)
+		state, code := signInWithProvider(t, service, repo, provider, oidctest.User{Subject: "sub-4", PreferredUsername: "bob"})
+
+		repo.On("FindUserByIdentity", ctx, "test", "sub-4").Return(domain.User{}, domain.ErrNotFound)
+		repo.On("CreateUserWithIdentity", ctx, mock.MatchedBy(func(user domain.User) bool { return user.Username() == "bob" }), mock.Anything).
+			Return(domain.User{}, domain.ErrAlreadyExists).Once()
+		repo.On("CreateUserWithIdentity", ctx, mock.MatchedBy(func(user domain.User) bool {
+			return len(user.Username()) == len("bob-")+6 && user.Username()[:4] == "bob-"
+		}), mock.Anything).Return(newAccountTestUser(t, ""), nil).Once()
+
+		_, err := service.CompleteLogin(ctx, "test", state, code)
+		require.NoError(t, err)
+		repo.AssertExpectations(t)
+	})
+
+	t.Run("Replayed code is rejected", func(t *testing.T) {
+		service, repo, _, provider := newOIDCTestService(t)
+		state, code := signInWithProvider(t, service, repo, provider, oidctest.User{Subject: "sub-5"})
+
+		user, err := domain.NewUser(5, "carol", domain.UnusablePasswordHash, false)
+		require.NoError(t, err)
+		repo.On("FindUserByIdentity", ctx, "test", "sub-5").Return(user, nil).Once()
+		repo.On("TouchIdentity", ctx, mock.Anything).Return(nil).Once()
+		_, err = service.CompleteLogin(ctx, "test", state, code)
+		require.NoError(t, err)
+
+		repo.On("ConsumeOIDCAuthRequest", ctx, "test", hashUserToken(state)).Return(domain.OIDCAuthRequest{}, domain.ErrInvalidToken).Once()
+		_, err = service.CompleteLogin(ctx, "test", state, code)
+		assert.ErrorIs(t, err, domain.ErrInvalidToken)
+	})
+}
+
+func TestDeriveUsername(t *testing.T) {
+	assert.Equal(t, "jdoe", deriveUsername("google", oidc.Claims{PreferredUsername: "JDoe"}))
+	assert.Equal(t, "jane.doe", deriveUsername("google", oidc.Claims{PreferredUsername: "jd", Email: "jane.doe@example.com"}))
+	assert.Equal(t, "google-1234", deriveUsername("google", oidc.Claims{Subject: "1234"}))
+}
@@ -0,0 +1,130 @@
+package service
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+	"toptal/internal/app/config"
+	"toptal/internal/app/domain"
+	"toptal/internal/app/handler/model"
+	"toptal/internal/pkg/onix"
+)
+
+// onixSubject maps the subjects of a scheme starting with code to a category.
+type onixSubject struct {
+	scheme   string
+	code     string
+	category string
+}
+
+// onixMapping turns ONIX products into import rows.
+type onixMapping struct {
+	subjects        []onixSubject
+	defaultCategory string
+	currency        string
+	priceTypes      []string
+}
+
+func newONIXMapping(cfg *config.ONIXConfig) (*onixMapping, error) {
+	mapping := &onixMapping{defaultCategory: cfg.DefaultCategory, currency: cfg.Currency, priceTypes: cfg.PriceTypes}
+	if mapping.currency != "" && !domain.IsCurrency(mapping.currency) {
+		return nil, fmt.Errorf("unknown ONIX currency %q", mapping.currency)
+	}
+	if len(mapping.priceTypes) == 0 {
+		mapping.priceTypes = []string{onix.PriceTypeRRPExcludingTax}
+	}
+	for _, entry := range cfg.SubjectCategories {
+		key, category, ok := strings.Cut(entry, "=")
+		scheme, code, ok2 := strings.Cut(key, ":")
+		if !ok || !ok2 || scheme == "" || code == "" || strings.TrimSpace(category) == "" {
+			return nil, fmt.Errorf("ONIX subject mapping %q is not scheme:code=Category", entry)
+		}
+		mapping.subjects = append(mapping.subjects, onixSubject{
+			scheme:   strings.TrimSpace(scheme),
+			code:     strings.ToUpper(strings.TrimSpace(code)),
+			category: strings.TrimSpace(category),
+		})
+	}
+	return mapping, nil
+}
+
+// category returns the category of the first subject that is mapped, taking the longest
+// code that matches it, or the default category.
+func (m *onixMapping) category(subjects []onix.Subject) string {
+	for _, subject := range subjects {
+		code := strings.ToUpper(strings.TrimSpace(subject.SubjectCode))
+		best := onixSubject{}
+		for _, candidate := range m.subjects {
+			if candidate.scheme == strings.TrimSpace(subject.SubjectSchemeIdentifier) &&
+				strings.HasPrefix(code, candidate.code) && len(candidate.code) > len(best.code) {
+				best = candidate
+			}
+		}
+		if best.category != "" {
+			return best.category
+		}
+	}
+	return m.defaultCategory
+}
+
+func (m *onixMapping) row(product onix.Product) (importRow, error) {
+	row := importRow{BookDetails: model.BookDetails{ISBN: product.ISBN()}}
+	if row.ISBN == "" {
+		return row, fmt.Errorf("product %q has no ISBN", product.RecordReference)
+	}
+	if product.Deleted() {
+		row.Remove = true
+		return row, nil
+	}
+
+	row.Title = product.Title()
+	row.Author = strings.Join(product.Authors(), ", ")
+	row.Year = product.PublicationYear()
+	if row.Year == 0 {
+		return row, fmt.Errorf("product %q has no publication date", product.RecordReference)
+	}
+	price, ok := product.Price(m.priceTypes, m.currency)
+	if !ok {
+		return row, fmt.Errorf("product %q has no price of type %s%s", product.RecordReference,
+			strings.Join(m.priceTypes, " or "), onixCurrencySuffix(m.currency))
+	}
+	var err error
+	row.Price, err = domain.ParseMoney(strings.TrimSpace(price.PriceAmount), strings.TrimSpace(price.CurrencyCode))
+	if err != nil {
+		return row, err
+	}
+	if onHand, ok := product.OnHand(); ok {
+		row.Stock = onHand
+	} else if !onix.Unavailable(product.Availability()) {
+		row.KeepStock = true
+	}
+	row.Category = m.category(product.Subjects())
+	if row.Category == "" {
+		return row, fmt.Errorf("%w: no category is mapped to the subjects of product %q",
+			domain.ErrInvalidCategory, product.RecordReference)
+	}
+	return row, nil
+}
+
+func onixCurrencySuffix(currency string) string {
+	if currency == "" {
+		return ""
+	}
+	return " in " + currency
+}
+
+func readImportONIX(r io.Reader, mapping *onixMapping, fn func(line int, row importRow, err error)) error {
+	reader := onix.NewReader(r)
+	for {
+		product, line, err := reader.Next()
+		if errors.Is(err, io.EOF) {
+			return nil
+		}
+		if err != nil {
+			return fmt.Errorf("%w: %v", domain.ErrInvalidImport, err)
+		}
+		row, err := mapping.row(product)
+		fn(line, row, err)
+	}
+}
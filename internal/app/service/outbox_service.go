@@ -0,0 +1,77 @@
+package service
+
+import (
+	"context"
+	"log/slog"
+	"time"
+	"toptal/internal/app/config"
+	"toptal/internal/pkg/mailer"
+)
+
+const (
+	outboxBatchSize = 50
+	// outboxLease hides claimed emails from other dispatchers while they are being sent.
+	outboxLease   = 5 * time.Minute
+	outboxBackoff = 30 * time.Second
+	outboxMaxWait = 6 * time.Hour
+)
+
+type OutboxService struct {
+	outboxRepository OutboxRepository
+	mailer           mailer.Mailer
+	config           *config.MailConfig
+}
+
+func NewOutboxService(repository OutboxRepository, m mailer.Mailer, cfg *config.MailConfig) *OutboxService {
+	return &OutboxService{outboxRepository: repository, mailer: m, config: cfg}
+}
+
+// DispatchPending sends the emails that are due and records the outcome of each.
+// Failed emails are retried with exponential backoff up to MaxAttempts times.
+func (s *OutboxService) DispatchPending(ctx context.Context) error {
+	emails, err := s.outboxRepository.ClaimOutboxEmails(ctx, outboxBatchSize, s.config.MaxAttempts, outboxLease)
+	if err != nil {
+		return err
+	}
+
+	for _, email := range emails {
+		message := mailer.Message{To: email.Recipient(), Subject: email.Subject(), Body: email.Body()}
+		if err := s.mailer.Send(ctx, message); err != nil {
+			slog.Error("Failed to send email", "id", email.Id(), "attempt", email.Attempts()+1, "error", err)
+			if err := s.outboxRepository.MarkOutboxEmailFailed(ctx, email.Id(), err.Error(), outboxRetryDelay(email.Attempts())); err != nil {
+				return err
+			}
+			continue
+		}
+		if err := s.outboxRepository.MarkOutboxEmailSent(ctx, email.Id()); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (s *OutboxService) StartOutboxDispatcherJob(ctx context.Context) {
+	ticker := time.NewTicker(s.config.DispatchInterval)
+	go func() {
+		defer ticker.Stop()
+		for {
+			select {
+			case <-ticker.C:
+				if err := s.DispatchPending(ctx); err != nil {
+					slog.Error(err.Error())
+				}
+			case <-ctx.Done():
+				return
+			}
+		}
+	}()
+	slog.Info("Outbox dispatcher job started", "interval seconds", s.config.DispatchInterval.Seconds())
+}
+
+func outboxRetryDelay(attempts int) time.Duration {
+	delay := outboxBackoff << attempts
+	if delay <= 0 || delay > outboxMaxWait {
+		return outboxMaxWait
+	}
+	return delay
+}
@@ -0,0 +1,129 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"time"
+	"toptal/internal/app/config"
+	"toptal/internal/app/domain"
+)
+
+const (
+	defaultPriceHistoryLimit = 50
+	maxPriceHistoryLimit     = 500
+)
+
+type PriceService struct {
+	priceRepository PriceRepository
+	config          *config.CatalogConfig
+}
+
+func NewPriceService(repository PriceRepository, cfg *config.CatalogConfig) *PriceService {
+	return &PriceService{priceRepository: repository, config: cfg}
+}
+
+// SchedulePriceChange stores a future price change or sale for the scheduler to apply.
+// A change starting in the past is applied on the scheduler's next run.
+func (s *PriceService) SchedulePriceChange(ctx context.Context, change domain.PriceChange) (domain.PriceChange, error) {
+	if change.IsSale() && !change.EndsAt().After(time.Now()) {
+		return domain.PriceChange{}, fmt.Errorf("%w: sale must end in the future", domain.ErrInvalidPriceChange)
+	}
+
+	created, err := s.priceRepository.InsertPriceChange(ctx, change, auditActor(ctx))
+	if err != nil {
+		return domain.PriceChange{}, err
+	}
+	slog.Info("Price change scheduled", "price_change_id", created.Id(), "book_id", created.BookId())
+	return created, nil
+}
+
+func (s *PriceService) GetPriceChanges(ctx context.Context, bookId int) ([]domain.PriceChange, error) {
+	return s.priceRepository.FindPriceChanges(ctx, bookId)
+}
+
+func (s *PriceService) CancelPriceChange(ctx context.Context, bookId int, id int) error {
+	return s.priceRepository.CancelPriceChange(ctx, bookId, id, auditActor(ctx))
+}
+
+// GetPriceHistory returns the book's price changes, newest first, at most
+// maxPriceHistoryLimit at a time.
+func (s *PriceService) GetPriceHistory(ctx context.Context, bookId int, limit, offset int) ([]domain.PriceHistoryEntry, error) {
+	if limit <= 0 {
+		limit = defaultPriceHistoryLimit
+	}
+	return s.priceRepository.FindPriceHistory(ctx, bookId, min(limit, maxPriceHistoryLimit), max(offset, 0))
+}
+
+// GetListPrices returns the prices of a book in currencies other than its own.
+func (s *PriceService) GetListPrices(ctx context.Context, bookId int) ([]domain.Money, error) {
+	return s.priceRepository.FindListPrices(ctx, bookId)
+}
+
+func (s *PriceService) SetListPrice(ctx context.Context, bookId int, price domain.Money) error {
+	if price.IsNegative() {
+		return fmt.Errorf("%w: price cannot be negative", domain.ErrInvalidMoney)
+	}
+	return s.priceRepository.SetListPrice(ctx, bookId, price, auditActor(ctx))
+}
+
+func (s *PriceService) DeleteListPrice(ctx context.Context, bookId int, currency string) error {
+	return s.priceRepository.DeleteListPrice(ctx, bookId, currency, auditActor(ctx))
+}
+
+func (s *PriceService) ApplyDuePriceChanges(ctx context.Context) error {
+	applied, err := s.priceRepository.ApplyDuePriceChanges(ctx, time.Now())
+	if err != nil {
+		return err
+	}
+	if applied > 0 {
+		slog.Info("Applied scheduled price changes", "count", applied)
+	}
+	return nil
+}
+
+func (s *PriceService) StartPriceSchedulerJob(ctx context.Context) {
+	ticker := time.NewTicker(s.config.PriceSchedulerInterval)
+	go func() {
+		defer ticker.Stop()
+		for {
+			select {
+			case <-ticker.C:
+				if err := s.ApplyDuePriceChanges(ctx); err != nil {
+					slog.Error("failed to apply scheduled price changes", "error", err)
+				}
+			case <-ctx.Done():
+				return
+			}
+		}
+	}()
+	slog.Info("Price scheduler job started", "interval seconds", s.config.PriceSchedulerInterval.Seconds())
+}
+
+// priceBooksIn prices the books from their price lists in the given currency. Books
+// priced in it already, or without a list price in it, keep their own price, as do books
+// in one of their formats.
+func priceBooksIn(ctx context.Context, repository PriceRepository, books []domain.Book, currency string) ([]domain.Book, error) {
+	bookIds := make([]int, 0, len(books))
+	for _, book := range books {
+		if book.Format() == "" && book.Price().Currency() != currency {
+			bookIds = append(bookIds, book.Id())
+		}
+	}
+	if currency == "" || len(bookIds) == 0 {
+		return books, nil
+	}
+
+	prices, err := repository.FindListPricesByBooks(ctx, bookIds, currency)
+	if err != nil {
+		return nil, err
+	}
+	priced := make([]domain.Book, len(books))
+	for i, book := range books {
+		priced[i] = book
+		if price, ok := prices[book.Id()]; ok && book.Format() == "" {
+			priced[i] = book.WithListPrice(price)
+		}
+	}
+	return priced, nil
+}
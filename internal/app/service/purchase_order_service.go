@@ -0,0 +1,161 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"toptal/internal/app/domain"
+)
+
+const (
+	defaultPurchaseOrderLimit = 50
+	maxPurchaseOrderLimit     = 500
+)
+
+// PurchaseOrderService drafts purchase orders at the cost prices of the supplier, sends
+// them, and adds the copies received to the stock.
+type PurchaseOrderService struct {
+	purchaseOrderRepository PurchaseOrderRepository
+	supplierRepository      SupplierRepository
+}
+
+func NewPurchaseOrderService(repository PurchaseOrderRepository, supplierRepository SupplierRepository) *PurchaseOrderService {
+	return &PurchaseOrderService{purchaseOrderRepository: repository, supplierRepository: supplierRepository}
+}
+
+// CreatePurchaseOrder drafts an order of books from a supplier. Each book must be
+// supplied by it, and is ordered at the cost price it charges.
+func (s *PurchaseOrderService) CreatePurchaseOrder(
+	ctx context.Context, supplierId int, quantities []domain.PurchaseQuantity,
+) (domain.PurchaseOrder, error) {
+	lines, err := s.orderLines(ctx, supplierId, quantities)
+	if err != nil {
+		return domain.PurchaseOrder{}, err
+	}
+	order, err := domain.NewPurchaseOrder(0, supplierId, domain.PurchaseOrderDraft, lines)
+	if err != nil {
+		return domain.PurchaseOrder{}, err
+	}
+
+	created, err := s.purchaseOrderRepository.InsertPurchaseOrder(ctx, order, auditActor(ctx))
+	if err != nil {
+		return domain.PurchaseOrder{}, err
+	}
+	slog.Info("Purchase order created", "purchase_order_id", created.Id(), "supplier_id", supplierId)
+	return created, nil
+}
+
+// UpdatePurchaseOrder replaces the books a draft orders, at the current cost prices.
+func (s *PurchaseOrderService) UpdatePurchaseOrder(
+	ctx context.Context, id int, quantities []domain.PurchaseQuantity,
+) (domain.PurchaseOrder, error) {
+	order, err := s.purchaseOrderRepository.FindPurchaseOrder(ctx, id)
+	if err != nil {
+		return domain.PurchaseOrder{}, err
+	}
+	if !order.IsDraft() {
+		return domain.PurchaseOrder{}, fmt.Errorf("%w: the order is %s", domain.ErrPurchaseOrderStatus, order.Status())
+	}
+	lines, err := s.orderLines(ctx, order.SupplierId(), quantities)
+	if err != nil {
+		return domain.PurchaseOrder{}, err
+	}
+	return s.purchaseOrderRepository.ReplacePurchaseOrderLines(ctx, id, lines, auditActor(ctx))
+}
+
+func (s *PurchaseOrderService) SendPurchaseOrder(ctx context.Context, id int) (domain.PurchaseOrder, error) {
+	order, err := s.purchaseOrderRepository.SendPurchaseOrder(ctx, id, auditActor(ctx))
+	if err != nil {
+		return domain.PurchaseOrder{}, err
+	}
+	slog.Info("Purchase order sent", "purchase_order_id", id)
+	return order, nil
+}
+
+// ReceivePurchaseOrder books a delivery and adds it to the stock. No quantities receives
+// everything outstanding.
+func (s *PurchaseOrderService) ReceivePurchaseOrder(
+	ctx context.Context, id int, quantities []domain.PurchaseQuantity,
+) (domain.PurchaseOrder, error) {
+	received := make(map[int]int, len(quantities))
+	for _, quantity := range quantities {
+		if _, ok := received[quantity.BookId]; ok {
+			return domain.PurchaseOrder{}, fmt.Errorf("%w: book %d is received twice", domain.ErrInvalidPurchaseOrder, quantity.BookId)
+		}
+		received[quantity.BookId] = quantity.Quantity
+	}
+
+	order, err := s.purchaseOrderRepository.ReceivePurchaseOrder(ctx, id, received, auditActor(ctx))
+	if err != nil {
+		return domain.PurchaseOrder{}, err
+	}
+	slog.Info("Purchase order received", "purchase_order_id", id, "status", order.Status())
+	return order, nil
+}
+
+// DeletePurchaseOrder deletes a draft.
+func (s *PurchaseOrderService) DeletePurchaseOrder(ctx context.Context, id int) error {
+	return s.purchaseOrderRepository.DeletePurchaseOrder(ctx, id, auditActor(ctx))
+}
+
+func (s *PurchaseOrderService) GetPurchaseOrder(ctx context.Context, id int) (domain.PurchaseOrder, error) {
+	return s.purchaseOrderRepository.FindPurchaseOrder(ctx, id)
+}
+
+// GetPurchaseOrders lists purchase orders newest first, at most maxPurchaseOrderLimit at
+// a time.
+func (s *PurchaseOrderService) GetPurchaseOrders(ctx context.Context, filter domain.PurchaseOrderFilter) ([]domain.PurchaseOrder, error) {
+	if filter.Status != "" && !domain.IsPurchaseOrderStatus(filter.Status) {
+		return nil, fmt.Errorf("%w: unknown status %q", domain.ErrInvalidPurchaseOrder, filter.Status)
+	}
+	if filter.Limit <= 0 {
+		filter.Limit = defaultPurchaseOrderLimit
+	}
+	filter.Limit = min(filter.Limit, maxPurchaseOrderLimit)
+	filter.Offset = max(filter.Offset, 0)
+	return s.purchaseOrderRepository.FindPurchaseOrders(ctx, filter)
+}
+
+// GetStockMovements lists the stock movements of a book, newest first, at most
+// maxPurchaseOrderLimit at a time.
+func (s *PurchaseOrderService) GetStockMovements(ctx context.Context, bookId int, limit, offset int) ([]domain.StockMovement, error) {
+	if limit <= 0 {
+		limit = defaultPurchaseOrderLimit
+	}
+	return s.purchaseOrderRepository.FindStockMovements(ctx, bookId, min(limit, maxPurchaseOrderLimit), max(offset, 0))
+}
+
+// orderLines prices the books to order at the cost prices of the supplier.
+func (s *PurchaseOrderService) orderLines(
+	ctx context.Context, supplierId int, quantities []domain.PurchaseQuantity,
+) ([]domain.PurchaseOrderLine, error) {
+	if _, err := s.supplierRepository.FindSupplierById(ctx, supplierId); err != nil {
+		return nil, err
+	}
+	bookIds := make([]int, len(quantities))
+	for i, quantity := range quantities {
+		bookIds[i] = quantity.BookId
+	}
+	bookSuppliers, err := s.supplierRepository.FindSupplierBooks(ctx, supplierId, bookIds)
+	if err != nil {
+		return nil, err
+	}
+	costPrices := make(map[int]domain.Money, len(bookSuppliers))
+	for _, bookSupplier := range bookSuppliers {
+		costPrices[bookSupplier.BookId()] = bookSupplier.CostPrice()
+	}
+
+	lines := make([]domain.PurchaseOrderLine, len(quantities))
+	for i, quantity := range quantities {
+		costPrice, ok := costPrices[quantity.BookId]
+		if !ok {
+			return nil, fmt.Errorf("%w: supplier %d does not supply book %d",
+				domain.ErrInvalidPurchaseOrder, supplierId, quantity.BookId)
+		}
+		lines[i], err = domain.NewPurchaseOrderLine(quantity.BookId, "", quantity.Quantity, costPrice)
+		if err != nil {
+			return nil, err
+		}
+	}
+	return lines, nil
+}
@@ -0,0 +1,199 @@
+package service
+
+import (
+	"context"
+	"testing"
+	"toptal/internal/app/domain"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+type MockSupplierRepository struct {
+	mock.Mock
+}
+
+func (m *MockSupplierRepository) FindSuppliers(ctx context.Context) ([]domain.Supplier, error) {
+	args := m.Called(ctx)
+	return args.Get(0).([]domain.Supplier), args.Error(1)
+}
+
+func (m *MockSupplierRepository) FindSupplierById(ctx context.Context, id int) (domain.Supplier, error) {
+	args := m.Called(ctx, id)
+	return args.Get(0).(domain.Supplier), args.Error(1)
+}
+
+func (m *MockSupplierRepository) InsertSupplier(ctx context.Context, supplier domain.Supplier, actor domain.AuditActor) (domain.Supplier, error) {
+	args := m.Called(ctx, supplier, actor)
+	return args.Get(0).(domain.Supplier), args.Error(1)
+}
+
+func (m *MockSupplierRepository) UpdateSupplier(ctx context.Context, supplier domain.Supplier, actor domain.AuditActor) (domain.Supplier, error) {
+	args := m.Called(ctx, supplier, actor)
+	return args.Get(0).(domain.Supplier), args.Error(1)
+}
+
+func (m *MockSupplierRepository) DeleteSupplier(ctx context.Context, id int, actor domain.AuditActor) error {
+	args := m.Called(ctx, id, actor)
+	return args.Error(0)
+}
+
+func (m *MockSupplierRepository) FindBookSuppliers(ctx context.Context, bookId int) ([]domain.BookSupplier, error) {
+	args := m.Called(ctx, bookId)
+	return args.Get(0).([]domain.BookSupplier), args.Error(1)
+}
+
+func (m *MockSupplierRepository) FindSupplierBooks(ctx context.Context, supplierId int, bookIds []int) ([]domain.BookSupplier, error) {
+	args := m.Called(ctx, supplierId, bookIds)
+	return args.Get(0).([]domain.BookSupplier), args.Error(1)
+}
+
+func (m *MockSupplierRepository) SaveBookSupplier(
+	ctx context.Context, bookSupplier domain.BookSupplier, actor domain.AuditActor,
+) (domain.BookSupplier, error) {
+	args := m.Called(ctx, bookSupplier, actor)
+	return args.Get(0).(domain.BookSupplier), args.Error(1)
+}
+
+func (m *MockSupplierRepository) DeleteBookSupplier(ctx context.Context, bookId int, supplierId int, actor domain.AuditActor) error {
+	args := m.Called(ctx, bookId, supplierId, actor)
+	return args.Error(0)
+}
+
+type MockPurchaseOrderRepository struct {
+	mock.Mock
+}
+
+func (m *MockPurchaseOrderRepository) InsertPurchaseOrder(
+	ctx context.Context, order domain.PurchaseOrder, actor domain.AuditActor,
+) (domain.PurchaseOrder, error) {
+	args := m.Called(ctx, order, actor)
+	return args.Get(0).(domain.PurchaseOrder), args.Error(1)
+}
+
+func (m *MockPurchaseOrderRepository) FindPurchaseOrder(ctx context.Context, id int) (domain.PurchaseOrder, error) {
+	args := m.Called(ctx, id)
+	return args.Get(0).(domain.PurchaseOrder), args.Error(1)
+}
+
+func (m *MockPurchaseOrderRepository) FindPurchaseOrders(ctx context.Context, filter domain.PurchaseOrderFilter) ([]domain.PurchaseOrder, error) {
+	args := m.Called(ctx, filter)
+	return args.Get(0).([]domain.PurchaseOrder), args.Error(1)
+}
+
+func (m *MockPurchaseOrderRepository) ReplacePurchaseOrderLines(
+	ctx context.Context, id int, lines []domain.PurchaseOrderLine, actor domain.AuditActor,
+) (domain.PurchaseOrder, error) {
+	args := m.Called(ctx, id, lines, actor)
+	return args.Get(0).(domain.PurchaseOrder), args.Error(1)
+}
+
+func (m *MockPurchaseOrderRepository) SendPurchaseOrder(ctx context.Context, id int, actor domain.AuditActor) (domain.PurchaseOrder, error) {
+	args := m.Called(ctx, id, actor)
+	return args.Get(0).(domain.PurchaseOrder), args.Error(1)
+}
+
+func (m *MockPurchaseOrderRepository) ReceivePurchaseOrder(
+	ctx context.Context, id int, quantities map[int]int, actor domain.AuditActor,
+) (domain.PurchaseOrder, error) {
+	args := m.Called(ctx, id, quantities, actor)
+	return args.Get(0).(domain.PurchaseOrder), args.Error(1)
+}
+
+func (m *MockPurchaseOrderRepository) DeletePurchaseOrder(ctx context.Context, id int, actor domain.AuditActor) error {
+	args := m.Called(ctx, id, actor)
+	return args.Error(0)
+}
+
+func (m *MockPurchaseOrderRepository) FindStockMovements(ctx context.Context, bookId int, limit, offset int) ([]domain.StockMovement, error) {
+	args := m.Called(ctx, bookId, limit, offset)
+	return args.Get(0).([]domain.StockMovement), args.Error(1)
+}
+
+func newPurchaseOrderTestService() (*PurchaseOrderService, *MockPurchaseOrderRepository, *MockSupplierRepository) {
+	repository := &MockPurchaseOrderRepository{}
+	suppliers := &MockSupplierRepository{}
+	return NewPurchaseOrderService(repository, suppliers), repository, suppliers
+}
+
+func newTestBookSupplier(t *testing.T, bookId int, cost string) domain.BookSupplier {
+	costPrice, err := domain.ParseMoney(cost, "EUR")
+	require.NoError(t, err)
+	bookSupplier, err := domain.NewBookSupplier(bookId, 3, costPrice, "")
+	require.NoError(t, err)
+	return bookSupplier
+}
+
+func TestPurchaseOrderService_CreatePurchaseOrderUsesCostPrices(t *testing.T) {
+	service, repository, suppliers := newPurchaseOrderTestService()
+	supplier, err := domain.NewSupplier(3, "Penguin", "", "")
+	require.NoError(t, err)
+
+	suppliers.On("FindSupplierById", mock.Anything, 3).Return(supplier, nil)
+	suppliers.On("FindSupplierBooks", mock.Anything, 3, []int{1, 2}).
+		Return([]domain.BookSupplier{newTestBookSupplier(t, 2, "3.00"), newTestBookSupplier(t, 1, "4.50")}, nil)
+	repository.On("InsertPurchaseOrder", mock.Anything, mock.MatchedBy(func(order domain.PurchaseOrder) bool {
+		return order.IsDraft() && order.Currency() == "EUR" && order.Total().Decimal() == "57.00"
+	}), mock.Anything).Return(domain.PurchaseOrder{}, nil)
+
+	_, err = service.CreatePurchaseOrder(context.Background(), 3, []domain.PurchaseQuantity{
+		{BookId: 1, Quantity: 10},
+		{BookId: 2, Quantity: 4},
+	})
+	require.NoError(t, err)
+	repository.AssertExpectations(t)
+}
+
+func TestPurchaseOrderService_CreatePurchaseOrderRejectsBooksNotSupplied(t *testing.T) {
+	service, repository, suppliers := newPurchaseOrderTestService()
+	supplier, err := domain.NewSupplier(3, "Penguin", "", "")
+	require.NoError(t, err)
+
+	suppliers.On("FindSupplierById", mock.Anything, 3).Return(supplier, nil)
+	suppliers.On("FindSupplierBooks", mock.Anything, 3, []int{1, 9}).
+		Return([]domain.BookSupplier{newTestBookSupplier(t, 1, "4.50")}, nil)
+
+	_, err = service.CreatePurchaseOrder(context.Background(), 3, []domain.PurchaseQuantity{
+		{BookId: 1, Quantity: 10},
+		{BookId: 9, Quantity: 1},
+	})
+	assert.ErrorIs(t, err, domain.ErrInvalidPurchaseOrder)
+	repository.AssertNotCalled(t, "InsertPurchaseOrder", mock.Anything, mock.Anything, mock.Anything)
+}
+
+func TestPurchaseOrderService_ReceivePurchaseOrder(t *testing.T) {
+	service, repository, _ := newPurchaseOrderTestService()
+
+	repository.On("ReceivePurchaseOrder", mock.Anything, 7, map[int]int{1: 6, 2: 4}, mock.Anything).
+		Return(domain.PurchaseOrder{}, nil)
+
+	_, err := service.ReceivePurchaseOrder(context.Background(), 7, []domain.PurchaseQuantity{
+		{BookId: 1, Quantity: 6},
+		{BookId: 2, Quantity: 4},
+	})
+	require.NoError(t, err)
+
+	_, err = service.ReceivePurchaseOrder(context.Background(), 7, []domain.PurchaseQuantity{
+		{BookId: 1, Quantity: 6},
+		{BookId: 1, Quantity: 4},
+	})
+	assert.ErrorIs(t, err, domain.ErrInvalidPurchaseOrder)
+	repository.AssertNumberOfCalls(t, "ReceivePurchaseOrder", 1)
+}
+
+func TestPurchaseOrderService_UpdatePurchaseOrderRejectsSentOrders(t *testing.T) {
+	service, repository, suppliers := newPurchaseOrderTestService()
+	costPrice, err := domain.ParseMoney("4.50", "EUR")
+	require.NoError(t, err)
+	line, err := domain.NewPurchaseOrderLine(1, "Dune", 10, costPrice)
+	require.NoError(t, err)
+	order, err := domain.NewPurchaseOrder(7, 3, domain.PurchaseOrderSent, []domain.PurchaseOrderLine{line})
+	require.NoError(t, err)
+
+	repository.On("FindPurchaseOrder", mock.Anything, 7).Return(order, nil)
+
+	_, err = service.UpdatePurchaseOrder(context.Background(), 7, []domain.PurchaseQuantity{{BookId: 1, Quantity: 5}})
+	assert.ErrorIs(t, err, domain.ErrPurchaseOrderStatus)
+	suppliers.AssertNotCalled(t, "FindSupplierBooks", mock.Anything, mock.Anything, mock.Anything)
+}
@@ -0,0 +1,146 @@
+package service
+
+import (
+	"context"
+	"log/slog"
+	"time"
+	"toptal/internal/app/config"
+	"toptal/internal/app/domain"
+)
+
+// RecommendationService recommends books bought by the same customers, filling in with
+// bestsellers where there are too few purchases to go by.
+type RecommendationService struct {
+	recommendationRepository RecommendationRepository
+	bookRepository           BookRepository
+	priceRepository          PriceRepository
+	config                   *config.RecommendationConfig
+}
+
+func NewRecommendationService(
+	recommendationRepository RecommendationRepository, bookRepository BookRepository,
+	priceRepository PriceRepository, cfg *config.RecommendationConfig,
+) *RecommendationService {
+	return &RecommendationService{
+		recommendationRepository: recommendationRepository,
+		bookRepository:           bookRepository,
+		priceRepository:          priceRepository,
+		config:                   cfg,
+	}
+}
+
+// GetBookRecommendations lists books customers of the book also bought, then the
+// bestsellers of its category.
+func (s *RecommendationService) GetBookRecommendations(
+	ctx context.Context, bookId int, limit int, currency string,
+) ([]domain.Recommendation, error) {
+	book, err := s.bookRepository.GetById(ctx, bookId)
+	if err != nil {
+		return nil, err
+	}
+
+	related, err := s.recommendationRepository.FindRelatedBooks(ctx, bookId, s.config.MinScore, limit)
+	if err != nil {
+		return nil, err
+	}
+	var bestsellers []domain.Book
+	if len(related) < limit {
+		exclude := append(bookIds(related), bookId)
+		bestsellers, err = s.recommendationRepository.FindBestsellers(
+			ctx, []int{book.CategoryId()}, exclude, 0, limit-len(related),
+		)
+		if err != nil {
+			return nil, err
+		}
+	}
+	return s.recommend(ctx, related, bestsellers, currency)
+}
+
+// GetUserRecommendations lists books bought along with the user's books, then the
+// bestsellers of the categories the user buys from, leaving out the books the user has.
+// Users who have bought nothing get the bestsellers of the shop.
+func (s *RecommendationService) GetUserRecommendations(
+	ctx context.Context, userId int, limit int, currency string,
+) ([]domain.Recommendation, error) {
+	related, err := s.recommendationRepository.FindUserRecommendations(ctx, userId, s.config.MinScore, limit)
+	if err != nil {
+		return nil, err
+	}
+	var bestsellers []domain.Book
+	if len(related) < limit {
+		categoryIds, err := s.recommendationRepository.FindPurchasedCategories(ctx, userId)
+		if err != nil {
+			return nil, err
+		}
+		bestsellers, err = s.recommendationRepository.FindBestsellers(
+			ctx, categoryIds, bookIds(related), userId, limit-len(related),
+		)
+		if err != nil {
+			return nil, err
+		}
+	}
+	return s.recommend(ctx, related, bestsellers, currency)
+}
+
+func (s *RecommendationService) recommend(
+	ctx context.Context, related, bestsellers []domain.Book, currency string,
+) ([]domain.Recommendation, error) {
+	books, err := priceBooksIn(ctx, s.priceRepository, append(related, bestsellers...), currency)
+	if err != nil {
+		return nil, err
+	}
+	recommendations := make([]domain.Recommendation, len(books))
+	for i, book := range books {
+		reason := domain.RecommendationCoPurchase
+		if i >= len(related) {
+			reason = domain.RecommendationBestseller
+		}
+		recommendations[i] = domain.NewRecommendation(book, reason)
+	}
+	return recommendations, nil
+}
+
+// RefreshAffinity adds the orders placed since the last refresh to the co-purchase counts.
+func (s *RecommendationService) RefreshAffinity(ctx context.Context) error {
+	total := 0
+	for {
+		added, err := s.recommendationRepository.RefreshAffinity(ctx, s.config.BatchSize)
+		if err != nil {
+			return err
+		}
+		total += added
+		if added < s.config.BatchSize {
+			break
+		}
+	}
+	if total > 0 {
+		slog.Info("Refreshed book affinity", "orders", total)
+	}
+	return nil
+}
+
+func (s *RecommendationService) StartRecommendationRefreshJob(ctx context.Context) {
+	ticker := time.NewTicker(s.config.RefreshInterval)
+	go func() {
+		defer ticker.Stop()
+		for {
+			select {
+			case <-ticker.C:
+				if err := s.RefreshAffinity(ctx); err != nil {
+					slog.Error("failed to refresh book affinity", "error", err)
+				}
+			case <-ctx.Done():
+				return
+			}
+		}
+	}()
+	slog.Info("Recommendation refresh job started", "interval minutes", s.config.RefreshInterval.Minutes())
+}
+
+func bookIds(books []domain.Book) []int {
+	ids := make([]int, len(books))
+	for i, book := range books {
+		ids[i] = book.Id()
+	}
+	return ids
+}
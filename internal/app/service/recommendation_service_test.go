@@ -0,0 +1,130 @@
+package service
+
+import (
+	"context"
+	"testing"
+	"toptal/internal/app/config"
+	"toptal/internal/app/domain"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+type MockRecommendationRepository struct {
+	mock.Mock
+}
+
+func (m *MockRecommendationRepository) RefreshAffinity(ctx context.Context, batchSize int) (int, error) {
+	args := m.Called(ctx, batchSize)
+	return args.Int(0), args.Error(1)
+}
+
+func (m *MockRecommendationRepository) FindRelatedBooks(ctx context.Context, bookId int, minScore, limit int) ([]domain.Book, error) {
+	args := m.Called(ctx, bookId, minScore, limit)
+	return args.Get(0).([]domain.Book), args.Error(1)
+}
+
+func (m *MockRecommendationRepository) FindUserRecommendations(ctx context.Context, userId int, minScore, limit int) ([]domain.Book, error) {
+	args := m.Called(ctx, userId, minScore, limit)
+	return args.Get(0).([]domain.Book), args.Error(1)
+}
+
+func (m *MockRecommendationRepository) FindBestsellers(
+	ctx context.Context, categoryIds []int, excludeIds []int, userId int, limit int,
+) ([]domain.Book, error) {
+	args := m.Called(ctx, categoryIds, excludeIds, userId, limit)
+	return args.Get(0).([]domain.Book), args.Error(1)
+}
+
+func (m *MockRecommendationRepository) FindPurchasedCategories(ctx context.Context, userId int) ([]int, error) {
+	args := m.Called(ctx, userId)
+	return args.Get(0).([]int), args.Error(1)
+}
+
+func newRecommendationTestBook(t *testing.T, id, categoryId int) domain.Book {
+	price, err := domain.ParseMoney("9.99", "USD")
+	require.NoError(t, err)
+	book, err := domain.NewBook(id, "Book", 2020, "Author", price, 3, categoryId)
+	require.NoError(t, err)
+	return book
+}
+
+func newRecommendationTestService() (*RecommendationService, *MockRecommendationRepository, *MockBookRepository) {
+	recommendations := &MockRecommendationRepository{}
+	books := &MockBookRepository{}
+	cfg := &config.RecommendationConfig{BatchSize: 100, MinScore: 2}
+	return NewRecommendationService(recommendations, books, nil, cfg), recommendations, books
+}
+
+func TestRecommendationService_GetBookRecommendationsFallsBackToBestsellers(t *testing.T) {
+	service, recommendations, books := newRecommendationTestService()
+	books.On("GetById", mock.Anything, 1).Return(newRecommendationTestBook(t, 1, 7), nil)
+	recommendations.On("FindRelatedBooks", mock.Anything, 1, 2, 3).
+		Return([]domain.Book{newRecommendationTestBook(t, 2, 7)}, nil)
+	recommendations.On("FindBestsellers", mock.Anything, []int{7}, []int{2, 1}, 0, 2).
+		Return([]domain.Book{newRecommendationTestBook(t, 5, 7), newRecommendationTestBook(t, 6, 7)}, nil)
+
+	result, err := service.GetBookRecommendations(context.Background(), 1, 3, "")
+	require.NoError(t, err)
+
+	require.Len(t, result, 3)
+	for i, expected := range []struct {
+		id     int
+		reason string
+	}{
+		{2, domain.RecommendationCoPurchase},
+		{5, domain.RecommendationBestseller},
+		{6, domain.RecommendationBestseller},
+	} {
+		book := result[i].Book()
+		assert.Equal(t, expected.id, book.Id())
+		assert.Equal(t, expected.reason, result[i].Reason())
+	}
+}
+
+func TestRecommendationService_GetBookRecommendationsWithEnoughData(t *testing.T) {
+	service, recommendations, books := newRecommendationTestService()
+	books.On("GetById", mock.Anything, 1).Return(newRecommendationTestBook(t, 1, 7), nil)
+	recommendations.On("FindRelatedBooks", mock.Anything, 1, 2, 1).
+		Return([]domain.Book{newRecommendationTestBook(t, 2, 7)}, nil)
+
+	result, err := service.GetBookRecommendations(context.Background(), 1, 1, "")
+	require.NoError(t, err)
+
+	require.Len(t, result, 1)
+	assert.Equal(t, domain.RecommendationCoPurchase, result[0].Reason())
+	recommendations.AssertNotCalled(t, "FindBestsellers", mock.Anything, mock.Anything, mock.Anything, mock.Anything, mock.Anything)
+}
+
+func TestRecommendationService_GetBookRecommendationsUnknownBook(t *testing.T) {
+	service, recommendations, books := newRecommendationTestService()
+	books.On("GetById", mock.Anything, 1).Return(domain.Book{}, domain.ErrNotFound)
+
+	_, err := service.GetBookRecommendations(context.Background(), 1, 3, "")
+	assert.ErrorIs(t, err, domain.ErrNotFound)
+	recommendations.AssertNotCalled(t, "FindRelatedBooks", mock.Anything, mock.Anything, mock.Anything, mock.Anything)
+}
+
+func TestRecommendationService_GetUserRecommendationsUsesPurchasedCategories(t *testing.T) {
+	service, recommendations, _ := newRecommendationTestService()
+	recommendations.On("FindUserRecommendations", mock.Anything, 9, 2, 2).Return([]domain.Book{}, nil)
+	recommendations.On("FindPurchasedCategories", mock.Anything, 9).Return([]int{3, 7}, nil)
+	recommendations.On("FindBestsellers", mock.Anything, []int{3, 7}, []int{}, 9, 2).
+		Return([]domain.Book{newRecommendationTestBook(t, 5, 3)}, nil)
+
+	result, err := service.GetUserRecommendations(context.Background(), 9, 2, "")
+	require.NoError(t, err)
+
+	require.Len(t, result, 1)
+	assert.Equal(t, domain.RecommendationBestseller, result[0].Reason())
+}
+
+func TestRecommendationService_RefreshAffinityRunsUntilCaughtUp(t *testing.T) {
+	service, recommendations, _ := newRecommendationTestService()
+	recommendations.On("RefreshAffinity", mock.Anything, 100).Return(100, nil).Once()
+	recommendations.On("RefreshAffinity", mock.Anything, 100).Return(20, nil).Once()
+
+	require.NoError(t, service.RefreshAffinity(context.Background()))
+	recommendations.AssertNumberOfCalls(t, "RefreshAffinity", 2)
+}
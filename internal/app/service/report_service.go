@@ -0,0 +1,47 @@
+package service
+
+import (
+	"context"
+	"toptal/internal/app/domain"
+)
+
+// ReportService runs the sales and inventory reports for admins.
+type ReportService struct {
+	reportRepository ReportRepository
+}
+
+func NewReportService(reportRepository ReportRepository) *ReportService {
+	return &ReportService{reportRepository: reportRepository}
+}
+
+// GetCategoryRevenue reports the revenue of each category per period and currency.
+func (s *ReportService) GetCategoryRevenue(ctx context.Context, filter domain.ReportFilter) ([]domain.CategoryRevenue, error) {
+	if err := filter.Validate(); err != nil {
+		return nil, err
+	}
+	return s.reportRepository.CategoryRevenue(ctx, filter)
+}
+
+// GetBookSales reports the copies sold of each book per period, best selling first.
+func (s *ReportService) GetBookSales(ctx context.Context, filter domain.ReportFilter) ([]domain.BookSales, error) {
+	if err := filter.Validate(); err != nil {
+		return nil, err
+	}
+	return s.reportRepository.BookSales(ctx, filter)
+}
+
+// GetBasketSize reports the number and average size of orders per period and currency.
+func (s *ReportService) GetBasketSize(ctx context.Context, filter domain.ReportFilter) ([]domain.BasketSize, error) {
+	if err := filter.Validate(); err != nil {
+		return nil, err
+	}
+	return s.reportRepository.BasketSize(ctx, filter)
+}
+
+// GetStockTurnover reports how fast the stock of each category sold per period.
+func (s *ReportService) GetStockTurnover(ctx context.Context, filter domain.ReportFilter) ([]domain.StockTurnover, error) {
+	if err := filter.Validate(); err != nil {
+		return nil, err
+	}
+	return s.reportRepository.StockTurnover(ctx, filter)
+}
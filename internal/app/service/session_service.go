@@ -0,0 +1,151 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"time"
+	"toptal/internal/app/auth"
+	"toptal/internal/app/domain"
+	"toptal/internal/app/util"
+)
+
+type SessionService struct {
+	sessionRepository SessionRepository
+}
+
+func NewSessionService(repository SessionRepository) *SessionService {
+	return &SessionService{sessionRepository: repository}
+}
+
+// StartSession records a login from the client in ctx and issues its access token.
+func (s *SessionService) StartSession(ctx context.Context, user domain.User) (string, error) {
+	client := util.GetClientInfo(ctx)
+	session, err := domain.NewSession(user.Id(), client.UserAgent, client.IPAddress, auth.TokenExpiration())
+	if err != nil {
+		return "", err
+	}
+
+	created, err := s.sessionRepository.InsertSession(ctx, session)
+	if err != nil {
+		return "", fmt.Errorf("failed to start session: %w", err)
+	}
+
+	token, err := auth.GenerateToken(&user, &created)
+	if err != nil {
+		return "", errors.New("failed to generate token")
+	}
+	return token, nil
+}
+
+// Authenticate checks that the session of an access token belongs to the user and is
+// still active. Tokens issued before sessions existed carry no session and are rejected.
+func (s *SessionService) Authenticate(ctx context.Context, userId int, sessionId int) error {
+	if sessionId == 0 {
+		return domain.ErrSessionRevoked
+	}
+
+	session, err := s.sessionRepository.FindSessionById(ctx, sessionId)
+	if err != nil {
+		if errors.Is(err, domain.ErrNotFound) {
+			return domain.ErrSessionRevoked
+		}
+		return err
+	}
+	if session.UserId() != userId || !session.Active(time.Now()) {
+		return domain.ErrSessionRevoked
+	}
+
+	if err := s.sessionRepository.TouchSession(ctx, sessionId); err != nil {
+		slog.Error("failed to record session activity", "session_id", sessionId, "error", err)
+	}
+	return nil
+}
+
+func (s *SessionService) GetSessions(ctx context.Context, userId int) ([]domain.Session, error) {
+	return s.sessionRepository.FindSessionsByUser(ctx, userId)
+}
+
+func (s *SessionService) RevokeSession(ctx context.Context, userId int, sessionId int) error {
+	if err := s.sessionRepository.RevokeSession(ctx, userId, sessionId); err != nil {
+		return err
+	}
+	slog.Info("Session revoked", "user_id", userId, "session_id", sessionId)
+	return nil
+}
+
+// RevokeUserSessions logs the user out everywhere and returns the number of sessions ended.
+func (s *SessionService) RevokeUserSessions(ctx context.Context, userId int) (int, error) {
+	revoked, err := s.sessionRepository.RevokeUserSessions(ctx, userId, 0, auditActor(ctx))
+	if err != nil {
+		return 0, err
+	}
+	slog.Info("User sessions revoked", "user_id", userId, "sessions", revoked)
+	return revoked, nil
+}
+
+// RevokeOtherSessions logs the user out everywhere but in the session of ctx, from which
+// the user made a change to their credentials.
+func (s *SessionService) RevokeOtherSessions(ctx context.Context, userId int) (int, error) {
+	current, _ := util.GetSessionID(ctx)
+	revoked, err := s.sessionRepository.RevokeUserSessions(ctx, userId, current, auditActor(ctx))
+	if err != nil {
+		return 0, err
+	}
+	slog.Info("Other user sessions revoked", "user_id", userId, "sessions", revoked)
+	return revoked, nil
+}
+
+// recentLogin reports whether the session in ctx belongs to the user and was started
+// within maxAge, so that logging in stands in for a password the user does not have.
+func (s *SessionService) recentLogin(ctx context.Context, userId int, maxAge time.Duration) (bool, error) {
+	sessionId, err := util.GetSessionID(ctx)
+	if err != nil {
+		return false, nil
+	}
+	session, err := s.sessionRepository.FindSessionById(ctx, sessionId)
+	if err != nil {
+		if errors.Is(err, domain.ErrNotFound) {
+			return false, nil
+		}
+		return false, err
+	}
+	now := time.Now()
+	return session.UserId() == userId && session.Active(now) && now.Sub(session.CreatedAt()) <= maxAge, nil
+}
+
+// attemptChallenge counts an answer to a two-factor challenge of the user and reports
+// whether the challenge could still be answered.
+func (s *SessionService) attemptChallenge(ctx context.Context, challengeId int, userId int) (bool, error) {
+	return s.sessionRepository.AttemptTwoFactorChallenge(ctx, challengeId, userId, twoFactorMaxAttempts)
+}
+
+// completeChallenge closes a two-factor challenge that was answered, and reports false
+// when another login completed it first.
+func (s *SessionService) completeChallenge(ctx context.Context, challengeId int) (bool, error) {
+	return s.sessionRepository.CompleteTwoFactorChallenge(ctx, challengeId)
+}
+
+// loginResult starts a session for an authenticated user, or issues a challenge token
+// when the user still has to pass two-factor authentication.
+func (s *SessionService) loginResult(ctx context.Context, user domain.User) (domain.LoginResult, error) {
+	if user.TOTPEnabled() {
+		expiresAt := auth.ChallengeExpiration()
+		challengeId, err := s.sessionRepository.InsertTwoFactorChallenge(ctx, user.Id(), expiresAt)
+		if err != nil {
+			return domain.LoginResult{}, fmt.Errorf("failed to start two-factor challenge: %w", err)
+		}
+		challenge, err := auth.GenerateChallengeToken(&user, challengeId, expiresAt)
+		if err != nil {
+			return domain.LoginResult{}, errors.New("failed to generate challenge token")
+		}
+		return domain.NewChallengeLoginResult(challenge), nil
+	}
+
+	token, err := s.StartSession(ctx, user)
+	if err != nil {
+		return domain.LoginResult{}, err
+	}
+	return domain.NewTokenLoginResult(token), nil
+}
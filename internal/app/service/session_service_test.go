@@ -0,0 +1,134 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+	"toptal/internal/app/auth"
+	"toptal/internal/app/domain"
+	"toptal/internal/app/util"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+type MockSessionRepository struct {
+	mock.Mock
+}
+
+func (m *MockSessionRepository) InsertSession(ctx context.Context, session domain.Session) (domain.Session, error) {
+	args := m.Called(ctx, session)
+	return args.Get(0).(domain.Session), args.Error(1)
+}
+
+func (m *MockSessionRepository) FindSessionById(ctx context.Context, id int) (domain.Session, error) {
+	args := m.Called(ctx, id)
+	return args.Get(0).(domain.Session), args.Error(1)
+}
+
+func (m *MockSessionRepository) FindSessionsByUser(ctx context.Context, userId int) ([]domain.Session, error) {
+	args := m.Called(ctx, userId)
+	return args.Get(0).([]domain.Session), args.Error(1)
+}
+
+func (m *MockSessionRepository) TouchSession(ctx context.Context, id int) error {
+	return m.Called(ctx, id).Error(0)
+}
+
+func (m *MockSessionRepository) RevokeSession(ctx context.Context, userId int, id int) error {
+	return m.Called(ctx, userId, id).Error(0)
+}
+
+func (m *MockSessionRepository) RevokeUserSessions(
+	ctx context.Context, userId int, keepSessionId int, actor domain.AuditActor,
+) (int, error) {
+	args := m.Called(ctx, userId, keepSessionId, actor)
+	return args.Int(0), args.Error(1)
+}
+
+func (m *MockSessionRepository) InsertTwoFactorChallenge(ctx context.Context, userId int, expiresAt time.Time) (int, error) {
+	args := m.Called(ctx, userId, expiresAt)
+	return args.Int(0), args.Error(1)
+}
+
+func (m *MockSessionRepository) AttemptTwoFactorChallenge(ctx context.Context, id int, userId int, maxAttempts int) (bool, error) {
+	args := m.Called(ctx, id, userId, maxAttempts)
+	return args.Bool(0), args.Error(1)
+}
+
+func (m *MockSessionRepository) CompleteTwoFactorChallenge(ctx context.Context, id int) (bool, error) {
+	args := m.Called(ctx, id)
+	return args.Bool(0), args.Error(1)
+}
+
+// newTestSessions returns a session service whose repository accepts every new session
+// and every answer to a two-factor challenge.
+func newTestSessions(t *testing.T) *SessionService {
+	repo := new(MockSessionRepository)
+	repo.On("InsertSession", mock.Anything, mock.Anything).Return(newTestSession(t, 1, 1, time.Now().Add(time.Hour)), nil)
+	repo.On("InsertTwoFactorChallenge", mock.Anything, mock.Anything, mock.Anything).Return(1, nil)
+	repo.On("AttemptTwoFactorChallenge", mock.Anything, 1, mock.Anything, twoFactorMaxAttempts).Return(true, nil)
+	repo.On("CompleteTwoFactorChallenge", mock.Anything, 1).Return(true, nil)
+	return NewSessionService(repo)
+}
+
+func newTestSession(t *testing.T, id int, userId int, expiresAt time.Time) domain.Session {
+	session, err := domain.NewSession(userId, "curl/8.0", "10.0.0.1", expiresAt)
+	require.NoError(t, err)
+	require.NoError(t, session.SetId(id))
+	return session
+}
+
+func TestSessionService_StartSession(t *testing.T) {
+	repo := new(MockSessionRepository)
+	service := NewSessionService(repo)
+	ctx := util.WithClientInfo(context.Background(), util.ClientInfo{UserAgent: "curl/8.0", IPAddress: "10.0.0.1"})
+
+	user, err := domain.NewUser(3, "alice", "hash", false)
+	require.NoError(t, err)
+
+	repo.On("InsertSession", ctx, mock.MatchedBy(func(session domain.Session) bool {
+		return session.UserId() == 3 && session.UserAgent() == "curl/8.0" && session.IPAddress() == "10.0.0.1"
+	})).Return(newTestSession(t, 9, 3, time.Now().Add(time.Hour)), nil).Once()
+
+	token, err := service.StartSession(ctx, user)
+	require.NoError(t, err)
+
+	claims, err := auth.ParseToken(token)
+	require.NoError(t, err)
+	assert.Equal(t, 3, claims.UserID)
+	assert.Equal(t, 9, claims.SessionID)
+}
+
+func TestSessionService_Authenticate(t *testing.T) {
+	ctx := context.Background()
+	repo := new(MockSessionRepository)
+	service := NewSessionService(repo)
+
+	active := newTestSession(t, 1, 3, time.Now().Add(time.Hour))
+	revoked := newTestSession(t, 2, 3, time.Now().Add(time.Hour))
+	require.NoError(t, revoked.SetRevokedAt(time.Now()))
+	expired := newTestSession(t, 3, 3, time.Now().Add(-time.Minute))
+
+	repo.On("FindSessionById", ctx, 1).Return(active, nil)
+	repo.On("FindSessionById", ctx, 2).Return(revoked, nil)
+	repo.On("FindSessionById", ctx, 3).Return(expired, nil)
+	repo.On("FindSessionById", ctx, 4).Return(domain.Session{}, domain.ErrNotFound)
+	repo.On("FindSessionById", ctx, 5).Return(domain.Session{}, errors.New("connection refused"))
+	repo.On("TouchSession", ctx, 1).Return(nil).Once()
+
+	assert.NoError(t, service.Authenticate(ctx, 3, 1))
+	repo.AssertCalled(t, "TouchSession", ctx, 1)
+
+	assert.ErrorIs(t, service.Authenticate(ctx, 4, 1), domain.ErrSessionRevoked, "session of another user")
+	assert.ErrorIs(t, service.Authenticate(ctx, 3, 2), domain.ErrSessionRevoked)
+	assert.ErrorIs(t, service.Authenticate(ctx, 3, 3), domain.ErrSessionRevoked)
+	assert.ErrorIs(t, service.Authenticate(ctx, 3, 4), domain.ErrSessionRevoked)
+	assert.ErrorIs(t, service.Authenticate(ctx, 3, 0), domain.ErrSessionRevoked, "token without a session")
+
+	err := service.Authenticate(ctx, 3, 5)
+	assert.Error(t, err)
+	assert.NotErrorIs(t, err, domain.ErrSessionRevoked)
+}
@@ -0,0 +1,96 @@
+package service
+
+import (
+	"context"
+	"log/slog"
+	"time"
+	"toptal/internal/app/config"
+	"toptal/internal/app/domain"
+)
+
+// StockAlertService finds the books that are low or out of stock, suggests how many
+// copies to reorder, and reports each book to the notifier once until it is restocked.
+type StockAlertService struct {
+	stockRepository StockAlertRepository
+	notifier        StockNotifier
+	config          *config.StockConfig
+}
+
+func NewStockAlertService(repository StockAlertRepository, notifier StockNotifier, cfg *config.StockConfig) *StockAlertService {
+	return &StockAlertService{stockRepository: repository, notifier: notifier, config: cfg}
+}
+
+func (s *StockAlertService) policy() domain.ReorderPolicy {
+	return domain.ReorderPolicy{
+		DefaultThreshold: s.config.DefaultThreshold,
+		SalesDays:        s.config.SalesDays,
+		CoverDays:        s.config.CoverDays,
+	}
+}
+
+// GetLowStock lists the books at or below their reorder threshold, out of stock first.
+func (s *StockAlertService) GetLowStock(ctx context.Context) ([]domain.StockAlert, error) {
+	return s.stockRepository.FindLowStock(ctx, s.policy())
+}
+
+// SetReorderThreshold sets the stock at or below which a book is reported, or makes it
+// use the default threshold when threshold is nil. It returns the threshold that applies.
+func (s *StockAlertService) SetReorderThreshold(ctx context.Context, bookId int, threshold *int) (int, error) {
+	if err := s.stockRepository.SetReorderThreshold(ctx, bookId, threshold, auditActor(ctx)); err != nil {
+		return 0, err
+	}
+	if threshold == nil {
+		return s.config.DefaultThreshold, nil
+	}
+	return *threshold, nil
+}
+
+// CheckStock reports the books that ran low or sold out since the last check. Books are
+// only marked as reported once the notifier succeeds, so failed alerts are sent again by
+// the next check.
+func (s *StockAlertService) CheckStock(ctx context.Context) error {
+	if _, err := s.stockRepository.ClearRestockedAlerts(ctx, s.config.DefaultThreshold); err != nil {
+		return err
+	}
+	alerts, err := s.stockRepository.FindLowStock(ctx, s.policy())
+	if err != nil {
+		return err
+	}
+
+	var pending []domain.StockAlert
+	for _, alert := range alerts {
+		if alert.NeedsNotification() {
+			pending = append(pending, alert)
+		}
+	}
+	if len(pending) == 0 {
+		return nil
+	}
+
+	if err := s.notifier.NotifyLowStock(ctx, pending); err != nil {
+		return err
+	}
+	if err := s.stockRepository.MarkNotified(ctx, pending); err != nil {
+		return err
+	}
+	slog.Info("Low stock reported", "books", len(pending))
+	return nil
+}
+
+func (s *StockAlertService) StartStockAlertJob(ctx context.Context) {
+	ticker := time.NewTicker(s.config.AlertInterval)
+	go func() {
+		defer ticker.Stop()
+		for {
+			select {
+			case <-ticker.C:
+				if err := s.CheckStock(ctx); err != nil {
+					slog.Error("failed to check stock", "error", err)
+				}
+			case <-ctx.Done():
+				return
+			}
+		}
+	}()
+	slog.Info("Stock alert job started", "interval minutes", s.config.AlertInterval.Minutes())
+}
@@ -0,0 +1,156 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"toptal/internal/app/config"
+	"toptal/internal/app/domain"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+type MockStockAlertRepository struct {
+	mock.Mock
+}
+
+func (m *MockStockAlertRepository) FindLowStock(ctx context.Context, policy domain.ReorderPolicy) ([]domain.StockAlert, error) {
+	args := m.Called(ctx, policy)
+	return args.Get(0).([]domain.StockAlert), args.Error(1)
+}
+
+func (m *MockStockAlertRepository) ClearRestockedAlerts(ctx context.Context, defaultThreshold int) (int, error) {
+	args := m.Called(ctx, defaultThreshold)
+	return args.Int(0), args.Error(1)
+}
+
+func (m *MockStockAlertRepository) MarkNotified(ctx context.Context, alerts []domain.StockAlert) error {
+	args := m.Called(ctx, alerts)
+	return args.Error(0)
+}
+
+func (m *MockStockAlertRepository) SetReorderThreshold(ctx context.Context, bookId int, threshold *int, actor domain.AuditActor) error {
+	args := m.Called(ctx, bookId, threshold, actor)
+	return args.Error(0)
+}
+
+type MockStockNotifier struct {
+	mock.Mock
+}
+
+func (m *MockStockNotifier) NotifyLowStock(ctx context.Context, alerts []domain.StockAlert) error {
+	args := m.Called(ctx, alerts)
+	return args.Error(0)
+}
+
+type MockEmailQueue struct {
+	mock.Mock
+}
+
+func (m *MockEmailQueue) QueueOutboxEmails(ctx context.Context, emails []domain.OutboxEmail) error {
+	args := m.Called(ctx, emails)
+	return args.Error(0)
+}
+
+var testReorderPolicy = domain.ReorderPolicy{DefaultThreshold: 5, SalesDays: 28, CoverDays: 30}
+
+func newStockAlertTestService() (*StockAlertService, *MockStockAlertRepository, *MockStockNotifier) {
+	repository := &MockStockAlertRepository{}
+	notifier := &MockStockNotifier{}
+	cfg := &config.StockConfig{DefaultThreshold: 5, SalesDays: 28, CoverDays: 30}
+	return NewStockAlertService(repository, notifier, cfg), repository, notifier
+}
+
+func newStockTestAlert(t *testing.T, id, stock int, notifiedLevel string) domain.StockAlert {
+	price, err := domain.ParseMoney("9.99", "USD")
+	require.NoError(t, err)
+	book, err := domain.NewBook(id, "Book", 2020, "Author", price, stock, 1)
+	require.NoError(t, err)
+	return domain.NewStockAlert(book, 5, 14, notifiedLevel, testReorderPolicy)
+}
+
+func TestStockAlertService_CheckStockReportsNewAlerts(t *testing.T) {
+	service, repository, notifier := newStockAlertTestService()
+	reported := newStockTestAlert(t, 1, 2, domain.StockLevelLow)
+	soldOut := newStockTestAlert(t, 2, 0, domain.StockLevelLow)
+	low := newStockTestAlert(t, 3, 4, "")
+
+	repository.On("ClearRestockedAlerts", mock.Anything, 5).Return(1, nil)
+	repository.On("FindLowStock", mock.Anything, testReorderPolicy).
+		Return([]domain.StockAlert{soldOut, reported, low}, nil)
+	notifier.On("NotifyLowStock", mock.Anything, []domain.StockAlert{soldOut, low}).Return(nil)
+	repository.On("MarkNotified", mock.Anything, []domain.StockAlert{soldOut, low}).Return(nil)
+
+	require.NoError(t, service.CheckStock(context.Background()))
+	repository.AssertExpectations(t)
+	notifier.AssertExpectations(t)
+}
+
+func TestStockAlertService_CheckStockRetriesFailedNotifications(t *testing.T) {
+	service, repository, notifier := newStockAlertTestService()
+	low := newStockTestAlert(t, 1, 2, "")
+
+	repository.On("ClearRestockedAlerts", mock.Anything, 5).Return(0, nil)
+	repository.On("FindLowStock", mock.Anything, testReorderPolicy).Return([]domain.StockAlert{low}, nil)
+	notifier.On("NotifyLowStock", mock.Anything, []domain.StockAlert{low}).Return(errors.New("webhook returned 502"))
+
+	assert.Error(t, service.CheckStock(context.Background()))
+	repository.AssertNotCalled(t, "MarkNotified", mock.Anything, mock.Anything)
+}
+
+func TestStockAlertService_CheckStockWithoutNewAlerts(t *testing.T) {
+	service, repository, notifier := newStockAlertTestService()
+
+	repository.On("ClearRestockedAlerts", mock.Anything, 5).Return(0, nil)
+	repository.On("FindLowStock", mock.Anything, testReorderPolicy).
+		Return([]domain.StockAlert{newStockTestAlert(t, 1, 2, domain.StockLevelLow)}, nil)
+
+	require.NoError(t, service.CheckStock(context.Background()))
+	notifier.AssertNotCalled(t, "NotifyLowStock", mock.Anything, mock.Anything)
+}
+
+func TestStockAlertService_SetReorderThreshold(t *testing.T) {
+	service, repository, _ := newStockAlertTestService()
+	threshold := 12
+	repository.On("SetReorderThreshold", mock.Anything, 1, &threshold, mock.Anything).Return(nil)
+	repository.On("SetReorderThreshold", mock.Anything, 2, (*int)(nil), mock.Anything).Return(nil)
+
+	applied, err := service.SetReorderThreshold(context.Background(), 1, &threshold)
+	require.NoError(t, err)
+	assert.Equal(t, 12, applied)
+
+	applied, err = service.SetReorderThreshold(context.Background(), 2, nil)
+	require.NoError(t, err)
+	assert.Equal(t, 5, applied)
+}
+
+func TestStockNotifiers_NotifyEveryNotifier(t *testing.T) {
+	failing := &MockStockNotifier{}
+	working := &MockStockNotifier{}
+	alerts := []domain.StockAlert{newStockTestAlert(t, 1, 0, "")}
+	failing.On("NotifyLowStock", mock.Anything, alerts).Return(errors.New("webhook returned 502"))
+	working.On("NotifyLowStock", mock.Anything, alerts).Return(nil)
+
+	err := StockNotifiers{failing, working}.NotifyLowStock(context.Background(), alerts)
+	assert.ErrorContains(t, err, "502")
+	working.AssertExpectations(t)
+}
+
+func TestEmailStockNotifier_QueuesEmailPerRecipient(t *testing.T) {
+	queue := &MockEmailQueue{}
+	notifier := NewEmailStockNotifier(queue, []string{"buyer@example.com", "ops@example.com"})
+	alerts := []domain.StockAlert{newStockTestAlert(t, 1, 0, "")}
+
+	var queued []domain.OutboxEmail
+	queue.On("QueueOutboxEmails", mock.Anything, mock.Anything).
+		Run(func(args mock.Arguments) { queued = args.Get(1).([]domain.OutboxEmail) }).
+		Return(nil)
+
+	require.NoError(t, notifier.NotifyLowStock(context.Background(), alerts))
+	require.Len(t, queued, 2)
+	assert.Equal(t, "ops@example.com", queued[1].Recipient())
+	assert.Equal(t, `"Book" is low on stock`, queued[0].Subject())
+	assert.Contains(t, queued[0].Body(), "Book by Author (book 1): out of stock, threshold 5, selling 0.5 a day. Suggested reorder: 20 copies.")
+}
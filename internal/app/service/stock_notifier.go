@@ -0,0 +1,142 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"strings"
+	"toptal/internal/app/domain"
+)
+
+// stockAlertEvent is the webhook event stock alerts are sent as.
+const stockAlertEvent = "stock.low"
+
+// StockNotifier reports books that have run low or out of stock.
+type StockNotifier interface {
+	NotifyLowStock(ctx context.Context, alerts []domain.StockAlert) error
+}
+
+// WebhookSender posts an event to a webhook.
+type WebhookSender interface {
+	Send(ctx context.Context, event string, data any) error
+}
+
+// StockNotifiers sends alerts to every notifier, even when some fail.
+type StockNotifiers []StockNotifier
+
+func (n StockNotifiers) NotifyLowStock(ctx context.Context, alerts []domain.StockAlert) error {
+	var errs []error
+	for _, notifier := range n {
+		if err := notifier.NotifyLowStock(ctx, alerts); err != nil {
+			errs = append(errs, err)
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// LogStockNotifier writes alerts to the application log.
+type LogStockNotifier struct{}
+
+func NewLogStockNotifier() *LogStockNotifier {
+	return &LogStockNotifier{}
+}
+
+func (n *LogStockNotifier) NotifyLowStock(_ context.Context, alerts []domain.StockAlert) error {
+	for _, alert := range alerts {
+		book := alert.Book()
+		slog.Warn("Book is low on stock",
+			"book_id", book.Id(), "title", book.Title(), "level", alert.Level(), "stock", book.Stock(),
+			"threshold", alert.Threshold(), "daily_sales", alert.DailySales(), "suggested_quantity", alert.SuggestedQuantity(),
+		)
+	}
+	return nil
+}
+
+// WebhookStockNotifier posts the alerts of a check in one "stock.low" event.
+type WebhookStockNotifier struct {
+	webhook WebhookSender
+}
+
+func NewWebhookStockNotifier(webhook WebhookSender) *WebhookStockNotifier {
+	return &WebhookStockNotifier{webhook: webhook}
+}
+
+type stockAlertPayload struct {
+	BookId            int     `json:"book_id"`
+	Title             string  `json:"title"`
+	Author            string  `json:"author"`
+	ISBN              string  `json:"isbn,omitempty"`
+	Level             string  `json:"level"`
+	Stock             int     `json:"stock"`
+	Threshold         int     `json:"threshold"`
+	DailySales        float64 `json:"daily_sales"`
+	SuggestedQuantity int     `json:"suggested_quantity"`
+}
+
+func (n *WebhookStockNotifier) NotifyLowStock(ctx context.Context, alerts []domain.StockAlert) error {
+	payload := make([]stockAlertPayload, len(alerts))
+	for i, alert := range alerts {
+		book := alert.Book()
+		payload[i] = stockAlertPayload{
+			BookId:            book.Id(),
+			Title:             book.Title(),
+			Author:            book.Author(),
+			ISBN:              book.ISBN(),
+			Level:             alert.Level(),
+			Stock:             book.Stock(),
+			Threshold:         alert.Threshold(),
+			DailySales:        alert.DailySales(),
+			SuggestedQuantity: alert.SuggestedQuantity(),
+		}
+	}
+	if err := n.webhook.Send(ctx, stockAlertEvent, map[string]any{"alerts": payload}); err != nil {
+		return fmt.Errorf("stock alert webhook: %w", err)
+	}
+	return nil
+}
+
+// EmailStockNotifier emails the alerts of a check to the recipients through the email
+// outbox.
+type EmailStockNotifier struct {
+	queue      EmailQueue
+	recipients []string
+}
+
+func NewEmailStockNotifier(queue EmailQueue, recipients []string) *EmailStockNotifier {
+	return &EmailStockNotifier{queue: queue, recipients: recipients}
+}
+
+func (n *EmailStockNotifier) NotifyLowStock(ctx context.Context, alerts []domain.StockAlert) error {
+	subject := fmt.Sprintf("%d books are low on stock", len(alerts))
+	if len(alerts) == 1 {
+		book := alerts[0].Book()
+		subject = fmt.Sprintf("%q is low on stock", book.Title())
+	}
+	body := stockAlertEmailBody(alerts)
+
+	emails := make([]domain.OutboxEmail, 0, len(n.recipients))
+	for _, recipient := range n.recipients {
+		email, err := domain.NewOutboxEmail(recipient, subject, body)
+		if err != nil {
+			return err
+		}
+		emails = append(emails, email)
+	}
+	return n.queue.QueueOutboxEmails(ctx, emails)
+}
+
+func stockAlertEmailBody(alerts []domain.StockAlert) string {
+	var body strings.Builder
+	body.WriteString("These books have run low on stock:\n\n")
+	for _, alert := range alerts {
+		book := alert.Book()
+		status := fmt.Sprintf("%d left", book.Stock())
+		if alert.Level() == domain.StockLevelOut {
+			status = "out of stock"
+		}
+		fmt.Fprintf(&body, "- %s by %s (book %d): %s, threshold %d, selling %.1f a day. Suggested reorder: %d copies.\n",
+			book.Title(), book.Author(), book.Id(), status, alert.Threshold(), alert.DailySales(), alert.SuggestedQuantity())
+	}
+	return body.String()
+}
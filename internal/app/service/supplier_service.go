@@ -0,0 +1,57 @@
+package service
+
+import (
+	"context"
+	"log/slog"
+	"toptal/internal/app/domain"
+)
+
+// SupplierService manages the suppliers the shop restocks from and the books each of
+// them supplies.
+type SupplierService struct {
+	supplierRepository SupplierRepository
+}
+
+func NewSupplierService(repository SupplierRepository) *SupplierService {
+	return &SupplierService{supplierRepository: repository}
+}
+
+func (s *SupplierService) GetSuppliers(ctx context.Context) ([]domain.Supplier, error) {
+	return s.supplierRepository.FindSuppliers(ctx)
+}
+
+func (s *SupplierService) GetSupplier(ctx context.Context, id int) (domain.Supplier, error) {
+	return s.supplierRepository.FindSupplierById(ctx, id)
+}
+
+func (s *SupplierService) CreateSupplier(ctx context.Context, supplier domain.Supplier) (domain.Supplier, error) {
+	created, err := s.supplierRepository.InsertSupplier(ctx, supplier, auditActor(ctx))
+	if err != nil {
+		return domain.Supplier{}, err
+	}
+	slog.Info("Supplier created", "supplier_id", created.Id())
+	return created, nil
+}
+
+func (s *SupplierService) UpdateSupplier(ctx context.Context, supplier domain.Supplier) (domain.Supplier, error) {
+	return s.supplierRepository.UpdateSupplier(ctx, supplier, auditActor(ctx))
+}
+
+// DeleteSupplier deletes a supplier that has no purchase orders.
+func (s *SupplierService) DeleteSupplier(ctx context.Context, id int) error {
+	return s.supplierRepository.DeleteSupplier(ctx, id, auditActor(ctx))
+}
+
+// GetBookSuppliers lists the suppliers of a book, cheapest first.
+func (s *SupplierService) GetBookSuppliers(ctx context.Context, bookId int) ([]domain.BookSupplier, error) {
+	return s.supplierRepository.FindBookSuppliers(ctx, bookId)
+}
+
+// SaveBookSupplier adds a supplier to a book or changes the cost price it charges.
+func (s *SupplierService) SaveBookSupplier(ctx context.Context, bookSupplier domain.BookSupplier) (domain.BookSupplier, error) {
+	return s.supplierRepository.SaveBookSupplier(ctx, bookSupplier, auditActor(ctx))
+}
+
+func (s *SupplierService) DeleteBookSupplier(ctx context.Context, bookId int, supplierId int) error {
+	return s.supplierRepository.DeleteBookSupplier(ctx, bookId, supplierId, auditActor(ctx))
+}
@@ -0,0 +1,78 @@
+package service
+
+import (
+	"fmt"
+	"toptal/internal/app/config"
+	"toptal/internal/app/domain"
+	"toptal/internal/pkg/tax"
+)
+
+// TaxService taxes orders by the tax class of their books and the region they ship to.
+type TaxService struct {
+	rules *tax.Rules
+}
+
+func NewTaxService(cfg *config.TaxConfig) (*TaxService, error) {
+	rates := make([]tax.Rate, len(cfg.Rates))
+	for i, entry := range cfg.Rates {
+		rate, err := tax.ParseRate(entry)
+		if err != nil {
+			return nil, err
+		}
+		rates[i] = rate
+	}
+	rules, err := tax.NewRules(rates, cfg.PricesIncludeTax)
+	if err != nil {
+		return nil, err
+	}
+	return &TaxService{rules: rules}, nil
+}
+
+// TaxOrder works out the tax on items shipped to address. The items must all be priced
+// in the same currency; they are returned with their tax set.
+func (s *TaxService) TaxOrder(address domain.ShippingAddress, items []domain.OrderItem) (domain.OrderTax, error) {
+	if len(items) == 0 {
+		return domain.OrderTax{}, domain.ErrCartEmpty
+	}
+	currency := items[0].Price().Currency()
+	lines := make([]tax.Line, len(items))
+	for i, item := range items {
+		if item.Price().Currency() != currency {
+			return domain.OrderTax{}, domain.ErrCurrencyMismatch
+		}
+		lines[i] = tax.Line{Class: item.TaxClass(), Amount: item.Price().Amount()}
+	}
+
+	breakdown, err := s.rules.Calculate(address.TaxRegion(), lines)
+	if err != nil {
+		return domain.OrderTax{}, fmt.Errorf("failed to calculate tax: %w", err)
+	}
+
+	money := func(amount int64) domain.Money {
+		m, _ := domain.NewMoney(amount, currency)
+		return m
+	}
+	taxed := make([]domain.OrderItem, len(items))
+	for i, item := range items {
+		if err := item.SetTax(money(breakdown.Lines[i].Tax)); err != nil {
+			return domain.OrderTax{}, err
+		}
+		taxed[i] = item
+	}
+	var taxLines []domain.OrderTaxLine
+	for _, summary := range breakdown.Summaries {
+		line, err := domain.NewOrderTaxLine(summary.Class, summary.BasisPoints, money(summary.Net), money(summary.Tax))
+		if err != nil {
+			return domain.OrderTax{}, err
+		}
+		taxLines = append(taxLines, line)
+	}
+
+	subtotal := breakdown.Net
+	if s.rules.PricesIncludeTax() {
+		subtotal = breakdown.Gross
+	}
+	return domain.NewOrderTax(
+		taxed, taxLines, money(subtotal), money(breakdown.Tax), money(breakdown.Gross), s.rules.PricesIncludeTax(),
+	)
+}
@@ -0,0 +1,83 @@
+package service
+
+import (
+	"testing"
+	"toptal/internal/app/config"
+	"toptal/internal/app/domain"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func newTaxTestItem(t *testing.T, taxClass string, amount int64, currency string) domain.OrderItem {
+	price, err := domain.NewMoney(amount, currency)
+	require.NoError(t, err)
+	item, err := domain.NewOrderItem(1, "Book", "Author", price)
+	require.NoError(t, err)
+	require.NoError(t, item.SetTaxClass(taxClass))
+	return item
+}
+
+func newTaxTestAddress(t *testing.T, region, country string) domain.ShippingAddress {
+	address, err := domain.NewShippingAddress("Jane Doe", "1 Main St", "", "Springfield", "12345", region, country)
+	require.NoError(t, err)
+	return address
+}
+
+func TestTaxService_TaxOrderExclusive(t *testing.T) {
+	service, err := NewTaxService(&config.TaxConfig{Rates: []string{"DE:standard=19", "DE:reduced=7"}})
+	require.NoError(t, err)
+
+	tax, err := service.TaxOrder(newTaxTestAddress(t, "", "DE"), []domain.OrderItem{
+		newTaxTestItem(t, "reduced", 1000, "EUR"),
+		newTaxTestItem(t, "standard", 2000, "EUR"),
+	})
+	require.NoError(t, err)
+
+	assert.False(t, tax.PricesIncludeTax())
+	assert.Equal(t, int64(3000), tax.Subtotal().Amount())
+	assert.Equal(t, int64(450), tax.TaxTotal().Amount())
+	assert.Equal(t, int64(3450), tax.Total().Amount())
+	assert.Equal(t, "EUR", tax.Total().Currency())
+	items := tax.Items()
+	assert.Equal(t, int64(70), items[0].Tax().Amount())
+	assert.Equal(t, int64(380), items[1].Tax().Amount())
+	lines := tax.Lines()
+	require.Len(t, lines, 2)
+	assert.Equal(t, "reduced", lines[0].TaxClass())
+	assert.Equal(t, 700, lines[0].BasisPoints())
+	assert.Equal(t, int64(1000), lines[0].Net().Amount())
+}
+
+func TestTaxService_TaxOrderInclusive(t *testing.T) {
+	service, err := NewTaxService(&config.TaxConfig{PricesIncludeTax: true, Rates: []string{"DE:reduced=7"}})
+	require.NoError(t, err)
+
+	tax, err := service.TaxOrder(newTaxTestAddress(t, "", "DE"), []domain.OrderItem{newTaxTestItem(t, "reduced", 1070, "EUR")})
+	require.NoError(t, err)
+
+	assert.True(t, tax.PricesIncludeTax())
+	assert.Equal(t, int64(1070), tax.Subtotal().Amount())
+	assert.Equal(t, int64(70), tax.TaxTotal().Amount())
+	assert.Equal(t, int64(1070), tax.Total().Amount())
+	assert.Equal(t, int64(1000), tax.Lines()[0].Net().Amount())
+}
+
+func TestTaxService_TaxOrderRegion(t *testing.T) {
+	service, err := NewTaxService(&config.TaxConfig{Rates: []string{"US-CA:standard=7.25"}})
+	require.NoError(t, err)
+
+	taxed, err := service.TaxOrder(newTaxTestAddress(t, "CA", "US"), []domain.OrderItem{newTaxTestItem(t, "standard", 1000, "USD")})
+	require.NoError(t, err)
+	assert.Equal(t, int64(73), taxed.TaxTotal().Amount())
+
+	untaxed, err := service.TaxOrder(newTaxTestAddress(t, "OR", "US"), []domain.OrderItem{newTaxTestItem(t, "standard", 1000, "USD")})
+	require.NoError(t, err)
+	assert.Equal(t, int64(0), untaxed.TaxTotal().Amount())
+	assert.Equal(t, int64(1000), untaxed.Total().Amount())
+}
+
+func TestNewTaxService_InvalidRate(t *testing.T) {
+	_, err := NewTaxService(&config.TaxConfig{Rates: []string{"DE:standard"}})
+	assert.Error(t, err)
+}
@@ -8,9 +8,19 @@ import (
 type contextKey string
 
 const (
-	UserIDKey contextKey = "user_id"
+	UserIDKey     contextKey = "user_id"
+	APIKeyIDKey   contextKey = "api_key_id"
+	SessionIDKey  contextKey = "session_id"
+	ClientInfoKey contextKey = "client_info"
+	RequestIDKey  contextKey = "request_id"
 )
 
+// ClientInfo describes the device a request came from.
+type ClientInfo struct {
+	UserAgent string
+	IPAddress string
+}
+
 func GetUserID(ctx context.Context) (int, error) {
 	val := ctx.Value(UserIDKey)
 	if val == nil {
@@ -28,3 +38,59 @@ func GetUserID(ctx context.Context) (int, error) {
 func WithUserID(ctx context.Context, userID int) context.Context {
 	return context.WithValue(ctx, UserIDKey, userID)
 }
+
+func GetAPIKeyID(ctx context.Context) (int, error) {
+	val := ctx.Value(APIKeyIDKey)
+	if val == nil {
+		return 0, fmt.Errorf("api key ID not found in context")
+	}
+
+	apiKeyID, ok := val.(int)
+	if !ok {
+		return 0, fmt.Errorf("invalid api key ID type in context")
+	}
+
+	return apiKeyID, nil
+}
+
+func WithAPIKeyID(ctx context.Context, apiKeyID int) context.Context {
+	return context.WithValue(ctx, APIKeyIDKey, apiKeyID)
+}
+
+func GetSessionID(ctx context.Context) (int, error) {
+	val := ctx.Value(SessionIDKey)
+	if val == nil {
+		return 0, fmt.Errorf("session ID not found in context")
+	}
+
+	sessionID, ok := val.(int)
+	if !ok {
+		return 0, fmt.Errorf("invalid session ID type in context")
+	}
+
+	return sessionID, nil
+}
+
+func WithSessionID(ctx context.Context, sessionID int) context.Context {
+	return context.WithValue(ctx, SessionIDKey, sessionID)
+}
+
+// GetClientInfo returns the client of the request, or an empty ClientInfo outside of one.
+func GetClientInfo(ctx context.Context) ClientInfo {
+	info, _ := ctx.Value(ClientInfoKey).(ClientInfo)
+	return info
+}
+
+func WithClientInfo(ctx context.Context, info ClientInfo) context.Context {
+	return context.WithValue(ctx, ClientInfoKey, info)
+}
+
+// GetRequestID returns the id of the request, or an empty string outside of one.
+func GetRequestID(ctx context.Context) string {
+	requestID, _ := ctx.Value(RequestIDKey).(string)
+	return requestID
+}
+
+func WithRequestID(ctx context.Context, requestID string) context.Context {
+	return context.WithValue(ctx, RequestIDKey, requestID)
+}
@@ -0,0 +1,23 @@
+// Package blob keeps files, such as the ebooks the shop sells and book covers, under keys
+// chosen by the caller. Keys are slash separated paths like "books/12/epub/3f9a.epub".
+package blob
+
+import (
+	"context"
+	"errors"
+	"io"
+)
+
+var (
+	ErrNotFound   = errors.New("blob not found")
+	ErrInvalidKey = errors.New("invalid blob key")
+)
+
+type Store interface {
+	// Put stores the content of r under key, replacing what was there, and returns its size.
+	Put(ctx context.Context, key string, r io.Reader) (int64, error)
+	// Open returns the content stored under key. The caller closes it.
+	Open(ctx context.Context, key string) (io.ReadSeekCloser, error)
+	// Delete removes key. Deleting a missing key is not an error.
+	Delete(ctx context.Context, key string) error
+}
@@ -0,0 +1,45 @@
+package blob
+
+import (
+	"context"
+	"io"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestFileStore(t *testing.T) {
+	ctx := context.Background()
+	store, err := NewFileStore(t.TempDir())
+	require.NoError(t, err)
+
+	size, err := store.Put(ctx, "books/1/epub/a.epub", strings.NewReader("first"))
+	require.NoError(t, err)
+	assert.Equal(t, int64(5), size)
+	_, err = store.Put(ctx, "books/1/epub/a.epub", strings.NewReader("second"))
+	require.NoError(t, err)
+
+	file, err := store.Open(ctx, "books/1/epub/a.epub")
+	require.NoError(t, err)
+	content, err := io.ReadAll(file)
+	require.NoError(t, err)
+	require.NoError(t, file.Close())
+	assert.Equal(t, "second", string(content))
+
+	require.NoError(t, store.Delete(ctx, "books/1/epub/a.epub"))
+	require.NoError(t, store.Delete(ctx, "books/1/epub/a.epub"))
+	_, err = store.Open(ctx, "books/1/epub/a.epub")
+	assert.ErrorIs(t, err, ErrNotFound)
+}
+
+func TestFileStore_InvalidKeys(t *testing.T) {
+	store, err := NewFileStore(t.TempDir())
+	require.NoError(t, err)
+
+	for _, key := range []string{"", "../secret", "/etc/passwd", "books/../../x", `books\x`, "books/"} {
+		_, err := store.Put(context.Background(), key, strings.NewReader("x"))
+		assert.ErrorIs(t, err, ErrInvalidKey, key)
+	}
+}
@@ -0,0 +1,88 @@
+// Package imaging scales images down in pure Go, for renditions such as thumbnails.
+package imaging
+
+import (
+	"image"
+	"image/color"
+	"image/draw"
+)
+
+// Fit scales src down to width pixels wide, keeping its aspect ratio, and paints it over
+// background so the result is opaque. Images that are already narrower keep their size.
+// Each pixel of the result is the average of the pixels of src it covers.
+func Fit(src image.Image, width int, background color.Color) *image.RGBA {
+	bounds := src.Bounds()
+	srcWidth, srcHeight := bounds.Dx(), bounds.Dy()
+	if width <= 0 || width > srcWidth {
+		width = srcWidth
+	}
+	height := max(1, (srcHeight*width+srcWidth/2)/srcWidth)
+
+	flat := image.NewRGBA(image.Rect(0, 0, srcWidth, srcHeight))
+	draw.Draw(flat, flat.Bounds(), image.NewUniform(background), image.Point{}, draw.Src)
+	draw.Draw(flat, flat.Bounds(), src, bounds.Min, draw.Over)
+	if width == srcWidth && height == srcHeight {
+		return flat
+	}
+	return scaleDown(flat, width, height)
+}
+
+// scaleDown resizes src with a box filter, first across then down. Source pixels on the
+// edge of a destination pixel count for the part of them it covers.
+func scaleDown(src *image.RGBA, width, height int) *image.RGBA {
+	srcWidth, srcHeight := src.Rect.Dx(), src.Rect.Dy()
+
+	rows := make([]float64, width*srcHeight*4)
+	for x, span := range spans(srcWidth, width) {
+		for y := 0; y < srcHeight; y++ {
+			var sum [4]float64
+			for _, w := range span {
+				offset := src.PixOffset(w.index, y)
+				for c := 0; c < 4; c++ {
+					sum[c] += float64(src.Pix[offset+c]) * w.weight
+				}
+			}
+			copy(rows[(y*width+x)*4:], sum[:])
+		}
+	}
+
+	dst := image.NewRGBA(image.Rect(0, 0, width, height))
+	for y, span := range spans(srcHeight, height) {
+		for x := 0; x < width; x++ {
+			var sum [4]float64
+			for _, w := range span {
+				offset := (w.index*width + x) * 4
+				for c := 0; c < 4; c++ {
+					sum[c] += rows[offset+c] * w.weight
+				}
+			}
+			offset := dst.PixOffset(x, y)
+			for c := 0; c < 4; c++ {
+				dst.Pix[offset+c] = uint8(min(255, sum[c]+0.5))
+			}
+		}
+	}
+	return dst
+}
+
+type weight struct {
+	index  int
+	weight float64
+}
+
+// spans returns, for each of the n destination pixels along an axis of srcSize source
+// pixels, the source pixels it covers with weights adding up to one.
+func spans(srcSize, n int) [][]weight {
+	scale := float64(srcSize) / float64(n)
+	result := make([][]weight, n)
+	for i := range result {
+		start, end := float64(i)*scale, float64(i+1)*scale
+		for j := int(start); j < srcSize && float64(j) < end; j++ {
+			covered := min(end, float64(j+1)) - max(start, float64(j))
+			if covered > 0 {
+				result[i] = append(result[i], weight{index: j, weight: covered / scale})
+			}
+		}
+	}
+	return result
+}
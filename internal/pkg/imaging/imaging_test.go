@@ -0,0 +1,41 @@
+package imaging
+
+import (
+	"image"
+	"image/color"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestFit(t *testing.T) {
+	src := image.NewNRGBA(image.Rect(0, 0, 400, 600))
+	for y := 0; y < 600; y++ {
+		for x := 0; x < 400; x++ {
+			if x < 200 {
+				src.Set(x, y, color.NRGBA{R: 255, A: 255})
+			} else {
+				src.Set(x, y, color.NRGBA{B: 255, A: 255})
+			}
+		}
+	}
+
+	scaled := Fit(src, 100, color.White)
+	assert.Equal(t, image.Rect(0, 0, 100, 150), scaled.Bounds())
+	assert.Equal(t, color.RGBA{R: 255, A: 255}, scaled.RGBAAt(10, 75))
+	assert.Equal(t, color.RGBA{B: 255, A: 255}, scaled.RGBAAt(90, 75))
+
+	odd := Fit(src, 3, color.White)
+	assert.Equal(t, image.Rect(0, 0, 3, 5), odd.Bounds())
+	middle := odd.RGBAAt(1, 2)
+	assert.InDelta(t, 128, middle.R, 1)
+	assert.InDelta(t, 128, middle.B, 1)
+}
+
+func TestFit_Transparency(t *testing.T) {
+	src := image.NewNRGBA(image.Rect(10, 10, 20, 20))
+
+	scaled := Fit(src, 50, color.White)
+	assert.Equal(t, image.Rect(0, 0, 10, 10), scaled.Bounds(), "small images are not enlarged")
+	assert.Equal(t, color.RGBA{R: 255, G: 255, B: 255, A: 255}, scaled.RGBAAt(5, 5))
+}
@@ -0,0 +1,41 @@
+package mailer
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"sync/atomic"
+	"time"
+)
+
+// FileMailer writes each message to an .eml file in dir, or only logs it when dir is
+// empty. It is meant for local development and tests.
+type FileMailer struct {
+	dir   string
+	from  string
+	count atomic.Int64
+}
+
+func NewFileMailer(dir string, from string) (*FileMailer, error) {
+	if dir != "" {
+		if err := os.MkdirAll(dir, 0o750); err != nil {
+			return nil, fmt.Errorf("failed to create mail directory: %w", err)
+		}
+	}
+	return &FileMailer{dir: dir, from: from}, nil
+}
+
+func (m *FileMailer) Send(_ context.Context, message Message) error {
+	slog.Info("Email sent", "to", message.To, "subject", message.Subject, "body", message.Body)
+	if m.dir == "" {
+		return nil
+	}
+
+	name := fmt.Sprintf("%s-%d.eml", time.Now().Format("20060102T150405.000000000"), m.count.Add(1))
+	if err := os.WriteFile(filepath.Join(m.dir, name), formatMessage(m.from, message), 0o640); err != nil {
+		return fmt.Errorf("failed to write email file: %w", err)
+	}
+	return nil
+}
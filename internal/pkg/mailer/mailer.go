@@ -0,0 +1,15 @@
+// Package mailer delivers plain-text email. Application code does not call it
+// directly: messages are written to the email outbox and delivered by a background job.
+package mailer
+
+import "context"
+
+type Message struct {
+	To      string
+	Subject string
+	Body    string
+}
+
+type Mailer interface {
+	Send(ctx context.Context, message Message) error
+}
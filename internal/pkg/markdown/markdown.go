@@ -0,0 +1,70 @@
+// Package markdown cleans up Markdown written by shop staff before it is stored, so
+// storefronts can render it without further checks.
+package markdown
+
+import (
+	"regexp"
+	"strings"
+	"unicode"
+)
+
+var (
+	htmlComment = regexp.MustCompile(`(?s)<!--.*?(-->|$)`)
+	// autolink matches <https://...> and <mailto:...>, which are Markdown rather than HTML.
+	autolink = regexp.MustCompile(`(?i)^<(https?://|mailto:)[^<>\s]*>`)
+	htmlTag  = regexp.MustCompile(`^</?[A-Za-z!?][^<>]*>`)
+	// linkTarget matches the target of an inline link or image, ](target, and of a link
+	// reference definition, [label]: target. Targets may hold balanced parentheses.
+	linkTarget    = regexp.MustCompile(`(\]\(\s*<?|(?m)^ {0,3}\[[^\]]+\]:\s*<?)((?:[^\s()>]|\([^\s()]*\))*)`)
+	allowedScheme = regexp.MustCompile(`(?i)^(https?|mailto):`)
+	scheme        = regexp.MustCompile(`^[A-Za-z][A-Za-z0-9+.-]*:`)
+)
+
+// Sanitize removes raw HTML and control characters from Markdown, and the targets of
+// links and images that are neither relative nor http, https or mailto URLs. Line endings
+// become \n and surrounding whitespace is trimmed.
+func Sanitize(s string) string {
+	s = strings.ReplaceAll(s, "\r\n", "\n")
+	s = strings.ReplaceAll(s, "\r", "\n")
+	s = strings.Map(func(r rune) rune {
+		if r == unicode.ReplacementChar || (unicode.IsControl(r) && r != '\n' && r != '\t') {
+			return -1
+		}
+		return r
+	}, s)
+	s = htmlComment.ReplaceAllString(s, "")
+	s = stripTags(s)
+	s = linkTarget.ReplaceAllStringFunc(s, func(match string) string {
+		parts := linkTarget.FindStringSubmatch(match)
+		target := parts[2]
+		if scheme.MatchString(target) && !allowedScheme.MatchString(target) {
+			return parts[1] + "#"
+		}
+		return match
+	})
+	return strings.TrimSpace(s)
+}
+
+// stripTags removes HTML tags but keeps autolinks and text that merely contains a <, such
+// as "a < b".
+func stripTags(s string) string {
+	var b strings.Builder
+	for {
+		i := strings.IndexByte(s, '<')
+		if i < 0 {
+			b.WriteString(s)
+			return b.String()
+		}
+		b.WriteString(s[:i])
+		s = s[i:]
+		if link := autolink.FindString(s); link != "" {
+			b.WriteString(link)
+			s = s[len(link):]
+		} else if tag := htmlTag.FindString(s); tag != "" {
+			s = s[len(tag):]
+		} else {
+			b.WriteByte('<')
+			s = s[1:]
+		}
+	}
+}
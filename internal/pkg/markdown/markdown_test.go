@@ -0,0 +1,30 @@
+package markdown
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSanitize(t *testing.T) {
+	tests := []struct {
+		name     string
+		input    string
+		expected string
+	}{
+		{"plain Markdown", "# Dune\r\n\r\nA *classic* of **science fiction**.", "# Dune\n\nA *classic* of **science fiction**."},
+		{"HTML tags", `Hello <script>alert("x")</script><b>world</b>`, `Hello alert("x")world`},
+		{"comments", "before<!-- hidden -->after<!-- unclosed", "beforeafter"},
+		{"comparisons", "a < b and 3<4", "a < b and 3<4"},
+		{"autolinks", "See <https://example.com/a> or <javascript:alert(1)>", "See <https://example.com/a> or"},
+		{"links", "[ok](https://example.com) [rel](/book/1) [bad](javascript:alert(1)) ![img](data:image/png;base64,x)",
+			"[ok](https://example.com) [rel](/book/1) [bad](#) ![img](#)"},
+		{"reference links", "[a][1]\n\n[1]: vbscript:msgbox", "[a][1]\n\n[1]: #"},
+		{"control characters", "tab\tand\x00null\x1b", "tab\tandnull"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.expected, Sanitize(tt.input))
+		})
+	}
+}
@@ -0,0 +1,106 @@
+package oidc
+
+import (
+	"context"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"toptal/internal/pkg/oidc/oidctest"
+)
+
+const redirectURL = "https://shop.example/oidc/test/callback"
+
+func setup(t *testing.T) (*oidctest.Provider, *Client) {
+	provider, err := oidctest.NewProvider("book-shop", "secret")
+	require.NoError(t, err)
+	t.Cleanup(provider.Close)
+
+	client := NewClient(Config{
+		Issuer:       provider.Issuer(),
+		ClientID:     "book-shop",
+		ClientSecret: "secret",
+		RedirectURL:  redirectURL,
+	}, http.DefaultClient)
+	return provider, client
+}
+
+func TestClient_AuthorizationCodeFlow(t *testing.T) {
+	ctx := context.Background()
+	provider, client := setup(t)
+
+	verifier, err := GenerateRandom()
+	require.NoError(t, err)
+	authURL, err := client.AuthCodeURL(ctx, "state-1", "nonce-1", verifier)
+	require.NoError(t, err)
+
+	callback, err := provider.Authorize(authURL, oidctest.User{
+		Subject: "user-1", Email: "alice@corp.example", EmailVerified: true, PreferredUsername: "alice",
+	})
+	require.NoError(t, err)
+	assert.Equal(t, "state-1", callback.Query().Get("state"))
+
+	idToken, err := client.Exchange(ctx, callback.Query().Get("code"), verifier)
+	require.NoError(t, err)
+
+	claims, err := client.VerifyIDToken(ctx, idToken, "nonce-1")
+	require.NoError(t, err)
+	assert.Equal(t, "user-1", claims.Subject)
+	assert.Equal(t, "alice@corp.example", claims.Email)
+	assert.True(t, claims.EmailVerified)
+	assert.Equal(t, "alice", claims.PreferredUsername)
+
+	_, err = client.VerifyIDToken(ctx, idToken, "other-nonce")
+	assert.ErrorIs(t, err, ErrInvalidIDToken)
+}
+
+func TestClient_ExchangeRequiresMatchingVerifier(t *testing.T) {
+	ctx := context.Background()
+	provider, client := setup(t)
+
+	authURL, err := client.AuthCodeURL(ctx, "state", "nonce", "the-real-verifier")
+	require.NoError(t, err)
+	callback, err := provider.Authorize(authURL, oidctest.User{Subject: "user-1"})
+	require.NoError(t, err)
+
+	_, err = client.Exchange(ctx, callback.Query().Get("code"), "a-guessed-verifier")
+	assert.Error(t, err)
+}
+
+func TestClient_VerifyIDTokenRejectsBadClaims(t *testing.T) {
+	ctx := context.Background()
+	provider, client := setup(t)
+	now := time.Now()
+
+	valid := func() jwt.MapClaims {
+		return jwt.MapClaims{
+			"iss": provider.Issuer(), "aud": "book-shop", "sub": "user-1", "nonce": "n",
+			"iat": now.Unix(), "exp": now.Add(time.Minute).Unix(),
+		}
+	}
+	token, err := provider.SignIDToken(valid())
+	require.NoError(t, err)
+	_, err = client.VerifyIDToken(ctx, token, "n")
+	require.NoError(t, err)
+
+	for name, mutate := range map[string]func(jwt.MapClaims){
+		"wrong audience": func(c jwt.MapClaims) { c["aud"] = "someone-else" },
+		"wrong issuer":   func(c jwt.MapClaims) { c["iss"] = "https://evil.example" },
+		"expired":        func(c jwt.MapClaims) { c["exp"] = now.Add(-time.Hour).Unix() },
+		"no expiry":      func(c jwt.MapClaims) { delete(c, "exp") },
+		"foreign azp":    func(c jwt.MapClaims) { c["aud"] = []string{"book-shop", "other"}; c["azp"] = "other" },
+	} {
+		t.Run(name, func(t *testing.T) {
+			claims := valid()
+			mutate(claims)
+			token, err := provider.SignIDToken(claims)
+			require.NoError(t, err)
+			_, err = client.VerifyIDToken(ctx, token, "n")
+			assert.ErrorIs(t, err, ErrInvalidIDToken)
+		})
+	}
+}
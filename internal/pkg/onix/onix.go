@@ -0,0 +1,438 @@
+// Package onix reads ONIX for Books 3.0 messages, the XML format publishers use to send
+// product metadata. Products are read one at a time, so large feeds are never held in
+// memory. Both the reference and the short tag names are understood, and only the parts of
+// a product a book shop needs are decoded.
+package onix
+
+import (
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"io"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// Notification types of code list 1.
+const (
+	NotificationEarly     = "01"
+	NotificationAdvance   = "02"
+	NotificationConfirmed = "03"
+	NotificationUpdate    = "04"
+	NotificationDelete    = "05"
+)
+
+// Product identifier types of code list 5.
+const (
+	IdentifierISBN10 = "02"
+	IdentifierGTIN13 = "03"
+	IdentifierISBN13 = "15"
+)
+
+const (
+	// TitleTypeDistinctive is the title of the product itself, code list 15.
+	TitleTypeDistinctive = "01"
+	// TitleLevelProduct is a title element at product level, code list 149.
+	TitleLevelProduct = "01"
+	// RoleAuthor is "By (author)", code list 17.
+	RoleAuthor = "A01"
+	// DateRolePublication is the publication date, code list 163.
+	DateRolePublication = "01"
+	// PriceTypeRRPExcludingTax is the default price type, code list 58.
+	PriceTypeRRPExcludingTax = "01"
+)
+
+var ErrUnsupportedRelease = errors.New("only ONIX 3.0 is supported")
+
+type Product struct {
+	RecordReference    string              `xml:"RecordReference"`
+	NotificationType   string              `xml:"NotificationType"`
+	ProductIdentifiers []ProductIdentifier `xml:"ProductIdentifier"`
+	DescriptiveDetail  DescriptiveDetail   `xml:"DescriptiveDetail"`
+	PublishingDetail   PublishingDetail    `xml:"PublishingDetail"`
+	ProductSupplies    []ProductSupply     `xml:"ProductSupply"`
+}
+
+type ProductIdentifier struct {
+	ProductIDType string `xml:"ProductIDType"`
+	IDValue       string `xml:"IDValue"`
+}
+
+type DescriptiveDetail struct {
+	TitleDetails []TitleDetail `xml:"TitleDetail"`
+	Contributors []Contributor `xml:"Contributor"`
+	Subjects     []Subject     `xml:"Subject"`
+}
+
+type TitleDetail struct {
+	TitleType     string         `xml:"TitleType"`
+	TitleElements []TitleElement `xml:"TitleElement"`
+}
+
+type TitleElement struct {
+	TitleElementLevel  string `xml:"TitleElementLevel"`
+	TitleText          string `xml:"TitleText"`
+	TitlePrefix        string `xml:"TitlePrefix"`
+	TitleWithoutPrefix string `xml:"TitleWithoutPrefix"`
+	Subtitle           string `xml:"Subtitle"`
+}
+
+type Contributor struct {
+	SequenceNumber   int      `xml:"SequenceNumber"`
+	ContributorRoles []string `xml:"ContributorRole"`
+	PersonName       string   `xml:"PersonName"`
+	NamesBeforeKey   string   `xml:"NamesBeforeKey"`
+	KeyNames         string   `xml:"KeyNames"`
+	CorporateName    string   `xml:"CorporateName"`
+}
+
+type Subject struct {
+	MainSubject             *struct{} `xml:"MainSubject"`
+	SubjectSchemeIdentifier string    `xml:"SubjectSchemeIdentifier"`
+	SubjectCode             string    `xml:"SubjectCode"`
+	SubjectHeadingText      string    `xml:"SubjectHeadingText"`
+}
+
+type PublishingDetail struct {
+	PublishingDates []PublishingDate `xml:"PublishingDate"`
+}
+
+type PublishingDate struct {
+	PublishingDateRole string `xml:"PublishingDateRole"`
+	Date               string `xml:"Date"`
+}
+
+type ProductSupply struct {
+	SupplyDetails []SupplyDetail `xml:"SupplyDetail"`
+}
+
+type SupplyDetail struct {
+	ProductAvailability string  `xml:"ProductAvailability"`
+	Stocks              []Stock `xml:"Stock"`
+	Prices              []Price `xml:"Price"`
+}
+
+type Stock struct {
+	OnHand string `xml:"OnHand"`
+}
+
+type Price struct {
+	PriceType    string `xml:"PriceType"`
+	PriceAmount  string `xml:"PriceAmount"`
+	CurrencyCode string `xml:"CurrencyCode"`
+}
+
+// Deleted reports whether the product is a deletion notice.
+func (p *Product) Deleted() bool {
+	return p.NotificationType == NotificationDelete
+}
+
+// ISBN returns the ISBN of the product as sent, preferring an ISBN-13, or "" when it has
+// none.
+func (p *Product) ISBN() string {
+	for _, idType := range []string{IdentifierISBN13, IdentifierGTIN13, IdentifierISBN10} {
+		for _, id := range p.ProductIdentifiers {
+			value := strings.TrimSpace(id.IDValue)
+			// A GTIN-13 is only an ISBN in the Bookland ranges.
+			if idType == IdentifierGTIN13 && !strings.HasPrefix(value, "978") && !strings.HasPrefix(value, "979") {
+				continue
+			}
+			if id.ProductIDType == idType && value != "" {
+				return value
+			}
+		}
+	}
+	return ""
+}
+
+// Title returns the distinctive title of the product, with its prefix such as "The".
+func (p *Product) Title() string {
+	for _, detail := range p.DescriptiveDetail.TitleDetails {
+		if detail.TitleType != TitleTypeDistinctive {
+			continue
+		}
+		for _, element := range detail.TitleElements {
+			if element.TitleElementLevel != TitleLevelProduct && element.TitleElementLevel != "" {
+				continue
+			}
+			if title := strings.TrimSpace(element.TitleText); title != "" {
+				return title
+			}
+			return strings.TrimSpace(strings.TrimSpace(element.TitlePrefix) + " " + strings.TrimSpace(element.TitleWithoutPrefix))
+		}
+	}
+	return ""
+}
+
+// Authors returns the names of the authors in sequence. Products without an author credit
+// all their contributors.
+func (p *Product) Authors() []string {
+	contributors := append([]Contributor(nil), p.DescriptiveDetail.Contributors...)
+	sort.SliceStable(contributors, func(i, j int) bool {
+		return contributors[i].SequenceNumber < contributors[j].SequenceNumber
+	})
+	var authors, others []string
+	for _, contributor := range contributors {
+		name := contributor.Name()
+		if name == "" {
+			continue
+		}
+		if contributor.HasRole(RoleAuthor) {
+			authors = append(authors, name)
+		} else {
+			others = append(others, name)
+		}
+	}
+	if len(authors) == 0 {
+		return others
+	}
+	return authors
+}
+
+func (c *Contributor) Name() string {
+	if name := strings.TrimSpace(c.PersonName); name != "" {
+		return name
+	}
+	if name := strings.TrimSpace(strings.TrimSpace(c.NamesBeforeKey) + " " + strings.TrimSpace(c.KeyNames)); name != "" {
+		return name
+	}
+	return strings.TrimSpace(c.CorporateName)
+}
+
+func (c *Contributor) HasRole(role string) bool {
+	for _, r := range c.ContributorRoles {
+		if strings.TrimSpace(r) == role {
+			return true
+		}
+	}
+	return false
+}
+
+// Subjects returns the subjects of the product, main subjects first.
+func (p *Product) Subjects() []Subject {
+	subjects := append([]Subject(nil), p.DescriptiveDetail.Subjects...)
+	sort.SliceStable(subjects, func(i, j int) bool {
+		return subjects[i].MainSubject != nil && subjects[j].MainSubject == nil
+	})
+	return subjects
+}
+
+// PublicationYear returns the year of the publication date, or 0 when it has none.
+func (p *Product) PublicationYear() int {
+	for _, date := range p.PublishingDetail.PublishingDates {
+		value := strings.TrimSpace(date.Date)
+		if date.PublishingDateRole != DateRolePublication || len(value) < 4 {
+			continue
+		}
+		if year, err := strconv.Atoi(value[:4]); err == nil {
+			return year
+		}
+	}
+	return 0
+}
+
+// Price returns the first price of one of the types, tried in order, in currency; any
+// currency matches when currency is empty. Prices without a type are of the default type.
+func (p *Product) Price(types []string, currency string) (Price, bool) {
+	for _, priceType := range types {
+		for _, supply := range p.ProductSupplies {
+			for _, detail := range supply.SupplyDetails {
+				for _, price := range detail.Prices {
+					t := price.PriceType
+					if t == "" {
+						t = PriceTypeRRPExcludingTax
+					}
+					if t == priceType && (currency == "" || price.CurrencyCode == currency) {
+						return price, true
+					}
+				}
+			}
+		}
+	}
+	return Price{}, false
+}
+
+// Availability returns the availability code of the first supply detail, code list 65.
+func (p *Product) Availability() string {
+	for _, supply := range p.ProductSupplies {
+		if len(supply.SupplyDetails) > 0 {
+			return strings.TrimSpace(supply.SupplyDetails[0].ProductAvailability)
+		}
+	}
+	return ""
+}
+
+// OnHand returns the stock on hand of the first supply detail that states it.
+func (p *Product) OnHand() (int, bool) {
+	for _, supply := range p.ProductSupplies {
+		for _, detail := range supply.SupplyDetails {
+			for _, stock := range detail.Stocks {
+				if onHand, err := strconv.Atoi(strings.TrimSpace(stock.OnHand)); err == nil {
+					return max(onHand, 0), true
+				}
+			}
+		}
+	}
+	return 0, false
+}
+
+// Unavailable reports whether an availability code says the product cannot be ordered:
+// cancelled, temporarily or permanently unavailable.
+func Unavailable(code string) bool {
+	return code == "01" || strings.HasPrefix(code, "3") || strings.HasPrefix(code, "4") || strings.HasPrefix(code, "5")
+}
+
+// Reader reads the products of a message.
+type Reader struct {
+	source  *xml.Decoder
+	decoder *xml.Decoder
+}
+
+func NewReader(r io.Reader) *Reader {
+	source := xml.NewDecoder(r)
+	source.Entity = xml.HTMLEntity
+	source.CharsetReader = charsetReader
+	return &Reader{source: source, decoder: xml.NewTokenDecoder(&referenceNames{source})}
+}
+
+// Next returns the next product and the line it starts on, or io.EOF after the last one.
+func (r *Reader) Next() (Product, int, error) {
+	for {
+		token, err := r.decoder.Token()
+		if err != nil {
+			return Product{}, 0, err
+		}
+		start, ok := token.(xml.StartElement)
+		if !ok {
+			continue
+		}
+		switch start.Name.Local {
+		case "ONIXMessage":
+			for _, attr := range start.Attr {
+				if attr.Name.Local == "release" && !strings.HasPrefix(attr.Value, "3.") {
+					return Product{}, 0, fmt.Errorf("%w, the message is release %s", ErrUnsupportedRelease, attr.Value)
+				}
+			}
+		case "Product":
+			line, _ := r.source.InputPos()
+			var product Product
+			if err := r.decoder.DecodeElement(&product, &start); err != nil {
+				return Product{}, line, err
+			}
+			return product, line, nil
+		case "Header":
+			if err := r.decoder.Skip(); err != nil {
+				return Product{}, 0, err
+			}
+		}
+	}
+}
+
+// referenceNames renames the short tags of a message to their reference names.
+type referenceNames struct {
+	source xml.TokenReader
+}
+
+func (n *referenceNames) Token() (xml.Token, error) {
+	token, err := n.source.Token()
+	switch t := token.(type) {
+	case xml.StartElement:
+		if name, ok := shortTags[t.Name.Local]; ok {
+			t.Name.Local = name
+		}
+		return t, err
+	case xml.EndElement:
+		if name, ok := shortTags[t.Name.Local]; ok {
+			t.Name.Local = name
+		}
+		return t, err
+	}
+	return token, err
+}
+
+// shortTags maps the short tags of the elements decoded to their reference names.
+var shortTags = map[string]string{
+	"ONIXmessage":       "ONIXMessage",
+	"header":            "Header",
+	"product":           "Product",
+	"a001":              "RecordReference",
+	"a002":              "NotificationType",
+	"productidentifier": "ProductIdentifier",
+	"b221":              "ProductIDType",
+	"b244":              "IDValue",
+	"descriptivedetail": "DescriptiveDetail",
+	"titledetail":       "TitleDetail",
+	"b202":              "TitleType",
+	"titleelement":      "TitleElement",
+	"x409":              "TitleElementLevel",
+	"b203":              "TitleText",
+	"b030":              "TitlePrefix",
+	"b031":              "TitleWithoutPrefix",
+	"b029":              "Subtitle",
+	"contributor":       "Contributor",
+	"b034":              "SequenceNumber",
+	"b035":              "ContributorRole",
+	"b036":              "PersonName",
+	"b039":              "NamesBeforeKey",
+	"b040":              "KeyNames",
+	"b047":              "CorporateName",
+	"subject":           "Subject",
+	"x425":              "MainSubject",
+	"b067":              "SubjectSchemeIdentifier",
+	"b069":              "SubjectCode",
+	"b070":              "SubjectHeadingText",
+	"publishingdetail":  "PublishingDetail",
+	"publishingdate":    "PublishingDate",
+	"x448":              "PublishingDateRole",
+	"b306":              "Date",
+	"productsupply":     "ProductSupply",
+	"supplydetail":      "SupplyDetail",
+	"j396":              "ProductAvailability",
+	"stock":             "Stock",
+	"j350":              "OnHand",
+	"price":             "Price",
+	"x462":              "PriceType",
+	"j151":              "PriceAmount",
+	"j152":              "CurrencyCode",
+}
+
+// charsetReader reads the Latin-1 messages some older systems still send; everything else
+// must be UTF-8.
+func charsetReader(charset string, input io.Reader) (io.Reader, error) {
+	switch strings.ToLower(charset) {
+	case "utf-8", "utf8":
+		return input, nil
+	case "iso-8859-1", "latin1", "latin-1":
+		return &latin1Reader{source: input}, nil
+	default:
+		return nil, fmt.Errorf("unsupported charset %q", charset)
+	}
+}
+
+type latin1Reader struct {
+	source io.Reader
+	buf    []byte
+	out    []byte
+}
+
+func (l *latin1Reader) Read(p []byte) (int, error) {
+	for len(l.out) == 0 {
+		if cap(l.buf) == 0 {
+			l.buf = make([]byte, 4096)
+		}
+		n, err := l.source.Read(l.buf)
+		for _, b := range l.buf[:n] {
+			l.out = append(l.out, string(rune(b))...)
+		}
+		if err != nil && len(l.out) == 0 {
+			return 0, err
+		}
+		if err != nil {
+			break
+		}
+	}
+	n := copy(p, l.out)
+	l.out = l.out[n:]
+	return n, nil
+}
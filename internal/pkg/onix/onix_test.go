@@ -0,0 +1,123 @@
+package onix
+
+import (
+	"errors"
+	"io"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const referenceMessage = `<?xml version="1.0" encoding="UTF-8"?>
+<ONIXMessage release="3.0" xmlns="http://ns.editeur.org/onix/3.0/reference">
+  <Header><Sender><SenderName>Ace</SenderName></Sender></Header>
+  <Product>
+    <RecordReference>ace.0441172717</RecordReference>
+    <NotificationType>03</NotificationType>
+    <ProductIdentifier><ProductIDType>01</ProductIDType><IDValue>ACE-1</IDValue></ProductIdentifier>
+    <ProductIdentifier><ProductIDType>15</ProductIDType><IDValue>9780441172719</IDValue></ProductIdentifier>
+    <DescriptiveDetail>
+      <TitleDetail>
+        <TitleType>01</TitleType>
+        <TitleElement>
+          <TitleElementLevel>01</TitleElementLevel>
+          <TitlePrefix>The</TitlePrefix><TitleWithoutPrefix>Dune Chronicles</TitleWithoutPrefix>
+        </TitleElement>
+      </TitleDetail>
+      <Contributor><SequenceNumber>2</SequenceNumber><ContributorRole>B01</ContributorRole><PersonName>An Editor</PersonName></Contributor>
+      <Contributor><SequenceNumber>1</SequenceNumber><ContributorRole>A01</ContributorRole><NamesBeforeKey>Frank</NamesBeforeKey><KeyNames>Herbert</KeyNames></Contributor>
+      <Subject><SubjectSchemeIdentifier>93</SubjectSchemeIdentifier><SubjectCode>FMB</SubjectCode></Subject>
+      <Subject><MainSubject/><SubjectSchemeIdentifier>10</SubjectSchemeIdentifier><SubjectCode>FIC028000</SubjectCode></Subject>
+    </DescriptiveDetail>
+    <PublishingDetail>
+      <PublishingDate><PublishingDateRole>01</PublishingDateRole><Date>19650801</Date></PublishingDate>
+    </PublishingDetail>
+    <ProductSupply>
+      <SupplyDetail>
+        <ProductAvailability>21</ProductAvailability>
+        <Stock><OnHand>12</OnHand></Stock>
+        <Price><PriceType>02</PriceType><PriceAmount>10.99</PriceAmount><CurrencyCode>EUR</CurrencyCode></Price>
+        <Price><PriceAmount>9.99</PriceAmount><CurrencyCode>USD</CurrencyCode></Price>
+      </SupplyDetail>
+    </ProductSupply>
+  </Product>
+  <Product>
+    <RecordReference>ace.old</RecordReference>
+    <NotificationType>05</NotificationType>
+    <ProductIdentifier><ProductIDType>02</ProductIDType><IDValue>0441172717</IDValue></ProductIdentifier>
+  </Product>
+</ONIXMessage>`
+
+func TestReader_ReferenceTags(t *testing.T) {
+	reader := NewReader(strings.NewReader(referenceMessage))
+
+	product, line, err := reader.Next()
+	require.NoError(t, err)
+	assert.Equal(t, 4, line)
+	assert.False(t, product.Deleted())
+	assert.Equal(t, "9780441172719", product.ISBN())
+	assert.Equal(t, "The Dune Chronicles", product.Title())
+	assert.Equal(t, []string{"Frank Herbert"}, product.Authors())
+	assert.Equal(t, 1965, product.PublicationYear())
+	subjects := product.Subjects()
+	require.Len(t, subjects, 2)
+	assert.Equal(t, "FIC028000", subjects[0].SubjectCode)
+	onHand, ok := product.OnHand()
+	assert.True(t, ok)
+	assert.Equal(t, 12, onHand)
+	assert.Equal(t, "21", product.Availability())
+
+	price, ok := product.Price([]string{PriceTypeRRPExcludingTax}, "")
+	assert.True(t, ok)
+	assert.Equal(t, "9.99", price.PriceAmount)
+	price, ok = product.Price([]string{"02", "01"}, "USD")
+	assert.True(t, ok)
+	assert.Equal(t, "USD", price.CurrencyCode)
+	_, ok = product.Price([]string{"01"}, "GBP")
+	assert.False(t, ok)
+
+	product, _, err = reader.Next()
+	require.NoError(t, err)
+	assert.True(t, product.Deleted())
+	assert.Equal(t, "0441172717", product.ISBN())
+
+	_, _, err = reader.Next()
+	assert.ErrorIs(t, err, io.EOF)
+}
+
+func TestReader_ShortTags(t *testing.T) {
+	message := `<?xml version="1.0" encoding="ISO-8859-1"?>
+<ONIXmessage release="3.0">
+<product><a001>r1</a001><a002>04</a002>
+<productidentifier><b221>03</b221><b244>9780441172719</b244></productidentifier>
+<descriptivedetail>
+<titledetail><b202>01</b202><titleelement><x409>01</x409><b203>Caf` + "\xe9" + ` &amp; Crime</b203></titleelement></titledetail>
+<contributor><b034>1</b034><b035>A01</b035><b036>Ann Author</b036></contributor>
+</descriptivedetail>
+</product>
+</ONIXmessage>`
+	product, _, err := NewReader(strings.NewReader(message)).Next()
+	require.NoError(t, err)
+	assert.Equal(t, NotificationUpdate, product.NotificationType)
+	assert.Equal(t, "9780441172719", product.ISBN())
+	assert.Equal(t, "Café & Crime", product.Title())
+	assert.Equal(t, []string{"Ann Author"}, product.Authors())
+	_, ok := product.OnHand()
+	assert.False(t, ok)
+}
+
+func TestReader_Release(t *testing.T) {
+	_, _, err := NewReader(strings.NewReader(`<ONIXMessage release="2.1"><Product/></ONIXMessage>`)).Next()
+	assert.True(t, errors.Is(err, ErrUnsupportedRelease))
+
+	_, _, err = NewReader(strings.NewReader(`<ONIXMessage release="3.0"><Product>`)).Next()
+	assert.Error(t, err)
+}
+
+func TestUnavailable(t *testing.T) {
+	for code, unavailable := range map[string]bool{"20": false, "21": false, "10": false, "31": true, "40": true, "51": true, "01": true} {
+		assert.Equal(t, unavailable, Unavailable(code), code)
+	}
+}
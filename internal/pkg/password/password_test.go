@@ -0,0 +1,93 @@
+package password
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"golang.org/x/crypto/bcrypt"
+)
+
+func newHasher(t *testing.T, params Params) *Hasher {
+	hasher, err := NewHasher(params)
+	require.NoError(t, err)
+	return hasher
+}
+
+var testArgon2 = Params{Algorithm: AlgorithmArgon2id, Argon2Memory: 64, Argon2Iterations: 1, Argon2Parallelism: 1}
+
+func TestHasher_HashAndVerify(t *testing.T) {
+	for name, params := range map[string]Params{
+		"bcrypt":   {Algorithm: AlgorithmBcrypt, BcryptCost: bcrypt.MinCost},
+		"argon2id": testArgon2,
+	} {
+		t.Run(name, func(t *testing.T) {
+			hasher := newHasher(t, params)
+			hash, err := hasher.Hash("correct horse")
+			require.NoError(t, err)
+
+			ok, err := hasher.Verify(hash, "correct horse")
+			require.NoError(t, err)
+			assert.True(t, ok)
+
+			ok, err = hasher.Verify(hash, "wrong horse")
+			require.NoError(t, err)
+			assert.False(t, ok)
+		})
+	}
+}
+
+func TestHasher_VerifiesEitherAlgorithm(t *testing.T) {
+	argon2Hash, err := newHasher(t, testArgon2).Hash("secret")
+	require.NoError(t, err)
+	assert.True(t, strings.HasPrefix(argon2Hash, "$argon2id$v=19$m=64,t=1,p=1$"))
+
+	ok, err := newHasher(t, Params{Algorithm: AlgorithmBcrypt, BcryptCost: bcrypt.MinCost}).Verify(argon2Hash, "secret")
+	require.NoError(t, err)
+	assert.True(t, ok)
+
+	_, err = newHasher(t, testArgon2).Verify("plaintext", "secret")
+	assert.ErrorIs(t, err, ErrUnknownHash)
+}
+
+func TestHasher_NeedsRehash(t *testing.T) {
+	weakBcrypt, err := bcrypt.GenerateFromPassword([]byte("secret"), bcrypt.MinCost)
+	require.NoError(t, err)
+	argon2Hash, err := newHasher(t, testArgon2).Hash("secret")
+	require.NoError(t, err)
+
+	bcryptPolicy := newHasher(t, Params{Algorithm: AlgorithmBcrypt, BcryptCost: bcrypt.MinCost + 1})
+	assert.True(t, bcryptPolicy.NeedsRehash(string(weakBcrypt)), "lower cost")
+	assert.True(t, bcryptPolicy.NeedsRehash(argon2Hash), "other algorithm")
+	assert.False(t, newHasher(t, Params{Algorithm: AlgorithmBcrypt, BcryptCost: bcrypt.MinCost}).NeedsRehash(string(weakBcrypt)))
+
+	stronger := testArgon2
+	stronger.Argon2Iterations = 2
+	assert.True(t, newHasher(t, stronger).NeedsRehash(argon2Hash), "more iterations")
+	assert.True(t, newHasher(t, testArgon2).NeedsRehash(string(weakBcrypt)), "other algorithm")
+	assert.False(t, newHasher(t, testArgon2).NeedsRehash(argon2Hash))
+}
+
+func TestNewHasher_InvalidParams(t *testing.T) {
+	_, err := NewHasher(Params{Algorithm: AlgorithmBcrypt, BcryptCost: 2})
+	assert.Error(t, err)
+	_, err = NewHasher(Params{Algorithm: AlgorithmArgon2id})
+	assert.Error(t, err)
+	_, err = NewHasher(Params{Algorithm: "md5"})
+	assert.Error(t, err)
+}
+
+func TestPolicy_Check(t *testing.T) {
+	policy := Policy{MinLength: 10, RequireUpper: true, RequireDigit: true, RequireSymbol: true}
+
+	assert.NoError(t, policy.Check("Tr0ub4dor&3x", "alice"))
+
+	err := policy.Check("short", "alice")
+	var policyErr *PolicyError
+	require.ErrorAs(t, err, &policyErr)
+	assert.Len(t, policyErr.Violations, 4)
+
+	assert.Error(t, policy.Check("Alice-2024-Secret", "alice"), "contains the username")
+	assert.NoError(t, Policy{}.Check("", ""))
+}
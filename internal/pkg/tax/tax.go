@@ -0,0 +1,231 @@
+// Package tax works out sales tax such as VAT on order lines. Rates are set per region and
+// tax class; amounts are integers in the minor unit of a currency and tax is rounded
+// half up on every line.
+package tax
+
+import (
+	"fmt"
+	"math/big"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// ClassStandard is the class every region should have a rate for. Classes without a rate
+// of their own in a region are taxed at its standard rate.
+const ClassStandard = "standard"
+
+// basisPoints is 100%. Rates are hundredths of a percent, so 7.25% is 725.
+const basisPoints = 10000
+
+var (
+	regionPattern = regexp.MustCompile(`^[A-Z]{2}(-[A-Z0-9]{1,3})?$`)
+	classPattern  = regexp.MustCompile(`^[a-z][a-z0-9_]{0,31}$`)
+)
+
+// Rate is the tax rate of a class in a region. Region is an ISO 3166-1 country code,
+// optionally followed by a subdivision as in "US-CA".
+type Rate struct {
+	Region      string
+	Class       string
+	BasisPoints int
+}
+
+// ParseRate reads a rate written as "region:class=percent", for example "DE:reduced=7"
+// or "US-CA:standard=7.25".
+func ParseRate(entry string) (Rate, error) {
+	key, percent, ok := strings.Cut(strings.TrimSpace(entry), "=")
+	if !ok {
+		return Rate{}, fmt.Errorf("tax rate %q is not region:class=percent", entry)
+	}
+	region, class, ok := strings.Cut(key, ":")
+	if !ok {
+		return Rate{}, fmt.Errorf("tax rate %q is not region:class=percent", entry)
+	}
+	points, err := parsePercent(percent)
+	if err != nil {
+		return Rate{}, fmt.Errorf("tax rate %q: %w", entry, err)
+	}
+	rate := Rate{Region: region, Class: class, BasisPoints: points}
+	return rate, rate.validate()
+}
+
+// parsePercent reads a percentage with at most two decimals as basis points.
+func parsePercent(value string) (int, error) {
+	units, fraction, _ := strings.Cut(value, ".")
+	if units == "" || len(fraction) > 2 {
+		return 0, fmt.Errorf("percent %q must have at most two decimals", value)
+	}
+	fraction += strings.Repeat("0", 2-len(fraction))
+	points, err := strconv.ParseUint(units+fraction, 10, 16)
+	if err != nil {
+		return 0, fmt.Errorf("invalid percent %q", value)
+	}
+	return int(points), nil
+}
+
+func (r Rate) validate() error {
+	if !regionPattern.MatchString(r.Region) {
+		return fmt.Errorf("invalid tax region %q", r.Region)
+	}
+	if !ValidClass(r.Class) {
+		return fmt.Errorf("invalid tax class %q", r.Class)
+	}
+	if r.BasisPoints < 0 || r.BasisPoints > basisPoints {
+		return fmt.Errorf("tax rate of %s in %s must be between 0 and 100%%", r.Class, r.Region)
+	}
+	return nil
+}
+
+// ValidClass reports whether class is a lower case identifier of at most 32 characters.
+func ValidClass(class string) bool {
+	return classPattern.MatchString(class)
+}
+
+// Rules holds the rates of every region and whether prices already include tax.
+type Rules struct {
+	rates            map[string]map[string]int
+	pricesIncludeTax bool
+}
+
+func NewRules(rates []Rate, pricesIncludeTax bool) (*Rules, error) {
+	rules := &Rules{rates: make(map[string]map[string]int), pricesIncludeTax: pricesIncludeTax}
+	for _, rate := range rates {
+		if err := rate.validate(); err != nil {
+			return nil, err
+		}
+		if rules.rates[rate.Region] == nil {
+			rules.rates[rate.Region] = make(map[string]int)
+		}
+		if _, ok := rules.rates[rate.Region][rate.Class]; ok {
+			return nil, fmt.Errorf("tax rate of %s in %s is set twice", rate.Class, rate.Region)
+		}
+		rules.rates[rate.Region][rate.Class] = rate.BasisPoints
+	}
+	return rules, nil
+}
+
+// PricesIncludeTax reports whether prices are gross, with tax taken out of them, rather
+// than net, with tax added on top.
+func (r *Rules) PricesIncludeTax() bool {
+	return r.pricesIncludeTax
+}
+
+// Rate returns the rate of class in region in basis points. A subdivision such as "US-CA"
+// falls back to its country. Regions without rates are not taxed.
+func (r *Rules) Rate(region string, class string) int {
+	for _, candidate := range []string{region, country(region)} {
+		classes, ok := r.rates[candidate]
+		if !ok {
+			continue
+		}
+		if points, ok := classes[class]; ok {
+			return points
+		}
+		if points, ok := classes[ClassStandard]; ok {
+			return points
+		}
+	}
+	return 0
+}
+
+func country(region string) string {
+	code, _, _ := strings.Cut(region, "-")
+	return code
+}
+
+// Line is an order line to tax: its price, as listed, and its tax class.
+type Line struct {
+	Class  string
+	Amount int64
+}
+
+// LineTax is the tax on one line. Net plus Tax is Gross.
+type LineTax struct {
+	Class       string
+	BasisPoints int
+	Net         int64
+	Tax         int64
+	Gross       int64
+}
+
+// Summary adds up the lines taxed at the same rate and class, as shown on an invoice.
+type Summary struct {
+	Class       string
+	BasisPoints int
+	Net         int64
+	Tax         int64
+}
+
+// Breakdown is the tax on a whole order.
+type Breakdown struct {
+	Lines     []LineTax
+	Summaries []Summary
+	Net       int64
+	Tax       int64
+	Gross     int64
+}
+
+// Calculate taxes the lines of an order shipped to region.
+func (r *Rules) Calculate(region string, lines []Line) (Breakdown, error) {
+	var breakdown Breakdown
+	type summaryKey struct {
+		class  string
+		points int
+	}
+	summaries := make(map[summaryKey]*Summary)
+	for _, line := range lines {
+		if line.Amount < 0 {
+			return Breakdown{}, fmt.Errorf("line amount cannot be negative")
+		}
+		points := r.Rate(region, line.Class)
+		taxed := LineTax{Class: line.Class, BasisPoints: points}
+		if r.pricesIncludeTax {
+			taxed.Gross = line.Amount
+			taxed.Net = mulDivRound(line.Amount, basisPoints, basisPoints+int64(points))
+			taxed.Tax = taxed.Gross - taxed.Net
+		} else {
+			taxed.Net = line.Amount
+			taxed.Tax = mulDivRound(line.Amount, int64(points), basisPoints)
+			taxed.Gross = taxed.Net + taxed.Tax
+		}
+		if taxed.Gross < taxed.Net {
+			return Breakdown{}, fmt.Errorf("order total overflows")
+		}
+		breakdown.Lines = append(breakdown.Lines, taxed)
+
+		key := summaryKey{class: taxed.Class, points: points}
+		if summaries[key] == nil {
+			summaries[key] = &Summary{Class: taxed.Class, BasisPoints: points}
+		}
+		summaries[key].Net += taxed.Net
+		summaries[key].Tax += taxed.Tax
+		breakdown.Net += taxed.Net
+		breakdown.Tax += taxed.Tax
+		breakdown.Gross += taxed.Gross
+		if breakdown.Net < 0 || breakdown.Gross < 0 {
+			return Breakdown{}, fmt.Errorf("order total overflows")
+		}
+	}
+
+	for _, summary := range summaries {
+		breakdown.Summaries = append(breakdown.Summaries, *summary)
+	}
+	sort.Slice(breakdown.Summaries, func(i, j int) bool {
+		a, b := breakdown.Summaries[i], breakdown.Summaries[j]
+		if a.Class != b.Class {
+			return a.Class < b.Class
+		}
+		return a.BasisPoints < b.BasisPoints
+	})
+	return breakdown, nil
+}
+
+// mulDivRound returns amount*numerator/denominator rounded half up, without overflowing
+// in between. The amount must not be negative.
+func mulDivRound(amount, numerator, denominator int64) int64 {
+	product := new(big.Int).Mul(big.NewInt(amount), big.NewInt(2*numerator))
+	product.Add(product, big.NewInt(denominator))
+	return product.Quo(product, big.NewInt(2*denominator)).Int64()
+}
@@ -0,0 +1,108 @@
+package tax
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func newTestRules(t *testing.T, pricesIncludeTax bool) *Rules {
+	var rates []Rate
+	for _, entry := range []string{"DE:standard=19", "DE:reduced=7", "US:standard=0", "US-CA:standard=7.25"} {
+		rate, err := ParseRate(entry)
+		require.NoError(t, err)
+		rates = append(rates, rate)
+	}
+	rules, err := NewRules(rates, pricesIncludeTax)
+	require.NoError(t, err)
+	return rules
+}
+
+func TestParseRate(t *testing.T) {
+	rate, err := ParseRate("US-CA:standard=7.25")
+	require.NoError(t, err)
+	assert.Equal(t, Rate{Region: "US-CA", Class: "standard", BasisPoints: 725}, rate)
+
+	for _, entry := range []string{"DE=19", "DE:reduced", "de:reduced=7", "DE:Reduced=7", "DE:reduced=7.125", "DE:reduced=101", "DE:reduced=-1"} {
+		_, err := ParseRate(entry)
+		assert.Error(t, err, entry)
+	}
+}
+
+func TestNewRules_Duplicate(t *testing.T) {
+	_, err := NewRules([]Rate{{"DE", "reduced", 700}, {"DE", "reduced", 500}}, false)
+	assert.Error(t, err)
+}
+
+func TestRules_Rate(t *testing.T) {
+	rules := newTestRules(t, false)
+
+	assert.Equal(t, 700, rules.Rate("DE", "reduced"))
+	// classes without a rate of their own pay the standard rate
+	assert.Equal(t, 1900, rules.Rate("DE", "ebook"))
+	assert.Equal(t, 725, rules.Rate("US-CA", "reduced"))
+	// subdivisions without rates fall back to the country
+	assert.Equal(t, 0, rules.Rate("US-OR", "standard"))
+	assert.Equal(t, 1900, rules.Rate("DE-BY", "standard"))
+	assert.Equal(t, 0, rules.Rate("FR", "standard"))
+}
+
+func TestRules_CalculateExclusive(t *testing.T) {
+	rules := newTestRules(t, false)
+
+	breakdown, err := rules.Calculate("DE", []Line{
+		{Class: "reduced", Amount: 1999},
+		{Class: "reduced", Amount: 1000},
+		{Class: "standard", Amount: 2500},
+	})
+	require.NoError(t, err)
+
+	// 19.99 * 7% = 1.3993, 10.00 * 7% = 0.70, 25.00 * 19% = 4.75
+	assert.Equal(t, []LineTax{
+		{Class: "reduced", BasisPoints: 700, Net: 1999, Tax: 140, Gross: 2139},
+		{Class: "reduced", BasisPoints: 700, Net: 1000, Tax: 70, Gross: 1070},
+		{Class: "standard", BasisPoints: 1900, Net: 2500, Tax: 475, Gross: 2975},
+	}, breakdown.Lines)
+	assert.Equal(t, []Summary{
+		{Class: "reduced", BasisPoints: 700, Net: 2999, Tax: 210},
+		{Class: "standard", BasisPoints: 1900, Net: 2500, Tax: 475},
+	}, breakdown.Summaries)
+	assert.Equal(t, int64(5499), breakdown.Net)
+	assert.Equal(t, int64(685), breakdown.Tax)
+	assert.Equal(t, int64(6184), breakdown.Gross)
+}
+
+func TestRules_CalculateInclusive(t *testing.T) {
+	rules := newTestRules(t, true)
+
+	breakdown, err := rules.Calculate("DE", []Line{{Class: "reduced", Amount: 1070}, {Class: "standard", Amount: 1000}})
+	require.NoError(t, err)
+
+	// 10.00 / 1.19 = 8.4033...
+	assert.Equal(t, []LineTax{
+		{Class: "reduced", BasisPoints: 700, Net: 1000, Tax: 70, Gross: 1070},
+		{Class: "standard", BasisPoints: 1900, Net: 840, Tax: 160, Gross: 1000},
+	}, breakdown.Lines)
+	assert.Equal(t, int64(2070), breakdown.Gross)
+	assert.Equal(t, int64(230), breakdown.Tax)
+}
+
+func TestRules_CalculateUntaxedRegion(t *testing.T) {
+	rules := newTestRules(t, false)
+
+	breakdown, err := rules.Calculate("FR", []Line{{Class: "reduced", Amount: 1500}})
+	require.NoError(t, err)
+	assert.Equal(t, int64(0), breakdown.Tax)
+	assert.Equal(t, int64(1500), breakdown.Gross)
+	assert.Equal(t, []Summary{{Class: "reduced", BasisPoints: 0, Net: 1500, Tax: 0}}, breakdown.Summaries)
+
+	_, err = rules.Calculate("FR", []Line{{Class: "reduced", Amount: -1}})
+	assert.Error(t, err)
+}
+
+func TestMulDivRound(t *testing.T) {
+	assert.Equal(t, int64(1), mulDivRound(5, 1, 10))
+	assert.Equal(t, int64(0), mulDivRound(4, 1, 10))
+	assert.Equal(t, int64(9223372036854775807/100*19), mulDivRound(9223372036854775807/100, 1900, 100))
+}
@@ -2,6 +2,7 @@ package validator
 
 import (
 	"fmt"
+	"strconv"
 	"strings"
 
 	"github.com/go-playground/validator/v10"
@@ -11,6 +12,12 @@ var validate *validator.Validate
 
 func init() {
 	validate = validator.New()
+	// maxbytes limits the length of a string in bytes rather than characters, as bcrypt
+	// only accepts passwords of up to 72 bytes.
+	_ = validate.RegisterValidation("maxbytes", func(fl validator.FieldLevel) bool {
+		limit, err := strconv.Atoi(fl.Param())
+		return err == nil && len(fl.Field().String()) <= limit
+	})
 }
 
 type ValidationError struct {
@@ -52,6 +59,8 @@ func getErrorMsg(err validator.FieldError) string {
 		return fmt.Sprintf("value must be no less than %s", err.Param())
 	case "max":
 		return fmt.Sprintf("value should not be greater than %s", err.Param())
+	case "maxbytes":
+		return fmt.Sprintf("value should not be longer than %s bytes", err.Param())
 	case "email":
 		return "invalid email format"
 	case "len":
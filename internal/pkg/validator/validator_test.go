@@ -0,0 +1,25 @@
+package validator
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestValidate_MaxBytes(t *testing.T) {
+	type request struct {
+		Password string `validate:"required,maxbytes=72"`
+	}
+
+	assert.NoError(t, Validate(request{Password: strings.Repeat("a", 72)}))
+
+	err := Validate(request{Password: strings.Repeat("a", 73)})
+	require.Error(t, err)
+	assert.Equal(t, "Password: value should not be longer than 72 bytes", err.Error())
+
+	// 40 characters but 80 bytes, which max=72 would have let through to bcrypt.
+	err = Validate(request{Password: strings.Repeat("é", 40)})
+	assert.Error(t, err)
+}
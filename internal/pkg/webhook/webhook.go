@@ -0,0 +1,76 @@
+// Package webhook posts JSON events to an HTTP endpoint. When a secret is set, each
+// request carries an HMAC-SHA256 signature of its body in the X-Webhook-Signature header,
+// as "sha256=<hex>", so the receiver can check where it came from.
+package webhook
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"time"
+)
+
+const (
+	EventHeader     = "X-Webhook-Event"
+	SignatureHeader = "X-Webhook-Signature"
+)
+
+// Event is the body of a webhook request.
+type Event struct {
+	Event  string    `json:"event"`
+	SentAt time.Time `json:"sent_at"`
+	Data   any       `json:"data"`
+}
+
+type Client struct {
+	url    string
+	secret []byte
+	http   *http.Client
+}
+
+func NewClient(url string, secret string, httpClient *http.Client) *Client {
+	return &Client{url: url, secret: []byte(secret), http: httpClient}
+}
+
+// Send posts the event with its data. Responses other than 2xx are errors.
+func (c *Client) Send(ctx context.Context, event string, data any) error {
+	body, err := json.Marshal(Event{Event: event, SentAt: time.Now().UTC(), Data: data})
+	if err != nil {
+		return fmt.Errorf("failed to encode webhook event: %w", err)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, c.url, bytes.NewReader(body))
+	if err != nil {
+		return fmt.Errorf("failed to create webhook request: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set(EventHeader, event)
+	if len(c.secret) > 0 {
+		req.Header.Set(SignatureHeader, Sign(c.secret, body))
+	}
+
+	resp, err := c.http.Do(req)
+	if err != nil {
+		return fmt.Errorf("failed to send webhook: %w", err)
+	}
+	defer resp.Body.Close()
+	_, _ = io.Copy(io.Discard, io.LimitReader(resp.Body, 64<<10))
+
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return fmt.Errorf("webhook returned %s", resp.Status)
+	}
+	return nil
+}
+
+// Sign returns the signature header value of body.
+func Sign(secret []byte, body []byte) string {
+	mac := hmac.New(sha256.New, secret)
+	mac.Write(body)
+	return "sha256=" + hex.EncodeToString(mac.Sum(nil))
+}
@@ -0,0 +1,55 @@
+package webhook
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestClient_Send(t *testing.T) {
+	var received Event
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, err := io.ReadAll(r.Body)
+		require.NoError(t, err)
+		assert.Equal(t, "application/json", r.Header.Get("Content-Type"))
+		assert.Equal(t, "stock.low", r.Header.Get(EventHeader))
+		assert.Equal(t, Sign([]byte("secret"), body), r.Header.Get(SignatureHeader))
+		require.NoError(t, json.Unmarshal(body, &received))
+		w.WriteHeader(http.StatusNoContent)
+	}))
+	defer server.Close()
+
+	client := NewClient(server.URL, "secret", server.Client())
+	require.NoError(t, client.Send(context.Background(), "stock.low", map[string]int{"book_id": 1}))
+
+	assert.Equal(t, "stock.low", received.Event)
+	assert.Equal(t, map[string]any{"book_id": float64(1)}, received.Data)
+	assert.False(t, received.SentAt.IsZero())
+}
+
+func TestClient_Send_Unsigned(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Empty(t, r.Header.Get(SignatureHeader))
+	}))
+	defer server.Close()
+
+	client := NewClient(server.URL, "", server.Client())
+	assert.NoError(t, client.Send(context.Background(), "stock.low", nil))
+}
+
+func TestClient_Send_ErrorStatus(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusBadGateway)
+	}))
+	defer server.Close()
+
+	client := NewClient(server.URL, "", server.Client())
+	err := client.Send(context.Background(), "stock.low", nil)
+	assert.ErrorContains(t, err, "502")
+}
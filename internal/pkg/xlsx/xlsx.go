@@ -0,0 +1,168 @@
+// Package xlsx writes Office Open XML spreadsheets with a single sheet, one row at a time,
+// so large tables can be streamed without holding them in memory. Strings are written
+// inline rather than through a shared string table.
+package xlsx
+
+import (
+	"archive/zip"
+	"bufio"
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"io"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// ContentType is the media type of the files a Writer produces.
+const ContentType = "application/vnd.openxmlformats-officedocument.spreadsheetml.sheet"
+
+// maxRows is the number of rows a sheet can hold.
+const maxRows = 1048576
+
+var ErrTooManyRows = errors.New("sheet is full")
+
+var numberPattern = regexp.MustCompile(`^-?[0-9]+(\.[0-9]+)?$`)
+
+// Number is a numeric cell written as given, such as "12.50", so decimals are not
+// rounded through a float.
+type Number string
+
+var staticParts = []struct {
+	name    string
+	content string
+}{
+	{"[Content_Types].xml", xml.Header + `<Types xmlns="http://schemas.openxmlformats.org/package/2006/content-types">` +
+		`<Default Extension="rels" ContentType="application/vnd.openxmlformats-package.relationships+xml"/>` +
+		`<Default Extension="xml" ContentType="application/xml"/>` +
+		`<Override PartName="/xl/workbook.xml" ContentType="application/vnd.openxmlformats-officedocument.spreadsheetml.sheet.main+xml"/>` +
+		`<Override PartName="/xl/worksheets/sheet1.xml" ContentType="application/vnd.openxmlformats-officedocument.spreadsheetml.worksheet+xml"/>` +
+		`</Types>`},
+	{"_rels/.rels", xml.Header + `<Relationships xmlns="http://schemas.openxmlformats.org/package/2006/relationships">` +
+		`<Relationship Id="rId1" Type="http://schemas.openxmlformats.org/officeDocument/2006/relationships/officeDocument" Target="xl/workbook.xml"/>` +
+		`</Relationships>`},
+	{"xl/_rels/workbook.xml.rels", xml.Header + `<Relationships xmlns="http://schemas.openxmlformats.org/package/2006/relationships">` +
+		`<Relationship Id="rId1" Type="http://schemas.openxmlformats.org/officeDocument/2006/relationships/worksheet" Target="worksheets/sheet1.xml"/>` +
+		`</Relationships>`},
+}
+
+// Writer writes a workbook to an underlying writer. Close must be called to finish it.
+type Writer struct {
+	zip   *zip.Writer
+	sheet *bufio.Writer
+	rows  int
+	err   error
+}
+
+// NewWriter starts a workbook with one sheet called sheetName.
+func NewWriter(w io.Writer, sheetName string) (*Writer, error) {
+	archive := zip.NewWriter(w)
+	for _, part := range staticParts {
+		if err := writePart(archive, part.name, part.content); err != nil {
+			return nil, err
+		}
+	}
+	workbook := xml.Header + `<workbook xmlns="http://schemas.openxmlformats.org/spreadsheetml/2006/main" ` +
+		`xmlns:r="http://schemas.openxmlformats.org/officeDocument/2006/relationships">` +
+		`<sheets><sheet name="` + escape(sheetName) + `" sheetId="1" r:id="rId1"/></sheets></workbook>`
+	if err := writePart(archive, "xl/workbook.xml", workbook); err != nil {
+		return nil, err
+	}
+
+	part, err := archive.Create("xl/worksheets/sheet1.xml")
+	if err != nil {
+		return nil, err
+	}
+	sheet := bufio.NewWriter(part)
+	_, err = sheet.WriteString(xml.Header + `<worksheet xmlns="http://schemas.openxmlformats.org/spreadsheetml/2006/main"><sheetData>`)
+	if err != nil {
+		return nil, err
+	}
+	return &Writer{zip: archive, sheet: sheet}, nil
+}
+
+func writePart(archive *zip.Writer, name, content string) error {
+	part, err := archive.Create(name)
+	if err != nil {
+		return err
+	}
+	_, err = io.WriteString(part, content)
+	return err
+}
+
+// WriteRow appends a row. Cells may be strings, Numbers, integers or nil for an empty cell.
+func (w *Writer) WriteRow(cells ...any) error {
+	if w.err != nil {
+		return w.err
+	}
+	if w.rows == maxRows {
+		return ErrTooManyRows
+	}
+	w.rows++
+
+	w.print(`<row r="` + strconv.Itoa(w.rows) + `">`)
+	for i, cell := range cells {
+		ref := column(i) + strconv.Itoa(w.rows)
+		switch value := cell.(type) {
+		case nil:
+		case string:
+			w.print(`<c r="` + ref + `" t="inlineStr"><is><t xml:space="preserve">` + escape(value) + `</t></is></c>`)
+		case Number:
+			if !numberPattern.MatchString(string(value)) {
+				return fmt.Errorf("cell %s: %q is not a number", ref, value)
+			}
+			w.print(`<c r="` + ref + `"><v>` + string(value) + `</v></c>`)
+		case int:
+			w.print(`<c r="` + ref + `"><v>` + strconv.Itoa(value) + `</v></c>`)
+		case int64:
+			w.print(`<c r="` + ref + `"><v>` + strconv.FormatInt(value, 10) + `</v></c>`)
+		default:
+			return fmt.Errorf("cell %s: unsupported type %T", ref, cell)
+		}
+	}
+	w.print(`</row>`)
+	return w.err
+}
+
+func (w *Writer) print(s string) {
+	if w.err == nil {
+		_, w.err = w.sheet.WriteString(s)
+	}
+}
+
+// Flush sends the rows written so far to the underlying writer.
+func (w *Writer) Flush() error {
+	if w.err == nil {
+		w.err = w.sheet.Flush()
+	}
+	if w.err == nil {
+		w.err = w.zip.Flush()
+	}
+	return w.err
+}
+
+// Close ends the sheet and the archive. It does not close the underlying writer.
+func (w *Writer) Close() error {
+	w.print(`</sheetData></worksheet>`)
+	if err := w.Flush(); err != nil {
+		return err
+	}
+	return w.zip.Close()
+}
+
+// column returns the letters of the zero-based column i: A, B, ..., Z, AA, ...
+func column(i int) string {
+	name := ""
+	for i++; i > 0; i = (i - 1) / 26 {
+		name = string(rune('A'+(i-1)%26)) + name
+	}
+	return name
+}
+
+// escape escapes text for XML, replacing characters XML cannot hold.
+func escape(s string) string {
+	var b strings.Builder
+	_ = xml.EscapeText(&b, []byte(s))
+	return b.String()
+}
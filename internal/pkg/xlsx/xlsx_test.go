@@ -0,0 +1,62 @@
+package xlsx
+
+import (
+	"archive/zip"
+	"bytes"
+	"encoding/xml"
+	"io"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func readPart(t *testing.T, archive *zip.Reader, name string) string {
+	part, err := archive.Open(name)
+	require.NoError(t, err, name)
+	defer part.Close()
+	content, err := io.ReadAll(part)
+	require.NoError(t, err)
+	return string(content)
+}
+
+func TestWriter(t *testing.T) {
+	var buf bytes.Buffer
+	w, err := NewWriter(&buf, "Books & more")
+	require.NoError(t, err)
+	require.NoError(t, w.WriteRow("title", "price", "stock"))
+	require.NoError(t, w.WriteRow("Tom & Jerry <3", Number("12.50"), 4))
+	require.NoError(t, w.WriteRow("", nil, int64(7)))
+	require.NoError(t, w.Close())
+
+	archive, err := zip.NewReader(bytes.NewReader(buf.Bytes()), int64(buf.Len()))
+	require.NoError(t, err)
+	for _, name := range []string{"[Content_Types].xml", "_rels/.rels", "xl/workbook.xml", "xl/_rels/workbook.xml.rels"} {
+		content := readPart(t, archive, name)
+		assert.NoError(t, xml.Unmarshal([]byte(content), new(struct{})), name)
+	}
+	assert.Contains(t, readPart(t, archive, "xl/workbook.xml"), `name="Books &amp; more"`)
+
+	sheet := readPart(t, archive, "xl/worksheets/sheet1.xml")
+	assert.NoError(t, xml.Unmarshal([]byte(sheet), new(struct{})))
+	assert.Contains(t, sheet, `<c r="A2" t="inlineStr"><is><t xml:space="preserve">Tom &amp; Jerry &lt;3</t></is></c>`)
+	assert.Contains(t, sheet, `<c r="B2"><v>12.50</v></c><c r="C2"><v>4</v></c>`)
+	assert.Contains(t, sheet, `<row r="3"><c r="A3" t="inlineStr"><is><t xml:space="preserve"></t></is></c><c r="C3"><v>7</v></c></row>`)
+}
+
+func TestWriterRejectsInvalidCells(t *testing.T) {
+	w, err := NewWriter(io.Discard, "Sheet")
+	require.NoError(t, err)
+	assert.Error(t, w.WriteRow(Number("NaN")))
+	assert.Error(t, w.WriteRow(1.5))
+}
+
+func TestColumn(t *testing.T) {
+	assert.Equal(t, "A", column(0))
+	assert.Equal(t, "Z", column(25))
+	assert.Equal(t, "AA", column(26))
+	assert.Equal(t, "AZ", column(51))
+	assert.Equal(t, "BA", column(52))
+	assert.Equal(t, "ZZ", column(701))
+	assert.Equal(t, "AAA", column(702))
+}
@@ -0,0 +1,14 @@
+CREATE TABLE api_keys
+(
+    id           SERIAL PRIMARY KEY,
+    name         VARCHAR   NOT NULL,
+    prefix       VARCHAR   NOT NULL UNIQUE,
+    key_hash     VARCHAR   NOT NULL,
+    scopes       TEXT[]    NOT NULL DEFAULT '{}',
+    created_by   INTEGER   NOT NULL,
+    created_at   TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT NOW(),
+    expires_at   TIMESTAMP WITH TIME ZONE,
+    last_used_at TIMESTAMP WITH TIME ZONE,
+    revoked_at   TIMESTAMP WITH TIME ZONE,
+    CONSTRAINT fk_api_keys_created_by FOREIGN KEY (created_by) REFERENCES users (id) ON DELETE RESTRICT
+);
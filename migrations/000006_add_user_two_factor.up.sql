@@ -0,0 +1,19 @@
+BEGIN;
+
+ALTER TABLE users
+    ADD COLUMN totp_secret    VARCHAR,
+    ADD COLUMN totp_enabled   BOOLEAN NOT NULL DEFAULT FALSE,
+    ADD COLUMN totp_last_step BIGINT;
+
+CREATE TABLE user_recovery_codes
+(
+    id        SERIAL PRIMARY KEY,
+    user_id   INTEGER NOT NULL,
+    code_hash VARCHAR NOT NULL,
+    used_at   TIMESTAMP WITH TIME ZONE,
+    CONSTRAINT fk_recovery_codes_user FOREIGN KEY (user_id) REFERENCES users (id) ON DELETE CASCADE
+);
+
+CREATE INDEX idx_user_recovery_codes_user_id ON user_recovery_codes (user_id);
+
+COMMIT;
@@ -0,0 +1,10 @@
+BEGIN;
+
+DROP TABLE IF EXISTS email_outbox;
+DROP TABLE IF EXISTS user_tokens;
+
+ALTER TABLE users
+    DROP COLUMN IF EXISTS email,
+    DROP COLUMN IF EXISTS email_verified;
+
+COMMIT;
@@ -0,0 +1,37 @@
+BEGIN;
+
+ALTER TABLE users
+    ADD COLUMN email          VARCHAR UNIQUE,
+    ADD COLUMN email_verified BOOLEAN NOT NULL DEFAULT FALSE;
+
+CREATE TABLE user_tokens
+(
+    id         SERIAL PRIMARY KEY,
+    user_id    INTEGER NOT NULL,
+    purpose    VARCHAR NOT NULL,
+    token_hash VARCHAR NOT NULL UNIQUE,
+    email      VARCHAR,
+    created_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT NOW(),
+    expires_at TIMESTAMP WITH TIME ZONE NOT NULL,
+    used_at    TIMESTAMP WITH TIME ZONE,
+    CONSTRAINT fk_user_tokens_user FOREIGN KEY (user_id) REFERENCES users (id) ON DELETE CASCADE
+);
+
+CREATE INDEX idx_user_tokens_user_id ON user_tokens (user_id, purpose);
+
+CREATE TABLE email_outbox
+(
+    id              SERIAL PRIMARY KEY,
+    recipient       VARCHAR NOT NULL,
+    subject         VARCHAR NOT NULL,
+    body            TEXT    NOT NULL,
+    created_at      TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT NOW(),
+    next_attempt_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT NOW(),
+    attempts        INTEGER NOT NULL DEFAULT 0,
+    last_error      TEXT,
+    sent_at         TIMESTAMP WITH TIME ZONE
+);
+
+CREATE INDEX idx_email_outbox_pending ON email_outbox (next_attempt_at) WHERE sent_at IS NULL;
+
+COMMIT;
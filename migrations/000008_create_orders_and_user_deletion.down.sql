@@ -0,0 +1,9 @@
+BEGIN;
+
+DROP TABLE IF EXISTS order_items;
+DROP TABLE IF EXISTS orders;
+
+ALTER TABLE users
+    DROP COLUMN IF EXISTS deleted_at;
+
+COMMIT;
@@ -0,0 +1,32 @@
+BEGIN;
+
+ALTER TABLE users
+    ADD COLUMN deleted_at TIMESTAMP WITH TIME ZONE;
+
+-- Order lines copy the book details so they survive edits and deletion of the book.
+CREATE TABLE orders
+(
+    id         SERIAL PRIMARY KEY,
+    user_id    INTEGER NOT NULL,
+    total      INTEGER NOT NULL CHECK (total >= 0),
+    created_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT NOW(),
+    CONSTRAINT fk_orders_user FOREIGN KEY (user_id) REFERENCES users (id) ON DELETE RESTRICT
+);
+
+CREATE INDEX idx_orders_user_id ON orders (user_id, created_at);
+
+CREATE TABLE order_items
+(
+    id       SERIAL PRIMARY KEY,
+    order_id INTEGER NOT NULL,
+    book_id  INTEGER,
+    title    VARCHAR NOT NULL,
+    author   VARCHAR NOT NULL,
+    price    INTEGER NOT NULL CHECK (price >= 0),
+    CONSTRAINT fk_order_items_order FOREIGN KEY (order_id) REFERENCES orders (id) ON DELETE CASCADE,
+    CONSTRAINT fk_order_items_book FOREIGN KEY (book_id) REFERENCES books (id) ON DELETE SET NULL
+);
+
+CREATE INDEX idx_order_items_order_id ON order_items (order_id);
+
+COMMIT;
@@ -0,0 +1,31 @@
+BEGIN;
+
+-- Pending OIDC logins. A row lives from the redirect to the provider until the callback.
+CREATE TABLE oidc_auth_requests
+(
+    state_hash    VARCHAR PRIMARY KEY,
+    provider      VARCHAR NOT NULL,
+    nonce         VARCHAR NOT NULL,
+    code_verifier VARCHAR NOT NULL,
+    created_at    TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT NOW(),
+    expires_at    TIMESTAMP WITH TIME ZONE NOT NULL
+);
+
+CREATE INDEX idx_oidc_auth_requests_expires_at ON oidc_auth_requests (expires_at);
+
+CREATE TABLE user_identities
+(
+    id            SERIAL PRIMARY KEY,
+    user_id       INTEGER NOT NULL,
+    provider      VARCHAR NOT NULL,
+    subject       VARCHAR NOT NULL,
+    email         VARCHAR,
+    created_at    TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT NOW(),
+    last_login_at TIMESTAMP WITH TIME ZONE,
+    CONSTRAINT uq_user_identities_subject UNIQUE (provider, subject),
+    CONSTRAINT fk_user_identities_user FOREIGN KEY (user_id) REFERENCES users (id) ON DELETE CASCADE
+);
+
+CREATE INDEX idx_user_identities_user_id ON user_identities (user_id);
+
+COMMIT;
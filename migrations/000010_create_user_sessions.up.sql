@@ -0,0 +1,20 @@
+BEGIN;
+
+-- One row per issued access token. Tokens carry the session id and are only accepted
+-- while the session is neither revoked nor expired.
+CREATE TABLE user_sessions
+(
+    id           SERIAL PRIMARY KEY,
+    user_id      INTEGER NOT NULL,
+    user_agent   VARCHAR,
+    ip_address   VARCHAR,
+    created_at   TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT NOW(),
+    last_seen_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT NOW(),
+    expires_at   TIMESTAMP WITH TIME ZONE NOT NULL,
+    revoked_at   TIMESTAMP WITH TIME ZONE,
+    CONSTRAINT fk_user_sessions_user FOREIGN KEY (user_id) REFERENCES users (id) ON DELETE CASCADE
+);
+
+CREATE INDEX idx_user_sessions_user_id ON user_sessions (user_id);
+
+COMMIT;
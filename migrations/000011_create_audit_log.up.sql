@@ -0,0 +1,37 @@
+BEGIN;
+
+-- Administrative changes. Actors are not foreign keys so that entries outlive the
+-- users and API keys that made them.
+CREATE TABLE audit_log
+(
+    id               BIGSERIAL PRIMARY KEY,
+    actor_user_id    INTEGER,
+    actor_api_key_id INTEGER,
+    action           VARCHAR NOT NULL,
+    entity_type      VARCHAR NOT NULL,
+    entity_id        INTEGER NOT NULL,
+    before           JSONB,
+    after            JSONB,
+    request_id       VARCHAR,
+    ip_address       VARCHAR,
+    created_at       TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT NOW()
+);
+
+CREATE INDEX idx_audit_log_entity ON audit_log (entity_type, entity_id);
+CREATE INDEX idx_audit_log_actor_user_id ON audit_log (actor_user_id);
+CREATE INDEX idx_audit_log_created_at ON audit_log (created_at);
+
+CREATE FUNCTION audit_log_append_only() RETURNS TRIGGER AS
+$$
+BEGIN
+    RAISE EXCEPTION 'audit_log is append-only';
+END;
+$$ LANGUAGE plpgsql;
+
+CREATE TRIGGER trg_audit_log_append_only
+    BEFORE UPDATE OR DELETE OR TRUNCATE
+    ON audit_log
+    FOR EACH STATEMENT
+EXECUTE FUNCTION audit_log_append_only();
+
+COMMIT;
@@ -0,0 +1,11 @@
+BEGIN;
+
+DELETE FROM books WHERE deleted_at IS NOT NULL;
+DELETE FROM categories WHERE deleted_at IS NOT NULL;
+
+ALTER TABLE books
+    DROP COLUMN IF EXISTS deleted_at;
+ALTER TABLE categories
+    DROP COLUMN IF EXISTS deleted_at;
+
+COMMIT;
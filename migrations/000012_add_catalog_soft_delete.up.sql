@@ -0,0 +1,13 @@
+BEGIN;
+
+-- Books and categories are archived rather than deleted so carts and order history keep
+-- their references. Archived rows are purged by a background job once they are old enough.
+ALTER TABLE books
+    ADD COLUMN deleted_at TIMESTAMP WITH TIME ZONE;
+ALTER TABLE categories
+    ADD COLUMN deleted_at TIMESTAMP WITH TIME ZONE;
+
+CREATE INDEX idx_books_deleted_at ON books (deleted_at) WHERE deleted_at IS NOT NULL;
+CREATE INDEX idx_categories_deleted_at ON categories (deleted_at) WHERE deleted_at IS NOT NULL;
+
+COMMIT;
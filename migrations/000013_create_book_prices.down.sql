@@ -0,0 +1,10 @@
+BEGIN;
+
+DROP TABLE IF EXISTS book_price_history;
+DROP TABLE IF EXISTS book_price_changes;
+
+ALTER TABLE books
+    DROP COLUMN IF EXISTS sale_price,
+    DROP COLUMN IF EXISTS sale_ends_at;
+
+COMMIT;
@@ -0,0 +1,49 @@
+BEGIN;
+
+-- The sale columns are maintained by the price scheduler from book_price_changes.
+ALTER TABLE books
+    ADD COLUMN sale_price   INT CHECK (sale_price >= 0),
+    ADD COLUMN sale_ends_at TIMESTAMP WITH TIME ZONE;
+
+-- Price changes scheduled by admins. Without ends_at the change replaces the book's
+-- price; with it the price is a sale shown next to the regular price until ends_at.
+CREATE TABLE book_price_changes
+(
+    id           SERIAL PRIMARY KEY,
+    book_id      INTEGER                  NOT NULL,
+    price        INT                      NOT NULL CHECK (price >= 0),
+    starts_at    TIMESTAMP WITH TIME ZONE NOT NULL,
+    ends_at      TIMESTAMP WITH TIME ZONE,
+    created_by   INTEGER,
+    created_at   TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT NOW(),
+    applied_at   TIMESTAMP WITH TIME ZONE,
+    ended_at     TIMESTAMP WITH TIME ZONE,
+    cancelled_at TIMESTAMP WITH TIME ZONE,
+    CONSTRAINT fk_book_price_changes_book FOREIGN KEY (book_id) REFERENCES books (id) ON DELETE CASCADE,
+    CONSTRAINT chk_book_price_changes_period CHECK (ends_at IS NULL OR ends_at > starts_at)
+);
+
+CREATE INDEX idx_book_price_changes_book_id ON book_price_changes (book_id, starts_at);
+CREATE INDEX idx_book_price_changes_open ON book_price_changes (starts_at)
+    WHERE cancelled_at IS NULL AND ended_at IS NULL;
+
+CREATE TABLE book_price_history
+(
+    id              BIGSERIAL PRIMARY KEY,
+    book_id         INTEGER                  NOT NULL,
+    price           INT                      NOT NULL,
+    sale_price      INT,
+    reason          VARCHAR                  NOT NULL,
+    price_change_id INTEGER,
+    changed_at      TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT NOW(),
+    CONSTRAINT fk_book_price_history_book FOREIGN KEY (book_id) REFERENCES books (id) ON DELETE CASCADE,
+    CONSTRAINT fk_book_price_history_change FOREIGN KEY (price_change_id) REFERENCES book_price_changes (id) ON DELETE SET NULL
+);
+
+CREATE INDEX idx_book_price_history_book_id ON book_price_history (book_id, changed_at);
+
+INSERT INTO book_price_history (book_id, price, reason)
+SELECT id, price, 'initial'
+FROM books;
+
+COMMIT;
@@ -0,0 +1,28 @@
+BEGIN;
+
+DROP TABLE IF EXISTS book_price_list;
+
+-- Amounts go back to whole dollars; cents are rounded.
+ALTER TABLE order_items
+    DROP COLUMN IF EXISTS currency,
+    ALTER COLUMN price TYPE INTEGER USING round(price / 100.0);
+
+ALTER TABLE orders
+    DROP COLUMN IF EXISTS currency,
+    ALTER COLUMN total TYPE INTEGER USING round(total / 100.0);
+
+ALTER TABLE book_price_history
+    DROP COLUMN IF EXISTS currency,
+    ALTER COLUMN price TYPE INT USING round(price / 100.0),
+    ALTER COLUMN sale_price TYPE INT USING round(sale_price / 100.0);
+
+ALTER TABLE book_price_changes
+    DROP COLUMN IF EXISTS currency,
+    ALTER COLUMN price TYPE INT USING round(price / 100.0);
+
+ALTER TABLE books
+    DROP COLUMN IF EXISTS currency,
+    ALTER COLUMN price TYPE INT USING round(price / 100.0),
+    ALTER COLUMN sale_price TYPE INT USING round(sale_price / 100.0);
+
+COMMIT;
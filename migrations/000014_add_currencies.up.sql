@@ -0,0 +1,40 @@
+BEGIN;
+
+-- Prices are amounts in the minor unit of their currency. Prices so far were whole US
+-- dollars, as the API took them, so they are converted to cents. The audit log keeps the
+-- amounts it recorded at the time.
+ALTER TABLE books
+    ALTER COLUMN price TYPE BIGINT USING price * 100,
+    ALTER COLUMN sale_price TYPE BIGINT USING sale_price * 100,
+    ADD COLUMN currency CHAR(3) NOT NULL DEFAULT 'USD';
+
+ALTER TABLE book_price_changes
+    ALTER COLUMN price TYPE BIGINT USING price * 100,
+    ADD COLUMN currency CHAR(3) NOT NULL DEFAULT 'USD';
+
+ALTER TABLE book_price_history
+    ALTER COLUMN price TYPE BIGINT USING price * 100,
+    ALTER COLUMN sale_price TYPE BIGINT USING sale_price * 100,
+    ADD COLUMN currency CHAR(3) NOT NULL DEFAULT 'USD';
+
+ALTER TABLE orders
+    ALTER COLUMN total TYPE BIGINT USING total * 100,
+    ADD COLUMN currency CHAR(3) NOT NULL DEFAULT 'USD';
+
+ALTER TABLE order_items
+    ALTER COLUMN price TYPE BIGINT USING price * 100,
+    ADD COLUMN currency CHAR(3) NOT NULL DEFAULT 'USD';
+
+-- Prices of a book in currencies other than its own. Customers who ask for one of these
+-- currencies see and pay the list price; sales only apply to the book's own currency.
+CREATE TABLE book_price_list
+(
+    book_id    INTEGER                  NOT NULL,
+    currency   CHAR(3)                  NOT NULL,
+    price      BIGINT                   NOT NULL CHECK (price >= 0),
+    updated_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT NOW(),
+    PRIMARY KEY (book_id, currency),
+    CONSTRAINT fk_book_price_list_book FOREIGN KEY (book_id) REFERENCES books (id) ON DELETE CASCADE
+);
+
+COMMIT;
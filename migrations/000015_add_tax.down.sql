@@ -0,0 +1,24 @@
+BEGIN;
+
+DROP TABLE IF EXISTS order_tax_lines;
+
+ALTER TABLE order_items
+    DROP COLUMN IF EXISTS tax,
+    DROP COLUMN IF EXISTS tax_class;
+
+ALTER TABLE orders
+    DROP COLUMN IF EXISTS shipping_country,
+    DROP COLUMN IF EXISTS shipping_region,
+    DROP COLUMN IF EXISTS shipping_postal_code,
+    DROP COLUMN IF EXISTS shipping_city,
+    DROP COLUMN IF EXISTS shipping_line2,
+    DROP COLUMN IF EXISTS shipping_line1,
+    DROP COLUMN IF EXISTS shipping_name,
+    DROP COLUMN IF EXISTS prices_include_tax,
+    DROP COLUMN IF EXISTS tax_total,
+    DROP COLUMN IF EXISTS subtotal;
+
+ALTER TABLE categories
+    DROP COLUMN IF EXISTS tax_class;
+
+COMMIT;
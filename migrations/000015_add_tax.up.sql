@@ -0,0 +1,45 @@
+BEGIN;
+
+-- Books are taxed by the class of their category, for example "reduced" for printed books.
+ALTER TABLE categories
+    ADD COLUMN tax_class VARCHAR(32) NOT NULL DEFAULT 'standard';
+
+-- Orders placed so far were not taxed: their subtotal is their total.
+ALTER TABLE orders
+    ADD COLUMN subtotal             BIGINT,
+    ADD COLUMN tax_total            BIGINT  NOT NULL DEFAULT 0,
+    ADD COLUMN prices_include_tax   BOOLEAN NOT NULL DEFAULT FALSE,
+    ADD COLUMN shipping_name        VARCHAR(255),
+    ADD COLUMN shipping_line1       VARCHAR(255),
+    ADD COLUMN shipping_line2       VARCHAR(255),
+    ADD COLUMN shipping_city        VARCHAR(255),
+    ADD COLUMN shipping_postal_code VARCHAR(32),
+    ADD COLUMN shipping_region      VARCHAR(3),
+    ADD COLUMN shipping_country     CHAR(2);
+
+UPDATE orders SET subtotal = total;
+
+ALTER TABLE orders
+    ALTER COLUMN subtotal SET NOT NULL;
+
+ALTER TABLE order_items
+    ADD COLUMN tax_class VARCHAR(32) NOT NULL DEFAULT 'standard',
+    ADD COLUMN tax       BIGINT      NOT NULL DEFAULT 0;
+
+-- The tax of an order per class and rate, as printed on the invoice.
+CREATE TABLE order_tax_lines
+(
+    id        SERIAL PRIMARY KEY,
+    order_id  INTEGER     NOT NULL,
+    tax_class VARCHAR(32) NOT NULL,
+    -- rate is in hundredths of a percent.
+    rate      INTEGER     NOT NULL,
+    net       BIGINT      NOT NULL,
+    tax       BIGINT      NOT NULL,
+    currency  CHAR(3)     NOT NULL,
+    CONSTRAINT fk_order_tax_lines_order FOREIGN KEY (order_id) REFERENCES orders (id) ON DELETE CASCADE
+);
+
+CREATE INDEX idx_order_tax_lines_order_id ON order_tax_lines (order_id);
+
+COMMIT;
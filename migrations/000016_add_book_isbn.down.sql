@@ -0,0 +1,9 @@
+BEGIN;
+
+DROP INDEX IF EXISTS idx_books_title_author;
+DROP INDEX IF EXISTS idx_books_isbn;
+
+ALTER TABLE books
+    DROP COLUMN IF EXISTS isbn;
+
+COMMIT;
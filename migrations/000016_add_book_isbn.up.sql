@@ -0,0 +1,12 @@
+BEGIN;
+
+-- ISBNs are stored as the 13 digits of an ISBN-13.
+ALTER TABLE books
+    ADD COLUMN isbn VARCHAR(13);
+
+CREATE UNIQUE INDEX idx_books_isbn ON books (isbn) WHERE isbn IS NOT NULL;
+
+-- Catalogue imports match books without an ISBN by title and author.
+CREATE INDEX idx_books_title_author ON books (lower(title), lower(author));
+
+COMMIT;
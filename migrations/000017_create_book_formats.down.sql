@@ -0,0 +1,14 @@
+BEGIN;
+
+DROP TABLE IF EXISTS downloads;
+
+ALTER TABLE order_items
+    DROP COLUMN IF EXISTS format_id,
+    DROP COLUMN IF EXISTS format;
+
+ALTER TABLE cart_items
+    DROP COLUMN IF EXISTS format_id;
+
+DROP TABLE IF EXISTS book_formats;
+
+COMMIT;
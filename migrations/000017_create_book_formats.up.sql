@@ -0,0 +1,50 @@
+BEGIN;
+
+-- A book sells in the formats listed here, each with its own price, next to the book
+-- itself. Digital formats have no stock: they never run out, but are only sold once
+-- their file has been uploaded to the blob store under file_key.
+CREATE TABLE book_formats
+(
+    id           SERIAL PRIMARY KEY,
+    book_id      INTEGER     NOT NULL,
+    format       VARCHAR(16) NOT NULL CHECK (format IN ('hardcover', 'paperback', 'epub', 'pdf')),
+    price        BIGINT      NOT NULL CHECK (price >= 0),
+    currency     CHAR(3)     NOT NULL,
+    stock        INTEGER CHECK (stock >= 0),
+    file_key     VARCHAR(255),
+    file_name    VARCHAR(255),
+    file_type    VARCHAR(127),
+    file_size    BIGINT,
+    created_at   TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT NOW(),
+    updated_at   TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT NOW(),
+    CONSTRAINT uq_book_formats_book_format UNIQUE (book_id, format),
+    CONSTRAINT chk_book_formats_stock CHECK ((format IN ('epub', 'pdf')) = (stock IS NULL)),
+    CONSTRAINT fk_book_formats_book FOREIGN KEY (book_id) REFERENCES books (id) ON DELETE CASCADE
+);
+
+-- Cart items and order lines without a format are for the book itself.
+ALTER TABLE cart_items
+    ADD COLUMN format_id INTEGER,
+    ADD CONSTRAINT fk_cart_items_format FOREIGN KEY (format_id) REFERENCES book_formats (id) ON DELETE CASCADE;
+
+ALTER TABLE order_items
+    ADD COLUMN format    VARCHAR(16),
+    ADD COLUMN format_id INTEGER,
+    ADD CONSTRAINT fk_order_items_format FOREIGN KEY (format_id) REFERENCES book_formats (id) ON DELETE SET NULL;
+
+-- A download is the right to fetch the file of a digital order line a limited number of times.
+CREATE TABLE downloads
+(
+    id             SERIAL PRIMARY KEY,
+    order_item_id  INTEGER NOT NULL UNIQUE,
+    user_id        INTEGER NOT NULL,
+    download_count INTEGER NOT NULL DEFAULT 0,
+    last_download  TIMESTAMP WITH TIME ZONE,
+    created_at     TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT NOW(),
+    CONSTRAINT fk_downloads_order_item FOREIGN KEY (order_item_id) REFERENCES order_items (id) ON DELETE CASCADE,
+    CONSTRAINT fk_downloads_user FOREIGN KEY (user_id) REFERENCES users (id) ON DELETE CASCADE
+);
+
+CREATE INDEX idx_downloads_user_id ON downloads (user_id);
+
+COMMIT;
@@ -0,0 +1,8 @@
+BEGIN;
+
+ALTER TABLE books
+    DROP COLUMN IF EXISTS cover_updated_at,
+    DROP COLUMN IF EXISTS cover_type,
+    DROP COLUMN IF EXISTS cover_key;
+
+COMMIT;
@@ -0,0 +1,9 @@
+BEGIN;
+
+-- The renditions of a cover are stored in the blob store under cover_key.
+ALTER TABLE books
+    ADD COLUMN cover_key        TEXT,
+    ADD COLUMN cover_type       VARCHAR(100),
+    ADD COLUMN cover_updated_at TIMESTAMP WITH TIME ZONE;
+
+COMMIT;
@@ -0,0 +1,19 @@
+BEGIN;
+
+DROP INDEX IF EXISTS idx_books_series;
+DROP INDEX IF EXISTS idx_books_language;
+
+ALTER TABLE books
+    DROP COLUMN IF EXISTS weight_g,
+    DROP COLUMN IF EXISTS depth_mm,
+    DROP COLUMN IF EXISTS height_mm,
+    DROP COLUMN IF EXISTS width_mm,
+    DROP COLUMN IF EXISTS series_position,
+    DROP COLUMN IF EXISTS series,
+    DROP COLUMN IF EXISTS publication_date,
+    DROP COLUMN IF EXISTS publisher,
+    DROP COLUMN IF EXISTS language,
+    DROP COLUMN IF EXISTS page_count,
+    DROP COLUMN IF EXISTS description;
+
+COMMIT;
@@ -0,0 +1,21 @@
+BEGIN;
+
+-- Descriptions are sanitised Markdown, languages BCP 47 tags in canonical form.
+-- Dimensions are in millimetres and weights in grams.
+ALTER TABLE books
+    ADD COLUMN description      TEXT,
+    ADD COLUMN page_count       INT CHECK (page_count > 0),
+    ADD COLUMN language         VARCHAR(35),
+    ADD COLUMN publisher        VARCHAR(255),
+    ADD COLUMN publication_date DATE,
+    ADD COLUMN series           VARCHAR(255),
+    ADD COLUMN series_position  INT CHECK (series_position > 0),
+    ADD COLUMN width_mm         INT CHECK (width_mm > 0),
+    ADD COLUMN height_mm        INT CHECK (height_mm > 0),
+    ADD COLUMN depth_mm         INT CHECK (depth_mm > 0),
+    ADD COLUMN weight_g         INT CHECK (weight_g > 0);
+
+CREATE INDEX idx_books_language ON books (lower(language));
+CREATE INDEX idx_books_series ON books (lower(series), series_position);
+
+COMMIT;
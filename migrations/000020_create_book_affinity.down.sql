@@ -0,0 +1,7 @@
+BEGIN;
+
+DROP INDEX IF EXISTS idx_order_items_book_id;
+DROP TABLE IF EXISTS book_affinity_state;
+DROP TABLE IF EXISTS book_affinity;
+
+COMMIT;
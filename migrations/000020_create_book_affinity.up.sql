@@ -0,0 +1,29 @@
+BEGIN;
+
+-- book_affinity counts, for each pair of books, the customers who bought both. It is
+-- kept up to date by a background job that adds the orders placed since its last run.
+CREATE TABLE book_affinity
+(
+    book_id         INTEGER NOT NULL,
+    related_book_id INTEGER NOT NULL,
+    score           INTEGER NOT NULL CHECK (score > 0),
+    PRIMARY KEY (book_id, related_book_id),
+    CONSTRAINT fk_book_affinity_book FOREIGN KEY (book_id) REFERENCES books (id) ON DELETE CASCADE,
+    CONSTRAINT fk_book_affinity_related_book FOREIGN KEY (related_book_id) REFERENCES books (id) ON DELETE CASCADE
+);
+
+CREATE INDEX idx_book_affinity_score ON book_affinity (book_id, score DESC);
+
+-- The single row of book_affinity_state is the last order counted in book_affinity.
+CREATE TABLE book_affinity_state
+(
+    id            BOOLEAN PRIMARY KEY DEFAULT TRUE CHECK (id),
+    last_order_id INTEGER                  NOT NULL DEFAULT 0,
+    refreshed_at  TIMESTAMP WITH TIME ZONE
+);
+
+INSERT INTO book_affinity_state DEFAULT VALUES;
+
+CREATE INDEX idx_order_items_book_id ON order_items (book_id);
+
+COMMIT;
@@ -0,0 +1,13 @@
+BEGIN;
+
+DROP TABLE IF EXISTS book_charts_state;
+DROP TABLE IF EXISTS book_charts;
+DROP TABLE IF EXISTS book_cart_adds_daily;
+DROP TABLE IF EXISTS book_sales_daily;
+DROP TABLE IF EXISTS book_cart_adds;
+DROP INDEX IF EXISTS idx_books_created_at;
+
+ALTER TABLE books
+    DROP COLUMN IF EXISTS created_at;
+
+COMMIT;
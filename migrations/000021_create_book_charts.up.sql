@@ -0,0 +1,64 @@
+BEGIN;
+
+-- Books already in the catalogue count as added now.
+ALTER TABLE books
+    ADD COLUMN created_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT NOW();
+
+CREATE INDEX idx_books_created_at ON books (created_at DESC) WHERE deleted_at IS NULL;
+
+-- book_cart_adds records each time a book is put in a cart, until the chart job counts
+-- it in book_cart_adds_daily.
+CREATE TABLE book_cart_adds
+(
+    book_id  INTEGER                  NOT NULL,
+    added_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT NOW(),
+    CONSTRAINT fk_book_cart_adds_book FOREIGN KEY (book_id) REFERENCES books (id) ON DELETE CASCADE
+);
+
+-- Copies sold and cart adds of each book per UTC day, kept up to date by the chart job.
+CREATE TABLE book_sales_daily
+(
+    book_id INTEGER NOT NULL,
+    day     DATE    NOT NULL,
+    copies  INTEGER NOT NULL CHECK (copies > 0),
+    PRIMARY KEY (book_id, day),
+    CONSTRAINT fk_book_sales_daily_book FOREIGN KEY (book_id) REFERENCES books (id) ON DELETE CASCADE
+);
+
+CREATE INDEX idx_book_sales_daily_day ON book_sales_daily (day);
+
+CREATE TABLE book_cart_adds_daily
+(
+    book_id INTEGER NOT NULL,
+    day     DATE    NOT NULL,
+    adds    INTEGER NOT NULL CHECK (adds > 0),
+    PRIMARY KEY (book_id, day),
+    CONSTRAINT fk_book_cart_adds_daily_book FOREIGN KEY (book_id) REFERENCES books (id) ON DELETE CASCADE
+);
+
+CREATE INDEX idx_book_cart_adds_daily_day ON book_cart_adds_daily (day);
+
+-- book_charts ranks books on each chart, such as the bestsellers of the week or the
+-- trending books. The chart job rebuilds it from the daily counts.
+CREATE TABLE book_charts
+(
+    chart   VARCHAR(32)      NOT NULL,
+    book_id INTEGER          NOT NULL,
+    score   DOUBLE PRECISION NOT NULL,
+    PRIMARY KEY (chart, book_id),
+    CONSTRAINT fk_book_charts_book FOREIGN KEY (book_id) REFERENCES books (id) ON DELETE CASCADE
+);
+
+CREATE INDEX idx_book_charts_score ON book_charts (chart, score DESC);
+
+-- The single row of book_charts_state is the last order counted in book_sales_daily.
+CREATE TABLE book_charts_state
+(
+    id            BOOLEAN PRIMARY KEY DEFAULT TRUE CHECK (id),
+    last_order_id INTEGER                  NOT NULL DEFAULT 0,
+    refreshed_at  TIMESTAMP WITH TIME ZONE
+);
+
+INSERT INTO book_charts_state DEFAULT VALUES;
+
+COMMIT;
@@ -0,0 +1,9 @@
+BEGIN;
+
+DROP INDEX IF EXISTS idx_order_items_report;
+DROP INDEX IF EXISTS idx_orders_created_at;
+
+ALTER TABLE order_items
+    DROP COLUMN IF EXISTS category_id;
+
+COMMIT;
@@ -0,0 +1,20 @@
+BEGIN;
+
+-- Order lines keep the category their book was in when it was sold, so revenue stays
+-- with it when the book moves or is purged.
+ALTER TABLE order_items
+    ADD COLUMN category_id INTEGER;
+
+UPDATE order_items oi
+SET category_id = b.category_id
+FROM books b
+WHERE b.id = oi.book_id;
+
+ALTER TABLE order_items
+    ADD CONSTRAINT fk_order_items_category FOREIGN KEY (category_id) REFERENCES categories (id) ON DELETE SET NULL;
+
+-- Reports scan orders by date and read their lines from the index alone.
+CREATE INDEX idx_orders_created_at ON orders (created_at) INCLUDE (total, currency, prices_include_tax);
+CREATE INDEX idx_order_items_report ON order_items (order_id) INCLUDE (book_id, category_id, price, tax, currency, format);
+
+COMMIT;
@@ -0,0 +1,6 @@
+BEGIN;
+
+DROP TABLE IF EXISTS stock_alerts;
+ALTER TABLE books DROP COLUMN IF EXISTS reorder_threshold;
+
+COMMIT;
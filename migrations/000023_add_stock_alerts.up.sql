@@ -0,0 +1,19 @@
+BEGIN;
+
+-- reorder_threshold is the stock at or below which a book is reported as running low.
+-- NULL uses the default threshold from the configuration.
+ALTER TABLE books ADD COLUMN reorder_threshold INTEGER CHECK (reorder_threshold >= 0);
+
+-- stock_alerts holds the books that have been reported as low or out of stock, so the
+-- stock alert job reports each of them once. A row is removed when the book is restocked
+-- above its threshold, and the book is reported again the next time it runs low.
+CREATE TABLE stock_alerts
+(
+    book_id     INTEGER PRIMARY KEY,
+    level       VARCHAR(8)               NOT NULL CHECK (level IN ('low', 'out')),
+    stock       INTEGER                  NOT NULL,
+    notified_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT NOW(),
+    CONSTRAINT fk_stock_alerts_book FOREIGN KEY (book_id) REFERENCES books (id) ON DELETE CASCADE
+);
+
+COMMIT;
@@ -0,0 +1,9 @@
+BEGIN;
+
+DROP TABLE IF EXISTS stock_movements;
+DROP TABLE IF EXISTS purchase_order_items;
+DROP TABLE IF EXISTS purchase_orders;
+DROP TABLE IF EXISTS book_suppliers;
+DROP TABLE IF EXISTS suppliers;
+
+COMMIT;
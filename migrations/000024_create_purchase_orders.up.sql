@@ -0,0 +1,79 @@
+BEGIN;
+
+CREATE TABLE suppliers
+(
+    id         SERIAL PRIMARY KEY,
+    name       VARCHAR(255)             NOT NULL UNIQUE,
+    email      VARCHAR(255),
+    phone      VARCHAR(50),
+    created_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT NOW()
+);
+
+-- book_suppliers are the books a supplier can deliver, at the price the shop pays for them.
+CREATE TABLE book_suppliers
+(
+    book_id      INTEGER                  NOT NULL,
+    supplier_id  INTEGER                  NOT NULL,
+    cost_price   BIGINT                   NOT NULL CHECK (cost_price >= 0),
+    currency     CHAR(3)                  NOT NULL,
+    supplier_sku VARCHAR(64),
+    updated_at   TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT NOW(),
+    PRIMARY KEY (book_id, supplier_id),
+    CONSTRAINT fk_book_suppliers_book FOREIGN KEY (book_id) REFERENCES books (id) ON DELETE CASCADE,
+    CONSTRAINT fk_book_suppliers_supplier FOREIGN KEY (supplier_id) REFERENCES suppliers (id) ON DELETE CASCADE
+);
+
+CREATE INDEX idx_book_suppliers_supplier_id ON book_suppliers (supplier_id);
+
+-- Purchase orders are drafted, sent to the supplier, and received in one or more
+-- deliveries. Suppliers with purchase orders cannot be deleted.
+CREATE TABLE purchase_orders
+(
+    id          SERIAL PRIMARY KEY,
+    supplier_id INTEGER                  NOT NULL,
+    status      VARCHAR(20)              NOT NULL DEFAULT 'draft'
+        CHECK (status IN ('draft', 'sent', 'partially_received', 'received')),
+    currency    CHAR(3)                  NOT NULL,
+    created_at  TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT NOW(),
+    sent_at     TIMESTAMP WITH TIME ZONE,
+    received_at TIMESTAMP WITH TIME ZONE,
+    CONSTRAINT fk_purchase_orders_supplier FOREIGN KEY (supplier_id) REFERENCES suppliers (id) ON DELETE RESTRICT
+);
+
+CREATE INDEX idx_purchase_orders_supplier_id ON purchase_orders (supplier_id, id DESC);
+CREATE INDEX idx_purchase_orders_status ON purchase_orders (status, id DESC);
+
+-- Purchase order lines keep the title of their book, so they outlive the book being purged.
+CREATE TABLE purchase_order_items
+(
+    id                SERIAL PRIMARY KEY,
+    purchase_order_id INTEGER      NOT NULL,
+    book_id           INTEGER,
+    title             VARCHAR(255) NOT NULL,
+    quantity          INTEGER      NOT NULL CHECK (quantity > 0),
+    received          INTEGER      NOT NULL DEFAULT 0 CHECK (received >= 0 AND received <= quantity),
+    cost_price        BIGINT       NOT NULL CHECK (cost_price >= 0),
+    CONSTRAINT uq_purchase_order_items_book UNIQUE (purchase_order_id, book_id),
+    CONSTRAINT fk_purchase_order_items_order FOREIGN KEY (purchase_order_id) REFERENCES purchase_orders (id) ON DELETE CASCADE,
+    CONSTRAINT fk_purchase_order_items_book FOREIGN KEY (book_id) REFERENCES books (id) ON DELETE SET NULL
+);
+
+-- stock_movements records every change made to books.stock outside of sales, with a
+-- positive quantity for stock coming in. Movements are an audit trail: they keep the title
+-- of their book and outlive it being purged.
+CREATE TABLE stock_movements
+(
+    id                SERIAL PRIMARY KEY,
+    book_id           INTEGER,
+    title             VARCHAR(255)             NOT NULL,
+    quantity          INTEGER                  NOT NULL CHECK (quantity <> 0),
+    reason            VARCHAR(32)              NOT NULL,
+    purchase_order_id INTEGER,
+    created_at        TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT NOW(),
+    CONSTRAINT fk_stock_movements_book FOREIGN KEY (book_id) REFERENCES books (id) ON DELETE SET NULL,
+    CONSTRAINT fk_stock_movements_purchase_order FOREIGN KEY (purchase_order_id) REFERENCES purchase_orders (id) ON DELETE SET NULL
+);
+
+CREATE INDEX idx_stock_movements_book_id ON stock_movements (book_id, id DESC);
+
+COMMIT;
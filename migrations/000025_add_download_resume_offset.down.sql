@@ -0,0 +1,5 @@
+BEGIN;
+
+ALTER TABLE downloads DROP COLUMN IF EXISTS resume_offset;
+
+COMMIT;
@@ -0,0 +1,8 @@
+BEGIN;
+
+-- resume_offset is where the last transfer of a download stopped before the end of the
+-- file. Only a request continuing at exactly that byte is a resume, which is not counted
+-- against the download limit; it is cleared as soon as a resume or a new transfer starts.
+ALTER TABLE downloads ADD COLUMN resume_offset BIGINT CHECK (resume_offset > 0);
+
+COMMIT;
@@ -0,0 +1,10 @@
+BEGIN;
+
+DROP INDEX IF EXISTS idx_downloads_file_key;
+ALTER TABLE downloads
+    DROP COLUMN IF EXISTS file_size,
+    DROP COLUMN IF EXISTS file_type,
+    DROP COLUMN IF EXISTS file_name,
+    DROP COLUMN IF EXISTS file_key;
+
+COMMIT;
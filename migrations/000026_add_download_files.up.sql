@@ -0,0 +1,19 @@
+BEGIN;
+
+-- A download keeps the file that was sold, so it outlives the format being replaced,
+-- removed or purged with its book. The blob store keeps a file while a download uses it.
+ALTER TABLE downloads
+    ADD COLUMN file_key  VARCHAR(255),
+    ADD COLUMN file_name VARCHAR(255),
+    ADD COLUMN file_type VARCHAR(127),
+    ADD COLUMN file_size BIGINT;
+
+UPDATE downloads d
+SET file_key = f.file_key, file_name = f.file_name, file_type = f.file_type, file_size = f.file_size
+FROM order_items oi
+JOIN book_formats f ON f.id = oi.format_id
+WHERE oi.id = d.order_item_id;
+
+CREATE INDEX idx_downloads_file_key ON downloads (file_key);
+
+COMMIT;
@@ -0,0 +1,5 @@
+BEGIN;
+
+DROP TABLE IF EXISTS two_factor_challenges;
+
+COMMIT;
@@ -0,0 +1,19 @@
+BEGIN;
+
+-- One row per challenge token issued after the password step of a two-factor login.
+-- Each answer counts as an attempt, and the challenge is completed by the first right one,
+-- so a token can neither be guessed at indefinitely nor used twice.
+CREATE TABLE two_factor_challenges
+(
+    id           SERIAL PRIMARY KEY,
+    user_id      INTEGER                  NOT NULL,
+    attempts     INTEGER                  NOT NULL DEFAULT 0,
+    created_at   TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT NOW(),
+    expires_at   TIMESTAMP WITH TIME ZONE NOT NULL,
+    completed_at TIMESTAMP WITH TIME ZONE,
+    CONSTRAINT fk_two_factor_challenges_user FOREIGN KEY (user_id) REFERENCES users (id) ON DELETE CASCADE
+);
+
+CREATE INDEX idx_two_factor_challenges_user_id ON two_factor_challenges (user_id);
+
+COMMIT;
@@ -16,7 +16,9 @@ import (
 	"github.com/testcontainers/testcontainers-go"
 	"github.com/testcontainers/testcontainers-go/wait"
 	"toptal/internal/app/config"
+	"toptal/internal/app/domain"
 	"toptal/internal/app/repository"
+	"toptal/internal/app/service"
 	"toptal/internal/pkg/pg"
 )
 
@@ -82,6 +84,11 @@ func TestConcurrentPurchases(t *testing.T) {
 	start := time.Now()
 	t.Log("Starting concurrent purchases...")
 
+	taxService, err := service.NewTaxService(&config.TaxConfig{Rates: []string{"DE:standard=19"}})
+	if err != nil {
+		t.Fatalf("Failed to create tax service: %v", err)
+	}
+
 	wg.Add(n)
 	for i := 1; i <= n; i++ {
 		userID := i
@@ -95,7 +102,12 @@ func TestConcurrentPurchases(t *testing.T) {
 			defer tmpDb.Close()
 			repo := repository.NewCartRepository(tmpDb, cartCfg)
 			t.Logf("Starting purchasing userId: %d", id)
-			err = repo.Purchase(context.Background(), userID)
+			address, _ := domain.NewShippingAddress("Test User", "1 Test St", "", "Berlin", "10115", "", "DE")
+			_, err = repo.Purchase(context.Background(), userID, "", address,
+				func(items []domain.OrderItem) (domain.OrderTax, error) {
+					return taxService.TaxOrder(address, items)
+				},
+			)
 			if err != nil {
 				t.Logf("Purchase error userId: %d error: %v", id, err)
 			} else {